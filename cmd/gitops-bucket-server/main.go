@@ -7,13 +7,18 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/johannesboyne/gofakes3"
 	"github.com/johannesboyne/gofakes3/backend/s3mem"
 	"github.com/weaveworks/weave-gitops/pkg/http"
+	runlogger "github.com/weaveworks/weave-gitops/pkg/logger"
 	"github.com/weaveworks/weave-gitops/pkg/s3"
 )
 
+// How often the retention of the session logs is enforced.
+const logsRetentionInterval = time.Minute
+
 func main() {
 	logger := log.New(os.Stdout, "", 0)
 
@@ -45,7 +50,9 @@ func main() {
 		syscall.SIGTERM)
 	defer cancel()
 
-	s3Server := gofakes3.New(s3mem.New(),
+	backend := s3mem.New()
+
+	s3Server := gofakes3.New(backend,
 		gofakes3.WithAutoBucket(true),
 		gofakes3.WithLogger(
 			gofakes3.StdLog(
@@ -58,12 +65,15 @@ func main() {
 	var (
 		httpPort, httpsPort int
 		certFile, keyFile   string
+		logsRetention       s3.Retention
 	)
 
 	flag.IntVar(&httpPort, "http-port", 9000, "TCP port to listen on for HTTP connections")
 	flag.IntVar(&httpsPort, "https-port", 9443, "TCP port to listen on for HTTPS connections")
 	flag.StringVar(&certFile, "cert-file", "", "Path to the HTTPS server certificate file")
 	flag.StringVar(&keyFile, "key-file", "", "Path to the HTTPS server certificate key file")
+	flag.DurationVar(&logsRetention.MaxAge, "logs-max-age", 0, "How long the logs of GitOps Run sessions are kept, 0 to keep them all")
+	flag.IntVar(&logsRetention.MaxObjects, "logs-max-objects", 0, "How many log lines are kept for each GitOps Run session, 0 to keep them all")
 	flag.Parse()
 
 	if certFile == "" {
@@ -74,6 +84,8 @@ func main() {
 		logger.Fatalf("please specify the path to the HTTPS server certificate key file")
 	}
 
+	go logsRetention.Run(ctx, logger, backend, runlogger.S3LogBucketName, logsRetentionInterval)
+
 	srv := http.MultiServer{
 		HTTPPort:  httpPort,
 		HTTPSPort: httpsPort,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net"
@@ -27,16 +28,22 @@ import (
 	"github.com/weaveworks/weave-gitops/cmd/gitops/cmderrors"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/faults"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/healthscore"
 	"github.com/weaveworks/weave-gitops/core/logger"
 	"github.com/weaveworks/weave-gitops/core/nsaccess"
 	core "github.com/weaveworks/weave-gitops/core/server"
+	"github.com/weaveworks/weave-gitops/core/statushistory"
 	"github.com/weaveworks/weave-gitops/pkg/featureflags"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
 	"github.com/weaveworks/weave-gitops/pkg/server"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	"github.com/weaveworks/weave-gitops/pkg/server/middleware"
+	"github.com/weaveworks/weave-gitops/pkg/server/policy"
 	"github.com/weaveworks/weave-gitops/pkg/telemetry"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -46,6 +53,8 @@ import (
 const (
 	// Allowed login requests per second
 	loginRequestRateLimit = 20
+	// How often the authorization policy is reloaded
+	policyReloadInterval = 30 * time.Second
 )
 
 // Options contains all the options for the gitops-server command.
@@ -57,24 +66,94 @@ type Options struct {
 	Path                          string
 	Port                          string
 	AuthMethods                   []string
+	AuthTokenFile                 string
+	ImpersonationAdminGroup       string
+	ImpersonationGroups           []string
+	AuthzPolicyConfigMap          string
+	// Identity headers set by a trusted proxy
+	TrustedHeader           auth.TrustedHeaderConfig
+	TrustedHeaderSecretFile string
 	// TLS config
 	Insecure    bool
 	MTLS        bool
 	TLSCertFile string
 	TLSKeyFile  string
+	// Connection tuning
+	HTTP HTTPOptions
 	// Stuff for profiles apparently
 	HelmRepoName      string
 	HelmRepoNamespace string
 	// OIDC
 	OIDC       auth.OIDCConfig
 	OIDCSecret string
+	// How a failed OIDC login is reported
+	OIDCCallbackErrorResponse string
+	// WebAuthn second factor for the cluster user
+	WebAuthn auth.WebAuthnConfig
 	// Dev mode
 	DevMode bool
 	// Metrics
-	EnableMetrics  bool
-	MetricsAddress string
+	EnableMetrics           bool
+	MetricsAddress          string
+	SessionMetricsGroups    int
+	SessionMetricsHashGroup bool
 
 	UseK8sCachedClients bool
+	CachedKinds         []string
+
+	// ClusterDefinitions registers the clusters declared in the management
+	// cluster
+	ClusterDefinitions bool
+	// ClusterSecretsSelector registers the clusters whose kubeconfig is in
+	// a secret matching it
+	ClusterSecretsSelector  string
+	ClusterSecretsNamespace string
+	// ClusterRegistration allows adding and removing clusters through the
+	// API, as kubeconfig secrets matching ClusterSecretsSelector
+	ClusterRegistration bool
+	// CAPIClusters registers the clusters provisioned by Cluster API
+	CAPIClusters bool
+
+	// Status history
+	StatusHistoryInterval time.Duration
+	StatusHistoryCapacity int
+
+	// Health scores
+	HealthScoreInterval time.Duration
+	HealthScoreConfig   string
+
+	// FaultInjectionConfig is a file of faults injected into clusters, for
+	// testing only
+	FaultInjectionConfig string
+
+	// Clusters tunes how often clusters are refreshed and how long users'
+	// clients and namespaces are cached
+	Clusters clustersmngr.ClustersManagerOptions
+	// NamespacesInclude and NamespacesExclude are patterns of the namespaces
+	// tracked on each cluster
+	NamespacesInclude []string
+	NamespacesExclude []string
+	// ClusterClient tunes the clients to each cluster
+	ClusterClient cluster.ClientConfig
+	// ClusterCABundle is a file of CAs trusted by the clients to leaf
+	// clusters
+	ClusterCABundle string
+	// AllowInsecureClusters allows leaf clusters whose kubeconfig skips TLS
+	// verification
+	AllowInsecureClusters bool
+	// TrustedKubeconfigNamespace is the namespace whose kubeconfig secrets
+	// and cluster definitions may use the exec plugins and credential files
+	// of their kubeconfig
+	TrustedKubeconfigNamespace string
+	// CloudCredentials are the providers of cloud credentials the
+	// kubeconfig secrets and cluster definitions of each namespace may use
+	CloudCredentials []string
+	// ClusterTokenLifetime is how long the renewed service account tokens
+	// of leaf clusters are valid for, 0 if they aren't renewed
+	ClusterTokenLifetime time.Duration
+
+	// SelfTest validates the configuration and exits instead of serving
+	SelfTest bool
 }
 
 var options Options
@@ -98,12 +177,66 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().StringVar(&options.Path, "path", "", "Path url")
 	cmd.Flags().StringVar(&options.Port, "port", server.DefaultPort, "UI port")
 	cmd.Flags().StringSliceVar(&options.AuthMethods, "auth-methods", auth.DefaultAuthMethodStrings(), fmt.Sprintf("Which auth methods to use, valid values are %s", strings.Join(auth.DefaultAuthMethodStrings(), ",")))
+	cmd.Flags().StringVar(&options.AuthTokenFile, "auth-token-file", "", "File of bearer tokens accepted by the token-file auth method, either CSV in kube-apiserver's --token-auth-file format or YAML")
+	cmd.Flags().StringVar(&options.TrustedHeader.UserHeader, "trusted-header-user", auth.DefaultTrustedUserHeader, "Header holding the user name for the trusted-header auth method")
+	cmd.Flags().StringVar(&options.TrustedHeader.GroupsHeader, "trusted-header-groups", auth.DefaultTrustedGroupsHeader, "Header holding the user's comma-separated groups for the trusted-header auth method")
+	cmd.Flags().StringVar(&options.TrustedHeader.SecretHeader, "trusted-header-secret-header", auth.DefaultTrustedSecretHeader, "Header the proxy sends the shared secret in")
+	cmd.Flags().StringVar(&options.TrustedHeaderSecretFile, "trusted-header-secret-file", "", "File holding the secret shared with the proxy. The identity headers are only trusted on requests that carry it")
+	cmd.Flags().StringSliceVar(&options.TrustedHeader.TrustedCIDRs, "trusted-header-cidrs", nil, "Networks the proxy connects from. The identity headers are only trusted on requests from them")
+	cmd.Flags().StringVar(&options.AuthzPolicyConfigMap, "authz-policy-configmap", "", fmt.Sprintf("Name of a ConfigMap in the server's namespace holding an authorization policy under the %s key, which allows or denies API methods to groups of users on top of Kubernetes RBAC", policy.ConfigMapKey))
+	cmd.Flags().StringVar(&options.ImpersonationAdminGroup, "impersonation-admin-group", "", "Members of this group can act as any other user for troubleshooting. Every request made while impersonating is logged with both identities")
+	cmd.Flags().StringSliceVar(&options.ImpersonationGroups, "impersonation-groups", []string{}, "Groups that members of the impersonation admin group may act as along with the user. Groups starting with system: can never be impersonated")
 	cmd.Flags().BoolVar(&options.UseK8sCachedClients, "use-k8s-cached-clients", false, "Enables the use of cached clients")
+	cmd.Flags().StringSliceVar(&options.CachedKinds, "cached-kinds", cluster.DefaultCachedKinds, "Kinds read from informers shared by all the users instead of from the API server when cached clients are enabled, as <kind>.<group>. The service account, and the credentials of every leaf cluster, must be able to list and watch them in all namespaces")
+	cmd.Flags().BoolVar(&options.ClusterDefinitions, "cluster-definitions", false, "Register the leaf clusters declared by GitopsClusterDefinition objects, and write their status back")
+	cmd.Flags().StringVar(&options.ClusterSecretsSelector, "cluster-secrets-selector", "", "Register the leaf clusters whose kubeconfig is in a secret matching this label selector, e.g. weave.works/cluster=true. Clusters are named <namespace>/<name> after their secret. The service account must be able to list and watch secrets, see the rbac.viewKubeconfigSecrets value of the chart")
+	cmd.Flags().StringVar(&options.ClusterSecretsNamespace, "cluster-secrets-namespace", "", "Namespace to read kubeconfig secrets from, all namespaces if empty")
+	cmd.Flags().BoolVar(&options.ClusterRegistration, "cluster-registration", false, "Allow adding and removing leaf clusters through the API. Their kubeconfig secrets are created in --cluster-secrets-namespace, or the server's namespace, with the labels of --cluster-secrets-selector, which may only compare labels for equality. Users must be able to manage these secrets")
+	cmd.Flags().BoolVar(&options.CAPIClusters, "capi-clusters", false, "Register the leaf clusters provisioned by Cluster API once they're ready, using their <name>-kubeconfig secret")
+	cmd.Flags().DurationVar(&options.StatusHistoryInterval, "status-history-interval", 0, "How often to snapshot the status of Flux objects, so it can be looked up at a point in the past. 0 disables recording. The service account must be able to list Flux objects and manage ConfigMaps in the server's namespace")
+	cmd.Flags().IntVar(&options.StatusHistoryCapacity, "status-history-capacity", statushistory.DefaultCapacity, "Number of status changes kept for each object")
+	cmd.Flags().DurationVar(&options.HealthScoreInterval, "health-score-interval", 0, "How often to compute the health score of each cluster from the state of its Flux objects. 0 disables scoring. The service account must be able to list Flux objects")
+	cmd.Flags().StringVar(&options.HealthScoreConfig, "health-score-config", "", "YAML file overriding the weights of kinds and the penalties of severities in the health score model")
+	cmd.Flags().StringVar(&options.FaultInjectionConfig, "fault-injection-config", os.Getenv("WEAVE_GITOPS_FAULT_INJECTION_CONFIG"), "YAML file of latency, errors and namespace churn injected into clusters, to test the API and UI with a degraded fleet. Requires WEAVE_GITOPS_FEATURE_DEV_MODE=true, never use it in production")
+
+	clustersDefaults := clustersmngr.DefaultClustersManagerOptions()
+	cmd.Flags().DurationVar(&options.Clusters.ClustersResyncPeriod, "clusters-resync-period", clustersDefaults.ClustersResyncPeriod, "How often the list of clusters is fetched again. Sources that watch their clusters update it in between")
+	cmd.Flags().DurationVar(&options.Clusters.NamespacesResyncPeriod, "namespaces-resync-period", clustersDefaults.NamespacesResyncPeriod, "How often the namespaces of each cluster are listed again. Changes are watched in between")
+	cmd.Flags().DurationVar(&options.Clusters.ClusterStatusPeriod, "cluster-status-period", clustersDefaults.ClusterStatusPeriod, "How often each cluster is checked to be reachable")
+	cmd.Flags().DurationVar(&options.Clusters.UserNamespacesTTL, "user-namespaces-ttl", clustersDefaults.UserNamespacesTTL, "How long the namespaces a user can access are cached for. Lower values pick up RBAC changes sooner, at the cost of more access reviews")
+	cmd.Flags().DurationVar(&options.Clusters.UsersClientsTTL, "users-clients-ttl", clustersDefaults.UsersClientsTTL, "How long the clients of each user are cached for. Defaults to WEAVE_GITOPS_USERS_CLIENTS_TTL if set")
+	cmd.Flags().IntVar(&options.Clusters.BreakerThreshold, "cluster-breaker-threshold", clustersDefaults.BreakerThreshold, "Number of consecutive failures after which calls to a cluster are skipped")
+	cmd.Flags().DurationVar(&options.Clusters.BreakerMinBackoff, "cluster-breaker-min-backoff", clustersDefaults.BreakerMinBackoff, "How long calls to a failing cluster are first skipped for, doubled after every failed retry")
+	cmd.Flags().DurationVar(&options.Clusters.BreakerMaxBackoff, "cluster-breaker-max-backoff", clustersDefaults.BreakerMaxBackoff, "Longest time calls to a failing cluster are skipped for")
+	cmd.Flags().BoolVar(&options.Clusters.ShareNamespacesByGroups, "share-namespaces-by-groups", false, "Check the namespaces a user can access once for all the users with the same groups. Only enable it if permissions are granted to groups, not to individual users")
+	cmd.Flags().StringSliceVar(&options.NamespacesInclude, "namespaces-include", nil, "Only track the namespaces matching these glob patterns, e.g. team-*. Prefix a pattern with <cluster>= to only apply it to that cluster")
+	cmd.Flags().StringSliceVar(&options.NamespacesExclude, "namespaces-exclude", nil, "Never track the namespaces matching these glob patterns, e.g. kube-system. Prefix a pattern with <cluster>= to only apply it to that cluster")
+	cmd.Flags().IntVar(&options.Clusters.MaxConcurrency, "clusters-max-concurrency", clustersDefaults.MaxConcurrency, "Most calls to clusters a single request makes at once, e.g. to create a user's clients or list objects across clusters and namespaces")
+	cmd.Flags().StringToStringVar(&options.Clusters.ClusterAliases, "cluster-aliases", nil, "Other names clusters can be picked by, e.g. management=Default. A cluster's own name takes precedence over an alias")
+	cmd.Flags().Float32Var(&options.ClusterClient.QPS, "cluster-client-qps", 0, fmt.Sprintf("Requests per second allowed to each cluster. 0 relies on the cluster's API Priority and Fairness if enabled, and allows %d otherwise", cluster.ClientQPS))
+	cmd.Flags().IntVar(&options.ClusterClient.Burst, "cluster-client-burst", 0, fmt.Sprintf("Burst of requests allowed to each cluster. 0 relies on the cluster's API Priority and Fairness if enabled, and allows %d otherwise", cluster.ClientBurst))
+	cmd.Flags().DurationVar(&options.ClusterClient.Timeout, "cluster-client-timeout", 0, "Timeout of requests to each cluster. 0 uses WEAVE_GITOPS_KUBE_CLIENT_TIMEOUT, or 30s")
+	cmd.Flags().StringVar(&options.ClusterClient.UserAgent, "cluster-client-user-agent", "", "User agent of requests to each cluster, to tell them apart in audit logs")
+	cmd.Flags().StringVar(&options.ClusterCABundle, "cluster-ca-bundle", "", "PEM file of CAs trusted on top of the CA of each leaf cluster, for API servers using a private CA. Leaf clusters whose kubeconfig sets no CA only trust these")
+	cmd.Flags().BoolVar(&options.AllowInsecureClusters, "allow-insecure-clusters", false, "Allow leaf clusters whose kubeconfig skips TLS verification. Their credentials, and the users impersonated on them, can then be intercepted; never use it in production")
+	cmd.Flags().StringVar(&options.TrustedKubeconfigNamespace, "trusted-kubeconfig-namespace", "", "Namespace, only writable by operators, whose kubeconfig secrets and cluster definitions may use the exec plugins, auth providers and credential files of their kubeconfig with the kubeconfig credentials. They're removed in every other namespace, as they run commands or read files on the server")
+	cmd.Flags().StringSliceVar(&options.CloudCredentials, "cloud-credentials", nil, "Allow the kubeconfig secrets and cluster definitions of a namespace, or a single one as <namespace>/<name>, to authenticate with the server's cloud identity, as <provider>=<namespace>[/<name>], e.g. eks=fleet. Whoever can write them picks the server the tokens are sent to, which must be an endpoint of a managed cluster")
+	cmd.Flags().DurationVar(&options.ClusterTokenLifetime, "cluster-token-lifetime", 0, "Renew the expiring service account tokens of leaf clusters with tokens valid this long, before they expire. 0 disables renewal. Service accounts must be allowed to create tokens for themselves. Renewed tokens are written back to the kubeconfig secrets of --cluster-secrets-selector and cluster definitions, those of Cluster API clusters are only kept in memory")
+	cmd.Flags().BoolVar(&options.SelfTest, "self-test", false, "Validate the configuration, print a JSON report and exit non-zero if any check fails")
 	//  TLS
 	cmd.Flags().BoolVar(&options.Insecure, "insecure", false, "do not attempt to read TLS certificates")
 	cmd.Flags().BoolVar(&options.MTLS, "mtls", false, "disable enforce mTLS")
 	cmd.Flags().StringVar(&options.TLSCertFile, "tls-cert-file", "", "filename for the TLS certificate, in-memory generated if omitted")
 	cmd.Flags().StringVar(&options.TLSKeyFile, "tls-private-key-file", "", "filename for the TLS key, in-memory generated if omitted")
+	// Connection tuning
+	cmd.Flags().DurationVar(&options.HTTP.ReadTimeout, "http-read-timeout", 0, "Maximum duration for reading an entire request, including the body. 0 means no timeout")
+	cmd.Flags().DurationVar(&options.HTTP.ReadHeaderTimeout, "http-read-header-timeout", 0, "Maximum duration for reading request headers. 0 means the read timeout is used")
+	cmd.Flags().DurationVar(&options.HTTP.WriteTimeout, "http-write-timeout", 0, "Maximum duration for writing a response. 0 means no timeout, which streaming endpoints rely on. Keep it over 30s for followed session logs")
+	cmd.Flags().DurationVar(&options.HTTP.IdleTimeout, "http-idle-timeout", 0, "How long to keep an idle connection open. Set this below the idle timeout of any load balancer in front of the server. 0 means the read timeout is used")
+	cmd.Flags().BoolVar(&options.HTTP.DisableKeepAlives, "http-disable-keepalives", false, "Close connections after each request instead of reusing them")
+	cmd.Flags().DurationVar(&options.HTTP.TCPKeepAlive, "tcp-keepalive-period", 15*time.Second, "Period between TCP keep-alive probes on client connections. A negative value disables them")
+	cmd.Flags().BoolVar(&options.HTTP.HTTP2, "http2", false, "Explicitly configure HTTP/2, and serve HTTP/2 over cleartext when --insecure is set")
+	cmd.Flags().Uint32Var(&options.HTTP.MaxConcurrentStreams, "http2-max-concurrent-streams", 0, "Maximum number of concurrent HTTP/2 streams per connection. 0 uses the default of 250")
 	// OIDC
 	cmd.Flags().StringVar(&options.OIDCSecret, "oidc-secret-name", auth.DefaultOIDCAuthSecretName, "Name of the secret that contains OIDC configuration")
 	cmd.Flags().StringVar(&options.OIDC.ClientID, "oidc-client-id", "", "The client ID for the OpenID Connect client")
@@ -113,14 +246,30 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().DurationVar(&options.OIDC.TokenDuration, "oidc-token-duration", time.Hour, "The duration of the ID token. It should be set in the format: number + time unit (s,m,h) e.g., 20m")
 	cmd.Flags().StringVar(&options.OIDC.ClaimsConfig.Username, "oidc-username-claim", auth.ClaimUsername, "JWT claim to use as the user name. By default email, which is expected to be a unique identifier of the end user. Admins can choose other claims, such as sub or name, depending on their provider")
 	cmd.Flags().StringVar(&options.OIDC.ClaimsConfig.Groups, "oidc-groups-claim", auth.ClaimGroups, "JWT claim to use as the user's group. If the claim is present it must be an array of strings")
+	cmd.Flags().StringVar(&options.OIDC.ClaimsConfig.DisplayName, "oidc-display-name-claim", "", "JWT claim to show as the user's name in the UI, e.g. name. It is not used for impersonation. By default the user name claim is shown")
+	cmd.Flags().StringSliceVar(&options.OIDC.Passthrough.Audiences, "oidc-passthrough-audiences", nil, "Audiences accepted in tokens passed through to Kubernetes when OIDC passthrough is enabled. By default the client ID")
+	cmd.Flags().StringToStringVar(&options.OIDC.Passthrough.RequiredClaims, "oidc-passthrough-required-claims", nil, "Claims, and their values, that tokens passed through to Kubernetes must have, e.g. hd=example.com")
+	cmd.Flags().DurationVar(&options.OIDC.Passthrough.ClockSkew, "oidc-passthrough-clock-skew", 0, "How long after expiry a token passed through to Kubernetes is still accepted")
+	cmd.Flags().StringVar(&options.OIDCCallbackErrorResponse, "oidc-callback-error-response", auth.CallbackErrorHTML, fmt.Sprintf("How a failed OIDC login is reported, one of %s, %s or %s (send the user back to the sign in page)", auth.CallbackErrorHTML, auth.CallbackErrorJSON, auth.CallbackErrorRedirect))
+	// WebAuthn
+	cmd.Flags().StringVar(&options.WebAuthn.RPID, "webauthn-rp-id", "", "The domain the dashboard is served from. If set, the cluster user can register a WebAuthn authenticator as a second factor")
+	cmd.Flags().StringVar(&options.WebAuthn.RPOrigin, "webauthn-rp-origin", "", "The origin of the dashboard as seen by the browser, e.g. https://gitops.example.com. Defaults to the WebAuthn RP ID")
 	// Metrics
 	cmd.Flags().BoolVar(&options.EnableMetrics, "enable-metrics", false, "Starts the metrics listener")
 	cmd.Flags().StringVar(&options.MetricsAddress, "metrics-address", ":2112", "If the metrics listener is enabled, bind to this address")
+	cmd.Flags().IntVar(&options.SessionMetricsGroups, "session-metrics-group-limit", auth.DefaultSessionGroupLimit, "Number of top-level groups reported in the active session metrics, the rest are reported as \"other\"")
+	cmd.Flags().BoolVar(&options.SessionMetricsHashGroup, "session-metrics-hash-groups", false, "Report groups in the active session metrics by a hash of their name")
 
 	return cmd
 }
 
 func runCmd(cmd *cobra.Command, args []string) error {
+	// The self-test report is the only thing written to stdout so it can be
+	// parsed, hence this runs before the logger is set up.
+	if options.SelfTest {
+		return runSelfTest(cmd.Context(), cmd.OutOrStdout(), options)
+	}
+
 	log, err := logger.New(options.LogLevel, options.Insecure)
 	if err != nil {
 		return err
@@ -155,7 +304,7 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not create scheme: %w", err)
 	}
 
-	rawClient, err := client.New(rest, client.Options{
+	rawClient, err := client.NewWithWatch(rest, client.Options{
 		Scheme: scheme,
 	})
 	if err != nil {
@@ -177,6 +326,48 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not initialise authentication server: %w", err)
 	}
 
+	if err := authServer.SetCallbackErrorResponse(options.OIDCCallbackErrorResponse); err != nil {
+		return err
+	}
+
+	authServer.SetSessionGroupMetrics(options.SessionMetricsGroups, options.SessionMetricsHashGroup)
+
+	if options.ImpersonationAdminGroup != "" {
+		authServer.EnableImpersonation(options.ImpersonationAdminGroup, options.ImpersonationGroups)
+	}
+
+	if options.AuthTokenFile != "" {
+		tokens, err := auth.LoadTokenFile(options.AuthTokenFile)
+		if err != nil {
+			return err
+		}
+
+		if err := authServer.SetStaticTokens(tokens); err != nil {
+			return err
+		}
+	}
+
+	if options.TrustedHeaderSecretFile != "" || len(options.TrustedHeader.TrustedCIDRs) > 0 {
+		if options.TrustedHeaderSecretFile != "" {
+			secret, err := os.ReadFile(options.TrustedHeaderSecretFile)
+			if err != nil {
+				return fmt.Errorf("could not read trusted header secret: %w", err)
+			}
+
+			options.TrustedHeader.Secret = strings.TrimSpace(string(secret))
+		}
+
+		if err := authServer.EnableTrustedHeaders(options.TrustedHeader); err != nil {
+			return err
+		}
+	}
+
+	if options.WebAuthn.RPID != "" {
+		if err := authServer.EnableWebAuthn(options.WebAuthn); err != nil {
+			return err
+		}
+	}
+
 	log.Info("Registering auth routes")
 
 	if err := auth.RegisterAuthServer(mux, "/oauth2", authServer, loginRequestRateLimit); err != nil {
@@ -185,7 +376,36 @@ func runCmd(cmd *cobra.Command, args []string) error {
 
 	ctx := context.Background()
 
-	cl, err := cluster.NewSingleCluster(cluster.DefaultCluster, rest, scheme, cluster.DefaultKubeConfigOptions...)
+	kubeConfigOptions := append([]cluster.KubeConfigOption{}, cluster.DefaultKubeConfigOptions...)
+	kubeConfigOptions = append(kubeConfigOptions, cluster.WithClientConfig(options.ClusterClient))
+
+	// the TLS settings of leaf clusters apply before they're connected to
+	leafKubeConfigOptions := []cluster.KubeConfigOption{}
+
+	if !options.AllowInsecureClusters {
+		leafKubeConfigOptions = append(leafKubeConfigOptions, cluster.RejectInsecure)
+	}
+
+	if options.ClusterCABundle != "" {
+		caData, err := os.ReadFile(options.ClusterCABundle)
+		if err != nil {
+			return fmt.Errorf("could not read cluster CA bundle: %w", err)
+		}
+
+		leafKubeConfigOptions = append(leafKubeConfigOptions, cluster.WithCABundle(caData))
+	}
+
+	var tokenRotator *cluster.TokenRotator
+
+	if options.ClusterTokenLifetime > 0 {
+		tokenRotator = cluster.NewTokenRotator(log, options.ClusterTokenLifetime)
+
+		leafKubeConfigOptions = append(leafKubeConfigOptions, cluster.WithTokenRotation(tokenRotator))
+	}
+
+	leafKubeConfigOptions = append(leafKubeConfigOptions, kubeConfigOptions...)
+
+	cl, err := cluster.NewSingleCluster(cluster.DefaultCluster, rest, scheme, kubeConfigOptions...)
 	if err != nil {
 		return fmt.Errorf("failed to create cluster client; %w", err)
 	}
@@ -199,30 +419,163 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if options.UseK8sCachedClients {
-		cl = cluster.NewDelegatingCacheCluster(cl, rest, scheme)
+	fetchers := []clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(cl)}
+
+	credentialsPolicy := fetcher.CredentialsPolicy{TrustedNamespace: options.TrustedKubeconfigNamespace}
+
+	if credentialsPolicy.CloudCredentials, err = fetcher.ParseCloudCredentials(options.CloudCredentials); err != nil {
+		return err
+	}
+
+	if options.ClusterDefinitions {
+		fetchers = append(fetchers, fetcher.NewClusterDefinitionsFetcher(log, rawClient, "", scheme, credentialsPolicy, leafKubeConfigOptions...))
+	}
+
+	if options.ClusterSecretsSelector != "" {
+		selector, err := labels.Parse(options.ClusterSecretsSelector)
+		if err != nil {
+			return fmt.Errorf("invalid cluster secrets selector: %w", err)
+		}
+
+		fetchers = append(fetchers, fetcher.NewKubeconfigSecretsFetcher(log, rawClient, options.ClusterSecretsNamespace, selector, scheme, credentialsPolicy, leafKubeConfigOptions...))
+	}
+
+	if options.CAPIClusters {
+		fetchers = append(fetchers, fetcher.NewCAPIClustersFetcher(log, rawClient, "", scheme, leafKubeConfigOptions...))
+	}
+
+	if tokenRotator != nil {
+		tokenRotator.SetStore(fetcher.NewTokenStore(rawClient, fetchers))
+		tokenRotator.Start(ctx)
+	}
+
+	if options.FaultInjectionConfig != "" {
+		if featureflags.Get("WEAVE_GITOPS_FEATURE_DEV_MODE") != "true" {
+			return errors.New("fault injection requires WEAVE_GITOPS_FEATURE_DEV_MODE=true")
+		}
+
+		data, err := os.ReadFile(options.FaultInjectionConfig)
+		if err != nil {
+			return fmt.Errorf("could not read fault injection config: %w", err)
+		}
+
+		faultsConfig, err := faults.Parse(data)
+		if err != nil {
+			return err
+		}
+
+		log.V(logger.LogLevelWarn).Info("Injecting faults into clusters. This should be used for testing only.")
+
+		for i, f := range fetchers {
+			fetchers[i] = faults.NewFetcher(f, faultsConfig)
+		}
 	}
 
-	fetcher := fetcher.NewSingleClusterFetcher(cl)
+	namespaceFilters, err := clustersmngr.ParseNamespaceFilters(options.NamespacesInclude, options.NamespacesExclude)
+	if err != nil {
+		return err
+	}
+
+	managerOptions := []clustersmngr.ClustersManagerOption{
+		clustersmngr.WithOptions(options.Clusters),
+		clustersmngr.WithNamespaceFilters(namespaceFilters),
+	}
+
+	// every cluster, the management cluster and the leaf ones, is read from
+	// the cache
+	if options.UseK8sCachedClients {
+		cachedKinds := make([]schema.GroupKind, 0, len(options.CachedKinds))
+		for _, kind := range options.CachedKinds {
+			cachedKinds = append(cachedKinds, schema.ParseGroupKind(kind))
+		}
+
+		managerOptions = append(managerOptions, clustersmngr.WithCachedKinds(scheme, cachedKinds))
+	}
 
-	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher}, nsaccess.NewChecker(nsaccess.DefautltWegoAppRules), log)
+	clustersManager := clustersmngr.NewClustersManager(fetchers, nsaccess.NewChecker(nsaccess.DefautltWegoAppRules), log, managerOptions...)
 	clustersManager.Start(ctx)
 
+	authServer.EnableTokenExchange(func(name string) bool {
+		_, err := clustersManager.GetCluster(name)
+		return err == nil
+	})
+
+	mux.Handle("/healthz", healthHandler(log, clustersManager, func(h clustersmngr.Health) bool { return h.Live }))
+	mux.Handle("/readyz", healthHandler(log, clustersManager, func(h clustersmngr.Health) bool { return h.Ready }))
+
 	coreConfig, err := core.NewCoreConfig(log, rest, clusterName, clustersManager)
 	if err != nil {
 		return fmt.Errorf("could not create core config: %w", err)
 	}
 
+	if options.ClusterRegistration {
+		if options.ClusterSecretsSelector == "" {
+			return errors.New("cluster registration requires --cluster-secrets-selector")
+		}
+
+		secretLabels, err := labels.ConvertSelectorToLabelsMap(options.ClusterSecretsSelector)
+		if err != nil {
+			return fmt.Errorf("cluster registration requires an equality cluster secrets selector: %w", err)
+		}
+
+		registrationNamespace := options.ClusterSecretsNamespace
+		if registrationNamespace == "" {
+			registrationNamespace = namespace
+		}
+
+		coreConfig.ClusterRegistration = &core.ClusterRegistration{
+			Namespace: registrationNamespace,
+			Labels:    secretLabels,
+		}
+	}
+
+	if options.StatusHistoryInterval > 0 {
+		coreConfig.StatusHistory = statushistory.NewStore(rawClient, namespace, options.StatusHistoryCapacity)
+		statushistory.NewRecorder(log, clustersManager, coreConfig.StatusHistory, options.StatusHistoryInterval).Start(ctx)
+	}
+
+	if options.HealthScoreInterval > 0 {
+		model := healthscore.DefaultModel()
+
+		if options.HealthScoreConfig != "" {
+			data, err := os.ReadFile(options.HealthScoreConfig)
+			if err != nil {
+				return fmt.Errorf("could not read health score config: %w", err)
+			}
+
+			if model, err = healthscore.ParseModel(data); err != nil {
+				return err
+			}
+		}
+
+		coreConfig.HealthScores = healthscore.NewScorer(log, clustersManager, model, options.HealthScoreInterval)
+		coreConfig.HealthScores.Start(ctx)
+	}
+
 	appConfig, err := server.DefaultApplicationsConfig(log)
 	if err != nil {
 		return fmt.Errorf("could not create http client: %w", err)
 	}
 
+	var policyEnforcer *policy.Enforcer
+
+	if options.AuthzPolicyConfigMap != "" {
+		policyEnforcer = server.NewPolicyEnforcer(log)
+		key := client.ObjectKey{Namespace: namespace, Name: options.AuthzPolicyConfigMap}
+
+		if err := policyEnforcer.LoadConfigMap(ctx, rawClient, key); err != nil {
+			return err
+		}
+
+		policyEnforcer.WatchConfigMap(ctx, rawClient, key, policyReloadInterval)
+	}
+
 	appAndProfilesHandlers, err := server.NewHandlers(ctx, log,
 		&server.Config{
 			AppConfig:        appConfig,
 			CoreServerConfig: coreConfig,
 			AuthServer:       authServer,
+			Policy:           policyEnforcer,
 		},
 	)
 	if err != nil {
@@ -230,6 +583,7 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	mux.Handle("/v1/", gziphandler.GzipHandler(appAndProfilesHandlers))
+	mux.Handle(server.KubeProxyPath, server.NewKubeProxy(log, authServer, clustersManager))
 
 	mux.Handle("/", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		// Assume anything with a file extension in the name is a static asset.
@@ -256,15 +610,16 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	handler = middleware.WithLogging(log, handler)
 
 	addr := net.JoinHostPort(options.Host, options.Port)
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: handler,
+
+	srv, err := newHTTPServer(log, addr, handler, options.HTTP, options.Insecure)
+	if err != nil {
+		return err
 	}
 
 	go func() {
 		log.Info("Starting server", "address", addr)
 
-		if err := listenAndServe(log, srv, options); err != nil {
+		if err := listenAndServe(ctx, log, srv, options); err != nil {
 			log.Error(err, "server exited")
 			os.Exit(1)
 		}
@@ -278,6 +633,8 @@ func runCmd(cmd *cobra.Command, args []string) error {
 			prometheus.DefaultGatherer,
 			k8sMetrics.Registry,
 			clustersmngr.Registry,
+			auth.Registry,
+			healthscore.Registry,
 		}
 		metricsMux.Handle("/metrics", promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}))
 
@@ -310,6 +667,10 @@ func runCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 
+	if err := clustersManager.Stop(ctx); err != nil {
+		return fmt.Errorf("clusters manager shutdown failed: %w", err)
+	}
+
 	if options.EnableMetrics {
 		if err := metricsServer.Shutdown(ctx); err != nil {
 			return fmt.Errorf("metrics server shutdown failed: %w", err)
@@ -319,14 +680,19 @@ func runCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func listenAndServe(log logr.Logger, srv *http.Server, options Options) error {
-	if options.Insecure {
-		log.Info("TLS connections disabled")
-		return srv.ListenAndServe()
+func listenAndServe(ctx context.Context, log logr.Logger, srv *http.Server, options Options) error {
+	if !options.Insecure && (options.TLSCertFile == "" || options.TLSKeyFile == "") {
+		return cmderrors.ErrNoTLSCertOrKey
 	}
 
-	if options.TLSCertFile == "" || options.TLSKeyFile == "" {
-		return cmderrors.ErrNoTLSCertOrKey
+	ln, err := listen(ctx, srv, options.HTTP)
+	if err != nil {
+		return err
+	}
+
+	if options.Insecure {
+		log.Info("TLS connections disabled")
+		return srv.Serve(ln)
 	}
 
 	if options.MTLS {
@@ -338,17 +704,20 @@ func listenAndServe(log logr.Logger, srv *http.Server, options Options) error {
 		caCertPool := x509.NewCertPool()
 		caCertPool.AppendCertsFromPEM(caCert)
 
-		srv.TLSConfig = &tls.Config{
-			ClientCAs:  caCertPool,
-			ClientAuth: tls.RequireAndVerifyClientCert,
+		// Keep any config set up for HTTP/2
+		if srv.TLSConfig == nil {
+			srv.TLSConfig = &tls.Config{}
 		}
+
+		srv.TLSConfig.ClientCAs = caCertPool
+		srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	} else {
 		log.Info("Using TLS", "cert_file", options.TLSCertFile, "key_file", options.TLSKeyFile)
 	}
 
-	// if tlsCert and tlsKey are both empty (""), ListenAndServeTLS will ignore
+	// if tlsCert and tlsKey are both empty (""), ServeTLS will ignore
 	// and happily use the TLSConfig supplied above
-	return srv.ListenAndServeTLS(options.TLSCertFile, options.TLSKeyFile)
+	return srv.ServeTLS(ln, options.TLSCertFile, options.TLSKeyFile)
 }
 
 func getAssets() fs.FS {
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+)
+
+// healthHandler writes the health of the clusters manager as JSON, with a
+// 503 status unless ok holds for it, for Kubernetes probes and monitoring.
+func healthHandler(log logr.Logger, clustersManager clustersmngr.ClustersManager, ok func(clustersmngr.Health) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := clustersManager.GetHealth()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if !ok(health) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if err := json.NewEncoder(w).Encode(health); err != nil {
+			log.Error(err, "error writing health check")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// HTTPOptions tunes the public server's connection handling. The zero value
+// matches the net/http defaults, so nothing changes unless a flag is set.
+//
+// Long-lived streams, like watches and log tails, are usually dropped by a
+// load balancer or ingress that thinks the connection is idle. These let the
+// server time out before the proxy does, or keep the connection busy.
+type HTTPOptions struct {
+	// ReadTimeout is the maximum time to read a whole request, including the body
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout is the maximum time to read the request headers
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout is the maximum time to write a response. This must be
+	// left unset if streaming endpoints are used.
+	WriteTimeout time.Duration
+	// IdleTimeout is how long to keep an idle keep-alive connection open
+	IdleTimeout time.Duration
+	// DisableKeepAlives closes connections after each request
+	DisableKeepAlives bool
+	// TCPKeepAlive is the period between TCP keep-alive probes, a negative
+	// value disables them
+	TCPKeepAlive time.Duration
+	// HTTP2 enables explicit HTTP/2 configuration, and HTTP/2 over
+	// cleartext (h2c) when TLS is disabled
+	HTTP2 bool
+	// MaxConcurrentStreams is the number of concurrent HTTP/2 streams per
+	// connection, 0 uses the http2 package default
+	MaxConcurrentStreams uint32
+}
+
+// newHTTPServer creates the public server with the connection tuning from
+// the options applied.
+func newHTTPServer(log logr.Logger, addr string, handler http.Handler, opts HTTPOptions, insecure bool) (*http.Server, error) {
+	if !opts.HTTP2 && opts.MaxConcurrentStreams != 0 {
+		return nil, fmt.Errorf("--http2-max-concurrent-streams requires --http2")
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+	}
+
+	srv.SetKeepAlivesEnabled(!opts.DisableKeepAlives)
+
+	if !opts.HTTP2 {
+		return srv, nil
+	}
+
+	h2 := &http2.Server{
+		MaxConcurrentStreams: opts.MaxConcurrentStreams,
+		IdleTimeout:          opts.IdleTimeout,
+	}
+
+	if insecure {
+		log.Info("Enabling HTTP/2 over cleartext")
+		srv.Handler = h2c.NewHandler(handler, h2)
+
+		return srv, nil
+	}
+
+	if err := http2.ConfigureServer(srv, h2); err != nil {
+		return nil, fmt.Errorf("could not configure HTTP/2: %w", err)
+	}
+
+	return srv, nil
+}
+
+// listen opens the server's listener with the TCP keep-alive period from
+// the options.
+func listen(ctx context.Context, srv *http.Server, opts HTTPOptions) (net.Listener, error) {
+	lc := net.ListenConfig{KeepAlive: opts.TCPKeepAlive}
+
+	return lc.Listen(ctx, "tcp", srv.Addr)
+}
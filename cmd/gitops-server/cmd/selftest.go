@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/cmderrors"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const selfTestTimeout = 30 * time.Second
+
+// ErrSelfTestFailed is returned when at least one of the self-test checks
+// did not pass.
+var ErrSelfTestFailed = errors.New("self-test failed")
+
+// SelfTestCheck is the result of a single self-test check.
+type SelfTestCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// SelfTestReport is written to stdout when the server is started with
+// --self-test.
+type SelfTestReport struct {
+	Passed bool            `json:"passed"`
+	Checks []SelfTestCheck `json:"checks"`
+}
+
+func (r *SelfTestReport) add(name string, err error) {
+	check := SelfTestCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Message = err.Error()
+	}
+
+	r.Checks = append(r.Checks, check)
+}
+
+func (r *SelfTestReport) skip(name, reason string) {
+	r.Checks = append(r.Checks, SelfTestCheck{Name: name, Passed: true, Skipped: true, Message: reason})
+}
+
+// runSelfTest validates the server configuration without starting the
+// server. It is meant to run as an init container or in CI to catch bad Helm
+// values before a broken server is rolled out.
+func runSelfTest(ctx context.Context, out io.Writer, options Options) error {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	report := &SelfTestReport{}
+
+	report.add("tls-files", checkTLSFiles(options))
+
+	rawClient, namespace, err := checkManagementCluster(ctx)
+	report.add("management-cluster", err)
+
+	authMethods, err := auth.ParseAuthMethodArray(options.AuthMethods)
+	report.add("auth-methods", err)
+
+	_, err = clustersmngr.ParseNamespaceFilters(options.NamespacesInclude, options.NamespacesExclude)
+	report.add("namespace-filters", err)
+
+	_, err = fetcher.ParseCloudCredentials(options.CloudCredentials)
+	report.add("cloud-credentials", err)
+
+	switch {
+	case rawClient == nil:
+		report.skip("cluster-user-secret", "management cluster is not accessible")
+		report.skip("oidc-issuer", "management cluster is not accessible")
+	default:
+		if authMethods[auth.UserAccount] {
+			report.add("cluster-user-secret", checkSecret(ctx, rawClient, namespace, auth.ClusterUserAuthSecretName))
+		} else {
+			report.skip("cluster-user-secret", "user-account auth is not enabled")
+		}
+
+		if authMethods[auth.OIDC] {
+			report.add("oidc-issuer", checkOIDCIssuer(ctx, rawClient, namespace, options))
+		} else {
+			report.skip("oidc-issuer", "oidc auth is not enabled")
+		}
+	}
+
+	report.Passed = true
+
+	for _, c := range report.Checks {
+		if !c.Passed {
+			report.Passed = false
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write self-test report: %w", err)
+	}
+
+	if !report.Passed {
+		return ErrSelfTestFailed
+	}
+
+	return nil
+}
+
+func checkTLSFiles(options Options) error {
+	if options.Insecure {
+		return nil
+	}
+
+	if options.TLSCertFile == "" || options.TLSKeyFile == "" {
+		return cmderrors.ErrNoTLSCertOrKey
+	}
+
+	if _, err := tls.LoadX509KeyPair(options.TLSCertFile, options.TLSKeyFile); err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+
+	return nil
+}
+
+func checkManagementCluster(ctx context.Context) (client.Client, string, error) {
+	rest, err := config.GetConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create client config: %w", err)
+	}
+
+	dc, err := discovery.NewDiscoveryClientForConfig(rest)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create discovery client: %w", err)
+	}
+
+	if _, err := dc.ServerVersion(); err != nil {
+		return nil, "", fmt.Errorf("management cluster is not reachable: %w", err)
+	}
+
+	scheme, err := kube.CreateScheme()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create scheme: %w", err)
+	}
+
+	rawClient, err := client.New(rest, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create kube http client: %w", err)
+	}
+
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, "", fmt.Errorf("couldn't get current namespace: %w", err)
+	}
+
+	return rawClient, namespace, nil
+}
+
+func checkSecret(ctx context.Context, cl client.Client, namespace, name string) error {
+	var secret corev1.Secret
+
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &secret); err != nil {
+		return fmt.Errorf("could not get secret %s/%s: %w", namespace, name, err)
+	}
+
+	return nil
+}
+
+func checkOIDCIssuer(ctx context.Context, cl client.Client, namespace string, options Options) error {
+	oidcConfig := options.OIDC
+
+	var secret corev1.Secret
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: options.OIDCSecret}, &secret); err == nil {
+		oidcConfig = auth.NewOIDCConfigFromSecret(secret)
+	}
+
+	if oidcConfig.IssuerURL == "" {
+		return fmt.Errorf("no issuer URL configured in flags or secret %s/%s", namespace, options.OIDCSecret)
+	}
+
+	if oidcConfig.ClientID == "" || oidcConfig.ClientSecret == "" {
+		return fmt.Errorf("OIDC client ID and client secret must both be set")
+	}
+
+	if _, err := oidc.NewProvider(ctx, oidcConfig.IssuerURL); err != nil {
+		return fmt.Errorf("issuer %s is not reachable: %w", oidcConfig.IssuerURL, err)
+	}
+
+	return nil
+}
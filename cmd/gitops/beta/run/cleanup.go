@@ -0,0 +1,128 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/weave-gitops/pkg/run"
+	"github.com/weaveworks/weave-gitops/pkg/run/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type CleanupCommandFlags struct {
+	RunID         string
+	All           bool
+	AllNamespaces bool
+	Force         bool
+	Timeout       time.Duration
+}
+
+var cleanupFlags CleanupCommandFlags
+
+func cleanupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Remove the resources left behind by GitOps Run",
+		Long:  "This removes the Kustomizations, HelmReleases and sources GitOps Run left behind in the cluster, e.g. when it was killed before cleaning up. They're found by their run-id annotation, which is the name of the session that created them. Either the session is picked with --run-id, or the resources of every session are removed with --all, including the ones of the sessions still running.",
+		Example: `
+# Remove the resources left behind by every session in the flux-system namespace
+gitops beta run cleanup --all
+
+# Remove the resources left behind by a session in all the namespaces, even when the Flux controllers can't finalize them
+gitops beta run cleanup --run-id run-main-1a2b3c4 --all-namespaces --force`,
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		RunE:              cleanupCommandRunE,
+		DisableAutoGenTag: true,
+	}
+
+	cmdFlags := cmd.Flags()
+
+	cmdFlags.StringVar(&cleanupFlags.RunID, "run-id", "", "Only remove the resources left behind by the session with this name.")
+	cmdFlags.BoolVar(&cleanupFlags.All, "all", false, "Remove the resources of every session, including the ones still running. This asks for confirmation when run in a terminal.")
+	cmdFlags.BoolVarP(&cleanupFlags.AllNamespaces, "all-namespaces", "A", false, "Remove the resources left behind in all the namespaces.")
+	cmdFlags.BoolVar(&cleanupFlags.Force, "force", false, "Remove the finalizers of the resources that aren't deleted within the timeout, e.g. when the Flux controllers are gone.")
+	cmdFlags.DurationVar(&cleanupFlags.Timeout, "timeout", time.Minute, "How long to wait for each resource to be deleted.")
+
+	kubeConfigArgs.AddFlags(cmdFlags)
+
+	return cmd
+}
+
+func cleanupCommandRunE(cmd *cobra.Command, args []string) error {
+	if cleanupFlags.RunID == "" && !cleanupFlags.All {
+		return errors.New("either --run-id or --all is required, as the resources of the sessions still running are removed too otherwise")
+	}
+
+	log := newOutputLogger()
+
+	kubeClient, _, err := getKubeClient(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	namespace := flags.Namespace
+	if cleanupFlags.AllNamespaces {
+		namespace = ""
+	}
+
+	ctx := context.Background()
+
+	leftovers, err := watch.FindLeftovers(ctx, kubeClient, namespace, cleanupFlags.RunID)
+	if err != nil {
+		return err
+	}
+
+	if len(leftovers) == 0 {
+		log.Successf("No GitOps Run resources are left behind")
+		return nil
+	}
+
+	if cleanupFlags.RunID == "" && isTerminal() {
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Would you like to remove the resources of the sessions %s, including the ones still running", strings.Join(runIDs(leftovers), ", ")),
+			IsConfirm: true,
+		}
+
+		// Answering "n" causes err to not be nil
+		if _, err := prompt.Run(); err != nil {
+			return errors.New("cleanup cancelled")
+		}
+	}
+
+	return watch.DeleteObjects(ctx, log, kubeClient, leftovers, watch.CleanupOptions{
+		Wait: run.WaitOptions{
+			Interval:    time.Second,
+			MaxInterval: 5 * time.Second,
+			Timeout:     cleanupFlags.Timeout,
+		},
+		Force: cleanupFlags.Force,
+	})
+}
+
+// runIDs returns the sessions the objects were created by.
+func runIDs(objects []client.Object) []string {
+	seen := map[string]bool{}
+	ids := []string{}
+
+	for _, obj := range objects {
+		id := obj.GetAnnotations()[watch.RunIDAnnotation]
+		if id == "" || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,40 @@
+package run
+
+import (
+	"reflect"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/weave-gitops/pkg/run/watch"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCleanupRequiresRunIDOrAll(t *testing.T) {
+	cleanupFlags = CleanupCommandFlags{}
+	defer func() { cleanupFlags = CleanupCommandFlags{} }()
+
+	if err := cleanupCommandRunE(&cobra.Command{}, nil); err == nil {
+		t.Fatal("expected an error without --run-id or --all")
+	}
+}
+
+func TestRunIDs(t *testing.T) {
+	annotated := func(id string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Annotations: map[string]string{watch.RunIDAnnotation: id}}
+	}
+
+	objects := []client.Object{
+		&kustomizev1.Kustomization{ObjectMeta: annotated("run-b")},
+		&sourcev1.Bucket{ObjectMeta: annotated("run-b")},
+		&corev1.Secret{},
+		&kustomizev1.Kustomization{ObjectMeta: annotated("run-a")},
+	}
+
+	if got, want := runIDs(objects), []string{"run-a", "run-b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("runIDs() = %v, want %v", got, want)
+	}
+}
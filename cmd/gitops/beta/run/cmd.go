@@ -1,17 +1,20 @@
 package run
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/url"
 	"os"
 	"os/signal"
 	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync/atomic"
+	"sync"
 	"syscall"
 	"time"
 
@@ -22,6 +25,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fsnotify/fsnotify"
 	"github.com/manifoldco/promptui"
+	"github.com/minio/minio-go/v7"
 	"github.com/spf13/cobra"
 	"github.com/weaveworks/weave-gitops/cmd/gitops/cmderrors"
 	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
@@ -32,12 +36,14 @@ import (
 	"github.com/weaveworks/weave-gitops/pkg/run"
 	"github.com/weaveworks/weave-gitops/pkg/run/bootstrap"
 	"github.com/weaveworks/weave-gitops/pkg/run/install"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
 	"github.com/weaveworks/weave-gitops/pkg/run/watch"
 	"github.com/weaveworks/weave-gitops/pkg/s3"
 	"github.com/weaveworks/weave-gitops/pkg/validate"
 	"github.com/weaveworks/weave-gitops/pkg/version"
+	"golang.org/x/term"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/yaml"
@@ -59,6 +65,27 @@ type RunCommandFlags struct {
 	Timeout         time.Duration
 	PortForward     string // port forward specifier, e.g. "port=8080:8080,resource=svc/app"
 	RootDir         string
+	Paths           []string
+	PathOrder       string
+	ValuesFiles     []string
+	Source          string
+	TargetNamespace string
+	Excludes        []string
+	FollowSymlinks  bool
+	SyncConcurrency int
+	Debounce        time.Duration
+	Substitute      map[string]string
+	SubstituteFrom  []string
+	DependsOn       []string
+	HealthChecks    []string
+	Diff            bool
+	Notify          bool
+	NotifyWebhook   string
+
+	// Reconciliation
+	ReconcileInterval    time.Duration
+	ReconcileMaxInterval time.Duration
+	ReconcileJitter      float64
 
 	// Dashboard
 	DashboardPort           string
@@ -70,7 +97,23 @@ type RunCommandFlags struct {
 	SessionNamespace    string
 	NoSession           bool
 	SkipResourceCleanup bool
+	ForceCleanup        bool
 	NoBootstrap         bool
+	SessionRequests     map[string]string
+	SessionLimits       map[string]string
+	SessionNodeSelector map[string]string
+	SessionTolerations  []string
+
+	// Output
+	NoTTY bool
+
+	// Logs
+	LogsMaxAge         time.Duration
+	LogsMaxObjects     int
+	LogsLokiURL        string
+	LogsFile           string
+	LogsFileMaxSize    int64
+	LogsFileMaxBackups int
 
 	// Global flags.
 	Namespace  string
@@ -103,6 +146,45 @@ gitops beta run ./deploy/overlays/dev
 # Listen on port 8080 on localhost, forwarding to 5000 in a pod of the service app.
 gitops beta run ./dev --port-forward port=8080:5000,resource=svc/app
 
+# Run the sync on the dev directory and forward both the http and metrics ports of the service app.
+gitops beta run ./dev --port-forward 'port=8080:http;9797:metrics,resource=svc/app'
+
+# Run the sync on the infrastructure and apps directories, applying apps once infrastructure is ready.
+gitops beta run ./infrastructure --path ./apps --path-order sequential
+
+# Run the sync on the podinfo Helm chart with the values of the dev environment.
+gitops beta run ./chart/podinfo --values ./chart/podinfo/values-dev.yaml
+
+# Run the sync on the dev directory, pushing it as an OCI artifact instead of to the dev bucket.
+gitops beta run ./dev --source oci
+
+# Run the sync on the dev directory, applying the objects without a namespace in the dev namespace.
+gitops beta run ./dev --target-namespace dev
+
+# Run the sync on the dev directory, without syncing the test files.
+gitops beta run ./dev --exclude '*_test.yaml'
+
+# Run the sync on the dev directory, substituting the variables like in production.
+gitops beta run ./dev --substitute cluster_env=dev --substitute-from secret/cluster-vars
+
+# Run the sync on the dev directory in a CI pipeline, reading the progress as JSON lines.
+gitops beta run ./dev --no-tty | jq -r 'select(.kind == "failure") | .message'
+
+# Run the sync on the dev directory, showing what each save changes in the cluster before syncing it.
+gitops beta run ./dev --diff
+
+# Run the sync on the dev directory, showing a desktop notification and posting to a chat webhook when the changes are reconciled or fail.
+gitops beta run ./dev --notify --notify-webhook https://chat.example.com/hooks/dev
+
+# Pause the sync of a running GitOps Run while refactoring the files, and resume it the same way. Entering p pauses or resumes it too.
+kill -USR2 $(pgrep -f 'gitops beta run')
+
+# Run the sync on the apps directory once the infrastructure Kustomization is ready, waiting for podinfo only.
+gitops beta run ./apps --depends-on infrastructure --health-check Deployment/podinfo.apps
+
+# Run the session on the dev nodes of a shared cluster, using at most 2 CPUs and 4Gi of memory.
+gitops beta run ./dev --session-node-selector pool=dev --session-tolerations dedicated=dev:NoSchedule --session-limits cpu=2,memory=4Gi
+
 # Run the sync on the dev directory with a specified root dir.
 gitops beta run ./clusters/default/dev --root-dir ./clusters/default
 
@@ -129,16 +211,47 @@ gitops beta run ./chart/podinfo --timeout 3m --port-forward namespace=flux-syste
 	cmdFlags.StringSliceVar(&flags.Components, "components", []string{"source-controller", "kustomize-controller", "helm-controller", "notification-controller"}, "The Flux components to install.")
 	cmdFlags.StringSliceVar(&flags.ComponentsExtra, "components-extra", []string{}, "Additional Flux components to install, allowed values are image-reflector-controller,image-automation-controller.")
 	cmdFlags.DurationVar(&flags.Timeout, "timeout", 5*time.Minute, "The timeout for operations during GitOps Run.")
-	cmdFlags.StringVar(&flags.PortForward, "port-forward", "", "Forward the port from a cluster's resource to your local machine i.e. 'port=8080:8080,resource=svc/app'.")
+	cmdFlags.DurationVar(&flags.ReconcileInterval, "reconcile-interval", 3*time.Second/2, "How often the state of the reconciliations is first checked. It's also checked whenever the objects change.")
+	cmdFlags.DurationVar(&flags.ReconcileMaxInterval, "reconcile-max-interval", 10*time.Second, "How often the state of the reconciliations is checked at most, as the interval is doubled after every check.")
+	cmdFlags.Float64Var(&flags.ReconcileJitter, "reconcile-jitter", 0.1, "The checks of the reconciliations are randomly delayed by up to this fraction of the interval, e.g. 0.1 for 10%.")
+	cmdFlags.StringVar(&flags.PortForward, "port-forward", "", "Forward the port from a cluster's resource to your local machine i.e. 'port=8080:8080,resource=svc/app'. The port may be a single number to use the same port locally, and the container port may be a port name. Several ports are separated by semicolons, i.e. 'port=8080:8080;9090:9090,resource=svc/app'. The ports of the services applied with the annotation run.weave.works/port-forward, i.e. '8080:http', are forwarded too.")
 	cmdFlags.StringVar(&flags.DashboardPort, "dashboard-port", "9001", "GitOps Dashboard port")
 	cmdFlags.BoolVar(&flags.SkipDashboardInstall, "skip-dashboard-install", false, "Skip installation of the Dashboard. This also disables the prompt asking whether the Dashboard should be installed.")
 	cmdFlags.StringVar(&flags.DashboardHashedPassword, "dashboard-hashed-password", "", "GitOps Dashboard password in BCrypt hash format")
 	cmdFlags.StringVar(&flags.RootDir, "root-dir", "", "Specify the root directory to watch for changes. If not specified, the root of Git repository will be used.")
+	cmdFlags.StringSliceVar(&flags.Paths, "path", []string{}, "Additional paths to sync, with a Kustomization each. May be repeated or comma-separated.")
+	cmdFlags.StringSliceVar(&flags.ValuesFiles, "values", []string{}, "Values files of the Helm chart, merged in order over its values.yaml. They must be under the root directory to be watched. May be repeated or comma-separated.")
+	cmdFlags.StringVar(&flags.PathOrder, "path-order", string(watch.PathOrderParallel), "How the paths are applied, either 'parallel' or 'sequential' so each path waits for the one before it to be ready.")
+	cmdFlags.StringVar(&flags.Source, "source", string(watch.SourceModeBucket), "How the files are synced to the cluster, either 'bucket' to put them in the dev bucket, or 'oci' to push them as an OCI artifact to a dev registry. The session logs are only kept in the dev bucket.")
+	cmdFlags.StringVar(&flags.TargetNamespace, "target-namespace", "", "The namespace the objects without a namespace are applied in, instead of the namespace of the Kustomizations. It's created if it's missing, and left in place when GitOps Run stops.")
+	cmdFlags.StringSliceVar(&flags.Excludes, "exclude", []string{}, "Patterns of files not to sync, in the .gitignore format, along with the ones listed by the .gitignore and .sourceignore of the root directory. May be repeated or comma-separated.")
+	cmdFlags.BoolVar(&flags.FollowSymlinks, "follow-symlinks", false, "Sync and watch the files and dirs the symlinks under the root directory point to, e.g. the manifests shared by several apps. The symlinks making cycles are skipped.")
+	cmdFlags.IntVar(&flags.SyncConcurrency, "sync-concurrency", 8, "How many files are uploaded to the dev bucket at once.")
+	cmdFlags.DurationVar(&flags.Debounce, "debounce", time.Second, "How long to wait without file changes before syncing them, so the changes made in quick succession are synced at once.")
+	cmdFlags.StringToStringVar(&flags.Substitute, "substitute", map[string]string{}, "Post-build substitution variables of the Kustomizations, e.g. 'cluster_env=dev'. May be repeated or comma-separated.")
+	cmdFlags.StringSliceVar(&flags.SubstituteFrom, "substitute-from", []string{}, "ConfigMaps or Secrets in the namespace of the Kustomizations holding post-build substitution variables, e.g. 'secret/cluster-vars' or 'configmap/cluster-vars'. May be repeated or comma-separated.")
+	cmdFlags.StringSliceVar(&flags.DependsOn, "depends-on", []string{}, "Kustomizations the Kustomizations of the paths depend on, e.g. the one applying the CRDs, either '<name>' in the namespace of the run or '<namespace>/<name>'. May be repeated or comma-separated.")
+	cmdFlags.StringSliceVar(&flags.HealthChecks, "health-check", []string{}, "Objects whose health is checked once the last path is applied, instead of all its objects, e.g. 'Deployment/podinfo.apps' or 'cert-manager.io/v1/Certificate/app.apps'. May be repeated or comma-separated.")
+	cmdFlags.BoolVar(&flags.Diff, "diff", false, "Show what the file changes would change in the cluster before syncing them, by a server-side dry-run of the objects of the Kustomizations.")
+	cmdFlags.BoolVar(&flags.Notify, "notify", false, "Show a desktop notification when the Kustomizations or the HelmRelease become healthy or fail, with notify-send on Linux or osascript on macOS.")
+	cmdFlags.StringVar(&flags.NotifyWebhook, "notify-webhook", "", "Post a notification as JSON to this URL when the Kustomizations or the HelmRelease become healthy or fail, e.g. a chat webhook.")
 	cmdFlags.StringVar(&flags.SessionName, "session-name", getSessionNameFromGit(), "Specify the name of the session. If not specified, the name of the current branch and the last commit id will be used.")
 	cmdFlags.StringVar(&flags.SessionNamespace, "session-namespace", "default", "Specify the namespace of the session.")
 	cmdFlags.BoolVar(&flags.NoSession, "no-session", false, "Disable session management. If not specified, the session will be enabled by default.")
 	cmdFlags.BoolVar(&flags.NoBootstrap, "no-bootstrap", false, "Disable bootstrapping at shutdown.")
+	cmdFlags.StringToStringVar(&flags.SessionRequests, "session-requests", map[string]string{}, "Resources requested by the vcluster of the session, e.g. 'cpu=500m,memory=1Gi'.")
+	cmdFlags.StringToStringVar(&flags.SessionLimits, "session-limits", map[string]string{}, "Resource limits of the vcluster of the session, e.g. 'cpu=2,memory=4Gi'.")
+	cmdFlags.StringToStringVar(&flags.SessionNodeSelector, "session-node-selector", map[string]string{}, "Labels of the nodes the vcluster of the session may run on, e.g. 'pool=dev'.")
+	cmdFlags.StringSliceVar(&flags.SessionTolerations, "session-tolerations", []string{}, "Taints the vcluster of the session tolerates, as '<key>[=<value>][:<effect>]', e.g. 'dedicated=dev:NoSchedule'. May be repeated or comma-separated.")
+	cmdFlags.BoolVar(&flags.NoTTY, "no-tty", false, "Don't prompt, and log the progress as JSON lines, e.g. in CI pipelines. Enabled when stdin or stdout isn't a terminal.")
 	cmdFlags.BoolVar(&flags.SkipResourceCleanup, "skip-resource-cleanup", false, "Skip resource cleanup. If not specified, the GitOps Run resources will be deleted by default.")
+	cmdFlags.BoolVar(&flags.ForceCleanup, "force-cleanup", false, "Remove the finalizers of the GitOps Run resources that aren't deleted within the timeout, e.g. when the Flux controllers are gone, so they're not left behind.")
+	cmdFlags.DurationVar(&flags.LogsMaxAge, "logs-max-age", 24*time.Hour, "How long the session logs are kept in the dev bucket, 0 to keep them all.")
+	cmdFlags.IntVar(&flags.LogsMaxObjects, "logs-max-objects", 10000, "How many lines of the session logs are kept in the dev bucket, 0 to keep them all.")
+	cmdFlags.StringVar(&flags.LogsLokiURL, "logs-loki-url", "", "Also push the session logs to the Loki at this URL, e.g. 'http://loki:3100'. Credentials may be set in the URL.")
+	cmdFlags.StringVar(&flags.LogsFile, "logs-file", "", "Also append the session logs to this local file, as JSON lines.")
+	cmdFlags.Int64Var(&flags.LogsFileMaxSize, "logs-file-max-size", 10, "The size in MiB the logs file is rotated at, 0 to never rotate it.")
+	cmdFlags.IntVar(&flags.LogsFileMaxBackups, "logs-file-max-backups", 3, "How many rotated logs files are kept.")
 
 	cmdFlags.StringVar(&flags.HiddenSessionName, "x-session-name", "", "The session name acknowledged by the sub-process. This is a hidden flag and should not be used.")
 	_ = cmdFlags.MarkHidden("x-session-name")
@@ -147,6 +260,8 @@ gitops beta run ./chart/podinfo --timeout 3m --port-forward namespace=flux-syste
 
 	kubeConfigArgs.AddFlags(cmd.Flags())
 
+	cmd.AddCommand(cleanupCommand())
+
 	return cmd
 }
 
@@ -182,7 +297,7 @@ func betaRunCommandPreRunE(endpoint *string) func(*cobra.Command, []string) erro
 	return func(cmd *cobra.Command, args []string) error {
 		numArgs := len(args)
 
-		if numArgs == 0 {
+		if numArgs == 0 && len(flags.Paths) == 0 {
 			return cmderrors.ErrNoFilePath
 		}
 
@@ -190,6 +305,66 @@ func betaRunCommandPreRunE(endpoint *string) func(*cobra.Command, []string) erro
 			return cmderrors.ErrMultipleFilePaths
 		}
 
+		switch watch.PathOrder(flags.PathOrder) {
+		case watch.PathOrderParallel, watch.PathOrderSequential:
+		default:
+			return fmt.Errorf("invalid path order %q, must be either %q or %q", flags.PathOrder, watch.PathOrderParallel, watch.PathOrderSequential)
+		}
+
+		switch watch.SourceMode(flags.Source) {
+		case watch.SourceModeBucket, watch.SourceModeOCI:
+		default:
+			return fmt.Errorf("invalid source %q, must be either %q or %q", flags.Source, watch.SourceModeBucket, watch.SourceModeOCI)
+		}
+
+		if flags.TargetNamespace != "" {
+			if errs := validation.IsDNS1123Label(flags.TargetNamespace); len(errs) > 0 {
+				return fmt.Errorf("invalid target namespace %q: %s", flags.TargetNamespace, strings.Join(errs, ", "))
+			}
+		}
+
+		if flags.PortForward != "" {
+			if _, err := watch.ParsePortForwardSpec(flags.PortForward); err != nil {
+				return err
+			}
+		}
+
+		for _, ref := range flags.SubstituteFrom {
+			if _, err := watch.ParseSubstituteFrom(ref); err != nil {
+				return err
+			}
+		}
+
+		for _, ref := range flags.DependsOn {
+			if _, err := watch.ParseDependsOn(ref); err != nil {
+				return err
+			}
+		}
+
+		for _, ref := range flags.HealthChecks {
+			if _, err := watch.ParseHealthCheck(ref); err != nil {
+				return err
+			}
+		}
+
+		if _, err := sessionScheduling(); err != nil {
+			return err
+		}
+
+		if flags.ReconcileInterval <= 0 {
+			return fmt.Errorf("invalid reconcile interval %v, must be positive", flags.ReconcileInterval)
+		}
+
+		if flags.ReconcileJitter < 0 {
+			return fmt.Errorf("invalid reconcile jitter %v, must not be negative", flags.ReconcileJitter)
+		}
+
+		if flags.NotifyWebhook != "" {
+			if u, err := url.ParseRequestURI(flags.NotifyWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("invalid notification webhook %q, must be an http or https URL", flags.NotifyWebhook)
+			}
+		}
+
 		return nil
 	}
 }
@@ -197,7 +372,7 @@ func betaRunCommandPreRunE(endpoint *string) func(*cobra.Command, []string) erro
 func getKubeClient(cmd *cobra.Command, args []string) (*kube.KubeHTTP, *rest.Config, error) {
 	var err error
 
-	log := logger.NewCLILogger(os.Stdout)
+	log := newOutputLogger()
 
 	if flags.Namespace, err = cmd.Flags().GetString("namespace"); err != nil {
 		return nil, nil, err
@@ -252,7 +427,7 @@ func getKubeClient(cmd *cobra.Command, args []string) (*kube.KubeHTTP, *rest.Con
 	return kubeClient, cfg, nil
 }
 
-func fluxStep(log logger.Logger, kubeClient *kube.KubeHTTP) (fluxVersion string, justInstalled bool, err error) {
+func fluxStep(log logger.Logger, kubeClient *kube.KubeHTTP, required []install.FluxComponent) (fluxVersion string, justInstalled bool, err error) {
 	ctx := context.Background()
 
 	log.Actionf("Checking if Flux is already installed ...")
@@ -260,60 +435,135 @@ func fluxStep(log logger.Logger, kubeClient *kube.KubeHTTP) (fluxVersion string,
 	if fluxVersion, err = install.GetFluxVersion(log, ctx, kubeClient); err != nil {
 		log.Warningf("Flux is not found: %v", err.Error())
 
-		product := fluxinstall.NewProduct(flags.FluxVersion)
+		if err := installFlux(ctx, log, flags.FluxVersion, flags.Namespace, flags.Components, flags.ComponentsExtra); err != nil {
+			return "", false, err
+		}
 
-		installer := fluxinstall.NewInstaller()
+		fluxVersion = flags.FluxVersion
 
-		execPath, err := installer.Ensure(ctx, product)
-		if err != nil {
-			execPath, err = installer.Install(ctx, product)
-			if err != nil {
-				return "", false, err
-			}
+		return fluxVersion, true, nil
+	} else {
+		log.Successf("Flux version %s is found", fluxVersion)
+	}
+
+	log.Actionf("Checking for the Flux components GitOps Run needs ...")
+
+	missing, fluxNamespace, err := install.MissingFluxComponents(ctx, kubeClient, required)
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(missing) == 0 {
+		return fluxVersion, false, nil
+	}
+
+	missingList := strings.Join(missing, ", ")
+
+	log.Warningf("Flux components %s are not found", missingList)
+
+	if !flags.NoTTY {
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Would you like to install the Flux components %s of version %s", missingList, fluxVersion),
+			IsConfirm: true,
+			Default:   "Y",
 		}
 
-		wd, err := os.Getwd()
-		if err != nil {
-			return "", false, err
+		// Answering "n" causes err to not be nil
+		if _, err := prompt.Run(); err != nil {
+			return "", false, fmt.Errorf("the Flux components %s are required by GitOps Run", missingList)
 		}
+	}
+
+	log.Actionf("Installing Flux components %s ...", missingList)
+
+	// the components are pinned to the installed version of Flux, and
+	// installed next to the other components, so they work with them
+	if fluxNamespace == "" {
+		fluxNamespace = flags.Namespace
+	}
+
+	if err := installFlux(ctx, log, strings.TrimPrefix(fluxVersion, "v"), fluxNamespace, missing, nil); err != nil {
+		return "", false, err
+	}
+
+	log.Successf("Installed Flux components %s", missingList)
+
+	return fluxVersion, false, nil
+}
 
-		flux, err := fluxexec.NewFlux(wd, execPath)
+// installFlux installs the Flux components of the version into the namespace
+// with the Flux CLI of that version, which is downloaded if needed.
+func installFlux(ctx context.Context, log logger.Logger, version, namespace string, componentNames, componentExtraNames []string) error {
+	product := fluxinstall.NewProduct(version)
+
+	installer := fluxinstall.NewInstaller()
+
+	execPath, err := installer.Ensure(ctx, product)
+	if err != nil {
+		execPath, err = installer.Install(ctx, product)
 		if err != nil {
-			return "", false, err
+			return err
 		}
+	}
 
-		// This means that Flux logs will be printed to the console, but not be sent to S3
-		flux.SetLogger(log.L())
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
 
-		var components []fluxexec.Component
-		for _, component := range flags.Components {
-			components = append(components, fluxexec.Component(component))
-		}
+	flux, err := fluxexec.NewFlux(wd, execPath)
+	if err != nil {
+		return err
+	}
 
-		var componentsExtra []fluxexec.ComponentExtra
-		for _, component := range flags.ComponentsExtra {
-			componentsExtra = append(componentsExtra, fluxexec.ComponentExtra(component))
-		}
+	// This means that Flux logs will be printed to the console, but not be sent to S3
+	flux.SetLogger(log.L())
 
-		if err := flux.Install(ctx,
-			fluxexec.Components(components...),
-			fluxexec.ComponentsExtra(componentsExtra...),
-			fluxexec.WithGlobalOptions(
-				fluxexec.Namespace(flags.Namespace),
-				fluxexec.Timeout(flags.Timeout),
-			),
-		); err != nil {
-			return "", false, err
-		}
+	var components []fluxexec.Component
+	for _, component := range componentNames {
+		components = append(components, fluxexec.Component(component))
+	}
 
-		fluxVersion = flags.FluxVersion
+	var componentsExtra []fluxexec.ComponentExtra
+	for _, component := range componentExtraNames {
+		componentsExtra = append(componentsExtra, fluxexec.ComponentExtra(component))
+	}
 
-		return fluxVersion, true, nil
-	} else {
-		log.Successf("Flux version %s is found", fluxVersion)
+	return flux.Install(ctx,
+		fluxexec.Components(components...),
+		fluxexec.ComponentsExtra(componentsExtra...),
+		fluxexec.WithGlobalOptions(
+			fluxexec.Namespace(namespace),
+			fluxexec.Timeout(flags.Timeout),
+		),
+	)
+}
+
+// requiredFluxComponents returns the Flux components syncing the paths, with
+// the CRDs of the objects GitOps Run creates for them.
+func requiredFluxComponents(paths *run.Paths) []install.FluxComponent {
+	source := install.FluxComponent{
+		Name: "source-controller",
+		CRDs: []string{"buckets.source.toolkit.fluxcd.io"},
 	}
 
-	return fluxVersion, false, nil
+	if watch.SourceMode(flags.Source) == watch.SourceModeOCI {
+		source.CRDs = []string{"ocirepositories.source.toolkit.fluxcd.io"}
+	}
+
+	if isHelm(paths.GetAbsoluteTargetDir()) {
+		source.CRDs = append(source.CRDs, "helmcharts.source.toolkit.fluxcd.io")
+
+		return []install.FluxComponent{source, {
+			Name: "helm-controller",
+			CRDs: []string{"helmreleases.helm.toolkit.fluxcd.io"},
+		}}
+	}
+
+	return []install.FluxComponent{source, {
+		Name: "kustomize-controller",
+		CRDs: []string{"kustomizations.kustomize.toolkit.fluxcd.io"},
+	}}
 }
 
 func dashboardStep(log logger.Logger, ctx context.Context, kubeClient *kube.KubeHTTP, generateManifestsOnly bool) (bool, []byte, string, error) {
@@ -331,7 +581,7 @@ func dashboardStep(log logger.Logger, ctx context.Context, kubeClient *kube.Kube
 		switch {
 		case flags.DashboardHashedPassword != "":
 			wantToInstallTheDashboard = true
-		case !flags.SkipDashboardInstall:
+		case !flags.SkipDashboardInstall && !flags.NoTTY:
 			prompt := promptui.Prompt{
 				Label:     "Would you like to install the GitOps Dashboard",
 				IsConfirm: true,
@@ -392,7 +642,7 @@ func dashboardStep(log logger.Logger, ctx context.Context, kubeClient *kube.Kube
 	if dashboardInstalled {
 		log.Actionf("Request reconciliation of dashboard (timeout %v) ...", flags.Timeout)
 
-		if err := install.ReconcileDashboard(ctx, kubeClient, dashboardName, flags.Namespace, dashboardPodName, flags.Timeout); err != nil {
+		if err := install.ReconcileDashboard(ctx, log, kubeClient, dashboardName, flags.Namespace, dashboardPodName, flags.Timeout); err != nil {
 			log.Failuref("Error requesting reconciliation of dashboard: %v", err.Error())
 		} else {
 			log.Successf("Dashboard reconciliation is done.")
@@ -403,18 +653,25 @@ func dashboardStep(log logger.Logger, ctx context.Context, kubeClient *kube.Kube
 }
 
 func runCommandWithSession(cmd *cobra.Command, args []string) (retErr error) {
-	paths, err := run.NewPaths(args[0], flags.RootDir)
+	allPaths, err := newRunPaths(args)
 	if err != nil {
 		return err
 	}
 
+	paths := allPaths[0]
+
+	// fail before creating the session rather than in it
+	if _, err := helmValuesFiles(paths); err != nil {
+		return err
+	}
+
 	kubeClient, _, err := getKubeClient(cmd, args)
 	if err != nil {
 		return err
 	}
 
 	// create session
-	sessionLog := logger.NewCLILogger(os.Stdout)
+	sessionLog := newOutputLogger()
 	sessionLog.Actionf("Preparing the cluster for GitOps Run session ...\n")
 
 	sessionLog.Println("You can run `gitops beta run --no-session` to disable session management.\n")
@@ -428,7 +685,7 @@ func runCommandWithSession(cmd *cobra.Command, args []string) (retErr error) {
 
 	var fluxJustInstalled bool
 
-	if _, fluxJustInstalled, err = fluxStep(log, kubeClient); err != nil {
+	if _, fluxJustInstalled, err = fluxStep(log, kubeClient, requiredFluxComponents(paths)); err != nil {
 		return fmt.Errorf("failed to install Flux on the host cluster: %v", err)
 	}
 
@@ -449,7 +706,7 @@ func runCommandWithSession(cmd *cobra.Command, args []string) (retErr error) {
 			return err
 		}
 
-		portForwardsForSession = append(portForwardsForSession, spec.HostPort)
+		portForwardsForSession = append(portForwardsForSession, spec.HostPorts()...)
 	}
 
 	var kind string
@@ -459,6 +716,11 @@ func runCommandWithSession(cmd *cobra.Command, args []string) (retErr error) {
 		kind = "ks"
 	}
 
+	scheduling, err := sessionScheduling()
+	if err != nil {
+		return err
+	}
+
 	session, err := install.NewSession(
 		sessionLog,
 		kubeClient,
@@ -467,6 +729,7 @@ func runCommandWithSession(cmd *cobra.Command, args []string) (retErr error) {
 		portForwardsForSession,
 		dashboardHashedPassword,
 		kind,
+		scheduling,
 	)
 
 	if err != nil {
@@ -499,8 +762,8 @@ func runCommandWithSession(cmd *cobra.Command, args []string) (retErr error) {
 
 	// now that the session is deleted, we return to the host cluster
 
-	// run bootstrap wizard only if Flux was not installed
-	if fluxJustInstalled && !flags.NoBootstrap {
+	// run bootstrap wizard only if Flux was not installed, and we can prompt
+	if fluxJustInstalled && !flags.NoBootstrap && !flags.NoTTY {
 		prompt := promptui.Prompt{
 			Label:     "Would you like to bootstrap your cluster into GitOps mode",
 			IsConfirm: true,
@@ -540,9 +803,17 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 	// There are two loggers in this function.
 	// 1. log0 is the os.Stdout logger
 	// 2. log is the S3 logger that also delegates its outputs to "log0".
-	log0 := logger.NewCLILogger(os.Stdout)
+	log0 := newOutputLogger()
+
+	allPaths, err := newRunPaths(args)
+	if err != nil {
+		return err
+	}
+
+	// the first path is the one bootstrapped
+	paths := allPaths[0]
 
-	paths, err := run.NewPaths(args[0], flags.RootDir)
+	valuesFiles, err := helmValuesFiles(paths)
 	if err != nil {
 		return err
 	}
@@ -593,7 +864,7 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 		fluxVersion       string
 	)
 
-	fluxVersion, fluxJustInstalled, err = fluxStep(log0, kubeClient)
+	fluxVersion, fluxJustInstalled, err = fluxStep(log0, kubeClient, requiredFluxComponents(paths))
 
 	if err != nil {
 		cancel()
@@ -612,42 +883,90 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 		username = current.Username
 	}
 
-	// ====================== Dev-bucket ======================
-	// Install dev-bucket server before everything, so that we can also forward logs to it
-	unusedPorts, err := run.GetUnusedPorts(2)
-	if err != nil {
-		cancel()
-		return err
-	}
+	useOCI := watch.SourceMode(flags.Source) == watch.SourceModeOCI
 
-	devBucketHTTPPort := unusedPorts[0]
-	devBucketHTTPSPort := unusedPorts[1]
+	var (
+		cancelDevPortForwarding func()
+		logSinks                []logger.LogSink
+		devBucketHTTPPort       int32
+		devBucketHTTPSPort      int32
+		devRegistryPort         int32
+		accessKey               []byte
+		secretKey               []byte
+		cert                    []byte
+	)
 
-	// generate access key and secret key for Minio auth
-	accessKey, err := s3.GenerateAccessKey(s3.DefaultRandIntFunc)
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed generating access key: %w", err)
-	}
+	if useOCI {
+		// ====================== Dev-registry ======================
+		// the dev registry replaces the dev bucket, so the session logs only go to the sinks set by flags
+		unusedPorts, err := run.GetUnusedPorts(1)
+		if err != nil {
+			cancel()
+			return err
+		}
 
-	secretKey, err := s3.GenerateSecretKey(s3.DefaultRandIntFunc)
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed generating secret key: %w", err)
-	}
+		devRegistryPort = unusedPorts[0]
 
-	cancelDevBucketPortForwarding, cert, err := watch.InstallDevBucketServer(ctx, log0, kubeClient, cfg, devBucketHTTPPort, devBucketHTTPSPort, accessKey, secretKey)
-	if err != nil {
-		cancel()
-		return fmt.Errorf("unable to install S3 bucket server: %w", err)
-	}
+		cancelDevPortForwarding, err = watch.InstallDevRegistry(ctx, log0, kubeClient, cfg, devRegistryPort)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("unable to install OCI registry: %w", err)
+		}
 
-	log, err := logger.NewS3LogWriter(sessionName, fmt.Sprintf("localhost:%d", devBucketHTTPSPort), accessKey, secretKey, cert, log0)
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed creating S3 log writer: %w", err)
+		logSinks, err = sessionLogSinks(sessionName)
+		if err != nil {
+			cancel()
+			return err
+		}
+	} else {
+		// ====================== Dev-bucket ======================
+		// Install dev-bucket server before everything, so that we can also forward logs to it
+		unusedPorts, err := run.GetUnusedPorts(2)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		devBucketHTTPPort = unusedPorts[0]
+		devBucketHTTPSPort = unusedPorts[1]
+
+		// generate access key and secret key for Minio auth
+		accessKey, err = s3.GenerateAccessKey(s3.DefaultRandIntFunc)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed generating access key: %w", err)
+		}
+
+		secretKey, err = s3.GenerateSecretKey(s3.DefaultRandIntFunc)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed generating secret key: %w", err)
+		}
+
+		cancelDevPortForwarding, cert, err = watch.InstallDevBucketServer(ctx, log0, kubeClient, cfg, devBucketHTTPPort, devBucketHTTPSPort, accessKey, secretKey, s3.Retention{
+			MaxAge:     flags.LogsMaxAge,
+			MaxObjects: flags.LogsMaxObjects,
+		})
+		if err != nil {
+			cancel()
+			return fmt.Errorf("unable to install S3 bucket server: %w", err)
+		}
+
+		s3Sink, err := logger.NewS3LogSink(sessionName, fmt.Sprintf("localhost:%d", devBucketHTTPSPort), accessKey, secretKey, cert)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed creating S3 log sink: %w", err)
+		}
+
+		logSinks, err = sessionLogSinks(sessionName, s3Sink)
+		if err != nil {
+			cancel()
+			return err
+		}
 	}
 
+	log := logger.NewSinkLogger(log0, logSinks...)
+
 	// ====================== Dashboard ======================
 	var (
 		dashboardInstalled bool
@@ -670,23 +989,61 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := watch.InitializeTargetDir(paths.GetAbsoluteTargetDir()); err != nil {
-		cancel()
-		return fmt.Errorf("couldn't set up against target %s: %w", paths.TargetDir, err)
+	var targetDirs []string
+
+	for _, p := range allPaths {
+		if err := watch.InitializeTargetDir(p.GetAbsoluteTargetDir()); err != nil {
+			cancel()
+			return fmt.Errorf("couldn't set up against target %s: %w", p.TargetDir, err)
+		}
+
+		// the paths are in the dev bucket, so they use slashes on every system
+		targetDirs = append(targetDirs, filepath.ToSlash(p.TargetDir))
 	}
 
 	setupParams := watch.SetupRunObjectParams{
-		Namespace:     flags.Namespace,
-		Path:          paths.TargetDir,
-		Timeout:       flags.Timeout,
-		DevBucketPort: devBucketHTTPPort,
-		SessionName:   sessionName,
-		Username:      username,
-		AccessKey:     accessKey,
-		SecretKey:     secretKey,
+		Namespace:       flags.Namespace,
+		Paths:           targetDirs,
+		PathOrder:       watch.PathOrder(flags.PathOrder),
+		ValuesFiles:     valuesFiles,
+		Timeout:         flags.Timeout,
+		DevBucketPort:   devBucketHTTPPort,
+		DevRegistryPort: devRegistryPort,
+		SessionName:     sessionName,
+		Username:        username,
+		AccessKey:       accessKey,
+		SecretKey:       secretKey,
+		Substitute:      flags.Substitute,
+		TargetNamespace: flags.TargetNamespace,
 	}
 
-	if !isHelm(paths.GetAbsoluteTargetDir()) {
+	for _, ref := range flags.SubstituteFrom {
+		// the references are validated before running, so this can't fail
+		substituteFrom, _ := watch.ParseSubstituteFrom(ref)
+
+		setupParams.SubstituteFrom = append(setupParams.SubstituteFrom, substituteFrom)
+	}
+
+	for _, ref := range flags.DependsOn {
+		// the references are validated before running, so this can't fail
+		dependsOn, _ := watch.ParseDependsOn(ref)
+
+		setupParams.DependsOn = append(setupParams.DependsOn, dependsOn)
+	}
+
+	for _, ref := range flags.HealthChecks {
+		// the references are validated before running, so this can't fail
+		healthCheck, _ := watch.ParseHealthCheck(ref)
+
+		setupParams.HealthChecks = append(setupParams.HealthChecks, healthCheck)
+	}
+
+	if useOCI {
+		if err := watch.SetupOCISourceAndKS(ctx, log, kubeClient, setupParams); err != nil {
+			cancel()
+			return err
+		}
+	} else if !isHelm(paths.GetAbsoluteTargetDir()) {
 		if err := watch.SetupBucketSourceAndKS(ctx, log, kubeClient, setupParams); err != nil {
 			cancel()
 			return err
@@ -698,10 +1055,14 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	minioClient, err := s3.NewMinioClient("localhost:"+strconv.Itoa(int(devBucketHTTPSPort)), accessKey, secretKey, cert)
-	if err != nil {
-		cancel()
-		return err
+	var minioClient *minio.Client
+
+	if !useOCI {
+		minioClient, err = s3.NewMinioClient("localhost:"+strconv.Itoa(int(devBucketHTTPSPort)), accessKey, secretKey, cert)
+		if err != nil {
+			cancel()
+			return err
+		}
 	}
 
 	// watch for file changes in dir gitRepoRoot
@@ -711,9 +1072,9 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	ignorer := watch.CreateIgnorer(paths.RootDir)
+	ignorer := watch.CreateIgnorer(paths.RootDir, flags.Excludes...)
 
-	err = filepath.Walk(paths.RootDir, watch.WatchDirsForFileWalker(watcher, ignorer))
+	err = watch.WalkDir(paths.RootDir, flags.FollowSymlinks, watch.WatchDirsForFileWalker(watcher, ignorer))
 	if err != nil {
 		cancel()
 		return err
@@ -722,9 +1083,11 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 	// cancel function to stop forwarding port
 	var (
 		cancelPortFwd func()
-		// atomic counter for the number of file change events that have changed
-		counter      uint64 = 1
-		needToRescan        = false
+		// the file change events, synced once they stop for the debounce window
+		debouncer    = watch.NewDebouncer(flags.Debounce)
+		needToRescan = false
+		// signals the ignore files changed, so the ignorer is created again
+		ignoreFilesChanged = make(chan struct{}, 1)
 	)
 
 	watcherCtx, watcherCancel := context.WithCancel(ctx)
@@ -734,6 +1097,14 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 		for {
 			select {
 			case event := <-watcher.Events:
+				if watch.IsIgnoreFile(paths.RootDir, event.Name) {
+					select {
+					case ignoreFilesChanged <- struct{}{}:
+					default:
+						// a reload is already pending
+					}
+				}
+
 				if event.Op&fsnotify.Create == fsnotify.Create ||
 					event.Op&fsnotify.Remove == fsnotify.Remove ||
 					event.Op&fsnotify.Rename == fsnotify.Rename {
@@ -754,42 +1125,155 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 					watcherCtx, watcherCancel = context.WithCancel(ctx)
 				}
 
-				atomic.AddUint64(&counter, 1)
+				debouncer.Add(time.Now())
 			case err := <-watcher.Errors:
-				if err != nil {
+				if err != nil && watch.AreEventsLost(err) {
+					log.Warningf("Some file changes were missed, syncing all the files again ...")
+
+					// the missed changes may have added dirs too
+					needToRescan = true
+
+					debouncer.Add(time.Now())
+				} else if err != nil {
 					log.Failuref("Error: %v", err)
 				}
 			}
 		}
 	}()
 
+	var notifier *watch.Notifier
+	if flags.Notify || flags.NotifyWebhook != "" {
+		notifier = watch.NewNotifier(flags.SessionName, flags.Notify, flags.NotifyWebhook)
+	}
+
+	reconcile := func(thisCtx context.Context) error {
+		var reconcileErr error
+		if useOCI {
+			reconcileErr = watch.ReconcileDevOCISourceAndKS(thisCtx, log, kubeClient, cfg, flags.Namespace, watch.DevKsNames(len(allPaths)), reconcileWaitOptions())
+		} else if !isHelm(paths.GetAbsoluteTargetDir()) {
+			reconcileErr = watch.ReconcileDevBucketSourceAndKS(thisCtx, log, kubeClient, cfg, flags.Namespace, watch.DevKsNames(len(allPaths)), reconcileWaitOptions())
+		} else {
+			reconcileErr = watch.ReconcileDevBucketSourceAndHelm(thisCtx, log, kubeClient, flags.Namespace, reconcileWaitOptions())
+		}
+
+		if reconcileErr != nil {
+			log.Failuref("Error requesting reconciliation: %v", reconcileErr)
+		} else {
+			log.Successf("Reconciliation is done.")
+		}
+
+		// the reconciliations cancelled by file changes are neither healthy
+		// nor failed
+		if notifier != nil && !errors.Is(reconcileErr, context.Canceled) {
+			status, message := watch.SyncStatusHealthy, "The changes are reconciled"
+			if reconcileErr != nil {
+				status, message = watch.SyncStatusFailed, reconcileErr.Error()
+			}
+
+			if err := notifier.Notify(ctx, status, message); err != nil {
+				log.Warningf("Error notifying: %v", err)
+			}
+		}
+
+		return reconcileErr
+	}
+
+	var (
+		// the snapshot the dev bucket is in, rolled back from by u, and
+		// listed by s while syncing
+		currentSnapshot   string
+		currentSnapshotMu sync.Mutex
+		// the IDs of the snapshots to roll back to, empty for the previous one
+		rollbacks = make(chan string, 1)
+	)
+
+	getCurrentSnapshot := func() string {
+		currentSnapshotMu.Lock()
+		defer currentSnapshotMu.Unlock()
+
+		return currentSnapshot
+	}
+
+	setCurrentSnapshot := func(id string) {
+		currentSnapshotMu.Lock()
+		defer currentSnapshotMu.Unlock()
+
+		currentSnapshot = id
+	}
+
 	// event aggregation loop
-	ticker := time.NewTicker(680 * time.Millisecond)
+	ticker := time.NewTicker(250 * time.Millisecond)
 
 	go func() {
-		for { // nolint:gosimple
+		for {
 			select {
+			case id := <-rollbacks:
+				if debouncer.Paused() {
+					log.Warningf("Syncing is paused, resume it to roll back")
+					continue
+				}
+
+				snapshot, err := rollbackSnapshot(ctx, minioClient, sessionName, getCurrentSnapshot(), id)
+				if err != nil {
+					log.Failuref("Error rolling back: %v", err)
+					continue
+				}
+
+				if err := watch.RestoreSnapshot(ctx, log, minioClient, snapshot); err != nil {
+					log.Failuref("Error rolling back to snapshot %s: %v", snapshot.ID, err)
+					continue
+				}
+
+				setCurrentSnapshot(snapshot.ID)
+
+				lastReconcile = time.Now()
+
+				if err := reconcile(watcherCtx); err == nil {
+					log.Successf("Rolled back to snapshot %s, until the files change again", snapshot.ID)
+				}
 			case <-ticker.C:
-				if counter > 0 {
+				if counter, ready := debouncer.Ready(time.Now()); ready {
 					log.Actionf("%d change events detected", counter)
 
-					// reset counter
-					atomic.StoreUint64(&counter, 0)
+					select {
+					case <-ignoreFilesChanged:
+						log.Actionf("Ignore files changed, reloading them ...")
+
+						ignorer = watch.CreateIgnorer(paths.RootDir, flags.Excludes...)
+
+						// the ignored dirs aren't watched
+						needToRescan = true
+					default:
+					}
 
 					// we have to skip validation for helm charts
-					if !isHelm(paths.GetAbsoluteTargetDir()) {
-						// validate only files under the target dir
-						log.Actionf("Validating files under %s/ ...", paths.TargetDir)
+					if !isHelm(paths.GetAbsoluteTargetDir()) && !validateTargetDirs(log, allPaths, kubernetesVersion, fluxVersion) {
+						continue
+					}
 
-						if err := validate.Validate(paths.GetAbsoluteTargetDir(), kubernetesVersion, fluxVersion); err != nil {
-							log.Failuref("Validation failed: please review the errors and try again: %v", err)
-							continue
+					if flags.Diff {
+						log.Actionf("Previewing the changes ...")
+
+						if err := watch.DiffDevKustomizations(ctx, log, kubeClient, paths.RootDir, ignorer, flags.FollowSymlinks, flags.Namespace, watch.DevKsNames(len(allPaths))); err != nil {
+							log.Failuref("Error previewing the changes: %v", err)
 						}
 					}
 
 					// use ctx, not thisCtx - incomplete uploads will never make anybody happy
-					if err := watch.SyncDir(ctx, log, paths.RootDir, watch.RunDevBucketName, minioClient, ignorer); err != nil {
+					if useOCI {
+						if err := watch.PushDir(ctx, log, paths.RootDir, fmt.Sprintf("localhost:%d", devRegistryPort), ignorer, flags.FollowSymlinks); err != nil {
+							log.Failuref("Error pushing dir: %v", err)
+						}
+					} else if err := watch.SyncDir(ctx, log, paths.RootDir, watch.RunDevBucketName, minioClient, ignorer, flags.FollowSymlinks, flags.SyncConcurrency); err != nil {
 						log.Failuref("Error syncing dir: %v", err)
+					} else if snapshot, recorded, err := watch.RecordSnapshot(ctx, minioClient, sessionName); err != nil {
+						log.Warningf("Error recording a snapshot: %v", err)
+					} else {
+						if recorded {
+							log.Actionf("Recorded snapshot %s", snapshot.ID)
+						}
+
+						setCurrentSnapshot(snapshot.ID)
 					}
 
 					if needToRescan {
@@ -803,7 +1287,7 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 							log.Failuref("Error creating new watcher: %v", err)
 						}
 
-						err = filepath.Walk(paths.RootDir, watch.WatchDirsForFileWalker(watcher, ignorer))
+						err = watch.WalkDir(paths.RootDir, flags.FollowSymlinks, watch.WatchDirsForFileWalker(watcher, ignorer))
 						if err != nil {
 							log.Failuref("Error re-walking dir: %v", err)
 						}
@@ -817,58 +1301,137 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 					// context that cancels when files change
 					thisCtx := watcherCtx
 
-					var reconcileErr error
-					if !isHelm(paths.GetAbsoluteTargetDir()) {
-						reconcileErr = watch.ReconcileDevBucketSourceAndKS(thisCtx, log, kubeClient, flags.Namespace, flags.Timeout)
-					} else {
-						reconcileErr = watch.ReconcileDevBucketSourceAndHelm(thisCtx, log, kubeClient, flags.Namespace, flags.Timeout)
-					}
+					reconcileErr := reconcile(thisCtx)
 
-					if reconcileErr != nil {
-						log.Failuref("Error requesting reconciliation: %v", reconcileErr)
-					} else {
-						log.Successf("Reconciliation is done.")
-					}
+					var portForwards []*watch.PortForwardSpec
 
 					if flags.PortForward != "" {
-						specMap, err := watch.ParsePortForwardSpec(flags.PortForward)
+						// the spec is validated before running, so this can't fail
+						specMap, _ := watch.ParsePortForwardSpec(flags.PortForward)
+
+						portForwards = append(portForwards, specMap)
+					}
+
+					if reconcileErr == nil && !isHelm(paths.GetAbsoluteTargetDir()) {
+						annotated, err := watch.FindAnnotatedPortForwards(thisCtx, log, kubeClient, flags.Namespace, watch.DevKsNames(len(allPaths)))
 						if err != nil {
-							log.Failuref("Error parsing port forward spec: %v", err)
+							log.Failuref("Error finding annotated services: %v", err)
 						}
 
-						// get pod from specMap
-						namespacedName := types.NamespacedName{Namespace: specMap.Namespace, Name: specMap.Name}
+						portForwards = append(portForwards, annotated...)
+					}
+
+					if len(portForwards) > 0 {
+						waitFwd := make(chan struct{}, 1)
+						cancelPortFwd = func() {
+							close(waitFwd)
 
-						pod, err := run.GetPodFromResourceDescription(thisCtx, namespacedName, specMap.Kind, kubeClient)
-						if err != nil {
-							log.Failuref("Error getting pod from specMap: %v", err)
+							cancelPortFwd = nil
 						}
 
-						if pod != nil {
-							waitFwd := make(chan struct{}, 1)
-							readyChannel := make(chan struct{})
-							cancelPortFwd = func() {
-								close(waitFwd)
+						watch.ShowPortForwards(log, portForwards)
 
-								cancelPortFwd = nil
-							}
+						// this function _BLOCKS_ until the stopChannel (waitPwd) is closed.
+						watch.ForwardPortsToReadyPods(thisCtx, log, kubeClient, cfg, portForwards, waitFwd)
 
-							log.Actionf("Port forwarding to pod %s/%s ...", pod.Namespace, pod.Name)
+						log.Successf("Port forwarding is stopped.")
+					}
+				}
+			}
+		}
+	}()
 
-							// this function _BLOCKS_ until the stopChannel (waitPwd) is closed.
-							if err := watch.ForwardPort(log.L(), pod, cfg, specMap, waitFwd, readyChannel); err != nil {
-								log.Failuref("Error forwarding port: %v", err)
-							}
+	// syncing is paused or resumed on SIGUSR2, or when p is entered
+	pauseToggles := make(chan struct{}, 1)
+	pauseSigs := make(chan os.Signal, 1)
+	signal.Notify(pauseSigs, syscall.SIGUSR2)
 
-							log.Successf("Port forwarding is stopped.")
-						}
+	go func() {
+		for range pauseSigs {
+			pauseToggles <- struct{}{}
+		}
+	}()
+
+	// stdin is left to the bootstrap prompt at shutdown, as it can't be read
+	// by both
+	readKeys := !flags.NoTTY && !(fluxJustInstalled && !flags.NoBootstrap)
+	if readKeys {
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) == 0 {
+					continue
+				}
+
+				switch {
+				case fields[0] == "p":
+					pauseToggles <- struct{}{}
+				case useOCI && (fields[0] == "s" || fields[0] == "u" || fields[0] == "r"):
+					log.Warningf("Snapshots are only recorded for the bucket source")
+				case fields[0] == "s":
+					snapshots, err := watch.ListSnapshots(ctx, minioClient, sessionName)
+					if err != nil {
+						log.Failuref("Error listing snapshots: %v", err)
+						continue
 					}
+
+					watch.ShowSnapshots(log, snapshots, getCurrentSnapshot())
+				case fields[0] == "u":
+					rollbacks <- ""
+				case fields[0] == "r" && len(fields) == 2:
+					rollbacks <- fields[1]
+				case fields[0] == "r":
+					log.Warningf("Enter r followed by the ID of the snapshot to roll back to")
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-pauseToggles:
+				pause := !debouncer.Paused()
+				if pause {
+					debouncer.Pause()
+				}
+
+				var err error
+				if isHelm(paths.GetAbsoluteTargetDir()) {
+					err = watch.SuspendDevHelmRelease(ctx, kubeClient, flags.Namespace, pause)
+				} else {
+					err = watch.SuspendDevKustomizations(ctx, kubeClient, flags.Namespace, watch.DevKsNames(len(allPaths)), pause)
+				}
+
+				switch {
+				case err != nil && pause:
+					debouncer.Resume()
+					log.Failuref("Error pausing syncing: %v", err)
+				case err != nil:
+					log.Failuref("Error resuming syncing: %v", err)
+				case pause:
+					log.Warningf("Syncing is paused, the file changes will be synced once it's resumed")
+				default:
+					// the changes made meanwhile are synced
+					debouncer.Resume()
+					log.Actionf("Syncing is resumed")
 				}
 			}
 		}
 	}()
 
 	// wait for interrupt or ctrl+C
+	if readKeys {
+		log.Waitingf("Enter p to pause or resume syncing ...")
+
+		if !useOCI {
+			log.Waitingf("Enter s to list the snapshots of the synced files, u to roll back to the previous one, or r <id> to roll back to one ...")
+		}
+	}
+
 	log.Waitingf("Press Ctrl+C to stop GitOps Run ...")
 
 	sig := <-sigs
@@ -879,14 +1442,20 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 
 	// re-enable listening for ctrl+C
 	signal.Reset(sig)
+	signal.Stop(pauseSigs)
 
 	if err := watcher.Close(); err != nil {
 		log.Warningf("Error closing watcher: %v", err.Error())
 	}
 
+	// the last session logs are put through the port forwarding
+	if err := log.Close(); err != nil {
+		log0.Warningf("Error closing session logs: %v", err.Error())
+	}
+
 	// print a blank line to make it easier to read the logs
 	fmt.Println()
-	cancelDevBucketPortForwarding()
+	cancelDevPortForwarding()
 
 	if cancelDashboardPortForwarding != nil {
 		cancelDashboardPortForwarding()
@@ -896,24 +1465,33 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 
 	// this is the default behaviour
 	if !flags.SkipResourceCleanup {
-		if !isHelm(paths.GetAbsoluteTargetDir()) {
-			if err := watch.CleanupBucketSourceAndKS(ctx, log0, kubeClient, flags.Namespace); err != nil {
-				return err
-			}
+		cleanupOpts := watch.CleanupOptions{
+			Wait:  reconcileWaitOptions(),
+			Force: flags.ForceCleanup,
+		}
+
+		var cleanupErr error
+		if useOCI {
+			cleanupErr = watch.CleanupOCISourceAndKS(ctx, log0, kubeClient, flags.Namespace, cleanupOpts)
+		} else if !isHelm(paths.GetAbsoluteTargetDir()) {
+			cleanupErr = watch.CleanupBucketSourceAndKS(ctx, log0, kubeClient, flags.Namespace, cleanupOpts)
 		} else {
-			if err := watch.CleanupBucketSourceAndHelm(ctx, log0, kubeClient, flags.Namespace); err != nil {
-				return err
-			}
+			cleanupErr = watch.CleanupBucketSourceAndHelm(ctx, log0, kubeClient, flags.Namespace, cleanupOpts)
 		}
 
-		// uninstall dev-bucket server
+		// uninstall dev-bucket server, or the dev registry
 		if err := watch.UninstallDevBucketServer(ctx, log0, kubeClient); err != nil {
 			return err
 		}
+
+		if cleanupErr != nil {
+			log0.Warningf("Some GitOps Run objects are left behind, run 'gitops beta run cleanup --force' to remove them")
+			return cleanupErr
+		}
 	}
 
-	// run bootstrap wizard only if Flux was not installed
-	if fluxJustInstalled && !flags.NoBootstrap {
+	// run bootstrap wizard only if Flux was not installed, and we can prompt
+	if fluxJustInstalled && !flags.NoBootstrap && !flags.NoTTY {
 		prompt := promptui.Prompt{
 			Label:     "Would you like to bootstrap your cluster into GitOps mode",
 			IsConfirm: true,
@@ -949,6 +1527,148 @@ func runCommandWithoutSession(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// sessionLogSinks returns the sinks of the session logs: the given ones, like
+// the dev bucket the dashboard reads them from, and the ones set by the flags.
+func sessionLogSinks(sessionName string, sinks ...logger.LogSink) ([]logger.LogSink, error) {
+
+	if flags.LogsLokiURL != "" {
+		sinks = append(sinks, logger.NewLokiLogSink(flags.LogsLokiURL, sessionName))
+	}
+
+	if flags.LogsFile != "" {
+		fileSink, err := logger.NewFileLogSink(flags.LogsFile, flags.LogsFileMaxSize*1024*1024, flags.LogsFileMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating file log sink: %w", err)
+		}
+
+		sinks = append(sinks, fileSink)
+	}
+
+	return sinks, nil
+}
+
+// newRunPaths returns the paths of the target given as argument, followed by
+// the ones given with --path.
+func newRunPaths(args []string) ([]*run.Paths, error) {
+	var result []*run.Paths
+
+	targets := append(append([]string{}, args...), flags.Paths...)
+
+	for _, target := range targets {
+		paths, err := run.NewPaths(target, flags.RootDir)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, paths)
+	}
+
+	if watch.SourceMode(flags.Source) == watch.SourceModeOCI {
+		for _, paths := range result {
+			if isHelm(paths.GetAbsoluteTargetDir()) {
+				return nil, fmt.Errorf("the Helm chart %s can't be run with --source %s", paths.TargetDir, watch.SourceModeOCI)
+			}
+		}
+	}
+
+	if len(result) > 1 {
+		for _, paths := range result {
+			if isHelm(paths.GetAbsoluteTargetDir()) {
+				return nil, fmt.Errorf("the Helm chart %s can't be run along with other paths", paths.TargetDir)
+			}
+		}
+	}
+
+	if len(flags.DependsOn) > 0 || len(flags.HealthChecks) > 0 {
+		for _, paths := range result {
+			if isHelm(paths.GetAbsoluteTargetDir()) {
+				return nil, fmt.Errorf("the Helm chart %s can't be run with --depends-on or --health-check", paths.TargetDir)
+			}
+		}
+	}
+
+	if flags.Diff {
+		for _, paths := range result {
+			if isHelm(paths.GetAbsoluteTargetDir()) {
+				return nil, fmt.Errorf("the Helm chart %s can't be run with --diff", paths.TargetDir)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// helmValuesFiles returns the values files of the Helm chart at paths,
+// relative to the root dir like the objects of the dev bucket. The
+// values.yaml of the chart comes first, so its defaults still apply.
+func helmValuesFiles(paths *run.Paths) ([]string, error) {
+	if len(flags.ValuesFiles) == 0 {
+		return nil, nil
+	}
+
+	if !isHelm(paths.GetAbsoluteTargetDir()) {
+		return nil, fmt.Errorf("values files can only be set when running a Helm chart")
+	}
+
+	var result []string
+
+	if _, err := os.Stat(filepath.Join(paths.GetAbsoluteTargetDir(), "values.yaml")); err == nil {
+		result = append(result, filepath.Join(paths.TargetDir, "values.yaml"))
+	}
+
+	for _, file := range flags.ValuesFiles {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Stat(absFile); err != nil {
+			return nil, fmt.Errorf("couldn't read values file: %w", err)
+		}
+
+		relFile, err := run.GetRelativePathToRootDir(paths.RootDir, absFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// only the root dir is synced to the dev bucket
+		if relFile == ".." || strings.HasPrefix(relFile, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("values file %s must be under the root directory %s", file, paths.RootDir)
+		}
+
+		// the values.yaml of the chart may be listed explicitly
+		if len(result) > 0 && result[0] == relFile {
+			continue
+		}
+
+		result = append(result, relFile)
+	}
+
+	// the values files are in the dev bucket, so they use slashes on every
+	// system
+	for i := range result {
+		result[i] = filepath.ToSlash(result[i])
+	}
+
+	return result, nil
+}
+
+// validateTargetDirs validates the files under each target dir, reporting
+// whether they're all valid.
+func validateTargetDirs(log logger.Logger, allPaths []*run.Paths, kubernetesVersion, fluxVersion string) bool {
+	for _, paths := range allPaths {
+		// validate only files under the target dir
+		log.Actionf("Validating files under %s/ ...", paths.TargetDir)
+
+		if err := validate.Validate(paths.GetAbsoluteTargetDir(), kubernetesVersion, fluxVersion); err != nil {
+			log.Failuref("Validation failed: please review the errors and try again: %v", err)
+			return false
+		}
+	}
+
+	return true
+}
+
 func isHelm(dir string) bool {
 	_, err := os.Stat(filepath.Join(dir, "Chart.yaml"))
 	return err == nil
@@ -1112,6 +1832,10 @@ func runBootstrap(ctx context.Context, log logger.Logger, paths *run.Paths, mani
 
 func betaRunCommandRunE(opts *config.Options) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
+		if !flags.NoTTY && !isTerminal() {
+			flags.NoTTY = true
+		}
+
 		if flags.NoSession {
 			return runCommandWithoutSession(cmd, args)
 		} else {
@@ -1119,3 +1843,82 @@ func betaRunCommandRunE(opts *config.Options) func(*cobra.Command, []string) err
 		}
 	}
 }
+
+// sessionScheduling returns where the vcluster of the session runs, and the
+// resources it may use.
+func sessionScheduling() (session.Scheduling, error) {
+	var (
+		result session.Scheduling
+		err    error
+	)
+
+	if result.Resources.Requests, err = session.ParseResourceList(flags.SessionRequests); err != nil {
+		return result, fmt.Errorf("invalid session requests: %w", err)
+	}
+
+	if result.Resources.Limits, err = session.ParseResourceList(flags.SessionLimits); err != nil {
+		return result, fmt.Errorf("invalid session limits: %w", err)
+	}
+
+	if len(flags.SessionNodeSelector) > 0 {
+		result.NodeSelector = flags.SessionNodeSelector
+	}
+
+	for _, t := range flags.SessionTolerations {
+		toleration, err := session.ParseToleration(t)
+		if err != nil {
+			return result, err
+		}
+
+		result.Tolerations = append(result.Tolerations, toleration)
+	}
+
+	return result, nil
+}
+
+// reconcileWaitOptions returns how the reconciliations of the GitOps Run
+// resources are waited for.
+func reconcileWaitOptions() run.WaitOptions {
+	return run.WaitOptions{
+		Interval:    flags.ReconcileInterval,
+		MaxInterval: flags.ReconcileMaxInterval,
+		Jitter:      flags.ReconcileJitter,
+		Timeout:     flags.Timeout,
+	}
+}
+
+// rollbackSnapshot returns the snapshot of the session with the ID, or the
+// one before currentID when the ID is empty.
+func rollbackSnapshot(ctx context.Context, minioClient *minio.Client, sessionName, currentID, id string) (watch.Snapshot, error) {
+	if id != "" {
+		return watch.GetSnapshot(ctx, minioClient, sessionName, id)
+	}
+
+	snapshots, err := watch.ListSnapshots(ctx, minioClient, sessionName)
+	if err != nil {
+		return watch.Snapshot{}, err
+	}
+
+	snapshot, ok := watch.PreviousSnapshot(snapshots, currentID)
+	if !ok {
+		return watch.Snapshot{}, errors.New("there's no earlier snapshot")
+	}
+
+	return snapshot, nil
+}
+
+// isTerminal returns whether GitOps Run can prompt on stdin and show its
+// progress on stdout.
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// newOutputLogger returns the logger showing the progress on stdout, as JSON
+// lines when there's no terminal.
+func newOutputLogger() logger.Logger {
+	if flags.NoTTY {
+		return logger.NewJSONLogger(os.Stdout)
+	}
+
+	return logger.NewCLILogger(os.Stdout)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
 	"github.com/weaveworks/weave-gitops/cmd/gitops/create/dashboard"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/create/tenant"
 )
 
 type CreateCommandFlags struct {
@@ -24,6 +25,11 @@ func GetCommand(opts *config.Options) *cobra.Command {
 gitops create dashboard ww-gitops \
   --password=$PASSWORD \
   --export > ./clusters/my-cluster/weave-gitops-dashboard.yaml
+
+# Create the namespaces, RBAC and Flux objects onboarding a tenant
+gitops create tenant team-a \
+  --with-namespace=team-a-apps \
+  --export > ./tenants/team-a.yaml
 		`,
 	}
 
@@ -31,6 +37,7 @@ gitops create dashboard ww-gitops \
 	cmd.PersistentFlags().DurationVar(&flags.Timeout, "timeout", 3*time.Minute, "The timeout for operations during resource creation.")
 
 	cmd.AddCommand(dashboard.DashboardCommand(opts))
+	cmd.AddCommand(tenant.TenantCommand(opts))
 
 	return cmd
 }
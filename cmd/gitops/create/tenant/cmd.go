@@ -0,0 +1,157 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/cmderrors"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
+	"github.com/weaveworks/weave-gitops/pkg/gitproviders"
+	"github.com/weaveworks/weave-gitops/pkg/tenancy"
+)
+
+type TenantCommandFlags struct {
+	Namespaces  []string
+	ClusterRole string
+	SourceURL   string
+	Branch      string
+	Path        string
+	// Pull request flags.
+	PRRepository string
+	PRPath       string
+}
+
+var flags TenantCommandFlags
+
+func TenantCommand(opts *config.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tenant",
+		Short: "Generate the namespaces, RBAC and Flux objects onboarding a tenant",
+		Long: `Generate the namespaces, RBAC and Flux objects onboarding a tenant.
+
+The namespaces are labelled with toolkit.fluxcd.io/tenant, so the dashboard
+shows objects in them as belonging to the tenant. Each namespace gets a
+service account for Flux to impersonate, bound to --cluster-role. If
+--source-url is set, the tenant's repository is synced from its first
+namespace as that service account.
+
+The manifests are printed, or committed to a new branch of --pr-repository
+and opened as a pull request. Opening a pull request reads the git provider
+token from GITHUB_TOKEN or GITLAB_TOKEN.`,
+		Example: `
+# Print the manifests of a tenant with two namespaces
+gitops create tenant team-a \
+  --with-namespace=team-a-apps,team-a-jobs \
+  --source-url=https://github.com/example/team-a \
+  --export > ./tenants/team-a.yaml
+
+# Open a pull request adding the tenant to the fleet repository
+gitops create tenant team-a \
+  --with-namespace=team-a-apps \
+  --pr-repository=https://github.com/example/fleet \
+  --pr-path=./tenants
+		`,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PreRunE:           createTenantCommandPreRunE,
+		RunE:              createTenantCommandRunE,
+		DisableAutoGenTag: true,
+	}
+
+	cmdFlags := cmd.Flags()
+
+	cmdFlags.StringSliceVar(&flags.Namespaces, "with-namespace", nil, "The namespaces of the tenant.")
+	cmdFlags.StringVar(&flags.ClusterRole, "cluster-role", tenancy.DefaultClusterRole, "The cluster role bound to the tenant in its namespaces.")
+	cmdFlags.StringVar(&flags.SourceURL, "source-url", "", "The repository of the tenant, synced from its first namespace.")
+	cmdFlags.StringVar(&flags.Branch, "branch", tenancy.DefaultBranch, "The branch of the tenant repository.")
+	cmdFlags.StringVar(&flags.Path, "path", tenancy.DefaultPath, "The path of the tenant's manifests in its repository.")
+	cmdFlags.StringVar(&flags.PRRepository, "pr-repository", "", "Open a pull request adding the tenant to this repository instead of printing the manifests.")
+	cmdFlags.StringVar(&flags.PRPath, "pr-path", "tenants", "The directory the tenant manifests are added to in the pull request.")
+
+	return cmd
+}
+
+func createTenantCommandPreRunE(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmderrors.ErrNoName
+	}
+
+	if len(args) > 1 {
+		return cmderrors.ErrMultipleNames
+	}
+
+	return nil
+}
+
+func createTenantCommandRunE(cmd *cobra.Command, args []string) error {
+	tenant := tenancy.Tenant{
+		Name:        args[0],
+		Namespaces:  flags.Namespaces,
+		ClusterRole: flags.ClusterRole,
+		SourceURL:   flags.SourceURL,
+		Branch:      flags.Branch,
+		Path:        flags.Path,
+	}
+
+	if err := tenant.Validate(); err != nil {
+		return err
+	}
+
+	if flags.PRRepository == "" {
+		manifests, err := tenant.Manifests()
+		if err != nil {
+			return fmt.Errorf("error generating tenant manifests: %w", err)
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), string(manifests))
+
+		return nil
+	}
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	repoURL, err := gitproviders.NewRepoURL(flags.PRRepository)
+	if err != nil {
+		return fmt.Errorf("invalid pull request repository: %w", err)
+	}
+
+	provider, err := newGitProvider(repoURL)
+	if err != nil {
+		return err
+	}
+
+	pr, err := tenancy.PullRequest(ctx, provider, repoURL, flags.PRPath, tenant)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Opened pull request %s\n", pr.Get().WebURL)
+
+	return nil
+}
+
+func newGitProvider(repoURL gitproviders.RepoURL) (gitproviders.GitProvider, error) {
+	tokenVar := "GITHUB_TOKEN"
+	if repoURL.Provider() == gitproviders.GitProviderGitLab {
+		tokenVar = "GITLAB_TOKEN"
+	}
+
+	token := os.Getenv(tokenVar)
+	if token == "" {
+		return nil, fmt.Errorf("%s must be set to open a pull request", tokenVar)
+	}
+
+	return gitproviders.New(gitproviders.Config{
+		Provider: repoURL.Provider(),
+		Hostname: repoURL.URL().Host,
+		Token:    token,
+	}, repoURL.Owner(), gitproviders.GetAccountType)
+}
@@ -7,27 +7,43 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/term"
 )
 
 func HashCommand(opts *config.Options) *cobra.Command {
+	hashConfig := auth.DefaultPasswordHashConfig()
+
 	cmd := &cobra.Command{
-		Use:   "bcrypt-hash",
-		Short: "Generates a hashed secret",
+		Use:     "bcrypt-hash",
+		Aliases: []string{"password-hash"},
+		Short:   "Generates a hashed secret",
 		Example: `
 PASSWORD="<your password>"
 echo -n $PASSWORD | gitops get bcrypt-hash
+
+# Use a higher bcrypt cost
+echo -n $PASSWORD | gitops get bcrypt-hash --cost 12
+
+# Use argon2id instead of bcrypt
+echo -n $PASSWORD | gitops get password-hash --algorithm argon2id --argon2-memory 131072
 `,
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		RunE:          hashCommandRunE(),
+		RunE:          hashCommandRunE(&hashConfig),
 	}
 
+	cmd.Flags().StringVar(&hashConfig.Algorithm, "algorithm", hashConfig.Algorithm, fmt.Sprintf("Hash algorithm, one of %s or %s", auth.PasswordHashBcrypt, auth.PasswordHashArgon2id))
+	cmd.Flags().IntVar(&hashConfig.BcryptCost, "cost", hashConfig.BcryptCost, fmt.Sprintf("bcrypt cost, between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	cmd.Flags().Uint32Var(&hashConfig.Argon2Memory, "argon2-memory", hashConfig.Argon2Memory, "argon2id memory in KiB")
+	cmd.Flags().Uint32Var(&hashConfig.Argon2Iterations, "argon2-iterations", hashConfig.Argon2Iterations, "argon2id number of passes over the memory")
+	cmd.Flags().Uint8Var(&hashConfig.Argon2Parallelism, "argon2-parallelism", hashConfig.Argon2Parallelism, "argon2id number of threads")
+
 	return cmd
 }
 
-func hashCommandRunE() func(*cobra.Command, []string) error {
+func hashCommandRunE(hashConfig *auth.PasswordHashConfig) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		file := os.Stdin
 		stats, err := file.Stat()
@@ -56,7 +72,7 @@ func hashCommandRunE() func(*cobra.Command, []string) error {
 			}
 		}
 
-		secret, err := bcrypt.GenerateFromPassword(p, bcrypt.DefaultCost)
+		secret, err := auth.HashPassword(p, *hashConfig)
 
 		if err != nil {
 			return err
@@ -6,6 +6,7 @@ import (
 	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
 	"github.com/weaveworks/weave-gitops/cmd/gitops/get/bcrypt"
 	configCmd "github.com/weaveworks/weave-gitops/cmd/gitops/get/config"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/get/sessionlogs"
 )
 
 func GetCommand(opts *config.Options) *cobra.Command {
@@ -18,11 +19,15 @@ gitops get config
 
 # Generate a hashed secret
 PASSWORD="<your password>"
-echo -n $PASSWORD | gitops get bcrypt-hash`,
+echo -n $PASSWORD | gitops get bcrypt-hash
+
+# Download the logs of a GitOps Run session
+gitops get session-logs run-main`,
 	}
 
 	cmd.AddCommand(bcrypt.HashCommand(opts))
 	cmd.AddCommand(configCmd.ConfigCommand(opts))
+	cmd.AddCommand(sessionlogs.SessionLogsCommand(opts))
 
 	return cmd
 }
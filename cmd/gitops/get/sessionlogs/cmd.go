@@ -0,0 +1,149 @@
+package sessionlogs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/cmderrors"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
+	gitopsconfig "github.com/weaveworks/weave-gitops/pkg/config"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+)
+
+// archivePath is where the API serves the logs of a session, as
+// core/server.SessionLogsArchivePath.
+const archivePath = "/v1/session_logs/%s/archive"
+
+type sessionLogsFlags struct {
+	Output string
+}
+
+var flags sessionLogsFlags
+
+func SessionLogsCommand(opts *config.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session-logs SESSION_NAME",
+		Short: "Download the logs of a GitOps Run session",
+		Long: `Download all the logs of a GitOps Run session as a tar.gz archive,
+e.g. to attach them to a support ticket.`,
+		Example: `
+# Download the logs of the session run-main to run-main-logs.tar.gz
+gitops get session-logs run-main --endpoint https://gitops.example.com
+
+# Download the logs of a session in the dev namespace to a file of your choice
+gitops get session-logs run-main --namespace dev --output logs.tar.gz`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PreRunE:           sessionLogsCommandPreRunE(&opts.Endpoint),
+		RunE:              sessionLogsCommandRunE(opts),
+		DisableAutoGenTag: true,
+	}
+
+	cmd.Flags().StringVarP(&flags.Output, "output", "o", "", "The file to write the archive to, or - for stdout. Defaults to SESSION_NAME-logs.tar.gz.")
+
+	return cmd
+}
+
+func sessionLogsCommandPreRunE(endpoint *string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if *endpoint == "" {
+			return cmderrors.ErrNoWGEEndpoint
+		}
+
+		return nil
+	}
+}
+
+func sessionLogsCommandRunE(opts *config.Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		log := logger.NewCLILogger(os.Stderr)
+
+		sessionName := args[0]
+
+		namespace, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			return err
+		}
+
+		endpoint := strings.TrimSuffix(opts.Endpoint, "/")
+
+		client := http.DefaultClient
+		if opts.InsecureSkipTLSVerify {
+			client = &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+				},
+			}
+		}
+
+		token, err := gitopsconfig.GetToken(endpoint)
+		if err != nil {
+			return err
+		}
+
+		output := flags.Output
+		if output == "" {
+			output = sessionName + "-logs.tar.gz"
+		}
+
+		log.Actionf("Downloading the logs of session %s/%s ...", namespace, sessionName)
+
+		if err := downloadArchive(client, endpoint, token, sessionName, namespace, output); err != nil {
+			return err
+		}
+
+		if output != "-" {
+			log.Successf("Logs written to %s", output)
+		}
+
+		return nil
+	}
+}
+
+func downloadArchive(client *http.Client, endpoint, token, sessionName, namespace, output string) error {
+	u := endpoint + fmt.Sprintf(archivePath, url.PathEscape(sessionName)) + "?" + url.Values{"sessionNamespace": {namespace}}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download session logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to download session logs: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if output == "-" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to download session logs: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,172 @@
+package login
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/cmderrors"
+	"github.com/weaveworks/weave-gitops/cmd/gitops/config"
+	gitopsconfig "github.com/weaveworks/weave-gitops/pkg/config"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+const oauth2Prefix = "/oauth2"
+
+func LoginCommand(opts *config.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to the Weave GitOps API",
+		Long: `Log in to the Weave GitOps API using the device authorization flow.
+The command prints a code and a URL that can be opened in a browser on any device,
+so it also works over SSH sessions where no browser is available. Approving the
+login from a dashboard session, of either the OIDC or the cluster user login,
+gives the CLI a token of its own, which commands like gitops get session-logs
+send as a bearer token.`,
+		Example: `
+# Log in to the Weave GitOps API
+gitops login --endpoint https://gitops.example.com`,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PreRunE:           loginCommandPreRunE(&opts.Endpoint),
+		RunE:              loginCommandRunE(opts),
+		DisableAutoGenTag: true,
+	}
+
+	return cmd
+}
+
+func loginCommandPreRunE(endpoint *string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if *endpoint == "" {
+			return cmderrors.ErrNoWGEEndpoint
+		}
+
+		return nil
+	}
+}
+
+func loginCommandRunE(opts *config.Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		log := logger.NewCLILogger(os.Stdout)
+
+		endpoint := strings.TrimSuffix(opts.Endpoint, "/")
+
+		client := http.DefaultClient
+		if opts.InsecureSkipTLSVerify {
+			client = &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+				},
+			}
+		}
+
+		da, err := requestDeviceCode(client, endpoint)
+		if err != nil {
+			return err
+		}
+
+		log.Println("To log in, open %s in a browser and confirm the code %s", da.VerificationURIComplete, da.UserCode)
+		log.Waitingf("Waiting for approval ...")
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), time.Duration(da.ExpiresIn)*time.Second)
+		defer cancel()
+
+		token, err := pollDeviceToken(ctx, client, endpoint, da)
+		if err != nil {
+			log.Failuref("Login failed")
+			return err
+		}
+
+		if err := gitopsconfig.SaveToken(endpoint, token.AccessToken); err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+
+		log.Successf("Logged in to %s", endpoint)
+
+		return nil
+	}
+}
+
+func requestDeviceCode(client *http.Client, endpoint string) (*auth.DeviceAuthorizationResponse, error) {
+	resp, err := client.PostForm(endpoint+oauth2Prefix+"/device/code", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start device login: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to start device login: unexpected status %s", resp.Status)
+	}
+
+	da := &auth.DeviceAuthorizationResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(da); err != nil {
+		return nil, fmt.Errorf("failed to decode device login response: %w", err)
+	}
+
+	return da, nil
+}
+
+// pollDeviceToken polls the token endpoint at the interval requested by the
+// server until the user approves or denies the login or the code expires.
+func pollDeviceToken(ctx context.Context, client *http.Client, endpoint string, da *auth.DeviceAuthorizationResponse) (*auth.DeviceTokenResponse, error) {
+	interval := time.Duration(da.Interval) * time.Second
+	form := url.Values{
+		"grant_type":  {auth.DeviceCodeGrantType},
+		"device_code": {da.DeviceCode},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for device login approval")
+		case <-time.After(interval):
+		}
+
+		resp, err := client.PostForm(endpoint+oauth2Prefix+"/device/token", form)
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll for token: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			token := &auth.DeviceTokenResponse{}
+			err := json.NewDecoder(resp.Body).Decode(token)
+			resp.Body.Close()
+
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode token response: %w", err)
+			}
+
+			return token, nil
+		}
+
+		errResp := &auth.OAuthErrorResponse{}
+		err = json.NewDecoder(resp.Body).Decode(errResp)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll for token: unexpected status %s", resp.Status)
+		}
+
+		switch errResp.Error {
+		case auth.DeviceErrAuthorizationPending:
+			continue
+		case auth.DeviceErrSlowDown:
+			interval += 5 * time.Second
+		case auth.DeviceErrAccessDenied:
+			return nil, fmt.Errorf("device login was denied")
+		case auth.DeviceErrExpiredToken:
+			return nil, fmt.Errorf("device code expired, please try again")
+		default:
+			return nil, fmt.Errorf("device login failed: %s", errResp.Error)
+		}
+	}
+}
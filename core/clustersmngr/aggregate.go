@@ -0,0 +1,99 @@
+package clustersmngr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+)
+
+// AggregateClusterFetcher merges the clusters of several fetchers, so
+// different discovery mechanisms can be used together, e.g. the management
+// cluster itself, kubeconfig secrets and Cluster API.
+//
+// Fetchers take precedence in order: when several return a cluster with the
+// same name, the cluster of the first one is kept and the others are
+// skipped. If any fetcher fails, fetching fails, rather than dropping the
+// clusters of the failed fetcher until it recovers.
+type AggregateClusterFetcher struct {
+	log      logr.Logger
+	fetchers []ClusterFetcher
+
+	// duplicates already logged, by cluster name, so they're reported once
+	// rather than on every resync
+	mu         sync.Mutex
+	duplicates map[string]bool
+}
+
+// NewAggregateClusterFetcher creates a fetcher of the clusters of fetchers,
+// in order of precedence.
+func NewAggregateClusterFetcher(log logr.Logger, fetchers ...ClusterFetcher) *AggregateClusterFetcher {
+	return &AggregateClusterFetcher{
+		log:        log,
+		fetchers:   fetchers,
+		duplicates: map[string]bool{},
+	}
+}
+
+func (af *AggregateClusterFetcher) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
+	clusters := []cluster.Cluster{}
+	byName := map[string]cluster.Cluster{}
+	duplicates := map[string]bool{}
+
+	for _, fetcher := range af.fetchers {
+		fetched, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range fetched {
+			if kept, ok := byName[c.GetName()]; ok {
+				duplicates[c.GetName()] = true
+
+				if !af.reported(c.GetName()) {
+					af.log.Info("Skipping cluster with the same name as one fetched first", "cluster", c.GetName(), "host", c.GetHost(), "keptHost", kept.GetHost())
+				}
+
+				continue
+			}
+
+			byName[c.GetName()] = c
+			clusters = append(clusters, c)
+		}
+	}
+
+	af.mu.Lock()
+	af.duplicates = duplicates
+	af.mu.Unlock()
+
+	opsDuplicateClusters.Set(float64(len(duplicates)))
+
+	return clusters, nil
+}
+
+func (af *AggregateClusterFetcher) reported(name string) bool {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	return af.duplicates[name]
+}
+
+// Watch watches the fetchers that can watch their clusters, until the
+// context is cancelled.
+func (af *AggregateClusterFetcher) Watch(ctx context.Context, changed func()) {
+	wg := sync.WaitGroup{}
+
+	for _, f := range af.fetchers {
+		if wf, ok := f.(WatchingClusterFetcher); ok {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				wf.Watch(ctx, changed)
+			}()
+		}
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,67 @@
+package clustersmngr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/clustersmngrfakes"
+)
+
+func TestAggregateClusterFetcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	newCluster := func(name, host string) cluster.Cluster {
+		c := &clusterfakes.FakeCluster{}
+		c.GetNameReturns(name)
+		c.GetHostReturns(host)
+
+		return c
+	}
+
+	self := new(clustersmngrfakes.FakeClusterFetcher)
+	self.FetchReturns([]cluster.Cluster{newCluster("Default", "https://self")}, nil)
+
+	secrets := new(clustersmngrfakes.FakeClusterFetcher)
+	secrets.FetchReturns([]cluster.Cluster{
+		newCluster("fleet/leaf", "https://leaf"),
+		newCluster("Default", "https://impostor"),
+	}, nil)
+
+	capi := new(clustersmngrfakes.FakeClusterFetcher)
+	capi.FetchReturns([]cluster.Cluster{
+		newCluster("fleet/leaf", "https://capi-leaf"),
+		newCluster("fleet/other", "https://other"),
+	}, nil)
+
+	f := clustersmngr.NewAggregateClusterFetcher(logr.Discard(), self, secrets, capi)
+
+	t.Run("first fetcher wins name collisions", func(t *testing.T) {
+		clusters, err := f.Fetch(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		hosts := []string{}
+		for _, c := range clusters {
+			hosts = append(hosts, c.GetName()+"="+c.GetHost())
+		}
+
+		g.Expect(hosts).To(Equal([]string{
+			"Default=https://self",
+			"fleet/leaf=https://leaf",
+			"fleet/other=https://other",
+		}))
+	})
+
+	t.Run("any failed fetcher fails", func(t *testing.T) {
+		capi.FetchReturns(nil, errors.New("boom"))
+
+		_, err := f.Fetch(ctx)
+		g.Expect(err).To(MatchError("boom"))
+	})
+}
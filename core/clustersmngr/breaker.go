@@ -0,0 +1,153 @@
+package clustersmngr
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// Consecutive failures after which calls to a cluster are skipped
+	breakerThreshold = 3
+	// How long calls are first skipped for, doubled on every failed retry
+	breakerMinBackoff = 10 * time.Second
+	breakerMaxBackoff = 5 * time.Minute
+)
+
+// ErrClusterUnavailable is returned instead of calling a cluster that failed
+// repeatedly, until its backoff window is over.
+var ErrClusterUnavailable = errors.New("cluster is unavailable")
+
+// ClustersBreakers keeps a circuit breaker for each cluster, by cluster name,
+// so one unreachable cluster doesn't make every request wait for dial
+// timeouts. A breaker opens after Threshold consecutive failures, then lets
+// one call through each time its backoff window is over; a success closes
+// it, a failure doubles the window. Settings left to zero keep their default.
+type ClustersBreakers struct {
+	sync.Mutex
+	Threshold  int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+type breaker struct {
+	failures  int
+	err       error
+	openUntil time.Time
+}
+
+func (cb *ClustersBreakers) threshold() int {
+	if cb.Threshold <= 0 {
+		return breakerThreshold
+	}
+
+	return cb.Threshold
+}
+
+func (cb *ClustersBreakers) minBackoff() time.Duration {
+	return durationOrDefault(cb.MinBackoff, breakerMinBackoff)
+}
+
+func (cb *ClustersBreakers) maxBackoff() time.Duration {
+	return durationOrDefault(cb.MaxBackoff, breakerMaxBackoff)
+}
+
+func (cb *ClustersBreakers) backoff(b *breaker) time.Duration {
+	backoff, maxBackoff := cb.minBackoff(), cb.maxBackoff()
+	for i := cb.threshold(); i < b.failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+func (cb *ClustersBreakers) clock() time.Time {
+	if cb.now == nil {
+		return time.Now()
+	}
+
+	return cb.now()
+}
+
+// Allow returns an error wrapping ErrClusterUnavailable and the last failure
+// if the cluster shouldn't be called. Once the backoff window is over, one
+// caller is let through to probe the cluster while others keep being
+// rejected for another window.
+func (cb *ClustersBreakers) Allow(cluster string) error {
+	cb.Lock()
+	defer cb.Unlock()
+
+	b, ok := cb.breakers[cluster]
+	if !ok || b.failures < cb.threshold() {
+		return nil
+	}
+
+	now := cb.clock()
+
+	if now.Before(b.openUntil) {
+		opsBreakerRejections.WithLabelValues(cluster).Inc()
+
+		return fmt.Errorf("%w after %d consecutive failures, retrying in %s: %v", ErrClusterUnavailable, b.failures, b.openUntil.Sub(now).Round(time.Second), b.err)
+	}
+
+	// If the probe never reports back, another one goes through later
+	b.openUntil = now.Add(cb.minBackoff())
+	opsBreakerState.WithLabelValues(cluster).Set(breakerHalfOpen)
+
+	return nil
+}
+
+// Failure records a failed call to a cluster.
+func (cb *ClustersBreakers) Failure(cluster string, err error) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	if cb.breakers == nil {
+		cb.breakers = map[string]*breaker{}
+	}
+
+	b, ok := cb.breakers[cluster]
+	if !ok {
+		b = &breaker{}
+		cb.breakers[cluster] = b
+	}
+
+	b.failures++
+	b.err = err
+
+	if b.failures >= cb.threshold() {
+		b.openUntil = cb.clock().Add(cb.backoff(b))
+		opsBreakerState.WithLabelValues(cluster).Set(breakerOpen)
+	}
+}
+
+// Success records a successful call to a cluster, which closes its breaker.
+func (cb *ClustersBreakers) Success(cluster string) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	if _, ok := cb.breakers[cluster]; !ok {
+		return
+	}
+
+	delete(cb.breakers, cluster)
+	opsBreakerState.WithLabelValues(cluster).Set(breakerClosed)
+}
+
+// Delete forgets the breaker of a removed cluster.
+func (cb *ClustersBreakers) Delete(cluster string) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	delete(cb.breakers, cluster)
+	opsBreakerState.DeleteLabelValues(cluster)
+	opsBreakerRejections.DeleteLabelValues(cluster)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -37,8 +38,21 @@ type Client interface {
 	// This method supports pagination with a caveat, the client.Limit passed will be multiplied
 	// by the number of clusters and namespaces, we decided to do this to avoid the complex coordination
 	// that would be required to make sure the number of items returned match the limit passed.
+	// Namespaced lists can be restricted to some namespaces with InNamespaces or client.InNamespace,
+	// label and field selectors are passed on so objects are filtered by the API servers.
+	// Lists can be restricted to some clusters with InClusters.
+	// A PartialFailure is returned if only some of the lists failed, a ClusteredListError if
+	// they all did.
 	ClusteredList(ctx context.Context, clist ClusteredObjectList, namespaced bool, opts ...client.ListOption) error
 
+	// ClusteredWatch watches the objects of the lists returned by newList in the clusters and
+	// namespaces the client has access to, like ClusteredList lists them, and sends the events
+	// of all the watches to the returned channel until ctx is done. Watches are restarted when
+	// they end or fail, failures are sent as events of type watch.Error.
+	// A ClusteredListError of the watches that couldn't be started is returned along with the
+	// channel, or without it if none could be started.
+	ClusteredWatch(ctx context.Context, newList func() client.ObjectList, namespaced bool, opts ...client.ListOption) (<-chan WatchEvent, error)
+
 	// ClientsPool returns the clients pool.
 	ClientsPool() ClientsPool
 
@@ -53,6 +67,29 @@ const (
 type clustersClient struct {
 	pool       ClientsPool
 	namespaces map[string][]v1.Namespace
+	// most lists ClusteredList makes at once, unbounded if 0
+	listConcurrency int
+	// other names of the clusters in the pool, by alias
+	aliases map[string]string
+}
+
+// ClientOption sets an option of a Client.
+type ClientOption func(*clustersClient)
+
+// WithListConcurrency bounds how many lists ClusteredList makes at once,
+// across all clusters and namespaces.
+func WithListConcurrency(n int) ClientOption {
+	return func(c *clustersClient) {
+		c.listConcurrency = n
+	}
+}
+
+// WithAliases lets clusters be picked by alias, when no cluster has that
+// name.
+func WithAliases(aliases map[string]string) ClientOption {
+	return func(c *clustersClient) {
+		c.aliases = aliases
+	}
 }
 
 type ListError struct {
@@ -82,19 +119,67 @@ func (cle ClusteredListError) Error() string {
 	return strings.Join(errs, "; ")
 }
 
-func NewClient(clientsPool ClientsPool, namespaces map[string][]v1.Namespace) Client {
-	return &clustersClient{
+// InvalidContinueTokenError is returned by ClusteredList for continue tokens
+// it didn't issue.
+type InvalidContinueTokenError struct {
+	Err error
+}
+
+func (e InvalidContinueTokenError) Error() string {
+	return fmt.Sprintf("invalid continue token: %v", e.Err)
+}
+
+func (e InvalidContinueTokenError) Unwrap() error {
+	return e.Err
+}
+
+// PartialFailure is returned by ClusteredList when only some of the lists
+// failed. The objects of the others are in the clustered list, so callers can
+// return them along with the errors instead of failing. It unwraps to its
+// ClusteredListError.
+type PartialFailure struct {
+	ClusteredListError
+	// List is the clustered list holding the objects that were listed.
+	List ClusteredObjectList
+}
+
+func (pf PartialFailure) Unwrap() error {
+	return pf.ClusteredListError
+}
+
+func NewClient(clientsPool ClientsPool, namespaces map[string][]v1.Namespace, opts ...ClientOption) Client {
+	c := &clustersClient{
 		pool:       clientsPool,
 		namespaces: namespaces,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *clustersClient) ClientsPool() ClientsPool {
 	return c.pool
 }
 
+// client returns the client of the cluster, picked by name or by alias.
+func (c *clustersClient) client(cluster string) (client.Client, error) {
+	cl, err := c.pool.Client(cluster)
+	if err == nil {
+		return cl, nil
+	}
+
+	if name, ok := c.aliases[cluster]; ok && errors.As(err, &ClusterNotFoundError{}) {
+		return c.pool.Client(name)
+	}
+
+	return nil, err
+}
+
 func (c *clustersClient) Get(ctx context.Context, cluster string, key client.ObjectKey, obj client.Object) error {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return err
 	}
@@ -106,7 +191,7 @@ func (c *clustersClient) Get(ctx context.Context, cluster string, key client.Obj
 }
 
 func (c *clustersClient) List(ctx context.Context, cluster string, list client.ObjectList, opts ...client.ListOption) error {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return err
 	}
@@ -127,17 +212,27 @@ func (c *clustersClient) ClusteredList(ctx context.Context, clist ClusteredObjec
 	continueToken := extractContinueToken(opts...)
 	if continueToken != "" {
 		if err := decodeFromBase64(paginationInfo, continueToken); err != nil {
-			return fmt.Errorf("failed decoding pagination info: %w", err)
+			return InvalidContinueTokenError{Err: err}
 		}
 	}
 
 	var (
-		errs = ClusteredListError{}
-		wg   = sync.WaitGroup{}
+		errs    = ClusteredListError{}
+		errsMu  = sync.Mutex{}
+		wg      = sync.WaitGroup{}
+		workers = newWorkerPool(c.listConcurrency)
+		lists   = 0
 	)
 
+	wantedNamespaces, opts := extractNamespaces(opts...)
+	wantedClusters, opts := c.extractClusters(opts...)
+
 	for clusterName, cc := range c.pool.Clients() {
-		namespaces := c.namespaces[clusterName]
+		if wantedClusters != nil && !wantedClusters[clusterName] {
+			continue
+		}
+
+		namespaces := filterNamespaces(c.namespaces[clusterName], wantedNamespaces)
 		if !namespaced {
 			namespaces = []v1.Namespace{{}}
 		}
@@ -148,30 +243,41 @@ func (c *clustersClient) ClusteredList(ctx context.Context, clist ClusteredObjec
 			// a prior request has been made so this one comes with a previous token,
 			// but if the namespace token is empty we ignore it because all items have been returned.
 			if continueToken != "" && nsContinueToken == "" {
+				// unless listing it failed, which is reported on every page
+				if failure := paginationInfo.Failure(clusterName, ns.Name); failure != "" {
+					lists++
+					errs.Add(ListError{Cluster: clusterName, Namespace: ns.Name, Err: fmt.Errorf("listing failed on an earlier page: %s", failure)})
+				}
+
 				continue
 			}
 
-			listOpts := append(opts, client.Continue(nsContinueToken))
-			listOpts = append(listOpts, client.InNamespace(ns.Name))
+			listOpts := make([]client.ListOption, 0, len(opts)+2)
+			listOpts = append(listOpts, opts...)
+			listOpts = append(listOpts, client.Continue(nsContinueToken), client.InNamespace(ns.Name))
 
-			wg.Add(1)
+			clusterName, nsName, c := clusterName, ns.Name, cc
 
-			go func(clusterName string, nsName string, c client.Client, optsWithNamespace ...client.ListOption) {
-				defer wg.Done()
+			lists++
 
+			workers.Go(&wg, func() {
 				list := clist.NewList()
 
 				ctx, cancel := context.WithTimeout(ctx, clientTimeout)
 				defer cancel()
 
-				if err := c.List(ctx, list, optsWithNamespace...); err != nil {
+				if err := c.List(ctx, list, listOpts...); err != nil {
+					errsMu.Lock()
 					errs.Add(ListError{Cluster: clusterName, Namespace: nsName, Err: err})
+					errsMu.Unlock()
+
+					paginationInfo.SetFailure(clusterName, nsName, err.Error())
 				}
 
 				paginationInfo.Set(clusterName, nsName, list.GetContinue())
 
 				clist.AddObjectList(clusterName, list)
-			}(clusterName, ns.Name, cc, listOpts...)
+			})
 		}
 	}
 
@@ -184,11 +290,74 @@ func (c *clustersClient) ClusteredList(ctx context.Context, clist ClusteredObjec
 
 	clist.SetContinue(continueToken)
 
-	if len(errs.Errors) > 0 {
-		return errs
+	if len(errs.Errors) == 0 {
+		return nil
 	}
 
-	return nil
+	if len(errs.Errors) < lists {
+		return PartialFailure{ClusteredListError: errs, List: clist}
+	}
+
+	return errs
+}
+
+// InNamespaces restricts ClusteredList to some namespaces of each cluster,
+// instead of every namespace the user can access. Namespaces the user can't
+// access are still skipped.
+type InNamespaces []string
+
+// ApplyToList does nothing, ClusteredList lists each namespace on its own.
+func (n InNamespaces) ApplyToList(*client.ListOptions) {}
+
+// extractNamespaces returns the namespaces requested with InNamespaces or a
+// non-empty client.InNamespace, nil if every namespace is to be listed, and
+// the other options.
+func extractNamespaces(opts ...client.ListOption) (map[string]bool, []client.ListOption) {
+	var (
+		wanted map[string]bool
+		rest   = make([]client.ListOption, 0, len(opts))
+	)
+
+	want := func(namespaces ...string) {
+		if wanted == nil {
+			wanted = map[string]bool{}
+		}
+
+		for _, ns := range namespaces {
+			wanted[ns] = true
+		}
+	}
+
+	for _, o := range opts {
+		switch v := o.(type) {
+		case InNamespaces:
+			want(v...)
+		case client.InNamespace:
+			if v != "" {
+				want(string(v))
+			}
+		default:
+			rest = append(rest, o)
+		}
+	}
+
+	return wanted, rest
+}
+
+func filterNamespaces(namespaces []v1.Namespace, wanted map[string]bool) []v1.Namespace {
+	if wanted == nil {
+		return namespaces
+	}
+
+	filtered := []v1.Namespace{}
+
+	for _, ns := range namespaces {
+		if wanted[ns.Name] {
+			filtered = append(filtered, ns)
+		}
+	}
+
+	return filtered
 }
 
 func extractContinueToken(opts ...client.ListOption) string {
@@ -205,7 +374,7 @@ func extractContinueToken(opts ...client.ListOption) string {
 }
 
 func (c *clustersClient) Create(ctx context.Context, cluster string, obj client.Object, opts ...client.CreateOption) error {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return err
 	}
@@ -214,7 +383,7 @@ func (c *clustersClient) Create(ctx context.Context, cluster string, obj client.
 }
 
 func (c *clustersClient) Delete(ctx context.Context, cluster string, obj client.Object, opts ...client.DeleteOption) error {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return err
 	}
@@ -223,7 +392,7 @@ func (c *clustersClient) Delete(ctx context.Context, cluster string, obj client.
 }
 
 func (c *clustersClient) Update(ctx context.Context, cluster string, obj client.Object, opts ...client.UpdateOption) error {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return err
 	}
@@ -232,7 +401,7 @@ func (c *clustersClient) Update(ctx context.Context, cluster string, obj client.
 }
 
 func (c *clustersClient) Patch(ctx context.Context, cluster string, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return err
 	}
@@ -241,7 +410,7 @@ func (c *clustersClient) Patch(ctx context.Context, cluster string, obj client.O
 }
 
 func (c clustersClient) Scoped(cluster string) (client.Client, error) {
-	client, err := c.pool.Client(cluster)
+	client, err := c.client(cluster)
 	if err != nil {
 		return nil, err
 	}
@@ -307,6 +476,33 @@ func (cl *ClusteredList) SetContinue(continueToken string) {
 type PaginationInfo struct {
 	sync.Mutex
 	ContinueTokens map[string]map[string]string
+	// Failures are the errors of the namespaces whose list failed, which
+	// have no continue token left.
+	Failures map[string]map[string]string `json:",omitempty"`
+}
+
+// SetFailure records that listing the namespace failed.
+func (pi *PaginationInfo) SetFailure(cluster string, namespace string, failure string) {
+	pi.Lock()
+	defer pi.Unlock()
+
+	if pi.Failures == nil {
+		pi.Failures = make(map[string]map[string]string)
+	}
+
+	if pi.Failures[cluster] == nil {
+		pi.Failures[cluster] = make(map[string]string)
+	}
+
+	pi.Failures[cluster][namespace] = failure
+}
+
+// Failure returns why listing the namespace failed, empty if it didn't.
+func (pi *PaginationInfo) Failure(cluster string, namespace string) string {
+	pi.Lock()
+	defer pi.Unlock()
+
+	return pi.Failures[cluster][namespace]
 }
 
 func (pi *PaginationInfo) Set(cluster string, namespace string, token string) {
@@ -343,6 +539,29 @@ func (pi *PaginationInfo) Get(cluster string, namespace string) string {
 	return ""
 }
 
+// HasMorePages tells whether a continue token returned by ClusteredList
+// has objects left to list in any cluster and namespace.
+func HasMorePages(continueToken string) bool {
+	if continueToken == "" {
+		return false
+	}
+
+	paginationInfo := &PaginationInfo{}
+	if err := decodeFromBase64(paginationInfo, continueToken); err != nil {
+		return false
+	}
+
+	for _, namespaces := range paginationInfo.ContinueTokens {
+		for _, token := range namespaces {
+			if token != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func decodeFromBase64(v interface{}, enc string) error {
 	return json.NewDecoder(base64.NewDecoder(base64.StdEncoding, strings.NewReader(enc))).Decode(v)
 }
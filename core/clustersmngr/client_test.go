@@ -2,8 +2,12 @@ package clustersmngr_test
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -22,6 +26,7 @@ import (
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/clustersmngrfakes"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
 	rbacv1 "k8s.io/api/rbac/v1"
 )
@@ -285,6 +290,64 @@ func TestClientCLusteredListErrors(t *testing.T) {
 	g.Expect(errors.As(cerr, &errs)).To(BeTrue())
 }
 
+// failingListClient fails every list.
+type failingListClient struct {
+	client.Client
+}
+
+func (c failingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return errors.New("cluster unreachable")
+}
+
+func TestClientClusteredListPartialFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nsMap := map[string][]corev1.Namespace{
+		"good": {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}},
+		"bad":  {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "ns-b"}}},
+	}
+
+	clientsPool := &clustersmngrfakes.FakeClientsPool{}
+	clientsPool.ClientsReturns(map[string]client.Client{
+		"good": slowListClient{running: new(int32), maxRunning: new(int32)},
+		"bad":  failingListClient{},
+	})
+
+	clustersClient := clustersmngr.NewClient(clientsPool, nsMap)
+
+	cklist := clustersmngr.NewClusteredList(func() client.ObjectList {
+		return &kustomizev1.KustomizationList{}
+	})
+
+	err := clustersClient.ClusteredList(context.Background(), cklist, true)
+
+	var pf clustersmngr.PartialFailure
+	g.Expect(errors.As(err, &pf)).To(BeTrue())
+	g.Expect(pf.Errors).To(HaveLen(2))
+	g.Expect(pf.List).To(Equal(cklist))
+	g.Expect(pf.List.Lists()).To(HaveKey("good"))
+
+	for _, e := range pf.Errors {
+		g.Expect(e.Cluster).To(Equal("bad"))
+	}
+
+	// callers handling any failed list keep working
+	var errs clustersmngr.ClusteredListError
+	g.Expect(errors.As(err, &errs)).To(BeTrue())
+	g.Expect(errs.Errors).To(HaveLen(2))
+
+	t.Run("every list failing isn't a partial failure", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		clientsPool.ClientsReturns(map[string]client.Client{"bad": failingListClient{}})
+
+		err := clustersClient.ClusteredList(context.Background(), cklist, true)
+
+		g.Expect(errors.As(err, &clustersmngr.PartialFailure{})).To(BeFalse())
+		g.Expect(errors.As(err, &errs)).To(BeTrue())
+	})
+}
+
 func TestClientList(t *testing.T) {
 	g := NewGomegaWithT(t)
 	ns := createNamespace(g)
@@ -510,3 +573,212 @@ func createClusterClientsPool(g *GomegaWithT, clusterName string) clustersmngr.C
 
 	return clientsPool
 }
+
+// slowListClient records how many lists run at once.
+type slowListClient struct {
+	client.Client
+	running, maxRunning *int32
+}
+
+func (c slowListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	running := atomic.AddInt32(c.running, 1)
+	defer atomic.AddInt32(c.running, -1)
+
+	for {
+		highest := atomic.LoadInt32(c.maxRunning)
+		if running <= highest || atomic.CompareAndSwapInt32(c.maxRunning, highest, running) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	return nil
+}
+
+// pagingListClient returns two pages.
+type pagingListClient struct {
+	client.Client
+}
+
+func (c pagingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	if listOpts.Continue == "" {
+		list.SetContinue("page-2")
+	}
+
+	return nil
+}
+
+func TestClientClusteredListPaginationFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nsMap := map[string][]corev1.Namespace{
+		"good": {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}},
+		"bad":  {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}},
+	}
+
+	clientsPool := &clustersmngrfakes.FakeClientsPool{}
+	clientsPool.ClientsReturns(map[string]client.Client{
+		"good": pagingListClient{},
+		"bad":  failingListClient{},
+	})
+
+	clustersClient := clustersmngr.NewClient(clientsPool, nsMap)
+
+	newList := func() clustersmngr.ClusteredObjectList {
+		return clustersmngr.NewClusteredList(func() client.ObjectList {
+			return &kustomizev1.KustomizationList{}
+		})
+	}
+
+	cklist := newList()
+	err := clustersClient.ClusteredList(context.Background(), cklist, true, client.Limit(1))
+	g.Expect(errors.As(err, &clustersmngr.PartialFailure{})).To(BeTrue())
+	g.Expect(clustersmngr.HasMorePages(cklist.GetContinue())).To(BeTrue())
+
+	// the namespace that failed isn't listed again, but isn't dropped
+	// silently either
+	err = clustersClient.ClusteredList(context.Background(), newList(), true, client.Limit(1), client.Continue(cklist.GetContinue()))
+
+	var errs clustersmngr.ClusteredListError
+	g.Expect(errors.As(err, &errs)).To(BeTrue())
+	g.Expect(errs.Errors).To(HaveLen(1))
+	g.Expect(errs.Errors[0].Cluster).To(Equal("bad"))
+	g.Expect(errs.Errors[0].Namespace).To(Equal("ns-a"))
+	g.Expect(errs.Errors[0].Err).To(MatchError(ContainSubstring("cluster unreachable")))
+
+	t.Run("malformed continue tokens are rejected", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		err := clustersClient.ClusteredList(context.Background(), newList(), true, client.Continue("not a token"))
+		g.Expect(errors.As(err, &clustersmngr.InvalidContinueTokenError{})).To(BeTrue())
+	})
+}
+
+func TestClientClusteredListConcurrency(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var running, maxRunning int32
+
+	clients := map[string]client.Client{}
+	nsMap := map[string][]corev1.Namespace{}
+
+	for i := 0; i < 3; i++ {
+		clusterName := "cluster-" + strconv.Itoa(i)
+		clients[clusterName] = slowListClient{running: &running, maxRunning: &maxRunning}
+
+		for j := 0; j < 4; j++ {
+			nsMap[clusterName] = append(nsMap[clusterName], corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-" + strconv.Itoa(j)}})
+		}
+	}
+
+	clientsPool := &clustersmngrfakes.FakeClientsPool{}
+	clientsPool.ClientsReturns(clients)
+
+	clustersClient := clustersmngr.NewClient(clientsPool, nsMap, clustersmngr.WithListConcurrency(2))
+
+	cklist := clustersmngr.NewClusteredList(func() client.ObjectList {
+		return &kustomizev1.KustomizationList{}
+	})
+
+	g.Expect(clustersClient.ClusteredList(context.Background(), cklist, true)).To(Succeed())
+
+	g.Expect(maxRunning).To(BeNumerically("<=", 2))
+
+	lists := 0
+	for _, l := range cklist.Lists() {
+		lists += len(l)
+	}
+
+	g.Expect(lists).To(Equal(12))
+}
+
+func TestHasMorePages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	token := func(tokens map[string]map[string]string) string {
+		data, err := json.Marshal(clustersmngr.PaginationInfo{ContinueTokens: tokens})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	g.Expect(clustersmngr.HasMorePages("")).To(BeFalse())
+	g.Expect(clustersmngr.HasMorePages("not a token")).To(BeFalse())
+	g.Expect(clustersmngr.HasMorePages(token(map[string]map[string]string{"c1": {"ns1": "", "ns2": ""}}))).To(BeFalse())
+	g.Expect(clustersmngr.HasMorePages(token(map[string]map[string]string{"c1": {"ns1": ""}, "c2": {"ns1": "next"}}))).To(BeTrue())
+}
+
+// recordingListClient records the options of each list.
+type recordingListClient struct {
+	client.Client
+	mu    *sync.Mutex
+	lists *[]client.ListOptions
+}
+
+func (c recordingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*c.lists = append(*c.lists, listOpts)
+
+	return nil
+}
+
+func TestClientClusteredListSelectors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var (
+		mu    sync.Mutex
+		lists []client.ListOptions
+	)
+
+	clusterName := "mycluster"
+
+	clientsPool := &clustersmngrfakes.FakeClientsPool{}
+	clientsPool.ClientsReturns(map[string]client.Client{clusterName: recordingListClient{mu: &mu, lists: &lists}})
+
+	nsMap := map[string][]corev1.Namespace{clusterName: {}}
+	for _, name := range []string{"ns-a", "ns-b", "ns-c"} {
+		nsMap[clusterName] = append(nsMap[clusterName], corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	clustersClient := clustersmngr.NewClient(clientsPool, nsMap)
+
+	listNamespaces := func(opts ...client.ListOption) []string {
+		lists = nil
+
+		cklist := clustersmngr.NewClusteredList(func() client.ObjectList {
+			return &kustomizev1.KustomizationList{}
+		})
+		g.Expect(clustersClient.ClusteredList(context.Background(), cklist, true, opts...)).To(Succeed())
+
+		namespaces := []string{}
+		for _, l := range lists {
+			namespaces = append(namespaces, l.Namespace)
+		}
+
+		return namespaces
+	}
+
+	g.Expect(listNamespaces()).To(ConsistOf("ns-a", "ns-b", "ns-c"))
+	g.Expect(listNamespaces(client.InNamespace(""))).To(ConsistOf("ns-a", "ns-b", "ns-c"))
+	g.Expect(listNamespaces(client.InNamespace("ns-b"))).To(ConsistOf("ns-b"))
+	g.Expect(listNamespaces(clustersmngr.InNamespaces{"ns-a", "ns-c", "forbidden"})).To(ConsistOf("ns-a", "ns-c"))
+
+	g.Expect(listNamespaces(
+		client.MatchingLabels{"app": "podinfo"},
+		client.MatchingFields{"metadata.name": "podinfo"},
+	)).To(HaveLen(3))
+
+	for _, l := range lists {
+		g.Expect(l.LabelSelector.String()).To(Equal("app=podinfo"))
+		g.Expect(l.FieldSelector.String()).To(Equal("metadata.name=podinfo"))
+	}
+}
@@ -41,8 +41,15 @@ type KubeConfigOption func(*rest.Config) (*rest.Config, error)
 type Cluster interface {
 	// GetName gets the name weave-gitops has given this cluster.
 	GetName() string
+	// GetID gets a stable identifier of the cluster, e.g. the UID of the
+	// object it was registered with, that doesn't change when the cluster is
+	// renamed. It's the name unless the fetcher that found the cluster set one
+	GetID() string
 	// GetHost gets the host of the cluster - this should match what's set in the `rest.Config`s below
 	GetHost() string
+	// GetLabels gets the labels of the cluster, set by the fetcher that found
+	// it, to group clusters e.g. by environment or region
+	GetLabels() map[string]string
 	// GetServerClient gets a un-impersonated client for this cluster
 	GetServerClient() (client.Client, error)
 	// GetUserClient gets an appropriately impersonated client for the user on this cluster
@@ -56,6 +63,12 @@ type Cluster interface {
 	GetServerConfig() (*rest.Config, error)
 }
 
+// Closer is implemented by clusters that hold resources, e.g. the informers
+// of a cache, which are released once the cluster is removed.
+type Closer interface {
+	Close()
+}
+
 func WithFlowControl(config *rest.Config) (*rest.Config, error) {
 	// flowcontrol.IsEnabled makes a request to the K8s API of the cluster stored in the config.
 	// It does a HEAD request to /livez/ping which uses the config.Dial timeout. We can use this
@@ -80,6 +93,45 @@ func WithFlowControl(config *rest.Config) (*rest.Config, error) {
 	return config, nil
 }
 
+// ClientConfig tunes the clients to each cluster. Fields left to zero keep
+// their default.
+type ClientConfig struct {
+	// QPS and Burst limit the requests made to each cluster. By default
+	// clients rely on the server's API Priority and Fairness if it's
+	// enabled, and allow ClientQPS and ClientBurst otherwise. Setting them
+	// applies them in both cases.
+	QPS   float32
+	Burst int
+	// Timeout of each request, by default WEAVE_GITOPS_KUBE_CLIENT_TIMEOUT
+	// or 30s.
+	Timeout   time.Duration
+	UserAgent string
+}
+
+// WithClientConfig applies cfg to the rest config of clusters. It must come
+// after WithFlowControl so QPS and Burst aren't overridden.
+func WithClientConfig(cfg ClientConfig) KubeConfigOption {
+	return func(config *rest.Config) (*rest.Config, error) {
+		if cfg.QPS > 0 {
+			config.QPS = cfg.QPS
+		}
+
+		if cfg.Burst > 0 {
+			config.Burst = cfg.Burst
+		}
+
+		if cfg.Timeout > 0 {
+			config.Timeout = cfg.Timeout
+		}
+
+		if cfg.UserAgent != "" {
+			config.UserAgent = cfg.UserAgent
+		}
+
+		return config, nil
+	}
+}
+
 func getEnvDuration(key string, defaultDuration time.Duration) time.Duration {
 	val := os.Getenv(key)
 	if val == "" {
@@ -0,0 +1,455 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/google"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
+)
+
+// The providers of cloud credentials, see CloudCredentials.
+const (
+	// EKSCredentials signs tokens with the AWS credentials of the server,
+	// e.g. from IAM roles for service accounts.
+	EKSCredentials = "eks"
+	// GKECredentials gets tokens for the Google service account of the
+	// server, e.g. from workload identity.
+	GKECredentials = "gke"
+	// AKSCredentials exchanges the Azure workload identity token of the
+	// server for Azure AD tokens.
+	AKSCredentials = "aks"
+	// AutoCredentials picks the provider matching the exec plugin of the
+	// kubeconfig.
+	AutoCredentials = "auto"
+	// KubeconfigCredentials keeps the exec plugin, auth provider and
+	// credential files of the kubeconfig, which otherwise are removed, see
+	// RemoveCredentialFiles. Only honoured for the kubeconfigs of the
+	// namespace the operator trusts.
+	KubeconfigCredentials = "kubeconfig"
+)
+
+const (
+	eksTokenPrefix     = "k8s-aws-v1."
+	eksClusterIDHeader = "x-k8s-aws-id"
+	// EKS tokens are valid for 15 minutes, they're renewed a minute early.
+	eksPresignExpiry = 15 * time.Minute
+	eksTokenExpiry   = 14 * time.Minute
+
+	// aksServerID is the application of the Azure AD integration of AKS,
+	// the audience of tokens unless the kubeconfig sets another.
+	aksServerID           = "6dae42f8-4368-4678-94ff-3960e28e3630"
+	defaultAzureAuthority = "https://login.microsoftonline.com/"
+	azureAssertionTypeJWT = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var gkeScopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/userinfo.email",
+}
+
+// The hosts of the API servers of managed clusters. Whoever writes a
+// kubeconfig picks its server, so the server's cloud tokens are only sent to
+// these.
+var (
+	eksEndpointSuffixes = []string{".eks.amazonaws.com", ".eks.amazonaws.com.cn"}
+	aksEndpointSuffixes = []string{".azmk8s.io"}
+	gkeEndpointSuffixes = []string{".gke.goog"}
+)
+
+// GKE clusters are mostly reached by IP, which is looked up in the GKE API
+// by the resource name of the cluster.
+var (
+	gkeClusterName = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/clusters/[^/]+$`)
+	gkeAPIURL      = "https://container.googleapis.com/v1/"
+	gkeTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+		return google.DefaultTokenSource(ctx, gkeScopes...)
+	}
+)
+
+// CloudCredentials authenticate to managed clusters natively, instead of with
+// the exec plugins of their kubeconfig (aws, gke-gcloud-auth-plugin,
+// kubelogin) which aren't available in the server's pod. The server's own
+// cloud identity is used, so no static token needs to be stored.
+type CloudCredentials struct {
+	// Provider is one of EKSCredentials, GKECredentials, AKSCredentials,
+	// AutoCredentials or KubeconfigCredentials.
+	Provider string
+	// ClusterName is the name of the EKS cluster, and Region its region.
+	// They're taken from the exec plugin's arguments if empty. For GKE
+	// clusters reached by IP, it's the resource name of the cluster,
+	// projects/<project>/locations/<location>/clusters/<name>.
+	ClusterName string
+	Region      string
+}
+
+// ProviderFor returns the provider of the credentials sent to the cluster of
+// config, resolving AutoCredentials, or an empty string if there are none.
+func (c CloudCredentials) ProviderFor(config *rest.Config) string {
+	switch c.Provider {
+	case KubeconfigCredentials:
+		return ""
+	case AutoCredentials:
+		return execProvider(config.ExecProvider)
+	default:
+		return c.Provider
+	}
+}
+
+// Apply replaces the exec plugin of config with tokens from the provider.
+// The tokens are only sent to the API servers of the provider's managed
+// clusters, and never through a proxy set by the kubeconfig.
+func (c CloudCredentials) Apply(config *rest.Config) error {
+	if c.Provider == KubeconfigCredentials {
+		return nil
+	}
+
+	provider := c.ProviderFor(config)
+	if provider == "" {
+		return errors.New("no cloud credentials match the kubeconfig")
+	}
+
+	if config.Proxy != nil {
+		return errors.New("cloud credentials can't be sent through a custom proxy")
+	}
+
+	var (
+		ts  oauth2.TokenSource
+		err error
+	)
+
+	switch provider {
+	case EKSCredentials:
+		ts, err = c.newEKSTokenSource(config.ExecProvider)
+	case GKECredentials:
+		ts, err = gkeTokenSource(context.Background())
+	case AKSCredentials:
+		ts, err = newAKSTokenSource(config.ExecProvider)
+	default:
+		return fmt.Errorf("unknown cloud credentials %q", c.Provider)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s credentials: %w", provider, err)
+	}
+
+	if err := c.checkEndpoint(provider, config.Host, ts); err != nil {
+		return fmt.Errorf("%s credentials: %w", provider, err)
+	}
+
+	config.ExecProvider = nil
+	config.AuthProvider = nil
+	config.BearerToken = ""
+	config.BearerTokenFile = ""
+	config.Wrap(transport.TokenSourceWrapTransport(oauth2.ReuseTokenSource(nil, ts)))
+
+	return nil
+}
+
+// checkEndpoint returns an error unless host is the API server of a managed
+// cluster of the provider, reached over https.
+func (c CloudCredentials) checkEndpoint(provider, host string, ts oauth2.TokenSource) error {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+
+	if u.Scheme != "https" {
+		return fmt.Errorf("tokens are only sent over https, not to %s", host)
+	}
+
+	hostname := u.Hostname()
+
+	switch provider {
+	case EKSCredentials:
+		if hasAnySuffix(hostname, eksEndpointSuffixes) {
+			return nil
+		}
+	case AKSCredentials:
+		if hasAnySuffix(hostname, aksEndpointSuffixes) {
+			return nil
+		}
+	case GKECredentials:
+		if hasAnySuffix(hostname, gkeEndpointSuffixes) {
+			return nil
+		}
+
+		if net.ParseIP(hostname) != nil && c.ClusterName != "" {
+			return checkGKEEndpoint(ts, c.ClusterName, hostname)
+		}
+	}
+
+	return fmt.Errorf("%s is not the API server of a managed cluster", hostname)
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkGKEEndpoint looks the cluster up in the GKE API, with the server's own
+// credentials, and returns an error unless ip is one of its endpoints.
+func checkGKEEndpoint(ts oauth2.TokenSource, name, ip string) error {
+	if !gkeClusterName.MatchString(name) {
+		return fmt.Errorf("GKE cluster %q is not a resource name, projects/<project>/locations/<location>/clusters/<name>", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gkeAPIURL+name, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := oauth2.NewClient(ctx, ts).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get GKE cluster %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get GKE cluster %s: %s", name, res.Status)
+	}
+
+	var gkeCluster struct {
+		Endpoint             string `json:"endpoint"`
+		PrivateClusterConfig struct {
+			PrivateEndpoint string `json:"privateEndpoint"`
+			PublicEndpoint  string `json:"publicEndpoint"`
+		} `json:"privateClusterConfig"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&gkeCluster); err != nil {
+		return fmt.Errorf("failed to decode GKE cluster %s: %w", name, err)
+	}
+
+	for _, endpoint := range []string{gkeCluster.Endpoint, gkeCluster.PrivateClusterConfig.PrivateEndpoint, gkeCluster.PrivateClusterConfig.PublicEndpoint} {
+		if endpoint != "" && endpoint == ip {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not an endpoint of GKE cluster %s", ip, name)
+}
+
+// LocalCredentials returns the settings of kubeconfig that run commands or
+// read files on the server, named as in kubeconfigs.
+func LocalCredentials(kubeconfig *clientcmdapi.Config) []string {
+	found := map[string]bool{}
+
+	for _, authInfo := range kubeconfig.AuthInfos {
+		found["exec"] = found["exec"] || authInfo.Exec != nil
+		found["auth-provider"] = found["auth-provider"] || authInfo.AuthProvider != nil
+		found["tokenFile"] = found["tokenFile"] || authInfo.TokenFile != ""
+		found["client-certificate"] = found["client-certificate"] || authInfo.ClientCertificate != ""
+		found["client-key"] = found["client-key"] || authInfo.ClientKey != ""
+	}
+
+	for _, cluster := range kubeconfig.Clusters {
+		found["certificate-authority"] = found["certificate-authority"] || cluster.CertificateAuthority != ""
+	}
+
+	var settings []string
+
+	for _, setting := range []string{"exec", "auth-provider", "tokenFile", "client-certificate", "client-key", "certificate-authority"} {
+		if found[setting] {
+			settings = append(settings, setting)
+		}
+	}
+
+	return settings
+}
+
+// RemoveCredentialFiles removes the token, certificate and key files of
+// kubeconfig, which are read when its REST config is created. Whoever can
+// write a kubeconfig could otherwise send the server's service account
+// token to their own API server.
+func RemoveCredentialFiles(kubeconfig *clientcmdapi.Config) {
+	for _, authInfo := range kubeconfig.AuthInfos {
+		authInfo.TokenFile = ""
+		authInfo.ClientCertificate = ""
+		authInfo.ClientKey = ""
+	}
+
+	for _, cluster := range kubeconfig.Clusters {
+		cluster.CertificateAuthority = ""
+	}
+}
+
+// execProvider returns the provider of the credentials the exec plugin gets.
+func execProvider(exec *clientcmdapi.ExecConfig) string {
+	if exec == nil {
+		return ""
+	}
+
+	switch filepath.Base(exec.Command) {
+	case "aws", "aws-iam-authenticator":
+		return EKSCredentials
+	case "gke-gcloud-auth-plugin":
+		return GKECredentials
+	case "kubelogin":
+		return AKSCredentials
+	default:
+		return ""
+	}
+}
+
+// execArg returns the value of one of the flags in the exec plugin's
+// arguments, in either the --flag value or --flag=value form.
+func execArg(exec *clientcmdapi.ExecConfig, flags ...string) string {
+	if exec == nil {
+		return ""
+	}
+
+	for i, arg := range exec.Args {
+		for _, flag := range flags {
+			if arg == flag && i+1 < len(exec.Args) {
+				return exec.Args[i+1]
+			}
+
+			if strings.HasPrefix(arg, flag+"=") {
+				return strings.TrimPrefix(arg, flag+"=")
+			}
+		}
+	}
+
+	return ""
+}
+
+func (c CloudCredentials) newEKSTokenSource(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error) {
+	clusterName := c.ClusterName
+	if clusterName == "" {
+		clusterName = execArg(exec, "--cluster-name", "--cluster-id", "-i")
+	}
+
+	if clusterName == "" {
+		return nil, errors.New("no EKS cluster name")
+	}
+
+	region := c.Region
+	if region == "" {
+		region = execArg(exec, "--region")
+	}
+
+	awsConfig := aws.Config{STSRegionalEndpoint: endpoints.RegionalSTSEndpoint}
+	if region != "" {
+		awsConfig.Region = aws.String(region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &eksTokenSource{sts: sts.New(sess), clusterName: clusterName}, nil
+}
+
+// eksTokenSource makes tokens the way aws eks get-token does: a presigned
+// STS GetCallerIdentity request, which the cluster makes to find out who is
+// calling.
+type eksTokenSource struct {
+	sts         *sts.STS
+	clusterName string
+}
+
+func (s *eksTokenSource) Token() (*oauth2.Token, error) {
+	req, _ := s.sts.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	req.HTTPRequest.Header.Add(eksClusterIDHeader, s.clusterName)
+
+	presigned, err := req.Presign(eksPresignExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign EKS token: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presigned)),
+		Expiry:      time.Now().Add(eksTokenExpiry),
+	}, nil
+}
+
+func newAKSTokenSource(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error) {
+	s := &aksTokenSource{
+		clientID:  os.Getenv("AZURE_CLIENT_ID"),
+		tenantID:  os.Getenv("AZURE_TENANT_ID"),
+		tokenFile: os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		authority: os.Getenv("AZURE_AUTHORITY_HOST"),
+		serverID:  execArg(exec, "--server-id"),
+	}
+
+	if s.clientID == "" || s.tenantID == "" || s.tokenFile == "" {
+		return nil, errors.New("AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_FEDERATED_TOKEN_FILE must be set by workload identity")
+	}
+
+	if s.authority == "" {
+		s.authority = defaultAzureAuthority
+	}
+
+	if s.serverID == "" {
+		s.serverID = aksServerID
+	}
+
+	return s, nil
+}
+
+// aksTokenSource exchanges the workload identity token of the server for an
+// Azure AD token of the cluster, as kubelogin does in workloadidentity mode.
+// The federated token is read on every exchange as it's rotated.
+type aksTokenSource struct {
+	clientID  string
+	tenantID  string
+	tokenFile string
+	authority string
+	serverID  string
+}
+
+func (s *aksTokenSource) Token() (*oauth2.Token, error) {
+	assertion, err := os.ReadFile(s.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federated token: %w", err)
+	}
+
+	config := clientcredentials.Config{
+		ClientID: s.clientID,
+		TokenURL: strings.TrimSuffix(s.authority, "/") + "/" + s.tenantID + "/oauth2/v2.0/token",
+		Scopes:   []string{s.serverID + "/.default"},
+		EndpointParams: map[string][]string{
+			"client_assertion_type": {azureAssertionTypeJWT},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+		},
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+
+	return config.Token(context.Background())
+}
@@ -0,0 +1,253 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCloudCredentialsEKS(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	config := &rest.Config{
+		Host: "https://0123456789ABCDEF.gr7.eu-west-1.eks.amazonaws.com",
+		ExecProvider: &clientcmdapi.ExecConfig{
+			Command: "aws",
+			Args:    []string{"--region", "eu-west-1", "eks", "get-token", "--cluster-name=production"},
+		},
+	}
+
+	g.Expect(CloudCredentials{Provider: AutoCredentials}.Apply(config)).To(Succeed())
+	g.Expect(config.ExecProvider).To(BeNil())
+
+	var authorization string
+
+	rt := config.WrapTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		authorization = req.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = rt.RoundTrip(req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	g.Expect(token).To(HavePrefix(eksTokenPrefix))
+
+	presigned, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, eksTokenPrefix))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	u, err := url.Parse(string(presigned))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(u.Host).To(Equal("sts.eu-west-1.amazonaws.com"))
+	g.Expect(u.Query().Get("Action")).To(Equal("GetCallerIdentity"))
+	g.Expect(u.Query().Get("X-Amz-Expires")).To(Equal("900"))
+	g.Expect(u.Query().Get("X-Amz-SignedHeaders")).To(ContainSubstring(eksClusterIDHeader))
+
+	t.Run("a token set by the user wins", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		req.Header.Set("Authorization", "Bearer user-token")
+
+		_, err = rt.RoundTrip(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(authorization).To(Equal("Bearer user-token"))
+	})
+}
+
+func TestCloudCredentialsAKS(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var form url.Values
+
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/tenant/oauth2/v2.0/token"))
+		g.Expect(r.ParseForm()).To(Succeed())
+		form = r.PostForm
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"aks-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer authority.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	g.Expect(os.WriteFile(tokenFile, []byte("federated-token\n"), 0o600)).To(Succeed())
+
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+	t.Setenv("AZURE_AUTHORITY_HOST", authority.URL+"/")
+
+	ts, err := newAKSTokenSource(&clientcmdapi.ExecConfig{
+		Command: "kubelogin",
+		Args:    []string{"get-token", "--server-id", "server"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	token, err := ts.Token()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(token.AccessToken).To(Equal("aks-token"))
+
+	g.Expect(form.Get("client_id")).To(Equal("client"))
+	g.Expect(form.Get("scope")).To(Equal("server/.default"))
+	g.Expect(form.Get("client_assertion")).To(Equal("federated-token"))
+	g.Expect(form.Get("client_assertion_type")).To(Equal(azureAssertionTypeJWT))
+}
+
+func TestCloudCredentialsInvalid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(CloudCredentials{Provider: "openstack"}.Apply(&rest.Config{})).To(MatchError(ContainSubstring("unknown cloud credentials")))
+	g.Expect(CloudCredentials{Provider: AutoCredentials}.Apply(&rest.Config{})).To(MatchError(ContainSubstring("no cloud credentials match")))
+	g.Expect(CloudCredentials{Provider: EKSCredentials}.Apply(&rest.Config{})).To(MatchError(ContainSubstring("no EKS cluster name")))
+}
+
+func TestCloudCredentialsEndpoints(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	var gkeRequests []string
+
+	gkeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gkeRequests = append(gkeRequests, r.Header.Get("Authorization")+" "+r.URL.Path)
+
+		if r.URL.Path != "/projects/fleet/locations/europe-west1/clusters/production" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"endpoint":"203.0.113.10","privateClusterConfig":{"privateEndpoint":"10.0.0.2"}}`))
+	}))
+	defer gkeAPI.Close()
+
+	defer func(url string, ts func(context.Context) (oauth2.TokenSource, error)) {
+		gkeAPIURL, gkeTokenSource = url, ts
+	}(gkeAPIURL, gkeTokenSource)
+
+	gkeAPIURL = gkeAPI.URL + "/"
+	gkeTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "gke-token"}), nil
+	}
+
+	eksExec := &clientcmdapi.ExecConfig{
+		Command: "aws",
+		Args:    []string{"--region", "eu-west-1", "eks", "get-token", "--cluster-name", "production"},
+	}
+
+	tests := []struct {
+		name        string
+		credentials CloudCredentials
+		config      *rest.Config
+		err         string
+	}{
+		{
+			name:        "EKS endpoint",
+			credentials: CloudCredentials{Provider: EKSCredentials},
+			config:      &rest.Config{Host: "https://ABCDEF.yl4.eu-west-1.eks.amazonaws.com", ExecProvider: eksExec},
+		},
+		{
+			name:        "EKS credentials for another host",
+			credentials: CloudCredentials{Provider: AutoCredentials},
+			config:      &rest.Config{Host: "https://eks.example.com", ExecProvider: eksExec},
+			err:         "eks.example.com is not the API server of a managed cluster",
+		},
+		{
+			name:        "plain http",
+			credentials: CloudCredentials{Provider: EKSCredentials},
+			config:      &rest.Config{Host: "http://ABCDEF.yl4.eu-west-1.eks.amazonaws.com", ExecProvider: eksExec},
+			err:         "only sent over https",
+		},
+		{
+			name:        "custom proxy",
+			credentials: CloudCredentials{Provider: EKSCredentials},
+			config:      &rest.Config{Host: "https://ABCDEF.yl4.eu-west-1.eks.amazonaws.com", ExecProvider: eksExec, Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy:3128"})},
+			err:         "custom proxy",
+		},
+		{
+			name:        "GKE DNS endpoint",
+			credentials: CloudCredentials{Provider: GKECredentials},
+			config:      &rest.Config{Host: "https://gke-0123456789abcdef.europe-west1.gke.goog"},
+		},
+		{
+			name:        "GKE endpoint looked up by IP",
+			credentials: CloudCredentials{Provider: GKECredentials, ClusterName: "projects/fleet/locations/europe-west1/clusters/production"},
+			config:      &rest.Config{Host: "https://10.0.0.2"},
+		},
+		{
+			name:        "GKE IP of another cluster",
+			credentials: CloudCredentials{Provider: GKECredentials, ClusterName: "projects/fleet/locations/europe-west1/clusters/production"},
+			config:      &rest.Config{Host: "https://198.51.100.7"},
+			err:         "198.51.100.7 is not an endpoint of GKE cluster",
+		},
+		{
+			name:        "GKE IP without a cluster",
+			credentials: CloudCredentials{Provider: GKECredentials},
+			config:      &rest.Config{Host: "https://203.0.113.10"},
+			err:         "not the API server of a managed cluster",
+		},
+		{
+			name:        "GKE cluster that isn't a resource name",
+			credentials: CloudCredentials{Provider: GKECredentials, ClusterName: "production/../../other"},
+			config:      &rest.Config{Host: "https://203.0.113.10"},
+			err:         "not a resource name",
+		},
+		{
+			name:        "AKS endpoint",
+			credentials: CloudCredentials{Provider: AKSCredentials},
+			config:      &rest.Config{Host: "https://production-dns-0123abcd.hcp.westeurope.azmk8s.io:443"},
+		},
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("federated-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			err := tt.credentials.Apply(tt.config)
+			if tt.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(tt.config.WrapTransport).NotTo(BeNil())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+				g.Expect(tt.config.WrapTransport).To(BeNil())
+			}
+		})
+	}
+
+	NewGomegaWithT(t).Expect(gkeRequests).To(ContainElement("Bearer gke-token /projects/fleet/locations/europe-west1/clusters/production"))
+}
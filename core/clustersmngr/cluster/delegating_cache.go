@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
-	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -18,17 +19,41 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
+// DefaultCachedKinds are the kinds the UI polls the most, read from the
+// cache of a cluster when it's enabled.
+var DefaultCachedKinds = []string{
+	"Kustomization.kustomize.toolkit.fluxcd.io",
+	"HelmRelease.helm.toolkit.fluxcd.io",
+	"GitRepository.source.toolkit.fluxcd.io",
+	"HelmRepository.source.toolkit.fluxcd.io",
+	"HelmChart.source.toolkit.fluxcd.io",
+	"Bucket.source.toolkit.fluxcd.io",
+	"OCIRepository.source.toolkit.fluxcd.io",
+}
+
 type delegatingCacheCluster struct {
-	restConfig *rest.Config
-	cluster    Cluster
-	scheme     *runtime.Scheme
+	restConfig  *rest.Config
+	cluster     Cluster
+	cachedKinds map[schema.GroupKind]bool
+	cache       *ClusterCache
 }
 
-func NewDelegatingCacheCluster(cluster Cluster, config *rest.Config, scheme *runtime.Scheme) Cluster {
+// NewDelegatingCacheCluster reads the given kinds from the informers of the
+// cache, shared by all the clients of the cluster, instead of from its API
+// server, once the user was checked to be allowed to read them. Reads of the
+// other kinds, and the ones the cache can't serve, go to the cluster. The
+// informers use the config, or the server config of the cluster if it's nil.
+func NewDelegatingCacheCluster(cluster Cluster, config *rest.Config, clusterCache *ClusterCache, cachedKinds []schema.GroupKind) Cluster {
+	kinds := make(map[schema.GroupKind]bool, len(cachedKinds))
+	for _, gk := range cachedKinds {
+		kinds[gk] = true
+	}
+
 	return &delegatingCacheCluster{
-		restConfig: config,
-		cluster:    cluster,
-		scheme:     scheme,
+		restConfig:  config,
+		cluster:     cluster,
+		cachedKinds: kinds,
+		cache:       clusterCache,
 	}
 }
 
@@ -36,17 +61,66 @@ func (c *delegatingCacheCluster) GetName() string {
 	return c.cluster.GetName()
 }
 
+func (c *delegatingCacheCluster) GetID() string {
+	return c.cluster.GetID()
+}
+
 func (c *delegatingCacheCluster) GetHost() string {
 	return c.cluster.GetHost()
 }
 
-func (c *delegatingCacheCluster) makeCachingClient(leafClient client.Client) (client.Client, error) {
-	mapper, err := apiutil.NewDiscoveryRESTMapper(c.restConfig)
+func (c *delegatingCacheCluster) GetLabels() map[string]string {
+	return c.cluster.GetLabels()
+}
+
+// ClusterCache holds the informers of a cluster. It's kept while the cluster
+// is, so the clusters created each time it's fetched share the informers.
+type ClusterCache struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	scheme *runtime.Scheme
+
+	mu    sync.Mutex
+	cache cache.Cache
+}
+
+// NewClusterCache creates the cache of a cluster, its informers run until
+// it's closed.
+func NewClusterCache(scheme *runtime.Scheme) *ClusterCache {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ClusterCache{
+		ctx:    ctx,
+		cancel: cancel,
+		scheme: scheme,
+	}
+}
+
+// Close stops the informers of the cache, it can't be used afterwards.
+func (c *ClusterCache) Close() {
+	c.cancel()
+}
+
+// get starts the cache on first use. Its informers are only started for the
+// kinds read from it.
+func (c *ClusterCache) get(config *rest.Config) (cache.Cache, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cache != nil {
+		return c.cache, nil
+	}
+
+	if c.ctx.Err() != nil {
+		return nil, errors.New("client cache is stopped")
+	}
+
+	mapper, err := apiutil.NewDynamicRESTMapper(config)
 	if err != nil {
 		return nil, fmt.Errorf("could not create RESTMapper from config: %w", err)
 	}
 
-	cache, err := cache.New(c.restConfig, cache.Options{
+	clientCache, err := cache.New(config, cache.Options{
 		Scheme: c.scheme,
 		Mapper: mapper,
 	})
@@ -54,30 +128,51 @@ func (c *delegatingCacheCluster) makeCachingClient(leafClient client.Client) (cl
 		return nil, fmt.Errorf("failed creating client cache: %w", err)
 	}
 
-	delegatingCache := newDelegatingCache(leafClient, cache, c.scheme)
+	go clientCache.Start(c.ctx) //nolint:errcheck
 
-	delegatingClient, err := client.NewDelegatingClient(client.NewDelegatingClientInput{
-		CacheReader: delegatingCache,
-		Client:      leafClient,
-		// Non-exact field matches are not supported by the cache.
-		// https://github.com/kubernetes-sigs/controller-runtime/issues/612
-		// TODO: Research if we can change the way we query those events so we can enable the cache for it.
-		UncachedObjects:   []client.Object{&v1.Event{}},
-		CacheUnstructured: true,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed creating DelegatingClient: %w", err)
+	if ok := clientCache.WaitForCacheSync(c.ctx); !ok {
+		return nil, errors.New("failed syncing client cache")
 	}
 
-	ctx := context.Background()
+	c.cache = clientCache
 
-	go delegatingCache.Start(ctx) //nolint:errcheck
+	return clientCache, nil
+}
 
-	if ok := delegatingCache.WaitForCacheSync(ctx); !ok {
-		return nil, errors.New("failed syncing client cache")
+// Close stops the informers of the cache of the cluster.
+func (c *delegatingCacheCluster) Close() {
+	c.cache.Close()
+}
+
+func (c *delegatingCacheCluster) getCache() (cache.Cache, error) {
+	config := c.restConfig
+	if config == nil {
+		var err error
+
+		if config, err = c.cluster.GetServerConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.cache.get(config)
+}
+
+func (c *delegatingCacheCluster) makeCachingClient(leafClient client.Client) (client.Client, error) {
+	if len(c.cachedKinds) == 0 {
+		return leafClient, nil
 	}
 
-	return delegatingClient, nil
+	clientCache, err := c.getCache()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachingClient{
+		Client:      leafClient,
+		cache:       newDelegatingCache(leafClient, clientCache, c.cache.scheme),
+		scheme:      c.cache.scheme,
+		cachedKinds: c.cachedKinds,
+	}, nil
 }
 
 func (c *delegatingCacheCluster) GetUserClient(user *auth.UserPrincipal) (client.Client, error) {
@@ -110,23 +205,76 @@ func (c *delegatingCacheCluster) GetServerConfig() (*rest.Config, error) {
 	return c.cluster.GetServerConfig()
 }
 
+// cachingClient reads the cached kinds from the cache, and everything else
+// from the cluster.
+type cachingClient struct {
+	client.Client
+
+	cache       client.Reader
+	scheme      *runtime.Scheme
+	cachedKinds map[schema.GroupKind]bool
+}
+
+func (c *cachingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if !c.isCached(obj) {
+		return c.Client.Get(ctx, key, obj, opts...)
+	}
+
+	return c.cache.Get(ctx, key, obj, opts...)
+}
+
+func (c *cachingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	// the cache has no continue tokens, and only matches the fields it
+	// indexed
+	if !c.isCached(list) || listOpts.Limit > 0 || listOpts.Continue != "" || listOpts.FieldSelector != nil {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	return c.cache.List(ctx, list, opts...)
+}
+
+// Watch watches the cluster, the cache would only know about the cached kinds.
+func (c *cachingClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	return Watch(ctx, c.Client, list, opts...)
+}
+
+func (c *cachingClient) isCached(obj runtime.Object) bool {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return false
+	}
+
+	// lists of unstructured objects may be set the kind of their items
+	return c.cachedKinds[schema.GroupKind{Group: gvk.Group, Kind: strings.TrimSuffix(gvk.Kind, "List")}]
+}
+
+// delegatingCache reads from the cache once the reader, i.e. the client of a
+// user, was allowed to read the same kind in the same namespace.
 type delegatingCache struct {
 	cache.Cache
 
 	Client client.Reader
 
-	scheme        *runtime.Scheme
-	checkedGVKs   map[schema.GroupVersionKind]struct{}
-	checkedGVKsMu *sync.Mutex
+	scheme    *runtime.Scheme
+	checked   map[checkedRead]struct{}
+	checkedMu *sync.Mutex
+}
+
+type checkedRead struct {
+	gvk       schema.GroupVersionKind
+	namespace string
 }
 
 func newDelegatingCache(cr client.Reader, cache cache.Cache, scheme *runtime.Scheme) cache.Cache {
 	dc := delegatingCache{
-		Cache:         cache,
-		Client:        cr,
-		scheme:        scheme,
-		checkedGVKs:   map[schema.GroupVersionKind]struct{}{},
-		checkedGVKsMu: &sync.Mutex{},
+		Cache:     cache,
+		Client:    cr,
+		scheme:    scheme,
+		checked:   map[checkedRead]struct{}{},
+		checkedMu: &sync.Mutex{},
 	}
 
 	return dc
@@ -138,7 +286,9 @@ func (dc delegatingCache) Get(ctx context.Context, key client.ObjectKey, obj cli
 		return err
 	}
 
-	bypass := dc.shouldBypassCheck(obj, gvk)
+	read := checkedRead{gvk: gvk, namespace: key.Namespace}
+
+	bypass := dc.shouldBypassCheck(read)
 	if !bypass {
 		partial := &metav1.PartialObjectMetadata{}
 		partial.SetGroupVersionKind(gvk)
@@ -147,7 +297,7 @@ func (dc delegatingCache) Get(ctx context.Context, key client.ObjectKey, obj cli
 			return err
 		}
 
-		dc.markGVKChecked(gvk)
+		dc.markChecked(read)
 	}
 
 	return dc.Cache.Get(ctx, key, obj)
@@ -159,7 +309,12 @@ func (dc delegatingCache) List(ctx context.Context, list client.ObjectList, opts
 		return err
 	}
 
-	bypass := dc.shouldBypassCheck(list, gvk)
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	read := checkedRead{gvk: gvk, namespace: listOpts.Namespace}
+
+	bypass := dc.shouldBypassCheck(read)
 	if !bypass {
 		partial := &metav1.PartialObjectMetadataList{}
 		partial.SetGroupVersionKind(gvk)
@@ -168,25 +323,23 @@ func (dc delegatingCache) List(ctx context.Context, list client.ObjectList, opts
 			return err
 		}
 
-		dc.markGVKChecked(gvk)
+		dc.markChecked(read)
 	}
 
 	return dc.Cache.List(ctx, list, opts...)
 }
 
-func (dc *delegatingCache) shouldBypassCheck(obj runtime.Object, gvk schema.GroupVersionKind) bool {
-	dc.checkedGVKsMu.Lock()
-	defer dc.checkedGVKsMu.Unlock()
+func (dc *delegatingCache) shouldBypassCheck(read checkedRead) bool {
+	dc.checkedMu.Lock()
+	defer dc.checkedMu.Unlock()
 
-	if _, isCheckedGVK := dc.checkedGVKs[gvk]; isCheckedGVK {
-		return true
-	}
+	_, isChecked := dc.checked[read]
 
-	return false
+	return isChecked
 }
 
-func (dc *delegatingCache) markGVKChecked(gvk schema.GroupVersionKind) {
-	dc.checkedGVKsMu.Lock()
-	defer dc.checkedGVKsMu.Unlock()
-	dc.checkedGVKs[gvk] = struct{}{}
+func (dc *delegatingCache) markChecked(read checkedRead) {
+	dc.checkedMu.Lock()
+	defer dc.checkedMu.Unlock()
+	dc.checked[read] = struct{}{}
 }
@@ -8,10 +8,13 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestDelegatingCacheGet(t *testing.T) {
@@ -86,6 +89,42 @@ func TestDelegatingCacheList(t *testing.T) {
 	g.Expect(fakeReader.Called).To(Equal(1))
 }
 
+func TestCachingClientReadsCachedKindsFromCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	ns := &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "flux-system"}}
+	cm := &corev1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "config", Namespace: "flux-system"}}
+
+	cacheReader := &fakeReader{}
+	c := &cachingClient{
+		Client:      fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(ns, cm).Build(),
+		cache:       cacheReader,
+		scheme:      scheme.Scheme,
+		cachedKinds: map[schema.GroupKind]bool{{Kind: "Namespace"}: true},
+	}
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(ns), &corev1.Namespace{})).To(Succeed())
+	g.Expect(c.List(ctx, &corev1.NamespaceList{})).To(Succeed())
+	g.Expect(cacheReader.Called).To(Equal(2))
+
+	cms := &corev1.ConfigMapList{}
+	g.Expect(c.List(ctx, cms, client.InNamespace("flux-system"))).To(Succeed())
+	g.Expect(cms.Items).To(HaveLen(1))
+	g.Expect(cacheReader.Called).To(Equal(2))
+
+	nsList := &corev1.NamespaceList{}
+	g.Expect(c.List(ctx, nsList, client.Limit(10))).To(Succeed())
+	g.Expect(nsList.Items).To(HaveLen(1))
+	g.Expect(cacheReader.Called).To(Equal(2))
+
+	unstructuredList := &unstructured.UnstructuredList{}
+	unstructuredList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	g.Expect(c.List(ctx, unstructuredList)).To(Succeed())
+	g.Expect(cacheReader.Called).To(Equal(3))
+}
+
 type fakeReader struct {
 	Called int
 }
@@ -0,0 +1,20 @@
+package cluster
+
+type identifiedCluster struct {
+	Cluster
+	id string
+}
+
+// WithID sets the stable ID of a cluster, e.g. the UID of the object it was
+// registered with, so the cluster is still recognised once renamed.
+func WithID(cl Cluster, id string) Cluster {
+	if id == "" {
+		return cl
+	}
+
+	return &identifiedCluster{Cluster: cl, id: id}
+}
+
+func (c *identifiedCluster) GetID() string {
+	return c.id
+}
@@ -0,0 +1,20 @@
+package cluster
+
+type labelledCluster struct {
+	Cluster
+	labels map[string]string
+}
+
+// WithLabels sets the labels of a cluster, e.g. from the object it was
+// registered with.
+func WithLabels(cl Cluster, labels map[string]string) Cluster {
+	if len(labels) == 0 {
+		return cl
+	}
+
+	return &labelledCluster{Cluster: cl, labels: labels}
+}
+
+func (c *labelledCluster) GetLabels() map[string]string {
+	return c.labels
+}
@@ -47,17 +47,26 @@ func (c *singleCluster) GetName() string {
 	return c.name
 }
 
+func (c *singleCluster) GetID() string {
+	return c.name
+}
+
 func (c *singleCluster) GetHost() string {
 	return c.restConfig.Host
 }
 
+func (c *singleCluster) GetLabels() map[string]string {
+	return nil
+}
+
 func getClientFromConfig(config *rest.Config, scheme *apiruntime.Scheme) (client.Client, error) {
 	mapper, err := apiutil.NewDiscoveryRESTMapper(config)
 	if err != nil {
 		return nil, fmt.Errorf("could not create RESTMapper from config: %w", err)
 	}
 
-	client, err := client.New(config, client.Options{
+	// watches of the core API go through the leaf clients
+	client, err := client.NewWithWatch(config, client.Options{
 		Scheme: scheme,
 		Mapper: mapper,
 	})
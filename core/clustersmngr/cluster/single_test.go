@@ -3,6 +3,7 @@ package cluster
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
@@ -27,6 +28,25 @@ func TestSingleCluster(t *testing.T) {
 	g.Expect(cluster.(*singleCluster).restConfig.BearerToken).To(Equal(config.BearerToken))
 }
 
+func TestSingleClusterClientConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := &rest.Config{Host: "my-host", QPS: ClientQPS, Burst: ClientBurst}
+
+	cluster, err := NewSingleCluster("Default", config, nil, WithClientConfig(ClientConfig{
+		QPS:       50,
+		Timeout:   time.Minute,
+		UserAgent: "weave-gitops",
+	}))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	restConfig := cluster.(*singleCluster).restConfig
+	g.Expect(restConfig.QPS).To(Equal(float32(50)))
+	g.Expect(restConfig.Burst).To(Equal(ClientBurst))
+	g.Expect(restConfig.Timeout).To(Equal(time.Minute))
+	g.Expect(restConfig.UserAgent).To(Equal("weave-gitops"))
+}
+
 func TestClientConfigWithUser(t *testing.T) {
 	var k8sEnv *testutils.K8sTestEnv
 
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"k8s.io/client-go/rest"
+)
+
+// TLSConfig overrides how the API server of a cluster is connected to, for
+// clusters whose kubeconfig can't be changed, e.g. the ones written by
+// Cluster API. Fields left empty keep what the kubeconfig sets.
+type TLSConfig struct {
+	// CAData is a PEM bundle trusted on top of the kubeconfig's CA, for API
+	// servers using a private CA.
+	CAData []byte
+	// ServerName is sent as SNI and checked against the server certificate,
+	// for API servers behind a proxy or load balancer.
+	ServerName string
+	// ProxyURL is the http, https or socks5 proxy to connect through.
+	ProxyURL string
+}
+
+// Apply sets the overrides on config.
+func (t TLSConfig) Apply(config *rest.Config) error {
+	if len(t.CAData) > 0 {
+		if !x509.NewCertPool().AppendCertsFromPEM(t.CAData) {
+			return errors.New("no valid certificate in CA bundle")
+		}
+
+		caData := config.CAData
+		if len(caData) == 0 && config.CAFile != "" {
+			data, err := os.ReadFile(config.CAFile)
+			if err != nil {
+				return fmt.Errorf("could not read CA file: %w", err)
+			}
+
+			caData = data
+		}
+
+		// the kubeconfig's CA is merged in as CAData takes precedence over
+		// CAFile
+		config.CAData = append(append(append([]byte{}, caData...), '\n'), t.CAData...)
+		config.CAFile = ""
+	}
+
+	if t.ServerName != "" {
+		config.ServerName = t.ServerName
+	}
+
+	if t.ProxyURL != "" {
+		u, err := url.Parse(t.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+		}
+
+		config.Proxy = http.ProxyURL(u)
+	}
+
+	return nil
+}
+
+// WithCABundle trusts a PEM bundle on top of the CA of each cluster. Clusters
+// that don't set a CA then only trust the bundle, not the system roots.
+func WithCABundle(caData []byte) KubeConfigOption {
+	return func(config *rest.Config) (*rest.Config, error) {
+		// client-go refuses a CA along with skipping verification
+		if config.Insecure {
+			return config, nil
+		}
+
+		if err := (TLSConfig{CAData: caData}).Apply(config); err != nil {
+			return nil, err
+		}
+
+		return config, nil
+	}
+}
+
+// RejectInsecure refuses clusters that skip verifying the server
+// certificate, which would let their credentials be intercepted. It must
+// come before the options that connect to the cluster.
+func RejectInsecure(config *rest.Config) (*rest.Config, error) {
+	if config.Insecure {
+		return nil, errors.New("cluster skips TLS verification, which isn't allowed")
+	}
+
+	return config, nil
+}
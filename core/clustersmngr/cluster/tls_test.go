@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/tls"
+	"k8s.io/client-go/rest"
+)
+
+func TestTLSConfigApply(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeconfigCA, err := tls.GenerateSelfSignedCertificate("kubeconfig-ca")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	privateCA, err := tls.GenerateSelfSignedCertificate("private-ca")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Run("merges the CA bundle with the kubeconfig's CA file", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		caFile := filepath.Join(t.TempDir(), "ca.crt")
+		g.Expect(os.WriteFile(caFile, kubeconfigCA.Cert, 0o600)).To(Succeed())
+
+		config := &rest.Config{Host: "https://leaf:6443"}
+		config.CAFile = caFile
+
+		g.Expect(TLSConfig{CAData: privateCA.Cert}.Apply(config)).To(Succeed())
+		g.Expect(config.CAFile).To(BeEmpty())
+		g.Expect(string(config.CAData)).To(ContainSubstring(string(kubeconfigCA.Cert)))
+		g.Expect(string(config.CAData)).To(ContainSubstring(string(privateCA.Cert)))
+	})
+
+	t.Run("sets the server name and proxy", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		config := &rest.Config{Host: "https://leaf:6443"}
+
+		g.Expect(TLSConfig{ServerName: "api.leaf", ProxyURL: "socks5://proxy:1080"}.Apply(config)).To(Succeed())
+		g.Expect(config.ServerName).To(Equal("api.leaf"))
+
+		req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		proxy, err := config.Proxy(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(proxy.String()).To(Equal("socks5://proxy:1080"))
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(TLSConfig{CAData: []byte("not a certificate")}.Apply(&rest.Config{})).To(MatchError(ContainSubstring("no valid certificate")))
+		g.Expect(TLSConfig{ProxyURL: "ftp://proxy"}.Apply(&rest.Config{})).To(MatchError(ContainSubstring("unsupported proxy scheme")))
+	})
+}
+
+func TestRejectInsecure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := &rest.Config{Host: "https://leaf:6443"}
+
+	_, err := RejectInsecure(config)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	config.Insecure = true
+
+	_, err = RejectInsecure(config)
+	g.Expect(err).To(HaveOccurred())
+
+	config, err = WithCABundle([]byte("ignored"))(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.CAData).To(BeEmpty())
+}
@@ -0,0 +1,336 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/core/logger"
+	"golang.org/x/oauth2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+const (
+	// How often tokens are checked for renewal.
+	tokenRotationPeriod = time.Minute
+	// Tokens of clusters that haven't been fetched again for this long are
+	// assumed to be gone and stop being renewed.
+	tokenStaleAfter = 24 * time.Hour
+)
+
+// TokenRotator renews the service account tokens leaf clusters are connected
+// with before they expire, with the TokenRequest API of each cluster, so
+// kubeconfigs can hold short-lived tokens. Tokens without an expiry, e.g.
+// from legacy service account secrets, are left alone. Renewed tokens are
+// written back with the TokenStore, if any, otherwise they're only kept in
+// memory and the kubeconfig's token must still be valid when the server
+// starts.
+//
+// The service account must be allowed to create tokens for itself
+// (serviceaccounts/token).
+type TokenRotator struct {
+	log      logr.Logger
+	lifetime time.Duration
+	now      func() time.Time
+	store    TokenStore
+
+	lock   sync.Mutex
+	tokens map[string]*rotatingToken
+}
+
+// NewTokenRotator creates a TokenRotator requesting tokens valid for
+// lifetime.
+func NewTokenRotator(log logr.Logger, lifetime time.Duration) *TokenRotator {
+	return &TokenRotator{
+		log:      log.WithName("token-rotator"),
+		lifetime: lifetime,
+		now:      time.Now,
+		tokens:   map[string]*rotatingToken{},
+	}
+}
+
+// TokenStore writes renewed tokens back to the kubeconfigs they were read
+// from.
+type TokenStore interface {
+	// UpdateToken replaces oldToken with newToken in the kubeconfigs holding
+	// it.
+	UpdateToken(ctx context.Context, oldToken, newToken string) error
+}
+
+// SetStore has renewed tokens written back to store, so they survive a
+// restart of the server. It must be called before Start.
+func (r *TokenRotator) SetStore(store TokenStore) {
+	r.store = store
+}
+
+// WithTokenRotation has the tokens of clusters renewed by r. It must come
+// before the options that connect to the cluster.
+func WithTokenRotation(r *TokenRotator) KubeConfigOption {
+	return func(config *rest.Config) (*rest.Config, error) {
+		claims, ok := parseServiceAccountToken(config.BearerToken)
+		if !ok {
+			return config, nil
+		}
+
+		// clusters are created again every time they're fetched, from the
+		// same kubeconfig, so they pick up the token renewed so far
+		key := tokenKey(config.Host, config.BearerToken)
+
+		r.lock.Lock()
+
+		t, found := r.tokens[key]
+		if !found {
+			t = newRotatingToken(config, claims)
+			r.tokens[key] = t
+		}
+
+		t.seen(r.now())
+
+		r.lock.Unlock()
+
+		config.BearerToken = ""
+		config.BearerTokenFile = ""
+		config.Wrap(transport.TokenSourceWrapTransport(t))
+
+		return config, nil
+	}
+}
+
+// Start renews tokens in the background until ctx is done.
+func (r *TokenRotator) Start(ctx context.Context) {
+	go wait.UntilWithContext(ctx, r.Rotate, tokenRotationPeriod)
+}
+
+// Rotate renews the tokens that reached 80% of their lifetime.
+func (r *TokenRotator) Rotate(ctx context.Context) {
+	now := r.now()
+
+	r.lock.Lock()
+
+	tokens := map[string]*rotatingToken{}
+
+	for key, t := range r.tokens {
+		if now.Sub(t.lastSeen()) > tokenStaleAfter {
+			delete(r.tokens, key)
+			continue
+		}
+
+		tokens[key] = t
+	}
+
+	r.lock.Unlock()
+
+	for key, t := range tokens {
+		if now.Before(t.renewAt()) {
+			continue
+		}
+
+		log := r.log.WithValues("host", t.config.Host, "namespace", t.namespace, "serviceAccount", t.name)
+
+		previous, _ := t.Token()
+
+		if err := t.renew(ctx, r.lifetime, now); err != nil {
+			log.Error(err, "failed to renew service account token", "expires", t.expiresAt())
+			continue
+		}
+
+		log.V(logger.LogLevelDebug).Info("Renewed service account token", "expires", t.expiresAt())
+
+		if r.store == nil {
+			continue
+		}
+
+		if err := r.persist(ctx, key, t, previous.AccessToken); err != nil {
+			log.Error(err, "failed to store renewed service account token")
+		}
+	}
+}
+
+// persist writes the renewed token of t back to its kubeconfig. Clusters
+// are created again from the updated kubeconfig, so t is keyed by the new
+// token from then on.
+func (r *TokenRotator) persist(ctx context.Context, key string, t *rotatingToken, previous string) error {
+	current, _ := t.Token()
+
+	if err := r.store.UpdateToken(ctx, previous, current.AccessToken); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.tokens[key] == t {
+		delete(r.tokens, key)
+		r.tokens[tokenKey(t.config.Host, current.AccessToken)] = t
+	}
+
+	return nil
+}
+
+func tokenKey(host, token string) string {
+	sum := sha256.Sum256([]byte(host + "\x00" + token))
+	return string(sum[:])
+}
+
+type serviceAccountClaims struct {
+	Audience   audiences `json:"aud"`
+	IssuedAt   int64     `json:"iat"`
+	Expires    int64     `json:"exp"`
+	Kubernetes struct {
+		Namespace      string `json:"namespace"`
+		ServiceAccount struct {
+			Name string `json:"name"`
+		} `json:"serviceaccount"`
+	} `json:"kubernetes.io"`
+}
+
+// audiences are either a string or a list of strings in JWTs.
+type audiences []string
+
+func (a *audiences) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audiences{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// parseServiceAccountToken returns the claims of a bound service account
+// token that expires. The signature isn't checked, the cluster does that.
+func parseServiceAccountToken(token string) (serviceAccountClaims, bool) {
+	claims := serviceAccountClaims{}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, false
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+
+	ok := claims.Expires > 0 && claims.Kubernetes.Namespace != "" && claims.Kubernetes.ServiceAccount.Name != ""
+
+	return claims, ok
+}
+
+// rotatingToken is the current token of a service account on a cluster, and
+// the source of the tokens of its clients.
+type rotatingToken struct {
+	config    *rest.Config
+	namespace string
+	name      string
+	audiences []string
+
+	lock     sync.Mutex
+	token    string
+	issued   time.Time
+	expires  time.Time
+	lastUsed time.Time
+}
+
+func newRotatingToken(config *rest.Config, claims serviceAccountClaims) *rotatingToken {
+	issued := time.Unix(claims.IssuedAt, 0)
+	if claims.IssuedAt == 0 {
+		issued = time.Now()
+	}
+
+	return &rotatingToken{
+		config:    rest.CopyConfig(config),
+		namespace: claims.Kubernetes.Namespace,
+		name:      claims.Kubernetes.ServiceAccount.Name,
+		audiences: claims.Audience,
+		token:     config.BearerToken,
+		issued:    issued,
+		expires:   time.Unix(claims.Expires, 0),
+	}
+}
+
+// Token returns the current token, clients read it on every request so
+// they pick up renewed tokens.
+func (t *rotatingToken) Token() (*oauth2.Token, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return &oauth2.Token{AccessToken: t.token}, nil
+}
+
+func (t *rotatingToken) seen(now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.lastUsed = now
+}
+
+func (t *rotatingToken) lastSeen() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.lastUsed
+}
+
+func (t *rotatingToken) renewAt() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.issued.Add(t.expires.Sub(t.issued) * 4 / 5)
+}
+
+func (t *rotatingToken) expiresAt() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.expires
+}
+
+// renew requests a new token with the current one.
+func (t *rotatingToken) renew(ctx context.Context, lifetime time.Duration, now time.Time) error {
+	current, _ := t.Token()
+
+	config := rest.CopyConfig(t.config)
+	config.BearerToken = current.AccessToken
+
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("making clientset: %w", err)
+	}
+
+	expirationSeconds := int64(lifetime.Seconds())
+
+	tr, err := cs.CoreV1().ServiceAccounts(t.namespace).CreateToken(ctx, t.name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         t.audiences,
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.token = tr.Status.Token
+	t.issued = now
+	t.expires = tr.Status.ExpirationTimestamp.Time
+
+	return nil
+}
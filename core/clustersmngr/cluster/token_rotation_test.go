@@ -0,0 +1,176 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func serviceAccountToken(g *WithT, issued, expires time.Time) string {
+	payload, err := json.Marshal(map[string]interface{}{
+		"aud": []string{"https://kubernetes.default.svc"},
+		"iat": issued.Unix(),
+		"exp": expires.Unix(),
+		"kubernetes.io": map[string]interface{}{
+			"namespace":      "weave-gitops",
+			"serviceaccount": map[string]string{"name": "dashboard"},
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return "header." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func TestTokenRotator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Now()
+	initial := serviceAccountToken(g, now.Add(-50*time.Minute), now.Add(10*time.Minute))
+
+	var requests []authenticationv1.TokenRequest
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/api/v1/namespaces/weave-gitops/serviceaccounts/dashboard/token"))
+		g.Expect(r.Header.Get("Authorization")).To(Equal("Bearer " + initial))
+
+		tr := authenticationv1.TokenRequest{}
+		g.Expect(json.NewDecoder(r.Body).Decode(&tr)).To(Succeed())
+		requests = append(requests, tr)
+
+		tr.Status = authenticationv1.TokenRequestStatus{
+			Token:               "renewed",
+			ExpirationTimestamp: metav1.NewTime(now.Add(time.Hour)),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		g.Expect(json.NewEncoder(w).Encode(tr)).To(Succeed())
+	}))
+	defer apiServer.Close()
+
+	rotator := NewTokenRotator(logr.Discard(), time.Hour)
+	rotator.now = func() time.Time { return now }
+
+	newConfig := func() *rest.Config {
+		config, err := WithTokenRotation(rotator)(&rest.Config{Host: apiServer.URL, BearerToken: initial})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(config.BearerToken).To(BeEmpty())
+
+		return config
+	}
+
+	tokenOf := func(config *rest.Config) string {
+		var authorization string
+
+		rt := config.WrapTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}))
+
+		req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		_, err = rt.RoundTrip(req)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return authorization
+	}
+
+	config := newConfig()
+	g.Expect(tokenOf(config)).To(Equal("Bearer " + initial))
+
+	rotator.Rotate(context.Background())
+	g.Expect(requests).To(HaveLen(1))
+	g.Expect(*requests[0].Spec.ExpirationSeconds).To(Equal(int64(3600)))
+	g.Expect(requests[0].Spec.Audiences).To(Equal([]string{"https://kubernetes.default.svc"}))
+
+	// clients already created and clusters fetched again both use it
+	g.Expect(tokenOf(config)).To(Equal("Bearer renewed"))
+	g.Expect(tokenOf(newConfig())).To(Equal("Bearer renewed"))
+
+	// not renewed again until 80% of its lifetime
+	rotator.Rotate(context.Background())
+	g.Expect(requests).To(HaveLen(1))
+
+	t.Run("stale tokens are dropped", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rotator.now = func() time.Time { return now.Add(tokenStaleAfter + time.Minute) }
+		rotator.Rotate(context.Background())
+
+		g.Expect(rotator.tokens).To(BeEmpty())
+	})
+}
+
+func TestTokenRotatorSkipsOtherTokens(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rotator := NewTokenRotator(logr.Discard(), time.Hour)
+
+	for _, token := range []string{"", "static-token", serviceAccountToken(g, time.Now(), time.Unix(0, 0))} {
+		config, err := WithTokenRotation(rotator)(&rest.Config{Host: "https://leaf:6443", BearerToken: token})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(config.BearerToken).To(Equal(token))
+	}
+
+	g.Expect(rotator.tokens).To(BeEmpty())
+}
+
+type tokenStoreFunc func(ctx context.Context, oldToken, newToken string) error
+
+func (f tokenStoreFunc) UpdateToken(ctx context.Context, oldToken, newToken string) error {
+	return f(ctx, oldToken, newToken)
+}
+
+func TestTokenRotatorStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Now()
+	initial := serviceAccountToken(g, now.Add(-50*time.Minute), now.Add(10*time.Minute))
+	renewed := serviceAccountToken(g, now, now.Add(time.Hour))
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr := authenticationv1.TokenRequest{}
+		g.Expect(json.NewDecoder(r.Body).Decode(&tr)).To(Succeed())
+
+		tr.Status = authenticationv1.TokenRequestStatus{
+			Token:               renewed,
+			ExpirationTimestamp: metav1.NewTime(now.Add(time.Hour)),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		g.Expect(json.NewEncoder(w).Encode(tr)).To(Succeed())
+	}))
+	defer apiServer.Close()
+
+	stored := map[string]string{}
+
+	rotator := NewTokenRotator(logr.Discard(), time.Hour)
+	rotator.now = func() time.Time { return now }
+	rotator.SetStore(tokenStoreFunc(func(ctx context.Context, oldToken, newToken string) error {
+		stored[oldToken] = newToken
+		return nil
+	}))
+
+	_, err := WithTokenRotation(rotator)(&rest.Config{Host: apiServer.URL, BearerToken: initial})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	rotator.Rotate(context.Background())
+	g.Expect(stored).To(Equal(map[string]string{initial: renewed}))
+
+	// clusters created again from the updated kubeconfig keep the same
+	// token instead of renewing it separately
+	_, err = WithTokenRotation(rotator)(&rest.Config{Host: apiServer.URL, BearerToken: renewed})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotator.tokens).To(HaveLen(1))
+	g.Expect(rotator.tokens).To(HaveKey(tokenKey(apiServer.URL, renewed)))
+}
@@ -0,0 +1,25 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrWatchNotSupported is returned when watching with a client that can't
+// watch.
+var ErrWatchNotSupported = errors.New("client doesn't support watches")
+
+// Watch watches the objects of the list with the client, if it can watch.
+// Clients wrapping another client implement client.WithWatch with it, so
+// watches go through all of them.
+func Watch(ctx context.Context, c client.Client, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	wc, ok := c.(client.WithWatch)
+	if !ok {
+		return nil, ErrWatchNotSupported
+	}
+
+	return wc.Watch(ctx, list, opts...)
+}
@@ -3,6 +3,7 @@ package clustersmngr
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
@@ -21,27 +22,17 @@ const (
 // ClusterNotFoundError cluster client can be found in the pool
 type ClusterNotFoundError struct {
 	Cluster string
+	// KnownClusters are the names of the clusters that could have been
+	// picked instead, if known.
+	KnownClusters []string
 }
 
 func (e ClusterNotFoundError) Error() string {
-	return fmt.Sprintf("cluster=%s not found", e.Cluster)
-}
-
-type clusterFetchers []ClusterFetcher
-
-func (fetchers clusterFetchers) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
-	clusters := []cluster.Cluster{}
-
-	for _, fetcher := range fetchers {
-		additionalClusters, err := fetcher.Fetch(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		clusters = append(clusters, additionalClusters...)
+	if len(e.KnownClusters) == 0 {
+		return fmt.Sprintf("cluster=%s not found", e.Cluster)
 	}
 
-	return clusters, nil
+	return fmt.Sprintf("cluster=%s not found, known clusters: %s", e.Cluster, strings.Join(e.KnownClusters, ", "))
 }
 
 // ClusterFetcher fetches all leaf clusters
@@ -51,6 +42,15 @@ type ClusterFetcher interface {
 	Fetch(ctx context.Context) ([]cluster.Cluster, error)
 }
 
+// WatchingClusterFetcher is a ClusterFetcher that can tell when its clusters
+// changed, so they're updated without waiting for the next resync.
+type WatchingClusterFetcher interface {
+	ClusterFetcher
+	// Watch calls changed whenever the clusters may have changed, until the
+	// context is cancelled.
+	Watch(ctx context.Context, changed func())
+}
+
 // ClientsPool stores all clients to the leaf clusters
 //
 //counterfeiter:generate . ClientsPool
@@ -95,3 +95,106 @@ func (cp *clientsPool) Client(name string) (client.Client, error) {
 
 	return nil, ClusterNotFoundError{Cluster: name}
 }
+
+// lazyClientsPool creates the client of a cluster the first time it's used,
+// so requests that only query one cluster don't create clients for all of
+// them.
+type lazyClientsPool struct {
+	clients   map[string]*lazyClient
+	newClient func(cluster.Cluster) (client.Client, error)
+	workers   int
+	mutex     sync.Mutex
+}
+
+type lazyClient struct {
+	once    sync.Once
+	cluster cluster.Cluster
+	client  client.Client
+	err     error
+}
+
+// newLazyClientsPool returns a pool of the clients of clusters, created with
+// newClient on first use. Clients() creates the clients that weren't yet, at
+// most workers at once.
+func newLazyClientsPool(clusters []cluster.Cluster, newClient func(cluster.Cluster) (client.Client, error), workers int) ClientsPool {
+	clients := make(map[string]*lazyClient, len(clusters))
+	for _, cl := range clusters {
+		clients[cl.GetName()] = &lazyClient{cluster: cl}
+	}
+
+	return &lazyClientsPool{
+		clients:   clients,
+		newClient: newClient,
+		workers:   workers,
+	}
+}
+
+// Add adds a cluster client to the pool, replacing the one that would have
+// been created.
+func (cp *lazyClientsPool) Add(c client.Client, cl cluster.Cluster) error {
+	lc := &lazyClient{cluster: cl}
+	lc.once.Do(func() { lc.client = c })
+
+	cp.mutex.Lock()
+	cp.clients[cl.GetName()] = lc
+	cp.mutex.Unlock()
+
+	return nil
+}
+
+// Clients returns the clients of the clusters, creating them if needed.
+// Clusters whose client can't be created are left out.
+func (cp *lazyClientsPool) Clients() map[string]client.Client {
+	cp.mutex.Lock()
+	lazyClients := make([]*lazyClient, 0, len(cp.clients))
+
+	for _, lc := range cp.clients {
+		lazyClients = append(lazyClients, lc)
+	}
+	cp.mutex.Unlock()
+
+	wg := sync.WaitGroup{}
+	workers := newWorkerPool(cp.workers)
+
+	for _, lc := range lazyClients {
+		lc := lc
+		workers.Go(&wg, func() { cp.create(lc) })
+	}
+
+	wg.Wait()
+
+	clients := map[string]client.Client{}
+
+	for _, lc := range lazyClients {
+		if lc.err == nil && lc.client != nil {
+			clients[lc.cluster.GetName()] = lc.client
+		}
+	}
+
+	return clients
+}
+
+// Client returns the client of the cluster, creating it on first use.
+func (cp *lazyClientsPool) Client(name string) (client.Client, error) {
+	cp.mutex.Lock()
+	lc, found := cp.clients[name]
+	cp.mutex.Unlock()
+
+	if !found {
+		return nil, ClusterNotFoundError{Cluster: name}
+	}
+
+	cp.create(lc)
+
+	if lc.err != nil {
+		return nil, lc.err
+	}
+
+	return lc.client, nil
+}
+
+func (cp *lazyClientsPool) create(lc *lazyClient) {
+	lc.once.Do(func() {
+		lc.client, lc.err = cp.newClient(lc.cluster)
+	})
+}
@@ -13,9 +13,36 @@ import (
 )
 
 type FakeClustersManager struct {
-	GetClustersStub        func() []cluster.Cluster
+	GetClusterStub        func(string) (cluster.Cluster, error)
+	getClusterMutex       sync.RWMutex
+	getClusterArgsForCall []struct {
+		arg1 string
+	}
+	getClusterReturns struct {
+		result1 cluster.Cluster
+		result2 error
+	}
+	getClusterReturnsOnCall map[int]struct {
+		result1 cluster.Cluster
+		result2 error
+	}
+	GetClusterStatusStub        func(string) (clustersmngr.ClusterStatus, bool)
+	getClusterStatusMutex       sync.RWMutex
+	getClusterStatusArgsForCall []struct {
+		arg1 string
+	}
+	getClusterStatusReturns struct {
+		result1 clustersmngr.ClusterStatus
+		result2 bool
+	}
+	getClusterStatusReturnsOnCall map[int]struct {
+		result1 clustersmngr.ClusterStatus
+		result2 bool
+	}
+	GetClustersStub        func(...clustersmngr.GetClustersOption) []cluster.Cluster
 	getClustersMutex       sync.RWMutex
 	getClustersArgsForCall []struct {
+		arg1 []clustersmngr.GetClustersOption
 	}
 	getClustersReturns struct {
 		result1 []cluster.Cluster
@@ -33,11 +60,22 @@ type FakeClustersManager struct {
 	getClustersNamespacesReturnsOnCall map[int]struct {
 		result1 map[string][]v1.Namespace
 	}
-	GetImpersonatedClientStub        func(context.Context, *auth.UserPrincipal) (clustersmngr.Client, error)
+	GetHealthStub        func() clustersmngr.Health
+	getHealthMutex       sync.RWMutex
+	getHealthArgsForCall []struct {
+	}
+	getHealthReturns struct {
+		result1 clustersmngr.Health
+	}
+	getHealthReturnsOnCall map[int]struct {
+		result1 clustersmngr.Health
+	}
+	GetImpersonatedClientStub        func(context.Context, *auth.UserPrincipal, ...clustersmngr.ImpersonatedClientOption) (clustersmngr.Client, error)
 	getImpersonatedClientMutex       sync.RWMutex
 	getImpersonatedClientArgsForCall []struct {
 		arg1 context.Context
 		arg2 *auth.UserPrincipal
+		arg3 []clustersmngr.ImpersonatedClientOption
 	}
 	getImpersonatedClientReturns struct {
 		result1 clustersmngr.Client
@@ -101,6 +139,16 @@ type FakeClustersManager struct {
 	getUserNamespacesReturnsOnCall map[int]struct {
 		result1 map[string][]v1.Namespace
 	}
+	InvalidateClusterStub        func(string)
+	invalidateClusterMutex       sync.RWMutex
+	invalidateClusterArgsForCall []struct {
+		arg1 string
+	}
+	InvalidateUserClientsStub        func(*auth.UserPrincipal)
+	invalidateUserClientsMutex       sync.RWMutex
+	invalidateUserClientsArgsForCall []struct {
+		arg1 *auth.UserPrincipal
+	}
 	RemoveWatcherStub        func(*clustersmngr.ClustersWatcher)
 	removeWatcherMutex       sync.RWMutex
 	removeWatcherArgsForCall []struct {
@@ -111,9 +159,21 @@ type FakeClustersManager struct {
 	startArgsForCall []struct {
 		arg1 context.Context
 	}
-	SubscribeStub        func() *clustersmngr.ClustersWatcher
+	StopStub        func(context.Context) error
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct {
+		arg1 context.Context
+	}
+	stopReturns struct {
+		result1 error
+	}
+	stopReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SubscribeStub        func(...clustersmngr.SubscribeOption) *clustersmngr.ClustersWatcher
 	subscribeMutex       sync.RWMutex
 	subscribeArgsForCall []struct {
+		arg1 []clustersmngr.SubscribeOption
 	}
 	subscribeReturns struct {
 		result1 *clustersmngr.ClustersWatcher
@@ -153,17 +213,148 @@ type FakeClustersManager struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeClustersManager) GetClusters() []cluster.Cluster {
+func (fake *FakeClustersManager) GetCluster(arg1 string) (cluster.Cluster, error) {
+	fake.getClusterMutex.Lock()
+	ret, specificReturn := fake.getClusterReturnsOnCall[len(fake.getClusterArgsForCall)]
+	fake.getClusterArgsForCall = append(fake.getClusterArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetClusterStub
+	fakeReturns := fake.getClusterReturns
+	fake.recordInvocation("GetCluster", []interface{}{arg1})
+	fake.getClusterMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClustersManager) GetClusterCallCount() int {
+	fake.getClusterMutex.RLock()
+	defer fake.getClusterMutex.RUnlock()
+	return len(fake.getClusterArgsForCall)
+}
+
+func (fake *FakeClustersManager) GetClusterCalls(stub func(string) (cluster.Cluster, error)) {
+	fake.getClusterMutex.Lock()
+	defer fake.getClusterMutex.Unlock()
+	fake.GetClusterStub = stub
+}
+
+func (fake *FakeClustersManager) GetClusterArgsForCall(i int) string {
+	fake.getClusterMutex.RLock()
+	defer fake.getClusterMutex.RUnlock()
+	argsForCall := fake.getClusterArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClustersManager) GetClusterReturns(result1 cluster.Cluster, result2 error) {
+	fake.getClusterMutex.Lock()
+	defer fake.getClusterMutex.Unlock()
+	fake.GetClusterStub = nil
+	fake.getClusterReturns = struct {
+		result1 cluster.Cluster
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClustersManager) GetClusterReturnsOnCall(i int, result1 cluster.Cluster, result2 error) {
+	fake.getClusterMutex.Lock()
+	defer fake.getClusterMutex.Unlock()
+	fake.GetClusterStub = nil
+	if fake.getClusterReturnsOnCall == nil {
+		fake.getClusterReturnsOnCall = make(map[int]struct {
+			result1 cluster.Cluster
+			result2 error
+		})
+	}
+	fake.getClusterReturnsOnCall[i] = struct {
+		result1 cluster.Cluster
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClustersManager) GetClusterStatus(arg1 string) (clustersmngr.ClusterStatus, bool) {
+	fake.getClusterStatusMutex.Lock()
+	ret, specificReturn := fake.getClusterStatusReturnsOnCall[len(fake.getClusterStatusArgsForCall)]
+	fake.getClusterStatusArgsForCall = append(fake.getClusterStatusArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetClusterStatusStub
+	fakeReturns := fake.getClusterStatusReturns
+	fake.recordInvocation("GetClusterStatus", []interface{}{arg1})
+	fake.getClusterStatusMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClustersManager) GetClusterStatusCallCount() int {
+	fake.getClusterMutex.RLock()
+	defer fake.getClusterMutex.RUnlock()
+	fake.getClusterStatusMutex.RLock()
+	defer fake.getClusterStatusMutex.RUnlock()
+	return len(fake.getClusterStatusArgsForCall)
+}
+
+func (fake *FakeClustersManager) GetClusterStatusCalls(stub func(string) (clustersmngr.ClusterStatus, bool)) {
+	fake.getClusterStatusMutex.Lock()
+	defer fake.getClusterStatusMutex.Unlock()
+	fake.GetClusterStatusStub = stub
+}
+
+func (fake *FakeClustersManager) GetClusterStatusArgsForCall(i int) string {
+	fake.getClusterStatusMutex.RLock()
+	defer fake.getClusterStatusMutex.RUnlock()
+	argsForCall := fake.getClusterStatusArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClustersManager) GetClusterStatusReturns(result1 clustersmngr.ClusterStatus, result2 bool) {
+	fake.getClusterStatusMutex.Lock()
+	defer fake.getClusterStatusMutex.Unlock()
+	fake.GetClusterStatusStub = nil
+	fake.getClusterStatusReturns = struct {
+		result1 clustersmngr.ClusterStatus
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeClustersManager) GetClusterStatusReturnsOnCall(i int, result1 clustersmngr.ClusterStatus, result2 bool) {
+	fake.getClusterStatusMutex.Lock()
+	defer fake.getClusterStatusMutex.Unlock()
+	fake.GetClusterStatusStub = nil
+	if fake.getClusterStatusReturnsOnCall == nil {
+		fake.getClusterStatusReturnsOnCall = make(map[int]struct {
+			result1 clustersmngr.ClusterStatus
+			result2 bool
+		})
+	}
+	fake.getClusterStatusReturnsOnCall[i] = struct {
+		result1 clustersmngr.ClusterStatus
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeClustersManager) GetClusters(arg1 ...clustersmngr.GetClustersOption) []cluster.Cluster {
 	fake.getClustersMutex.Lock()
 	ret, specificReturn := fake.getClustersReturnsOnCall[len(fake.getClustersArgsForCall)]
 	fake.getClustersArgsForCall = append(fake.getClustersArgsForCall, struct {
-	}{})
+		arg1 []clustersmngr.GetClustersOption
+	}{arg1})
 	stub := fake.GetClustersStub
 	fakeReturns := fake.getClustersReturns
-	fake.recordInvocation("GetClusters", []interface{}{})
+	fake.recordInvocation("GetClusters", []interface{}{arg1})
 	fake.getClustersMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1...)
 	}
 	if specificReturn {
 		return ret.result1
@@ -177,12 +368,19 @@ func (fake *FakeClustersManager) GetClustersCallCount() int {
 	return len(fake.getClustersArgsForCall)
 }
 
-func (fake *FakeClustersManager) GetClustersCalls(stub func() []cluster.Cluster) {
+func (fake *FakeClustersManager) GetClustersCalls(stub func(...clustersmngr.GetClustersOption) []cluster.Cluster) {
 	fake.getClustersMutex.Lock()
 	defer fake.getClustersMutex.Unlock()
 	fake.GetClustersStub = stub
 }
 
+func (fake *FakeClustersManager) GetClustersArgsForCall(i int) []clustersmngr.GetClustersOption {
+	fake.getClustersMutex.RLock()
+	defer fake.getClustersMutex.RUnlock()
+	argsForCall := fake.getClustersArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeClustersManager) GetClustersReturns(result1 []cluster.Cluster) {
 	fake.getClustersMutex.Lock()
 	defer fake.getClustersMutex.Unlock()
@@ -259,19 +457,73 @@ func (fake *FakeClustersManager) GetClustersNamespacesReturnsOnCall(i int, resul
 	}{result1}
 }
 
-func (fake *FakeClustersManager) GetImpersonatedClient(arg1 context.Context, arg2 *auth.UserPrincipal) (clustersmngr.Client, error) {
+func (fake *FakeClustersManager) GetHealth() clustersmngr.Health {
+	fake.getHealthMutex.Lock()
+	ret, specificReturn := fake.getHealthReturnsOnCall[len(fake.getHealthArgsForCall)]
+	fake.getHealthArgsForCall = append(fake.getHealthArgsForCall, struct {
+	}{})
+	stub := fake.GetHealthStub
+	fakeReturns := fake.getHealthReturns
+	fake.recordInvocation("GetHealth", []interface{}{})
+	fake.getHealthMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClustersManager) GetHealthCallCount() int {
+	fake.getHealthMutex.RLock()
+	defer fake.getHealthMutex.RUnlock()
+	return len(fake.getHealthArgsForCall)
+}
+
+func (fake *FakeClustersManager) GetHealthCalls(stub func() clustersmngr.Health) {
+	fake.getHealthMutex.Lock()
+	defer fake.getHealthMutex.Unlock()
+	fake.GetHealthStub = stub
+}
+
+func (fake *FakeClustersManager) GetHealthReturns(result1 clustersmngr.Health) {
+	fake.getHealthMutex.Lock()
+	defer fake.getHealthMutex.Unlock()
+	fake.GetHealthStub = nil
+	fake.getHealthReturns = struct {
+		result1 clustersmngr.Health
+	}{result1}
+}
+
+func (fake *FakeClustersManager) GetHealthReturnsOnCall(i int, result1 clustersmngr.Health) {
+	fake.getHealthMutex.Lock()
+	defer fake.getHealthMutex.Unlock()
+	fake.GetHealthStub = nil
+	if fake.getHealthReturnsOnCall == nil {
+		fake.getHealthReturnsOnCall = make(map[int]struct {
+			result1 clustersmngr.Health
+		})
+	}
+	fake.getHealthReturnsOnCall[i] = struct {
+		result1 clustersmngr.Health
+	}{result1}
+}
+
+func (fake *FakeClustersManager) GetImpersonatedClient(arg1 context.Context, arg2 *auth.UserPrincipal, arg3 ...clustersmngr.ImpersonatedClientOption) (clustersmngr.Client, error) {
 	fake.getImpersonatedClientMutex.Lock()
 	ret, specificReturn := fake.getImpersonatedClientReturnsOnCall[len(fake.getImpersonatedClientArgsForCall)]
 	fake.getImpersonatedClientArgsForCall = append(fake.getImpersonatedClientArgsForCall, struct {
 		arg1 context.Context
 		arg2 *auth.UserPrincipal
-	}{arg1, arg2})
+		arg3 []clustersmngr.ImpersonatedClientOption
+	}{arg1, arg2, arg3})
 	stub := fake.GetImpersonatedClientStub
 	fakeReturns := fake.getImpersonatedClientReturns
-	fake.recordInvocation("GetImpersonatedClient", []interface{}{arg1, arg2})
+	fake.recordInvocation("GetImpersonatedClient", []interface{}{arg1, arg2, arg3})
 	fake.getImpersonatedClientMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -285,17 +537,17 @@ func (fake *FakeClustersManager) GetImpersonatedClientCallCount() int {
 	return len(fake.getImpersonatedClientArgsForCall)
 }
 
-func (fake *FakeClustersManager) GetImpersonatedClientCalls(stub func(context.Context, *auth.UserPrincipal) (clustersmngr.Client, error)) {
+func (fake *FakeClustersManager) GetImpersonatedClientCalls(stub func(context.Context, *auth.UserPrincipal, ...clustersmngr.ImpersonatedClientOption) (clustersmngr.Client, error)) {
 	fake.getImpersonatedClientMutex.Lock()
 	defer fake.getImpersonatedClientMutex.Unlock()
 	fake.GetImpersonatedClientStub = stub
 }
 
-func (fake *FakeClustersManager) GetImpersonatedClientArgsForCall(i int) (context.Context, *auth.UserPrincipal) {
+func (fake *FakeClustersManager) GetImpersonatedClientArgsForCall(i int) (context.Context, *auth.UserPrincipal, []clustersmngr.ImpersonatedClientOption) {
 	fake.getImpersonatedClientMutex.RLock()
 	defer fake.getImpersonatedClientMutex.RUnlock()
 	argsForCall := fake.getImpersonatedClientArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeClustersManager) GetImpersonatedClientReturns(result1 clustersmngr.Client, result2 error) {
@@ -581,6 +833,70 @@ func (fake *FakeClustersManager) GetUserNamespacesReturnsOnCall(i int, result1 m
 	}{result1}
 }
 
+func (fake *FakeClustersManager) InvalidateCluster(arg1 string) {
+	fake.invalidateClusterMutex.Lock()
+	fake.invalidateClusterArgsForCall = append(fake.invalidateClusterArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.InvalidateClusterStub
+	fake.recordInvocation("InvalidateCluster", []interface{}{arg1})
+	fake.invalidateClusterMutex.Unlock()
+	if stub != nil {
+		fake.InvalidateClusterStub(arg1)
+	}
+}
+
+func (fake *FakeClustersManager) InvalidateClusterCallCount() int {
+	fake.invalidateClusterMutex.RLock()
+	defer fake.invalidateClusterMutex.RUnlock()
+	return len(fake.invalidateClusterArgsForCall)
+}
+
+func (fake *FakeClustersManager) InvalidateClusterCalls(stub func(string)) {
+	fake.invalidateClusterMutex.Lock()
+	defer fake.invalidateClusterMutex.Unlock()
+	fake.InvalidateClusterStub = stub
+}
+
+func (fake *FakeClustersManager) InvalidateClusterArgsForCall(i int) string {
+	fake.invalidateClusterMutex.RLock()
+	defer fake.invalidateClusterMutex.RUnlock()
+	argsForCall := fake.invalidateClusterArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClustersManager) InvalidateUserClients(arg1 *auth.UserPrincipal) {
+	fake.invalidateUserClientsMutex.Lock()
+	fake.invalidateUserClientsArgsForCall = append(fake.invalidateUserClientsArgsForCall, struct {
+		arg1 *auth.UserPrincipal
+	}{arg1})
+	stub := fake.InvalidateUserClientsStub
+	fake.recordInvocation("InvalidateUserClients", []interface{}{arg1})
+	fake.invalidateUserClientsMutex.Unlock()
+	if stub != nil {
+		fake.InvalidateUserClientsStub(arg1)
+	}
+}
+
+func (fake *FakeClustersManager) InvalidateUserClientsCallCount() int {
+	fake.invalidateUserClientsMutex.RLock()
+	defer fake.invalidateUserClientsMutex.RUnlock()
+	return len(fake.invalidateUserClientsArgsForCall)
+}
+
+func (fake *FakeClustersManager) InvalidateUserClientsCalls(stub func(*auth.UserPrincipal)) {
+	fake.invalidateUserClientsMutex.Lock()
+	defer fake.invalidateUserClientsMutex.Unlock()
+	fake.InvalidateUserClientsStub = stub
+}
+
+func (fake *FakeClustersManager) InvalidateUserClientsArgsForCall(i int) *auth.UserPrincipal {
+	fake.invalidateUserClientsMutex.RLock()
+	defer fake.invalidateUserClientsMutex.RUnlock()
+	argsForCall := fake.invalidateUserClientsArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeClustersManager) RemoveWatcher(arg1 *clustersmngr.ClustersWatcher) {
 	fake.removeWatcherMutex.Lock()
 	fake.removeWatcherArgsForCall = append(fake.removeWatcherArgsForCall, struct {
@@ -595,6 +911,10 @@ func (fake *FakeClustersManager) RemoveWatcher(arg1 *clustersmngr.ClustersWatche
 }
 
 func (fake *FakeClustersManager) RemoveWatcherCallCount() int {
+	fake.invalidateClusterMutex.RLock()
+	defer fake.invalidateClusterMutex.RUnlock()
+	fake.invalidateUserClientsMutex.RLock()
+	defer fake.invalidateUserClientsMutex.RUnlock()
 	fake.removeWatcherMutex.RLock()
 	defer fake.removeWatcherMutex.RUnlock()
 	return len(fake.removeWatcherArgsForCall)
@@ -645,17 +965,65 @@ func (fake *FakeClustersManager) StartArgsForCall(i int) context.Context {
 	return argsForCall.arg1
 }
 
-func (fake *FakeClustersManager) Subscribe() *clustersmngr.ClustersWatcher {
+func (fake *FakeClustersManager) Stop(arg1 context.Context) error {
+	fake.stopMutex.Lock()
+	ret, specificReturn := fake.stopReturnsOnCall[len(fake.stopArgsForCall)]
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.StopStub
+	fakeReturns := fake.stopReturns
+	fake.recordInvocation("Stop", []interface{}{arg1})
+	fake.stopMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClustersManager) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeClustersManager) StopCalls(stub func(context.Context) error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = stub
+}
+
+func (fake *FakeClustersManager) StopArgsForCall(i int) context.Context {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	argsForCall := fake.stopArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClustersManager) StopReturns(result1 error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = nil
+	fake.stopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClustersManager) Subscribe(arg1 ...clustersmngr.SubscribeOption) *clustersmngr.ClustersWatcher {
 	fake.subscribeMutex.Lock()
 	ret, specificReturn := fake.subscribeReturnsOnCall[len(fake.subscribeArgsForCall)]
 	fake.subscribeArgsForCall = append(fake.subscribeArgsForCall, struct {
-	}{})
+		arg1 []clustersmngr.SubscribeOption
+	}{arg1})
 	stub := fake.SubscribeStub
 	fakeReturns := fake.subscribeReturns
-	fake.recordInvocation("Subscribe", []interface{}{})
+	fake.recordInvocation("Subscribe", []interface{}{arg1})
 	fake.subscribeMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1...)
 	}
 	if specificReturn {
 		return ret.result1
@@ -669,12 +1037,19 @@ func (fake *FakeClustersManager) SubscribeCallCount() int {
 	return len(fake.subscribeArgsForCall)
 }
 
-func (fake *FakeClustersManager) SubscribeCalls(stub func() *clustersmngr.ClustersWatcher) {
+func (fake *FakeClustersManager) SubscribeCalls(stub func(...clustersmngr.SubscribeOption) *clustersmngr.ClustersWatcher) {
 	fake.subscribeMutex.Lock()
 	defer fake.subscribeMutex.Unlock()
 	fake.SubscribeStub = stub
 }
 
+func (fake *FakeClustersManager) SubscribeArgsForCall(i int) []clustersmngr.SubscribeOption {
+	fake.subscribeMutex.RLock()
+	defer fake.subscribeMutex.RUnlock()
+	argsForCall := fake.subscribeArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeClustersManager) SubscribeReturns(result1 *clustersmngr.ClustersWatcher) {
 	fake.subscribeMutex.Lock()
 	defer fake.subscribeMutex.Unlock()
@@ -856,10 +1231,14 @@ func (fake *FakeClustersManager) UpdateUserNamespacesArgsForCall(i int) (context
 func (fake *FakeClustersManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getClusterStatusMutex.RLock()
+	defer fake.getClusterStatusMutex.RUnlock()
 	fake.getClustersMutex.RLock()
 	defer fake.getClustersMutex.RUnlock()
 	fake.getClustersNamespacesMutex.RLock()
 	defer fake.getClustersNamespacesMutex.RUnlock()
+	fake.getHealthMutex.RLock()
+	defer fake.getHealthMutex.RUnlock()
 	fake.getImpersonatedClientMutex.RLock()
 	defer fake.getImpersonatedClientMutex.RUnlock()
 	fake.getImpersonatedClientForClusterMutex.RLock()
@@ -874,6 +1253,8 @@ func (fake *FakeClustersManager) Invocations() map[string][][]interface{} {
 	defer fake.removeWatcherMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
 	fake.subscribeMutex.RLock()
 	defer fake.subscribeMutex.RUnlock()
 	fake.updateClustersMutex.RLock()
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -13,11 +15,16 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/logger"
 	"github.com/weaveworks/weave-gitops/core/nsaccess"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -25,11 +32,20 @@ import (
 
 const (
 	userNamespaceTTL = 30 * time.Second
-	// How often we need to stop the world and remove outdated records.
-	userNamespaceResolution = 30 * time.Second
-	watchClustersFrequency  = 30 * time.Second
-	watchNamespaceFrequency = 30 * time.Second
-	usersClientResolution   = 30 * time.Second
+	// How often clusters are fetched again, fetchers that can watch their
+	// clusters trigger updates in between.
+	watchClustersFrequency = 30 * time.Second
+	// How often namespace informers resync, changes are watched.
+	namespaceResyncPeriod = 10 * time.Minute
+	// How long to wait before watching the namespaces of a cluster that
+	// failed again, doubled after each failure up to the max.
+	namespaceWatchRetryPeriod    = time.Second
+	namespaceWatchMaxRetryPeriod = 5 * time.Minute
+	// How often clusters are checked to be reachable
+	clusterStatusFrequency = 30 * time.Second
+	usersClientResolution  = 30 * time.Second
+	// Most calls to clusters a single operation makes at once
+	defaultMaxConcurrency = 50
 )
 
 var (
@@ -111,9 +127,113 @@ var (
 		},
 	)
 
+	opsBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "cluster_breaker_state",
+			Help:      "The state of the circuit breaker of each cluster, 0 closed, 1 open and calls are skipped, 2 half-open and a call is probing the cluster",
+		},
+		[]string{
+			"cluster",
+		},
+	)
+	opsBreakerRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "cluster_breaker_rejections_total",
+			Help:      "The number of calls to a cluster skipped because its circuit breaker is open",
+		},
+		[]string{
+			"cluster",
+		},
+	)
+	opsDuplicateClusters = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "duplicate_clusters",
+			Help:      "The number of clusters skipped because a cluster with the same name was fetched first",
+		})
+
+	opsCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "cache_requests_total",
+			Help:      "The number of lookups in the users caches, by whether the entry was found",
+		},
+		[]string{
+			// Which cache was looked up
+			"cache",
+			// hit or miss
+			"result",
+		},
+	)
+	opsCacheEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "cache_entries",
+			Help:      "The number of entries in the users caches, e.g. the number of cached clients",
+		},
+		[]string{
+			"cache",
+		},
+	)
+	opsGetClientDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "get_client_duration_seconds",
+			Help:      "The time taken to get a client for a cluster, from the cache or by creating it",
+			// from 100µs for cached clients to 26s for clusters that are
+			// slow to answer
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		},
+		[]string{
+			"cluster",
+			// whether the client was cached
+			"cached",
+		},
+	)
+	opsClusterRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gitops",
+			Subsystem: "clustersmngr",
+			Name:      "cluster_request_duration_seconds",
+			Help:      "The time taken by the gets and lists made to a cluster, by kind",
+			// from 5ms to 10s, past the timeout of lists
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{
+			"cluster",
+			// get or list
+			"verb",
+			"group",
+			"version",
+			"kind",
+		},
+	)
+
 	Registry = prometheus.NewRegistry()
 )
 
+// Values of the cache label of the cache metrics
+const (
+	usersNamespacesCache       = "users_namespaces"
+	usersClientsCache          = "users_clients"
+	usersDiscoveryClientsCache = "users_discovery_clients"
+)
+
+// Values of the cluster_breaker_state metric
+const (
+	breakerClosed   = 0
+	breakerOpen     = 1
+	breakerHalfOpen = 2
+)
+
 func registerMetrics() {
 	_ = Registry.Register(opsUpdateClusters)
 	_ = Registry.Register(opsClustersCount)
@@ -121,6 +241,22 @@ func registerMetrics() {
 	_ = Registry.Register(opsNamespacesCount)
 	_ = Registry.Register(opsCreateServerClient)
 	_ = Registry.Register(opsCreateUserClient)
+	_ = Registry.Register(opsBreakerState)
+	_ = Registry.Register(opsBreakerRejections)
+	_ = Registry.Register(opsDuplicateClusters)
+	_ = Registry.Register(opsCacheRequests)
+	_ = Registry.Register(opsCacheEntries)
+	_ = Registry.Register(opsGetClientDuration)
+	_ = Registry.Register(opsClusterRequestDuration)
+}
+
+func recordCacheRequest(cache string, found bool) {
+	result := "miss"
+	if found {
+		result = "hit"
+	}
+
+	opsCacheRequests.WithLabelValues(cache, result).Inc()
 }
 
 // ClientError is an error returned by the GetImpersonatedClient function which contains
@@ -135,13 +271,20 @@ func (ce *ClientError) Error() string {
 	return ce.Err.Error()
 }
 
+func (ce *ClientError) Unwrap() error {
+	return ce.Err
+}
+
 // ClustersManager is a manager for creating clients for clusters
 //
 //counterfeiter:generate . ClustersManager
 type ClustersManager interface {
-	// GetImpersonatedClient returns the clusters client for the given user
-	GetImpersonatedClient(ctx context.Context, user *auth.UserPrincipal) (Client, error)
-	// GetImpersonatedClientForCluster returns the client for the given user and cluster
+	// GetImpersonatedClient returns the clusters client for the given user, the
+	// client of each cluster is created when first used unless
+	// WithEagerClients is set
+	GetImpersonatedClient(ctx context.Context, user *auth.UserPrincipal, opts ...ImpersonatedClientOption) (Client, error)
+	// GetImpersonatedClientForCluster returns the client for the given user and cluster,
+	// picked by name or alias. A ClusterNotFoundError lists the known clusters otherwise
 	GetImpersonatedClientForCluster(ctx context.Context, user *auth.UserPrincipal, clusterName string) (Client, error)
 	// GetImpersonatedDiscoveryClient returns the discovery for the given user and for the given cluster
 	GetImpersonatedDiscoveryClient(ctx context.Context, user *auth.UserPrincipal, clusterName string) (discovery.DiscoveryInterface, error)
@@ -159,18 +302,41 @@ type ClustersManager interface {
 	GetUserNamespaces(user *auth.UserPrincipal) map[string][]v1.Namespace
 	// Start starts go routines to keep clusters and namespaces lists up to date
 	Start(ctx context.Context)
-	// Subscribe returns a new ClustersWatcher
-	Subscribe() *ClustersWatcher
+	// Stop stops the go routines started by Start, unsubscribes the watchers
+	// and drops the cached clients. It waits for the go routines to return
+	// until ctx is done
+	Stop(ctx context.Context) error
+	// Subscribe returns a new ClustersWatcher, WithCurrentClusters delivers
+	// the current clusters first
+	Subscribe(opts ...SubscribeOption) *ClustersWatcher
 	// RemoveWatcher removes the given ClustersWatcher from the list of watchers
 	RemoveWatcher(cw *ClustersWatcher)
-	// GetClusters returns all the currently known clusters
-	GetClusters() []cluster.Cluster
+	// GetClusters returns all the currently known clusters, or the ones with
+	// MatchingClusterLabels
+	GetClusters(opts ...GetClustersOption) []cluster.Cluster
+	// GetCluster returns the cluster with the name, or else the one the name
+	// is an alias of. A ClusterNotFoundError lists the known clusters otherwise
+	GetCluster(name string) (cluster.Cluster, error)
+	// GetClusterStatus returns the connectivity status of a cluster, false
+	// if the cluster hasn't been checked yet
+	GetClusterStatus(clusterName string) (ClusterStatus, bool)
+	// InvalidateUserClients drops the cached clients and namespaces of the
+	// user, e.g. when their groups changed
+	InvalidateUserClients(user *auth.UserPrincipal)
+	// InvalidateCluster drops the cached clients and namespaces of every user
+	// for the cluster, e.g. when its credentials were rotated. They're kept
+	// when a cluster is only renamed
+	InvalidateCluster(clusterName string)
+	// GetHealth returns whether the manager loaded its clusters and keeps
+	// them up to date, and how many are reachable
+	GetHealth() Health
 }
 
 type clustersManager struct {
-	clustersFetchers clusterFetchers
+	clustersFetchers *AggregateClusterFetcher
 	nsChecker        nsaccess.Checker
 	log              logr.Logger
+	options          ClustersManagerOptions
 
 	// list of clusters returned by the clusters fetcher
 	clusters *Clusters
@@ -178,57 +344,218 @@ type clustersManager struct {
 	clustersHash string
 	// the lists of all namespaces of each cluster
 	clustersNamespaces *ClustersNamespaces
+	// the connectivity to each cluster
+	clustersStatus *ClustersStatus
+	// circuit breakers skipping the clusters that keep failing
+	clustersBreakers *ClustersBreakers
 	// lists of namespaces accessible by the user on every cluster
 	usersNamespaces *UsersNamespaces
 	usersClients    *UsersClients
+	// discovery clients of each user, cleared when the clusters change
+	usersDiscoveryClients *UsersDiscoveryClients
 
 	initialClustersLoad chan bool
-	// list of watchers to notify of clusters updates
-	watchers []*ClustersWatcher
+	// when the clusters were loaded and last fetched
+	fetchHealth fetchHealth
+	// list of watchers to notify of clusters updates, the lock is held
+	// while the clusters are updated so new watchers don't miss changes
+	watchersLock sync.Mutex
+	watchers     []*ClustersWatcher
+	// asks for the clusters to be updated before the next resync
+	clustersChangedCh chan struct{}
+
+	// namespace informers of each cluster by cluster identity, kept when the
+	// cluster is renamed, started once the manager is started
+	namespaceInformersLock sync.Mutex
+	namespaceInformers     map[string]namespaceInformer
+	informersCtx           context.Context
+
+	// caches of the cached kinds of each cluster by cluster identity, closed
+	// once the cluster is removed or the manager is stopped
+	clusterCaches map[string]*cluster.ClusterCache
+
+	// cancels the go routines started by Start, held with the namespace
+	// informers lock so no informer starts once they're stopped
+	cancel    context.CancelFunc
+	running   sync.WaitGroup
+	stopOnce  sync.Once
+	closeOnce sync.Once
+	stopped   chan struct{}
+}
+
+type namespaceInformer struct {
+	clusterID   string
+	clusterName string
+	cancel      context.CancelFunc
 }
 
 // ClusterListUpdate records the changes to the cluster state managed by the factory.
+// Removed clusters are to be dropped before the added ones are added, a
+// cluster whose definition changed is both removed and added.
 type ClusterListUpdate struct {
 	Added   []cluster.Cluster
 	Removed []cluster.Cluster
 }
 
+func (u *ClusterListUpdate) empty() bool {
+	return len(u.Added) == 0 && len(u.Removed) == 0
+}
+
+// merge folds a later update into this one, so a cluster added then removed
+// before the update is delivered isn't seen at all.
+func (u *ClusterListUpdate) merge(later ClusterListUpdate) {
+	for _, removed := range later.Removed {
+		added := -1
+
+		for i, cl := range u.Added {
+			if clusterKey(cl) == clusterKey(removed) {
+				added = i
+				break
+			}
+		}
+
+		if added >= 0 {
+			u.Added = append(u.Added[:added:added], u.Added[added+1:]...)
+		} else {
+			u.Removed = append(u.Removed, removed)
+		}
+	}
+
+	u.Added = append(u.Added, later.Added...)
+}
+
 // ClustersWatcher watches for cluster list updates and notifies the registered clients.
+// Updates that come in while the client hasn't received the previous one are
+// merged, so slow clients don't hold up the clusters manager.
 type ClustersWatcher struct {
 	Updates chan ClusterListUpdate
 	cf      *clustersManager
+
+	lock    sync.Mutex
+	pending ClusterListUpdate
+	notify  chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	unsubscribeOnce sync.Once
 }
 
-// Notify publishes cluster updates to the current watcher.
+func newClustersWatcher(cf *clustersManager) *ClustersWatcher {
+	cw := &ClustersWatcher{
+		Updates: make(chan ClusterListUpdate, 1),
+		cf:      cf,
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go cw.deliver()
+
+	return cw
+}
+
+// Notify publishes cluster updates to the current watcher, without waiting
+// for them to be received.
 func (cw *ClustersWatcher) Notify(addedClusters, removedClusters []cluster.Cluster) {
-	cw.Updates <- ClusterListUpdate{Added: addedClusters, Removed: removedClusters}
+	cw.lock.Lock()
+	cw.pending.merge(ClusterListUpdate{Added: addedClusters, Removed: removedClusters})
+	cw.lock.Unlock()
+
+	select {
+	case cw.notify <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the pending updates until the watcher is unsubscribed.
+func (cw *ClustersWatcher) deliver() {
+	defer close(cw.stopped)
+
+	for {
+		select {
+		case <-cw.done:
+			return
+		case <-cw.notify:
+		}
+
+		cw.lock.Lock()
+		update := cw.pending
+		cw.pending = ClusterListUpdate{}
+		cw.lock.Unlock()
+
+		if update.empty() {
+			continue
+		}
+
+		select {
+		case <-cw.done:
+			return
+		case cw.Updates <- update:
+		}
+	}
 }
 
 // Unsubscribe removes the given ClustersWatcher from the list of watchers.
+// Updates not yet delivered are dropped. Watchers are unsubscribed when the
+// clusters manager is stopped, unsubscribing again does nothing.
 func (cw *ClustersWatcher) Unsubscribe() {
-	cw.cf.RemoveWatcher(cw)
-	close(cw.Updates)
+	cw.unsubscribeOnce.Do(func() {
+		cw.cf.RemoveWatcher(cw)
+		close(cw.done)
+		<-cw.stopped
+		close(cw.Updates)
+	})
 }
 
-func NewClustersManager(fetchers []ClusterFetcher, nsChecker nsaccess.Checker, logger logr.Logger) ClustersManager {
+// NewClustersManager creates a ClustersManager, options left unset keep
+// their default. The clusters of all fetchers are merged, see
+// AggregateClusterFetcher for how name collisions are handled.
+func NewClustersManager(fetchers []ClusterFetcher, nsChecker nsaccess.Checker, logger logr.Logger, opts ...ClustersManagerOption) ClustersManager {
 	registerMetrics()
 
+	options := newClustersManagerOptions(opts...)
+
 	return &clustersManager{
-		clustersFetchers:    fetchers,
-		nsChecker:           nsChecker,
-		clusters:            &Clusters{},
-		clustersNamespaces:  &ClustersNamespaces{},
-		usersNamespaces:     &UsersNamespaces{Cache: ttlcache.New(userNamespaceResolution)},
-		usersClients:        &UsersClients{Cache: ttlcache.New(usersClientResolution)},
-		log:                 logger,
-		initialClustersLoad: make(chan bool),
-		watchers:            []*ClustersWatcher{},
+		clustersFetchers:   NewAggregateClusterFetcher(logger, fetchers...),
+		nsChecker:          nsChecker,
+		options:            options,
+		clusters:           &Clusters{},
+		clustersNamespaces: &ClustersNamespaces{},
+		clustersStatus:     &ClustersStatus{},
+		clustersBreakers: &ClustersBreakers{
+			Threshold:  options.BreakerThreshold,
+			MinBackoff: options.BreakerMinBackoff,
+			MaxBackoff: options.BreakerMaxBackoff,
+		},
+		usersNamespaces:       &UsersNamespaces{Cache: ttlcache.New(options.CacheResolution), TTL: options.UserNamespacesTTL, ShareByGroups: options.ShareNamespacesByGroups},
+		usersClients:          &UsersClients{Cache: ttlcache.New(options.CacheResolution), TTL: options.UsersClientsTTL},
+		usersDiscoveryClients: &UsersDiscoveryClients{Cache: ttlcache.New(options.CacheResolution), TTL: options.UsersClientsTTL},
+		log:                   logger,
+		initialClustersLoad:   make(chan bool),
+		watchers:              []*ClustersWatcher{},
+		clustersChangedCh:     make(chan struct{}, 1),
+		namespaceInformers:    map[string]namespaceInformer{},
+		clusterCaches:         map[string]*cluster.ClusterCache{},
+		stopped:               make(chan struct{}),
 	}
 }
 
 // Subscribe returns a new ClustersWatcher.
-func (cf *clustersManager) Subscribe() *ClustersWatcher {
-	cw := &ClustersWatcher{cf: cf, Updates: make(chan ClusterListUpdate, 1)}
+func (cf *clustersManager) Subscribe(opts ...SubscribeOption) *ClustersWatcher {
+	subscribeOpts := SubscribeOptions{}
+	for _, opt := range opts {
+		opt(&subscribeOpts)
+	}
+
+	cw := newClustersWatcher(cf)
+
+	cf.watchersLock.Lock()
+	defer cf.watchersLock.Unlock()
+
+	if clusters := cf.clusters.Get(); subscribeOpts.CurrentClusters && len(clusters) > 0 {
+		cw.Notify(append([]cluster.Cluster{}, clusters...), nil)
+	}
+
 	cf.watchers = append(cf.watchers, cw)
 
 	return cw
@@ -236,6 +563,9 @@ func (cf *clustersManager) Subscribe() *ClustersWatcher {
 
 // RemoveWatcher removes the given ClustersWatcher from the list of watchers.
 func (cf *clustersManager) RemoveWatcher(cw *ClustersWatcher) {
+	cf.watchersLock.Lock()
+	defer cf.watchersLock.Unlock()
+
 	watchers := []*ClustersWatcher{}
 	for _, w := range cf.watchers {
 		if cw != w {
@@ -246,13 +576,109 @@ func (cf *clustersManager) RemoveWatcher(cw *ClustersWatcher) {
 	cf.watchers = watchers
 }
 
-func (cf *clustersManager) GetClusters() []cluster.Cluster {
-	return cf.clusters.Get()
+func (cf *clustersManager) GetClusters(opts ...GetClustersOption) []cluster.Cluster {
+	clustersOpts := GetClustersOptions{}
+	for _, opt := range opts {
+		opt(&clustersOpts)
+	}
+
+	return selectClusters(cf.clusters.Get(), clustersOpts.Selector)
+}
+
+// selectClusters returns the clusters whose labels match selector, all of
+// them if it's nil.
+func selectClusters(clusters []cluster.Cluster, selector labels.Selector) []cluster.Cluster {
+	if selector == nil || selector.Empty() {
+		return clusters
+	}
+
+	selected := []cluster.Cluster{}
+
+	for _, cl := range clusters {
+		if selector.Matches(labels.Set(cl.GetLabels())) {
+			selected = append(selected, cl)
+		}
+	}
+
+	return selected
 }
 
 func (cf *clustersManager) Start(ctx context.Context) {
-	go cf.watchClusters(ctx)
-	go cf.watchNamespaces(ctx)
+	cf.fetchHealth.setStarted(time.Now())
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	cf.namespaceInformersLock.Lock()
+	cf.cancel = cancel
+	cf.namespaceInformersLock.Unlock()
+
+	cf.run(func() { cf.watchClusters(ctx) })
+	cf.run(func() { cf.watchNamespaces(ctx) })
+}
+
+// run runs f in a go routine Stop waits for.
+func (cf *clustersManager) run(f func()) {
+	cf.running.Add(1)
+
+	go func() {
+		defer cf.running.Done()
+		f()
+	}()
+}
+
+func (cf *clustersManager) Stop(ctx context.Context) error {
+	cf.stopOnce.Do(func() {
+		cf.namespaceInformersLock.Lock()
+		if cf.cancel != nil {
+			cf.cancel()
+		}
+		cf.namespaceInformersLock.Unlock()
+
+		go func() {
+			cf.running.Wait()
+			close(cf.stopped)
+		}()
+	})
+
+	select {
+	case <-cf.stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("failed stopping clusters manager: %w", ctx.Err())
+	}
+
+	// the go routines are gone, nothing fills the caches anymore
+	cf.closeOnce.Do(cf.close)
+
+	return nil
+}
+
+// close unsubscribes the watchers, so the ones ranging over their updates
+// return, drops the cached clients and namespaces and stops the informers of
+// the cached kinds.
+func (cf *clustersManager) close() {
+	cf.watchersLock.Lock()
+	watchers := append([]*ClustersWatcher{}, cf.watchers...)
+	cf.watchersLock.Unlock()
+
+	for _, w := range watchers {
+		w.Unsubscribe()
+	}
+
+	cf.usersClients.Clear()
+	cf.usersDiscoveryClients.Clear()
+	cf.usersNamespaces.Clear()
+
+	for _, cl := range cf.clusters.Get() {
+		if closer, ok := cl.(cluster.Closer); ok {
+			closer.Close()
+		}
+	}
+
+	for _, c := range []*ttlcache.Cache{cf.usersClients.Cache, cf.usersDiscoveryClients.Cache, cf.usersNamespaces.Cache} {
+		if err := c.Close(); err != nil {
+			cf.log.Error(err, "failed closing cache")
+		}
+	}
 }
 
 func (cf *clustersManager) watchClusters(ctx context.Context) {
@@ -260,60 +686,315 @@ func (cf *clustersManager) watchClusters(ctx context.Context) {
 		cf.log.Error(err, "failed updating clusters")
 	}
 
-	cf.initialClustersLoad <- true
+	cf.fetchHealth.setLoaded()
+
+	select {
+	case <-ctx.Done():
+		return
+	case cf.initialClustersLoad <- true:
+	}
+
+	cf.run(func() { wait.UntilWithContext(ctx, cf.checkClusters, cf.options.ClusterStatusPeriod) })
+
+	// fetchers that can watch their clusters trigger an update as soon as
+	// something changes, the ticker is only a resync
+	cf.run(func() { cf.clustersFetchers.Watch(ctx, cf.clustersChanged) })
+
+	ticker := time.NewTicker(cf.options.ClustersResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-cf.clustersChangedCh:
+		}
 
-	if err := wait.PollImmediateInfinite(watchClustersFrequency, func() (bool, error) {
 		if err := cf.UpdateClusters(ctx); err != nil {
 			cf.log.Error(err, "Failed to update clusters")
 		}
+	}
+}
 
-		return false, nil
-	}); err != nil {
-		cf.log.Error(err, "failed polling clusters")
+// clustersChanged asks for the clusters to be updated. Changes that come in
+// while an update is already pending are folded into it.
+func (cf *clustersManager) clustersChanged() {
+	select {
+	case cf.clustersChangedCh <- struct{}{}:
+	default:
 	}
 }
 
 // UpdateClusters updates the clusters list and notifies the registered watchers.
 func (cf *clustersManager) UpdateClusters(ctx context.Context) error {
 	clusters, err := cf.clustersFetchers.Fetch(ctx)
+
+	cf.fetchHealth.setFetched(time.Now(), err)
+
 	if err != nil {
 		return fmt.Errorf("failed to fetch clusters: %w", err)
 	}
 
+	cf.watchersLock.Lock()
+	defer cf.watchersLock.Unlock()
+
+	clusters = cf.withCaches(clusters)
+
 	addedClusters, removedClusters := cf.clusters.Set(clusters)
 
 	opsUpdateClusters.Inc()
 	opsClustersCount.Set(float64(len(clusters)))
 
 	if len(addedClusters) > 0 || len(removedClusters) > 0 {
+		// a changed cluster definition shows up as removed and added, so
+		// dropping the clients of removed clusters also drops the ones
+		// pointing at the old host or credentials. A renamed cluster keeps
+		// its ID and host, its clients and namespaces are kept.
+		renamed := map[string]cluster.Cluster{}
+		for _, cl := range addedClusters {
+			renamed[clusterIdentity(cl)] = cl
+		}
+
+		for _, cl := range removedClusters {
+			if newCluster, ok := renamed[clusterIdentity(cl)]; ok {
+				cf.log.Info("cluster renamed", "cluster", newCluster.GetName(), "previousName", cl.GetName())
+				opsNamespacesCount.DeleteLabelValues(cl.GetName())
+				opsNamespacesCount.WithLabelValues(newCluster.GetName()).Set(float64(len(cf.clustersNamespaces.Get(clusterID(cl)))))
+			} else {
+				cf.invalidateCluster(clusterID(cl))
+			}
+
+			// the status and breaker of the new name are set by the next
+			// calls to the cluster
+			cf.clustersStatus.Delete(cl.GetName())
+			cf.clustersBreakers.Delete(cl.GetName())
+		}
+
 		// notify watchers of the changes
 		for _, w := range cf.watchers {
 			w.Notify(addedClusters, removedClusters)
 		}
+
+		cf.syncNamespaceInformers()
 	}
 
 	return nil
 }
 
+// withCaches reads the cached kinds of the clusters from informers. The
+// informers of a cluster are kept as long as the cluster is, as the
+// fetchers return new clusters every time. It's called with the watchers
+// lock held.
+func (cf *clustersManager) withCaches(clusters []cluster.Cluster) []cluster.Cluster {
+	if len(cf.options.CachedKinds) == 0 {
+		return clusters
+	}
+
+	caches := make(map[string]*cluster.ClusterCache, len(clusters))
+	cached := make([]cluster.Cluster, 0, len(clusters))
+
+	for _, cl := range clusters {
+		key := clusterIdentity(cl)
+
+		clusterCache, ok := cf.clusterCaches[key]
+		if !ok {
+			clusterCache = cluster.NewClusterCache(cf.options.CacheScheme)
+		}
+
+		caches[key] = clusterCache
+		cached = append(cached, cluster.NewDelegatingCacheCluster(cl, nil, clusterCache, cf.options.CachedKinds))
+	}
+
+	// the informers of removed clusters would keep watching them
+	for key, clusterCache := range cf.clusterCaches {
+		if _, ok := caches[key]; !ok {
+			clusterCache.Close()
+		}
+	}
+
+	cf.clusterCaches = caches
+
+	return cached
+}
+
 func (cf *clustersManager) watchNamespaces(ctx context.Context) {
 	// waits the first load of cluster to start watching namespaces
-	<-cf.initialClustersLoad
+	select {
+	case <-ctx.Done():
+		return
+	case <-cf.initialClustersLoad:
+	}
 
-	if err := wait.PollImmediateInfinite(watchNamespaceFrequency, func() (bool, error) {
-		if err := cf.UpdateNamespaces(ctx); err != nil {
-			if merr, ok := err.(*multierror.Error); ok {
-				for _, cerr := range merr.Errors {
-					cf.log.Error(cerr, "failed to update namespaces")
-				}
-			}
+	cf.namespaceInformersLock.Lock()
+	cf.informersCtx = ctx
+	cf.namespaceInformersLock.Unlock()
+
+	cf.syncNamespaceInformers()
+}
+
+// syncNamespaceInformers starts watching the namespaces of new clusters and
+// stops watching the removed ones. It does nothing until the manager is
+// started.
+func (cf *clustersManager) syncNamespaceInformers() {
+	cf.namespaceInformersLock.Lock()
+	defer cf.namespaceInformersLock.Unlock()
+
+	if cf.informersCtx == nil || cf.informersCtx.Err() != nil {
+		return
+	}
+
+	current := map[string]cluster.Cluster{}
+	for _, cl := range cf.clusters.Get() {
+		current[clusterIdentity(cl)] = cl
+	}
+
+	for key, informer := range cf.namespaceInformers {
+		if cl, ok := current[key]; ok {
+			informer.clusterName = cl.GetName()
+			cf.namespaceInformers[key] = informer
+
+			continue
+		}
+
+		informer.cancel()
+		delete(cf.namespaceInformers, key)
+
+		cf.clustersNamespaces.Delete(informer.clusterID)
+		opsNamespacesCount.DeleteLabelValues(informer.clusterName)
+	}
+
+	for key, cl := range current {
+		if _, ok := cf.namespaceInformers[key]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(cf.informersCtx)
+		cf.namespaceInformers[key] = namespaceInformer{clusterID: clusterID(cl), clusterName: cl.GetName(), cancel: cancel}
+
+		cl := cl
+		cf.run(func() { cf.watchClusterNamespaces(ctx, cl) })
+	}
+}
+
+// watchClusterNamespaces keeps the namespaces of a cluster up to date with a
+// shared informer until the context is cancelled.
+func (cf *clustersManager) watchClusterNamespaces(ctx context.Context, cl cluster.Cluster) {
+	clientset, ok := cf.serverClientset(ctx, cl)
+	if !ok {
+		return
+	}
+
+	factory := informers.NewSharedInformerFactory(clientset, cf.options.NamespacesResyncPeriod)
+	namespaces := factory.Core().V1().Namespaces()
+	informer := namespaces.Informer()
+
+	refresh := func(changed bool) {
+		// the cluster may have been renamed since the informer started
+		current := cl
+		if renamed, ok := cf.clusters.GetByID(clusterID(cl)); ok {
+			current = renamed
+		}
+
+		list, err := namespaces.Lister().List(labels.Everything())
+		if err != nil {
+			cf.log.Error(err, "failed to list namespaces", "cluster", current.GetName())
+			return
 		}
 
-		return false, nil
+		items := make([]v1.Namespace, 0, len(list))
+		for _, ns := range list {
+			items = append(items, *ns)
+		}
+
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+		items = cf.options.NamespaceFilters.Filter(current.GetName(), items)
+
+		cf.clustersNamespaces.Set(clusterID(cl), items)
+		cf.clustersStatus.SetListed(current.GetName())
+		cf.clustersBreakers.Success(current.GetName())
+		opsNamespacesCount.WithLabelValues(current.GetName()).Set(float64(len(items)))
+		opsUpdateNamespaces.Inc()
+
+		// users may have access to a new namespace, or lost one
+		if changed {
+			cf.usersNamespaces.Clear()
+		}
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if informer.HasSynced() {
+				refresh(true)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNs, oldOk := oldObj.(*v1.Namespace)
+			newNs, newOk := newObj.(*v1.Namespace)
+
+			// resyncs send updates for objects that didn't change
+			if !oldOk || !newOk || oldNs.ResourceVersion == newNs.ResourceVersion {
+				return
+			}
+
+			if informer.HasSynced() {
+				refresh(false)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			refresh(true)
+		},
+	})
+
+	if err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		cf.clustersStatus.SetError(cl.GetName(), err)
+		cache.DefaultWatchErrorHandler(r, err)
 	}); err != nil {
-		cf.log.Error(err, "failed polling namespaces")
+		cf.log.Error(err, "failed to set namespaces watch error handler", "cluster", cl.GetName())
+	}
+
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return
+	}
+
+	refresh(true)
+}
+
+// serverClientset gets the clientset of the cluster, retrying with backoff
+// until it succeeds or the context is cancelled when the cluster is removed.
+func (cf *clustersManager) serverClientset(ctx context.Context, cl cluster.Cluster) (kubernetes.Interface, bool) {
+	delay := namespaceWatchRetryPeriod
+
+	for {
+		clientset, err := cl.GetServerClientset()
+		if err == nil {
+			return clientset, true
+		}
+
+		cf.log.Error(err, "failed to watch namespaces", "cluster", cl.GetName(), "retryIn", delay)
+		cf.clustersStatus.SetError(cl.GetName(), err)
+		cf.clustersBreakers.Failure(cl.GetName(), err)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false
+		case <-timer.C:
+		}
+
+		if delay *= 2; delay > namespaceWatchMaxRetryPeriod {
+			delay = namespaceWatchMaxRetryPeriod
+		}
 	}
 }
 
+// UpdateNamespaces lists the namespaces of every cluster once, the manager
+// keeps them up to date by itself once started.
 func (cf *clustersManager) UpdateNamespaces(ctx context.Context) error {
 	var result *multierror.Error
 
@@ -323,6 +1004,7 @@ func (cf *clustersManager) UpdateNamespaces(ctx context.Context) error {
 			for _, err := range merr.Errors {
 				if cerr, ok := err.(*ClientError); ok {
 					result = multierror.Append(result, fmt.Errorf("%w, cluster: %v", cerr, cerr.ClusterName))
+					cf.clustersStatus.SetError(cerr.ClusterName, cerr.Err)
 				}
 			}
 		}
@@ -338,7 +1020,17 @@ func (cf *clustersManager) UpdateNamespaces(ctx context.Context) error {
 		result = multierror.Append(result, err)
 	}
 
+	ids := map[string]string{}
+	for _, cl := range cf.clusters.Get() {
+		ids[cl.GetName()] = clusterID(cl)
+	}
+
 	for clusterName, lists := range nsList.Lists() {
+		id, ok := ids[clusterName]
+		if !ok {
+			id = clusterName
+		}
+
 		// This is the "namespace loop", but namespaces aren't
 		// namespaced so only 1 item
 		for _, l := range lists {
@@ -347,8 +1039,11 @@ func (cf *clustersManager) UpdateNamespaces(ctx context.Context) error {
 				continue
 			}
 
-			cf.clustersNamespaces.Set(clusterName, list.Items)
-			opsNamespacesCount.WithLabelValues(clusterName).Set(float64(len(list.Items)))
+			items := cf.options.NamespaceFilters.Filter(clusterName, list.Items)
+
+			cf.clustersNamespaces.Set(id, items)
+			cf.clustersStatus.SetListed(clusterName)
+			opsNamespacesCount.WithLabelValues(clusterName).Set(float64(len(items)))
 		}
 	}
 
@@ -357,8 +1052,129 @@ func (cf *clustersManager) UpdateNamespaces(ctx context.Context) error {
 	return result.ErrorOrNil()
 }
 
+// checkClusters records whether each cluster's API server answers, and its
+// version. Clusters are checked even when their breaker is open, so they're
+// called again as soon as they're back.
+func (cf *clustersManager) checkClusters(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	workers := newWorkerPool(cf.options.MaxConcurrency)
+
+	for _, cl := range cf.clusters.Get() {
+		cl := cl
+
+		workers.Go(&wg, func() {
+			version, err := checkCluster(cl)
+			if err != nil {
+				cf.log.V(logger.LogLevelDebug).Info("cluster is unreachable", "cluster", cl.GetName(), "error", err.Error())
+			}
+
+			cf.clustersStatus.SetChecked(cl.GetName(), version, err)
+
+			if err != nil {
+				cf.clustersBreakers.Failure(cl.GetName(), err)
+			} else {
+				cf.clustersBreakers.Success(cl.GetName())
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func checkCluster(cl cluster.Cluster) (string, error) {
+	clientset, err := cl.GetServerClientset()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+
+	return info.GitVersion, nil
+}
+
+func (cf *clustersManager) GetClusterStatus(clusterName string) (ClusterStatus, bool) {
+	return cf.clustersStatus.Get(clusterName)
+}
+
+func (cf *clustersManager) GetHealth() Health {
+	h := Health{}
+
+	cf.fetchHealth.check(&h, cf.options.ClustersResyncPeriod, time.Now())
+
+	clusters := cf.clusters.Get()
+	namespaces := cf.clustersNamespaces.GetAll()
+
+	h.Clusters = len(clusters)
+
+	for _, cl := range clusters {
+		status, _ := cf.clustersStatus.Get(cl.GetName())
+		if status.Reachable {
+			h.ReachableClusters++
+		}
+
+		if _, ok := namespaces[clusterID(cl)]; !ok {
+			continue
+		}
+
+		h.NamespacesCached++
+
+		if h.OldestNamespacesUpdate.IsZero() || status.LastSuccessfulList.Before(h.OldestNamespacesUpdate) {
+			h.OldestNamespacesUpdate = status.LastSuccessfulList
+		}
+	}
+
+	return h
+}
+
+func (cf *clustersManager) InvalidateUserClients(user *auth.UserPrincipal) {
+	if user == nil {
+		return
+	}
+
+	cf.usersClients.DeleteUser(user.ID)
+	cf.usersDiscoveryClients.DeleteUser(user.ID)
+	cf.usersNamespaces.DeleteUser(user.ID)
+}
+
+func (cf *clustersManager) InvalidateCluster(clusterName string) {
+	id := clusterName
+
+	for _, cl := range cf.clusters.Get() {
+		if cl.GetName() == clusterName {
+			id = clusterID(cl)
+			break
+		}
+	}
+
+	cf.invalidateCluster(id)
+}
+
+func (cf *clustersManager) invalidateCluster(id string) {
+	cf.usersClients.DeleteCluster(id)
+	cf.usersDiscoveryClients.DeleteCluster(id)
+	cf.usersNamespaces.DeleteCluster(id)
+}
+
 func (cf *clustersManager) GetClustersNamespaces() map[string][]v1.Namespace {
-	return cf.clustersNamespaces.namespaces
+	return cf.namespacesByName()
+}
+
+// namespacesByName returns the namespaces of the current clusters by name,
+// they're cached by ID.
+func (cf *clustersManager) namespacesByName() map[string][]v1.Namespace {
+	byID := cf.clustersNamespaces.GetAll()
+	namespaces := make(map[string][]v1.Namespace, len(byID))
+
+	for _, cl := range cf.clusters.Get() {
+		if nsList, ok := byID[clusterID(cl)]; ok {
+			namespaces[cl.GetName()] = nsList
+		}
+	}
+
+	return namespaces
 }
 
 func (cf *clustersManager) syncCaches() {
@@ -372,22 +1188,42 @@ func (cf *clustersManager) syncCaches() {
 	}
 }
 
-func (cf *clustersManager) GetImpersonatedClient(ctx context.Context, user *auth.UserPrincipal) (Client, error) {
+func (cf *clustersManager) GetImpersonatedClient(ctx context.Context, user *auth.UserPrincipal, opts ...ImpersonatedClientOption) (Client, error) {
 	if user == nil {
 		return nil, errors.New("no user supplied")
 	}
 
+	clientOpts := ImpersonatedClientOptions{}
+	for _, opt := range opts {
+		opt(&clientOpts)
+	}
+
+	clusters := selectClusters(cf.clusters.Get(), clientOpts.ClusterSelector)
+
+	if !clientOpts.Eager {
+		pool := newLazyClientsPool(clusters, func(cluster cluster.Cluster) (client.Client, error) {
+			client, err := cf.getOrCreateClient(ctx, user, cluster)
+			if err != nil {
+				return nil, &ClientError{ClusterName: cluster.GetName(), Err: fmt.Errorf("failed creating user client: %w", err)}
+			}
+
+			return client, nil
+		}, cf.options.MaxConcurrency)
+
+		return NewClient(pool, cf.userNsList(ctx, user), WithListConcurrency(cf.options.MaxConcurrency), WithAliases(cf.options.ClusterAliases)), nil
+	}
+
 	pool := NewClustersClientsPool()
-	errChan := make(chan error, len(cf.clusters.Get()))
+	errChan := make(chan error, len(clusters))
 
 	var wg sync.WaitGroup
 
-	for _, cl := range cf.clusters.Get() {
-		wg.Add(1)
+	workers := newWorkerPool(cf.options.MaxConcurrency)
 
-		go func(cluster cluster.Cluster, pool ClientsPool, errChan chan error) {
-			defer wg.Done()
+	for _, cl := range clusters {
+		cluster := cl
 
+		workers.Go(&wg, func() {
 			client, err := cf.getOrCreateClient(ctx, user, cluster)
 			if err != nil {
 				errChan <- &ClientError{ClusterName: cluster.GetName(), Err: fmt.Errorf("failed creating user client to pool: %w", err)}
@@ -397,7 +1233,7 @@ func (cf *clustersManager) GetImpersonatedClient(ctx context.Context, user *auth
 			if err := pool.Add(client, cluster); err != nil {
 				errChan <- &ClientError{ClusterName: cluster.GetName(), Err: fmt.Errorf("failed adding cluster client to pool: %w", err)}
 			}
-		}(cl, pool, errChan)
+		})
 	}
 
 	wg.Wait()
@@ -409,7 +1245,7 @@ func (cf *clustersManager) GetImpersonatedClient(ctx context.Context, user *auth
 		result = multierror.Append(result, err)
 	}
 
-	return NewClient(pool, cf.userNsList(ctx, user)), result.ErrorOrNil()
+	return NewClient(pool, cf.userNsList(ctx, user), WithListConcurrency(cf.options.MaxConcurrency), WithAliases(cf.options.ClusterAliases)), result.ErrorOrNil()
 }
 
 func (cf *clustersManager) GetImpersonatedClientForCluster(ctx context.Context, user *auth.UserPrincipal, clusterName string) (Client, error) {
@@ -417,20 +1253,11 @@ func (cf *clustersManager) GetImpersonatedClientForCluster(ctx context.Context,
 		return nil, errors.New("no user supplied")
 	}
 
-	var cl cluster.Cluster
-
 	pool := NewClustersClientsPool()
-	clusters := cf.clusters.Get()
-
-	for _, c := range clusters {
-		if c.GetName() == clusterName {
-			cl = c
-			break
-		}
-	}
 
-	if cl == nil {
-		return nil, fmt.Errorf("cluster not found: %s", clusterName)
+	cl, err := cf.GetCluster(clusterName)
+	if err != nil {
+		return nil, err
 	}
 
 	client, err := cf.getOrCreateClient(ctx, user, cl)
@@ -442,7 +1269,7 @@ func (cf *clustersManager) GetImpersonatedClientForCluster(ctx context.Context,
 		return nil, fmt.Errorf("failed adding cluster client to pool: %w", err)
 	}
 
-	return NewClient(pool, cf.userNsList(ctx, user)), nil
+	return NewClient(pool, cf.userNsList(ctx, user), WithListConcurrency(cf.options.MaxConcurrency), WithAliases(cf.options.ClusterAliases)), nil
 }
 
 func (cf *clustersManager) GetImpersonatedDiscoveryClient(ctx context.Context, user *auth.UserPrincipal, clusterName string) (discovery.DiscoveryInterface, error) {
@@ -450,20 +1277,55 @@ func (cf *clustersManager) GetImpersonatedDiscoveryClient(ctx context.Context, u
 		return nil, errors.New("no user supplied")
 	}
 
-	for _, cluster := range cf.clusters.Get() {
-		if cluster.GetName() == clusterName {
-			var err error
+	cluster, err := cf.GetCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
 
-			clientset, err := cluster.GetUserClientset(user)
-			if err != nil {
-				return nil, fmt.Errorf("error creating client for cluster: %w", err)
-			}
+	clusterName = cluster.GetName()
 
-			return clientset.Discovery(), nil
-		}
+	if client, found := cf.usersDiscoveryClients.Get(user, clusterID(cluster)); found {
+		return client, nil
 	}
 
-	return nil, fmt.Errorf("cluster not found: %s", clusterName)
+	if err := cf.clustersBreakers.Allow(clusterName); err != nil {
+		return nil, err
+	}
+
+	clientset, err := cluster.GetUserClientset(user)
+	if err != nil {
+		return nil, fmt.Errorf("error creating client for cluster: %w", err)
+	}
+
+	cf.usersDiscoveryClients.Set(user, clusterID(cluster), clientset.Discovery())
+
+	return clientset.Discovery(), nil
+}
+
+func (cf *clustersManager) GetCluster(name string) (cluster.Cluster, error) {
+	clusters := cf.clusters.Get()
+
+	byName := make(map[string]cluster.Cluster, len(clusters))
+	for _, cl := range clusters {
+		byName[cl.GetName()] = cl
+	}
+
+	if cl, ok := byName[name]; ok {
+		return cl, nil
+	}
+
+	if cl, ok := byName[cf.options.ClusterAliases[name]]; ok {
+		return cl, nil
+	}
+
+	known := make([]string, 0, len(byName))
+	for clusterName := range byName {
+		known = append(known, clusterName)
+	}
+
+	sort.Strings(known)
+
+	return nil, ClusterNotFoundError{Cluster: name, KnownClusters: known}
 }
 
 func (cf *clustersManager) GetServerClient(ctx context.Context) (Client, error) {
@@ -472,12 +1334,12 @@ func (cf *clustersManager) GetServerClient(ctx context.Context) (Client, error)
 
 	var wg sync.WaitGroup
 
-	for _, cl := range cf.clusters.Get() {
-		wg.Add(1)
+	workers := newWorkerPool(cf.options.MaxConcurrency)
 
-		go func(cluster cluster.Cluster, pool ClientsPool, errChan chan error) {
-			defer wg.Done()
+	for _, cl := range cf.clusters.Get() {
+		cluster := cl
 
+		workers.Go(&wg, func() {
 			client, err := cf.getOrCreateClient(ctx, nil, cluster)
 			if err != nil {
 				errChan <- &ClientError{ClusterName: cluster.GetName(), Err: fmt.Errorf("failed creating server client to pool: %w", err)}
@@ -487,7 +1349,7 @@ func (cf *clustersManager) GetServerClient(ctx context.Context) (Client, error)
 			if err := pool.Add(client, cluster); err != nil {
 				errChan <- &ClientError{ClusterName: cluster.GetName(), Err: fmt.Errorf("failed adding cluster client to pool: %w", err)}
 			}
-		}(cl, pool, errChan)
+		})
 	}
 
 	wg.Wait()
@@ -499,19 +1361,18 @@ func (cf *clustersManager) GetServerClient(ctx context.Context) (Client, error)
 		result = multierror.Append(result, err)
 	}
 
-	return NewClient(pool, cf.clustersNamespaces.namespaces), result.ErrorOrNil()
+	return NewClient(pool, cf.namespacesByName(), WithListConcurrency(cf.options.MaxConcurrency), WithAliases(cf.options.ClusterAliases)), result.ErrorOrNil()
 }
 
 func (cf *clustersManager) UpdateUserNamespaces(ctx context.Context, user *auth.UserPrincipal) {
 	wg := sync.WaitGroup{}
+	workers := newWorkerPool(cf.options.MaxConcurrency)
 
 	for _, cl := range cf.clusters.Get() {
-		wg.Add(1)
+		cluster := cl
 
-		go func(cluster cluster.Cluster) {
-			defer wg.Done()
-
-			clusterNs := cf.clustersNamespaces.Get(cluster.GetName())
+		workers.Go(&wg, func() {
+			clusterNs := cf.clustersNamespaces.Get(clusterID(cluster))
 
 			clientset, err := cluster.GetUserClientset(user)
 			if err != nil {
@@ -525,8 +1386,8 @@ func (cf *clustersManager) UpdateUserNamespaces(ctx context.Context, user *auth.
 				return
 			}
 
-			cf.usersNamespaces.Set(user, cluster.GetName(), filteredNs)
-		}(cl)
+			cf.usersNamespaces.Set(user, clusterID(cluster), filteredNs)
+		})
 	}
 
 	wg.Wait()
@@ -548,6 +1409,13 @@ func (cf *clustersManager) userNsList(ctx context.Context, user *auth.UserPrinci
 }
 
 func (cf *clustersManager) getOrCreateClient(ctx context.Context, user *auth.UserPrincipal, cluster cluster.Cluster) (client.Client, error) {
+	start := time.Now()
+	cached := false
+
+	defer func() {
+		opsGetClientDuration.WithLabelValues(cluster.GetName(), strconv.FormatBool(cached)).Observe(time.Since(start).Seconds())
+	}()
+
 	isServer := false
 
 	if user == nil {
@@ -557,7 +1425,13 @@ func (cf *clustersManager) getOrCreateClient(ctx context.Context, user *auth.Use
 		isServer = true
 	}
 
-	if client, found := cf.usersClients.Get(user, cluster.GetName()); found {
+	// Cached clients of a broken cluster would still wait for timeouts
+	if err := cf.clustersBreakers.Allow(cluster.GetName()); err != nil {
+		return nil, err
+	}
+
+	if client, found := cf.usersClients.Get(user, clusterID(cluster)); found {
+		cached = true
 		return client, nil
 	}
 
@@ -575,10 +1449,15 @@ func (cf *clustersManager) getOrCreateClient(ctx context.Context, user *auth.Use
 	}
 
 	if err != nil {
+		cf.clustersBreakers.Failure(cluster.GetName(), err)
+
 		return nil, fmt.Errorf("failed creating client for cluster=%s: %w", cluster.GetName(), err)
 	}
 
-	cf.usersClients.Set(user, cluster.GetName(), client)
+	client = NewDurationObserver(client, cluster.GetName())
+
+	cf.clustersBreakers.Success(cluster.GetName())
+	cf.usersClients.Set(user, clusterID(cluster), client)
 
 	return client, nil
 }
@@ -5,12 +5,14 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cheshir/ttlcache"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -28,18 +30,17 @@ func (c *Clusters) Set(newClusters []cluster.Cluster) (added, removed []cluster.
 	currentClustersSet := sets.NewString()
 
 	for _, cluster := range c.clusters {
-		clusterKey := fmt.Sprintf("%s:%s", cluster.GetName(), cluster.GetHost())
-		currentClustersSet.Insert(clusterKey)
+		currentClustersSet.Insert(clusterKey(cluster))
 	}
 
 	newClustersSet := sets.NewString()
 	clustersMap := map[string]cluster.Cluster{}
 
 	for _, cluster := range newClusters {
-		clusterKey := fmt.Sprintf("%s:%s", cluster.GetName(), cluster.GetHost())
-		newClustersSet.Insert(clusterKey)
+		key := clusterKey(cluster)
+		newClustersSet.Insert(key)
 
-		clustersMap[clusterKey] = cluster
+		clustersMap[key] = cluster
 	}
 
 	addedClusters := newClustersSet.Difference(currentClustersSet)
@@ -54,6 +55,28 @@ func (c *Clusters) Set(newClusters []cluster.Cluster) (added, removed []cluster.
 	return added, removed
 }
 
+// clusterKey identifies a cluster by name and host, so a cluster that moved
+// to another host shows up as removed and added.
+func clusterKey(cl cluster.Cluster) string {
+	return fmt.Sprintf("%s:%s", cl.GetName(), cl.GetHost())
+}
+
+// clusterID is the key of the caches of a cluster, kept when the cluster is
+// renamed. Clusters that don't set an ID are identified by name.
+func clusterID(cl cluster.Cluster) string {
+	if id := cl.GetID(); id != "" {
+		return id
+	}
+
+	return cl.GetName()
+}
+
+// clusterIdentity identifies a cluster by ID and host, a renamed cluster
+// keeps it.
+func clusterIdentity(cl cluster.Cluster) string {
+	return fmt.Sprintf("%s:%s", clusterID(cl), cl.GetHost())
+}
+
 func appendClusters(clustersMap map[string]cluster.Cluster, keys []string) []cluster.Cluster {
 	clusters := []cluster.Cluster{}
 
@@ -71,16 +94,31 @@ func (c *Clusters) Get() []cluster.Cluster {
 	return c.clusters
 }
 
+// GetByID returns the current cluster with the ID.
+func (c *Clusters) GetByID(id string) (cluster.Cluster, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, cl := range c.clusters {
+		if clusterID(cl) == id {
+			return cl, true
+		}
+	}
+
+	return nil, false
+}
+
+// Hash changes when clusters are added or removed, not when they're renamed.
 func (c *Clusters) Hash() string {
-	names := []string{}
+	ids := []string{}
 
 	for _, cluster := range c.clusters {
-		names = append(names, cluster.GetName())
+		ids = append(ids, clusterID(cluster))
 	}
 
-	sort.Strings(names)
+	sort.Strings(ids)
 
-	return strings.Join(names, "")
+	return strings.Join(ids, "")
 }
 
 type ClustersNamespaces struct {
@@ -106,6 +144,27 @@ func (cn *ClustersNamespaces) Clear() {
 	cn.namespaces = make(map[string][]v1.Namespace)
 }
 
+// GetAll returns a copy of the namespaces of every cluster, so it can be
+// read while the namespaces are being updated.
+func (cn *ClustersNamespaces) GetAll() map[string][]v1.Namespace {
+	cn.RLock()
+	defer cn.RUnlock()
+
+	namespaces := make(map[string][]v1.Namespace, len(cn.namespaces))
+	for cluster, nsList := range cn.namespaces {
+		namespaces[cluster] = nsList
+	}
+
+	return namespaces
+}
+
+func (cn *ClustersNamespaces) Delete(cluster string) {
+	cn.Lock()
+	defer cn.Unlock()
+
+	delete(cn.namespaces, cluster)
+}
+
 func (cn *ClustersNamespaces) Get(cluster string) []v1.Namespace {
 	cn.Lock()
 	defer cn.Unlock()
@@ -115,10 +174,26 @@ func (cn *ClustersNamespaces) Get(cluster string) []v1.Namespace {
 
 type UsersNamespaces struct {
 	Cache *ttlcache.Cache
+	// TTL defaults to 30s
+	TTL time.Duration
+	// ShareByGroups shares the namespaces of users with the same groups, so
+	// their access is only checked once. Only enable it if permissions are
+	// granted to groups, not to individual users.
+	ShareByGroups bool
+
+	index cacheIndex
 }
 
 func (un *UsersNamespaces) Get(user *auth.UserPrincipal, cluster string) ([]v1.Namespace, bool) {
-	if val, found := un.Cache.Get(un.cacheKey(user, cluster)); found {
+	val, found := un.Cache.Get(un.cacheKey(user, cluster))
+
+	if !found && un.sharing(user) {
+		val, found = un.Cache.Get(un.groupsCacheKey(user, cluster))
+	}
+
+	recordCacheRequest(usersNamespacesCache, found)
+
+	if found {
 		return val.([]v1.Namespace), true
 	}
 
@@ -126,16 +201,32 @@ func (un *UsersNamespaces) Get(user *auth.UserPrincipal, cluster string) ([]v1.N
 }
 
 func (un *UsersNamespaces) Set(user *auth.UserPrincipal, cluster string, nsList []v1.Namespace) {
-	un.Cache.Set(un.cacheKey(user, cluster), nsList, userNamespaceTTL)
+	ttl := durationOrDefault(un.TTL, userNamespaceTTL)
+
+	un.index.set(usersNamespacesCache, un.Cache, un.cacheKey(user, cluster), cacheIndexEntry{userID: user.ID, cluster: cluster}, nsList, ttl)
+
+	// the shared entry isn't dropped with the user's, other users may rely
+	// on it
+	if un.sharing(user) {
+		un.index.set(usersNamespacesCache, un.Cache, un.groupsCacheKey(user, cluster), cacheIndexEntry{cluster: cluster}, nsList, ttl)
+	}
+}
+
+// sharing tells whether the namespaces of the user are shared with the users
+// with the same groups. Users without groups don't share theirs, their
+// permissions can only be their own.
+func (un *UsersNamespaces) sharing(user *auth.UserPrincipal) bool {
+	return un.ShareByGroups && len(user.Groups) > 0
 }
 
 // GetAll will return all namespace mappings based on the list of clusters provided.
-// The cache very well may contain more, but this List is targeted.
+// The cache very well may contain more, but this List is targeted. The
+// namespaces are cached by cluster ID, and returned by cluster name.
 func (un *UsersNamespaces) GetAll(user *auth.UserPrincipal, clusters []cluster.Cluster) map[string][]v1.Namespace {
 	namespaces := map[string][]v1.Namespace{}
 
 	for _, cluster := range clusters {
-		if nsList, found := un.Get(user, cluster.GetName()); found {
+		if nsList, found := un.Get(user, clusterID(cluster)); found {
 			namespaces[cluster.GetName()] = nsList
 		}
 	}
@@ -144,15 +235,38 @@ func (un *UsersNamespaces) GetAll(user *auth.UserPrincipal, clusters []cluster.C
 }
 
 func (un *UsersNamespaces) Clear() {
-	un.Cache.Clear()
+	un.index.clear(usersNamespacesCache, un.Cache)
+}
+
+// DeleteUser drops the namespaces of the user on every cluster.
+func (un *UsersNamespaces) DeleteUser(userID string) {
+	un.index.delete(usersNamespacesCache, un.Cache, func(e cacheIndexEntry) bool { return e.userID == userID })
+}
+
+// DeleteCluster drops the namespaces of every user on the cluster.
+func (un *UsersNamespaces) DeleteCluster(cluster string) {
+	un.index.delete(usersNamespacesCache, un.Cache, func(e cacheIndexEntry) bool { return e.cluster == cluster })
+}
+
+func (un *UsersNamespaces) cacheKey(user *auth.UserPrincipal, cluster string) uint64 {
+	return ttlcache.StringKey(fmt.Sprintf("user:%s:%s", user.ID, cluster))
 }
 
-func (un UsersNamespaces) cacheKey(user *auth.UserPrincipal, cluster string) uint64 {
-	return ttlcache.StringKey(fmt.Sprintf("%s:%s", user.ID, cluster))
+// groupsCacheKey is the same for users with the same groups, whatever their
+// order. Its prefix keeps it apart from the keys of users.
+func (un *UsersNamespaces) groupsCacheKey(user *auth.UserPrincipal, cluster string) uint64 {
+	groups := append([]string{}, user.Groups...)
+	sort.Strings(groups)
+
+	return ttlcache.StringKey(fmt.Sprintf("groups:%s:%s", strings.Join(groups, "\x00"), cluster))
 }
 
 type UsersClients struct {
 	Cache *ttlcache.Cache
+	// TTL defaults to WEAVE_GITOPS_USERS_CLIENTS_TTL, or 30m
+	TTL time.Duration
+
+	index cacheIndex
 }
 
 func (uc *UsersClients) cacheKey(user *auth.UserPrincipal, clusterName string) uint64 {
@@ -160,11 +274,14 @@ func (uc *UsersClients) cacheKey(user *auth.UserPrincipal, clusterName string) u
 }
 
 func (uc *UsersClients) Set(user *auth.UserPrincipal, clusterName string, client client.Client) {
-	uc.Cache.Set(uc.cacheKey(user, clusterName), client, usersClientsTTL)
+	uc.index.set(usersClientsCache, uc.Cache, uc.cacheKey(user, clusterName), cacheIndexEntry{userID: user.ID, cluster: clusterName}, client, durationOrDefault(uc.TTL, usersClientsTTL))
 }
 
 func (uc *UsersClients) Get(user *auth.UserPrincipal, clusterName string) (client.Client, bool) {
-	if val, found := uc.Cache.Get(uc.cacheKey(user, clusterName)); found {
+	val, found := uc.Cache.Get(uc.cacheKey(user, clusterName))
+	recordCacheRequest(usersClientsCache, found)
+
+	if found {
 		return val.(client.Client), true
 	}
 
@@ -172,5 +289,224 @@ func (uc *UsersClients) Get(user *auth.UserPrincipal, clusterName string) (clien
 }
 
 func (uc *UsersClients) Clear() {
-	uc.Cache.Clear()
+	uc.index.clear(usersClientsCache, uc.Cache)
+}
+
+// DeleteUser drops the clients of the user, whatever the groups they were
+// created with, on every cluster.
+func (uc *UsersClients) DeleteUser(userID string) {
+	uc.index.delete(usersClientsCache, uc.Cache, func(e cacheIndexEntry) bool { return e.userID == userID })
+}
+
+// DeleteCluster drops the clients of every user on the cluster.
+func (uc *UsersClients) DeleteCluster(clusterName string) {
+	uc.index.delete(usersClientsCache, uc.Cache, func(e cacheIndexEntry) bool { return e.cluster == clusterName })
+}
+
+// UsersDiscoveryClients caches the discovery clients of each user for each
+// cluster, building a clientset for every discovery request is expensive.
+type UsersDiscoveryClients struct {
+	Cache *ttlcache.Cache
+	// TTL defaults to WEAVE_GITOPS_USERS_CLIENTS_TTL, or 30m
+	TTL time.Duration
+
+	index cacheIndex
+}
+
+func (udc *UsersDiscoveryClients) cacheKey(user *auth.UserPrincipal, clusterName string) uint64 {
+	return ttlcache.StringKey(fmt.Sprintf("%s:%s-%s", user.ID, strings.Join(user.Groups, "/"), clusterName))
+}
+
+func (udc *UsersDiscoveryClients) Set(user *auth.UserPrincipal, clusterName string, client discovery.DiscoveryInterface) {
+	udc.index.set(usersDiscoveryClientsCache, udc.Cache, udc.cacheKey(user, clusterName), cacheIndexEntry{userID: user.ID, cluster: clusterName}, client, durationOrDefault(udc.TTL, usersClientsTTL))
+}
+
+func (udc *UsersDiscoveryClients) Get(user *auth.UserPrincipal, clusterName string) (discovery.DiscoveryInterface, bool) {
+	val, found := udc.Cache.Get(udc.cacheKey(user, clusterName))
+	recordCacheRequest(usersDiscoveryClientsCache, found)
+
+	if found {
+		return val.(discovery.DiscoveryInterface), true
+	}
+
+	return nil, false
+}
+
+func (udc *UsersDiscoveryClients) Clear() {
+	udc.index.clear(usersDiscoveryClientsCache, udc.Cache)
+}
+
+// DeleteUser drops the discovery clients of the user on every cluster.
+func (udc *UsersDiscoveryClients) DeleteUser(userID string) {
+	udc.index.delete(usersDiscoveryClientsCache, udc.Cache, func(e cacheIndexEntry) bool { return e.userID == userID })
+}
+
+// DeleteCluster drops the discovery clients of every user on the cluster.
+func (udc *UsersDiscoveryClients) DeleteCluster(clusterName string) {
+	udc.index.delete(usersDiscoveryClientsCache, udc.Cache, func(e cacheIndexEntry) bool { return e.cluster == clusterName })
+}
+
+// cacheIndexEntry is who and what cluster a cache entry is for.
+type cacheIndexEntry struct {
+	userID  string
+	cluster string
+	expires time.Time
+}
+
+// cacheIndex keeps track of the entries of a users cache, whose keys are
+// hashes, so the entries of a user or a cluster can be dropped, and the
+// entries can be counted. Expired entries are forgotten whenever the index
+// changes.
+type cacheIndex struct {
+	sync.Mutex
+	entries map[uint64]cacheIndexEntry
+}
+
+// set adds the value to the cache, under the lock so it can't be missed by
+// a concurrent delete.
+func (ci *cacheIndex) set(name string, cache *ttlcache.Cache, key uint64, entry cacheIndexEntry, value interface{}, ttl time.Duration) {
+	ci.Lock()
+	defer ci.Unlock()
+
+	if ci.entries == nil {
+		ci.entries = map[uint64]cacheIndexEntry{}
+	}
+
+	entry.expires = time.Now().Add(ttl)
+	ci.entries[key] = entry
+	cache.Set(key, value, ttl)
+
+	ci.updateMetrics(name)
+}
+
+// delete drops the entries matching from the cache.
+func (ci *cacheIndex) delete(name string, cache *ttlcache.Cache, matching func(cacheIndexEntry) bool) {
+	ci.Lock()
+	defer ci.Unlock()
+
+	for key, entry := range ci.entries {
+		if matching(entry) {
+			cache.Delete(key)
+			delete(ci.entries, key)
+		}
+	}
+
+	ci.updateMetrics(name)
+}
+
+func (ci *cacheIndex) clear(name string, cache *ttlcache.Cache) {
+	ci.Lock()
+	defer ci.Unlock()
+
+	ci.entries = nil
+	cache.Clear()
+
+	ci.updateMetrics(name)
+}
+
+// updateMetrics forgets the expired entries and reports how many are left,
+// it must be called with the lock held.
+func (ci *cacheIndex) updateMetrics(name string) {
+	now := time.Now()
+
+	for key, entry := range ci.entries {
+		if entry.expires.Before(now) {
+			delete(ci.entries, key)
+		}
+	}
+
+	opsCacheEntries.WithLabelValues(name).Set(float64(len(ci.entries)))
+}
+
+// ClusterStatus is the connectivity of the server to a cluster.
+type ClusterStatus struct {
+	Name string
+	// Reachable is whether the cluster answered the last time it was checked
+	Reachable bool
+	// ServerVersion is the version of the cluster's API server
+	ServerVersion string
+	// LastChecked is when the cluster was last checked
+	LastChecked time.Time
+	// LastSuccessfulList is when the namespaces of the cluster were last
+	// listed
+	LastSuccessfulList time.Time
+	// Error is the last error talking to the cluster, it's cleared once
+	// the cluster answers again
+	Error string
+}
+
+// ClustersStatus keeps the status of each cluster, by cluster name.
+type ClustersStatus struct {
+	sync.RWMutex
+	statuses map[string]ClusterStatus
+}
+
+func (cs *ClustersStatus) update(cluster string, f func(*ClusterStatus)) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	if cs.statuses == nil {
+		cs.statuses = map[string]ClusterStatus{}
+	}
+
+	status := cs.statuses[cluster]
+	status.Name = cluster
+	f(&status)
+	cs.statuses[cluster] = status
+}
+
+// SetChecked records the result of checking a cluster.
+func (cs *ClustersStatus) SetChecked(cluster, serverVersion string, err error) {
+	cs.update(cluster, func(s *ClusterStatus) {
+		s.LastChecked = time.Now()
+		s.Reachable = err == nil
+
+		if err != nil {
+			s.Error = err.Error()
+			return
+		}
+
+		s.ServerVersion = serverVersion
+		s.Error = ""
+	})
+}
+
+// SetListed records that the namespaces of a cluster were listed.
+func (cs *ClustersStatus) SetListed(cluster string) {
+	cs.update(cluster, func(s *ClusterStatus) {
+		s.LastSuccessfulList = time.Now()
+	})
+}
+
+// SetError records an error talking to a cluster, which is then considered
+// unreachable until it's checked again.
+func (cs *ClustersStatus) SetError(cluster string, err error) {
+	cs.update(cluster, func(s *ClusterStatus) {
+		s.Reachable = false
+		s.Error = err.Error()
+	})
+}
+
+func (cs *ClustersStatus) Get(cluster string) (ClusterStatus, bool) {
+	cs.RLock()
+	defer cs.RUnlock()
+
+	status, ok := cs.statuses[cluster]
+
+	return status, ok
+}
+
+func (cs *ClustersStatus) Delete(cluster string) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	delete(cs.statuses, cluster)
+}
+
+func durationOrDefault(d, defaultDuration time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultDuration
+	}
+
+	return d
 }
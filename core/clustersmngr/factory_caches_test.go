@@ -1,6 +1,7 @@
 package clustersmngr_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -12,7 +13,9 @@ import (
 	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestUsersNamespaces(t *testing.T) {
@@ -41,6 +44,105 @@ func TestUsersNamespaces(t *testing.T) {
 		nsMap := un.GetAll(user, []cluster.Cluster{cl})
 		g.Expect(nsMap).To(Equal(map[string][]v1.Namespace{clusterName: {ns}}))
 	})
+
+	t.Run("namespaces expire after the TTL", func(t *testing.T) {
+		un := clustersmngr.UsersNamespaces{Cache: ttlcache.New(10 * time.Millisecond), TTL: 10 * time.Millisecond}
+		un.Set(user, clusterName, []v1.Namespace{ns})
+
+		g.Eventually(func() bool {
+			_, found := un.Get(user, clusterName)
+			return found
+		}, time.Second).Should(BeFalse())
+	})
+
+	t.Run("namespaces are shared by users with the same groups", func(t *testing.T) {
+		un := clustersmngr.UsersNamespaces{Cache: ttlcache.New(1 * time.Second), ShareByGroups: true}
+		un.Set(&auth.UserPrincipal{ID: "alice", Groups: []string{"team-a", "team-b"}}, clusterName, []v1.Namespace{ns})
+
+		nss, found := un.Get(&auth.UserPrincipal{ID: "bob", Groups: []string{"team-b", "team-a"}}, clusterName)
+		g.Expect(found).To(BeTrue())
+		g.Expect(nss).To(Equal([]v1.Namespace{ns}))
+
+		_, found = un.Get(&auth.UserPrincipal{ID: "carol", Groups: []string{"team-a"}}, clusterName)
+		g.Expect(found).To(BeFalse())
+
+		un.Set(&auth.UserPrincipal{ID: "dave"}, clusterName, []v1.Namespace{ns})
+		_, found = un.Get(&auth.UserPrincipal{ID: "erin"}, clusterName)
+		g.Expect(found).To(BeFalse(), "users without groups don't share namespaces")
+
+		un.DeleteUser("alice")
+		_, found = un.Get(&auth.UserPrincipal{ID: "bob", Groups: []string{"team-a", "team-b"}}, clusterName)
+		g.Expect(found).To(BeTrue(), "dropping a user keeps the shared namespaces")
+	})
+
+	t.Run("namespaces aren't shared by default", func(t *testing.T) {
+		un := clustersmngr.UsersNamespaces{Cache: ttlcache.New(1 * time.Second)}
+		un.Set(&auth.UserPrincipal{ID: "alice", Groups: []string{"team-a"}}, clusterName, []v1.Namespace{ns})
+
+		_, found := un.Get(&auth.UserPrincipal{ID: "bob", Groups: []string{"team-a"}}, clusterName)
+		g.Expect(found).To(BeFalse())
+	})
+}
+
+func TestUsersClientsInvalidation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	uc := clustersmngr.UsersClients{Cache: ttlcache.New(1 * time.Second)}
+
+	alice := &auth.UserPrincipal{ID: "alice", Groups: []string{"team-a"}}
+	aliceNewGroups := &auth.UserPrincipal{ID: "alice", Groups: []string{"team-a", "team-b"}}
+	bob := &auth.UserPrincipal{ID: "bob"}
+
+	for _, user := range []*auth.UserPrincipal{alice, aliceNewGroups, bob} {
+		for _, cluster := range []string{"cluster-1", "cluster-2"} {
+			uc.Set(user, cluster, fake.NewClientBuilder().Build())
+		}
+	}
+
+	found := func(user *auth.UserPrincipal, cluster string) bool {
+		_, ok := uc.Get(user, cluster)
+		return ok
+	}
+
+	uc.DeleteUser(alice.ID)
+
+	g.Expect(found(alice, "cluster-1")).To(BeFalse())
+	g.Expect(found(aliceNewGroups, "cluster-2")).To(BeFalse())
+	g.Expect(found(bob, "cluster-1")).To(BeTrue())
+
+	uc.DeleteCluster("cluster-1")
+
+	g.Expect(found(bob, "cluster-1")).To(BeFalse())
+	g.Expect(found(bob, "cluster-2")).To(BeTrue())
+
+	uc.Set(alice, "cluster-1", fake.NewClientBuilder().Build())
+	g.Expect(found(alice, "cluster-1")).To(BeTrue(), "clients can be cached again once dropped")
+}
+
+func TestUsersDiscoveryClients(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	udc := clustersmngr.UsersDiscoveryClients{Cache: ttlcache.New(1 * time.Second)}
+
+	user := &auth.UserPrincipal{ID: "user-id", Groups: []string{"team-a"}}
+	client := discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{})
+
+	udc.Set(user, "cluster-1", client)
+
+	cached, found := udc.Get(user, "cluster-1")
+	g.Expect(found).To(BeTrue())
+	g.Expect(cached).To(BeIdenticalTo(client))
+
+	_, found = udc.Get(user, "cluster-2")
+	g.Expect(found).To(BeFalse())
+
+	_, found = udc.Get(&auth.UserPrincipal{ID: "user-id", Groups: []string{"team-b"}}, "cluster-1")
+	g.Expect(found).To(BeFalse())
+
+	udc.Clear()
+
+	_, found = udc.Get(user, "cluster-1")
+	g.Expect(found).To(BeFalse())
 }
 
 func TestClusters(t *testing.T) {
@@ -93,6 +195,45 @@ func TestClustersNamespaces(t *testing.T) {
 	g.Expect(cs.Get(clusterName)).To(HaveLen(0))
 }
 
+func TestClustersStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cs := clustersmngr.ClustersStatus{}
+
+	_, found := cs.Get("cluster-1")
+	g.Expect(found).To(BeFalse())
+
+	cs.SetChecked("cluster-1", "v1.25.4", nil)
+	cs.SetListed("cluster-1")
+
+	status, found := cs.Get("cluster-1")
+	g.Expect(found).To(BeTrue())
+	g.Expect(status.Name).To(Equal("cluster-1"))
+	g.Expect(status.Reachable).To(BeTrue())
+	g.Expect(status.ServerVersion).To(Equal("v1.25.4"))
+	g.Expect(status.LastSuccessfulList).NotTo(BeZero())
+
+	cs.SetError("cluster-1", errors.New("connection refused"))
+
+	status, _ = cs.Get("cluster-1")
+	g.Expect(status.Reachable).To(BeFalse())
+	g.Expect(status.Error).To(Equal("connection refused"))
+	// What was known about the cluster is kept
+	g.Expect(status.ServerVersion).To(Equal("v1.25.4"))
+
+	cs.SetChecked("cluster-1", "v1.25.5", nil)
+
+	status, _ = cs.Get("cluster-1")
+	g.Expect(status.Reachable).To(BeTrue())
+	g.Expect(status.Error).To(BeEmpty())
+	g.Expect(status.ServerVersion).To(Equal("v1.25.5"))
+
+	cs.Delete("cluster-1")
+
+	_, found = cs.Get("cluster-1")
+	g.Expect(found).To(BeFalse())
+}
+
 var ClusterComparer = cmp.Comparer(func(a, b cluster.Cluster) bool {
 	return a.GetName() == b.GetName() && a.GetHost() == b.GetHost()
 })
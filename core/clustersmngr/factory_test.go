@@ -1,7 +1,9 @@
 package clustersmngr_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
@@ -15,7 +17,15 @@ import (
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	"golang.org/x/net/context"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestGetImpersonatedClient(t *testing.T) {
@@ -383,6 +393,110 @@ func TestUpdateClusters(t *testing.T) {
 	})
 }
 
+func TestSubscribeCurrentClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newCluster := func(name string) *clusterfakes.FakeCluster {
+		cl := new(clusterfakes.FakeCluster)
+		cl.GetNameReturns(name)
+		cl.GetHostReturns("https://" + name)
+
+		return cl
+	}
+
+	clusterNames := func(c []cluster.Cluster) []string {
+		names := []string{}
+		for _, v := range c {
+			names = append(names, v.GetName())
+		}
+
+		return names
+	}
+
+	c1, c2, c3 := newCluster("bar"), newCluster("foo"), newCluster("baz")
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{c1, c2}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	t.Run("current clusters are delivered first", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		watcher := clustersManager.Subscribe(clustersmngr.WithCurrentClusters())
+		defer watcher.Unsubscribe()
+
+		var update clustersmngr.ClusterListUpdate
+		g.Eventually(watcher.Updates).Should(Receive(&update))
+		g.Expect(clusterNames(update.Added)).To(ConsistOf("bar", "foo"))
+		g.Expect(update.Removed).To(BeEmpty())
+	})
+
+	t.Run("nothing is delivered by default", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		watcher := clustersManager.Subscribe()
+		defer watcher.Unsubscribe()
+
+		g.Consistently(watcher.Updates, "50ms").ShouldNot(Receive())
+	})
+
+	t.Run("updates are merged while not received", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		watcher := clustersManager.Subscribe()
+		defer watcher.Unsubscribe()
+
+		// the watcher isn't read from, so none of these may block
+		for _, clusters := range [][]cluster.Cluster{
+			{c1},
+			{c1, c3},
+			{c1, c2, c3},
+			{c1, c2},
+			{c1},
+		} {
+			clustersFetcher.FetchReturns(clusters, nil)
+			g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+		}
+
+		received := map[string]int{}
+
+		timeout := time.After(time.Second)
+
+		for done := false; !done; {
+			select {
+			case update := <-watcher.Updates:
+				for _, name := range clusterNames(update.Removed) {
+					received[name]--
+				}
+
+				for _, name := range clusterNames(update.Added) {
+					received[name]++
+				}
+			case <-time.After(50 * time.Millisecond):
+				done = true
+			case <-timeout:
+				t.Fatal("timed out receiving updates")
+			}
+		}
+
+		g.Expect(received).To(Equal(map[string]int{"foo": -1}))
+	})
+
+	t.Run("unsubscribe closes the updates", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		watcher := clustersManager.Subscribe()
+		watcher.Unsubscribe()
+
+		g.Eventually(watcher.Updates).Should(BeClosed())
+	})
+}
+
 func TestClientCaching(t *testing.T) {
 	g := NewGomegaWithT(t)
 	logger := logr.Discard()
@@ -425,3 +539,799 @@ func TestClientCaching(t *testing.T) {
 	g.Expect(cluster.GetUserClientCallCount()).To(Equal(1))
 	g.Expect(cluster.GetUserClientArgsForCall(0).ID).To(Equal(userID))
 }
+
+func TestClientCacheInvalidation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{}, nil)
+
+	cluster := new(clusterfakes.FakeCluster)
+	cluster.GetNameReturns("Default")
+	cluster.GetServerClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+	cluster.GetUserClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+	cluster.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(cluster)}, nsChecker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	user := &auth.UserPrincipal{ID: "user-id", Groups: []string{"team-a"}}
+
+	getClient := func() {
+		_, err := clustersManager.GetImpersonatedClientForCluster(ctx, user, "Default")
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	getClient()
+	getClient()
+	g.Expect(cluster.GetUserClientCallCount()).To(Equal(1))
+
+	clustersManager.InvalidateUserClients(&auth.UserPrincipal{ID: "user-id"})
+	getClient()
+	g.Expect(cluster.GetUserClientCallCount()).To(Equal(2))
+
+	clustersManager.InvalidateCluster("Default")
+	getClient()
+	g.Expect(cluster.GetUserClientCallCount()).To(Equal(3))
+}
+
+func TestClientCacheMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{}, nil)
+
+	cluster := new(clusterfakes.FakeCluster)
+	cluster.GetNameReturns("metrics-cluster")
+	cluster.GetServerClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+	cluster.GetUserClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+	cluster.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(cluster)}, nsChecker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	requests := func(result string) float64 {
+		return metricValue(g, "gitops_clustersmngr_cache_requests_total", map[string]string{"cache": "users_clients", "result": result})
+	}
+
+	hits, misses := requests("hit"), requests("miss")
+
+	user := &auth.UserPrincipal{ID: "metrics-user"}
+
+	for i := 0; i < 3; i++ {
+		_, err := clustersManager.GetImpersonatedClientForCluster(ctx, user, "metrics-cluster")
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	g.Expect(requests("miss") - misses).To(Equal(1.0))
+	g.Expect(requests("hit") - hits).To(Equal(2.0))
+	g.Expect(metricValue(g, "gitops_clustersmngr_cache_entries", map[string]string{"cache": "users_clients"})).To(BeNumerically(">=", 1))
+	g.Expect(metricValue(g, "gitops_clustersmngr_get_client_duration_seconds", map[string]string{"cluster": "metrics-cluster", "cached": "true"})).To(Equal(2.0))
+}
+
+func TestClusterRequestDurationMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{}, nil)
+
+	cluster := new(clusterfakes.FakeCluster)
+	cluster.GetNameReturns("slow-cluster")
+	cluster.GetUserClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+	cluster.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(cluster)}, nsChecker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	c, err := clustersManager.GetImpersonatedClientForCluster(ctx, &auth.UserPrincipal{ID: "metrics-user"}, "slow-cluster")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.List(ctx, "slow-cluster", &v1.NamespaceList{})).To(Succeed())
+	g.Expect(c.List(ctx, "slow-cluster", &v1.NamespaceList{})).To(Succeed())
+	g.Expect(c.Get(ctx, "slow-cluster", client.ObjectKey{Name: "missing", Namespace: "default"}, &v1.ConfigMap{})).NotTo(Succeed())
+
+	requests := func(verb, kind string) float64 {
+		return metricValue(g, "gitops_clustersmngr_cluster_request_duration_seconds", map[string]string{
+			"cluster": "slow-cluster",
+			"verb":    verb,
+			"group":   "",
+			"version": "v1",
+			"kind":    kind,
+		})
+	}
+
+	g.Expect(requests("list", "Namespace")).To(Equal(2.0))
+	g.Expect(requests("get", "ConfigMap")).To(Equal(1.0))
+}
+
+// metricValue returns the value of a counter or gauge, or the number of
+// observations of a histogram, registered by the clusters manager.
+func metricValue(g *WithT, name string, labels map[string]string) float64 {
+	families, err := clustersmngr.Registry.Gather()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+
+			switch {
+			case m.Counter != nil:
+				return m.GetCounter().GetValue()
+			case m.Gauge != nil:
+				return m.GetGauge().GetValue()
+			case m.Histogram != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestGetImpersonatedClientLazy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{}, nil)
+
+	newCluster := func(name string, err error) *clusterfakes.FakeCluster {
+		cl := new(clusterfakes.FakeCluster)
+		cl.GetNameReturns(name)
+		cl.GetServerClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+		cl.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+		if err != nil {
+			cl.GetUserClientReturns(nil, err)
+		} else {
+			cl.GetUserClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+		}
+
+		return cl
+	}
+
+	clusterA := newCluster("cluster-a", nil)
+	clusterB := newCluster("cluster-b", nil)
+	broken := newCluster("broken", errors.New("no route to host"))
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{clusterA, clusterB, broken}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, nsChecker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	t.Run("clients are created on first use", func(t *testing.T) {
+		user := &auth.UserPrincipal{ID: "lazy-user"}
+
+		clustersClient, err := clustersManager.GetImpersonatedClient(ctx, user)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(clusterA.GetUserClientCallCount()).To(Equal(0))
+
+		_, err = clustersClient.Scoped("cluster-a")
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = clustersClient.Scoped("cluster-a")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(clusterA.GetUserClientCallCount()).To(Equal(1))
+		g.Expect(clusterB.GetUserClientCallCount()).To(Equal(0))
+
+		_, err = clustersClient.Scoped("broken")
+		g.Expect(err).To(MatchError(ContainSubstring("no route to host")))
+
+		_, err = clustersClient.Scoped("unknown")
+		g.Expect(err).To(MatchError(clustersmngr.ClusterNotFoundError{Cluster: "unknown"}))
+
+		g.Expect(clustersClient.ClientsPool().Clients()).To(HaveLen(2))
+		g.Expect(clusterB.GetUserClientCallCount()).To(Equal(1))
+	})
+
+	t.Run("eager clients report the clusters that failed", func(t *testing.T) {
+		user := &auth.UserPrincipal{ID: "eager-user"}
+
+		clustersClient, err := clustersManager.GetImpersonatedClient(ctx, user, clustersmngr.WithEagerClients())
+		g.Expect(err).To(HaveOccurred())
+
+		var clientErr *clustersmngr.ClientError
+		g.Expect(errors.As(err, &clientErr)).To(BeTrue())
+		g.Expect(clientErr.ClusterName).To(Equal("broken"))
+
+		g.Expect(clustersClient.ClientsPool().Clients()).To(HaveLen(2))
+	})
+}
+
+func TestClusterSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{}, nil)
+
+	newCluster := func(name string, clusterLabels map[string]string) *clusterfakes.FakeCluster {
+		cl := new(clusterfakes.FakeCluster)
+		cl.GetNameReturns(name)
+		cl.GetLabelsReturns(clusterLabels)
+		cl.GetServerClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+		cl.GetUserClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+		cl.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+		return cl
+	}
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{
+		newCluster("management", nil),
+		newCluster("prod-eu", map[string]string{"env": "prod", "region": "eu"}),
+		newCluster("prod-us", map[string]string{"env": "prod", "region": "us"}),
+		newCluster("staging-eu", map[string]string{"env": "staging", "region": "eu"}),
+	}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, nsChecker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	clusterNames := func(clusters []cluster.Cluster) []string {
+		names := []string{}
+		for _, cl := range clusters {
+			names = append(names, cl.GetName())
+		}
+
+		return names
+	}
+
+	g.Expect(clusterNames(clustersManager.GetClusters())).To(HaveLen(4))
+
+	selector, err := labels.Parse("env=prod")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusterNames(clustersManager.GetClusters(clustersmngr.MatchingClusterLabels(selector)))).To(ConsistOf("prod-eu", "prod-us"))
+
+	selector, err = labels.Parse("region=eu,env!=prod")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusterNames(clustersManager.GetClusters(clustersmngr.MatchingClusterLabels(selector)))).To(ConsistOf("staging-eu"))
+
+	user := &auth.UserPrincipal{ID: "selector-user"}
+
+	for _, eager := range []bool{false, true} {
+		opts := []clustersmngr.ImpersonatedClientOption{clustersmngr.WithClusterSelector(selector)}
+		if eager {
+			opts = append(opts, clustersmngr.WithEagerClients())
+		}
+
+		clustersClient, err := clustersManager.GetImpersonatedClient(ctx, user, opts...)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		clients := clustersClient.ClientsPool().Clients()
+		g.Expect(clients).To(HaveLen(1))
+		g.Expect(clients).To(HaveKey("staging-eu"))
+	}
+}
+
+func TestClusterAliases(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{}, nil)
+
+	newCluster := func(name string) *clusterfakes.FakeCluster {
+		cl := new(clusterfakes.FakeCluster)
+		cl.GetNameReturns(name)
+		cl.GetServerClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+		cl.GetUserClientReturns(ctrlclientfake.NewClientBuilder().WithObjects(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}).Build(), nil)
+		cl.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+		return cl
+	}
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{newCluster("Default"), newCluster("prod"), newCluster("staging")}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, nsChecker, logr.Discard(),
+		clustersmngr.WithClusterAliases(map[string]string{"management": "Default", "staging": "prod"}),
+	)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	user := &auth.UserPrincipal{ID: "alias-user"}
+
+	t.Run("clusters are picked by alias", func(t *testing.T) {
+		clustersClient, err := clustersManager.GetImpersonatedClientForCluster(ctx, user, "management")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		ns := &v1.Namespace{}
+		g.Expect(clustersClient.Get(ctx, "management", client.ObjectKey{Name: "Default"}, ns)).To(Succeed())
+
+		_, err = clustersManager.GetImpersonatedDiscoveryClient(ctx, user, "management")
+		g.Expect(err).NotTo(HaveOccurred())
+	})
+
+	t.Run("names take precedence over aliases", func(t *testing.T) {
+		clustersClient, err := clustersManager.GetImpersonatedClientForCluster(ctx, user, "staging")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		ns := &v1.Namespace{}
+		g.Expect(clustersClient.Get(ctx, "staging", client.ObjectKey{Name: "staging"}, ns)).To(Succeed())
+	})
+
+	t.Run("unknown clusters list the known ones", func(t *testing.T) {
+		_, err := clustersManager.GetImpersonatedClientForCluster(ctx, user, "unknown")
+		g.Expect(err).To(MatchError(clustersmngr.ClusterNotFoundError{
+			Cluster:       "unknown",
+			KnownClusters: []string{"Default", "prod", "staging"},
+		}))
+		g.Expect(err.Error()).To(Equal("cluster=unknown not found, known clusters: Default, prod, staging"))
+	})
+}
+
+func TestUpdateNamespacesFiltered(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	namespaces := []client.Object{}
+	for _, name := range []string{"default", "kube-system", "team-a"} {
+		namespaces = append(namespaces, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	cluster := new(clusterfakes.FakeCluster)
+	cluster.GetNameReturns("Default")
+	cluster.GetServerClientReturns(ctrlclientfake.NewClientBuilder().WithObjects(namespaces...).Build(), nil)
+
+	filters, err := clustersmngr.ParseNamespaceFilters(nil, []string{"kube-*"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(cluster)}, &nsaccessfakes.FakeChecker{}, logr.Discard(),
+		clustersmngr.WithNamespaceFilters(filters),
+	)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+	g.Expect(clustersManager.UpdateNamespaces(ctx)).To(Succeed())
+
+	names := []string{}
+	for _, ns := range clustersManager.GetClustersNamespaces()["Default"] {
+		names = append(names, ns.Name)
+	}
+
+	g.Expect(names).To(ConsistOf("default", "team-a"))
+}
+
+func TestRenamedClusterKeepsCaches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsChecker := &nsaccessfakes.FakeChecker{}
+	nsChecker.FilterAccessibleNamespacesReturns([]v1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "default"}}}, nil)
+
+	newCluster := func(name, id, host string) *clusterfakes.FakeCluster {
+		cl := new(clusterfakes.FakeCluster)
+		cl.GetNameReturns(name)
+		cl.GetIDReturns(id)
+		cl.GetHostReturns(host)
+		cl.GetServerClientReturns(ctrlclientfake.NewClientBuilder().WithObjects(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}).Build(), nil)
+		cl.GetUserClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+		cl.GetUserClientsetReturns(fake.NewSimpleClientset(), nil)
+
+		return cl
+	}
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{newCluster("staging", "uid-1", "https://leaf:6443")}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, nsChecker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+	g.Expect(clustersManager.UpdateNamespaces(ctx)).To(Succeed())
+
+	user := &auth.UserPrincipal{ID: "renaming-user"}
+
+	_, err := clustersManager.GetImpersonatedClient(ctx, user, clustersmngr.WithEagerClients())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clustersManager.GetUserNamespaces(user)).To(HaveKey("staging"))
+
+	t.Run("renamed clusters keep their clients and namespaces", func(t *testing.T) {
+		renamed := newCluster("production", "uid-1", "https://leaf:6443")
+		clustersFetcher.FetchReturns([]cluster.Cluster{renamed}, nil)
+		g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+		g.Expect(clustersManager.GetClustersNamespaces()).To(HaveKey("production"))
+		g.Expect(clustersManager.GetClustersNamespaces()).NotTo(HaveKey("staging"))
+
+		checks := nsChecker.FilterAccessibleNamespacesCallCount()
+
+		clustersClient, err := clustersManager.GetImpersonatedClient(ctx, user, clustersmngr.WithEagerClients())
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(clustersManager.GetUserNamespaces(user)).To(HaveKey("production"))
+		g.Expect(clustersClient.ClientsPool().Clients()).To(HaveKey("production"))
+
+		g.Expect(renamed.GetUserClientCallCount()).To(Equal(0))
+		g.Expect(nsChecker.FilterAccessibleNamespacesCallCount()).To(Equal(checks))
+	})
+
+	t.Run("replaced clusters don't", func(t *testing.T) {
+		replaced := newCluster("production", "uid-2", "https://leaf:6443")
+		clustersFetcher.FetchReturns([]cluster.Cluster{replaced}, nil)
+		g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+		_, err := clustersManager.GetImpersonatedClient(ctx, user, clustersmngr.WithEagerClients())
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(replaced.GetUserClientCallCount()).To(Equal(1))
+	})
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientset := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf")
+	leaf.GetHostReturns("https://leaf:6443")
+	leaf.GetServerClientsetReturns(clientset, nil)
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	clustersManager.Start(ctx)
+
+	namespaceNames := func() []string {
+		names := []string{}
+		for _, ns := range clustersManager.GetClustersNamespaces()["leaf"] {
+			names = append(names, ns.Name)
+		}
+
+		return names
+	}
+
+	g.Eventually(namespaceNames).Should(Equal([]string{"default"}))
+
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}, metav1.CreateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Eventually(namespaceNames).Should(Equal([]string{"apps", "default"}))
+
+	t.Run("namespaces of removed clusters are dropped", func(t *testing.T) {
+		clustersFetcher.FetchReturns([]cluster.Cluster{}, nil)
+		g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+		g.Expect(clustersManager.GetClustersNamespaces()).NotTo(HaveKey("leaf"))
+	})
+}
+
+func TestWatchNamespacesRetriesFailedClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf")
+	leaf.GetHostReturns("https://leaf:6443")
+	leaf.GetServerClientsetReturnsOnCall(0, nil, errors.New("connection refused"))
+	leaf.GetServerClientsetReturns(fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}), nil)
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf}, nil)
+
+	// the clusters don't change, so only the retry can pick the cluster up
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	clustersManager.Start(ctx)
+
+	g.Eventually(func() []v1.Namespace {
+		return clustersManager.GetClustersNamespaces()["leaf"]
+	}, "5s").Should(HaveLen(1))
+}
+
+func TestClusterStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf")
+	leaf.GetHostReturns("https://leaf:6443")
+	leaf.GetServerClientsetReturns(fake.NewSimpleClientset(), nil)
+
+	unreachable := &clusterfakes.FakeCluster{}
+	unreachable.GetNameReturns("unreachable")
+	unreachable.GetHostReturns("https://unreachable:6443")
+	unreachable.GetServerClientsetReturns(nil, errors.New("connection refused"))
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf, unreachable}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	clustersManager.Start(ctx)
+
+	g.Eventually(func() clustersmngr.ClusterStatus {
+		status, _ := clustersManager.GetClusterStatus("leaf")
+		return status
+	}).Should(And(
+		HaveField("Reachable", BeTrue()),
+		HaveField("ServerVersion", Not(BeEmpty())),
+		HaveField("LastSuccessfulList", Not(BeZero())),
+	))
+
+	g.Eventually(func() clustersmngr.ClusterStatus {
+		status, _ := clustersManager.GetClusterStatus("unreachable")
+		return status
+	}).Should(And(
+		HaveField("Reachable", BeFalse()),
+		HaveField("LastChecked", Not(BeZero())),
+		HaveField("Error", "connection refused"),
+	))
+
+	t.Run("removed clusters have no status", func(t *testing.T) {
+		clustersFetcher.FetchReturns([]cluster.Cluster{leaf}, nil)
+		g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+		_, found := clustersManager.GetClusterStatus("unreachable")
+		g.Expect(found).To(BeFalse())
+	})
+}
+
+func TestHealth(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf")
+	leaf.GetHostReturns("https://leaf:6443")
+	leaf.GetServerClientsetReturns(fake.NewSimpleClientset(), nil)
+
+	unreachable := &clusterfakes.FakeCluster{}
+	unreachable.GetNameReturns("unreachable")
+	unreachable.GetHostReturns("https://unreachable:6443")
+	unreachable.GetServerClientsetReturns(nil, errors.New("connection refused"))
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf, unreachable}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard(),
+		clustersmngr.WithClustersResyncPeriod(50*time.Millisecond),
+	)
+
+	health := clustersManager.GetHealth()
+	g.Expect(health.Live).To(BeTrue())
+	g.Expect(health.Ready).To(BeFalse())
+	g.Expect(health.Loaded).To(BeFalse())
+
+	clustersManager.Start(ctx)
+
+	g.Eventually(clustersManager.GetHealth).Should(And(
+		HaveField("Live", BeTrue()),
+		HaveField("Ready", BeTrue()),
+		HaveField("Loaded", BeTrue()),
+		HaveField("Clusters", 2),
+		HaveField("ReachableClusters", 1),
+		HaveField("NamespacesCached", 1),
+		HaveField("OldestNamespacesUpdate", Not(BeZero())),
+	))
+
+	t.Run("failing to fetch clusters makes the manager unready", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		clustersFetcher.FetchReturns(nil, errors.New("the server is currently unable to handle the request"))
+
+		g.Eventually(clustersManager.GetHealth).Should(And(
+			HaveField("Live", BeTrue()),
+			HaveField("Ready", BeFalse()),
+			HaveField("Reason", ContainSubstring("not fetched successfully")),
+		))
+	})
+}
+
+func TestClusterBreaker(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	unreachable := &clusterfakes.FakeCluster{}
+	unreachable.GetNameReturns("unreachable")
+	unreachable.GetHostReturns("https://unreachable:6443")
+	unreachable.GetServerClientReturns(nil, errors.New("dial tcp: i/o timeout"))
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{unreachable}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	for i := 0; i < 3; i++ {
+		_, err := clustersManager.GetServerClient(ctx)
+		g.Expect(err).To(MatchError(ContainSubstring("i/o timeout")))
+		g.Expect(errors.Is(err, clustersmngr.ErrClusterUnavailable)).To(BeFalse())
+	}
+
+	g.Expect(unreachable.GetServerClientCallCount()).To(Equal(3))
+
+	t.Run("skips the cluster with the last error once open", func(t *testing.T) {
+		_, err := clustersManager.GetServerClient(ctx)
+		g.Expect(errors.Is(err, clustersmngr.ErrClusterUnavailable)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("after 3 consecutive failures")))
+		g.Expect(err).To(MatchError(ContainSubstring("i/o timeout")))
+
+		_, err = clustersManager.GetImpersonatedDiscoveryClient(ctx, &auth.UserPrincipal{ID: "user-id"}, "unreachable")
+		g.Expect(errors.Is(err, clustersmngr.ErrClusterUnavailable)).To(BeTrue())
+
+		g.Expect(unreachable.GetServerClientCallCount()).To(Equal(3))
+		g.Expect(unreachable.GetUserClientsetCallCount()).To(BeZero())
+	})
+
+	t.Run("removed clusters are forgotten", func(t *testing.T) {
+		clustersFetcher.FetchReturns([]cluster.Cluster{}, nil)
+		g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+		clustersFetcher.FetchReturns([]cluster.Cluster{unreachable}, nil)
+		g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+		_, err := clustersManager.GetServerClient(ctx)
+		g.Expect(errors.Is(err, clustersmngr.ErrClusterUnavailable)).To(BeFalse())
+		g.Expect(unreachable.GetServerClientCallCount()).To(Equal(4))
+	})
+}
+
+func TestClusterBreakerOptions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	unreachable := &clusterfakes.FakeCluster{}
+	unreachable.GetNameReturns("unreachable")
+	unreachable.GetServerClientReturns(nil, errors.New("dial tcp: i/o timeout"))
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{unreachable}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager(
+		[]clustersmngr.ClusterFetcher{clustersFetcher},
+		&nsaccessfakes.FakeChecker{},
+		logr.Discard(),
+		clustersmngr.WithBreaker(1, time.Hour, time.Hour),
+	)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	_, err := clustersManager.GetServerClient(ctx)
+	g.Expect(errors.Is(err, clustersmngr.ErrClusterUnavailable)).To(BeFalse())
+
+	_, err = clustersManager.GetServerClient(ctx)
+	g.Expect(errors.Is(err, clustersmngr.ErrClusterUnavailable)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("after 1 consecutive failures, retrying in 1h0m0s")))
+	g.Expect(unreachable.GetServerClientCallCount()).To(Equal(1))
+}
+
+func TestCachedKinds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf")
+	leaf.GetHostReturns("https://leaf:6443")
+	leaf.GetServerClientReturns(ctrlclientfake.NewClientBuilder().Build(), nil)
+	leaf.GetServerConfigReturns(&rest.Config{Host: "https://leaf:6443"}, nil)
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard(),
+		clustersmngr.WithCachedKinds(scheme.Scheme, []schema.GroupKind{{Kind: "ConfigMap"}}),
+	)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	// leaf clusters are read from the cache too
+	cached, err := clustersManager.GetCluster("leaf")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cached).NotTo(BeIdenticalTo(leaf))
+	g.Expect(cached.GetName()).To(Equal("leaf"))
+
+	// removing the cluster stops the informers of its cache
+	clustersFetcher.FetchReturns([]cluster.Cluster{}, nil)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	_, err = cached.GetServerClient()
+	g.Expect(err).To(MatchError(ContainSubstring("stopped")))
+
+	// and so does stopping the manager
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf}, nil)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	cached, err = clustersManager.GetCluster("leaf")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(clustersManager.Stop(ctx)).To(Succeed())
+
+	_, err = cached.GetServerClient()
+	g.Expect(err).To(MatchError(ContainSubstring("stopped")))
+}
+
+func TestStop(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf")
+	leaf.GetHostReturns("https://leaf:6443")
+	leaf.GetServerClientsetReturns(fake.NewSimpleClientset(), nil)
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchReturns([]cluster.Cluster{leaf}, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard(),
+		clustersmngr.WithClustersResyncPeriod(10*time.Millisecond),
+	)
+
+	watcher := clustersManager.Subscribe()
+
+	clustersManager.Start(context.Background())
+
+	g.Eventually(clustersManager.GetClustersNamespaces).Should(HaveKey("leaf"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g.Expect(clustersManager.Stop(ctx)).To(Succeed())
+
+	// the watcher's updates are closed once the pending ones are dropped
+	g.Eventually(watcher.Updates).Should(BeClosed())
+	watcher.Unsubscribe()
+
+	fetches := clustersFetcher.FetchCallCount()
+	g.Consistently(clustersFetcher.FetchCallCount, 50*time.Millisecond).Should(Equal(fetches))
+
+	g.Expect(clustersManager.Stop(ctx)).To(Succeed())
+}
+
+func TestStopTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// a fetch that never returns keeps the manager from stopping
+	blocked := make(chan struct{})
+	defer close(blocked)
+
+	clustersFetcher := new(clustersmngrfakes.FakeClusterFetcher)
+	clustersFetcher.FetchStub = func(context.Context) ([]cluster.Cluster, error) {
+		<-blocked
+		return nil, nil
+	}
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	clustersManager.Start(context.Background())
+
+	g.Eventually(clustersFetcher.FetchCallCount).Should(Equal(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	g.Expect(clustersManager.Stop(ctx)).To(MatchError(context.DeadlineExceeded))
+}
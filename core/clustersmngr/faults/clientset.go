@@ -0,0 +1,256 @@
+package faults
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// PhantomLabel is set on the namespaces made up by namespace churn.
+const PhantomLabel = "weave.works/fault-injection"
+
+// faultyClientset injects faults into discovery and namespaces, which is
+// what the ClustersManager and the API use clientsets for.
+type faultyClientset struct {
+	kubernetes.Interface
+	faults Faults
+	churn  *churn
+}
+
+func newClientset(cs kubernetes.Interface, faults Faults) kubernetes.Interface {
+	return &faultyClientset{Interface: cs, faults: faults, churn: &churn{}}
+}
+
+func (c *faultyClientset) Discovery() discovery.DiscoveryInterface {
+	return &faultyDiscovery{DiscoveryInterface: c.Interface.Discovery(), faults: c.faults}
+}
+
+func (c *faultyClientset) CoreV1() corev1client.CoreV1Interface {
+	return &faultyCoreV1{CoreV1Interface: c.Interface.CoreV1(), faults: c.faults, churn: c.churn}
+}
+
+type faultyDiscovery struct {
+	discovery.DiscoveryInterface
+	faults Faults
+}
+
+func (d *faultyDiscovery) ServerVersion() (*version.Info, error) {
+	if err := d.faults.inject(context.Background(), "server version"); err != nil {
+		return nil, err
+	}
+
+	return d.DiscoveryInterface.ServerVersion()
+}
+
+func (d *faultyDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	if err := d.faults.inject(context.Background(), "server groups"); err != nil {
+		return nil, err
+	}
+
+	return d.DiscoveryInterface.ServerGroups()
+}
+
+func (d *faultyDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	if err := d.faults.inject(context.Background(), "server resources"); err != nil {
+		return nil, err
+	}
+
+	return d.DiscoveryInterface.ServerResourcesForGroupVersion(groupVersion)
+}
+
+func (d *faultyDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	if err := d.faults.inject(context.Background(), "server preferred resources"); err != nil {
+		return nil, err
+	}
+
+	return d.DiscoveryInterface.ServerPreferredResources()
+}
+
+func (d *faultyDiscovery) OpenAPISchema() (*openapi_v2.Document, error) {
+	if err := d.faults.inject(context.Background(), "openapi schema"); err != nil {
+		return nil, err
+	}
+
+	return d.DiscoveryInterface.OpenAPISchema()
+}
+
+type faultyCoreV1 struct {
+	corev1client.CoreV1Interface
+	faults Faults
+	churn  *churn
+}
+
+func (c *faultyCoreV1) Namespaces() corev1client.NamespaceInterface {
+	return &faultyNamespaces{NamespaceInterface: c.CoreV1Interface.Namespaces(), faults: c.faults, churn: c.churn}
+}
+
+type faultyNamespaces struct {
+	corev1client.NamespaceInterface
+	faults Faults
+	churn  *churn
+}
+
+func (n *faultyNamespaces) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Namespace, error) {
+	if err := n.faults.inject(ctx, "get namespace"); err != nil {
+		return nil, err
+	}
+
+	return n.NamespaceInterface.Get(ctx, name, opts)
+}
+
+func (n *faultyNamespaces) List(ctx context.Context, opts metav1.ListOptions) (*v1.NamespaceList, error) {
+	if err := n.faults.inject(ctx, "list namespaces"); err != nil {
+		return nil, err
+	}
+
+	list, err := n.NamespaceInterface.List(ctx, opts)
+	if err != nil || n.faults.NamespaceChurn.Duration == 0 {
+		return list, err
+	}
+
+	list.Items = append(list.Items, n.churn.current(list.ResourceVersion))
+
+	return list, nil
+}
+
+func (n *faultyNamespaces) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	if err := n.faults.inject(ctx, "watch namespaces"); err != nil {
+		return nil, err
+	}
+
+	w, err := n.NamespaceInterface.Watch(ctx, opts)
+	if err != nil || n.faults.NamespaceChurn.Duration == 0 {
+		return w, err
+	}
+
+	return newChurnWatcher(w, n.churn, n.faults.NamespaceChurn.Duration), nil
+}
+
+// churn is the phantom namespace currently in a cluster.
+type churn struct {
+	sync.Mutex
+	generation int
+	rv         string
+}
+
+func (c *churn) phantom() v1.Namespace {
+	return v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("phantom-%d", c.generation),
+			Labels:          map[string]string{PhantomLabel: "true"},
+			ResourceVersion: c.rv,
+		},
+		Status: v1.NamespaceStatus{Phase: v1.NamespaceActive},
+	}
+}
+
+// current returns the phantom namespace, with the resource version of the
+// last real change so clients resume watching from the right place.
+func (c *churn) current(rv string) v1.Namespace {
+	c.Lock()
+	defer c.Unlock()
+
+	if rv != "" {
+		c.rv = rv
+	}
+
+	return c.phantom()
+}
+
+// replace replaces the phantom namespace with a new one.
+func (c *churn) replace() (old, current v1.Namespace) {
+	c.Lock()
+	defer c.Unlock()
+
+	old = c.phantom()
+	c.generation++
+
+	return old, c.phantom()
+}
+
+// churnWatcher forwards the events of a watch, adding the replacement of
+// the phantom namespace every period.
+type churnWatcher struct {
+	watcher  watch.Interface
+	churn    *churn
+	period   time.Duration
+	result   chan watch.Event
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newChurnWatcher(w watch.Interface, c *churn, period time.Duration) *churnWatcher {
+	cw := &churnWatcher{
+		watcher: w,
+		churn:   c,
+		period:  period,
+		result:  make(chan watch.Event),
+		stop:    make(chan struct{}),
+	}
+
+	go cw.run()
+
+	return cw
+}
+
+func (cw *churnWatcher) run() {
+	defer close(cw.result)
+
+	ticker := time.NewTicker(cw.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-cw.watcher.ResultChan():
+			if !ok {
+				return
+			}
+
+			if ns, ok := e.Object.(*v1.Namespace); ok {
+				cw.churn.current(ns.ResourceVersion)
+			}
+
+			if !cw.send(e) {
+				return
+			}
+		case <-ticker.C:
+			old, current := cw.churn.replace()
+
+			if !cw.send(watch.Event{Type: watch.Deleted, Object: &old}) || !cw.send(watch.Event{Type: watch.Added, Object: &current}) {
+				return
+			}
+		case <-cw.stop:
+			return
+		}
+	}
+}
+
+func (cw *churnWatcher) send(e watch.Event) bool {
+	select {
+	case cw.result <- e:
+		return true
+	case <-cw.stop:
+		return false
+	}
+}
+
+func (cw *churnWatcher) Stop() {
+	cw.stopOnce.Do(func() {
+		close(cw.stop)
+		cw.watcher.Stop()
+	})
+}
+
+func (cw *churnWatcher) ResultChan() <-chan watch.Event {
+	return cw.result
+}
@@ -0,0 +1,148 @@
+package faults
+
+import (
+	"context"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type faultyCluster struct {
+	cluster.Cluster
+	faults Faults
+}
+
+// NewCluster injects faults into the clients of a cluster. Creating a
+// client is a call to the cluster too, as it discovers the cluster's APIs.
+func NewCluster(cl cluster.Cluster, faults Faults) cluster.Cluster {
+	if faults == (Faults{}) {
+		return cl
+	}
+
+	return &faultyCluster{Cluster: cl, faults: faults}
+}
+
+func (c *faultyCluster) GetServerClient() (client.Client, error) {
+	if err := c.faults.inject(context.Background(), "creating server client"); err != nil {
+		return nil, err
+	}
+
+	cl, err := c.Cluster.GetServerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultyClient{Client: cl, faults: c.faults}, nil
+}
+
+func (c *faultyCluster) GetUserClient(user *auth.UserPrincipal) (client.Client, error) {
+	if err := c.faults.inject(context.Background(), "creating user client"); err != nil {
+		return nil, err
+	}
+
+	cl, err := c.Cluster.GetUserClient(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultyClient{Client: cl, faults: c.faults}, nil
+}
+
+func (c *faultyCluster) GetServerClientset() (kubernetes.Interface, error) {
+	if err := c.faults.inject(context.Background(), "creating server clientset"); err != nil {
+		return nil, err
+	}
+
+	cs, err := c.Cluster.GetServerClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	return newClientset(cs, c.faults), nil
+}
+
+func (c *faultyCluster) GetUserClientset(user *auth.UserPrincipal) (kubernetes.Interface, error) {
+	if err := c.faults.inject(context.Background(), "creating user clientset"); err != nil {
+		return nil, err
+	}
+
+	cs, err := c.Cluster.GetUserClientset(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClientset(cs, c.faults), nil
+}
+
+// faultyClient injects faults into every call to the cluster.
+type faultyClient struct {
+	client.Client
+	faults Faults
+}
+
+func (c *faultyClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.faults.inject(ctx, "get"); err != nil {
+		return err
+	}
+
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *faultyClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.faults.inject(ctx, "list"); err != nil {
+		return err
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *faultyClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.faults.inject(ctx, "create"); err != nil {
+		return err
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *faultyClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.faults.inject(ctx, "delete"); err != nil {
+		return err
+	}
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *faultyClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.faults.inject(ctx, "update"); err != nil {
+		return err
+	}
+
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *faultyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.faults.inject(ctx, "patch"); err != nil {
+		return err
+	}
+
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *faultyClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if err := c.faults.inject(ctx, "delete all"); err != nil {
+		return err
+	}
+
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *faultyClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	if err := c.faults.inject(ctx, "watch"); err != nil {
+		return nil, err
+	}
+
+	return cluster.Watch(ctx, c.Client, list, opts...)
+}
@@ -0,0 +1,96 @@
+// Package faults injects latency, errors and namespace churn into the
+// clusters of a ClustersManager, to see how the API and UI behave with a
+// degraded fleet without breaking real clusters. It's meant for development
+// and test environments only.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// AllClusters is the key of the faults applying to clusters without their
+// own.
+const AllClusters = "*"
+
+// ErrInjected is returned by calls failed on purpose.
+var ErrInjected = errors.New("injected fault")
+
+// Faults are injected into calls to a cluster.
+type Faults struct {
+	// Latency is added to every call.
+	Latency metav1.Duration `json:"latency,omitempty"`
+	// ErrorRate is the share of calls that fail, from 0 to 1.
+	ErrorRate float64 `json:"errorRate,omitempty"`
+	// NamespaceChurn is how often a phantom namespace is replaced by
+	// another one in the namespaces of the cluster, 0 disables churn.
+	NamespaceChurn metav1.Duration `json:"namespaceChurn,omitempty"`
+}
+
+// Config are the faults of each cluster by name.
+type Config struct {
+	Clusters map[string]Faults `json:"clusters"`
+}
+
+// Parse reads a YAML config, e.g.
+//
+//	clusters:
+//	  "*":
+//	    latency: 200ms
+//	  leaf-1:
+//	    latency: 5s
+//	    errorRate: 0.5
+//	    namespaceChurn: 30s
+func Parse(data []byte) (Config, error) {
+	var c Config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return Config{}, fmt.Errorf("invalid fault injection config: %w", err)
+	}
+
+	for name, f := range c.Clusters {
+		if f.Latency.Duration < 0 || f.NamespaceChurn.Duration < 0 {
+			return Config{}, fmt.Errorf("invalid fault injection config: durations of %s must not be negative", name)
+		}
+
+		if f.ErrorRate < 0 || f.ErrorRate > 1 {
+			return Config{}, fmt.Errorf("invalid fault injection config: error rate of %s must be between 0 and 1", name)
+		}
+	}
+
+	return c, nil
+}
+
+// For returns the faults of a cluster.
+func (c Config) For(clusterName string) Faults {
+	if f, ok := c.Clusters[clusterName]; ok {
+		return f
+	}
+
+	return c.Clusters[AllClusters]
+}
+
+// inject waits for the latency, then fails at the error rate.
+func (f Faults) inject(ctx context.Context, op string) error {
+	if f.Latency.Duration > 0 {
+		timer := time.NewTimer(f.Latency.Duration)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if f.ErrorRate > 0 && rand.Float64() < f.ErrorRate { //nolint:gosec
+		return fmt.Errorf("%s: %w", op, ErrInjected)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package faults_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/faults"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/nsaccess/nsaccessfakes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config, err := faults.Parse([]byte(`
+clusters:
+  "*":
+    latency: 200ms
+  leaf-1:
+    errorRate: 0.5
+    namespaceChurn: 30s
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.For("leaf-1")).To(Equal(faults.Faults{ErrorRate: 0.5, NamespaceChurn: metav1.Duration{Duration: 30 * time.Second}}))
+	g.Expect(config.For("leaf-2")).To(Equal(faults.Faults{Latency: metav1.Duration{Duration: 200 * time.Millisecond}}))
+
+	for _, invalid := range []string{
+		"clusters: {leaf-1: {errorRate: 2}}",
+		"clusters: {leaf-1: {latency: -1s}}",
+		"clusters: {leaf-1: {timeout: 1s}}",
+	} {
+		_, err := faults.Parse([]byte(invalid))
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
+
+func TestFetcherErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf-1")
+	leaf.GetServerClientReturns(ctrlfake.NewClientBuilder().Build(), nil)
+
+	healthy := &clusterfakes.FakeCluster{}
+	healthy.GetNameReturns("leaf-2")
+	healthy.GetServerClientReturns(ctrlfake.NewClientBuilder().Build(), nil)
+
+	clustersFetcher := faults.NewFetcher(fetcherOf(leaf, healthy), faults.Config{Clusters: map[string]faults.Faults{
+		"leaf-1": {ErrorRate: 1},
+	}})
+
+	clusters, err := clustersFetcher.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(2))
+
+	_, err = clusters[0].GetServerClient()
+	g.Expect(errors.Is(err, faults.ErrInjected)).To(BeTrue())
+	g.Expect(leaf.GetServerClientCallCount()).To(BeZero())
+
+	// Clusters without faults aren't wrapped
+	g.Expect(clusters[1]).To(BeIdenticalTo(cluster.Cluster(healthy)))
+}
+
+func TestNamespaceChurn(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf-1")
+	leaf.GetHostReturns("https://leaf-1:6443")
+	leaf.GetServerClientsetReturns(fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}), nil)
+
+	clustersFetcher := faults.NewFetcher(fetcher.NewSingleClusterFetcher(leaf), faults.Config{Clusters: map[string]faults.Faults{
+		faults.AllClusters: {NamespaceChurn: metav1.Duration{Duration: 50 * time.Millisecond}},
+	}})
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{clustersFetcher}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	clustersManager.Start(ctx)
+
+	namespaces := func() []string {
+		names := []string{}
+		for _, ns := range clustersManager.GetClustersNamespaces()["leaf-1"] {
+			names = append(names, ns.Name)
+		}
+
+		return names
+	}
+
+	g.Eventually(namespaces).Should(ConsistOf("apps", "phantom-0"))
+	g.Eventually(namespaces).Should(ConsistOf("apps", "phantom-2"))
+}
+
+type staticFetcher []cluster.Cluster
+
+func (f staticFetcher) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
+	return f, nil
+}
+
+func fetcherOf(clusters ...cluster.Cluster) clustersmngr.ClusterFetcher {
+	return staticFetcher(clusters)
+}
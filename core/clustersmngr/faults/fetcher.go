@@ -0,0 +1,47 @@
+package faults
+
+import (
+	"context"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+)
+
+type fetcher struct {
+	fetcher clustersmngr.ClusterFetcher
+	config  Config
+}
+
+type watchingFetcher struct {
+	fetcher
+	watcher clustersmngr.WatchingClusterFetcher
+}
+
+// NewFetcher injects faults into the clusters of a fetcher.
+func NewFetcher(f clustersmngr.ClusterFetcher, config Config) clustersmngr.ClusterFetcher {
+	wrapped := fetcher{fetcher: f, config: config}
+
+	if w, ok := f.(clustersmngr.WatchingClusterFetcher); ok {
+		return watchingFetcher{fetcher: wrapped, watcher: w}
+	}
+
+	return wrapped
+}
+
+func (f fetcher) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
+	clusters, err := f.fetcher.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	faulty := make([]cluster.Cluster, 0, len(clusters))
+	for _, cl := range clusters {
+		faulty = append(faulty, NewCluster(cl, f.config.For(cl.GetName())))
+	}
+
+	return faulty, nil
+}
+
+func (f watchingFetcher) Watch(ctx context.Context, changed func()) {
+	f.watcher.Watch(ctx, changed)
+}
@@ -0,0 +1,207 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/logger"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CAPIClusterGVK is the kind of the clusters provisioned by Cluster API.
+var CAPIClusterGVK = schema.GroupVersionKind{
+	Group:   "cluster.x-k8s.io",
+	Version: "v1beta1",
+	Kind:    "Cluster",
+}
+
+// capiProvisioned is the phase of Cluster API clusters that can be used.
+const capiProvisioned = "Provisioned"
+
+type capiFetcher struct {
+	log               logr.Logger
+	client            client.Client
+	namespace         string
+	scheme            *apiruntime.Scheme
+	kubeConfigOptions []cluster.KubeConfigOption
+}
+
+// NewCAPIClustersFetcher creates a fetcher of the clusters provisioned by
+// Cluster API in the management cluster.
+//
+// Clusters are named <namespace>/<name> after their Cluster object, and
+// connected to with the kubeconfig Cluster API writes to the
+// <name>-kubeconfig secret, and get the labels of their Cluster object.
+// Clusters are only registered once ready, so clusters still being
+// provisioned, or being deleted, aren't queried.
+//
+// Clusters are read from namespace, or every namespace if it's empty.
+func NewCAPIClustersFetcher(log logr.Logger, c client.Client, namespace string, scheme *apiruntime.Scheme, kubeConfigOptions ...cluster.KubeConfigOption) mngr.ClusterFetcher {
+	return &capiFetcher{
+		log:               log.WithName("capi-clusters"),
+		client:            c,
+		namespace:         namespace,
+		scheme:            scheme,
+		kubeConfigOptions: kubeConfigOptions,
+	}
+}
+
+func newCAPIClusterList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(CAPIClusterGVK.GroupVersion().WithKind(CAPIClusterGVK.Kind + "List"))
+
+	return list
+}
+
+func (f *capiFetcher) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
+	list := newCAPIClusterList()
+
+	if err := f.client.List(ctx, list, client.InNamespace(f.namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			f.log.V(logger.LogLevelDebug).Info("Cluster API CRDs not installed")
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list Cluster API clusters: %w", err)
+	}
+
+	clusters := []cluster.Cluster{}
+
+	for i := range list.Items {
+		capiCluster := &list.Items[i]
+
+		if !capiClusterReady(capiCluster) {
+			f.log.V(logger.LogLevelDebug).Info("Skipping Cluster API cluster that isn't ready", "namespace", capiCluster.GetNamespace(), "name", capiCluster.GetName())
+			continue
+		}
+
+		c, err := f.cluster(ctx, capiCluster)
+		if err != nil {
+			f.log.Error(err, "skipping Cluster API cluster", "namespace", capiCluster.GetNamespace(), "name", capiCluster.GetName())
+			continue
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	return clusters, nil
+}
+
+func (f *capiFetcher) cluster(ctx context.Context, capiCluster *unstructured.Unstructured) (cluster.Cluster, error) {
+	secretName := capiCluster.GetName() + "-kubeconfig"
+
+	secret := corev1.Secret{}
+	if err := f.client.Get(ctx, client.ObjectKey{Namespace: capiCluster.GetNamespace(), Name: secretName}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig secret: %w", err)
+	}
+
+	data, ok := secret.Data[DefaultKubeconfigSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %q", secretName, DefaultKubeconfigSecretKey)
+	}
+
+	// Cluster API writes kubeconfigs with their credentials inline, whoever
+	// else writes the secret can't run commands or read files on the server
+	config, err := CredentialsPolicy{}.restConfig(f.log.WithValues("namespace", secret.Namespace, "name", secret.Name), client.ObjectKeyFromObject(&secret), data, cluster.TLSConfig{}, cluster.CloudCredentials{})
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: %w", secretName, err)
+	}
+
+	c, err := cluster.NewSingleCluster(capiCluster.GetNamespace()+"/"+capiCluster.GetName(), config, f.scheme, f.kubeConfigOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	c = cluster.WithID(c, string(capiCluster.GetUID()))
+
+	return cluster.WithLabels(c, capiCluster.GetLabels()), nil
+}
+
+// capiClusterReady tells whether a cluster is provisioned and its control
+// plane can be queried.
+func capiClusterReady(capiCluster *unstructured.Unstructured) bool {
+	if capiCluster.GetDeletionTimestamp() != nil {
+		return false
+	}
+
+	if phase, _, _ := unstructured.NestedString(capiCluster.Object, "status", "phase"); phase != capiProvisioned {
+		return false
+	}
+
+	controlPlaneReady, _, _ := unstructured.NestedBool(capiCluster.Object, "status", "controlPlaneReady")
+
+	return controlPlaneReady
+}
+
+// Watch calls changed when clusters are created or deleted, or become ready
+// or not. It needs a client that can watch, otherwise changes are only
+// picked up when the clusters are fetched again.
+func (f *capiFetcher) Watch(ctx context.Context, changed func()) {
+	wc, ok := f.client.(client.WithWatch)
+	if !ok {
+		return
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		w, err := wc.Watch(ctx, newCAPIClusterList(), client.InNamespace(f.namespace))
+		if err != nil {
+			if !apimeta.IsNoMatchError(err) {
+				f.log.Error(err, "failed to watch Cluster API clusters")
+			}
+
+			return
+		}
+		defer w.Stop()
+
+		// the readiness of each cluster, status updates are frequent while
+		// provisioning but only readiness changes matter
+		ready := map[client.ObjectKey]bool{}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+
+				if event.Type == watch.Error {
+					f.log.V(logger.LogLevelDebug).Info("Cluster API clusters watch failed, restarting it", "object", event.Object)
+					return
+				}
+
+				capiCluster, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+
+				key := client.ObjectKeyFromObject(capiCluster)
+
+				switch event.Type {
+				case watch.Deleted:
+					delete(ready, key)
+					changed()
+				case watch.Added, watch.Modified:
+					wasReady, seen := ready[key]
+					isReady := capiClusterReady(capiCluster)
+					ready[key] = isReady
+
+					if !seen || wasReady != isReady {
+						changed()
+					}
+				}
+			}
+		}
+	}, watchRetryPeriod)
+}
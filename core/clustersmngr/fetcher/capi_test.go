@@ -0,0 +1,138 @@
+package fetcher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCAPIClustersFetcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme := capiScheme(g)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		capiCluster("ready", "Provisioned", true),
+		kubeconfigSecret("ready-kubeconfig", "https://ready:6443"),
+		capiCluster("provisioning", "Provisioning", false),
+		kubeconfigSecret("provisioning-kubeconfig", "https://provisioning:6443"),
+		capiCluster("no-secret", "Provisioned", true),
+	).Build()
+
+	f := fetcher.NewCAPIClustersFetcher(logr.Discard(), c, "", scheme)
+
+	clusters, err := f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(1))
+	g.Expect(clusters[0].GetName()).To(Equal("fleet/ready"))
+	g.Expect(clusters[0].GetHost()).To(Equal("https://ready:6443"))
+
+	provisioned := capiCluster("provisioning", "Provisioned", true)
+	existing := getCAPICluster(g, c, "provisioning")
+	provisioned.SetResourceVersion(existing.GetResourceVersion())
+	g.Expect(c.Update(ctx, provisioned)).To(Succeed())
+
+	clusters, err = f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(2))
+}
+
+func TestCAPIClustersFetcherWithoutCRD(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	f := fetcher.NewCAPIClustersFetcher(logr.Discard(), fake.NewClientBuilder().WithScheme(scheme).Build(), "", scheme)
+
+	clusters, err := f.Fetch(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(BeEmpty())
+}
+
+func TestCAPIClustersFetcherWatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := capiScheme(g)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	f := fetcher.NewCAPIClustersFetcher(logr.Discard(), c, "", scheme)
+
+	changes := make(chan struct{}, 10)
+
+	go f.(mngr.WatchingClusterFetcher).Watch(ctx, func() { changes <- struct{}{} })
+
+	// the watch may not be started yet, keep updating the cluster until the
+	// change is seen
+	g.Expect(c.Create(ctx, capiCluster("leaf", "Provisioning", false))).To(Succeed())
+	g.Eventually(func() int {
+		cl := getCAPICluster(g, c, "leaf")
+		cl.SetLabels(map[string]string{"touched": time.Now().Format(time.RFC3339Nano)})
+		g.Expect(c.Update(ctx, cl)).To(Succeed())
+
+		return len(changes)
+	}).Should(BeNumerically(">", 0))
+
+	for drained := false; !drained; {
+		select {
+		case <-changes:
+		case <-time.After(100 * time.Millisecond):
+			drained = true
+		}
+	}
+
+	// status updates that don't change the readiness are ignored
+	cl := getCAPICluster(g, c, "leaf")
+	g.Expect(unstructured.SetNestedField(cl.Object, "Provisioning cluster", "status", "message")).To(Succeed())
+	g.Expect(c.Update(ctx, cl)).To(Succeed())
+	g.Consistently(changes, "200ms").ShouldNot(Receive())
+
+	ready := capiCluster("leaf", "Provisioned", true)
+	ready.SetResourceVersion(getCAPICluster(g, c, "leaf").GetResourceVersion())
+	g.Expect(c.Update(ctx, ready)).To(Succeed())
+	g.Eventually(changes).Should(Receive())
+}
+
+func capiScheme(g *WithT) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	scheme.AddKnownTypeWithName(fetcher.CAPIClusterGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(fetcher.CAPIClusterGVK.GroupVersion().WithKind(fetcher.CAPIClusterGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	return scheme
+}
+
+func capiCluster(name, phase string, controlPlaneReady bool) *unstructured.Unstructured {
+	cl := &unstructured.Unstructured{}
+	cl.SetGroupVersionKind(fetcher.CAPIClusterGVK)
+	cl.SetNamespace("fleet")
+	cl.SetName(name)
+	cl.SetCreationTimestamp(metav1.NewTime(time.Now()))
+	_ = unstructured.SetNestedField(cl.Object, phase, "status", "phase")
+	_ = unstructured.SetNestedField(cl.Object, controlPlaneReady, "status", "controlPlaneReady")
+
+	return cl
+}
+
+func getCAPICluster(g *WithT, c client.Client, name string) *unstructured.Unstructured {
+	cl := &unstructured.Unstructured{}
+	cl.SetGroupVersionKind(fetcher.CAPIClusterGVK)
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "fleet", Name: name}, cl)).To(Succeed())
+
+	return cl
+}
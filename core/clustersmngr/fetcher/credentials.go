@@ -0,0 +1,68 @@
+package fetcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CredentialsPolicy is what the operator allows kubeconfig secrets and
+// cluster definitions to authenticate with. Whoever can write them also
+// picks the server the credentials are sent to, so the server's own
+// credentials are only used for the objects the policy allows.
+type CredentialsPolicy struct {
+	// TrustedNamespace is the namespace, only written by the operator, whose
+	// objects may use the exec plugins, auth providers and credential files
+	// of their kubeconfig with the kubeconfig provider. They're removed from
+	// the kubeconfigs of the objects of any other namespace, which would
+	// otherwise run commands or read files on the server.
+	TrustedNamespace string
+	// CloudCredentials lists, for each provider of cloud credentials, the
+	// namespaces of the objects allowed to use it, or single objects as
+	// <namespace>/<name>.
+	CloudCredentials map[string][]string
+}
+
+// ParseCloudCredentials parses the providers of cloud credentials allowed
+// for a namespace or a single object, as <provider>=<namespace>[/<name>],
+// e.g. eks=fleet.
+func ParseCloudCredentials(entries []string) (map[string][]string, error) {
+	allowed := map[string][]string{}
+
+	for _, entry := range entries {
+		provider, target, ok := strings.Cut(entry, "=")
+		if !ok || target == "" {
+			return nil, fmt.Errorf("invalid cloud credentials %q, expected <provider>=<namespace>[/<name>]", entry)
+		}
+
+		switch provider {
+		case cluster.EKSCredentials, cluster.GKECredentials, cluster.AKSCredentials:
+		default:
+			return nil, fmt.Errorf("invalid cloud credentials %q, the provider must be one of %s, %s or %s", entry, cluster.EKSCredentials, cluster.GKECredentials, cluster.AKSCredentials)
+		}
+
+		allowed[provider] = append(allowed[provider], target)
+	}
+
+	return allowed, nil
+}
+
+// trustsKubeconfig tells whether the object may use the local credentials
+// of its kubeconfig.
+func (p CredentialsPolicy) trustsKubeconfig(key client.ObjectKey) bool {
+	return p.TrustedNamespace != "" && key.Namespace == p.TrustedNamespace
+}
+
+// allowsCloudCredentials tells whether the object may authenticate with the
+// server's credentials of the provider.
+func (p CredentialsPolicy) allowsCloudCredentials(provider string, key client.ObjectKey) bool {
+	for _, target := range p.CloudCredentials[provider] {
+		if target == key.Namespace || target == key.String() {
+			return true
+		}
+	}
+
+	return false
+}
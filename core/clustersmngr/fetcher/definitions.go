@@ -0,0 +1,375 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/logger"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterDefinitionGVK is the kind of the objects that declare the leaf
+// clusters in the management cluster.
+var ClusterDefinitionGVK = schema.GroupVersionKind{
+	Group:   "gitops.weave.works",
+	Version: "v1alpha1",
+	Kind:    "GitopsClusterDefinition",
+}
+
+const (
+	// DefaultKubeconfigSecretKey is the key of the kubeconfig in the secret
+	// referenced by a cluster definition, as used by Cluster API.
+	DefaultKubeconfigSecretKey = "value"
+
+	// ReadyCondition is set on cluster definitions once the cluster has
+	// been registered.
+	ReadyCondition = "Ready"
+
+	reasonReachable         = "ClusterReachable"
+	reasonUnreachable       = "ClusterUnreachable"
+	reasonInvalidKubeconfig = "InvalidKubeconfig"
+
+	probeTimeout = 10 * time.Second
+	// How many clusters are probed at the same time.
+	probeConcurrency = 10
+
+	// How long to wait before watching definitions again after the watch
+	// failed or ended.
+	watchRetryPeriod = 10 * time.Second
+)
+
+type definitionsFetcher struct {
+	log               logr.Logger
+	client            client.Client
+	namespace         string
+	scheme            *apiruntime.Scheme
+	policy            CredentialsPolicy
+	kubeConfigOptions []cluster.KubeConfigOption
+}
+
+// NewClusterDefinitionsFetcher creates a fetcher of the clusters declared by
+// GitopsClusterDefinition objects in the management cluster, so the fleet
+// can be managed with GitOps itself.
+//
+// Each definition references a secret holding the cluster's kubeconfig, and
+// may override how the cluster is connected to in spec.tls, and how to
+// authenticate to it in spec.credentials, within what policy allows. The
+// exec plugin, auth provider and credential files of the kubeconfig are
+// removed, unless the kubeconfig provider is set. Clusters are named
+// <namespace>/<name> after their definition, get its labels, and are only
+// registered once they can be reached. The reachability and version of each
+// cluster are written back to the definition's status. Deleting a definition
+// unregisters its cluster on the next refresh.
+//
+// Definitions are read from namespace, or every namespace if it's empty.
+func NewClusterDefinitionsFetcher(log logr.Logger, c client.Client, namespace string, scheme *apiruntime.Scheme, policy CredentialsPolicy, kubeConfigOptions ...cluster.KubeConfigOption) mngr.ClusterFetcher {
+	return &definitionsFetcher{
+		log:               log.WithName("cluster-definitions"),
+		client:            c,
+		namespace:         namespace,
+		scheme:            scheme,
+		policy:            policy,
+		kubeConfigOptions: kubeConfigOptions,
+	}
+}
+
+func (f *definitionsFetcher) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(ClusterDefinitionGVK.GroupVersion().WithKind(ClusterDefinitionGVK.Kind + "List"))
+
+	if err := f.client.List(ctx, &list, client.InNamespace(f.namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			f.log.V(logger.LogLevelDebug).Info("Cluster definitions CRD not installed")
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list cluster definitions: %w", err)
+	}
+
+	// clusters are probed in parallel, so unreachable ones don't hold up
+	// the others for their timeout
+	registered := make([]cluster.Cluster, len(list.Items))
+	slots := make(chan struct{}, probeConcurrency)
+	wg := sync.WaitGroup{}
+
+	for i := range list.Items {
+		def := &list.Items[i]
+
+		// Deleted definitions are drained, they stop being served straight
+		// away.
+		if def.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			c, version, cond := f.register(ctx, def)
+
+			if err := f.writeStatus(ctx, def, version, cond); err != nil {
+				f.log.Error(err, "failed to write cluster definition status", "namespace", def.GetNamespace(), "name", def.GetName())
+			}
+
+			registered[i] = c
+		}(i)
+	}
+
+	wg.Wait()
+
+	clusters := []cluster.Cluster{}
+
+	for _, c := range registered {
+		if c != nil {
+			clusters = append(clusters, c)
+		}
+	}
+
+	return clusters, nil
+}
+
+// Watch calls changed when definitions are created, deleted or their spec
+// changes. It needs a client that can watch, otherwise changes are only
+// picked up when the clusters are fetched again.
+func (f *definitionsFetcher) Watch(ctx context.Context, changed func()) {
+	wc, ok := f.client.(client.WithWatch)
+	if !ok {
+		return
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		list := unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(ClusterDefinitionGVK.GroupVersion().WithKind(ClusterDefinitionGVK.Kind + "List"))
+
+		w, err := wc.Watch(ctx, &list, client.InNamespace(f.namespace))
+		if err != nil {
+			if !apimeta.IsNoMatchError(err) {
+				f.log.Error(err, "failed to watch cluster definitions")
+			}
+
+			return
+		}
+		defer w.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+
+				if event.Type == watch.Error {
+					f.log.V(logger.LogLevelDebug).Info("Cluster definitions watch failed, restarting it", "object", event.Object)
+					return
+				}
+
+				if definitionChanged(event) {
+					changed()
+				}
+			}
+		}
+	}, watchRetryPeriod)
+}
+
+// definitionChanged tells whether a watch event needs the clusters to be
+// fetched again. Status updates, including the ones written by the fetcher,
+// don't.
+func definitionChanged(event watch.Event) bool {
+	if event.Type != watch.Modified {
+		return true
+	}
+
+	def, ok := event.Object.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+
+	observed, found, _ := unstructured.NestedInt64(def.Object, "status", "observedGeneration")
+
+	return !found || observed != def.GetGeneration() || def.GetDeletionTimestamp() != nil
+}
+
+// register creates the cluster of a definition, returning the condition to
+// report. The cluster is nil if it can't be reached.
+func (f *definitionsFetcher) register(ctx context.Context, def *unstructured.Unstructured) (cluster.Cluster, string, metav1.Condition) {
+	name := def.GetNamespace() + "/" + def.GetName()
+
+	config, err := f.restConfig(ctx, def)
+	if err != nil {
+		return nil, "", notReady(reasonInvalidKubeconfig, err)
+	}
+
+	probeConfig := rest.CopyConfig(config)
+	probeConfig.Timeout = probeTimeout
+
+	dc, err := discovery.NewDiscoveryClientForConfig(probeConfig)
+	if err != nil {
+		return nil, "", notReady(reasonInvalidKubeconfig, err)
+	}
+
+	version, err := dc.ServerVersion()
+	if err != nil {
+		return nil, "", notReady(reasonUnreachable, err)
+	}
+
+	c, err := cluster.NewSingleCluster(name, config, f.scheme, f.kubeConfigOptions...)
+	if err != nil {
+		return nil, version.GitVersion, notReady(reasonUnreachable, err)
+	}
+
+	c = cluster.WithID(c, string(def.GetUID()))
+	c = cluster.WithLabels(c, def.GetLabels())
+
+	return c, version.GitVersion, metav1.Condition{
+		Type:    ReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  reasonReachable,
+		Message: fmt.Sprintf("Cluster %s is registered", name),
+	}
+}
+
+// secretRef returns the secret holding the kubeconfig of the definition,
+// and its key in the secret.
+func secretRef(def *unstructured.Unstructured) (client.ObjectKey, string, error) {
+	secretName, _, _ := unstructured.NestedString(def.Object, "spec", "secretRef", "name")
+	if secretName == "" {
+		return client.ObjectKey{}, "", fmt.Errorf("spec.secretRef.name is required")
+	}
+
+	key, _, _ := unstructured.NestedString(def.Object, "spec", "secretRef", "key")
+	if key == "" {
+		key = DefaultKubeconfigSecretKey
+	}
+
+	return client.ObjectKey{Namespace: def.GetNamespace(), Name: secretName}, key, nil
+}
+
+func (f *definitionsFetcher) restConfig(ctx context.Context, def *unstructured.Unstructured) (*rest.Config, error) {
+	secretKey, key, err := secretRef(def)
+	if err != nil {
+		return nil, err
+	}
+
+	secretName := secretKey.Name
+
+	secret := corev1.Secret{}
+	if err := f.client.Get(ctx, secretKey, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig secret: %w", err)
+	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %q", secretName, key)
+	}
+
+	tlsConfig := cluster.TLSConfig{}
+	tlsConfig.ServerName, _, _ = unstructured.NestedString(def.Object, "spec", "tls", "serverName")
+	tlsConfig.ProxyURL, _, _ = unstructured.NestedString(def.Object, "spec", "tls", "proxyURL")
+
+	if caKey, _, _ := unstructured.NestedString(def.Object, "spec", "tls", "caKey"); caKey != "" {
+		if tlsConfig.CAData, ok = secret.Data[caKey]; !ok {
+			return nil, fmt.Errorf("secret %s has no key %q", secretName, caKey)
+		}
+	}
+
+	credentials := cluster.CloudCredentials{}
+	credentials.Provider, _, _ = unstructured.NestedString(def.Object, "spec", "credentials", "provider")
+	credentials.ClusterName, _, _ = unstructured.NestedString(def.Object, "spec", "credentials", "clusterName")
+	credentials.Region, _, _ = unstructured.NestedString(def.Object, "spec", "credentials", "region")
+
+	config, err := f.policy.restConfig(f.log.WithValues("namespace", def.GetNamespace(), "name", def.GetName()), client.ObjectKeyFromObject(def), data, tlsConfig, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: %w", secretName, err)
+	}
+
+	return config, nil
+}
+
+func notReady(reason string, err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    ReadyCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	}
+}
+
+// writeStatus updates the status of a definition, but only if it has
+// changed so refreshing the clusters doesn't keep writing to the API.
+func (f *definitionsFetcher) writeStatus(ctx context.Context, def *unstructured.Unstructured, version string, cond metav1.Condition) error {
+	conditions := []metav1.Condition{}
+
+	raw, _, _ := unstructured.NestedSlice(def.Object, "status", "conditions")
+	for _, r := range raw {
+		u, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		c := metav1.Condition{}
+		if err := apiruntime.DefaultUnstructuredConverter.FromUnstructured(u, &c); err != nil {
+			return fmt.Errorf("invalid status condition: %w", err)
+		}
+
+		conditions = append(conditions, c)
+	}
+
+	cond.ObservedGeneration = def.GetGeneration()
+	apimeta.SetStatusCondition(&conditions, cond)
+
+	rawConditions := []interface{}{}
+
+	for i := range conditions {
+		u, err := apiruntime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i])
+		if err != nil {
+			return err
+		}
+
+		rawConditions = append(rawConditions, u)
+	}
+
+	status := map[string]interface{}{
+		"observedGeneration": def.GetGeneration(),
+		"conditions":         rawConditions,
+	}
+
+	if version != "" {
+		status["kubernetesVersion"] = version
+	} else if previous, found, _ := unstructured.NestedString(def.Object, "status", "kubernetesVersion"); found {
+		// keep the last known version while the cluster is unreachable
+		status["kubernetesVersion"] = previous
+	}
+
+	current, _, _ := unstructured.NestedMap(def.Object, "status")
+	if reflect.DeepEqual(current, status) {
+		return nil
+	}
+
+	if err := unstructured.SetNestedMap(def.Object, status, "status"); err != nil {
+		return err
+	}
+
+	return f.client.Status().Update(ctx, def)
+}
@@ -0,0 +1,242 @@
+package fetcher_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterDefinitionsFetcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(rw, r)
+			return
+		}
+
+		fmt.Fprint(rw, `{"major": "1", "minor": "25", "gitVersion": "v1.25.4"}`)
+	}))
+	defer apiServer.Close()
+
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	scheme.AddKnownTypeWithName(fetcher.ClusterDefinitionGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(fetcher.ClusterDefinitionGVK.GroupVersion().WithKind(fetcher.ClusterDefinitionGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		kubeconfigSecret("leaf-kubeconfig", apiServer.URL),
+		kubeconfigSecret("stopped-kubeconfig", stopped.URL),
+		clusterDefinition("leaf", "leaf-kubeconfig"),
+		clusterDefinition("stopped", "stopped-kubeconfig"),
+		clusterDefinition("no-secret", "missing"),
+	).Build()
+
+	f := fetcher.NewClusterDefinitionsFetcher(logr.Discard(), c, "", scheme, fetcher.CredentialsPolicy{})
+
+	clusters, err := f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(1))
+	g.Expect(clusters[0].GetName()).To(Equal("fleet/leaf"))
+	g.Expect(clusters[0].GetHost()).To(Equal(apiServer.URL))
+
+	leaf := readyCondition(g, c, "leaf")
+	g.Expect(leaf.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(getDefinition(g, c, "leaf").Object["status"]).To(HaveKeyWithValue("kubernetesVersion", "v1.25.4"))
+
+	g.Expect(readyCondition(g, c, "stopped").Reason).To(Equal("ClusterUnreachable"))
+	g.Expect(readyCondition(g, c, "no-secret").Reason).To(Equal("InvalidKubeconfig"))
+
+	// Unchanged statuses aren't written again
+	resourceVersion := getDefinition(g, c, "leaf").GetResourceVersion()
+	_, err = f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(getDefinition(g, c, "leaf").GetResourceVersion()).To(Equal(resourceVersion))
+
+	g.Expect(c.Delete(ctx, getDefinition(g, c, "leaf"))).To(Succeed())
+
+	clusters, err = f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(BeEmpty())
+}
+
+func TestClusterDefinitionsFetcherProbesInParallel(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	const count = 3
+
+	// each cluster only answers once they're all being probed
+	probing := sync.WaitGroup{}
+	probing.Add(count)
+
+	allProbing := make(chan struct{})
+	go func() {
+		probing.Wait()
+		close(allProbing)
+	}()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		probing.Done()
+
+		select {
+		case <-allProbing:
+			fmt.Fprint(rw, `{"major": "1", "minor": "25", "gitVersion": "v1.25.4"}`)
+		case <-time.After(2 * time.Second):
+			http.Error(rw, "probed one at a time", http.StatusServiceUnavailable)
+		}
+	}))
+	defer apiServer.Close()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	scheme.AddKnownTypeWithName(fetcher.ClusterDefinitionGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(fetcher.ClusterDefinitionGVK.GroupVersion().WithKind(fetcher.ClusterDefinitionGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("leaf-%d", i)
+		builder = builder.WithObjects(kubeconfigSecret(name+"-kubeconfig", apiServer.URL), clusterDefinition(name, name+"-kubeconfig"))
+	}
+
+	f := fetcher.NewClusterDefinitionsFetcher(logr.Discard(), builder.Build(), "", scheme, fetcher.CredentialsPolicy{})
+
+	clusters, err := f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(count))
+}
+
+func TestClusterDefinitionsFetcherWithoutCRD(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	f := fetcher.NewClusterDefinitionsFetcher(logr.Discard(), fake.NewClientBuilder().WithScheme(scheme).Build(), "", scheme, fetcher.CredentialsPolicy{})
+
+	clusters, err := f.Fetch(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(BeEmpty())
+}
+
+func kubeconfigSecret(name, server string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet"},
+		Data: map[string][]byte{
+			fetcher.DefaultKubeconfigSecretKey: []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: leaf
+  cluster:
+    server: %s
+contexts:
+- name: leaf
+  context:
+    cluster: leaf
+    user: leaf
+current-context: leaf
+users:
+- name: leaf
+  user:
+    token: my-token
+`, server)),
+		},
+	}
+}
+
+func clusterDefinition(name, secretName string) *unstructured.Unstructured {
+	def := &unstructured.Unstructured{}
+	def.SetGroupVersionKind(fetcher.ClusterDefinitionGVK)
+	def.SetNamespace("fleet")
+	def.SetName(name)
+	def.SetCreationTimestamp(metav1.NewTime(time.Now()))
+	_ = unstructured.SetNestedField(def.Object, secretName, "spec", "secretRef", "name")
+
+	return def
+}
+
+func getDefinition(g *WithT, c client.Client, name string) *unstructured.Unstructured {
+	def := &unstructured.Unstructured{}
+	def.SetGroupVersionKind(fetcher.ClusterDefinitionGVK)
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "fleet", Name: name}, def)).To(Succeed())
+
+	return def
+}
+
+func readyCondition(g *WithT, c client.Client, name string) metav1.Condition {
+	conditions, _, _ := unstructured.NestedSlice(getDefinition(g, c, name).Object, "status", "conditions")
+	g.Expect(conditions).To(HaveLen(1))
+
+	cond := metav1.Condition{}
+	g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(conditions[0].(map[string]interface{}), &cond)).To(Succeed())
+	g.Expect(cond.Type).To(Equal(fetcher.ReadyCondition))
+
+	return cond
+}
+
+func TestClusterDefinitionsFetcherWatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	scheme.AddKnownTypeWithName(fetcher.ClusterDefinitionGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(fetcher.ClusterDefinitionGVK.GroupVersion().WithKind(fetcher.ClusterDefinitionGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	f := fetcher.NewClusterDefinitionsFetcher(logr.Discard(), c, "", scheme, fetcher.CredentialsPolicy{})
+
+	changes := make(chan struct{}, 10)
+
+	go f.(mngr.WatchingClusterFetcher).Watch(ctx, func() { changes <- struct{}{} })
+
+	// the watch may not be started yet, keep creating definitions until
+	// one is seen
+	created := 0
+	g.Eventually(func() int {
+		g.Expect(c.Create(ctx, clusterDefinition(fmt.Sprintf("leaf-%d", created), "leaf-kubeconfig"))).To(Succeed())
+		created++
+
+		return len(changes)
+	}).Should(BeNumerically(">", 0))
+
+	// drain the events of the other definitions
+	for drained := false; !drained; {
+		select {
+		case <-changes:
+		case <-time.After(100 * time.Millisecond):
+			drained = true
+		}
+	}
+
+	// status updates don't need the clusters to be fetched again
+	def := getDefinition(g, c, "leaf-0")
+	g.Expect(unstructured.SetNestedField(def.Object, def.GetGeneration(), "status", "observedGeneration")).To(Succeed())
+	g.Expect(c.Update(ctx, def)).To(Succeed())
+	g.Consistently(changes, "200ms").ShouldNot(Receive())
+
+	g.Expect(c.Delete(ctx, def)).To(Succeed())
+	g.Eventually(changes).Should(Receive())
+}
@@ -0,0 +1,267 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ClusterNameAnnotation overrides the name of the cluster of a kubeconfig
+	// secret, by default <namespace>/<name> of the secret.
+	ClusterNameAnnotation = "clusters.weave.works/name"
+	// KubeconfigKeyAnnotation overrides the key of the kubeconfig in a
+	// kubeconfig secret, by default DefaultKubeconfigSecretKey.
+	KubeconfigKeyAnnotation = "clusters.weave.works/kubeconfig-key"
+	// CAKeyAnnotation is the key of a PEM bundle in a kubeconfig secret,
+	// trusted on top of the kubeconfig's CA.
+	CAKeyAnnotation = "clusters.weave.works/ca-key"
+	// TLSServerNameAnnotation overrides the server name the certificate of
+	// the cluster is checked against.
+	TLSServerNameAnnotation = "clusters.weave.works/tls-server-name"
+	// ProxyURLAnnotation overrides the proxy the cluster is reached through.
+	ProxyURLAnnotation = "clusters.weave.works/proxy-url"
+	// CredentialsAnnotation authenticates to the cluster with the server's
+	// cloud identity instead of the kubeconfig's exec plugin, one of eks,
+	// gke, aks or auto, if the CredentialsPolicy allows it, or with the exec
+	// plugin, auth provider and credential files of the kubeconfig if set to
+	// kubeconfig in the trusted namespace of the policy. They're removed
+	// otherwise.
+	CredentialsAnnotation = "clusters.weave.works/credentials"
+)
+
+type secretsFetcher struct {
+	log               logr.Logger
+	client            client.Client
+	namespace         string
+	selector          labels.Selector
+	scheme            *apiruntime.Scheme
+	policy            CredentialsPolicy
+	kubeConfigOptions []cluster.KubeConfigOption
+}
+
+// NewKubeconfigSecretsFetcher creates a fetcher of the clusters whose
+// kubeconfig is in a secret matching selector, so leaf clusters can be
+// registered by labelling the secrets Cluster API or other tools already
+// create.
+//
+// Secrets are read from namespace, or every namespace if it's empty. The
+// kubeconfig is read from the DefaultKubeconfigSecretKey key, and the
+// cluster is named <namespace>/<name> after the secret, unless set by the
+// KubeconfigKeyAnnotation and ClusterNameAnnotation annotations. The
+// CAKeyAnnotation, TLSServerNameAnnotation and ProxyURLAnnotation annotations
+// override how the cluster is connected to, and CredentialsAnnotation how to
+// authenticate to it, within what policy allows. Clusters are labelled with
+// the labels of their secret. Secrets that don't hold a valid kubeconfig are
+// skipped.
+func NewKubeconfigSecretsFetcher(log logr.Logger, c client.Client, namespace string, selector labels.Selector, scheme *apiruntime.Scheme, policy CredentialsPolicy, kubeConfigOptions ...cluster.KubeConfigOption) mngr.ClusterFetcher {
+	return &secretsFetcher{
+		log:               log.WithName("kubeconfig-secrets"),
+		client:            c,
+		namespace:         namespace,
+		selector:          selector,
+		scheme:            scheme,
+		policy:            policy,
+		kubeConfigOptions: kubeConfigOptions,
+	}
+}
+
+func (f *secretsFetcher) Fetch(ctx context.Context) ([]cluster.Cluster, error) {
+	list := corev1.SecretList{}
+
+	if err := f.client.List(ctx, &list, client.InNamespace(f.namespace), client.MatchingLabelsSelector{Selector: f.selector}); err != nil {
+		return nil, fmt.Errorf("failed to list kubeconfig secrets: %w", err)
+	}
+
+	clusters := []cluster.Cluster{}
+
+	for i := range list.Items {
+		secret := &list.Items[i]
+
+		if secret.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		c, err := f.cluster(secret)
+		if err != nil {
+			f.log.Error(err, "skipping kubeconfig secret", "namespace", secret.Namespace, "name", secret.Name)
+			continue
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	return clusters, nil
+}
+
+// kubeconfigKey returns the key of the kubeconfig in a kubeconfig secret.
+func kubeconfigKey(secret *corev1.Secret) string {
+	if key := secret.Annotations[KubeconfigKeyAnnotation]; key != "" {
+		return key
+	}
+
+	return DefaultKubeconfigSecretKey
+}
+
+func (f *secretsFetcher) cluster(secret *corev1.Secret) (cluster.Cluster, error) {
+	key := kubeconfigKey(secret)
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret has no key %q", key)
+	}
+
+	tlsConfig := cluster.TLSConfig{
+		ServerName: secret.Annotations[TLSServerNameAnnotation],
+		ProxyURL:   secret.Annotations[ProxyURLAnnotation],
+	}
+
+	if caKey := secret.Annotations[CAKeyAnnotation]; caKey != "" {
+		if tlsConfig.CAData, ok = secret.Data[caKey]; !ok {
+			return nil, fmt.Errorf("secret has no key %q", caKey)
+		}
+	}
+
+	credentials := cluster.CloudCredentials{Provider: secret.Annotations[CredentialsAnnotation]}
+
+	config, err := f.policy.restConfig(f.log.WithValues("namespace", secret.Namespace, "name", secret.Name), client.ObjectKeyFromObject(secret), data, tlsConfig, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	name := secret.Annotations[ClusterNameAnnotation]
+	if name == "" {
+		name = secret.Namespace + "/" + secret.Name
+	}
+
+	c, err := cluster.NewSingleCluster(name, config, f.scheme, f.kubeConfigOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the name may come from an annotation, the secret stays the same when
+	// it changes
+	c = cluster.WithID(c, string(secret.UID))
+
+	return cluster.WithLabels(c, secret.Labels), nil
+}
+
+// Watch calls changed when matching secrets are created, updated or deleted,
+// or stop matching the selector. It needs a client that can watch, otherwise
+// changes are only picked up when the clusters are fetched again.
+func (f *secretsFetcher) Watch(ctx context.Context, changed func()) {
+	wc, ok := f.client.(client.WithWatch)
+	if !ok {
+		return
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		w, err := wc.Watch(ctx, &corev1.SecretList{}, client.InNamespace(f.namespace), client.MatchingLabelsSelector{Selector: f.selector})
+		if err != nil {
+			f.log.Error(err, "failed to watch kubeconfig secrets")
+			return
+		}
+		defer w.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+
+				if event.Type == watch.Error {
+					f.log.V(logger.LogLevelDebug).Info("Kubeconfig secrets watch failed, restarting it", "object", event.Object)
+					return
+				}
+
+				if f.secretChanged(event) {
+					changed()
+				}
+			}
+		}
+	}, watchRetryPeriod)
+}
+
+// secretChanged tells whether a watch event needs the clusters to be fetched
+// again. The API server only sends events of matching secrets, and a
+// deletion when one stops matching, but not every client filters them.
+func (f *secretsFetcher) secretChanged(event watch.Event) bool {
+	switch event.Type {
+	case watch.Deleted:
+		return true
+	case watch.Added, watch.Modified:
+		secret, ok := event.Object.(*corev1.Secret)
+
+		return !ok || f.selector.Matches(labels.Set(secret.Labels))
+	default:
+		return false
+	}
+}
+
+// restConfig returns the REST config of a kubeconfig, connecting as set by
+// tlsConfig and authenticating with credentials if their provider is set and
+// allowed for the object the kubeconfig comes from. The exec plugins, auth
+// providers and credential files of the kubeconfig are removed unless the
+// provider is cluster.KubeconfigCredentials and the object is in the trusted
+// namespace, so whoever can write the kubeconfig can't run commands or read
+// files on the server.
+func (p CredentialsPolicy) restConfig(log logr.Logger, key client.ObjectKey, data []byte, tlsConfig cluster.TLSConfig, credentials cluster.CloudCredentials) (*rest.Config, error) {
+	kubeconfig, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+
+	trusted := credentials.Provider == cluster.KubeconfigCredentials && p.trustsKubeconfig(key)
+
+	if credentials.Provider == cluster.KubeconfigCredentials && !trusted {
+		log.Info("Ignoring kubeconfig credentials outside the trusted namespace", "trustedNamespace", p.TrustedNamespace)
+	}
+
+	if settings := cluster.LocalCredentials(kubeconfig); len(settings) > 0 && !trusted {
+		log.Info("Ignoring kubeconfig settings that run commands or read files on the server", "settings", settings)
+		cluster.RemoveCredentialFiles(kubeconfig)
+	}
+
+	config, err := clientcmd.NewDefaultClientConfig(*kubeconfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+
+	if err := tlsConfig.Apply(config); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
+
+	// the cloud credentials read the exec plugin's arguments, and are only
+	// sent to the server once it and the proxy are known
+	if credentials.Provider != "" {
+		if provider := credentials.ProviderFor(config); provider != "" && !p.allowsCloudCredentials(provider, key) {
+			return nil, fmt.Errorf("%s credentials are not allowed for %s", provider, key)
+		}
+
+		if err := credentials.Apply(config); err != nil {
+			return nil, err
+		}
+	}
+
+	if !trusted {
+		config.ExecProvider = nil
+		config.AuthProvider = nil
+	}
+
+	return config, nil
+}
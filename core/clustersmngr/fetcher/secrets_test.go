@@ -0,0 +1,304 @@
+package fetcher_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKubeconfigSecretsFetcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	leaf := labelledSecret("leaf", "https://leaf:6443")
+
+	named := labelledSecret("named", "https://named:6443")
+	named.UID = "named-uid"
+	named.Annotations = map[string]string{
+		fetcher.ClusterNameAnnotation:   "production",
+		fetcher.KubeconfigKeyAnnotation: "kubeconfig",
+		fetcher.TLSServerNameAnnotation: "api.named",
+	}
+	named.Data["kubeconfig"] = named.Data[fetcher.DefaultKubeconfigSecretKey]
+	delete(named.Data, fetcher.DefaultKubeconfigSecretKey)
+
+	invalid := labelledSecret("invalid", "")
+	invalid.Data[fetcher.DefaultKubeconfigSecretKey] = []byte("not a kubeconfig")
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		leaf,
+		named,
+		invalid,
+		kubeconfigSecret("unlabelled", "https://unlabelled:6443"),
+	).Build()
+
+	f := fetcher.NewKubeconfigSecretsFetcher(logr.Discard(), c, "", labels.SelectorFromSet(labels.Set{"weave.works/cluster": "true"}), scheme, fetcher.CredentialsPolicy{})
+
+	clusters, err := f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	hosts := map[string]string{}
+	serverNames := map[string]string{}
+	ids := map[string]string{}
+
+	for _, cl := range clusters {
+		hosts[cl.GetName()] = cl.GetHost()
+		ids[cl.GetName()] = cl.GetID()
+
+		config, err := cl.GetServerConfig()
+		g.Expect(err).NotTo(HaveOccurred())
+		serverNames[cl.GetName()] = config.ServerName
+
+		g.Expect(cl.GetLabels()).To(HaveKeyWithValue("weave.works/cluster", "true"))
+	}
+
+	g.Expect(hosts).To(Equal(map[string]string{
+		"fleet/leaf": "https://leaf:6443",
+		"production": "https://named:6443",
+	}))
+	// renaming the cluster with the annotation keeps the secret's UID
+	g.Expect(ids).To(Equal(map[string]string{
+		"fleet/leaf": "fleet/leaf",
+		"production": "named-uid",
+	}))
+	g.Expect(serverNames).To(Equal(map[string]string{
+		"fleet/leaf": "",
+		"production": "api.named",
+	}))
+
+	g.Expect(c.Delete(ctx, leaf)).To(Succeed())
+
+	clusters, err = f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	names := []string{}
+	for _, cl := range clusters {
+		names = append(names, cl.GetName())
+	}
+
+	sort.Strings(names)
+	g.Expect(names).To(Equal([]string{"production"}))
+}
+
+func TestKubeconfigSecretsFetcherWatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	f := fetcher.NewKubeconfigSecretsFetcher(logr.Discard(), c, "", labels.SelectorFromSet(labels.Set{"weave.works/cluster": "true"}), scheme, fetcher.CredentialsPolicy{})
+
+	changes := make(chan struct{}, 10)
+
+	go f.(mngr.WatchingClusterFetcher).Watch(ctx, func() { changes <- struct{}{} })
+
+	// the watch may not be started yet, keep creating secrets until one is
+	// seen
+	created := 0
+	g.Eventually(func() int {
+		g.Expect(c.Create(ctx, labelledSecret(fmt.Sprintf("leaf-%d", created), "https://leaf:6443"))).To(Succeed())
+		created++
+
+		return len(changes)
+	}).Should(BeNumerically(">", 0))
+
+	for drained := false; !drained; {
+		select {
+		case <-changes:
+		case <-time.After(100 * time.Millisecond):
+			drained = true
+		}
+	}
+
+	// secrets that don't match the selector are ignored
+	g.Expect(c.Create(ctx, kubeconfigSecret("unlabelled", "https://unlabelled:6443"))).To(Succeed())
+	g.Consistently(changes, "200ms").ShouldNot(Receive())
+
+	g.Expect(c.Delete(ctx, labelledSecret("leaf-0", ""))).To(Succeed())
+	g.Eventually(changes).Should(Receive())
+}
+
+func TestKubeconfigSecretsFetcherLocalCredentials(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	// the files need to exist for the kubeconfig to be valid
+	dir := t.TempDir()
+	for _, name := range []string{"token", "tls.crt", "tls.key"} {
+		g.Expect(os.WriteFile(filepath.Join(dir, name), []byte("secret"), 0o600)).To(Succeed())
+	}
+
+	kubeconfig := []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: leaf
+  cluster:
+    server: https://leaf:6443
+contexts:
+- name: leaf
+  context:
+    cluster: leaf
+    user: leaf
+current-context: leaf
+users:
+- name: leaf
+  user:
+    tokenFile: %[1]s/token
+    client-certificate: %[1]s/tls.crt
+    client-key: %[1]s/tls.key
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: sh
+      args: ["-c", "id"]
+`, dir))
+
+	untrusted := labelledSecret("untrusted", "")
+	untrusted.Data[fetcher.DefaultKubeconfigSecretKey] = kubeconfig
+
+	trusted := labelledSecret("trusted", "")
+	trusted.Data[fetcher.DefaultKubeconfigSecretKey] = kubeconfig
+	trusted.Annotations = map[string]string{fetcher.CredentialsAnnotation: cluster.KubeconfigCredentials}
+
+	// asks for the kubeconfig credentials outside the trusted namespace
+	tenant := labelledSecret("trusted", "")
+	tenant.Namespace = "tenant"
+	tenant.Data[fetcher.DefaultKubeconfigSecretKey] = kubeconfig
+	tenant.Annotations = map[string]string{fetcher.CredentialsAnnotation: cluster.KubeconfigCredentials}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(untrusted, trusted, tenant).Build()
+
+	f := fetcher.NewKubeconfigSecretsFetcher(logr.Discard(), c, "", labels.SelectorFromSet(labels.Set{"weave.works/cluster": "true"}), scheme, fetcher.CredentialsPolicy{TrustedNamespace: "fleet"})
+
+	clusters, err := f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(3))
+
+	for _, cl := range clusters {
+		config, err := cl.GetServerConfig()
+		g.Expect(err).NotTo(HaveOccurred())
+
+		if cl.GetName() != "fleet/trusted" {
+			g.Expect(config.ExecProvider).To(BeNil())
+			g.Expect(config.BearerToken).To(BeEmpty())
+			g.Expect(config.BearerTokenFile).To(BeEmpty())
+			g.Expect(config.CertFile).To(BeEmpty())
+			g.Expect(config.KeyFile).To(BeEmpty())
+			g.Expect(config.CertData).To(BeEmpty())
+		} else {
+			g.Expect(config.ExecProvider).NotTo(BeNil())
+			g.Expect(config.BearerToken).To(Equal("secret"))
+			g.Expect(config.CertFile).To(Equal(filepath.Join(dir, "tls.crt")))
+		}
+	}
+}
+
+func TestKubeconfigSecretsFetcherCloudCredentials(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	eksSecret := func(name, server string) *corev1.Secret {
+		secret := labelledSecret(name, "")
+		secret.Annotations = map[string]string{fetcher.CredentialsAnnotation: cluster.AutoCredentials}
+		secret.Data[fetcher.DefaultKubeconfigSecretKey] = []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: leaf
+  cluster:
+    server: %s
+contexts:
+- name: leaf
+  context:
+    cluster: leaf
+    user: leaf
+current-context: leaf
+users:
+- name: leaf
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: ["--region", "eu-west-1", "eks", "get-token", "--cluster-name", "%s"]
+`, server, name))
+
+		return secret
+	}
+
+	allowed := eksSecret("allowed", "https://ABCDEF.gr7.eu-west-1.eks.amazonaws.com")
+	notAllowed := eksSecret("not-allowed", "https://ABCDEF.gr7.eu-west-1.eks.amazonaws.com")
+	otherServer := eksSecret("other-server", "https://attacker.example.com")
+	proxied := eksSecret("proxied", "https://ABCDEF.gr7.eu-west-1.eks.amazonaws.com")
+	proxied.Annotations[fetcher.ProxyURLAnnotation] = "http://attacker.example.com:3128"
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(allowed, notAllowed, otherServer, proxied).Build()
+
+	cloudCredentials, err := fetcher.ParseCloudCredentials([]string{"eks=fleet/allowed", "eks=fleet/other-server", "eks=fleet/proxied", "gke=fleet"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	f := fetcher.NewKubeconfigSecretsFetcher(logr.Discard(), c, "", labels.SelectorFromSet(labels.Set{"weave.works/cluster": "true"}), scheme, fetcher.CredentialsPolicy{CloudCredentials: cloudCredentials})
+
+	clusters, err := f.Fetch(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(1))
+	g.Expect(clusters[0].GetName()).To(Equal("fleet/allowed"))
+
+	config, err := clusters[0].GetServerConfig()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.ExecProvider).To(BeNil())
+	g.Expect(config.WrapTransport).NotTo(BeNil())
+}
+
+func TestParseCloudCredentials(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	allowed, err := fetcher.ParseCloudCredentials([]string{"eks=fleet", "eks=team-a/production", "aks=fleet"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allowed).To(Equal(map[string][]string{
+		"eks": {"fleet", "team-a/production"},
+		"aks": {"fleet"},
+	}))
+
+	for _, entry := range []string{"fleet", "eks=", "kubeconfig=fleet", "auto=fleet"} {
+		_, err := fetcher.ParseCloudCredentials([]string{entry})
+		g.Expect(err).To(HaveOccurred(), entry)
+	}
+}
+
+func labelledSecret(name, server string) *corev1.Secret {
+	secret := kubeconfigSecret(name, server)
+	secret.Labels = map[string]string{"weave.works/cluster": "true"}
+
+	return secret
+}
@@ -0,0 +1,191 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// kubeconfigRef is the key of a kubeconfig in a secret.
+type kubeconfigRef struct {
+	secret client.ObjectKey
+	key    string
+}
+
+// kubeconfigSource is implemented by the fetchers reading kubeconfigs from
+// secrets the operator maintains.
+type kubeconfigSource interface {
+	kubeconfigRefs(ctx context.Context) ([]kubeconfigRef, error)
+}
+
+type tokenStore struct {
+	client  client.Client
+	sources []kubeconfigSource
+}
+
+// NewTokenStore creates a cluster.TokenStore writing renewed tokens back to
+// the kubeconfig secrets of fetchers, so they survive a restart of the
+// server. Secrets are patched with their resourceVersion, and read again if
+// they changed in the meantime. The kubeconfigs of Cluster API clusters are
+// owned by Cluster API, and left alone.
+//
+// The service account must be allowed to patch the secrets.
+func NewTokenStore(c client.Client, fetchers []mngr.ClusterFetcher) cluster.TokenStore {
+	s := &tokenStore{client: c}
+
+	for _, f := range fetchers {
+		if source, ok := f.(kubeconfigSource); ok {
+			s.sources = append(s.sources, source)
+		}
+	}
+
+	return s
+}
+
+func (s *tokenStore) UpdateToken(ctx context.Context, oldToken, newToken string) error {
+	updated := false
+
+	for _, source := range s.sources {
+		refs, err := source.kubeconfigRefs(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, ref := range refs {
+			ok, err := s.updateSecret(ctx, ref, oldToken, newToken)
+			if err != nil {
+				return fmt.Errorf("failed to update kubeconfig secret %s: %w", ref.secret, err)
+			}
+
+			updated = updated || ok
+		}
+	}
+
+	if !updated {
+		return fmt.Errorf("no kubeconfig secret holds the token")
+	}
+
+	return nil
+}
+
+// updateSecret replaces the token in the kubeconfig of ref, and tells
+// whether it held it.
+func (s *tokenStore) updateSecret(ctx context.Context, ref kubeconfigRef, oldToken, newToken string) (bool, error) {
+	updated := false
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updated = false
+
+		secret := &corev1.Secret{}
+		if err := s.client.Get(ctx, ref.secret, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		data, ok, err := replaceToken(secret.Data[ref.key], oldToken, newToken)
+		if err != nil || !ok {
+			return err
+		}
+
+		patch := client.MergeFromWithOptions(secret.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		secret.Data[ref.key] = data
+
+		if err := s.client.Patch(ctx, secret, patch); err != nil {
+			return err
+		}
+
+		updated = true
+
+		return nil
+	})
+
+	return updated, err
+}
+
+// replaceToken replaces the token of the users of a kubeconfig, and tells
+// whether any held it.
+func replaceToken(data []byte, oldToken, newToken string) ([]byte, bool, error) {
+	if len(data) == 0 {
+		return nil, false, nil
+	}
+
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	replaced := false
+
+	for _, authInfo := range config.AuthInfos {
+		if authInfo.Token == oldToken {
+			authInfo.Token = newToken
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		return nil, false, nil
+	}
+
+	data, err = clientcmd.Write(*config)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+func (f *secretsFetcher) kubeconfigRefs(ctx context.Context) ([]kubeconfigRef, error) {
+	list := corev1.SecretList{}
+
+	if err := f.client.List(ctx, &list, client.InNamespace(f.namespace), client.MatchingLabelsSelector{Selector: f.selector}); err != nil {
+		return nil, fmt.Errorf("failed to list kubeconfig secrets: %w", err)
+	}
+
+	refs := []kubeconfigRef{}
+
+	for i := range list.Items {
+		secret := &list.Items[i]
+		refs = append(refs, kubeconfigRef{secret: client.ObjectKeyFromObject(secret), key: kubeconfigKey(secret)})
+	}
+
+	return refs, nil
+}
+
+func (f *definitionsFetcher) kubeconfigRefs(ctx context.Context) ([]kubeconfigRef, error) {
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(ClusterDefinitionGVK.GroupVersion().WithKind(ClusterDefinitionGVK.Kind + "List"))
+
+	if err := f.client.List(ctx, &list, client.InNamespace(f.namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list cluster definitions: %w", err)
+	}
+
+	refs := []kubeconfigRef{}
+
+	for i := range list.Items {
+		secret, key, err := secretRef(&list.Items[i])
+		if err != nil {
+			continue
+		}
+
+		refs = append(refs, kubeconfigRef{secret: secret, key: key})
+	}
+
+	return refs, nil
+}
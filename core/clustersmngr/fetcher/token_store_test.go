@@ -0,0 +1,53 @@
+package fetcher_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	mngr "github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTokenStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	leaf := labelledSecret("leaf", "https://leaf:6443")
+	unlabelled := kubeconfigSecret("unlabelled", "https://unlabelled:6443")
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(leaf, unlabelled).Build()
+
+	fetchers := []mngr.ClusterFetcher{
+		fetcher.NewKubeconfigSecretsFetcher(logr.Discard(), c, "", labels.SelectorFromSet(labels.Set{"weave.works/cluster": "true"}), scheme, fetcher.CredentialsPolicy{}),
+	}
+
+	store := fetcher.NewTokenStore(c, fetchers)
+
+	g.Expect(store.UpdateToken(ctx, "my-token", "renewed")).To(Succeed())
+
+	tokenOf := func(secret *corev1.Secret) string {
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+
+		config, err := clientcmd.Load(secret.Data[fetcher.DefaultKubeconfigSecretKey])
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return config.AuthInfos["leaf"].Token
+	}
+
+	g.Expect(tokenOf(leaf)).To(Equal("renewed"))
+	// only the secrets of the fetchers are written to
+	g.Expect(tokenOf(unlabelled)).To(Equal("my-token"))
+
+	g.Expect(store.UpdateToken(ctx, "my-token", "renewed-again")).To(MatchError(ContainSubstring("no kubeconfig secret holds the token")))
+}
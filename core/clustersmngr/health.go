@@ -0,0 +1,104 @@
+package clustersmngr
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// How many resync periods the clusters may go without being fetched before
+// the manager is considered stuck, or without being fetched successfully
+// before it's considered not ready.
+const healthResyncPeriods = 3
+
+// Health is the state of the clusters manager, for the liveness and
+// readiness probes of the server.
+type Health struct {
+	// Live is false when the clusters haven't been fetched for several
+	// resync periods, i.e. the manager is stuck.
+	Live bool `json:"live"`
+	// Ready is false until the clusters were loaded, and when fetching them
+	// kept failing for several resync periods.
+	Ready bool `json:"ready"`
+	// Reason is why the manager isn't live or ready.
+	Reason string `json:"reason,omitempty"`
+	// Loaded is whether the clusters were fetched once since the manager
+	// started, successfully or not.
+	Loaded bool `json:"loaded"`
+	// LastFetch is when the clusters were last fetched, and
+	// LastSuccessfulFetch when fetching them last succeeded.
+	LastFetch           time.Time `json:"lastFetch"`
+	LastSuccessfulFetch time.Time `json:"lastSuccessfulFetch"`
+	// Clusters is the number of known clusters, ReachableClusters the ones
+	// that answered the last time they were checked.
+	Clusters          int `json:"clusters"`
+	ReachableClusters int `json:"reachableClusters"`
+	// NamespacesCached is the number of clusters whose namespaces are
+	// cached, and OldestNamespacesUpdate the oldest time the namespaces of
+	// one of them were last updated.
+	NamespacesCached       int       `json:"namespacesCached"`
+	OldestNamespacesUpdate time.Time `json:"oldestNamespacesUpdate"`
+}
+
+// fetchHealth records when the manager started and fetched its clusters.
+type fetchHealth struct {
+	sync.RWMutex
+	started             time.Time
+	loaded              bool
+	lastFetch           time.Time
+	lastSuccessfulFetch time.Time
+}
+
+func (fh *fetchHealth) setStarted(now time.Time) {
+	fh.Lock()
+	defer fh.Unlock()
+
+	fh.started = now
+}
+
+func (fh *fetchHealth) setLoaded() {
+	fh.Lock()
+	defer fh.Unlock()
+
+	fh.loaded = true
+}
+
+func (fh *fetchHealth) setFetched(now time.Time, err error) {
+	fh.Lock()
+	defer fh.Unlock()
+
+	fh.lastFetch = now
+
+	if err == nil {
+		fh.lastSuccessfulFetch = now
+	}
+}
+
+// check sets the fetch times of h and whether the manager is live and ready,
+// given how often the clusters are fetched.
+func (fh *fetchHealth) check(h *Health, resyncPeriod time.Duration, now time.Time) {
+	fh.RLock()
+	defer fh.RUnlock()
+
+	h.Loaded = fh.loaded
+	h.LastFetch = fh.lastFetch
+	h.LastSuccessfulFetch = fh.lastSuccessfulFetch
+
+	maxAge := healthResyncPeriods * resyncPeriod
+
+	switch {
+	case !fh.loaded && !fh.started.IsZero() && now.Sub(fh.started) > maxAge:
+		h.Reason = fmt.Sprintf("clusters not loaded %s after starting", maxAge)
+	case !fh.loaded:
+		h.Live = true
+		h.Reason = "clusters not loaded yet"
+	case now.Sub(fh.lastFetch) > maxAge:
+		h.Reason = fmt.Sprintf("clusters not fetched for over %s", maxAge)
+	case now.Sub(fh.lastSuccessfulFetch) > maxAge:
+		h.Live = true
+		h.Reason = fmt.Sprintf("clusters not fetched successfully for over %s", maxAge)
+	default:
+		h.Live = true
+		h.Ready = true
+	}
+}
@@ -0,0 +1,106 @@
+package clustersmngr
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// NamespaceFilter picks the namespaces of a cluster that are tracked, the
+// others are never checked for access nor listed. Patterns are globs, e.g.
+// team-*.
+type NamespaceFilter struct {
+	// Include only keeps the namespaces matching one of these patterns, if
+	// there are any.
+	Include []string
+	// Exclude drops the namespaces matching one of these patterns.
+	Exclude []string
+}
+
+// NamespaceFilters are the namespace filters of each cluster by name. The
+// filter of the empty name applies to every cluster, on top of their own.
+type NamespaceFilters map[string]NamespaceFilter
+
+// ParseNamespaceFilters parses include and exclude patterns, each either
+// <pattern> for every cluster or <cluster>=<pattern> for one cluster.
+func ParseNamespaceFilters(include, exclude []string) (NamespaceFilters, error) {
+	filters := NamespaceFilters{}
+
+	add := func(value string, included bool) error {
+		clusterName, pattern := "", value
+		if i := strings.LastIndex(value, "="); i >= 0 {
+			clusterName, pattern = value[:i], value[i+1:]
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid namespace pattern %q", value)
+		}
+
+		filter := filters[clusterName]
+
+		if included {
+			filter.Include = append(filter.Include, pattern)
+		} else {
+			filter.Exclude = append(filter.Exclude, pattern)
+		}
+
+		filters[clusterName] = filter
+
+		return nil
+	}
+
+	for _, value := range include {
+		if err := add(value, true); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, value := range exclude {
+		if err := add(value, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return filters, nil
+}
+
+// Keep tells whether the namespace of the cluster is tracked.
+func (f NamespaceFilters) Keep(clusterName, namespace string) bool {
+	global, own := f[""], f[clusterName]
+
+	include := append(append([]string{}, global.Include...), own.Include...)
+	if len(include) > 0 && !matchesAny(include, namespace) {
+		return false
+	}
+
+	return !matchesAny(global.Exclude, namespace) && !matchesAny(own.Exclude, namespace)
+}
+
+// Filter returns the namespaces of the cluster that are tracked.
+func (f NamespaceFilters) Filter(clusterName string, namespaces []v1.Namespace) []v1.Namespace {
+	if len(f) == 0 {
+		return namespaces
+	}
+
+	filtered := make([]v1.Namespace, 0, len(namespaces))
+
+	for _, ns := range namespaces {
+		if f.Keep(clusterName, ns.Name) {
+			filtered = append(filtered, ns)
+		}
+	}
+
+	return filtered
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
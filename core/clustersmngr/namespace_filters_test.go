@@ -0,0 +1,45 @@
+package clustersmngr_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceFilters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	filters, err := clustersmngr.ParseNamespaceFilters(
+		[]string{"leaf-1=team-*", "leaf-1=flux-system"},
+		[]string{"kube-*", "velero", "leaf-1=team-legacy"},
+	)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	namespaces := []v1.Namespace{}
+	for _, name := range []string{"default", "flux-system", "kube-system", "kube-public", "team-a", "team-legacy", "velero"} {
+		namespaces = append(namespaces, v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	names := func(namespaces []v1.Namespace) []string {
+		result := []string{}
+		for _, ns := range namespaces {
+			result = append(result, ns.Name)
+		}
+
+		return result
+	}
+
+	g.Expect(names(filters.Filter("management", namespaces))).To(Equal([]string{"default", "flux-system", "team-a", "team-legacy"}))
+	g.Expect(names(filters.Filter("leaf-1", namespaces))).To(Equal([]string{"flux-system", "team-a"}))
+
+	g.Expect(names(clustersmngr.NamespaceFilters(nil).Filter("management", namespaces))).To(HaveLen(len(namespaces)))
+
+	_, err = clustersmngr.ParseNamespaceFilters([]string{"leaf-1=[team"}, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("invalid namespace pattern")))
+
+	_, err = clustersmngr.ParseNamespaceFilters(nil, []string{"leaf-1="})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,64 @@
+package clustersmngr
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// DurationObserver is the client of a cluster recording how long its gets
+// and lists take, by kind, so slow clusters show up in the
+// cluster_request_duration_seconds metric. Lists made by ClusteredList are
+// recorded for each namespace.
+type DurationObserver struct {
+	client.Client
+	clusterName string
+}
+
+// NewDurationObserver wraps the client of a cluster.
+func NewDurationObserver(c client.Client, clusterName string) *DurationObserver {
+	return &DurationObserver{Client: c, clusterName: clusterName}
+}
+
+func (o *DurationObserver) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	defer o.observe("get", obj, time.Now())
+
+	return o.Client.Get(ctx, key, obj, opts...)
+}
+
+func (o *DurationObserver) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	defer o.observe("list", list, time.Now())
+
+	return o.Client.List(ctx, list, opts...)
+}
+
+// Watch watches with the client of the cluster, watches aren't recorded as
+// they last until they're stopped.
+func (o *DurationObserver) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	return cluster.Watch(ctx, o.Client, list, opts...)
+}
+
+func (o *DurationObserver) observe(verb string, obj runtime.Object, start time.Time) {
+	// the kind of unstructured objects is set, the one of typed objects is
+	// looked up in the scheme
+	gvk := obj.GetObjectKind().GroupVersionKind()
+
+	if scheme := o.Scheme(); scheme != nil {
+		if schemeGVK, err := apiutil.GVKForObject(obj, scheme); err == nil {
+			gvk = schemeGVK
+		}
+	}
+
+	kind := gvk.Kind
+	if verb == "list" {
+		kind = strings.TrimSuffix(kind, "List")
+	}
+
+	opsClusterRequestDuration.WithLabelValues(o.clusterName, verb, gvk.Group, gvk.Version, kind).Observe(time.Since(start).Seconds())
+}
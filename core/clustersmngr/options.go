@@ -0,0 +1,233 @@
+package clustersmngr
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClustersManagerOptions tune the refresh intervals and cache TTLs of a
+// ClustersManager. Fields left to zero keep their default.
+type ClustersManagerOptions struct {
+	// ClustersResyncPeriod is how often clusters are fetched again,
+	// fetchers that can watch their clusters trigger updates in between.
+	ClustersResyncPeriod time.Duration
+	// NamespacesResyncPeriod is how often namespace informers resync,
+	// changes are watched.
+	NamespacesResyncPeriod time.Duration
+	// ClusterStatusPeriod is how often clusters are checked to be reachable.
+	ClusterStatusPeriod time.Duration
+	// UserNamespacesTTL is how long the namespaces a user can access are
+	// cached for.
+	UserNamespacesTTL time.Duration
+	// UsersClientsTTL is how long the clients of each user are cached for.
+	UsersClientsTTL time.Duration
+	// CacheResolution is how often expired entries are removed from the
+	// users caches.
+	CacheResolution time.Duration
+	// BreakerThreshold is the number of consecutive failures after which
+	// calls to a cluster are skipped, for BreakerMinBackoff at first then
+	// twice as long after every failed retry, up to BreakerMaxBackoff.
+	BreakerThreshold  int
+	BreakerMinBackoff time.Duration
+	BreakerMaxBackoff time.Duration
+	// MaxConcurrency is the most calls to clusters a single operation makes
+	// at once, e.g. creating the clients of a user or listing objects
+	// across clusters.
+	MaxConcurrency int
+	// ShareNamespacesByGroups shares the namespaces a user can access with
+	// the users with the same groups, so access is checked once per group
+	// set. Only enable it if permissions are granted to groups, not to
+	// individual users.
+	ShareNamespacesByGroups bool
+	// NamespaceFilters picks the namespaces of each cluster that are
+	// tracked, all of them by default.
+	NamespaceFilters NamespaceFilters
+	// ClusterAliases are other names of clusters, e.g. management for the
+	// management cluster, by alias. A cluster's own name takes precedence
+	// over an alias.
+	ClusterAliases map[string]string
+	// CachedKinds are read from informers shared by the clients of each
+	// cluster instead of from its API server, decoded with CacheScheme.
+	// Nothing is cached by default.
+	CachedKinds []schema.GroupKind
+	CacheScheme *runtime.Scheme
+}
+
+// ClustersManagerOption sets an option of a ClustersManager.
+type ClustersManagerOption func(*ClustersManagerOptions)
+
+// DefaultClustersManagerOptions returns the options used when none are set.
+// The users clients TTL can also be set with WEAVE_GITOPS_USERS_CLIENTS_TTL.
+func DefaultClustersManagerOptions() ClustersManagerOptions {
+	return ClustersManagerOptions{
+		ClustersResyncPeriod:   watchClustersFrequency,
+		NamespacesResyncPeriod: namespaceResyncPeriod,
+		ClusterStatusPeriod:    clusterStatusFrequency,
+		UserNamespacesTTL:      userNamespaceTTL,
+		UsersClientsTTL:        usersClientsTTL,
+		CacheResolution:        usersClientResolution,
+		BreakerThreshold:       breakerThreshold,
+		BreakerMinBackoff:      breakerMinBackoff,
+		BreakerMaxBackoff:      breakerMaxBackoff,
+		MaxConcurrency:         defaultMaxConcurrency,
+	}
+}
+
+// WithOptions sets all the options at once, e.g. from flags.
+func WithOptions(o ClustersManagerOptions) ClustersManagerOption {
+	return func(opts *ClustersManagerOptions) {
+		*opts = o
+	}
+}
+
+func WithClustersResyncPeriod(d time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.ClustersResyncPeriod = d }
+}
+
+func WithNamespacesResyncPeriod(d time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.NamespacesResyncPeriod = d }
+}
+
+func WithClusterStatusPeriod(d time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.ClusterStatusPeriod = d }
+}
+
+func WithUserNamespacesTTL(d time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.UserNamespacesTTL = d }
+}
+
+func WithUsersClientsTTL(d time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.UsersClientsTTL = d }
+}
+
+func WithCacheResolution(d time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.CacheResolution = d }
+}
+
+func WithBreaker(threshold int, minBackoff, maxBackoff time.Duration) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) {
+		o.BreakerThreshold = threshold
+		o.BreakerMinBackoff = minBackoff
+		o.BreakerMaxBackoff = maxBackoff
+	}
+}
+
+func WithMaxConcurrency(n int) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.MaxConcurrency = n }
+}
+
+// WithNamespaceFilters sets the namespaces of each cluster that are tracked.
+func WithNamespaceFilters(filters NamespaceFilters) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.NamespaceFilters = filters }
+}
+
+// WithClusterAliases sets other names clusters can be picked by.
+func WithClusterAliases(aliases map[string]string) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) { o.ClusterAliases = aliases }
+}
+
+// WithCachedKinds reads the kinds of every cluster from shared informers.
+func WithCachedKinds(scheme *runtime.Scheme, kinds []schema.GroupKind) ClustersManagerOption {
+	return func(o *ClustersManagerOptions) {
+		o.CacheScheme = scheme
+		o.CachedKinds = kinds
+	}
+}
+
+// newClustersManagerOptions applies options on top of the defaults.
+func newClustersManagerOptions(opts ...ClustersManagerOption) ClustersManagerOptions {
+	defaults := DefaultClustersManagerOptions()
+	o := defaults
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	o.ClustersResyncPeriod = durationOrDefault(o.ClustersResyncPeriod, defaults.ClustersResyncPeriod)
+	o.NamespacesResyncPeriod = durationOrDefault(o.NamespacesResyncPeriod, defaults.NamespacesResyncPeriod)
+	o.ClusterStatusPeriod = durationOrDefault(o.ClusterStatusPeriod, defaults.ClusterStatusPeriod)
+	o.UserNamespacesTTL = durationOrDefault(o.UserNamespacesTTL, defaults.UserNamespacesTTL)
+	o.UsersClientsTTL = durationOrDefault(o.UsersClientsTTL, defaults.UsersClientsTTL)
+	o.CacheResolution = durationOrDefault(o.CacheResolution, defaults.CacheResolution)
+	o.BreakerMinBackoff = durationOrDefault(o.BreakerMinBackoff, defaults.BreakerMinBackoff)
+	o.BreakerMaxBackoff = durationOrDefault(o.BreakerMaxBackoff, defaults.BreakerMaxBackoff)
+
+	if o.BreakerThreshold <= 0 {
+		o.BreakerThreshold = defaults.BreakerThreshold
+	}
+
+	if o.MaxConcurrency <= 0 {
+		o.MaxConcurrency = defaults.MaxConcurrency
+	}
+
+	return o
+}
+
+// ImpersonatedClientOptions tune the clients returned by
+// GetImpersonatedClient.
+type ImpersonatedClientOptions struct {
+	// Eager creates the clients of every cluster upfront, and returns the
+	// errors of the clusters whose client couldn't be created. By default
+	// the client of a cluster is only created when it's first used.
+	Eager bool
+	// ClusterSelector only covers the clusters with matching labels, all
+	// clusters if it's nil.
+	ClusterSelector labels.Selector
+}
+
+// ImpersonatedClientOption sets an option of GetImpersonatedClient.
+type ImpersonatedClientOption func(*ImpersonatedClientOptions)
+
+// WithEagerClients creates the clients of every cluster upfront, for
+// requests that query every cluster and report the ones that failed.
+func WithEagerClients() ImpersonatedClientOption {
+	return func(opts *ImpersonatedClientOptions) {
+		opts.Eager = true
+	}
+}
+
+// WithClusterSelector only covers the clusters whose labels match selector.
+func WithClusterSelector(selector labels.Selector) ImpersonatedClientOption {
+	return func(opts *ImpersonatedClientOptions) {
+		opts.ClusterSelector = selector
+	}
+}
+
+// GetClustersOptions filter the clusters returned by GetClusters.
+type GetClustersOptions struct {
+	// Selector only returns the clusters with matching labels, all clusters
+	// if it's nil.
+	Selector labels.Selector
+}
+
+// GetClustersOption sets an option of GetClusters.
+type GetClustersOption func(*GetClustersOptions)
+
+// MatchingClusterLabels only returns the clusters whose labels match
+// selector.
+func MatchingClusterLabels(selector labels.Selector) GetClustersOption {
+	return func(opts *GetClustersOptions) {
+		opts.Selector = selector
+	}
+}
+
+// SubscribeOptions tune the watchers returned by Subscribe.
+type SubscribeOptions struct {
+	// CurrentClusters delivers the current clusters as added first, so
+	// watchers don't need to get them separately and risk missing changes.
+	CurrentClusters bool
+}
+
+// SubscribeOption sets an option of Subscribe.
+type SubscribeOption func(*SubscribeOptions)
+
+// WithCurrentClusters delivers the current clusters first, as an update
+// adding all of them.
+func WithCurrentClusters() SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		opts.CurrentClusters = true
+	}
+}
@@ -0,0 +1,288 @@
+package clustersmngr
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// watchRestartPeriod is how long a watch that failed waits before it's
+// started again.
+const watchRestartPeriod = 5 * time.Second
+
+// WatchEvent is an event of one of the watches started by ClusteredWatch.
+// Events of type watch.Error hold a *metav1.Status, e.g. when a watch
+// couldn't be restarted, the watch is retried afterwards.
+type WatchEvent struct {
+	watch.Event
+	// Cluster is the cluster of the watch.
+	Cluster string
+	// Namespace is the namespace of the watch, empty for cluster scoped
+	// watches.
+	Namespace string
+}
+
+// Err returns the error of an event of type watch.Error.
+func (e WatchEvent) Err() error {
+	if e.Type != watch.Error {
+		return nil
+	}
+
+	return apierrors.FromObject(e.Object)
+}
+
+func (c *clustersClient) ClusteredWatch(ctx context.Context, newList func() client.ObjectList, namespaced bool, opts ...client.ListOption) (<-chan WatchEvent, error) {
+	var (
+		errs    = ClusteredListError{}
+		mu      = sync.Mutex{}
+		wg      = sync.WaitGroup{}
+		workers = newWorkerPool(c.listConcurrency)
+		watches = []*clusterWatch{}
+	)
+
+	wantedNamespaces, opts := extractNamespaces(opts...)
+	wantedClusters, opts := c.extractClusters(opts...)
+
+	for clusterName, cc := range c.pool.Clients() {
+		if wantedClusters != nil && !wantedClusters[clusterName] {
+			continue
+		}
+
+		namespaces := filterNamespaces(c.namespaces[clusterName], wantedNamespaces)
+		if !namespaced {
+			namespaces = []v1.Namespace{{}}
+		}
+
+		for _, ns := range namespaces {
+			w := &clusterWatch{
+				cluster:   clusterName,
+				namespace: ns.Name,
+				client:    cc,
+				newList:   newList,
+				opts:      append(opts[:len(opts):len(opts)], client.InNamespace(ns.Name)),
+			}
+
+			workers.Go(&wg, func() {
+				err := w.start(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					errs.Add(ListError{Cluster: w.cluster, Namespace: w.namespace, Err: err})
+					return
+				}
+
+				watches = append(watches, w)
+			})
+		}
+	}
+
+	wg.Wait()
+
+	if len(watches) == 0 && len(errs.Errors) > 0 {
+		return nil, errs
+	}
+
+	events := make(chan WatchEvent)
+
+	for _, w := range watches {
+		wg.Add(1)
+
+		go func(w *clusterWatch) {
+			defer wg.Done()
+
+			w.forward(ctx, events)
+		}(w)
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	if len(errs.Errors) > 0 {
+		return events, errs
+	}
+
+	return events, nil
+}
+
+// InClusters restricts ClusteredList and ClusteredWatch to some clusters,
+// picked by name or by alias, instead of every cluster of the client.
+type InClusters []string
+
+// ApplyToList does nothing, the clusters are picked by the clustered calls.
+func (n InClusters) ApplyToList(*client.ListOptions) {}
+
+// extractClusters returns the clusters requested with InClusters, nil if
+// every cluster is to be used, and the other options.
+func (c *clustersClient) extractClusters(opts ...client.ListOption) (map[string]bool, []client.ListOption) {
+	var (
+		wanted map[string]bool
+		rest   = make([]client.ListOption, 0, len(opts))
+	)
+
+	for _, o := range opts {
+		clusters, ok := o.(InClusters)
+		if !ok {
+			rest = append(rest, o)
+			continue
+		}
+
+		if wanted == nil {
+			wanted = map[string]bool{}
+		}
+
+		for _, name := range clusters {
+			if alias, ok := c.aliases[name]; ok && !c.hasCluster(name) {
+				name = alias
+			}
+
+			wanted[name] = true
+		}
+	}
+
+	return wanted, rest
+}
+
+func (c *clustersClient) hasCluster(name string) bool {
+	_, ok := c.pool.Clients()[name]
+
+	return ok
+}
+
+// clusterWatch watches the objects of a cluster or of one of its
+// namespaces.
+type clusterWatch struct {
+	cluster   string
+	namespace string
+	client    client.Client
+	newList   func() client.ObjectList
+	opts      []client.ListOption
+
+	watcher watch.Interface
+	// resourceVersion is the version of the last event, so the watch is
+	// restarted where it ended
+	resourceVersion string
+}
+
+func (w *clusterWatch) start(ctx context.Context) error {
+	raw := &metav1.ListOptions{
+		ResourceVersion:     w.resourceVersion,
+		AllowWatchBookmarks: true,
+	}
+
+	watcher, err := cluster.Watch(ctx, w.client, w.newList(), append(w.opts[:len(w.opts):len(w.opts)], &client.ListOptions{Raw: raw})...)
+	if err != nil {
+		return err
+	}
+
+	w.watcher = watcher
+
+	return nil
+}
+
+// forward sends the events of the watch until ctx is done. API servers end
+// watches after a while, they're restarted from the last event seen, or
+// from scratch if that's too old, which sends the current objects again.
+func (w *clusterWatch) forward(ctx context.Context, events chan<- WatchEvent) {
+	for {
+		failed := !w.drain(ctx, events)
+		w.watcher.Stop()
+
+		for {
+			if failed {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(watchRestartPeriod):
+				}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			err := w.start(ctx)
+			if err == nil {
+				break
+			}
+
+			if !w.send(ctx, events, watch.Event{Type: watch.Error, Object: errorStatus(err)}) {
+				return
+			}
+
+			failed = true
+		}
+	}
+}
+
+// drain sends the events of the watch until it ends, it returns false if
+// the watch failed.
+func (w *clusterWatch) drain(ctx context.Context, events chan<- WatchEvent) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case event, ok := <-w.watcher.ResultChan():
+			if !ok {
+				return true
+			}
+
+			switch event.Type {
+			case watch.Error:
+				// e.g. the resource version is too old
+				w.resourceVersion = ""
+
+				w.send(ctx, events, event)
+
+				return false
+			case watch.Bookmark:
+				w.setResourceVersion(event.Object)
+				continue
+			}
+
+			w.setResourceVersion(event.Object)
+
+			if !w.send(ctx, events, event) {
+				return true
+			}
+		}
+	}
+}
+
+func (w *clusterWatch) setResourceVersion(obj interface{}) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		w.resourceVersion = accessor.GetResourceVersion()
+	}
+}
+
+func (w *clusterWatch) send(ctx context.Context, events chan<- WatchEvent, event watch.Event) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case events <- WatchEvent{Event: event, Cluster: w.cluster, Namespace: w.namespace}:
+		return true
+	}
+}
+
+func errorStatus(err error) *metav1.Status {
+	if status, ok := err.(apierrors.APIStatus); ok {
+		s := status.Status()
+		return &s
+	}
+
+	return &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: err.Error(),
+	}
+}
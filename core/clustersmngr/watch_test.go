@@ -0,0 +1,125 @@
+package clustersmngr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/clustersmngrfakes"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClientClusteredWatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clients := map[string]client.Client{
+		"a": fake.NewClientBuilder().WithScheme(scheme).Build(),
+		"b": fake.NewClientBuilder().WithScheme(scheme).Build(),
+		// can't watch
+		"bad": failingListClient{},
+	}
+
+	nsMap := map[string][]corev1.Namespace{
+		"a":   {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "ns-b"}}},
+		"b":   {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}},
+		"bad": {{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}},
+	}
+
+	clientsPool := &clustersmngrfakes.FakeClientsPool{}
+	clientsPool.ClientsReturns(clients)
+
+	clustersClient := clustersmngr.NewClient(clientsPool, nsMap, clustersmngr.WithAliases(map[string]string{"management": "a"}))
+
+	newList := func() client.ObjectList { return &kustomizev1.KustomizationList{} }
+
+	kustomization := func(name, namespace string) *kustomizev1.Kustomization {
+		return &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+
+	next := func(events <-chan clustersmngr.WatchEvent) clustersmngr.WatchEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+
+		return clustersmngr.WatchEvent{}
+	}
+
+	t.Run("sends the events of every cluster and namespace", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := clustersClient.ClusteredWatch(ctx, newList, true)
+
+		var errs clustersmngr.ClusteredListError
+		g.Expect(errors.As(err, &errs)).To(BeTrue())
+		g.Expect(errs.Errors).To(HaveLen(1))
+		g.Expect(errs.Errors[0].Cluster).To(Equal("bad"))
+		g.Expect(errs.Errors[0].Err).To(MatchError(cluster.ErrWatchNotSupported))
+		g.Expect(events).NotTo(BeNil())
+
+		g.Expect(clients["a"].Create(ctx, kustomization("one", "ns-b"))).To(Succeed())
+
+		event := next(events)
+		g.Expect(event.Type).To(Equal(watch.Added))
+		g.Expect(event.Cluster).To(Equal("a"))
+		g.Expect(event.Namespace).To(Equal("ns-b"))
+		g.Expect(event.Object.(client.Object).GetName()).To(Equal("one"))
+
+		g.Expect(clients["b"].Create(ctx, kustomization("two", "ns-a"))).To(Succeed())
+
+		event = next(events)
+		g.Expect(event.Type).To(Equal(watch.Added))
+		g.Expect(event.Cluster).To(Equal("b"))
+		g.Expect(event.Object.(client.Object).GetName()).To(Equal("two"))
+
+		cancel()
+
+		g.Eventually(events).Should(BeClosed())
+	})
+
+	t.Run("watches some clusters and namespaces", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := clustersClient.ClusteredWatch(ctx, newList, true,
+			clustersmngr.InClusters{"management"}, clustersmngr.InNamespaces{"ns-a"})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(clients["b"].Create(ctx, kustomization("three", "ns-a"))).To(Succeed())
+		g.Expect(clients["a"].Create(ctx, kustomization("three", "ns-b"))).To(Succeed())
+		g.Expect(clients["a"].Create(ctx, kustomization("three", "ns-a"))).To(Succeed())
+
+		event := next(events)
+		g.Expect(event.Cluster).To(Equal("a"))
+		g.Expect(event.Namespace).To(Equal("ns-a"))
+		g.Expect(event.Object.(client.Object).GetNamespace()).To(Equal("ns-a"))
+	})
+
+	t.Run("fails when no watch can be started", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		events, err := clustersClient.ClusteredWatch(context.Background(), newList, true, clustersmngr.InClusters{"bad"})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(events).To(BeNil())
+	})
+}
@@ -0,0 +1,35 @@
+package clustersmngr
+
+import "sync"
+
+// workerPool bounds how many calls to clusters run at once, so fanning out
+// to hundreds of clusters doesn't open as many connections at the same
+// time. A nil pool doesn't bound anything.
+type workerPool chan struct{}
+
+func newWorkerPool(size int) workerPool {
+	if size <= 0 {
+		return nil
+	}
+
+	return make(workerPool, size)
+}
+
+// Go runs f in a goroutine tracked by wg, once a worker is free.
+func (p workerPool) Go(wg *sync.WaitGroup, f func()) {
+	wg.Add(1)
+
+	if p != nil {
+		p <- struct{}{}
+	}
+
+	go func() {
+		defer wg.Done()
+
+		if p != nil {
+			defer func() { <-p }()
+		}
+
+		f()
+	}()
+}
@@ -21,6 +21,7 @@ type Reconcilable interface {
 	AsClientObject() client.Object
 	GroupVersionKind() schema.GroupVersionKind
 	SetSuspended(suspend bool)
+	IsSuspended() bool
 	DeepCopyClientObject() client.Object
 }
 
@@ -77,6 +78,10 @@ func (obj GitRepositoryAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj GitRepositoryAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj GitRepositoryAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -101,6 +106,10 @@ func (obj BucketAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj BucketAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj BucketAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -125,6 +134,10 @@ func (obj HelmChartAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj HelmChartAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj HelmChartAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -149,6 +162,10 @@ func (obj HelmRepositoryAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj HelmRepositoryAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj HelmRepositoryAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -173,6 +190,10 @@ func (obj OCIRepositoryAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj OCIRepositoryAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj OCIRepositoryAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -208,6 +229,10 @@ func (obj HelmReleaseAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj HelmReleaseAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj HelmReleaseAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -241,6 +266,10 @@ func (obj KustomizationAdapter) SetSuspended(suspend bool) {
 	obj.Spec.Suspend = suspend
 }
 
+func (obj KustomizationAdapter) IsSuspended() bool {
+	return obj.Spec.Suspend
+}
+
 func (obj KustomizationAdapter) DeepCopyClientObject() client.Object {
 	return obj.DeepCopy()
 }
@@ -17,11 +17,109 @@ import (
 var k8sPollInterval = 2 * time.Second
 var k8sTimeout = 1 * time.Minute
 
+// ErrSyncTimeout is returned when a resource wasn't reconciled in time.
+var ErrSyncTimeout = errors.New("sync request timed out. The sync operation may still be in progress")
+
+const (
+	// ReconcileRequestedByAnnotation records who asked for the last manual reconciliation.
+	ReconcileRequestedByAnnotation = "reconcile.weave.works/requested-by"
+	// ReconcileOriginAnnotation records where the last manual reconciliation was requested from.
+	ReconcileOriginAnnotation = "reconcile.weave.works/origin"
+)
+
+const (
+	// SuspendRequestedByAnnotation records who suspended or resumed the object last.
+	SuspendRequestedByAnnotation = "suspend.weave.works/requested-by"
+	// SuspendOriginAnnotation records where the object was suspended or resumed from.
+	SuspendOriginAnnotation = "suspend.weave.works/origin"
+	// SuspendRequestedAtAnnotation records when the object was suspended or resumed.
+	SuspendRequestedAtAnnotation = "suspend.weave.works/requested-at"
+	// SuspendCommentAnnotation records why the object was suspended or resumed.
+	SuspendCommentAnnotation = "suspend.weave.works/comment"
+)
+
+// Origins of a reconciliation request.
+const (
+	OriginUI  = "ui"
+	OriginCLI = "cli"
+	OriginAPI = "api"
+)
+
+// ReconcileTrigger describes who requested a reconciliation and from where,
+// so that manual syncs can be told apart from interval-based runs.
+type ReconcileTrigger struct {
+	RequestedBy string
+	Origin      string
+}
+
+// Apply records the trigger in the annotations of obj, replacing the trigger
+// of any previous request.
+func (t ReconcileTrigger) Apply(obj metav1.Object) {
+	ann := obj.GetAnnotations()
+	if ann == nil {
+		ann = map[string]string{}
+	}
+
+	delete(ann, ReconcileRequestedByAnnotation)
+	delete(ann, ReconcileOriginAnnotation)
+
+	if t.RequestedBy != "" {
+		ann[ReconcileRequestedByAnnotation] = t.RequestedBy
+	}
+
+	if t.Origin != "" {
+		ann[ReconcileOriginAnnotation] = t.Origin
+	}
+
+	obj.SetAnnotations(ann)
+}
+
+// SuspendRequest describes who suspended or resumed an object, from where
+// and why, so that it can be audited.
+type SuspendRequest struct {
+	RequestedBy string
+	Origin      string
+	Comment     string
+}
+
+// Apply records the request in the annotations of obj, replacing the request
+// that suspended or resumed it before.
+func (r SuspendRequest) Apply(obj metav1.Object, at time.Time) {
+	ann := obj.GetAnnotations()
+	if ann == nil {
+		ann = map[string]string{}
+	}
+
+	delete(ann, SuspendRequestedByAnnotation)
+	delete(ann, SuspendOriginAnnotation)
+	delete(ann, SuspendCommentAnnotation)
+
+	if r.RequestedBy != "" {
+		ann[SuspendRequestedByAnnotation] = r.RequestedBy
+	}
+
+	if r.Origin != "" {
+		ann[SuspendOriginAnnotation] = r.Origin
+	}
+
+	if r.Comment != "" {
+		ann[SuspendCommentAnnotation] = r.Comment
+	}
+
+	ann[SuspendRequestedAtAnnotation] = at.Format(time.RFC3339)
+
+	obj.SetAnnotations(ann)
+}
+
 // RequestReconciliation sets the annotations of an object so that the flux controller(s) will force a reconciliation.
 // Take straight from the flux CLI source:
 // https://github.com/fluxcd/flux2/blob/cb53243fc11de81de3a34616d14322d66573aa65/cmd/flux/reconcile.go#L155
-func RequestReconciliation(ctx context.Context, k client.Client, name client.ObjectKey, gvk schema.GroupVersionKind) error {
-	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+// It returns the value of the request annotation, which the controller sets as the
+// last handled reconcile request once it reconciled the object.
+func RequestReconciliation(ctx context.Context, k client.Client, name client.ObjectKey, gvk schema.GroupVersionKind, trigger ReconcileTrigger) (string, error) {
+	requestedAt := time.Now().Format(time.RFC3339Nano)
+
+	return requestedAt, retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
 		object := &metav1.PartialObjectMetadata{}
 		object.SetGroupVersionKind(gvk)
 		object.SetName(name.Name)
@@ -32,12 +130,13 @@ func RequestReconciliation(ctx context.Context, k client.Client, name client.Obj
 		patch := client.MergeFrom(object.DeepCopy())
 		if ann := object.GetAnnotations(); ann == nil {
 			object.SetAnnotations(map[string]string{
-				meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+				meta.ReconcileRequestAnnotation: requestedAt,
 			})
 		} else {
-			ann[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+			ann[meta.ReconcileRequestAnnotation] = requestedAt
 			object.SetAnnotations(ann)
 		}
+		trigger.Apply(object)
 		return k.Patch(ctx, object, patch)
 	})
 }
@@ -50,7 +149,32 @@ func WaitForSync(ctx context.Context, c client.Client, key client.ObjectKey, obj
 		checkResourceSync(ctx, c, key, obj, obj.GetLastHandledReconcileRequest()),
 	); err != nil {
 		if errors.Is(err, wait.ErrWaitTimeout) {
-			return errors.New("sync request timed out. The sync operation may still be in progress")
+			return ErrSyncTimeout
+		}
+
+		return fmt.Errorf("syncing resource: %w", err)
+	}
+
+	return nil
+}
+
+// WaitForReconciliation polls the k8s API until the resource handled the
+// reconciliation requested at requestedAt, and times out eventually. The
+// resource is left as it is once reconciled, with its conditions.
+func WaitForReconciliation(ctx context.Context, c client.Client, key client.ObjectKey, obj Reconcilable, requestedAt string) error {
+	if err := wait.PollImmediate(
+		k8sPollInterval,
+		k8sTimeout,
+		func() (bool, error) {
+			if err := c.Get(ctx, key, obj.AsClientObject()); err != nil {
+				return false, err
+			}
+
+			return obj.GetLastHandledReconcileRequest() == requestedAt, nil
+		},
+	); err != nil {
+		if errors.Is(err, wait.ErrWaitTimeout) {
+			return ErrSyncTimeout
 		}
 
 		return fmt.Errorf("syncing resource: %w", err)
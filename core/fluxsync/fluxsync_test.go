@@ -0,0 +1,36 @@
+package fluxsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWaitForReconciliationTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func(interval, timeout time.Duration) {
+		k8sPollInterval, k8sTimeout = interval, timeout
+	}(k8sPollInterval, k8sTimeout)
+
+	k8sPollInterval, k8sTimeout = 10*time.Millisecond, 50*time.Millisecond
+
+	scheme := runtime.NewScheme()
+	g.Expect(sourcev1.AddToScheme(scheme)).To(Succeed())
+
+	repo := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "flux-system"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo).Build()
+
+	obj := GitRepositoryAdapter{GitRepository: &sourcev1.GitRepository{}}
+
+	err := WaitForReconciliation(context.Background(), c, client.ObjectKeyFromObject(repo), obj, time.Now().Format(time.RFC3339Nano))
+	g.Expect(errors.Is(err, ErrSyncTimeout)).To(BeTrue())
+}
@@ -0,0 +1,156 @@
+// Package healthscore reduces the GitOps state of each cluster to a single
+// score from 0 to 100, so wallboards and SLO alerts don't have to make sense
+// of raw object counts.
+package healthscore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"sigs.k8s.io/yaml"
+)
+
+// Severities of the state of an object.
+const (
+	Healthy     = "healthy"
+	Progressing = "progressing"
+	Suspended   = "suspended"
+	Unknown     = "unknown"
+	Failed      = "failed"
+)
+
+var severities = []string{Healthy, Progressing, Suspended, Unknown, Failed}
+
+// Model weighs objects by kind, and penalises them by the severity of their
+// state. The score of a cluster is
+//
+//	100 * (1 - sum(kind weight * severity penalty) / sum(kind weight))
+//
+// over all its objects, so a cluster where every object failed scores 0,
+// and a cluster without objects scores 100.
+type Model struct {
+	// Kinds are the weights of each kind, kinds weighing 0 aren't scored.
+	Kinds map[string]float64 `json:"kinds,omitempty"`
+	// Severities are the penalties of each severity, from 0 to 1.
+	Severities map[string]float64 `json:"severities,omitempty"`
+}
+
+// DefaultModel weighs automations more than sources, as they're what
+// actually gets deployed.
+func DefaultModel() *Model {
+	return &Model{
+		Kinds: map[string]float64{
+			kustomizev1.KustomizationKind: 3,
+			helmv2.HelmReleaseKind:        3,
+			sourcev1.GitRepositoryKind:    1,
+			sourcev1.HelmRepositoryKind:   1,
+			sourcev1.HelmChartKind:        1,
+			sourcev1.BucketKind:           1,
+			sourcev1.OCIRepositoryKind:    1,
+		},
+		Severities: map[string]float64{
+			Healthy:     0,
+			Progressing: 0.25,
+			Suspended:   0.5,
+			Unknown:     0.5,
+			Failed:      1,
+		},
+	}
+}
+
+// ParseModel reads a YAML model on top of the default one, so only the
+// weights that differ need to be set, e.g.
+//
+//	kinds:
+//	  HelmChart: 0
+//	severities:
+//	  suspended: 0.1
+func ParseModel(data []byte) (*Model, error) {
+	m := DefaultModel()
+	if err := yaml.UnmarshalStrict(data, m); err != nil {
+		return nil, fmt.Errorf("invalid health score model: %w", err)
+	}
+
+	for kind, weight := range m.Kinds {
+		if _, ok := kindsByName[kind]; !ok {
+			return nil, fmt.Errorf("invalid health score model: unknown kind %q", kind)
+		}
+
+		if weight < 0 {
+			return nil, fmt.Errorf("invalid health score model: weight of %s must not be negative", kind)
+		}
+	}
+
+	for severity, penalty := range m.Severities {
+		if !isSeverity(severity) {
+			return nil, fmt.Errorf("invalid health score model: unknown severity %q", severity)
+		}
+
+		if penalty < 0 || penalty > 1 {
+			return nil, fmt.Errorf("invalid health score model: penalty of %s must be between 0 and 1", severity)
+		}
+	}
+
+	return m, nil
+}
+
+// ScoredKinds returns the kinds that weigh in the score, sorted by name.
+func (m *Model) ScoredKinds() []string {
+	kinds := []string{}
+
+	for kind, weight := range m.Kinds {
+		if weight > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// Counts are numbers of objects by kind and severity.
+type Counts map[string]map[string]int
+
+// Add counts an object.
+func (c Counts) Add(kind, severity string) {
+	if c[kind] == nil {
+		c[kind] = map[string]int{}
+	}
+
+	c[kind][severity]++
+}
+
+// Score returns the score of objects, rounded to one decimal.
+func (m *Model) Score(counts Counts) float64 {
+	total, penalty := 0.0, 0.0
+
+	for kind, bySeverity := range counts {
+		weight := m.Kinds[kind]
+
+		for severity, n := range bySeverity {
+			total += weight * float64(n)
+			penalty += weight * m.Severities[severity] * float64(n)
+		}
+	}
+
+	if total == 0 {
+		return 100
+	}
+
+	return math.Round(1000*(1-penalty/total)) / 10
+}
+
+func isSeverity(s string) bool {
+	for _, severity := range severities {
+		if s == severity {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package healthscore
+
+import (
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseModel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m, err := ParseModel([]byte(`
+kinds:
+  HelmChart: 0
+severities:
+  suspended: 0.1
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(m.Kinds[sourcev1.HelmChartKind]).To(BeZero())
+	g.Expect(m.Kinds[kustomizev1.KustomizationKind]).To(Equal(3.0))
+	g.Expect(m.Severities[Suspended]).To(Equal(0.1))
+	g.Expect(m.Severities[Failed]).To(Equal(1.0))
+	g.Expect(m.ScoredKinds()).NotTo(ContainElement(sourcev1.HelmChartKind))
+
+	for _, invalid := range []string{
+		"kinds: {Deployment: 1}",
+		"kinds: {Kustomization: -1}",
+		"severities: {broken: 1}",
+		"severities: {failed: 2}",
+		"weights: {}",
+	} {
+		_, err := ParseModel([]byte(invalid))
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
+
+func TestModelScore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	m := DefaultModel()
+
+	g.Expect(m.Score(Counts{})).To(Equal(100.0))
+
+	counts := Counts{}
+	counts.Add(kustomizev1.KustomizationKind, Healthy)
+	counts.Add(kustomizev1.KustomizationKind, Failed)
+	counts.Add(sourcev1.GitRepositoryKind, Progressing)
+	// (3*1 + 1*0.25) / (3+3+1)
+	g.Expect(m.Score(counts)).To(Equal(53.6))
+
+	// Kinds that aren't scored don't count
+	counts.Add("ConfigMap", Failed)
+	g.Expect(m.Score(counts)).To(Equal(53.6))
+}
@@ -0,0 +1,246 @@
+package healthscore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/statushistory"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var kindsByName = func() map[string]schema.GroupVersionKind {
+	kinds := map[string]schema.GroupVersionKind{}
+	for _, gvk := range statushistory.DefaultKinds {
+		kinds[gvk.Kind] = gvk
+	}
+
+	return kinds
+}()
+
+var (
+	opsScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gitops",
+			Subsystem: "health",
+			Name:      "score",
+			Help:      "The health score of the cluster, from 0 to 100",
+		},
+		[]string{"cluster"},
+	)
+	opsObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gitops",
+			Subsystem: "health",
+			Name:      "objects",
+			Help:      "The number of scored objects by kind and severity",
+		},
+		[]string{"cluster", "kind", "severity"},
+	)
+
+	Registry = prometheus.NewRegistry()
+)
+
+func init() {
+	Registry.MustRegister(opsScore, opsObjects)
+}
+
+// Score is the health of a cluster.
+type Score struct {
+	ClusterName string
+	Score       float64
+	Objects     Counts
+	Time        time.Time
+}
+
+// Scorer periodically scores every cluster. It uses the server's own
+// permissions, so the service account must be able to list the scored
+// kinds.
+type Scorer struct {
+	log             logr.Logger
+	clustersManager clustersmngr.ClustersManager
+	model           *Model
+	interval        time.Duration
+	now             func() time.Time
+
+	mu     sync.RWMutex
+	scores map[string]Score
+}
+
+// NewScorer creates a scorer that scores clusters every interval.
+func NewScorer(log logr.Logger, clustersManager clustersmngr.ClustersManager, model *Model, interval time.Duration) *Scorer {
+	return &Scorer{
+		log:             log.WithName("health-score"),
+		clustersManager: clustersManager,
+		model:           model,
+		interval:        interval,
+		now:             time.Now,
+		scores:          map[string]Score{},
+	}
+}
+
+// Start scores clusters until the context is cancelled.
+func (s *Scorer) Start(ctx context.Context) {
+	go wait.UntilWithContext(ctx, s.Update, s.interval)
+}
+
+// Update scores every cluster. Clusters where some objects couldn't be
+// listed aren't scored, rather than scored on partial data, so alerts can
+// tell a missing score from a good one.
+func (s *Scorer) Update(ctx context.Context) {
+	c, err := s.clustersManager.GetServerClient(ctx)
+	if err != nil {
+		s.log.Error(err, "failed to get clients for some clusters")
+
+		if c == nil {
+			return
+		}
+	}
+
+	counts := map[string]Counts{}
+	for _, cl := range s.clustersManager.GetClusters() {
+		counts[cl.GetName()] = Counts{}
+	}
+
+	failed := map[string]bool{}
+
+	for _, kind := range s.model.ScoredKinds() {
+		gvk := kindsByName[kind]
+
+		clist := clustersmngr.NewClusteredList(func() client.ObjectList {
+			list := unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk)
+
+			return &list
+		})
+
+		if err := c.ClusteredList(ctx, clist, false); err != nil {
+			var errs clustersmngr.ClusteredListError
+			if !errors.As(err, &errs) {
+				s.log.Error(err, "failed to list objects", "kind", kind)
+				return
+			}
+
+			for _, e := range errs.Errors {
+				s.log.Error(e.Err, "failed to list objects", "kind", kind, "cluster", e.Cluster)
+				failed[e.Cluster] = true
+			}
+		}
+
+		for clusterName, lists := range clist.Lists() {
+			if counts[clusterName] == nil {
+				counts[clusterName] = Counts{}
+			}
+
+			for _, l := range lists {
+				list, ok := l.(*unstructured.UnstructuredList)
+				if !ok {
+					continue
+				}
+
+				for i := range list.Items {
+					counts[clusterName].Add(kind, SeverityOf(&list.Items[i]))
+				}
+			}
+		}
+	}
+
+	now := s.now()
+	scores := map[string]Score{}
+
+	for clusterName, c := range counts {
+		if failed[clusterName] {
+			continue
+		}
+
+		scores[clusterName] = Score{
+			ClusterName: clusterName,
+			Score:       s.model.Score(c),
+			Objects:     c,
+			Time:        now,
+		}
+	}
+
+	s.mu.Lock()
+	s.scores = scores
+	s.mu.Unlock()
+
+	recordMetrics(scores)
+}
+
+// Scores returns the latest score of each cluster, sorted by cluster name.
+func (s *Scorer) Scores() []Score {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores := []Score{}
+	for _, score := range s.scores {
+		scores = append(scores, score)
+	}
+
+	sort.Slice(scores, func(i, j int) bool { return scores[i].ClusterName < scores[j].ClusterName })
+
+	return scores
+}
+
+func recordMetrics(scores map[string]Score) {
+	// Clusters that went away or can't be scored anymore stop reporting
+	opsScore.Reset()
+	opsObjects.Reset()
+
+	for clusterName, score := range scores {
+		opsScore.WithLabelValues(clusterName).Set(score.Score)
+
+		for kind, bySeverity := range score.Objects {
+			for severity, n := range bySeverity {
+				opsObjects.WithLabelValues(clusterName, kind, severity).Set(float64(n))
+			}
+		}
+	}
+}
+
+// SeverityOf returns the severity of the state of a Flux object.
+func SeverityOf(obj *unstructured.Unstructured) string {
+	if suspended, _, _ := unstructured.NestedBool(obj.Object, "spec", "suspend"); suspended {
+		return Suspended
+	}
+
+	ready, reconciling := "", false
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch m["type"] {
+		case "Ready":
+			ready, _ = m["status"].(string)
+		case "Reconciling":
+			reconciling = m["status"] == "True"
+		}
+	}
+
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	outdated := observedGeneration < obj.GetGeneration()
+
+	switch {
+	case reconciling || (ready == "True" && outdated):
+		return Progressing
+	case ready == "True":
+		return Healthy
+	case ready == "False":
+		return Failed
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,138 @@
+package healthscore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/nsaccess/nsaccessfakes"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScorerUpdate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ready := metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Succeeded"}
+	failed := metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "BuildFailed"}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "apps"},
+			Status:     kustomizev1.KustomizationStatus{Conditions: []metav1.Condition{ready}},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "apps"},
+			Status:     kustomizev1.KustomizationStatus{Conditions: []metav1.Condition{failed}},
+		},
+		&sourcev1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "apps"},
+			Spec:       sourcev1.GitRepositorySpec{Suspend: true},
+		},
+	).Build()
+
+	cluster := clusterfakes.FakeCluster{}
+	cluster.GetNameReturns("Default")
+	cluster.GetServerClientReturns(fakeClient, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(&cluster)}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	scorer := NewScorer(logr.Discard(), clustersManager, DefaultModel(), time.Minute)
+	scorer.now = func() time.Time { return time.Date(2022, 11, 10, 14, 5, 0, 0, time.UTC) }
+
+	scorer.Update(ctx)
+
+	// (3*0 + 3*1 + 1*0.5) / (3+3+1)
+	g.Expect(scorer.Scores()).To(Equal([]Score{{
+		ClusterName: "Default",
+		Score:       50,
+		Objects: Counts{
+			kustomizev1.KustomizationKind: {Healthy: 1, Failed: 1},
+			sourcev1.GitRepositoryKind:    {Suspended: 1},
+		},
+		Time: time.Date(2022, 11, 10, 14, 5, 0, 0, time.UTC),
+	}}))
+
+	g.Expect(testutil.ToFloat64(opsScore.WithLabelValues("Default"))).To(Equal(50.0))
+	g.Expect(testutil.ToFloat64(opsObjects.WithLabelValues("Default", kustomizev1.KustomizationKind, Failed))).To(Equal(1.0))
+}
+
+func TestSeverityOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		obj    map[string]interface{}
+		result string
+	}{
+		{
+			name:   "no status",
+			obj:    map[string]interface{}{},
+			result: Unknown,
+		},
+		{
+			name: "suspended",
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"suspend": true},
+				"status": conditions("Ready", "False"),
+			},
+			result: Suspended,
+		},
+		{
+			name:   "ready",
+			obj:    map[string]interface{}{"status": conditions("Ready", "True")},
+			result: Healthy,
+		},
+		{
+			name:   "not ready",
+			obj:    map[string]interface{}{"status": conditions("Ready", "False")},
+			result: Failed,
+		},
+		{
+			name:   "reconciling",
+			obj:    map[string]interface{}{"status": conditions("Ready", "Unknown", "Reconciling", "True")},
+			result: Progressing,
+		},
+		{
+			name: "ready but not reconciled since changed",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"status":   conditions("Ready", "True"),
+			},
+			result: Progressing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(SeverityOf(&unstructured.Unstructured{Object: tt.obj})).To(Equal(tt.result))
+		})
+	}
+}
+
+// conditions returns a status with conditions from pairs of types and
+// statuses.
+func conditions(pairs ...string) map[string]interface{} {
+	conds := []interface{}{}
+	for i := 0; i < len(pairs); i += 2 {
+		conds = append(conds, map[string]interface{}{"type": pairs[i], "status": pairs[i+1]})
+	}
+
+	return map[string]interface{}{"conditions": conds, "observedGeneration": int64(1)}
+}
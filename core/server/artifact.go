@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ArtifactPath is where the artifact of a source is downloaded from. It
+// takes the same kind, namespace and clusterName query parameters as
+// GetObject.
+const ArtifactPath = "/v1/object/{name}/artifact"
+
+// sourceControllerService is the name of the service artifacts are served by.
+const sourceControllerService = "source-controller"
+
+var artifactKinds = map[string]bool{
+	sourcev1.GitRepositoryKind:  true,
+	sourcev1.OCIRepositoryKind:  true,
+	sourcev1.BucketKind:         true,
+	sourcev1.HelmChartKind:      true,
+	sourcev1.HelmRepositoryKind: true,
+}
+
+// DownloadArtifact streams the current artifact of a source from
+// source-controller, through the API server of the source's cluster, picked
+// by name or alias.
+func (cs *coreServer) DownloadArtifact(rw http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	kind := query.Get("kind")
+	if !artifactKinds[kind] {
+		http.Error(rw, fmt.Sprintf("objects of kind %q don't have artifacts", kind), http.StatusBadRequest)
+		return
+	}
+
+	gvk, err := cs.primaryKinds.Lookup(kind)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clusterName := query.Get("clusterName")
+	if clusterName == "" {
+		clusterName = cluster.DefaultCluster
+	}
+
+	// The artifact is fetched with the server's permissions, so check the
+	// user can see the source itself.
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("error getting impersonating client: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	obj := unstructured.Unstructured{}
+	obj.SetGroupVersionKind(*gvk)
+
+	if err := clustersClient.Get(ctx, clusterName, client.ObjectKey{Name: pathParams["name"], Namespace: query.Get("namespace")}, &obj); err != nil {
+		http.Error(rw, err.Error(), statusFromAPIError(err))
+		return
+	}
+
+	artifactURL, _, _ := unstructured.NestedString(obj.Object, "status", "artifact", "url")
+	if artifactURL == "" {
+		http.Error(rw, fmt.Sprintf("%s %s/%s has no artifact", kind, obj.GetNamespace(), obj.GetName()), http.StatusNotFound)
+		return
+	}
+
+	namespace, service, port, artifactPath, err := parseArtifactURL(artifactURL)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	c, err := cs.clustersManager.GetCluster(clusterName)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	res, err := proxyArtifact(ctx, c, namespace, service, port, artifactPath)
+	if err != nil {
+		cs.logger.Error(err, "failed to fetch artifact", "cluster", clusterName, "url", artifactURL)
+		http.Error(rw, fmt.Sprintf("failed to fetch artifact: %v", err), http.StatusBadGateway)
+
+		return
+	}
+	defer res.Body.Close()
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		rw.Header().Set("Content-Type", contentType)
+	}
+
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(artifactPath)))
+
+	if revision, _, _ := unstructured.NestedString(obj.Object, "status", "artifact", "revision"); revision != "" {
+		rw.Header().Set("X-Artifact-Revision", revision)
+	}
+
+	if _, err := io.Copy(rw, res.Body); err != nil {
+		cs.logger.Error(err, "failed to stream artifact", "cluster", clusterName, "url", artifactURL)
+	}
+}
+
+// proxyArtifact gets the artifact from the service proxy of the API server
+// of the cluster, with the server's permissions.
+func proxyArtifact(ctx context.Context, c cluster.Cluster, namespace, service, port, artifactPath string) (*http.Response, error) {
+	config, err := c.GetServerConfig()
+	if err != nil {
+		return nil, fmt.Errorf("getting config of cluster: %w", err)
+	}
+
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating client for cluster: %w", err)
+	}
+
+	u, _, err := rest.DefaultServerURL(config.Host, "", schema.GroupVersion{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join("/", u.Path, "api/v1/namespaces", namespace, "services", utilnet.JoinSchemeNamePort("http", service, port), "proxy", artifactPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	return res, nil
+}
+
+// parseArtifactURL splits an artifact URL advertised by source-controller,
+// e.g. http://source-controller.flux-system.svc.cluster.local./gitrepository/apps/podinfo/abc.tar.gz,
+// into the service it is served by and the path on that service.
+//
+// Only URLs of the source-controller service are accepted, as the status of
+// a source shouldn't be able to make the dashboard fetch from anywhere else.
+func parseArtifactURL(artifactURL string) (namespace, service, port, artifactPath string, err error) {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("invalid artifact URL %q: %w", artifactURL, err)
+	}
+
+	labels := strings.Split(strings.TrimSuffix(u.Hostname(), "."), ".")
+	if u.Scheme != "http" || len(labels) < 3 || labels[2] != "svc" {
+		return "", "", "", "", fmt.Errorf("artifact URL %q is not served by an in-cluster service", artifactURL)
+	}
+
+	if labels[0] != sourceControllerService {
+		return "", "", "", "", fmt.Errorf("artifact URL %q is not served by %s", artifactURL, sourceControllerService)
+	}
+
+	return labels[1], labels[0], u.Port(), u.Path, nil
+}
+
+func statusFromAPIError(err error) int {
+	switch {
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsForbidden(err):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
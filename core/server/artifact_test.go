@@ -0,0 +1,129 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/nsaccess/nsaccessfakes"
+	"github.com/weaveworks/weave-gitops/core/server"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	typedauth "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	restclient "k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDownloadArtifact(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	withArtifact := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: ns.Name},
+		Status: sourcev1.GitRepositoryStatus{
+			Artifact: &sourcev1.Artifact{
+				URL:      "http://source-controller.flux-system.svc.cluster.local./gitrepository/apps/podinfo/abc123.tar.gz",
+				Revision: "main/abc123",
+			},
+		},
+	}
+	elsewhere := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: ns.Name},
+		Status: sourcev1.GitRepositoryStatus{
+			Artifact: &sourcev1.Artifact{URL: "http://example.com/abc123.tar.gz"},
+		},
+	}
+	otherService := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-service", Namespace: ns.Name},
+		Status: sourcev1.GitRepositoryStatus{
+			Artifact: &sourcev1.Artifact{URL: "http://kube-dns.kube-system.svc.cluster.local./abc123.tar.gz"},
+		},
+	}
+	noArtifact := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: ns.Name},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns, withArtifact, elsewhere, otherService, noArtifact).Build()
+
+	var proxied *http.Request
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		proxied = r.Clone(context.Background())
+
+		rw.Header().Set("Content-Type", "application/x-tar")
+		_, _ = rw.Write([]byte("tarball"))
+	}))
+	defer apiServer.Close()
+
+	clientset := k8sfake.NewSimpleClientset()
+
+	cluster := clusterfakes.FakeCluster{}
+	cluster.GetNameReturns("Default")
+	cluster.GetUserClientReturns(client, nil)
+	cluster.GetServerClientReturns(client, nil)
+	cluster.GetUserClientsetReturns(clientset, nil)
+	cluster.GetServerClientsetReturns(clientset, nil)
+	cluster.GetServerConfigReturns(&restclient.Config{Host: apiServer.URL}, nil)
+
+	checker := nsaccessfakes.FakeChecker{}
+	checker.FilterAccessibleNamespacesStub = func(ctx context.Context, t typedauth.AuthorizationV1Interface, n []corev1.Namespace) ([]corev1.Namespace, error) {
+		return n, nil
+	}
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(&cluster)}, &checker, logr.Discard(),
+		clustersmngr.WithClusterAliases(map[string]string{"management": "Default"}),
+	)
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+	g.Expect(clustersManager.UpdateNamespaces(ctx)).To(Succeed())
+
+	cfg, err := server.NewCoreConfig(logr.Discard(), &restclient.Config{}, "foobar", clustersManager)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	mux := runtime.NewServeMux()
+	g.Expect(server.Hydrate(ctx, mux, cfg)).To(Succeed())
+
+	download := func(name, kind string, query ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/object/"+name+"/artifact?namespace=apps&kind="+kind+strings.Join(query, ""), nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.UserPrincipal{ID: "anne", Groups: []string{"system:masters"}}))
+
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+
+		return res
+	}
+
+	res := download("podinfo", sourcev1.GitRepositoryKind)
+	g.Expect(res.Code).To(Equal(http.StatusOK))
+	g.Expect(res.Body.String()).To(Equal("tarball"))
+	g.Expect(res.Header().Get("Content-Disposition")).To(Equal(`attachment; filename="abc123.tar.gz"`))
+	g.Expect(res.Header().Get("X-Artifact-Revision")).To(Equal("main/abc123"))
+	g.Expect(res.Header().Get("Content-Type")).To(Equal("application/x-tar"))
+	g.Expect(proxied.URL.Path).To(Equal("/api/v1/namespaces/flux-system/services/http:source-controller:/proxy/gitrepository/apps/podinfo/abc123.tar.gz"))
+
+	proxied = nil
+	res = download("podinfo", sourcev1.GitRepositoryKind, "&clusterName=management")
+	g.Expect(res.Code).To(Equal(http.StatusOK))
+	g.Expect(proxied).NotTo(BeNil())
+
+	g.Expect(download("pending", sourcev1.GitRepositoryKind).Code).To(Equal(http.StatusNotFound))
+	g.Expect(download("missing", sourcev1.GitRepositoryKind).Code).To(Equal(http.StatusNotFound))
+	g.Expect(download("elsewhere", sourcev1.GitRepositoryKind).Code).To(Equal(http.StatusBadGateway))
+	g.Expect(download("other-service", sourcev1.GitRepositoryKind).Code).To(Equal(http.StatusBadGateway))
+	g.Expect(download("podinfo", "Kustomization").Code).To(Equal(http.StatusBadRequest))
+}
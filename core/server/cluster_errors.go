@@ -0,0 +1,87 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// clusterClientError is the status of an error getting the client of a
+// cluster. Unknown clusters are not found, with the known ones listed.
+func clusterClientError(err error) error {
+	if errors.As(err, &clustersmngr.ClusterNotFoundError{}) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	return doClientError(err)
+}
+
+// clusterErrors groups the errors of the lists that failed by cluster, sorted
+// by cluster name. Lists of a whole cluster have no namespace.
+func clusterErrors(errs []clustersmngr.ListError) []*pb.ClusterError {
+	var (
+		byCluster = map[string]*pb.ClusterError{}
+		messages  = map[string][]string{}
+		seen      = map[[2]string]bool{}
+	)
+
+	for _, e := range errs {
+		ce, ok := byCluster[e.Cluster]
+		if !ok {
+			ce = &pb.ClusterError{ClusterName: e.Cluster}
+			byCluster[e.Cluster] = ce
+		}
+
+		if e.Namespace != "" {
+			ce.Namespaces = append(ce.Namespaces, e.Namespace)
+		}
+
+		// namespaces usually fail for the same reason, e.g. the cluster
+		// being unreachable
+		msg := e.Err.Error()
+		if !seen[[2]string{e.Cluster, msg}] {
+			seen[[2]string{e.Cluster, msg}] = true
+			messages[e.Cluster] = append(messages[e.Cluster], msg)
+		}
+	}
+
+	result := make([]*pb.ClusterError, 0, len(byCluster))
+
+	for clusterName, ce := range byCluster {
+		sort.Strings(ce.Namespaces)
+		ce.Message = strings.Join(messages[clusterName], "; ")
+
+		result = append(result, ce)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ClusterName < result[j].ClusterName
+	})
+
+	return result
+}
+
+// clientListErrors returns the clusters GetImpersonatedClient couldn't make a
+// client for as failed lists.
+func clientListErrors(err error) []clustersmngr.ListError {
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		return nil
+	}
+
+	var errs []clustersmngr.ListError
+
+	for _, err := range merr.Errors {
+		if cerr, ok := err.(*clustersmngr.ClientError); ok {
+			errs = append(errs, clustersmngr.ListError{Cluster: cerr.ClusterName, Err: cerr.Err})
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func (cs *coreServer) GetClusterStatus(ctx context.Context, msg *pb.GetClusterStatusRequest) (*pb.GetClusterStatusResponse, error) {
+	selector, err := parseClusterSelector(msg.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetClusterStatusResponse{Clusters: []*pb.ClusterStatus{}}
+
+	// the errors and versions of clusters are only shown to their users
+	visible := cs.userClusters(ctx)
+
+	for _, cl := range cs.clustersManager.GetClusters(clustersmngr.MatchingClusterLabels(selector)) {
+		if msg.ClusterName != "" && cl.GetName() != msg.ClusterName {
+			continue
+		}
+
+		if !visible[cl.GetName()] {
+			continue
+		}
+
+		// Clusters that haven't been checked yet only have a name
+		s, _ := cs.clustersManager.GetClusterStatus(cl.GetName())
+		s.Name = cl.GetName()
+
+		clusterStatus := clusterStatusToProto(s)
+		clusterStatus.Labels = cl.GetLabels()
+		clusterStatus.Id = cl.GetID()
+
+		resp.Clusters = append(resp.Clusters, clusterStatus)
+	}
+
+	if msg.ClusterName != "" && len(resp.Clusters) == 0 {
+		return nil, status.Errorf(codes.NotFound, "cluster %q not found", msg.ClusterName)
+	}
+
+	return resp, nil
+}
+
+// userClusters returns the names of the clusters the user can access any
+// namespace of.
+func (cs *coreServer) userClusters(ctx context.Context) map[string]bool {
+	principal := auth.Principal(ctx)
+
+	namespaces := cs.clustersManager.GetUserNamespaces(principal)
+	if len(namespaces) == 0 {
+		cs.clustersManager.UpdateUserNamespaces(ctx, principal)
+		namespaces = cs.clustersManager.GetUserNamespaces(principal)
+	}
+
+	clusters := map[string]bool{}
+
+	for clusterName, ns := range namespaces {
+		if len(ns) > 0 {
+			clusters[clusterName] = true
+		}
+	}
+
+	return clusters
+}
+
+// parseClusterSelector parses a selector of cluster labels, nil if it's
+// empty.
+func parseClusterSelector(selector string) (labels.Selector, error) {
+	if selector == "" {
+		return nil, nil
+	}
+
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cluster label selector: %s", err)
+	}
+
+	return parsed, nil
+}
+
+func clusterStatusToProto(s clustersmngr.ClusterStatus) *pb.ClusterStatus {
+	return &pb.ClusterStatus{
+		Name:               s.Name,
+		Reachable:          s.Reachable,
+		ServerVersion:      s.ServerVersion,
+		LastChecked:        formatTime(s.LastChecked),
+		LastSuccessfulList: formatTime(s.LastSuccessfulList),
+		Error:              s.Error,
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
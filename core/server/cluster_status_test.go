@@ -0,0 +1,122 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/clustersmngrfakes"
+	"github.com/weaveworks/weave-gitops/core/server"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestGetClusterStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := auth.WithPrincipal(context.Background(), &auth.UserPrincipal{ID: "anne"})
+
+	checked := time.Date(2022, 11, 30, 12, 0, 0, 0, time.UTC)
+
+	statuses := map[string]clustersmngr.ClusterStatus{
+		"Default": {
+			Name:               "Default",
+			Reachable:          true,
+			ServerVersion:      "v1.25.4",
+			LastChecked:        checked,
+			LastSuccessfulList: checked.Add(-time.Minute),
+		},
+		"leaf": {
+			Name:        "leaf",
+			LastChecked: checked,
+			Error:       errors.New("connection refused").Error(),
+		},
+	}
+
+	clusters := []cluster.Cluster{}
+
+	for _, name := range []string{"Default", "leaf", "new"} {
+		cl := &clusterfakes.FakeCluster{}
+		cl.GetNameReturns(name)
+
+		if name == "leaf" {
+			cl.GetIDReturns("leaf-uid")
+			cl.GetLabelsReturns(map[string]string{"env": "prod"})
+		}
+
+		clusters = append(clusters, cl)
+	}
+
+	clustersManager := &clustersmngrfakes.FakeClustersManager{}
+	clustersManager.GetClustersReturns(clusters)
+	clustersManager.GetUserNamespacesReturns(map[string][]corev1.Namespace{
+		"Default": {{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}},
+		"leaf":    {{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}},
+		"new":     {{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}},
+	})
+	clustersManager.GetClusterStatusStub = func(name string) (clustersmngr.ClusterStatus, bool) {
+		s, ok := statuses[name]
+		return s, ok
+	}
+
+	cfg, err := server.NewCoreConfig(logr.Discard(), &rest.Config{}, "test", clustersManager)
+	g.Expect(err).NotTo(HaveOccurred())
+	coreSrv, err := server.NewCoreServer(cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	resp, err := coreSrv.GetClusterStatus(ctx, &pb.GetClusterStatusRequest{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resp.Clusters).To(HaveLen(3))
+
+	g.Expect(resp.Clusters[0].Reachable).To(BeTrue())
+	g.Expect(resp.Clusters[0].ServerVersion).To(Equal("v1.25.4"))
+	g.Expect(resp.Clusters[0].LastChecked).To(Equal("2022-11-30T12:00:00Z"))
+	g.Expect(resp.Clusters[0].LastSuccessfulList).To(Equal("2022-11-30T11:59:00Z"))
+
+	g.Expect(resp.Clusters[1].Reachable).To(BeFalse())
+	g.Expect(resp.Clusters[1].Error).To(Equal("connection refused"))
+	g.Expect(resp.Clusters[1].LastSuccessfulList).To(BeEmpty())
+	g.Expect(resp.Clusters[1].Labels).To(Equal(map[string]string{"env": "prod"}))
+	g.Expect(resp.Clusters[1].Id).To(Equal("leaf-uid"))
+
+	// Not checked yet
+	g.Expect(resp.Clusters[2].Name).To(Equal("new"))
+	g.Expect(resp.Clusters[2].LastChecked).To(BeEmpty())
+
+	resp, err = coreSrv.GetClusterStatus(ctx, &pb.GetClusterStatusRequest{ClusterName: "leaf"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resp.Clusters).To(HaveLen(1))
+	g.Expect(resp.Clusters[0].Name).To(Equal("leaf"))
+
+	_, err = coreSrv.GetClusterStatus(ctx, &pb.GetClusterStatusRequest{ClusterName: "missing"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+	_, err = coreSrv.GetClusterStatus(ctx, &pb.GetClusterStatusRequest{LabelSelector: "env in prod"})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	t.Run("clusters the user can't access aren't shown", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		clustersManager.GetUserNamespacesReturns(map[string][]corev1.Namespace{
+			"Default": {{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}},
+			"leaf":    {},
+		})
+
+		resp, err := coreSrv.GetClusterStatus(ctx, &pb.GetClusterStatusRequest{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(resp.Clusters).To(HaveLen(1))
+		g.Expect(resp.Clusters[0].Name).To(Equal("Default"))
+
+		_, err = coreSrv.GetClusterStatus(ctx, &pb.GetClusterStatusRequest{ClusterName: "leaf"})
+		g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterRegistration is where the clusters added through the API are
+// written, so the kubeconfig secrets fetcher picks them up.
+type ClusterRegistration struct {
+	// Namespace the kubeconfig secrets are created in, on the management
+	// cluster.
+	Namespace string
+	// Labels of the kubeconfig secrets, matching the fetcher's selector.
+	Labels map[string]string
+}
+
+func (cs *coreServer) AddCluster(ctx context.Context, msg *pb.AddClusterRequest) (*pb.AddClusterResponse, error) {
+	if cs.clusterRegistration == nil {
+		return nil, status.Error(codes.FailedPrecondition, "cluster registration is not enabled")
+	}
+
+	if errs := validation.IsDNS1123Subdomain(msg.Name); len(errs) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cluster name %q: %s", msg.Name, strings.Join(errs, ", "))
+	}
+
+	if err := validateKubeconfig([]byte(msg.Kubeconfig)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid kubeconfig: %s", err)
+	}
+
+	c, err := cs.managementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      msg.Name,
+			Namespace: cs.clusterRegistration.Namespace,
+			Labels:    cs.clusterRegistration.Labels,
+		},
+		Data: map[string][]byte{
+			fetcher.DefaultKubeconfigSecretKey: []byte(msg.Kubeconfig),
+		},
+	}
+
+	if err := c.Create(ctx, secret); err != nil {
+		return nil, clusterSecretError(err, "creating kubeconfig secret")
+	}
+
+	cs.logger.Info("Cluster added", "user", auth.Principal(ctx).ID, "namespace", secret.Namespace, "name", secret.Name)
+
+	cs.updateClusters(ctx)
+
+	return &pb.AddClusterResponse{ClusterName: secret.Namespace + "/" + secret.Name}, nil
+}
+
+func (cs *coreServer) RemoveCluster(ctx context.Context, msg *pb.RemoveClusterRequest) (*pb.RemoveClusterResponse, error) {
+	if cs.clusterRegistration == nil {
+		return nil, status.Error(codes.FailedPrecondition, "cluster registration is not enabled")
+	}
+
+	c, err := cs.managementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Name: msg.Name, Namespace: cs.clusterRegistration.Namespace}
+
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, clusterSecretError(err, "getting kubeconfig secret")
+	}
+
+	// only secrets that could have been added are removed, not any secret
+	// the user can delete
+	if !labels.SelectorFromSet(cs.clusterRegistration.Labels).Matches(labels.Set(secret.Labels)) {
+		return nil, status.Errorf(codes.NotFound, "cluster %q was not registered", msg.Name)
+	}
+
+	if err := c.Delete(ctx, secret, client.Preconditions{UID: &secret.UID}); err != nil {
+		return nil, clusterSecretError(err, "deleting kubeconfig secret")
+	}
+
+	cs.logger.Info("Cluster removed", "user", auth.Principal(ctx).ID, "namespace", secret.Namespace, "name", secret.Name)
+
+	cs.updateClusters(ctx)
+
+	return &pb.RemoveClusterResponse{}, nil
+}
+
+// managementClient returns a client of the management cluster impersonating
+// the user, so they need to be allowed to manage the kubeconfig secrets.
+func (cs *coreServer) managementClient(ctx context.Context) (client.Client, error) {
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error getting impersonating client: %s", err)
+	}
+
+	c, err := clustersClient.Scoped(cluster.DefaultCluster)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "getting cluster client: %s", err)
+	}
+
+	return c, nil
+}
+
+// updateClusters picks up the change right away. The secrets are watched and
+// fetched periodically too, so a failure is only logged.
+func (cs *coreServer) updateClusters(ctx context.Context) {
+	if err := cs.clustersManager.UpdateClusters(ctx); err != nil {
+		cs.logger.Error(err, "failed to update clusters")
+	}
+}
+
+// validateKubeconfig checks the kubeconfig can be used, without running
+// commands or reading files on the server, which whoever can add a cluster
+// could use to get the server's credentials.
+func validateKubeconfig(data []byte) error {
+	kubeconfig, err := clientcmd.Load(data)
+	if err != nil {
+		return err
+	}
+
+	if settings := cluster.LocalCredentials(kubeconfig); len(settings) > 0 {
+		return fmt.Errorf("%s not allowed, the credentials must be inline", strings.Join(settings, ", "))
+	}
+
+	_, err = clientcmd.NewDefaultClientConfig(*kubeconfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+
+	return err
+}
+
+func clusterSecretError(err error, msg string) error {
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		return status.Errorf(codes.AlreadyExists, "%s: %s", msg, err)
+	case apierrors.IsNotFound(err):
+		return status.Errorf(codes.NotFound, "%s: %s", msg, err)
+	case apierrors.IsForbidden(err):
+		return status.Errorf(codes.PermissionDenied, "%s: %s", msg, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %s", msg, err)
+	}
+}
@@ -0,0 +1,113 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/server"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAddRemoveCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	unrelated := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "clusters"}}
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(unrelated).Build()
+	cfg := makeServerConfig(k, t)
+	cfg.ClusterRegistration = &server.ClusterRegistration{
+		Namespace: "clusters",
+		Labels:    map[string]string{"weave.works/cluster": "true"},
+	}
+
+	c := makeServer(cfg, t)
+
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"leaf": {Server: "https://leaf.example.com"}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"leaf": {Token: "token"}},
+		Contexts:       map[string]*clientcmdapi.Context{"leaf": {Cluster: "leaf", AuthInfo: "leaf"}},
+		CurrentContext: "leaf",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	res, err := c.AddCluster(ctx, &pb.AddClusterRequest{Name: "leaf", Kubeconfig: string(kubeconfig)})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.ClusterName).To(Equal("clusters/leaf"))
+
+	secret := &corev1.Secret{}
+	g.Expect(k.Get(ctx, client.ObjectKey{Name: "leaf", Namespace: "clusters"}, secret)).To(Succeed())
+	g.Expect(secret.Labels).To(Equal(map[string]string{"weave.works/cluster": "true"}))
+	g.Expect(secret.Data[fetcher.DefaultKubeconfigSecretKey]).To(Equal(kubeconfig))
+
+	_, err = c.AddCluster(ctx, &pb.AddClusterRequest{Name: "leaf", Kubeconfig: string(kubeconfig)})
+	g.Expect(status.Code(err)).To(Equal(codes.AlreadyExists))
+
+	_, err = c.AddCluster(ctx, &pb.AddClusterRequest{Name: "Not_Valid", Kubeconfig: string(kubeconfig)})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	_, err = c.AddCluster(ctx, &pb.AddClusterRequest{Name: "broken", Kubeconfig: "not a kubeconfig"})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	// kubeconfigs can't run commands or read files on the server
+	for name, authInfo := range map[string]*clientcmdapi.AuthInfo{
+		"exec":          {Exec: &clientcmdapi.ExecConfig{Command: "sh", Args: []string{"-c", "id"}}},
+		"auth-provider": {AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc"}},
+		"token-file":    {TokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token"},
+		"cert-file":     {ClientCertificate: "/etc/tls/tls.crt", ClientKey: "/etc/tls/tls.key"},
+	} {
+		local, err := clientcmd.Write(clientcmdapi.Config{
+			Clusters:       map[string]*clientcmdapi.Cluster{"leaf": {Server: "https://leaf.example.com"}},
+			AuthInfos:      map[string]*clientcmdapi.AuthInfo{"leaf": authInfo},
+			Contexts:       map[string]*clientcmdapi.Context{"leaf": {Cluster: "leaf", AuthInfo: "leaf"}},
+			CurrentContext: "leaf",
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		_, err = c.AddCluster(ctx, &pb.AddClusterRequest{Name: name, Kubeconfig: string(local)})
+		g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument), name)
+		g.Expect(k.Get(ctx, client.ObjectKey{Name: name, Namespace: "clusters"}, &corev1.Secret{})).NotTo(Succeed())
+	}
+
+	_, err = c.RemoveCluster(ctx, &pb.RemoveClusterRequest{Name: "unrelated"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+	g.Expect(k.Get(ctx, client.ObjectKeyFromObject(unrelated), &corev1.Secret{})).To(Succeed())
+
+	_, err = c.RemoveCluster(ctx, &pb.RemoveClusterRequest{Name: "leaf"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(k.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})).NotTo(Succeed())
+
+	_, err = c.RemoveCluster(ctx, &pb.RemoveClusterRequest{Name: "leaf"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+}
+
+func TestAddClusterDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	k := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := makeServer(makeServerConfig(k, t), t)
+
+	_, err = c.AddCluster(context.Background(), &pb.AddClusterRequest{Name: "leaf"})
+	g.Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+	_, err = c.RemoveCluster(context.Background(), &pb.RemoveClusterRequest{Name: "leaf"})
+	g.Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+}
@@ -2,10 +2,14 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/fluxsync"
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	"google.golang.org/grpc/codes"
@@ -27,37 +31,128 @@ func (cs *coreServer) ListEvents(ctx context.Context, msg *pb.ListEventsRequest)
 	if msg.InvolvedObject.ClusterName != "" {
 		clustersClient, err = cs.clustersManager.GetImpersonatedClientForCluster(ctx, auth.Principal(ctx), msg.InvolvedObject.ClusterName)
 	} else {
-		clustersClient, err = cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+		clustersClient, err = cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx), clustersmngr.WithEagerClients())
 	}
 
 	if err != nil {
-		return nil, doClientError(err)
+		return nil, clusterClientError(err)
+	}
+
+	kind := msg.InvolvedObject.Kind
+
+	gvk, err := cs.primaryKinds.Lookup(kind)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad request: not a recognized object kind")
+	}
+
+	involvedObjects := []corev1.ObjectReference{{
+		Kind:      gvk.Kind,
+		Name:      msg.InvolvedObject.Name,
+		Namespace: msg.InvolvedObject.Namespace,
+	}}
+
+	var failedLists []clustersmngr.ListError
+
+	if msg.WithSource {
+		clusterName := msg.InvolvedObject.ClusterName
+		if clusterName == "" {
+			clusterName = cluster.DefaultCluster
+		}
+
+		source, err := getEventsSource(ctx, clustersClient, clusterName, msg.InvolvedObject, gvk.Kind)
+		if err != nil {
+			// the events of the object are still returned
+			failedLists = append(failedLists, clustersmngr.ListError{
+				Cluster:   clusterName,
+				Namespace: msg.InvolvedObject.Namespace,
+				Err:       fmt.Errorf("getting source: %w", err),
+			})
+		} else if source != nil {
+			involvedObjects = append(involvedObjects, *source)
+		}
 	}
 
 	clist := clustersmngr.NewClusteredList(func() client.ObjectList {
 		return &corev1.EventList{}
 	})
 
-	kind := msg.InvolvedObject.Kind
+	for i, obj := range involvedObjects {
+		fields := client.MatchingFields{
+			"involvedObject.kind":      obj.Kind,
+			"involvedObject.name":      obj.Name,
+			"involvedObject.namespace": obj.Namespace,
+		}
 
-	gvk, err := cs.primaryKinds.Lookup(kind)
+		if err := list(ctx, clustersClient, temporarilyEmptyAppName, obj.Namespace, clist, fields); err != nil {
+			var (
+				pf   clustersmngr.PartialFailure
+				errs clustersmngr.ClusteredListError
+			)
+
+			// the events of the clusters that could be listed are still
+			// returned, and the ones of the object when the ones of its
+			// source can't be listed
+			switch {
+			case errors.As(err, &pf):
+				failedLists = append(failedLists, pf.Errors...)
+			case i > 0 && errors.As(err, &errs):
+				failedLists = append(failedLists, errs.Errors...)
+			default:
+				return nil, fmt.Errorf("could not get events: %w", err)
+			}
+		}
+	}
+
+	return &pb.ListEventsResponse{Events: eventsToProto(clist), ClusterErrors: clusterErrors(failedLists)}, nil
+}
+
+// getEventsSource returns the source of a Kustomization or HelmRelease,
+// which defaults to the namespace of the object, and nil for other kinds.
+func getEventsSource(ctx context.Context, c clustersmngr.Client, clusterName string, ref *pb.ObjectRef, kind string) (*corev1.ObjectReference, error) {
+	obj, err := getFluxObject(kind)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "bad request: not a recognized object kind")
+		return nil, nil
 	}
 
-	fields := client.MatchingFields{
-		"involvedObject.kind":      gvk.Kind,
-		"involvedObject.name":      msg.InvolvedObject.Name,
-		"involvedObject.namespace": msg.InvolvedObject.Namespace,
+	automation, ok := obj.(fluxsync.Automation)
+	if !ok {
+		return nil, nil
 	}
 
-	if err := list(ctx, clustersClient, temporarilyEmptyAppName, msg.InvolvedObject.Namespace, clist, fields); err != nil {
-		return nil, fmt.Errorf("could not get events: %w", err)
+	if err := c.Get(ctx, clusterName, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, automation.AsClientObject()); err != nil {
+		return nil, err
 	}
 
-	events := []*pb.Event{}
+	sourceRef := automation.SourceRef()
 
-	for _, lists := range clist.Lists() {
+	namespace := sourceRef.Namespace()
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+
+	return &corev1.ObjectReference{
+		Kind:      sourceRef.Kind(),
+		Name:      sourceRef.Name(),
+		Namespace: namespace,
+	}, nil
+}
+
+// eventsToProto returns the events of the lists, the most recent first.
+// Events listed twice, or recorded again with the same reason and message
+// instead of being aggregated, are only returned once, as the latest.
+func eventsToProto(clist clustersmngr.ClusteredObjectList) []*pb.Event {
+	type clusterEvent struct {
+		cluster string
+		event   corev1.Event
+	}
+
+	type eventKey struct {
+		cluster, kind, namespace, name, eventType, reason, message string
+	}
+
+	latest := map[eventKey]clusterEvent{}
+
+	for clusterName, lists := range clist.Lists() {
 		for _, l := range lists {
 			list, ok := l.(*corev1.EventList)
 			if !ok {
@@ -65,20 +160,78 @@ func (cs *coreServer) ListEvents(ctx context.Context, msg *pb.ListEventsRequest)
 			}
 
 			for _, e := range list.Items {
-				events = append(events, &pb.Event{
-					Type:      e.Type,
-					Component: e.Source.Component,
-					Name:      e.ObjectMeta.Name,
-					Reason:    e.Reason,
-					Message:   e.Message,
-					Timestamp: e.LastTimestamp.Format(time.RFC3339),
-					Host:      e.Source.Host,
-				})
+				key := eventKey{
+					cluster:   clusterName,
+					kind:      e.InvolvedObject.Kind,
+					namespace: e.InvolvedObject.Namespace,
+					name:      e.InvolvedObject.Name,
+					eventType: e.Type,
+					reason:    e.Reason,
+					message:   e.Message,
+				}
+
+				if prev, ok := latest[key]; ok && !eventTime(e).After(eventTime(prev.event)) {
+					continue
+				}
+
+				latest[key] = clusterEvent{cluster: clusterName, event: e}
 			}
 		}
 	}
 
-	return &pb.ListEventsResponse{Events: events}, nil
+	sorted := make([]clusterEvent, 0, len(latest))
+	for _, e := range latest {
+		sorted = append(sorted, e)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		ti, tj := eventTime(sorted[i].event), eventTime(sorted[j].event)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+
+		return sorted[i].event.Name < sorted[j].event.Name
+	})
+
+	events := make([]*pb.Event, 0, len(sorted))
+
+	for _, ce := range sorted {
+		e := ce.event
+
+		events = append(events, &pb.Event{
+			Type:      e.Type,
+			Component: e.Source.Component,
+			Name:      e.ObjectMeta.Name,
+			Reason:    e.Reason,
+			Message:   e.Message,
+			Timestamp: eventTime(e).Format(time.RFC3339),
+			Host:      e.Source.Host,
+			Uid:       string(e.UID),
+			InvolvedObject: &pb.ObjectRef{
+				Kind:        e.InvolvedObject.Kind,
+				Name:        e.InvolvedObject.Name,
+				Namespace:   e.InvolvedObject.Namespace,
+				ClusterName: ce.cluster,
+			},
+		})
+	}
+
+	return events
+}
+
+// eventTime is when an event last happened. Events recorded with the
+// events.k8s.io API have no last timestamp.
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+
+	return e.CreationTimestamp.Time
 }
 
 func list(ctx context.Context, k8s clustersmngr.Client, appName, namespace string, list clustersmngr.ClusteredObjectList, extraOpts ...client.ListOption) error {
@@ -3,11 +3,13 @@ package server_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"testing"
 
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	. "github.com/onsi/gomega"
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
@@ -15,6 +17,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestListEvents(t *testing.T) {
@@ -132,6 +135,75 @@ func TestListEvents(t *testing.T) {
 	g.Expect(res.Events[0].Component).To(Equal(helmEvent.Source.Component))
 }
 
+func TestListEventsWithSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	apps := corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "apps"}}
+	fluxSystem := corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "flux-system"}}
+	gitRepo := makeGitRepo("podinfo", fluxSystem)
+	kust := makeKustomization("podinfo", apps, gitRepo)
+
+	now := time.Now().Truncate(time.Second)
+
+	event := func(name string, obj client.Object, kind, reason string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: obj.GetNamespace(),
+			},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      kind,
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+			},
+			Type:          corev1.EventTypeNormal,
+			Reason:        reason,
+			Message:       reason,
+			LastTimestamp: v1.NewTime(at),
+		}
+	}
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&apps, &fluxSystem, gitRepo, kust,
+		event("podinfo.1", kust, kustomizev1.KustomizationKind, "ReconciliationSucceeded", now.Add(-time.Hour)),
+		// recorded again instead of being aggregated
+		event("podinfo.2", kust, kustomizev1.KustomizationKind, "ReconciliationSucceeded", now.Add(-time.Minute)),
+		event("podinfo.3", kust, kustomizev1.KustomizationKind, "Progressing", now.Add(-2*time.Hour)),
+		event("podinfo.4", gitRepo, sourcev1.GitRepositoryKind, "NewArtifact", now.Add(-30*time.Minute)),
+	).Build()
+	c := makeServer(makeServerConfig(k, t), t)
+
+	ref := &pb.ObjectRef{
+		Kind:      kustomizev1.KustomizationKind,
+		Name:      kust.Name,
+		Namespace: kust.Namespace,
+	}
+
+	res, err := c.ListEvents(ctx, &pb.ListEventsRequest{InvolvedObject: ref, WithSource: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.ClusterErrors).To(BeEmpty())
+
+	names := []string{}
+	for _, e := range res.Events {
+		names = append(names, e.Name)
+	}
+
+	g.Expect(names).To(Equal([]string{"podinfo.2", "podinfo.4", "podinfo.3"}))
+	g.Expect(res.Events[0].Timestamp).To(Equal(now.Add(-time.Minute).UTC().Format(time.RFC3339)))
+	g.Expect(res.Events[1].InvolvedObject.Kind).To(Equal(sourcev1.GitRepositoryKind))
+	g.Expect(res.Events[1].InvolvedObject.Namespace).To(Equal(fluxSystem.Name))
+	g.Expect(res.Events[1].InvolvedObject.ClusterName).To(Equal("Default"))
+
+	// only the events of the object
+	res, err = c.ListEvents(ctx, &pb.ListEventsRequest{InvolvedObject: ref})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Events).To(HaveLen(2))
+}
+
 func newNamespace(ctx context.Context, k client.Client, g *GomegaWithT) *corev1.Namespace {
 	ns := &corev1.Namespace{}
 	ns.Name = "kube-test-" + rand.String(5)
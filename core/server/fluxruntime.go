@@ -56,15 +56,12 @@ func lookupEnv(envVar string, fallback string) string {
 func (cs *coreServer) ListFluxRuntimeObjects(ctx context.Context, msg *pb.ListFluxRuntimeObjectsRequest) (*pb.ListFluxRuntimeObjectsResponse, error) {
 	respErrors := []*pb.ListError{}
 
-	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
-	if err != nil {
-		if merr, ok := err.(*multierror.Error); ok {
-			for _, err := range merr.Errors {
-				if cerr, ok := err.(*clustersmngr.ClientError); ok {
-					respErrors = append(respErrors, &pb.ListError{ClusterName: cerr.ClusterName, Message: cerr.Error()})
-				}
-			}
-		}
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx), clustersmngr.WithEagerClients())
+
+	failedLists := clientListErrors(err)
+
+	for _, e := range failedLists {
+		respErrors = append(respErrors, &pb.ListError{ClusterName: e.Cluster, Message: e.Err.Error()})
 	}
 
 	var results []*pb.Deployment
@@ -85,6 +82,8 @@ func (cs *coreServer) ListFluxRuntimeObjects(ctx context.Context, msg *pb.ListFl
 		for _, fluxNs := range fluxNamepsaces {
 			if err := clustersClient.List(ctx, clusterName, list, opts, client.InNamespace(fluxNs.Name)); err != nil {
 				respErrors = append(respErrors, &pb.ListError{ClusterName: clusterName, Namespace: fluxNs.Name, Message: fmt.Sprintf("%s, %s", ErrListingDeployments.Error(), err)})
+				failedLists = append(failedLists, clustersmngr.ListError{Cluster: clusterName, Namespace: fluxNs.Name, Err: err})
+
 				continue
 			}
 
@@ -116,11 +115,11 @@ func (cs *coreServer) ListFluxRuntimeObjects(ctx context.Context, msg *pb.ListFl
 		}
 	}
 
-	return &pb.ListFluxRuntimeObjectsResponse{Deployments: results, Errors: respErrors}, nil
+	return &pb.ListFluxRuntimeObjectsResponse{Deployments: results, Errors: respErrors, ClusterErrors: clusterErrors(failedLists)}, nil
 }
 
 func (cs *coreServer) ListFluxCrds(ctx context.Context, msg *pb.ListFluxCrdsRequest) (*pb.ListFluxCrdsResponse, error) {
-	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx), clustersmngr.WithEagerClients())
 	if err != nil {
 		return nil, fmt.Errorf("error getting impersonating client: %w", err)
 	}
@@ -131,6 +130,8 @@ func (cs *coreServer) ListFluxCrds(ctx context.Context, msg *pb.ListFluxCrdsRequ
 
 	respErrors := []*pb.ListError{}
 
+	var failedLists []clustersmngr.ListError
+
 	opts := client.MatchingLabels{
 		coretypes.PartOfLabel: FluxNamespacePartOf,
 	}
@@ -148,6 +149,8 @@ func (cs *coreServer) ListFluxCrds(ctx context.Context, msg *pb.ListFluxCrdsRequ
 				Message:     e.Err.Error(),
 			})
 		}
+
+		failedLists = errs.Errors
 	}
 
 	results := []*pb.Crd{}
@@ -181,7 +184,7 @@ func (cs *coreServer) ListFluxCrds(ctx context.Context, msg *pb.ListFluxCrdsRequ
 		}
 	}
 
-	return &pb.ListFluxCrdsResponse{Crds: results, Errors: respErrors}, nil
+	return &pb.ListFluxCrdsResponse{Crds: results, Errors: respErrors, ClusterErrors: clusterErrors(failedLists)}, nil
 }
 
 func filterFluxNamespace(nss []v1.Namespace) []v1.Namespace {
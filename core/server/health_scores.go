@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"sort"
+
+	"github.com/weaveworks/weave-gitops/core/healthscore"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (cs *coreServer) GetHealthScores(ctx context.Context, msg *pb.GetHealthScoresRequest) (*pb.GetHealthScoresResponse, error) {
+	if cs.healthScores == nil {
+		return nil, status.Error(codes.FailedPrecondition, "health scores are not being computed")
+	}
+
+	resp := &pb.GetHealthScoresResponse{Scores: []*pb.ClusterHealthScore{}}
+
+	// scores count every object of the cluster, they're only shown to its
+	// users
+	visible := cs.userClusters(ctx)
+
+	for _, s := range cs.healthScores.Scores() {
+		if msg.ClusterName != "" && s.ClusterName != msg.ClusterName {
+			continue
+		}
+
+		if !visible[s.ClusterName] {
+			continue
+		}
+
+		resp.Scores = append(resp.Scores, healthScoreToProto(s))
+	}
+
+	if msg.ClusterName != "" && len(resp.Scores) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no health score for cluster %q", msg.ClusterName)
+	}
+
+	return resp, nil
+}
+
+func healthScoreToProto(s healthscore.Score) *pb.ClusterHealthScore {
+	objects := []*pb.HealthScoreCount{}
+
+	for kind, bySeverity := range s.Objects {
+		for severity, n := range bySeverity {
+			objects = append(objects, &pb.HealthScoreCount{Kind: kind, Severity: severity, Count: int32(n)})
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return objects[i].Kind < objects[j].Kind
+		}
+
+		return objects[i].Severity < objects[j].Severity
+	})
+
+	return &pb.ClusterHealthScore{
+		ClusterName: s.ClusterName,
+		Score:       s.Score,
+		Objects:     objects,
+		Timestamp:   formatTime(s.Time),
+	}
+}
@@ -0,0 +1,104 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/healthscore"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedauth "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetHealthScores(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: ns.Name},
+		Status: kustomizev1.KustomizationStatus{Conditions: []metav1.Condition{
+			{Type: "Ready", Status: metav1.ConditionFalse, Reason: "BuildFailed"},
+		}},
+	}
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(&ns, kustomization).Build()
+	cfg := makeServerConfig(client, t)
+	g.Expect(cfg.ClustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	cfg.HealthScores = healthscore.NewScorer(logr.Discard(), cfg.ClustersManager, healthscore.DefaultModel(), time.Minute)
+	cfg.HealthScores.Update(ctx)
+
+	c := makeServer(cfg, t)
+
+	res, err := c.GetHealthScores(ctx, &pb.GetHealthScoresRequest{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Scores).To(HaveLen(1))
+	g.Expect(res.Scores[0].ClusterName).To(Equal("Default"))
+	g.Expect(res.Scores[0].Score).To(BeZero())
+	g.Expect(res.Scores[0].Objects).To(HaveLen(1))
+	g.Expect(res.Scores[0].Objects[0].Kind).To(Equal(kustomizev1.KustomizationKind))
+	g.Expect(res.Scores[0].Objects[0].Severity).To(Equal(healthscore.Failed))
+	g.Expect(res.Scores[0].Objects[0].Count).To(Equal(int32(1)))
+	g.Expect(res.Scores[0].Timestamp).NotTo(BeEmpty())
+
+	_, err = c.GetHealthScores(ctx, &pb.GetHealthScoresRequest{ClusterName: "unknown"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+}
+
+func TestGetHealthScoresOfInaccessibleClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(&ns).Build()
+	cfg := makeServerConfig(client, t)
+	g.Expect(cfg.ClustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	// the user can't access any namespace of the cluster
+	nsChecker.FilterAccessibleNamespacesStub = func(ctx context.Context, _ typedauth.AuthorizationV1Interface, _ []corev1.Namespace) ([]corev1.Namespace, error) {
+		return nil, nil
+	}
+
+	cfg.HealthScores = healthscore.NewScorer(logr.Discard(), cfg.ClustersManager, healthscore.DefaultModel(), time.Minute)
+	cfg.HealthScores.Update(ctx)
+
+	c := makeServer(cfg, t)
+
+	res, err := c.GetHealthScores(ctx, &pb.GetHealthScoresRequest{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Scores).To(BeEmpty())
+
+	_, err = c.GetHealthScores(ctx, &pb.GetHealthScoresRequest{ClusterName: "Default"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+}
+
+func TestGetHealthScoresDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := makeServer(makeServerConfig(client, t), t)
+
+	_, err = c.GetHealthScores(context.Background(), &pb.GetHealthScoresRequest{})
+	g.Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+}
@@ -6,13 +6,15 @@ import (
 	"fmt"
 
 	"github.com/fluxcd/helm-controller/api/v2beta1"
-	"github.com/hashicorp/go-multierror"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr"
 	"github.com/weaveworks/weave-gitops/core/logger"
 	"github.com/weaveworks/weave-gitops/core/server/types"
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -41,22 +43,28 @@ func (cs *coreServer) ListObjects(ctx context.Context, msg *pb.ListObjectsReques
 		return nil, err
 	}
 
+	clusterSelector, err := parseClusterSelector(msg.ClusterLabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
 	var clustersClient clustersmngr.Client
 
 	if msg.ClusterName != "" {
 		clustersClient, err = cs.clustersManager.GetImpersonatedClientForCluster(ctx, auth.Principal(ctx), msg.ClusterName)
+		if err != nil {
+			return nil, clusterClientError(err)
+		}
 	} else {
-		clustersClient, err = cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+		clustersClient, err = cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx), clustersmngr.WithEagerClients(), clustersmngr.WithClusterSelector(clusterSelector))
 	}
 
-	if err != nil {
-		if merr, ok := err.(*multierror.Error); ok {
-			for _, err := range merr.Errors {
-				if cerr, ok := err.(*clustersmngr.ClientError); ok {
-					respErrors = append(respErrors, &pb.ListError{ClusterName: cerr.ClusterName, Message: cerr.Error()})
-				}
-			}
-		}
+	// clusters that fail are reported, the objects of the others are still
+	// returned
+	failedLists := clientListErrors(err)
+
+	for _, e := range failedLists {
+		respErrors = append(respErrors, &pb.ListError{ClusterName: e.Cluster, Message: e.Err.Error()})
 	}
 
 	clist := clustersmngr.NewClusteredList(func() client.ObjectList {
@@ -68,11 +76,32 @@ func (cs *coreServer) ListObjects(ctx context.Context, msg *pb.ListObjectsReques
 	listOptions := []client.ListOption{
 		client.InNamespace(msg.Namespace),
 	}
+	if len(msg.Namespaces) > 0 {
+		listOptions = append(listOptions, clustersmngr.InNamespaces(msg.Namespaces))
+	}
+
 	if len(msg.Labels) > 0 {
 		listOptions = append(listOptions, client.MatchingLabels(msg.Labels))
 	}
 
+	if msg.FieldSelector != "" {
+		selector, err := fields.ParseSelector(msg.FieldSelector)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid field selector: %s", err)
+		}
+
+		listOptions = append(listOptions, client.MatchingFieldsSelector{Selector: selector})
+	}
+
+	if msg.Pagination != nil {
+		listOptions = append(listOptions, client.Limit(msg.Pagination.PageSize), client.Continue(msg.Pagination.PageToken))
+	}
+
 	if err := clustersClient.ClusteredList(ctx, clist, true, listOptions...); err != nil {
+		if errors.As(err, &clustersmngr.InvalidContinueTokenError{}) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
 		var errs clustersmngr.ClusteredListError
 		if !errors.As(err, &errs) {
 			return nil, err
@@ -81,6 +110,8 @@ func (cs *coreServer) ListObjects(ctx context.Context, msg *pb.ListObjectsReques
 		for _, e := range errs.Errors {
 			respErrors = append(respErrors, &pb.ListError{ClusterName: e.Cluster, Namespace: e.Namespace, Message: e.Err.Error()})
 		}
+
+		failedLists = append(failedLists, errs.Errors...)
 	}
 
 	var results []*pb.Object
@@ -129,9 +160,16 @@ func (cs *coreServer) ListObjects(ctx context.Context, msg *pb.ListObjectsReques
 		}
 	}
 
+	nextToken := ""
+	if msg.Pagination != nil && clustersmngr.HasMorePages(clist.GetContinue()) {
+		nextToken = clist.GetContinue()
+	}
+
 	return &pb.ListObjectsResponse{
-		Objects: results,
-		Errors:  respErrors,
+		Objects:       results,
+		Errors:        respErrors,
+		NextToken:     nextToken,
+		ClusterErrors: clusterErrors(failedLists),
 	}, nil
 }
 
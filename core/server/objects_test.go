@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
@@ -15,10 +16,15 @@ import (
 	"github.com/weaveworks/weave-gitops/core/server/types"
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -759,4 +765,162 @@ func TestListObjectsLabels(t *testing.T) {
 	err = json.Unmarshal([]byte(res.Objects[0].Payload), &data)
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(data["metadata"].(map[string]interface{})["name"]).To(Equal(deployment1.Name))
+
+	res, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:        "Deployment",
+		ClusterName: "Default",
+		Pagination:  &pb.Pagination{PageSize: 10},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Objects).To(HaveLen(2))
+	g.Expect(res.NextToken).To(BeEmpty(), "everything fits in one page")
+}
+
+func TestListObjectsNamespacesAndFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	objects := []runtime.Object{}
+
+	for _, name := range []string{"ns-a", "ns-b", "ns-c"} {
+		objects = append(objects,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: name}},
+		)
+	}
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+
+	cfg := makeServerConfig(fakeClient, t)
+	c := makeServer(cfg, t)
+
+	res, err := c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:        "Deployment",
+		ClusterName: "Default",
+		Namespaces:  []string{"ns-a", "ns-c"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Objects).To(HaveLen(2))
+
+	namespaces := []string{}
+	for _, obj := range res.Objects {
+		namespaces = append(namespaces, objectNamespace(g, obj))
+	}
+
+	g.Expect(namespaces).To(ConsistOf("ns-a", "ns-c"))
+
+	res, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:        "Deployment",
+		ClusterName: "Default",
+		Namespace:   "ns-b",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Objects).To(HaveLen(1))
+	g.Expect(objectNamespace(g, res.Objects[0])).To(Equal("ns-b"))
+
+	_, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:          "Deployment",
+		ClusterName:   "Default",
+		FieldSelector: "metadata.name in (podinfo)",
+	})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	_, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:        "Deployment",
+		ClusterName: "Default",
+		Pagination:  &pb.Pagination{PageSize: 1, PageToken: "not a token"},
+	})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	// the management cluster has no labels
+	res, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:                 "Deployment",
+		ClusterLabelSelector: "env=prod",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Objects).To(BeEmpty())
+
+	res, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:                 "Deployment",
+		ClusterLabelSelector: "!env",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Objects).To(HaveLen(3))
+
+	_, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:                 "Deployment",
+		ClusterLabelSelector: "env in prod",
+	})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+}
+
+// unstructuredListErrorClient fails the lists ListObjects makes.
+type unstructuredListErrorClient struct {
+	client.Client
+}
+
+func (c unstructuredListErrorClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if _, ok := list.(*unstructured.UnstructuredList); ok {
+		return errors.New("connection refused")
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestListObjectsClusterErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	nsA := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}
+	nsB := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-b"}}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(nsA, nsB).Build()
+	cfg := makeServerConfig(unstructuredListErrorClient{fakeClient}, t)
+	c := makeServer(cfg, t)
+
+	res, err := c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind: kustomizev1.KustomizationKind,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Objects).To(BeEmpty())
+	g.Expect(res.Errors).To(HaveLen(2))
+	g.Expect(res.ClusterErrors).To(HaveLen(1))
+	g.Expect(res.ClusterErrors[0].ClusterName).To(Equal("Default"))
+	g.Expect(res.ClusterErrors[0].Namespaces).To(Equal([]string{"ns-a", "ns-b"}))
+	g.Expect(res.ClusterErrors[0].Message).To(Equal("connection refused"))
+}
+
+func TestListObjectsUnknownCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	cfg := makeServerConfig(fakeClient, t)
+	c := makeServer(cfg, t)
+
+	_, err = c.ListObjects(ctx, &pb.ListObjectsRequest{
+		Kind:        kustomizev1.KustomizationKind,
+		ClusterName: "unknown",
+	})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+	g.Expect(err.Error()).To(ContainSubstring("known clusters: Default"))
+}
+
+func objectNamespace(g *WithT, obj *pb.Object) string {
+	var data map[string]interface{}
+	g.Expect(json.Unmarshal([]byte(obj.Payload), &data)).To(Succeed())
+
+	return data["metadata"].(map[string]interface{})["namespace"].(string)
 }
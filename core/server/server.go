@@ -3,25 +3,39 @@ package server
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/go-logr/logr"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/healthscore"
 	"github.com/weaveworks/weave-gitops/core/nsaccess"
+	"github.com/weaveworks/weave-gitops/core/statushistory"
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"k8s.io/client-go/rest"
 )
 
 func Hydrate(ctx context.Context, mux *runtime.ServeMux, cfg CoreServerConfig) error {
-	appsServer, err := NewCoreServer(cfg)
-	if err != nil {
-		return fmt.Errorf("unable to create new kube client: %w", err)
-	}
+	appsServer := newCoreServer(cfg)
 
-	if err = pb.RegisterCoreHandlerServer(ctx, mux, appsServer); err != nil {
+	if err := pb.RegisterCoreHandlerServer(ctx, mux, appsServer); err != nil {
 		return fmt.Errorf("could not register new app server: %w", err)
 	}
 
+	// Artifacts are streamed as files, which doesn't fit the gateway.
+	if err := mux.HandlePath(http.MethodGet, ArtifactPath, appsServer.DownloadArtifact); err != nil {
+		return fmt.Errorf("could not register artifact download: %w", err)
+	}
+
+	if err := mux.HandlePath(http.MethodGet, SessionLogsArchivePath, appsServer.DownloadSessionLogs); err != nil {
+		return fmt.Errorf("could not register session logs download: %w", err)
+	}
+
+	// Registered after the gateway's handlers, which it takes precedence over.
+	if err := mux.HandlePath(http.MethodPost, WatchObjectsPath, appsServer.serveWatchObjects(mux)); err != nil {
+		return fmt.Errorf("could not register objects watch: %w", err)
+	}
+
 	return nil
 }
 
@@ -34,6 +48,11 @@ type coreServer struct {
 	nsChecker       nsaccess.Checker
 	clustersManager clustersmngr.ClustersManager
 	primaryKinds    *PrimaryKinds
+	statusHistory   *statushistory.Store
+	healthScores    *healthscore.Scorer
+	openAPISchemas  *openAPISchemas
+	// nil if clusters can't be added through the API
+	clusterRegistration *ClusterRegistration
 }
 
 type CoreServerConfig struct {
@@ -43,6 +62,15 @@ type CoreServerConfig struct {
 	NSAccess        nsaccess.Checker
 	ClustersManager clustersmngr.ClustersManager
 	PrimaryKinds    *PrimaryKinds
+	// StatusHistory is where object status snapshots are recorded, nil if
+	// recording is disabled
+	StatusHistory *statushistory.Store
+	// HealthScores computes the health score of each cluster, nil if
+	// scoring is disabled
+	HealthScores *healthscore.Scorer
+	// ClusterRegistration is where clusters added through the API are
+	// written, nil if registration is disabled
+	ClusterRegistration *ClusterRegistration
 }
 
 func NewCoreConfig(log logr.Logger, cfg *rest.Config, clusterName string, clustersManager clustersmngr.ClustersManager) (CoreServerConfig, error) {
@@ -62,10 +90,19 @@ func NewCoreConfig(log logr.Logger, cfg *rest.Config, clusterName string, cluste
 }
 
 func NewCoreServer(cfg CoreServerConfig) (pb.CoreServer, error) {
+	return newCoreServer(cfg), nil
+}
+
+func newCoreServer(cfg CoreServerConfig) *coreServer {
 	return &coreServer{
 		logger:          cfg.log,
 		nsChecker:       cfg.NSAccess,
 		clustersManager: cfg.ClustersManager,
 		primaryKinds:    cfg.PrimaryKinds,
-	}, nil
+		statusHistory:   cfg.StatusHistory,
+		healthScores:    cfg.HealthScores,
+		openAPISchemas:  newOpenAPISchemas(),
+
+		clusterRegistration: cfg.ClusterRegistration,
+	}
 }
@@ -0,0 +1,589 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/minio/minio-go/v7"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
+	"github.com/weaveworks/weave-gitops/pkg/s3"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Followed logs are read again every sessionLogsPollInterval until there are
+// new lines, for up to sessionLogsMaxWait.
+const (
+	sessionLogsPollInterval = time.Second
+	sessionLogsMaxWait      = 30 * time.Second
+)
+
+const defaultSessionLogsPageSize = 1000
+
+func (cs *coreServer) GetSessionLogs(ctx context.Context, msg *pb.GetSessionLogsRequest) (*pb.GetSessionLogsResponse, error) {
+	token, err := decodeSessionLogsToken(msg.Token)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting impersonating client: %w", err)
+	}
+
+	cli, err := clustersClient.Scoped(cluster.DefaultCluster)
+	if err != nil {
+		return nil, fmt.Errorf("getting cluster client: %w", err)
+	}
+
+	sessions, err := resolveSessions(ctx, cli, msg)
+	if err != nil {
+		var notFound *session.NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		return nil, err
+	}
+
+	filter, err := newSessionLogsFilter(msg.Level, msg.Sources)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	pageSize := int(msg.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultSessionLogsPageSize
+	}
+
+	// Sessions usually share a dev bucket server, so only connect once
+	minioClients := map[session.LogSource]*minio.Client{}
+
+	var hasMore bool
+
+	read := func() ([]*pb.LogEntry, error) {
+		respErrors := multierror.Error{}
+		perSession := map[string]sessionLogsRead{}
+
+		for _, s := range sessions {
+			id := s.SessionName
+			source := s.LogSource
+
+			// The run ID and bucket don't affect the connection
+			server := source
+			server.RunID, server.Bucket = "", ""
+
+			minioClient, ok := minioClients[server]
+			if !ok {
+				minioClient, err = devBucketClient(ctx, cli, source)
+				if err != nil {
+					respErrors = *multierror.Append(fmt.Errorf("connecting to the logs of session %q: %w", id, err), respErrors.Errors...)
+					continue
+				}
+
+				minioClients[server] = minioClient
+			}
+
+			read, err := readSessionLogs(ctx, minioClient, id, source, parseSessionLogsPosition(token[id]), filter, pageSize)
+			if err != nil {
+				respErrors = *multierror.Append(fmt.Errorf("reading logs of session %q: %w", id, err), respErrors.Errors...)
+				continue
+			}
+
+			perSession[id] = read
+		}
+
+		var logs []*pb.LogEntry
+
+		logs, hasMore = pageSessionLogs(perSession, token, pageSize)
+
+		return logs, respErrors.ErrorOrNil()
+	}
+
+	var logs []*pb.LogEntry
+
+	if msg.Follow {
+		logs, err = pollSessionLogs(ctx, sessionLogsPollInterval, sessionLogsMaxWait, read)
+	} else {
+		logs, err = read()
+	}
+
+	nextToken, tokenErr := encodeSessionLogsToken(token)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	resp := &pb.GetSessionLogsResponse{
+		Logs:      logs,
+		NextToken: nextToken,
+		HasMore:   hasMore,
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	return resp, nil
+}
+
+// pollSessionLogs reads the logs every interval until there are new lines or
+// reading fails, for up to wait or until ctx is done.
+func pollSessionLogs(ctx context.Context, interval, wait time.Duration, read func() ([]*pb.LogEntry, error)) ([]*pb.LogEntry, error) {
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		logs, err := read()
+		if len(logs) > 0 || err != nil {
+			return logs, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return logs, nil
+		case <-timeout.C:
+			return logs, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolveSessions returns the sessions requested either by ID or by label
+// selector, sorted by name and de-duplicated.
+func resolveSessions(ctx context.Context, cli client.Client, msg *pb.GetSessionLogsRequest) ([]*session.InternalSession, error) {
+	if len(msg.SessionIds) == 0 && msg.SessionSelector == "" {
+		return nil, fmt.Errorf("at least one session ID or a session selector is required")
+	}
+
+	found := map[string]*session.InternalSession{}
+
+	for _, id := range msg.SessionIds {
+		s, err := session.Get(cli, id, msg.SessionNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		found[id] = s
+	}
+
+	if msg.SessionSelector != "" {
+		selector, err := labels.Parse(msg.SessionSelector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing session selector: %w", err)
+		}
+
+		for k, v := range map[string]string{
+			"app":                       "vcluster",
+			"app.kubernetes.io/part-of": "gitops-run",
+		} {
+			req, err := labels.NewRequirement(k, selection.Equals, []string{v})
+			if err != nil {
+				return nil, err
+			}
+
+			selector = selector.Add(*req)
+		}
+
+		list := appsv1.StatefulSetList{}
+		if err := cli.List(ctx, &list, client.InNamespace(msg.SessionNamespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, fmt.Errorf("listing sessions: %w", err)
+		}
+
+		for _, item := range list.Items {
+			if found[item.Name] != nil {
+				continue
+			}
+
+			s, err := session.Get(cli, item.Name, item.Namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			found[item.Name] = s
+		}
+	}
+
+	result := make([]*session.InternalSession, 0, len(found))
+	for _, s := range found {
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SessionName < result[j].SessionName
+	})
+
+	return result, nil
+}
+
+// devBucketClient connects to the dev bucket server a session writes its
+// logs to, using the credentials and certificate recorded on the session.
+func devBucketClient(ctx context.Context, cli client.Client, source session.LogSource) (*minio.Client, error) {
+	credentials := corev1.Secret{}
+	if err := cli.Get(ctx, source.Credentials, &credentials); err != nil {
+		return nil, fmt.Errorf("getting dev bucket credentials: %w", err)
+	}
+
+	certs := corev1.Secret{}
+	if err := cli.Get(ctx, source.Certificate, &certs); err != nil {
+		return nil, fmt.Errorf("getting dev bucket certificate: %w", err)
+	}
+
+	svc := corev1.Service{}
+	if err := cli.Get(ctx, source.Server, &svc); err != nil {
+		return nil, fmt.Errorf("getting dev bucket service: %w", err)
+	}
+
+	var port int32
+
+	for _, p := range svc.Spec.Ports {
+		if p.Name == source.Server.Name+"-https" {
+			port = p.Port
+		}
+	}
+
+	if port == 0 {
+		return nil, fmt.Errorf("dev bucket service has no https port")
+	}
+
+	endpoint := fmt.Sprintf("%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, port)
+
+	return s3.NewMinioClient(endpoint, credentials.Data["accesskey"], credentials.Data["secretkey"], certs.Data["cert.pem"])
+}
+
+// sessionLogsPosition is where reading the logs of a session stopped: after
+// the object at key, or after its first lines if lines isn't 0.
+type sessionLogsPosition struct {
+	key   string
+	lines int
+}
+
+// parseSessionLogsPosition parses the position recorded in a token, either
+// an object key or, for a partially read object, the key and the number of
+// lines read separated by a #.
+func parseSessionLogsPosition(s string) sessionLogsPosition {
+	if i := strings.LastIndex(s, "#"); i >= 0 {
+		if lines, err := strconv.Atoi(s[i+1:]); err == nil && lines > 0 {
+			return sessionLogsPosition{key: s[:i], lines: lines}
+		}
+	}
+
+	return sessionLogsPosition{key: s}
+}
+
+func (p sessionLogsPosition) String() string {
+	if p.lines == 0 {
+		return p.key
+	}
+
+	return fmt.Sprintf("%s#%d", p.key, p.lines)
+}
+
+// sessionLogLine is a log entry along with the position right after it.
+type sessionLogLine struct {
+	entry *pb.LogEntry
+	after sessionLogsPosition
+}
+
+// sessionLogsRead is the lines read from a session, where reading stopped
+// and whether it stopped because the page was full.
+type sessionLogsRead struct {
+	lines []sessionLogLine
+	end   sessionLogsPosition
+	more  bool
+}
+
+// readSessionLogs returns up to limit log lines of a session written after
+// from that match filter.
+func readSessionLogs(ctx context.Context, minioClient *minio.Client, sessionID string, source session.LogSource, from sessionLogsPosition, filter sessionLogsFilter, limit int) (sessionLogsRead, error) {
+	result := sessionLogsRead{end: from}
+
+	// readObject reads the lines of an object after the first skip ones,
+	// and returns whether the page filled up before its end.
+	readObject := func(key string, skip int) (bool, error) {
+		obj, err := minioClient.GetObject(ctx, source.Bucket, key, minio.GetObjectOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		content, err := io.ReadAll(obj)
+		obj.Close()
+
+		if err != nil {
+			return false, err
+		}
+
+		entries := newLogEntries(sessionID, source.RunID, key, content)
+
+		for i := skip; i < len(entries); i++ {
+			if len(result.lines) >= limit {
+				result.end = sessionLogsPosition{key: key, lines: i}
+				return true, nil
+			}
+
+			after := sessionLogsPosition{key: key, lines: i + 1}
+			if i+1 == len(entries) {
+				after.lines = 0
+			}
+
+			if filter.matches(entries[i].Level, entries[i].Source) {
+				result.lines = append(result.lines, sessionLogLine{entry: entries[i], after: after})
+			}
+		}
+
+		result.end = sessionLogsPosition{key: key}
+
+		return false, nil
+	}
+
+	// the rest of an object read partially comes first
+	if from.lines > 0 {
+		full, err := readObject(from.key, from.lines)
+		if err != nil || full {
+			result.more = full
+			return result, err
+		}
+	}
+
+	// stop listing when returning early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := minioClient.ListObjects(ctx, source.Bucket, minio.ListObjectsOptions{
+		Prefix:     source.RunID + "/",
+		StartAfter: from.key,
+	})
+
+	for info := range objects {
+		if info.Err != nil {
+			return sessionLogsRead{}, info.Err
+		}
+
+		if len(result.lines) >= limit {
+			result.more = true
+			return result, nil
+		}
+
+		full, err := readObject(info.Key, 0)
+		if err != nil {
+			return sessionLogsRead{}, err
+		}
+
+		if full {
+			result.more = true
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// newLogEntries builds the log entries of an object written by the S3 log
+// sink, holding a LogEntry per line. Older sessions wrote a plain text
+// line per object, named after the time it was logged, which are info lines
+// from the CLI.
+func newLogEntries(sessionID, runID, key string, content []byte) []*pb.LogEntry {
+	sortingKey := strings.TrimSuffix(strings.TrimPrefix(key, runID+"/"), path.Ext(key))
+
+	if path.Ext(key) != logger.S3LogObjectSuffix {
+		entry := &pb.LogEntry{
+			SessionId:  sessionID,
+			Message:    strings.TrimSuffix(string(content), "\n"),
+			SortingKey: sortingKey,
+			Level:      logger.LogLevelInfo,
+			Source:     logger.LogSourceCLI,
+		}
+
+		if t, err := time.ParseInLocation(logger.S3LogTimestampFormat, sortingKey, time.Local); err == nil {
+			entry.Timestamp = t.Format(time.RFC3339Nano)
+		}
+
+		return []*pb.LogEntry{entry}
+	}
+
+	var entries []*pb.LogEntry
+
+	for i, line := range bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n")) {
+		logged := logger.LogEntry{}
+		if err := json.Unmarshal(line, &logged); err != nil {
+			continue
+		}
+
+		entries = append(entries, &pb.LogEntry{
+			Timestamp: logged.Timestamp.Format(time.RFC3339Nano),
+			SessionId: sessionID,
+			Message:   logged.Message,
+			// lines are sorted by time, then in the order they were put
+			SortingKey: fmt.Sprintf("%s-%s-%05d", logged.Timestamp.UTC().Format(logger.S3LogTimestampFormat), sortingKey, i),
+			Level:      logged.Level,
+			Source:     logged.Source,
+		})
+	}
+
+	return entries
+}
+
+var sessionLogLevels = map[string]int{
+	logger.LogLevelInfo:    0,
+	logger.LogLevelWarning: 1,
+	logger.LogLevelError:   2,
+}
+
+var sessionLogSources = map[string]bool{
+	logger.LogSourceCLI:           true,
+	logger.LogSourceFlux:          true,
+	logger.LogSourceKustomization: true,
+}
+
+// sessionLogsFilter selects the lines at least as severe as a level and
+// logged by some sources.
+type sessionLogsFilter struct {
+	minLevel int
+	sources  map[string]bool
+}
+
+// newSessionLogsFilter returns the filter of a request. An empty level or
+// list of sources selects all lines.
+func newSessionLogsFilter(level string, sources []string) (sessionLogsFilter, error) {
+	filter := sessionLogsFilter{}
+
+	if level != "" {
+		minLevel, ok := sessionLogLevels[level]
+		if !ok {
+			return filter, fmt.Errorf("unknown log level %q", level)
+		}
+
+		filter.minLevel = minLevel
+	}
+
+	for _, source := range sources {
+		if !sessionLogSources[source] {
+			return filter, fmt.Errorf("unknown log source %q", source)
+		}
+
+		if filter.sources == nil {
+			filter.sources = map[string]bool{}
+		}
+
+		filter.sources[source] = true
+	}
+
+	return filter, nil
+}
+
+func (f sessionLogsFilter) matches(level, source string) bool {
+	if f.sources != nil && !f.sources[source] {
+		return false
+	}
+
+	// unknown levels are only filtered out along with info lines
+	return sessionLogLevels[level] >= f.minLevel
+}
+
+// pageSessionLogs interleaves the lines read from several sessions in
+// timestamp order, up to pageSize of them, and moves the token of each
+// session past the lines returned. Lines with the same timestamp are ordered
+// by session ID.
+func pageSessionLogs(perSession map[string]sessionLogsRead, token map[string]string, pageSize int) ([]*pb.LogEntry, bool) {
+	lines := []sessionLogLine{}
+	hasMore := false
+
+	for _, read := range perSession {
+		lines = append(lines, read.lines...)
+		hasMore = hasMore || read.more
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].entry.SortingKey != lines[j].entry.SortingKey {
+			return lines[i].entry.SortingKey < lines[j].entry.SortingKey
+		}
+
+		return lines[i].entry.SessionId < lines[j].entry.SessionId
+	})
+
+	// the sessions whose lines didn't all fit are read again after the last
+	// line returned
+	truncated := map[string]bool{}
+
+	if len(lines) > pageSize {
+		for _, line := range lines[pageSize:] {
+			truncated[line.entry.SessionId] = true
+		}
+
+		lines = lines[:pageSize]
+		hasMore = true
+	}
+
+	for id, read := range perSession {
+		if !truncated[id] && read.end.key != "" {
+			token[id] = read.end.String()
+		}
+	}
+
+	result := make([]*pb.LogEntry, 0, len(lines))
+
+	for _, line := range lines {
+		if truncated[line.entry.SessionId] {
+			token[line.entry.SessionId] = line.after.String()
+		}
+
+		result = append(result, line.entry)
+	}
+
+	return result, hasMore
+}
+
+// The pagination token records the last object read for each session so
+// the next request only returns newer lines.
+func decodeSessionLogsToken(token string) (map[string]string, error) {
+	result := map[string]string{}
+	if token == "" {
+		return result, nil
+	}
+
+	data, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return result, nil
+}
+
+func encodeSessionLogsToken(token map[string]string) (string, error) {
+	if len(token) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+// SessionLogsArchivePath is where all the logs of a GitOps Run session are
+// downloaded from, as a tar.gz archive of the objects it wrote. It takes the
+// namespace of the session as the sessionNamespace query parameter.
+const SessionLogsArchivePath = "/v1/session_logs/{sessionId}/archive"
+
+// DownloadSessionLogs streams the logs of a session from its dev bucket,
+// e.g. to attach them to a support ticket.
+func (cs *coreServer) DownloadSessionLogs(rw http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	ctx := r.Context()
+	sessionID := pathParams["sessionId"]
+
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("error getting impersonating client: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	cli, err := clustersClient.Scoped(cluster.DefaultCluster)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("getting cluster client: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := session.Get(cli, sessionID, r.URL.Query().Get("sessionNamespace"))
+	if err != nil {
+		var notFound *session.NotFoundError
+		if errors.As(err, &notFound) {
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		http.Error(rw, err.Error(), statusFromAPIError(err))
+
+		return
+	}
+
+	minioClient, err := devBucketClient(ctx, cli, s.LogSource)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("connecting to the logs of session %q: %v", sessionID, err), http.StatusBadGateway)
+		return
+	}
+
+	// list the objects first, so failing to is reported with the status
+	objects, err := listSessionLogObjects(ctx, minioClient, s.LogSource)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("listing logs of session %q: %v", sessionID, err), http.StatusBadGateway)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/gzip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sessionID+"-logs.tar.gz"))
+
+	if err := writeSessionLogsArchive(ctx, rw, minioClient, sessionID, s.LogSource, objects); err != nil {
+		cs.logger.Error(err, "failed to stream session logs", "session", sessionID)
+	}
+}
+
+func listSessionLogObjects(ctx context.Context, minioClient *minio.Client, source session.LogSource) ([]minio.ObjectInfo, error) {
+	var objects []minio.ObjectInfo
+
+	for info := range minioClient.ListObjects(ctx, source.Bucket, minio.ListObjectsOptions{
+		Prefix: source.RunID + "/",
+	}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+
+		objects = append(objects, info)
+	}
+
+	return objects, nil
+}
+
+// writeSessionLogsArchive writes the log objects of a session to w as a
+// tar.gz archive, in a directory named after the session.
+func writeSessionLogsArchive(ctx context.Context, w io.Writer, minioClient *minio.Client, sessionID string, source session.LogSource, objects []minio.ObjectInfo) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, info := range objects {
+		obj, err := minioClient.GetObject(ctx, source.Bucket, info.Key, minio.GetObjectOptions{})
+		if err != nil {
+			return err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Name:    path.Join(sessionID, strings.TrimPrefix(info.Key, source.RunID+"/")),
+			Mode:    0o644,
+			Size:    info.Size,
+			ModTime: info.LastModified,
+		})
+		if err == nil {
+			_, err = io.CopyN(tw, obj, info.Size)
+		}
+
+		obj.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
+)
+
+func TestWriteSessionLogsArchive(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	minioClient := newFakeDevBucket(t, g)
+
+	source := session.LogSource{RunID: "run-1", Bucket: "gitops-run-logs"}
+
+	for key, content := range map[string]string{
+		"run-1/20221010-101010.00000.json": `{"message":"one"}` + "\n",
+		"run-1/20221010-101011.00000.json": `{"message":"two"}` + "\n",
+		"run-2/20221010-101012.00000.json": `{"message":"other run"}` + "\n",
+	} {
+		_, err := minioClient.PutObject(ctx, source.Bucket, key, strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	objects, err := listSessionLogObjects(ctx, minioClient, source)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(objects).To(HaveLen(2))
+
+	var archive bytes.Buffer
+	g.Expect(writeSessionLogsArchive(ctx, &archive, minioClient, "session-a", source, objects)).To(Succeed())
+
+	gz, err := gzip.NewReader(&archive)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		g.Expect(err).NotTo(HaveOccurred())
+
+		content, err := io.ReadAll(tr)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		files[hdr.Name] = string(content)
+	}
+
+	g.Expect(files).To(Equal(map[string]string{
+		"session-a/20221010-101010.00000.json": `{"message":"one"}` + "\n",
+		"session-a/20221010-101011.00000.json": `{"message":"two"}` + "\n",
+	}))
+}
+
+// newFakeDevBucket returns a client of an in-memory dev bucket server.
+func newFakeDevBucket(t *testing.T, g *WithT) *minio.Client {
+	srv := httptest.NewServer(gofakes3.New(s3mem.New(), gofakes3.WithAutoBucket(true)).Server())
+	t.Cleanup(srv.Close)
+
+	minioClient, err := minio.New(strings.TrimPrefix(srv.URL, "http://"), &minio.Options{
+		Creds: credentials.NewStaticV4("access", "secret", ""),
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return minioClient
+}
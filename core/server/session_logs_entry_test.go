@@ -0,0 +1,44 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewLogEntries(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("parses the JSON lines of a chunk", func(t *testing.T) {
+		entries := newLogEntries("session-a", "run-1", "run-1/20221010-101010.12345.json", []byte(
+			`{"timestamp":"2022-10-10T10:10:10.12345Z","level":"info","source":"flux","message":"reconciling"}`+"\n"+
+				`{"timestamp":"2022-10-10T10:10:11Z","level":"error","source":"kustomization","message":"dry-run failed"}`+"\n"))
+
+		g.Expect(entries).To(HaveLen(2))
+
+		g.Expect(entries[0].SessionId).To(Equal("session-a"))
+		g.Expect(entries[0].SortingKey).To(Equal("20221010-101010.12345-20221010-101010.12345-00000"))
+		g.Expect(entries[0].Timestamp).To(Equal("2022-10-10T10:10:10.12345Z"))
+		g.Expect(entries[0].Message).To(Equal("reconciling"))
+
+		g.Expect(entries[1].SortingKey).To(Equal("20221010-101011.00000-20221010-101010.12345-00001"))
+		g.Expect(entries[1].Level).To(Equal("error"))
+		g.Expect(entries[1].Source).To(Equal("kustomization"))
+		g.Expect(entries[1].Message).To(Equal("dry-run failed"))
+	})
+
+	t.Run("keeps text lines of older sessions", func(t *testing.T) {
+		entries := newLogEntries("session-a", "run-1", "run-1/20221010-101010.12345.txt", []byte("► Reconciling\n"))
+
+		g.Expect(entries).To(HaveLen(1))
+		g.Expect(entries[0].SortingKey).To(Equal("20221010-101010.12345"))
+		g.Expect(entries[0].Message).To(Equal("► Reconciling"))
+		g.Expect(entries[0].Level).To(Equal("info"))
+		g.Expect(entries[0].Source).To(Equal("cli"))
+
+		ts, err := time.Parse(time.RFC3339Nano, entries[0].Timestamp)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ts.Second()).To(Equal(10))
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSessionLogsFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("selects all lines by default", func(t *testing.T) {
+		filter, err := newSessionLogsFilter("", nil)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(filter.matches("info", "cli")).To(BeTrue())
+		g.Expect(filter.matches("error", "kustomization")).To(BeTrue())
+	})
+
+	t.Run("selects lines at least as severe as the level", func(t *testing.T) {
+		filter, err := newSessionLogsFilter("warning", nil)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(filter.matches("info", "cli")).To(BeFalse())
+		g.Expect(filter.matches("warning", "cli")).To(BeTrue())
+		g.Expect(filter.matches("error", "flux")).To(BeTrue())
+	})
+
+	t.Run("selects lines from the sources", func(t *testing.T) {
+		filter, err := newSessionLogsFilter("error", []string{"flux", "kustomization"})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(filter.matches("error", "cli")).To(BeFalse())
+		g.Expect(filter.matches("error", "kustomization")).To(BeTrue())
+		g.Expect(filter.matches("info", "flux")).To(BeFalse())
+	})
+
+	t.Run("rejects unknown levels and sources", func(t *testing.T) {
+		_, err := newSessionLogsFilter("debug", nil)
+		g.Expect(err).To(MatchError(`unknown log level "debug"`))
+
+		_, err = newSessionLogsFilter("", []string{"helm"})
+		g.Expect(err).To(MatchError(`unknown log source "helm"`))
+	})
+}
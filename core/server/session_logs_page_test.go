@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
+)
+
+func TestSessionLogsPages(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	minioClient := newFakeDevBucket(t, g)
+
+	line := func(second, level, message string) string {
+		return `{"timestamp":"2022-10-10T10:10:` + second + `Z","level":"` + level + `","source":"cli","message":"` + message + `"}` + "\n"
+	}
+
+	for key, content := range map[string]string{
+		"run-a/20221010-101010.00000.json": line("10", "info", "a1") + line("12", "error", "a2") + line("14", "info", "a3"),
+		"run-a/20221010-101020.00000.json": line("20", "info", "a4"),
+		"run-b/20221010-101011.00000.json": line("11", "info", "b1") + line("13", "error", "b2"),
+	} {
+		_, err := minioClient.PutObject(ctx, "gitops-run-logs", key, strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	sources := map[string]session.LogSource{
+		"session-a": {RunID: "run-a", Bucket: "gitops-run-logs"},
+		"session-b": {RunID: "run-b", Bucket: "gitops-run-logs"},
+	}
+
+	// readPage reads a page the way GetSessionLogs does
+	readPage := func(token map[string]string, filter sessionLogsFilter, pageSize int) ([]string, bool) {
+		perSession := map[string]sessionLogsRead{}
+
+		for id, source := range sources {
+			read, err := readSessionLogs(ctx, minioClient, id, source, parseSessionLogsPosition(token[id]), filter, pageSize)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			perSession[id] = read
+		}
+
+		logs, hasMore := pageSessionLogs(perSession, token, pageSize)
+
+		messages := []string{}
+		for _, entry := range logs {
+			messages = append(messages, entry.Message)
+		}
+
+		return messages, hasMore
+	}
+
+	t.Run("returns each line once, in order", func(t *testing.T) {
+		token := map[string]string{}
+
+		messages, hasMore := readPage(token, sessionLogsFilter{}, 2)
+		g.Expect(messages).To(Equal([]string{"a1", "b1"}))
+		g.Expect(hasMore).To(BeTrue())
+		g.Expect(token).To(HaveKeyWithValue("session-a", "run-a/20221010-101010.00000.json#1"))
+
+		messages, hasMore = readPage(token, sessionLogsFilter{}, 2)
+		g.Expect(messages).To(Equal([]string{"a2", "b2"}))
+		g.Expect(hasMore).To(BeTrue())
+
+		messages, hasMore = readPage(token, sessionLogsFilter{}, 2)
+		g.Expect(messages).To(Equal([]string{"a3", "a4"}))
+		g.Expect(hasMore).To(BeFalse())
+
+		messages, hasMore = readPage(token, sessionLogsFilter{}, 2)
+		g.Expect(messages).To(BeEmpty())
+		g.Expect(hasMore).To(BeFalse())
+	})
+
+	t.Run("fills pages with the lines matching the filter", func(t *testing.T) {
+		token := map[string]string{}
+		errorsOnly := sessionLogsFilter{minLevel: sessionLogLevels["error"]}
+
+		messages, hasMore := readPage(token, errorsOnly, 1)
+		g.Expect(messages).To(Equal([]string{"a2"}))
+		g.Expect(hasMore).To(BeTrue())
+
+		messages, _ = readPage(token, errorsOnly, 1)
+		g.Expect(messages).To(Equal([]string{"b2"}))
+
+		messages, hasMore = readPage(token, errorsOnly, 1)
+		g.Expect(messages).To(BeEmpty())
+		g.Expect(hasMore).To(BeFalse())
+		g.Expect(token).To(Equal(map[string]string{
+			"session-a": "run-a/20221010-101020.00000.json",
+			"session-b": "run-b/20221010-101011.00000.json",
+		}))
+	})
+}
+
+func TestParseSessionLogsPosition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(parseSessionLogsPosition("run-a/20221010-101010.00000.json#3")).To(Equal(sessionLogsPosition{key: "run-a/20221010-101010.00000.json", lines: 3}))
+	g.Expect(parseSessionLogsPosition("run-a/20221010-101010.00000.txt")).To(Equal(sessionLogsPosition{key: "run-a/20221010-101010.00000.txt"}))
+	g.Expect(parseSessionLogsPosition("run#a/20221010-101010.00000.json")).To(Equal(sessionLogsPosition{key: "run#a/20221010-101010.00000.json"}))
+	g.Expect(sessionLogsPosition{key: "run-a/1.json", lines: 3}.String()).To(Equal("run-a/1.json#3"))
+}
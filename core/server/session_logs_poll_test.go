@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+)
+
+func TestPollSessionLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	t.Run("returns once new lines are written", func(t *testing.T) {
+		reads := 0
+
+		logs, err := pollSessionLogs(ctx, time.Millisecond, time.Minute, func() ([]*pb.LogEntry, error) {
+			reads++
+			if reads < 3 {
+				return nil, nil
+			}
+
+			return []*pb.LogEntry{{Message: "reconciled"}}, nil
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(logs).To(HaveLen(1))
+		g.Expect(reads).To(Equal(3))
+	})
+
+	t.Run("returns errors right away", func(t *testing.T) {
+		reads := 0
+
+		_, err := pollSessionLogs(ctx, time.Millisecond, time.Minute, func() ([]*pb.LogEntry, error) {
+			reads++
+			return nil, errors.New("bucket not found")
+		})
+		g.Expect(err).To(MatchError("bucket not found"))
+		g.Expect(reads).To(Equal(1))
+	})
+
+	t.Run("returns no lines once the wait is over", func(t *testing.T) {
+		logs, err := pollSessionLogs(ctx, time.Millisecond, 20*time.Millisecond, func() ([]*pb.LogEntry, error) {
+			return nil, nil
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(logs).To(BeEmpty())
+	})
+
+	t.Run("stops when the request is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		logs, err := pollSessionLogs(ctx, time.Millisecond, time.Minute, func() ([]*pb.LogEntry, error) {
+			return nil, nil
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(logs).To(BeEmpty())
+	})
+}
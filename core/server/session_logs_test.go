@@ -0,0 +1,60 @@
+package server_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	api "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetSessionLogs_RequiresSessions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	c, _ := makeGRPCServer(k8sEnv.Rest, t)
+
+	_, err := c.GetSessionLogs(ctx, &api.GetSessionLogsRequest{
+		SessionNamespace: "default",
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("session ID or a session selector is required"))
+}
+
+func TestGetSessionLogs_InvalidSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	c, _ := makeGRPCServer(k8sEnv.Rest, t)
+
+	_, err := c.GetSessionLogs(ctx, &api.GetSessionLogsRequest{
+		SessionNamespace: "default",
+		SessionSelector:  "app in (",
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("parsing session selector"))
+}
+
+func TestGetSessionLogs_InvalidToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	c := makeServer(makeServerConfig(fake.NewClientBuilder().Build(), t), t)
+
+	for _, token := range []string{"not base64!", base64.URLEncoding.EncodeToString([]byte("not json"))} {
+		_, err := c.GetSessionLogs(ctx, &api.GetSessionLogsRequest{
+			SessionNamespace: "default",
+			SessionIds:       []string{"run-1"},
+			Token:            token,
+		})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument), token)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (cs *coreServer) ListSessions(ctx context.Context, msg *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	c, err := cs.managementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := session.List(c, msg.Namespace)
+	if err != nil {
+		return nil, sessionError(err, "listing sessions")
+	}
+
+	resp := &pb.ListSessionsResponse{Sessions: []*pb.Session{}}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, sessionToProto(s))
+	}
+
+	return resp, nil
+}
+
+func (cs *coreServer) GetSession(ctx context.Context, msg *pb.GetSessionRequest) (*pb.GetSessionResponse, error) {
+	c, err := cs.managementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := session.Get(c, msg.Name, msg.Namespace)
+	if err != nil {
+		return nil, sessionError(err, "getting session")
+	}
+
+	return &pb.GetSessionResponse{Session: sessionToProto(s)}, nil
+}
+
+func (cs *coreServer) DeleteSession(ctx context.Context, msg *pb.DeleteSessionRequest) (*pb.DeleteSessionResponse, error) {
+	c, err := cs.managementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := session.Get(c, msg.Name, msg.Namespace)
+	if err != nil {
+		return nil, sessionError(err, "getting session")
+	}
+
+	if err := session.Terminate(c, s); err != nil {
+		return nil, sessionError(err, "terminating session")
+	}
+
+	cs.logger.Info("Session terminated", "user", auth.Principal(ctx).ID, "namespace", s.SessionNamespace, "name", s.SessionName)
+
+	// uninstalling the vcluster takes a while, so don't hold up the request
+	go func() {
+		if err := session.WaitForRemoval(c, s); err != nil {
+			cs.logger.Error(err, "failed to clean up session", "namespace", s.SessionNamespace, "name", s.SessionName)
+		}
+	}()
+
+	return &pb.DeleteSessionResponse{}, nil
+}
+
+func sessionToProto(s *session.InternalSession) *pb.Session {
+	return &pb.Session{
+		Name:            s.SessionName,
+		Namespace:       s.SessionNamespace,
+		Command:         s.Command,
+		CliVersion:      s.CliVersion,
+		PortForward:     s.PortForward,
+		TargetNamespace: s.Namespace,
+	}
+}
+
+func sessionError(err error, msg string) error {
+	var notFound *session.NotFoundError
+
+	switch {
+	case errors.As(err, &notFound):
+		return status.Error(codes.NotFound, err.Error())
+	case apierrors.IsNotFound(err):
+		return status.Errorf(codes.NotFound, "%s: %s", msg, err)
+	case apierrors.IsForbidden(err):
+		return status.Errorf(codes.PermissionDenied, "%s: %s", msg, err)
+	default:
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+}
@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	. "github.com/onsi/gomega"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSessions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	newSession := func(name, namespace string) []client.Object {
+		return []client.Object{
+			&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"app":                       "vcluster",
+						"app.kubernetes.io/part-of": "gitops-run",
+					},
+					Annotations: map[string]string{
+						"run.weave.works/command":      "gitops beta run ./deploy",
+						"run.weave.works/cli-version":  "v0.12.0",
+						"run.weave.works/port-forward": "port=8080:80",
+						"run.weave.works/namespace":    "flux-system",
+					},
+				},
+			},
+			&helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}},
+		}
+	}
+
+	objects := append(newSession("run-a", "default"), newSession("run-b", "dev")...)
+	objects = append(objects, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}})
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	c := makeServer(makeServerConfig(k, t), t)
+
+	t.Run("lists sessions in all namespaces", func(t *testing.T) {
+		res, err := c.ListSessions(ctx, &pb.ListSessionsRequest{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(res.Sessions).To(HaveLen(2))
+	})
+
+	t.Run("lists sessions in a namespace", func(t *testing.T) {
+		res, err := c.ListSessions(ctx, &pb.ListSessionsRequest{Namespace: "dev"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(res.Sessions).To(HaveLen(1))
+		g.Expect(res.Sessions[0].Name).To(Equal("run-b"))
+	})
+
+	t.Run("gets a session", func(t *testing.T) {
+		res, err := c.GetSession(ctx, &pb.GetSessionRequest{Name: "run-a", Namespace: "default"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(res.Session.Command).To(Equal("gitops beta run ./deploy"))
+		g.Expect(res.Session.CliVersion).To(Equal("v0.12.0"))
+		g.Expect(res.Session.PortForward).To(Equal([]string{"port=8080:80"}))
+		g.Expect(res.Session.TargetNamespace).To(Equal("flux-system"))
+
+		_, err = c.GetSession(ctx, &pb.GetSessionRequest{Name: "missing", Namespace: "default"})
+		g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	t.Run("deletes a session", func(t *testing.T) {
+		_, err := c.DeleteSession(ctx, &pb.DeleteSessionRequest{Name: "unrelated", Namespace: "default"})
+		g.Expect(err).To(HaveOccurred())
+
+		_, err = c.DeleteSession(ctx, &pb.DeleteSessionRequest{Name: "run-a", Namespace: "default"})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		err = k.Get(ctx, client.ObjectKey{Name: "run-a", Namespace: "default"}, &helmv2.HelmRelease{})
+		g.Expect(err).To(HaveOccurred())
+
+		_, err = c.DeleteSession(ctx, &pb.DeleteSessionRequest{Name: "missing", Namespace: "default"})
+		g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/statushistory"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (cs *coreServer) GetObjectStatusHistory(ctx context.Context, msg *pb.GetObjectStatusHistoryRequest) (*pb.GetObjectStatusHistoryResponse, error) {
+	if cs.statusHistory == nil {
+		return nil, status.Error(codes.FailedPrecondition, "status history is not being recorded")
+	}
+
+	at := time.Now()
+
+	if msg.Timestamp != "" {
+		var err error
+
+		at, err = time.Parse(time.RFC3339, msg.Timestamp)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid timestamp %q: %v", msg.Timestamp, err)
+		}
+	}
+
+	gvk, err := cs.primaryKinds.Lookup(msg.Kind)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	clusterName := msg.ClusterName
+	if clusterName == "" {
+		clusterName = cluster.DefaultCluster
+	}
+
+	// The history is read with the server's permissions, so check the user
+	// can see the object itself.
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting impersonating client: %w", err)
+	}
+
+	obj := unstructured.Unstructured{}
+	obj.SetGroupVersionKind(*gvk)
+
+	if err := clustersClient.Get(ctx, clusterName, client.ObjectKey{Name: msg.Name, Namespace: msg.Namespace}, &obj); err != nil {
+		return nil, err
+	}
+
+	history, err := cs.statusHistory.History(ctx, statushistory.ObjectRef{
+		ClusterName: clusterName,
+		Kind:        gvk.Kind,
+		Namespace:   msg.Namespace,
+		Name:        msg.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	current, window := statushistory.At(history, at, int(msg.Around))
+
+	resp := &pb.GetObjectStatusHistoryResponse{
+		Snapshots: []*pb.StatusSnapshot{},
+	}
+
+	if current != nil {
+		resp.Snapshot = snapshotToProto(*current)
+	}
+
+	for _, s := range window {
+		resp.Snapshots = append(resp.Snapshots, snapshotToProto(s))
+	}
+
+	return resp, nil
+}
+
+func snapshotToProto(s statushistory.Snapshot) *pb.StatusSnapshot {
+	snap := &pb.StatusSnapshot{
+		Timestamp:          s.Time.Format(time.RFC3339),
+		Revision:           s.Revision,
+		ObservedGeneration: s.ObservedGeneration,
+		Suspended:          s.Suspended,
+		Conditions:         []*pb.Condition{},
+	}
+
+	for _, c := range s.Conditions {
+		snap.Conditions = append(snap.Conditions, conditionToProto(c.Type, c.Status, c.Reason, c.Message, c.LastTransitionTime))
+	}
+
+	return snap
+}
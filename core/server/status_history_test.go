@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/statushistory"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetObjectStatusHistory(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: ns.Name},
+	}
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(&ns, kustomization).Build()
+	cfg := makeServerConfig(client, t)
+	cfg.StatusHistory = statushistory.NewStore(client, "flux-system", 0)
+
+	ref := statushistory.ObjectRef{ClusterName: cluster.DefaultCluster, Kind: kustomizev1.KustomizationKind, Namespace: ns.Name, Name: "podinfo"}
+	base := time.Date(2022, 11, 10, 14, 0, 0, 0, time.UTC)
+
+	for i, rev := range []string{"main/a", "main/b", "main/c"} {
+		g.Expect(cfg.StatusHistory.Record(ctx, ref, statushistory.Snapshot{
+			Time:     base.Add(time.Duration(i) * 10 * time.Minute),
+			Revision: rev,
+			Conditions: []statushistory.Condition{
+				{Type: "Ready", Status: "True", Reason: "ReconciliationSucceeded"},
+			},
+		})).To(Succeed())
+	}
+
+	c := makeServer(cfg, t)
+
+	res, err := c.GetObjectStatusHistory(ctx, &pb.GetObjectStatusHistoryRequest{
+		Kind:        kustomizev1.KustomizationKind,
+		Name:        "podinfo",
+		Namespace:   ns.Name,
+		ClusterName: cluster.DefaultCluster,
+		Timestamp:   "2022-11-10T14:05:00Z",
+		Around:      1,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Snapshot.Revision).To(Equal("main/a"))
+	g.Expect(res.Snapshot.Timestamp).To(Equal("2022-11-10T14:00:00Z"))
+	g.Expect(res.Snapshot.Conditions[0].Reason).To(Equal("ReconciliationSucceeded"))
+	g.Expect(res.Snapshots).To(HaveLen(2))
+	g.Expect(res.Snapshots[1].Revision).To(Equal("main/b"))
+
+	_, err = c.GetObjectStatusHistory(ctx, &pb.GetObjectStatusHistoryRequest{
+		Kind:        kustomizev1.KustomizationKind,
+		Name:        "podinfo",
+		Namespace:   ns.Name,
+		ClusterName: cluster.DefaultCluster,
+		Timestamp:   "five past two",
+	})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+}
+
+func TestGetObjectStatusHistoryNotRecorded(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := makeServer(makeServerConfig(client, t), t)
+
+	_, err = c.GetObjectStatusHistory(context.Background(), &pb.GetObjectStatusHistoryRequest{
+		Kind:      kustomizev1.KustomizationKind,
+		Name:      "podinfo",
+		Namespace: "apps",
+	})
+	g.Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+}
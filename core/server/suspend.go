@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/weaveworks/weave-gitops/core/fluxsync"
@@ -12,11 +13,33 @@ import (
 )
 
 func (cs *coreServer) ToggleSuspendResource(ctx context.Context, msg *pb.ToggleSuspendResourceRequest) (*pb.ToggleSuspendResourceResponse, error) {
+	return &pb.ToggleSuspendResourceResponse{}, cs.setSuspended(ctx, msg.Objects, msg.Suspend, "")
+}
+
+func (cs *coreServer) SuspendResource(ctx context.Context, msg *pb.SuspendResourceRequest) (*pb.SuspendResourceResponse, error) {
+	return &pb.SuspendResourceResponse{}, cs.setSuspended(ctx, msg.Objects, true, msg.Comment)
+}
+
+func (cs *coreServer) ResumeResource(ctx context.Context, msg *pb.ResumeResourceRequest) (*pb.ResumeResourceResponse, error) {
+	return &pb.ResumeResourceResponse{}, cs.setSuspended(ctx, msg.Objects, false, msg.Comment)
+}
+
+// setSuspended suspends or resumes the objects with the client impersonating
+// the user, recording the request in their annotations. It goes on when some
+// objects can't be patched, returning all the errors.
+func (cs *coreServer) setSuspended(ctx context.Context, objects []*pb.ObjectRef, suspend bool, comment string) error {
 	principal := auth.Principal(ctx)
 	respErrors := multierror.Error{}
 
-	for _, obj := range msg.Objects {
-		clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, auth.Principal(ctx))
+	trigger := reconcileTrigger(ctx, principal)
+	request := fluxsync.SuspendRequest{
+		RequestedBy: trigger.RequestedBy,
+		Origin:      trigger.Origin,
+		Comment:     comment,
+	}
+
+	for _, obj := range objects {
+		clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, principal)
 		if err != nil {
 			respErrors = *multierror.Append(fmt.Errorf("error getting impersonating client: %w", err), respErrors.Errors...)
 			continue
@@ -53,9 +76,10 @@ func (cs *coreServer) ToggleSuspendResource(ctx context.Context, msg *pb.ToggleS
 
 		patch := client.MergeFrom(obj.DeepCopyClientObject())
 
-		obj.SetSuspended(msg.Suspend)
+		obj.SetSuspended(suspend)
+		request.Apply(obj, time.Now())
 
-		if msg.Suspend {
+		if suspend {
 			log.Info("Suspending resource")
 		} else {
 			log.Info("Resuming resource")
@@ -66,7 +90,7 @@ func (cs *coreServer) ToggleSuspendResource(ctx context.Context, msg *pb.ToggleS
 		}
 	}
 
-	return &pb.ToggleSuspendResourceResponse{}, respErrors.ErrorOrNil()
+	return respErrors.ErrorOrNil()
 }
 
 func getReconcilableObject(kind string) (fluxsync.Reconcilable, error) {
@@ -8,10 +8,13 @@ import (
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/fluxsync"
 	api "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestSuspend_Suspend(t *testing.T) {
@@ -150,6 +153,13 @@ func checkSpec(t *testing.T, k client.Client, name types.NamespacedName, obj cli
 			t.Error(err)
 		}
 
+		return v.Spec.Suspend
+
+	case *sourcev1.OCIRepository:
+		if err := k.Get(context.Background(), name, v); err != nil {
+			t.Error(err)
+		}
+
 		return v.Spec.Suspend
 	}
 
@@ -161,3 +171,65 @@ func checkSpec(t *testing.T, k client.Client, name types.NamespacedName, obj cli
 func TestSuspend_Resume(t *testing.T) {
 
 }
+
+func TestSuspendResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	ks := &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "flux-system"}}
+	hr := &helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "apps"}}
+	repo := &sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "flux-system"}}
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ks, hr, repo).Build()
+	c := makeServer(makeServerConfig(k, t), t)
+
+	objects := []*api.ObjectRef{
+		{Kind: kustomizev1.KustomizationKind, Name: ks.Name, Namespace: ks.Namespace, ClusterName: "Default"},
+		{Kind: helmv2.HelmReleaseKind, Name: hr.Name, Namespace: hr.Namespace, ClusterName: "Default"},
+		{Kind: sourcev1.OCIRepositoryKind, Name: repo.Name, Namespace: repo.Namespace, ClusterName: "Default"},
+	}
+
+	t.Run("suspends the objects, recording who suspended them", func(t *testing.T) {
+		_, err := c.SuspendResource(ctx, &api.SuspendResourceRequest{Objects: objects, Comment: "INC-123"})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		for _, obj := range []client.Object{ks, hr, repo} {
+			g.Expect(checkSpec(t, k, client.ObjectKeyFromObject(obj), obj)).To(BeTrue())
+
+			annotations := obj.GetAnnotations()
+			g.Expect(annotations).To(HaveKeyWithValue(fluxsync.SuspendRequestedByAnnotation, "anne"))
+			g.Expect(annotations).To(HaveKeyWithValue(fluxsync.SuspendOriginAnnotation, fluxsync.OriginUI))
+			g.Expect(annotations).To(HaveKeyWithValue(fluxsync.SuspendCommentAnnotation, "INC-123"))
+			g.Expect(annotations).To(HaveKey(fluxsync.SuspendRequestedAtAnnotation))
+		}
+	})
+
+	t.Run("resumes the objects, replacing the comment", func(t *testing.T) {
+		_, err := c.ResumeResource(ctx, &api.ResumeResourceRequest{Objects: objects})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		for _, obj := range []client.Object{ks, hr, repo} {
+			g.Expect(checkSpec(t, k, client.ObjectKeyFromObject(obj), obj)).To(BeFalse())
+
+			annotations := obj.GetAnnotations()
+			g.Expect(annotations).To(HaveKeyWithValue(fluxsync.SuspendRequestedByAnnotation, "anne"))
+			g.Expect(annotations).NotTo(HaveKey(fluxsync.SuspendCommentAnnotation))
+		}
+	})
+
+	t.Run("returns the errors of all the objects", func(t *testing.T) {
+		_, err := c.SuspendResource(ctx, &api.SuspendResourceRequest{Objects: []*api.ObjectRef{
+			{Kind: kustomizev1.KustomizationKind, Name: "missing", Namespace: "flux-system", ClusterName: "Default"},
+			{Kind: "ConfigMap", Name: "cm", Namespace: "flux-system", ClusterName: "Default"},
+			objects[0],
+		}})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("2 errors occurred"))
+
+		g.Expect(checkSpec(t, k, client.ObjectKeyFromObject(ks), ks)).To(BeTrue())
+	})
+}
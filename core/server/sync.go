@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
@@ -11,12 +13,17 @@ import (
 	"github.com/weaveworks/weave-gitops/core/fluxsync"
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (cs *coreServer) SyncFluxObject(ctx context.Context, msg *pb.SyncFluxObjectRequest) (*pb.SyncFluxObjectResponse, error) {
 	principal := auth.Principal(ctx)
 	respErrors := multierror.Error{}
+	trigger := reconcileTrigger(ctx, principal)
 
 	for _, sync := range msg.Objects {
 		clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, principal)
@@ -83,7 +90,7 @@ func (cs *coreServer) SyncFluxObject(ctx context.Context, msg *pb.SyncFluxObject
 			)
 			log.Info("Syncing resource")
 
-			if err := fluxsync.RequestReconciliation(ctx, c, sourceKey, sourceGvk); err != nil {
+			if _, err := fluxsync.RequestReconciliation(ctx, c, sourceKey, sourceGvk, trigger); err != nil {
 				respErrors = *multierror.Append(fmt.Errorf("requesting source reconciliation: %w", err), respErrors.Errors...)
 				continue
 			}
@@ -103,7 +110,7 @@ func (cs *coreServer) SyncFluxObject(ctx context.Context, msg *pb.SyncFluxObject
 		log.Info("Syncing resource")
 
 		gvk := obj.GroupVersionKind()
-		if err := fluxsync.RequestReconciliation(ctx, c, key, gvk); err != nil {
+		if _, err := fluxsync.RequestReconciliation(ctx, c, key, gvk, trigger); err != nil {
 			respErrors = *multierror.Append(fmt.Errorf("requesting reconciliation: %w", err), respErrors.Errors...)
 			continue
 		}
@@ -117,6 +124,148 @@ func (cs *coreServer) SyncFluxObject(ctx context.Context, msg *pb.SyncFluxObject
 	return &pb.SyncFluxObjectResponse{}, respErrors.ErrorOrNil()
 }
 
+func (cs *coreServer) SyncResource(ctx context.Context, msg *pb.SyncResourceRequest) (*pb.SyncResourceResponse, error) {
+	if msg.Object == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "object is required")
+	}
+
+	principal := auth.Principal(ctx)
+	trigger := reconcileTrigger(ctx, principal)
+
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, principal)
+	if err != nil {
+		return nil, fmt.Errorf("error getting impersonating client: %w", err)
+	}
+
+	c, err := clustersClient.Scoped(msg.Object.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("getting cluster client: %w", err)
+	}
+
+	key := client.ObjectKey{
+		Name:      msg.Object.Name,
+		Namespace: msg.Object.Namespace,
+	}
+
+	obj, err := getFluxObject(msg.Object.Kind)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error converting to object: %s", err)
+	}
+
+	if err := c.Get(ctx, key, obj.AsClientObject()); err != nil {
+		return nil, fmt.Errorf("error getting object: %w", err)
+	}
+
+	// checked before syncing the source, which would be synced for nothing
+	if obj.IsSuspended() {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s %s/%s is suspended", msg.Object.Kind, key.Namespace, key.Name)
+	}
+
+	resp := &pb.SyncResourceResponse{}
+
+	if msg.WithSource {
+		automation, ok := obj.(fluxsync.Automation)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%s has no source to sync", msg.Object.Kind)
+		}
+
+		sourceRef := automation.SourceRef()
+
+		_, sourceObj, err := fluxsync.ToReconcileable(sourceRef.Kind())
+		if err != nil {
+			return nil, fmt.Errorf("getting source type for %q: %w", sourceRef.Kind(), err)
+		}
+
+		// sourceRef.Namespace is an optional field in flux, defaulting to the
+		// namespace of the automation.
+		sourceNs := sourceRef.Namespace()
+		if sourceNs == "" {
+			sourceNs = key.Namespace
+		}
+
+		sourceKey := client.ObjectKey{
+			Name:      sourceRef.Name(),
+			Namespace: sourceNs,
+		}
+
+		if err := cs.syncAndWait(ctx, c, principal, trigger, sourceKey, sourceObj); err != nil {
+			return nil, err
+		}
+
+		resp.SourceConditions = conditionsToProto(sourceObj.GetConditions())
+	}
+
+	if err := cs.syncAndWait(ctx, c, principal, trigger, key, obj); err != nil {
+		return nil, err
+	}
+
+	resp.Conditions = conditionsToProto(obj.GetConditions())
+	resp.LastHandledReconcileAt = obj.GetLastHandledReconcileRequest()
+
+	return resp, nil
+}
+
+// syncAndWait requests the reconciliation of the object and waits until the
+// controller handled that request, leaving obj with the reconciled status.
+// The errors of suspended objects, which the controllers don't reconcile,
+// and of timeouts carry their gRPC code.
+func (cs *coreServer) syncAndWait(ctx context.Context, c client.Client, principal *auth.UserPrincipal, trigger fluxsync.ReconcileTrigger, key client.ObjectKey, obj fluxsync.Reconcilable) error {
+	gvk := obj.GroupVersionKind()
+	ref := fmt.Sprintf("%s %s/%s", gvk.Kind, key.Namespace, key.Name)
+
+	if err := c.Get(ctx, key, obj.AsClientObject()); err != nil {
+		return fmt.Errorf("getting %s: %w", ref, err)
+	}
+
+	if obj.IsSuspended() {
+		return status.Errorf(codes.FailedPrecondition, "%s is suspended", ref)
+	}
+
+	log := cs.logger.WithValues(
+		"user", principal.ID,
+		"kind", gvk.Kind,
+		"name", key.Name,
+		"namespace", key.Namespace,
+	)
+	log.Info("Syncing resource")
+
+	requestedAt, err := fluxsync.RequestReconciliation(ctx, c, key, gvk, trigger)
+	if err != nil {
+		return fmt.Errorf("requesting reconciliation of %s: %w", ref, err)
+	}
+
+	if err := fluxsync.WaitForReconciliation(ctx, c, key, obj, requestedAt); err != nil {
+		if errors.Is(err, fluxsync.ErrSyncTimeout) {
+			return status.Errorf(codes.DeadlineExceeded, "syncing %s: %s", ref, err)
+		}
+
+		return fmt.Errorf("syncing %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// reconcileTrigger records the principal requesting a sync. Requests that
+// carry a bearer token come from API clients, the UI authenticates with a
+// cookie instead.
+func reconcileTrigger(ctx context.Context, principal *auth.UserPrincipal) fluxsync.ReconcileTrigger {
+	trigger := fluxsync.ReconcileTrigger{Origin: fluxsync.OriginUI}
+
+	if principal != nil {
+		trigger.RequestedBy = principal.ID
+
+		if principal.Impersonator != "" {
+			trigger.RequestedBy = fmt.Sprintf("%s (impersonated by %s)", principal.ID, principal.Impersonator)
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		trigger.Origin = fluxsync.OriginAPI
+	}
+
+	return trigger
+}
+
 func getFluxObject(kind string) (fluxsync.Reconcilable, error) {
 	switch kind {
 	case kustomizev1.KustomizationKind:
@@ -136,3 +285,30 @@ func getFluxObject(kind string) (fluxsync.Reconcilable, error) {
 
 	return nil, fmt.Errorf("not supported kind: %s", kind)
 }
+
+func conditionsToProto(conditions []metav1.Condition) []*pb.Condition {
+	out := []*pb.Condition{}
+
+	for _, c := range conditions {
+		out = append(out, conditionToProto(c.Type, string(c.Status), c.Reason, c.Message, c.LastTransitionTime.Time))
+	}
+
+	return out
+}
+
+// conditionToProto returns a condition of the API, without a timestamp when
+// the last transition time isn't known.
+func conditionToProto(conditionType, conditionStatus, reason, message string, lastTransitionTime time.Time) *pb.Condition {
+	cond := &pb.Condition{
+		Type:    conditionType,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	}
+
+	if !lastTransitionTime.IsZero() {
+		cond.Timestamp = lastTransitionTime.Format(time.RFC3339)
+	}
+
+	return cond
+}
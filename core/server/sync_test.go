@@ -11,6 +11,8 @@ import (
 	"github.com/fluxcd/pkg/apis/meta"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -19,6 +21,7 @@ import (
 	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestSync(t *testing.T) {
@@ -149,6 +152,15 @@ func TestSync(t *testing.T) {
 					if err != nil {
 						t.Errorf(err.Error())
 					}
+
+					obj := tt.automation.AsClientObject()
+					if err := k.Get(ctx, types.NamespacedName{Name: name, Namespace: ns.Name}, obj); err != nil {
+						t.Fatal(err)
+					}
+
+					if origin := obj.GetAnnotations()[fluxsync.ReconcileOriginAnnotation]; origin != fluxsync.OriginUI {
+						t.Errorf("expected reconcile origin %q, got %q", fluxsync.OriginUI, origin)
+					}
 					return
 				}
 			}
@@ -328,3 +340,155 @@ func makeHelmRelease(name string, ns corev1.Namespace, repo *sourcev1.HelmReposi
 		},
 	}
 }
+
+func TestSyncResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "flux-system"}}
+	gitRepo := makeGitRepo("myapp", ns)
+	kust := makeKustomization("myapp", ns, gitRepo)
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gitRepo, kust).Build()
+	c := makeServer(makeServerConfig(k, t), t)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	// handle the reconcile requests like the controllers would
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_ = handleReconcileRequest(ctx, k, &sourcev1.GitRepository{}, client.ObjectKeyFromObject(gitRepo))
+				_ = handleReconcileRequest(ctx, k, &kustomizev1.Kustomization{}, client.ObjectKeyFromObject(kust))
+			}
+		}
+	}()
+
+	ref := &pb.ObjectRef{
+		ClusterName: "Default",
+		Kind:        kustomizev1.KustomizationKind,
+		Name:        kust.Name,
+		Namespace:   kust.Namespace,
+	}
+
+	res, err := c.SyncResource(ctx, &pb.SyncResourceRequest{Object: ref, WithSource: true})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(k.Get(ctx, client.ObjectKeyFromObject(kust), kust)).To(Succeed())
+	g.Expect(res.LastHandledReconcileAt).To(Equal(kust.GetAnnotations()[meta.ReconcileRequestAnnotation]))
+	g.Expect(res.Conditions).To(HaveLen(1))
+	g.Expect(res.Conditions[0].Type).To(Equal(meta.ReadyCondition))
+	g.Expect(res.Conditions[0].Status).To(Equal("True"))
+	g.Expect(res.SourceConditions).To(HaveLen(1))
+
+	g.Expect(k.Get(ctx, client.ObjectKeyFromObject(gitRepo), gitRepo)).To(Succeed())
+	g.Expect(gitRepo.GetAnnotations()).To(HaveKeyWithValue(fluxsync.ReconcileOriginAnnotation, fluxsync.OriginUI))
+
+	_, err = c.SyncResource(ctx, &pb.SyncResourceRequest{
+		Object:     &pb.ObjectRef{ClusterName: "Default", Kind: sourcev1.GitRepositoryKind, Name: gitRepo.Name, Namespace: gitRepo.Namespace},
+		WithSource: true,
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	_, err = c.SyncResource(ctx, &pb.SyncResourceRequest{})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+}
+
+func TestSyncResourceSuspended(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "flux-system"}}
+
+	suspendedRepo := makeGitRepo("suspended", ns)
+	suspendedRepo.Spec.Suspend = true
+	kust := makeKustomization("myapp", ns, suspendedRepo)
+
+	gitRepo := makeGitRepo("myapp", ns)
+	suspendedKust := makeKustomization("suspended", ns, gitRepo)
+	suspendedKust.Spec.Suspend = true
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(suspendedRepo, kust, gitRepo, suspendedKust).Build()
+	c := makeServer(makeServerConfig(k, t), t)
+
+	tests := []struct {
+		name string
+		ref  *pb.ObjectRef
+	}{
+		{
+			name: "suspended object",
+			ref:  &pb.ObjectRef{ClusterName: "Default", Kind: kustomizev1.KustomizationKind, Name: suspendedKust.Name, Namespace: ns.Name},
+		},
+		{
+			name: "suspended source",
+			ref:  &pb.ObjectRef{ClusterName: "Default", Kind: kustomizev1.KustomizationKind, Name: kust.Name, Namespace: ns.Name},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			_, err := c.SyncResource(ctx, &pb.SyncResourceRequest{Object: tt.ref, WithSource: true})
+			g.Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+			g.Expect(err.Error()).To(ContainSubstring("is suspended"))
+		})
+	}
+
+	// nothing was requested
+	g.Expect(k.Get(ctx, client.ObjectKeyFromObject(kust), kust)).To(Succeed())
+	g.Expect(kust.GetAnnotations()).NotTo(HaveKey(meta.ReconcileRequestAnnotation))
+	g.Expect(k.Get(ctx, client.ObjectKeyFromObject(suspendedKust), suspendedKust)).To(Succeed())
+	g.Expect(suspendedKust.GetAnnotations()).NotTo(HaveKey(meta.ReconcileRequestAnnotation))
+}
+
+// handleReconcileRequest marks the reconcile request of the object as handled,
+// with a Ready condition.
+func handleReconcileRequest(ctx context.Context, k client.Client, o client.Object, key client.ObjectKey) error {
+	if err := k.Get(ctx, key, o); err != nil {
+		return err
+	}
+
+	obj := fluxsync.NewReconcileable(o)
+
+	requestedAt := obj.GetAnnotations()[meta.ReconcileRequestAnnotation]
+	if requestedAt == "" || requestedAt == obj.GetLastHandledReconcileRequest() {
+		return nil
+	}
+
+	conditions := []metav1.Condition{{
+		Type:               meta.ReadyCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             meta.SucceededReason,
+		LastTransitionTime: metav1.Now(),
+	}}
+
+	switch obj := o.(type) {
+	case *sourcev1.GitRepository:
+		obj.Status.SetLastHandledReconcileRequest(requestedAt)
+		obj.Status.Conditions = conditions
+	case *kustomizev1.Kustomization:
+		obj.Status.SetLastHandledReconcileRequest(requestedAt)
+		obj.Status.Conditions = conditions
+	default:
+		return errors.New("handling reconcile request: unsupported type")
+	}
+
+	return k.Status().Update(ctx, o)
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/tenancy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (cs *coreServer) GenerateTenantManifests(ctx context.Context, msg *pb.GenerateTenantManifestsRequest) (*pb.GenerateTenantManifestsResponse, error) {
+	tenant := tenancy.Tenant{
+		Name:        msg.Name,
+		Namespaces:  msg.Namespaces,
+		ClusterRole: msg.ClusterRole,
+		SourceURL:   msg.SourceUrl,
+		Branch:      msg.Branch,
+		Path:        msg.Path,
+	}
+
+	if err := tenant.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	manifests, err := tenant.Manifests()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generating tenant manifests: %v", err)
+	}
+
+	return &pb.GenerateTenantManifestsResponse{Manifests: string(manifests)}, nil
+}
@@ -0,0 +1,35 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGenerateTenantManifests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).To(BeNil())
+
+	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := makeServer(makeServerConfig(client, t), t)
+
+	res, err := c.GenerateTenantManifests(context.Background(), &pb.GenerateTenantManifestsRequest{
+		Name:       "team-a",
+		Namespaces: []string{"apps"},
+		SourceUrl:  "https://github.com/example/team-a",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Manifests).To(ContainSubstring("toolkit.fluxcd.io/tenant: team-a"))
+	g.Expect(res.Manifests).To(ContainSubstring("kind: Kustomization"))
+
+	_, err = c.GenerateTenantManifests(context.Background(), &pb.GenerateTenantManifestsRequest{Name: "team-a"})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"github.com/weaveworks/weave-gitops/pkg/tenancy"
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -12,7 +13,7 @@ func getMatchingLabels(appName string) client.MatchingLabels {
 func GetTenant(namespace, clusterName string, clusterUserNamespaces map[string][]v1.Namespace) string {
 	for _, ns := range clusterUserNamespaces[clusterName] {
 		if ns.GetName() == namespace {
-			return ns.Labels["toolkit.fluxcd.io/tenant"]
+			return ns.Labels[tenancy.TenantLabel]
 		}
 	}
 
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/kubectl/pkg/util/openapi"
+	"k8s.io/kubectl/pkg/util/openapi/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Severities of manifest findings.
+const (
+	FindingError   = "error"
+	FindingWarning = "warning"
+)
+
+// fluxGroupSuffix is shared by the API groups of the Flux controllers.
+const fluxGroupSuffix = ".toolkit.fluxcd.io"
+
+func (cs *coreServer) ValidateManifests(ctx context.Context, msg *pb.ValidateManifestsRequest) (*pb.ValidateManifestsResponse, error) {
+	clusterName := msg.ClusterName
+	if clusterName == "" {
+		clusterName = cluster.DefaultCluster
+	}
+
+	docs, err := splitManifests(msg.Manifests)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid manifests: %v", err)
+	}
+
+	dc, err := cs.clustersManager.GetImpersonatedDiscoveryClient(ctx, auth.Principal(ctx), clusterName)
+	if errors.As(err, &clustersmngr.ClusterNotFoundError{}) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "error creating discovery client: %v", err)
+	}
+
+	v, err := newManifestValidator(dc, func() (openapi.Resources, error) {
+		return cs.openAPISchemas.get(clusterName, dc)
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error discovering cluster APIs: %v", err)
+	}
+
+	resp := &pb.ValidateManifestsResponse{
+		Valid:    true,
+		Findings: []*pb.ManifestFinding{},
+	}
+
+	for i, doc := range docs {
+		for _, f := range v.validate(doc) {
+			f.Document = int32(i)
+
+			if f.Severity == FindingError {
+				resp.Valid = false
+			}
+
+			resp.Findings = append(resp.Findings, f)
+		}
+	}
+
+	return resp, nil
+}
+
+// splitManifests returns the non-empty documents of a YAML stream.
+func splitManifests(manifests string) ([][]byte, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(manifests)))
+	docs := [][]byte{}
+
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(string(doc)) != "" {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// openAPISchemaTTL is how long the parsed OpenAPI schema of a cluster is
+// reused for.
+const openAPISchemaTTL = 5 * time.Minute
+
+// openAPISchemas keeps the parsed OpenAPI schema of each cluster, which is
+// the same for every user, and slow to download and parse.
+type openAPISchemas struct {
+	now func() time.Time
+
+	lock    sync.Mutex
+	schemas map[string]cachedSchema
+}
+
+type cachedSchema struct {
+	resources openapi.Resources
+	expires   time.Time
+}
+
+func newOpenAPISchemas() *openAPISchemas {
+	return &openAPISchemas{
+		now:     time.Now,
+		schemas: map[string]cachedSchema{},
+	}
+}
+
+// get returns the schema of the cluster, from dc once the cached one
+// expired.
+func (s *openAPISchemas) get(clusterName string, dc discovery.OpenAPISchemaInterface) (openapi.Resources, error) {
+	s.lock.Lock()
+	cached, ok := s.schemas[clusterName]
+	s.lock.Unlock()
+
+	if ok && s.now().Before(cached.expires) {
+		return cached.resources, nil
+	}
+
+	doc, err := dc.OpenAPISchema()
+	if err != nil {
+		return nil, fmt.Errorf("getting OpenAPI schema: %w", err)
+	}
+
+	resources, err := openapi.NewOpenAPIData(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI schema: %w", err)
+	}
+
+	s.lock.Lock()
+	s.schemas[clusterName] = cachedSchema{resources: resources, expires: s.now().Add(openAPISchemaTTL)}
+	s.lock.Unlock()
+
+	return resources, nil
+}
+
+// manifestValidator checks manifests against what a cluster serves. The
+// resources of each group version are only discovered when needed.
+type manifestValidator struct {
+	dc        discovery.DiscoveryInterface
+	groups    map[string]metav1.APIGroup
+	resources map[string][]metav1.APIResource
+	schema    *validation.SchemaValidation
+}
+
+func newManifestValidator(dc discovery.DiscoveryInterface, schema func() (openapi.Resources, error)) (*manifestValidator, error) {
+	groupList, err := dc.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("listing API groups: %w", err)
+	}
+
+	groups := map[string]metav1.APIGroup{}
+	for _, g := range groupList.Groups {
+		groups[g.Name] = g
+	}
+
+	resources, err := schema()
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifestValidator{
+		dc:        dc,
+		groups:    groups,
+		resources: map[string][]metav1.APIResource{},
+		schema:    validation.NewSchemaValidation(resources),
+	}, nil
+}
+
+func (v *manifestValidator) validate(doc []byte) []*pb.ManifestFinding {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+		return []*pb.ManifestFinding{{Severity: FindingError, Message: fmt.Sprintf("invalid YAML: %v", err)}}
+	}
+
+	// Documents with only comments
+	if len(obj.Object) == 0 {
+		return nil
+	}
+
+	finding := func(severity, format string, args ...interface{}) *pb.ManifestFinding {
+		return &pb.ManifestFinding{
+			Severity:   severity,
+			ApiVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+			Namespace:  obj.GetNamespace(),
+			Message:    fmt.Sprintf(format, args...),
+		}
+	}
+
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return []*pb.ManifestFinding{finding(FindingError, "apiVersion and kind are required")}
+	}
+
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		return []*pb.ManifestFinding{finding(FindingError, "invalid apiVersion: %v", err)}
+	}
+
+	group, ok := v.groups[gv.Group]
+	if !ok {
+		return []*pb.ManifestFinding{finding(FindingError, "API group %q is not served by the cluster", gv.Group)}
+	}
+
+	if !servesVersion(group, gv.Version) {
+		return []*pb.ManifestFinding{finding(FindingError, "%s is not served by the cluster, served versions are %s", gv, servedVersions(group))}
+	}
+
+	resource, err := v.resource(gv, obj.GetKind())
+	if err != nil {
+		return []*pb.ManifestFinding{finding(FindingError, "%v", err)}
+	}
+
+	if resource == nil {
+		return []*pb.ManifestFinding{finding(FindingError, "kind %s is not served by the cluster in %s", obj.GetKind(), gv)}
+	}
+
+	findings := []*pb.ManifestFinding{}
+
+	// Flux deprecates and removes its API versions faster than Kubernetes
+	if strings.HasSuffix(gv.Group, fluxGroupSuffix) && group.PreferredVersion.Version != gv.Version {
+		findings = append(findings, finding(FindingWarning, "%s is not the version of %s installed in the cluster, use %s", gv.Version, obj.GetKind(), group.PreferredVersion.GroupVersion))
+	}
+
+	if !resource.Namespaced && obj.GetNamespace() != "" {
+		findings = append(findings, finding(FindingWarning, "%s is cluster-scoped, its namespace is ignored", obj.GetKind()))
+	}
+
+	if err := v.schema.ValidateBytes(doc); err != nil {
+		errs := []error{err}
+		if agg, ok := err.(utilerrors.Aggregate); ok {
+			errs = agg.Errors()
+		}
+
+		for _, err := range errs {
+			findings = append(findings, finding(FindingError, "%v", err))
+		}
+	}
+
+	return findings
+}
+
+// resource returns the API resource of a kind, or nil if the cluster
+// doesn't serve it.
+func (v *manifestValidator) resource(gv schema.GroupVersion, kind string) (*metav1.APIResource, error) {
+	resources, ok := v.resources[gv.String()]
+	if !ok {
+		list, err := v.dc.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			return nil, fmt.Errorf("listing resources of %s: %w", gv, err)
+		}
+
+		resources = list.APIResources
+		v.resources[gv.String()] = resources
+	}
+
+	for i, r := range resources {
+		// Subresources have the kind of their parent
+		if r.Kind == kind && !strings.Contains(r.Name, "/") {
+			return &resources[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func servesVersion(group metav1.APIGroup, version string) bool {
+	for _, v := range group.Versions {
+		if v.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func servedVersions(group metav1.APIGroup) string {
+	versions := []string{}
+	for _, v := range group.Versions {
+		versions = append(versions, v.GroupVersion)
+	}
+
+	return strings.Join(versions, ", ")
+}
@@ -0,0 +1,174 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/clustersmngrfakes"
+	"github.com/weaveworks/weave-gitops/core/server"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const configMapSchema = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.25.4"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.api.core.v1.ConfigMap": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {"type": "object"},
+        "data": {"type": "object", "additionalProperties": {"type": "string"}}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "", "kind": "ConfigMap", "version": "v1"}]
+    }
+  }
+}`
+
+type schemaDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	doc     *openapi_v2.Document
+	fetches *int
+}
+
+func (d schemaDiscovery) OpenAPISchema() (*openapi_v2.Document, error) {
+	*d.fetches++
+	return d.doc, nil
+}
+
+func TestValidateManifests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	doc, err := openapi_v2.ParseDocument([]byte(configMapSchema))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	fetches := 0
+
+	dc := schemaDiscovery{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+				{Name: "namespaces", Kind: "Namespace"},
+				{Name: "namespaces/status", Kind: "Namespace"},
+			}},
+			{GroupVersion: "kustomize.toolkit.fluxcd.io/v1beta2", APIResources: []metav1.APIResource{
+				{Name: "kustomizations", Kind: "Kustomization", Namespaced: true},
+			}},
+			{GroupVersion: "kustomize.toolkit.fluxcd.io/v1beta1", APIResources: []metav1.APIResource{
+				{Name: "kustomizations", Kind: "Kustomization", Namespaced: true},
+			}},
+		}}},
+		doc:     doc,
+		fetches: &fetches,
+	}
+
+	clustersManager := &clustersmngrfakes.FakeClustersManager{}
+	clustersManager.GetImpersonatedDiscoveryClientReturns(dc, nil)
+
+	cfg, err := server.NewCoreConfig(logr.Discard(), &rest.Config{}, "test", clustersManager)
+	g.Expect(err).NotTo(HaveOccurred())
+	coreSrv, err := server.NewCoreServer(cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.UserPrincipal{ID: "anne"})
+
+	resp, err := coreSrv.ValidateManifests(ctx, &pb.ValidateManifestsRequest{Manifests: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: valid
+  namespace: apps
+data:
+  key: value
+---
+# only comments
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: invalid
+spec:
+  key: value
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1beta1
+kind: Kustomization
+metadata:
+  name: old
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: future
+---
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: podinfo
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: apps
+  namespace: apps
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: unknown
+`})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resp.Valid).To(BeFalse())
+
+	findings := map[string]*pb.ManifestFinding{}
+	for _, f := range resp.Findings {
+		findings[f.Name] = f
+	}
+
+	g.Expect(findings).NotTo(HaveKey("valid"))
+	g.Expect(findings).To(HaveLen(6))
+
+	g.Expect(findings["invalid"].Document).To(Equal(int32(2)))
+	g.Expect(findings["invalid"].Severity).To(Equal(server.FindingError))
+	g.Expect(findings["invalid"].Message).To(ContainSubstring(`unknown field "spec"`))
+
+	g.Expect(findings["old"].Severity).To(Equal(server.FindingWarning))
+	g.Expect(findings["old"].Message).To(ContainSubstring("use kustomize.toolkit.fluxcd.io/v1beta2"))
+
+	g.Expect(findings["future"].Severity).To(Equal(server.FindingError))
+	g.Expect(findings["future"].Message).To(ContainSubstring("kustomize.toolkit.fluxcd.io/v1 is not served by the cluster"))
+
+	g.Expect(findings["podinfo"].Message).To(Equal(`API group "helm.toolkit.fluxcd.io" is not served by the cluster`))
+
+	g.Expect(findings["apps"].Severity).To(Equal(server.FindingWarning))
+	g.Expect(findings["apps"].Kind).To(Equal("Namespace"))
+
+	g.Expect(findings["unknown"].Message).To(Equal("kind Secret is not served by the cluster in v1"))
+
+	t.Run("manifests without errors are valid", func(t *testing.T) {
+		resp, err := coreSrv.ValidateManifests(ctx, &pb.ValidateManifestsRequest{Manifests: `
+apiVersion: kustomize.toolkit.fluxcd.io/v1beta1
+kind: Kustomization
+metadata:
+  name: old
+`})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(resp.Valid).To(BeTrue())
+		g.Expect(resp.Findings).To(HaveLen(1))
+	})
+
+	t.Run("the schema of the cluster is reused", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(fetches).To(Equal(1))
+	})
+}
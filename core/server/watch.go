@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/server/types"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WatchObjectsPath is where WatchObjects is served over HTTP, as the gateway
+// can't serve streaming calls in process. Responses are sent as lines of
+// JSON, like the gateway streams them.
+const WatchObjectsPath = "/v1/objects/watch"
+
+func (cs *coreServer) WatchObjects(msg *pb.WatchObjectsRequest, stream pb.Core_WatchObjectsServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	if len(msg.Kinds) == 0 {
+		return status.Error(codes.InvalidArgument, "kinds are required")
+	}
+
+	gvks := make([]schema.GroupVersionKind, 0, len(msg.Kinds))
+
+	for _, kind := range msg.Kinds {
+		gvk, err := cs.primaryKinds.Lookup(kind)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		gvks = append(gvks, *gvk)
+	}
+
+	principal := auth.Principal(ctx)
+
+	clustersClient, err := cs.clustersManager.GetImpersonatedClient(ctx, principal, clustersmngr.WithEagerClients())
+
+	// clusters whose client couldn't be created are reported, the others are
+	// still watched
+	failedWatches := clientListErrors(err)
+	if err != nil && failedWatches == nil {
+		return fmt.Errorf("error getting impersonating client: %w", err)
+	}
+
+	opts := []client.ListOption{}
+	if len(msg.Namespaces) > 0 {
+		opts = append(opts, clustersmngr.InNamespaces(msg.Namespaces))
+	}
+
+	if len(msg.ClusterNames) > 0 {
+		opts = append(opts, clustersmngr.InClusters(msg.ClusterNames))
+	}
+
+	if len(msg.Labels) > 0 {
+		opts = append(opts, client.MatchingLabels(msg.Labels))
+	}
+
+	var (
+		responses             = make(chan *pb.WatchObjectsResponse)
+		wg                    = sync.WaitGroup{}
+		clusterUserNamespaces = cs.clustersManager.GetUserNamespaces(principal)
+	)
+
+	for _, gvk := range gvks {
+		gvk := gvk
+
+		events, err := clustersClient.ClusteredWatch(ctx, func() client.ObjectList {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk)
+
+			return list
+		}, true, opts...)
+
+		var errs clustersmngr.ClusteredListError
+		if errors.As(err, &errs) {
+			failedWatches = append(failedWatches, errs.Errors...)
+		} else if err != nil {
+			return err
+		}
+
+		if events == nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for event := range events {
+				select {
+				case <-ctx.Done():
+					return
+				case responses <- watchEventToProto(event, gvk, clusterUserNamespaces):
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(responses)
+	}()
+
+	if len(failedWatches) > 0 {
+		resp := &pb.WatchObjectsResponse{}
+
+		for _, e := range failedWatches {
+			resp.Errors = append(resp.Errors, &pb.ListError{ClusterName: e.Cluster, Namespace: e.Namespace, Message: e.Err.Error()})
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	for resp := range responses {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func watchEventToProto(event clustersmngr.WatchEvent, gvk schema.GroupVersionKind, clusterUserNamespaces map[string][]v1.Namespace) *pb.WatchObjectsResponse {
+	listError := func(err error) *pb.WatchObjectsResponse {
+		return &pb.WatchObjectsResponse{
+			Errors: []*pb.ListError{{ClusterName: event.Cluster, Namespace: event.Namespace, Message: err.Error()}},
+		}
+	}
+
+	if err := event.Err(); err != nil {
+		return listError(err)
+	}
+
+	unstructuredObj, err := toUnstructured(event.Object, gvk)
+	if err != nil {
+		return listError(fmt.Errorf("converting items: %w", err))
+	}
+
+	var obj client.Object = unstructuredObj
+
+	if gvk.Kind == "Secret" {
+		obj, err = sanitizeSecret(unstructuredObj)
+		if err != nil {
+			return listError(fmt.Errorf("error sanitizing secrets: %w", err))
+		}
+	}
+
+	tenant := GetTenant(obj.GetNamespace(), event.Cluster, clusterUserNamespaces)
+
+	o, err := types.K8sObjectToProto(obj, event.Cluster, tenant, nil)
+	if err != nil {
+		return listError(fmt.Errorf("converting items: %w", err))
+	}
+
+	return &pb.WatchObjectsResponse{Type: string(event.Type), Object: o}
+}
+
+// toUnstructured returns the object of a watch event as unstructured, some
+// clients send typed objects, without their kind.
+func toUnstructured(obj k8sruntime.Object, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+
+	content, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+
+	return u, nil
+}
+
+// serveWatchObjects serves WatchObjects over HTTP, forwarding the responses
+// the way the gateway does for streaming calls.
+func (cs *coreServer) serveWatchObjects(mux *runtime.ServeMux) runtime.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(mux, r)
+
+		msg := &pb.WatchObjectsRequest{}
+		if err := inbound.NewDecoder(r.Body).Decode(msg); err != nil && !errors.Is(err, io.EOF) {
+			runtime.HTTPError(r.Context(), mux, outbound, rw, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		stream := &watchObjectsStream{ctx: ctx, responses: make(chan *pb.WatchObjectsResponse)}
+		done := make(chan error, 1)
+
+		go func() {
+			err := cs.WatchObjects(msg, stream)
+			if err == nil {
+				err = io.EOF
+			}
+
+			done <- err
+		}()
+
+		recv := func() (proto.Message, error) {
+			select {
+			case resp := <-stream.responses:
+				return resp, nil
+			case err := <-done:
+				return nil, err
+			}
+		}
+
+		runtime.ForwardResponseStream(runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{}), mux, outbound, rw, r, recv)
+	}
+}
+
+// watchObjectsStream hands the responses of WatchObjects over to the HTTP
+// handler.
+type watchObjectsStream struct {
+	grpc.ServerStream
+
+	ctx       context.Context
+	responses chan *pb.WatchObjectsResponse
+}
+
+func (s *watchObjectsStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchObjectsStream) Send(resp *pb.WatchObjectsResponse) error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case s.responses <- resp:
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/nsaccess/nsaccessfakes"
+	"github.com/weaveworks/weave-gitops/core/server"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	typedauth "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	restclient "k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWatchObjects(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	apps := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	kust := &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: apps.Name}}
+	otherKust := &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: other.Name}}
+
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(apps, other, kust, otherKust).Build()
+
+	cluster := clusterfakes.FakeCluster{}
+	cluster.GetNameReturns("Default")
+	cluster.GetUserClientReturns(k, nil)
+	cluster.GetServerClientReturns(k, nil)
+	cluster.GetUserClientsetReturns(k8sfake.NewSimpleClientset(), nil)
+
+	checker := nsaccessfakes.FakeChecker{}
+	checker.FilterAccessibleNamespacesStub = func(ctx context.Context, t typedauth.AuthorizationV1Interface, n []corev1.Namespace) ([]corev1.Namespace, error) {
+		return n, nil
+	}
+
+	principal := &auth.UserPrincipal{ID: "anne", Groups: []string{"system:masters"}}
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(&cluster)}, &checker, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+	g.Expect(clustersManager.UpdateNamespaces(ctx)).To(Succeed())
+	clustersManager.UpdateUserNamespaces(ctx, principal)
+
+	cfg, err := server.NewCoreConfig(logr.Discard(), &restclient.Config{}, "foobar", clustersManager)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	mux := runtime.NewServeMux()
+	g.Expect(server.Hydrate(ctx, mux, cfg)).To(Succeed())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	}))
+	defer srv.Close()
+
+	watch := func(ctx context.Context, body string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+server.WatchObjectsPath, strings.NewReader(body))
+		g.Expect(err).NotTo(HaveOccurred())
+
+		res, err := http.DefaultClient.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return res
+	}
+
+	t.Run("streams the changes of the objects", func(t *testing.T) {
+		// ends the request
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		lines := make(chan string)
+
+		// the response starts with the first change
+		go func() {
+			res := watch(ctx, `{"kinds": ["Kustomization"], "namespaces": ["apps"]}`)
+			defer res.Body.Close()
+
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				select {
+				case lines <- scanner.Text():
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		timeout := time.After(5 * time.Second)
+
+		var line string
+
+		// the watch starts in the background, change the objects until it
+		// sees a change
+	wait:
+		for {
+			select {
+			case line = <-lines:
+				break wait
+			case <-ticker.C:
+				for _, obj := range []client.Object{otherKust, kust} {
+					patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+					obj.SetLabels(map[string]string{"changed": time.Now().Format("150405.000")})
+					g.Expect(k.Patch(ctx, obj, patch)).To(Succeed())
+				}
+			case <-timeout:
+				t.Fatal("timed out waiting for a change")
+			}
+		}
+
+		var resp struct {
+			Result struct {
+				Type   string `json:"type"`
+				Object struct {
+					Payload     string `json:"payload"`
+					ClusterName string `json:"clusterName"`
+				} `json:"object"`
+			} `json:"result"`
+		}
+		g.Expect(json.Unmarshal([]byte(line), &resp)).To(Succeed())
+
+		g.Expect(resp.Result.Type).To(Equal("MODIFIED"))
+		g.Expect(resp.Result.Object.ClusterName).To(Equal("Default"))
+
+		var obj kustomizev1.Kustomization
+		g.Expect(json.Unmarshal([]byte(resp.Result.Object.Payload), &obj)).To(Succeed())
+		g.Expect(obj.Kind).To(Equal(kustomizev1.KustomizationKind))
+		g.Expect(obj.Namespace).To(Equal("apps"))
+		g.Expect(obj.Name).To(Equal("podinfo"))
+	})
+
+	t.Run("requires kinds", func(t *testing.T) {
+		res := watch(ctx, `{}`)
+		defer res.Body.Close()
+
+		g.Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+}
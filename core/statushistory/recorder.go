@@ -0,0 +1,160 @@
+package statushistory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultKinds are the Flux objects whose status is recorded.
+var DefaultKinds = []schema.GroupVersionKind{
+	kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind),
+	helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind),
+	sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind),
+	sourcev1.GroupVersion.WithKind(sourcev1.HelmRepositoryKind),
+	sourcev1.GroupVersion.WithKind(sourcev1.HelmChartKind),
+	sourcev1.GroupVersion.WithKind(sourcev1.BucketKind),
+	sourcev1.GroupVersion.WithKind(sourcev1.OCIRepositoryKind),
+}
+
+// Recorder periodically snapshots the status of Flux objects in every
+// cluster. It uses the server's own permissions, so the service account
+// must be able to list the recorded kinds.
+type Recorder struct {
+	log             logr.Logger
+	clustersManager clustersmngr.ClustersManager
+	store           *Store
+	interval        time.Duration
+	kinds           []schema.GroupVersionKind
+	now             func() time.Time
+}
+
+// NewRecorder creates a recorder that snapshots the default kinds every
+// interval.
+func NewRecorder(log logr.Logger, clustersManager clustersmngr.ClustersManager, store *Store, interval time.Duration) *Recorder {
+	return &Recorder{
+		log:             log.WithName("status-history"),
+		clustersManager: clustersManager,
+		store:           store,
+		interval:        interval,
+		kinds:           DefaultKinds,
+		now:             time.Now,
+	}
+}
+
+// Start records snapshots until the context is cancelled.
+func (r *Recorder) Start(ctx context.Context) {
+	go wait.UntilWithContext(ctx, r.Record, r.interval)
+}
+
+// listedKind is a kind successfully listed in a cluster.
+type listedKind struct {
+	cluster string
+	kind    string
+}
+
+// Record takes a snapshot of every object, and prunes the history of the
+// objects that are gone. Failures are logged rather than returned so one
+// broken cluster or kind doesn't stop the others.
+func (r *Recorder) Record(ctx context.Context) {
+	c, err := r.clustersManager.GetServerClient(ctx)
+	if err != nil {
+		// Clusters that couldn't be connected to are still recorded
+		r.log.Error(err, "failed to get clients for some clusters")
+
+		if c == nil {
+			return
+		}
+	}
+
+	now := r.now()
+
+	seen := map[ObjectRef]bool{}
+	listed := map[listedKind]bool{}
+
+	for _, gvk := range r.kinds {
+		gvk := gvk
+
+		clist := clustersmngr.NewClusteredList(func() client.ObjectList {
+			list := unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk)
+
+			return &list
+		})
+
+		failed := map[string]bool{}
+
+		if err := c.ClusteredList(ctx, clist, false); err != nil {
+			var errs clustersmngr.ClusteredListError
+			if !errors.As(err, &errs) {
+				r.log.Error(err, "failed to list objects", "kind", gvk.Kind)
+				continue
+			}
+
+			for _, e := range errs.Errors {
+				r.log.Error(e.Err, "failed to list objects", "kind", gvk.Kind, "cluster", e.Cluster)
+				failed[e.Cluster] = true
+			}
+		}
+
+		for clusterName, lists := range clist.Lists() {
+			// the history of objects that may just not have been listed is
+			// kept
+			if !failed[clusterName] {
+				listed[listedKind{cluster: clusterName, kind: gvk.Kind}] = true
+			}
+
+			for _, l := range lists {
+				list, ok := l.(*unstructured.UnstructuredList)
+				if !ok {
+					continue
+				}
+
+				for i := range list.Items {
+					obj := &list.Items[i]
+					ref := ObjectRef{
+						ClusterName: clusterName,
+						Kind:        gvk.Kind,
+						Namespace:   obj.GetNamespace(),
+						Name:        obj.GetName(),
+					}
+
+					seen[ref] = true
+
+					if err := r.store.Record(ctx, ref, SnapshotFromObject(obj, now)); err != nil {
+						r.log.Error(err, "failed to record status")
+					}
+				}
+			}
+		}
+	}
+
+	clusters := map[string]bool{}
+	for _, cl := range r.clustersManager.GetClusters() {
+		clusters[cl.GetName()] = true
+	}
+
+	// objects are gone once their cluster is, or once their kind was
+	// listed without them
+	keep := func(ref ObjectRef) bool {
+		if !clusters[ref.ClusterName] {
+			return false
+		}
+
+		return seen[ref] || !listed[listedKind{cluster: ref.ClusterName, kind: ref.Kind}]
+	}
+
+	if err := r.store.Prune(ctx, keep); err != nil {
+		r.log.Error(err, "failed to prune status history")
+	}
+}
@@ -0,0 +1,100 @@
+package statushistory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/fetcher"
+	"github.com/weaveworks/weave-gitops/core/nsaccess/nsaccessfakes"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRecorderRecord(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "apps"},
+		Status: kustomizev1.KustomizationStatus{
+			LastAppliedRevision: "main/abc123",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+		kustomization,
+	).Build()
+
+	cluster := clusterfakes.FakeCluster{}
+	cluster.GetNameReturns("Default")
+	cluster.GetServerClientReturns(fakeClient, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(&cluster)}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	store := NewStore(fakeClient, "flux-system", 0)
+	recorder := NewRecorder(logr.Discard(), clustersManager, store, time.Minute)
+	recorder.now = func() time.Time { return time.Date(2022, 11, 10, 14, 5, 0, 0, time.UTC) }
+
+	recorder.Record(ctx)
+
+	history, err := store.History(ctx, ObjectRef{ClusterName: "Default", Kind: kustomizev1.KustomizationKind, Namespace: "apps", Name: "podinfo"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).To(Equal([]Snapshot{
+		{Time: time.Date(2022, 11, 10, 14, 5, 0, 0, time.UTC), Revision: "main/abc123"},
+	}))
+}
+
+func TestRecorderPrunesGoneObjects(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	scheme, err := kube.CreateScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+		&kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "apps"}},
+	).Build()
+
+	cluster := clusterfakes.FakeCluster{}
+	cluster.GetNameReturns("Default")
+	cluster.GetServerClientReturns(fakeClient, nil)
+
+	clustersManager := clustersmngr.NewClustersManager([]clustersmngr.ClusterFetcher{fetcher.NewSingleClusterFetcher(&cluster)}, &nsaccessfakes.FakeChecker{}, logr.Discard())
+	g.Expect(clustersManager.UpdateClusters(ctx)).To(Succeed())
+
+	store := NewStore(fakeClient, "flux-system", 0)
+
+	podinfo := ObjectRef{ClusterName: "Default", Kind: kustomizev1.KustomizationKind, Namespace: "apps", Name: "podinfo"}
+	deleted := ObjectRef{ClusterName: "Default", Kind: kustomizev1.KustomizationKind, Namespace: "apps", Name: "deleted"}
+	removedCluster := ObjectRef{ClusterName: "leaf", Kind: kustomizev1.KustomizationKind, Namespace: "apps", Name: "podinfo"}
+
+	for _, ref := range []ObjectRef{podinfo, deleted, removedCluster} {
+		g.Expect(store.Record(ctx, ref, Snapshot{Revision: "a"})).To(Succeed())
+	}
+
+	NewRecorder(logr.Discard(), clustersManager, store, time.Minute).Record(ctx)
+
+	history, err := store.History(ctx, podinfo)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).NotTo(BeEmpty())
+
+	for _, ref := range []ObjectRef{deleted, removedCluster} {
+		history, err := store.History(ctx, ref)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(history).To(BeEmpty(), ref.Name)
+	}
+}
@@ -0,0 +1,149 @@
+// Package statushistory records how the status of Flux objects changes over
+// time, so the dashboard can show what an object looked like at a point in
+// the past.
+package statushistory
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Condition messages can be long, e.g. a whole kustomize build error, so
+// they're truncated to keep the history of an object within a ConfigMap.
+const maxMessageLength = 512
+
+// ObjectRef identifies an object whose status is recorded.
+type ObjectRef struct {
+	ClusterName string
+	Kind        string
+	Namespace   string
+	Name        string
+}
+
+// Snapshot is the status of an object from the time it was recorded until
+// the next snapshot. The JSON keys are short as many are stored per object.
+type Snapshot struct {
+	Time               time.Time   `json:"t"`
+	Revision           string      `json:"r,omitempty"`
+	ObservedGeneration int64       `json:"g,omitempty"`
+	Suspended          bool        `json:"s,omitempty"`
+	Conditions         []Condition `json:"c,omitempty"`
+}
+
+// Condition is a status condition of an object.
+type Condition struct {
+	Type               string    `json:"t"`
+	Status             string    `json:"s"`
+	Reason             string    `json:"r,omitempty"`
+	Message            string    `json:"m,omitempty"`
+	LastTransitionTime time.Time `json:"lt,omitempty"`
+}
+
+// SnapshotFromObject takes a snapshot of the status of a Flux object. The
+// revision is the last applied revision of automations, or the artifact
+// revision of sources.
+func SnapshotFromObject(obj *unstructured.Unstructured, now time.Time) Snapshot {
+	snap := Snapshot{Time: now.UTC().Truncate(time.Second)}
+
+	snap.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "lastAppliedRevision")
+	if snap.Revision == "" {
+		snap.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "artifact", "revision")
+	}
+
+	snap.ObservedGeneration, _, _ = unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	snap.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		cond := Condition{}
+		cond.Type, _, _ = unstructured.NestedString(m, "type")
+		cond.Status, _, _ = unstructured.NestedString(m, "status")
+		cond.Reason, _, _ = unstructured.NestedString(m, "reason")
+		cond.Message, _, _ = unstructured.NestedString(m, "message")
+
+		if len(cond.Message) > maxMessageLength {
+			cond.Message = cond.Message[:maxMessageLength]
+		}
+
+		if ts, _, _ := unstructured.NestedString(m, "lastTransitionTime"); ts != "" {
+			if t, err := time.Parse(time.RFC3339, ts); err == nil {
+				cond.LastTransitionTime = t.UTC()
+			}
+		}
+
+		snap.Conditions = append(snap.Conditions, cond)
+	}
+
+	return snap
+}
+
+// sameStatus returns true if the snapshots only differ by when they were
+// taken.
+func sameStatus(a, b Snapshot) bool {
+	if a.Revision != b.Revision || a.ObservedGeneration != b.ObservedGeneration || a.Suspended != b.Suspended {
+		return false
+	}
+
+	if len(a.Conditions) != len(b.Conditions) {
+		return false
+	}
+
+	for i := range a.Conditions {
+		if !a.Conditions[i].LastTransitionTime.Equal(b.Conditions[i].LastTransitionTime) {
+			return false
+		}
+
+		ac, bc := a.Conditions[i], b.Conditions[i]
+		ac.LastTransitionTime, bc.LastTransitionTime = time.Time{}, time.Time{}
+
+		if ac != bc {
+			return false
+		}
+	}
+
+	return true
+}
+
+// At returns the snapshot in effect at the time, along with up to around
+// snapshots either side of it. The snapshot is nil if the history starts
+// after the time. The history must be ordered oldest first.
+func At(history []Snapshot, t time.Time, around int) (*Snapshot, []Snapshot) {
+	if len(history) == 0 {
+		return nil, nil
+	}
+
+	// The first snapshot taken after t
+	next := sort.Search(len(history), func(i int) bool {
+		return history[i].Time.After(t)
+	})
+
+	var current *Snapshot
+
+	if next > 0 {
+		s := history[next-1]
+		current = &s
+	}
+
+	if around < 0 {
+		around = 0
+	}
+
+	start := next - 1 - around
+	if start < 0 {
+		start = 0
+	}
+
+	end := next + around
+	if end > len(history) {
+		end = len(history)
+	}
+
+	return current, history[start:end]
+}
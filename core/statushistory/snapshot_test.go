@@ -0,0 +1,123 @@
+package statushistory
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSnapshotFromObject(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Date(2022, 11, 10, 14, 5, 30, 500, time.UTC)
+
+	kustomization := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"suspend": true},
+		"status": map[string]interface{}{
+			"lastAppliedRevision": "main/abc123",
+			"observedGeneration":  int64(3),
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "Ready",
+					"status":             "False",
+					"reason":             "BuildFailed",
+					"message":            strings.Repeat("x", 1000),
+					"lastTransitionTime": "2022-11-10T14:00:00Z",
+				},
+			},
+		},
+	}}
+
+	snap := SnapshotFromObject(kustomization, now)
+	g.Expect(snap.Time).To(Equal(time.Date(2022, 11, 10, 14, 5, 30, 0, time.UTC)))
+	g.Expect(snap.Revision).To(Equal("main/abc123"))
+	g.Expect(snap.ObservedGeneration).To(Equal(int64(3)))
+	g.Expect(snap.Suspended).To(BeTrue())
+	g.Expect(snap.Conditions).To(HaveLen(1))
+	g.Expect(snap.Conditions[0].Reason).To(Equal("BuildFailed"))
+	g.Expect(snap.Conditions[0].Message).To(HaveLen(maxMessageLength))
+	g.Expect(snap.Conditions[0].LastTransitionTime).To(Equal(time.Date(2022, 11, 10, 14, 0, 0, 0, time.UTC)))
+
+	gitRepository := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"artifact": map[string]interface{}{"revision": "main/def456"},
+		},
+	}}
+
+	g.Expect(SnapshotFromObject(gitRepository, now).Revision).To(Equal("main/def456"))
+}
+
+func TestAt(t *testing.T) {
+	base := time.Date(2022, 11, 10, 14, 0, 0, 0, time.UTC)
+
+	history := []Snapshot{}
+	for i := 0; i < 5; i++ {
+		history = append(history, Snapshot{Time: base.Add(time.Duration(i) * 10 * time.Minute), Revision: string(rune('a' + i))})
+	}
+
+	revisions := func(snaps []Snapshot) string {
+		s := ""
+		for _, snap := range snaps {
+			s += snap.Revision
+		}
+
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		around  int
+		current string
+		window  string
+	}{
+		{
+			name:    "between snapshots",
+			at:      base.Add(25 * time.Minute),
+			around:  1,
+			current: "c",
+			window:  "bcd",
+		},
+		{
+			name:    "exactly at a snapshot",
+			at:      base.Add(20 * time.Minute),
+			around:  0,
+			current: "c",
+			window:  "c",
+		},
+		{
+			name:    "before the history",
+			at:      base.Add(-time.Minute),
+			around:  2,
+			current: "",
+			window:  "ab",
+		},
+		{
+			name:    "after the history",
+			at:      base.Add(time.Hour),
+			around:  2,
+			current: "e",
+			window:  "cde",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			current, window := At(history, tt.at, tt.around)
+
+			if tt.current == "" {
+				g.Expect(current).To(BeNil())
+			} else {
+				g.Expect(current).NotTo(BeNil())
+				g.Expect(current.Revision).To(Equal(tt.current))
+			}
+
+			g.Expect(revisions(window)).To(Equal(tt.window))
+		})
+	}
+}
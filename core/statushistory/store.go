@@ -0,0 +1,202 @@
+package statushistory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultCapacity is the number of snapshots kept for each object.
+	DefaultCapacity = 100
+
+	snapshotsKey = "snapshots"
+
+	clusterAnnotation   = "statushistory.weave.works/cluster"
+	kindAnnotation      = "statushistory.weave.works/kind"
+	namespaceAnnotation = "statushistory.weave.works/namespace"
+	nameAnnotation      = "statushistory.weave.works/name"
+)
+
+// Labels set on the ConfigMaps holding status history.
+var storeLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "weave-gitops",
+	"app.kubernetes.io/component":  "status-history",
+}
+
+// Store keeps the status history of each object in a ConfigMap, as a ring
+// buffer of the most recent snapshots.
+type Store struct {
+	client    client.Client
+	namespace string
+	capacity  int
+}
+
+// NewStore creates a store that keeps up to capacity snapshots per object
+// in ConfigMaps in the namespace.
+func NewStore(c client.Client, namespace string, capacity int) *Store {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+
+	return &Store{
+		client:    c,
+		namespace: namespace,
+		capacity:  capacity,
+	}
+}
+
+// Record adds the snapshot to the history of the object, unless the status
+// hasn't changed since the last snapshot.
+func (s *Store) Record(ctx context.Context, ref ObjectRef, snap Snapshot) error {
+	cm := corev1.ConfigMap{}
+
+	err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: configMapName(ref)}, &cm)
+	if apierrors.IsNotFound(err) {
+		return s.create(ctx, ref, []Snapshot{snap})
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting status history of %s: %w", refString(ref), err)
+	}
+
+	history, err := decode(&cm)
+	if err != nil {
+		return fmt.Errorf("reading status history of %s: %w", refString(ref), err)
+	}
+
+	if len(history) > 0 && sameStatus(history[len(history)-1], snap) {
+		return nil
+	}
+
+	history = append(history, snap)
+	if len(history) > s.capacity {
+		history = history[len(history)-s.capacity:]
+	}
+
+	if err := encode(&cm, history); err != nil {
+		return err
+	}
+
+	if err := s.client.Update(ctx, &cm); err != nil {
+		return fmt.Errorf("updating status history of %s: %w", refString(ref), err)
+	}
+
+	return nil
+}
+
+// History returns the recorded snapshots of the object, oldest first. It's
+// not an error for there to be no history.
+func (s *Store) History(ctx context.Context, ref ObjectRef) ([]Snapshot, error) {
+	cm := corev1.ConfigMap{}
+
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: configMapName(ref)}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("getting status history of %s: %w", refString(ref), err)
+	}
+
+	return decode(&cm)
+}
+
+// Prune deletes the history of the objects keep returns false for, so the
+// history of deleted objects doesn't pile up.
+func (s *Store) Prune(ctx context.Context, keep func(ObjectRef) bool) error {
+	list := corev1.ConfigMapList{}
+
+	if err := s.client.List(ctx, &list, client.InNamespace(s.namespace), client.MatchingLabels(storeLabels)); err != nil {
+		return fmt.Errorf("listing status history: %w", err)
+	}
+
+	for i := range list.Items {
+		cm := &list.Items[i]
+		ref := ObjectRef{
+			ClusterName: cm.Annotations[clusterAnnotation],
+			Kind:        cm.Annotations[kindAnnotation],
+			Namespace:   cm.Annotations[namespaceAnnotation],
+			Name:        cm.Annotations[nameAnnotation],
+		}
+
+		if keep(ref) {
+			continue
+		}
+
+		if err := s.client.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("deleting status history of %s: %w", refString(ref), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) create(ctx context.Context, ref ObjectRef, history []Snapshot) error {
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName(ref),
+			Namespace: s.namespace,
+			Labels:    storeLabels,
+			Annotations: map[string]string{
+				clusterAnnotation:   ref.ClusterName,
+				kindAnnotation:      ref.Kind,
+				namespaceAnnotation: ref.Namespace,
+				nameAnnotation:      ref.Name,
+			},
+		},
+	}
+
+	if err := encode(&cm, history); err != nil {
+		return err
+	}
+
+	if err := s.client.Create(ctx, &cm); err != nil {
+		return fmt.Errorf("creating status history of %s: %w", refString(ref), err)
+	}
+
+	return nil
+}
+
+func decode(cm *corev1.ConfigMap) ([]Snapshot, error) {
+	data, ok := cm.Data[snapshotsKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var history []Snapshot
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func encode(cm *corev1.ConfigMap, history []Snapshot) error {
+	b, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("encoding status history: %w", err)
+	}
+
+	cm.Data = map[string]string{snapshotsKey: string(b)}
+
+	return nil
+}
+
+// configMapName is derived from a hash of the object reference, as the
+// reference may be too long for a name.
+func configMapName(ref ObjectRef) string {
+	sum := sha256.Sum256([]byte(refString(ref)))
+
+	return "status-history-" + hex.EncodeToString(sum[:])[:16]
+}
+
+func refString(ref ObjectRef) string {
+	return fmt.Sprintf("%s/%s/%s/%s", ref.ClusterName, ref.Kind, ref.Namespace, ref.Name)
+}
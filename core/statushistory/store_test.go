@@ -0,0 +1,66 @@
+package statushistory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStoreRecord(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().Build()
+	store := NewStore(c, "flux-system", 3)
+
+	ref := ObjectRef{ClusterName: "Default", Kind: "Kustomization", Namespace: "apps", Name: "podinfo"}
+	base := time.Date(2022, 11, 10, 14, 0, 0, 0, time.UTC)
+
+	history, err := store.History(ctx, ref)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).To(BeEmpty())
+
+	g.Expect(store.Record(ctx, ref, Snapshot{Time: base, Revision: "a"})).To(Succeed())
+
+	// Unchanged statuses aren't recorded again
+	g.Expect(store.Record(ctx, ref, Snapshot{Time: base.Add(time.Minute), Revision: "a"})).To(Succeed())
+
+	for i, rev := range []string{"b", "c", "d"} {
+		g.Expect(store.Record(ctx, ref, Snapshot{Time: base.Add(time.Duration(i+2) * time.Minute), Revision: rev})).To(Succeed())
+	}
+
+	history, err = store.History(ctx, ref)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).To(Equal([]Snapshot{
+		{Time: base.Add(2 * time.Minute), Revision: "b"},
+		{Time: base.Add(3 * time.Minute), Revision: "c"},
+		{Time: base.Add(4 * time.Minute), Revision: "d"},
+	}))
+
+	cms := corev1.ConfigMapList{}
+	g.Expect(c.List(ctx, &cms, client.InNamespace("flux-system"), client.MatchingLabels(storeLabels))).To(Succeed())
+	g.Expect(cms.Items).To(HaveLen(1))
+	g.Expect(cms.Items[0].Annotations).To(HaveKeyWithValue(nameAnnotation, "podinfo"))
+}
+
+func TestStoreKeepsObjectsApart(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	store := NewStore(fake.NewClientBuilder().Build(), "flux-system", 0)
+
+	podinfo := ObjectRef{ClusterName: "Default", Kind: "Kustomization", Namespace: "apps", Name: "podinfo"}
+	other := ObjectRef{ClusterName: "leaf", Kind: "Kustomization", Namespace: "apps", Name: "podinfo"}
+
+	g.Expect(store.Record(ctx, podinfo, Snapshot{Revision: "a"})).To(Succeed())
+	g.Expect(store.Record(ctx, other, Snapshot{Revision: "b"})).To(Succeed())
+
+	history, err := store.History(ctx, podinfo)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).To(Equal([]Snapshot{{Revision: "a"}}))
+}
@@ -22,6 +22,10 @@ type CoreClient interface {
 	GetObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*GetObjectResponse, error)
 	// ListObjects gets data about primary objects.
 	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error)
+	// WatchObjects streams the changes of primary objects of some kinds
+	// across clusters, so they don't need to be listed again to be kept up
+	// to date. The current objects are sent first, as added.
+	WatchObjects(ctx context.Context, in *WatchObjectsRequest, opts ...grpc.CallOption) (Core_WatchObjectsClient, error)
 	// ListFluxRuntimeObjects lists the flux runtime deployments from a cluster.
 	ListFluxRuntimeObjects(ctx context.Context, in *ListFluxRuntimeObjectsRequest, opts ...grpc.CallOption) (*ListFluxRuntimeObjectsResponse, error)
 	ListFluxCrds(ctx context.Context, in *ListFluxCrdsRequest, opts ...grpc.CallOption) (*ListFluxCrdsResponse, error)
@@ -35,16 +39,60 @@ type CoreClient interface {
 	GetFluxNamespace(ctx context.Context, in *GetFluxNamespaceRequest, opts ...grpc.CallOption) (*GetFluxNamespaceResponse, error)
 	// ListNamespaces returns with the list of available namespaces.
 	ListNamespaces(ctx context.Context, in *ListNamespacesRequest, opts ...grpc.CallOption) (*ListNamespacesResponse, error)
-	// ListEvents returns with a list of events
+	// ListEvents returns with a list of events, the most recent first
 	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
 	// SyncResource forces a reconciliation of a Flux resource
 	SyncFluxObject(ctx context.Context, in *SyncFluxObjectRequest, opts ...grpc.CallOption) (*SyncFluxObjectResponse, error)
+	// SyncResource requests a reconciliation of a Flux object in any
+	// cluster, and of its source first if asked, and waits for it to be
+	// reconciled, returning its conditions once it is.
+	SyncResource(ctx context.Context, in *SyncResourceRequest, opts ...grpc.CallOption) (*SyncResourceResponse, error)
 	// GetVersion returns version information about the server
 	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
 	// GetFeatureFlags returns configuration information about the server
 	GetFeatureFlags(ctx context.Context, in *GetFeatureFlagsRequest, opts ...grpc.CallOption) (*GetFeatureFlagsResponse, error)
 	// ToggleSuspendResource suspends or resumes a flux object.
 	ToggleSuspendResource(ctx context.Context, in *ToggleSuspendResourceRequest, opts ...grpc.CallOption) (*ToggleSuspendResourceResponse, error)
+	// SuspendResource suspends Flux objects in any cluster, recording who
+	// suspended them in their annotations.
+	SuspendResource(ctx context.Context, in *SuspendResourceRequest, opts ...grpc.CallOption) (*SuspendResourceResponse, error)
+	// ResumeResource resumes Flux objects in any cluster, recording who
+	// resumed them in their annotations.
+	ResumeResource(ctx context.Context, in *ResumeResourceRequest, opts ...grpc.CallOption) (*ResumeResourceResponse, error)
+	// GetSessionLogs returns the logs of one or more GitOps Run sessions,
+	// interleaved and ordered by time. With follow set, it waits for new
+	// lines when there are none yet.
+	GetSessionLogs(ctx context.Context, in *GetSessionLogsRequest, opts ...grpc.CallOption) (*GetSessionLogsResponse, error)
+	// GetObjectStatusHistory returns the recorded status of an object at a
+	// point in the past, along with the snapshots around it.
+	GetObjectStatusHistory(ctx context.Context, in *GetObjectStatusHistoryRequest, opts ...grpc.CallOption) (*GetObjectStatusHistoryResponse, error)
+	// ValidateManifests checks manifests against the API versions and
+	// schemas served by a cluster, without applying them.
+	ValidateManifests(ctx context.Context, in *ValidateManifestsRequest, opts ...grpc.CallOption) (*ValidateManifestsResponse, error)
+	// GetClusterStatus returns whether the server can reach each cluster,
+	// so objects missing from unreachable clusters can be explained.
+	GetClusterStatus(ctx context.Context, in *GetClusterStatusRequest, opts ...grpc.CallOption) (*GetClusterStatusResponse, error)
+	// GetHealthScores returns the health score of each cluster, from 0 to
+	// 100, as weighed by the server's scoring model.
+	GetHealthScores(ctx context.Context, in *GetHealthScoresRequest, opts ...grpc.CallOption) (*GetHealthScoresResponse, error)
+	// GenerateTenantManifests returns the namespaces, RBAC and Flux objects
+	// onboarding a tenant, to be committed to the fleet repository.
+	GenerateTenantManifests(ctx context.Context, in *GenerateTenantManifestsRequest, opts ...grpc.CallOption) (*GenerateTenantManifestsResponse, error)
+	// AddCluster registers a leaf cluster by writing its kubeconfig to a
+	// secret the server picks clusters up from.
+	AddCluster(ctx context.Context, in *AddClusterRequest, opts ...grpc.CallOption) (*AddClusterResponse, error)
+	// RemoveCluster unregisters a leaf cluster added with AddCluster,
+	// deleting its kubeconfig secret.
+	RemoveCluster(ctx context.Context, in *RemoveClusterRequest, opts ...grpc.CallOption) (*RemoveClusterResponse, error)
+	// ListSessions lists the GitOps Run sessions in a namespace, or in all
+	// namespaces the user can list them in.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// GetSession returns a GitOps Run session.
+	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*GetSessionResponse, error)
+	// DeleteSession stops a GitOps Run session, uninstalling its vcluster.
+	// It returns once the uninstall is started, and the session's volume is
+	// removed in the background.
+	DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error)
 }
 
 type coreClient struct {
@@ -73,6 +121,38 @@ func (c *coreClient) ListObjects(ctx context.Context, in *ListObjectsRequest, op
 	return out, nil
 }
 
+func (c *coreClient) WatchObjects(ctx context.Context, in *WatchObjectsRequest, opts ...grpc.CallOption) (Core_WatchObjectsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Core_ServiceDesc.Streams[0], "/gitops_core.v1.Core/WatchObjects", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &coreWatchObjectsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Core_WatchObjectsClient interface {
+	Recv() (*WatchObjectsResponse, error)
+	grpc.ClientStream
+}
+
+type coreWatchObjectsClient struct {
+	grpc.ClientStream
+}
+
+func (x *coreWatchObjectsClient) Recv() (*WatchObjectsResponse, error) {
+	m := new(WatchObjectsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *coreClient) ListFluxRuntimeObjects(ctx context.Context, in *ListFluxRuntimeObjectsRequest, opts ...grpc.CallOption) (*ListFluxRuntimeObjectsResponse, error) {
 	out := new(ListFluxRuntimeObjectsResponse)
 	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/ListFluxRuntimeObjects", in, out, opts...)
@@ -145,6 +225,15 @@ func (c *coreClient) SyncFluxObject(ctx context.Context, in *SyncFluxObjectReque
 	return out, nil
 }
 
+func (c *coreClient) SyncResource(ctx context.Context, in *SyncResourceRequest, opts ...grpc.CallOption) (*SyncResourceResponse, error) {
+	out := new(SyncResourceResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/SyncResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *coreClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
 	out := new(GetVersionResponse)
 	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GetVersion", in, out, opts...)
@@ -172,6 +261,123 @@ func (c *coreClient) ToggleSuspendResource(ctx context.Context, in *ToggleSuspen
 	return out, nil
 }
 
+func (c *coreClient) SuspendResource(ctx context.Context, in *SuspendResourceRequest, opts ...grpc.CallOption) (*SuspendResourceResponse, error) {
+	out := new(SuspendResourceResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/SuspendResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ResumeResource(ctx context.Context, in *ResumeResourceRequest, opts ...grpc.CallOption) (*ResumeResourceResponse, error) {
+	out := new(ResumeResourceResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/ResumeResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) GetSessionLogs(ctx context.Context, in *GetSessionLogsRequest, opts ...grpc.CallOption) (*GetSessionLogsResponse, error) {
+	out := new(GetSessionLogsResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GetSessionLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) GetObjectStatusHistory(ctx context.Context, in *GetObjectStatusHistoryRequest, opts ...grpc.CallOption) (*GetObjectStatusHistoryResponse, error) {
+	out := new(GetObjectStatusHistoryResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GetObjectStatusHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ValidateManifests(ctx context.Context, in *ValidateManifestsRequest, opts ...grpc.CallOption) (*ValidateManifestsResponse, error) {
+	out := new(ValidateManifestsResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/ValidateManifests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) GetClusterStatus(ctx context.Context, in *GetClusterStatusRequest, opts ...grpc.CallOption) (*GetClusterStatusResponse, error) {
+	out := new(GetClusterStatusResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GetClusterStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) GetHealthScores(ctx context.Context, in *GetHealthScoresRequest, opts ...grpc.CallOption) (*GetHealthScoresResponse, error) {
+	out := new(GetHealthScoresResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GetHealthScores", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) GenerateTenantManifests(ctx context.Context, in *GenerateTenantManifestsRequest, opts ...grpc.CallOption) (*GenerateTenantManifestsResponse, error) {
+	out := new(GenerateTenantManifestsResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GenerateTenantManifests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) AddCluster(ctx context.Context, in *AddClusterRequest, opts ...grpc.CallOption) (*AddClusterResponse, error) {
+	out := new(AddClusterResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/AddCluster", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) RemoveCluster(ctx context.Context, in *RemoveClusterRequest, opts ...grpc.CallOption) (*RemoveClusterResponse, error) {
+	out := new(RemoveClusterResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/RemoveCluster", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*GetSessionResponse, error) {
+	out := new(GetSessionResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/GetSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coreClient) DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error) {
+	out := new(DeleteSessionResponse)
+	err := c.cc.Invoke(ctx, "/gitops_core.v1.Core/DeleteSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CoreServer is the server API for Core service.
 // All implementations must embed UnimplementedCoreServer
 // for forward compatibility
@@ -180,6 +386,10 @@ type CoreServer interface {
 	GetObject(context.Context, *GetObjectRequest) (*GetObjectResponse, error)
 	// ListObjects gets data about primary objects.
 	ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error)
+	// WatchObjects streams the changes of primary objects of some kinds
+	// across clusters, so they don't need to be listed again to be kept up
+	// to date. The current objects are sent first, as added.
+	WatchObjects(*WatchObjectsRequest, Core_WatchObjectsServer) error
 	// ListFluxRuntimeObjects lists the flux runtime deployments from a cluster.
 	ListFluxRuntimeObjects(context.Context, *ListFluxRuntimeObjectsRequest) (*ListFluxRuntimeObjectsResponse, error)
 	ListFluxCrds(context.Context, *ListFluxCrdsRequest) (*ListFluxCrdsResponse, error)
@@ -193,16 +403,60 @@ type CoreServer interface {
 	GetFluxNamespace(context.Context, *GetFluxNamespaceRequest) (*GetFluxNamespaceResponse, error)
 	// ListNamespaces returns with the list of available namespaces.
 	ListNamespaces(context.Context, *ListNamespacesRequest) (*ListNamespacesResponse, error)
-	// ListEvents returns with a list of events
+	// ListEvents returns with a list of events, the most recent first
 	ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error)
 	// SyncResource forces a reconciliation of a Flux resource
 	SyncFluxObject(context.Context, *SyncFluxObjectRequest) (*SyncFluxObjectResponse, error)
+	// SyncResource requests a reconciliation of a Flux object in any
+	// cluster, and of its source first if asked, and waits for it to be
+	// reconciled, returning its conditions once it is.
+	SyncResource(context.Context, *SyncResourceRequest) (*SyncResourceResponse, error)
 	// GetVersion returns version information about the server
 	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
 	// GetFeatureFlags returns configuration information about the server
 	GetFeatureFlags(context.Context, *GetFeatureFlagsRequest) (*GetFeatureFlagsResponse, error)
 	// ToggleSuspendResource suspends or resumes a flux object.
 	ToggleSuspendResource(context.Context, *ToggleSuspendResourceRequest) (*ToggleSuspendResourceResponse, error)
+	// SuspendResource suspends Flux objects in any cluster, recording who
+	// suspended them in their annotations.
+	SuspendResource(context.Context, *SuspendResourceRequest) (*SuspendResourceResponse, error)
+	// ResumeResource resumes Flux objects in any cluster, recording who
+	// resumed them in their annotations.
+	ResumeResource(context.Context, *ResumeResourceRequest) (*ResumeResourceResponse, error)
+	// GetSessionLogs returns the logs of one or more GitOps Run sessions,
+	// interleaved and ordered by time. With follow set, it waits for new
+	// lines when there are none yet.
+	GetSessionLogs(context.Context, *GetSessionLogsRequest) (*GetSessionLogsResponse, error)
+	// GetObjectStatusHistory returns the recorded status of an object at a
+	// point in the past, along with the snapshots around it.
+	GetObjectStatusHistory(context.Context, *GetObjectStatusHistoryRequest) (*GetObjectStatusHistoryResponse, error)
+	// ValidateManifests checks manifests against the API versions and
+	// schemas served by a cluster, without applying them.
+	ValidateManifests(context.Context, *ValidateManifestsRequest) (*ValidateManifestsResponse, error)
+	// GetClusterStatus returns whether the server can reach each cluster,
+	// so objects missing from unreachable clusters can be explained.
+	GetClusterStatus(context.Context, *GetClusterStatusRequest) (*GetClusterStatusResponse, error)
+	// GetHealthScores returns the health score of each cluster, from 0 to
+	// 100, as weighed by the server's scoring model.
+	GetHealthScores(context.Context, *GetHealthScoresRequest) (*GetHealthScoresResponse, error)
+	// GenerateTenantManifests returns the namespaces, RBAC and Flux objects
+	// onboarding a tenant, to be committed to the fleet repository.
+	GenerateTenantManifests(context.Context, *GenerateTenantManifestsRequest) (*GenerateTenantManifestsResponse, error)
+	// AddCluster registers a leaf cluster by writing its kubeconfig to a
+	// secret the server picks clusters up from.
+	AddCluster(context.Context, *AddClusterRequest) (*AddClusterResponse, error)
+	// RemoveCluster unregisters a leaf cluster added with AddCluster,
+	// deleting its kubeconfig secret.
+	RemoveCluster(context.Context, *RemoveClusterRequest) (*RemoveClusterResponse, error)
+	// ListSessions lists the GitOps Run sessions in a namespace, or in all
+	// namespaces the user can list them in.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// GetSession returns a GitOps Run session.
+	GetSession(context.Context, *GetSessionRequest) (*GetSessionResponse, error)
+	// DeleteSession stops a GitOps Run session, uninstalling its vcluster.
+	// It returns once the uninstall is started, and the session's volume is
+	// removed in the background.
+	DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error)
 	mustEmbedUnimplementedCoreServer()
 }
 
@@ -216,6 +470,9 @@ func (UnimplementedCoreServer) GetObject(context.Context, *GetObjectRequest) (*G
 func (UnimplementedCoreServer) ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListObjects not implemented")
 }
+func (UnimplementedCoreServer) WatchObjects(*WatchObjectsRequest, Core_WatchObjectsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchObjects not implemented")
+}
 func (UnimplementedCoreServer) ListFluxRuntimeObjects(context.Context, *ListFluxRuntimeObjectsRequest) (*ListFluxRuntimeObjectsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListFluxRuntimeObjects not implemented")
 }
@@ -240,6 +497,9 @@ func (UnimplementedCoreServer) ListEvents(context.Context, *ListEventsRequest) (
 func (UnimplementedCoreServer) SyncFluxObject(context.Context, *SyncFluxObjectRequest) (*SyncFluxObjectResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SyncFluxObject not implemented")
 }
+func (UnimplementedCoreServer) SyncResource(context.Context, *SyncResourceRequest) (*SyncResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncResource not implemented")
+}
 func (UnimplementedCoreServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
 }
@@ -249,6 +509,45 @@ func (UnimplementedCoreServer) GetFeatureFlags(context.Context, *GetFeatureFlags
 func (UnimplementedCoreServer) ToggleSuspendResource(context.Context, *ToggleSuspendResourceRequest) (*ToggleSuspendResourceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ToggleSuspendResource not implemented")
 }
+func (UnimplementedCoreServer) SuspendResource(context.Context, *SuspendResourceRequest) (*SuspendResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendResource not implemented")
+}
+func (UnimplementedCoreServer) ResumeResource(context.Context, *ResumeResourceRequest) (*ResumeResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeResource not implemented")
+}
+func (UnimplementedCoreServer) GetSessionLogs(context.Context, *GetSessionLogsRequest) (*GetSessionLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSessionLogs not implemented")
+}
+func (UnimplementedCoreServer) GetObjectStatusHistory(context.Context, *GetObjectStatusHistoryRequest) (*GetObjectStatusHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetObjectStatusHistory not implemented")
+}
+func (UnimplementedCoreServer) ValidateManifests(context.Context, *ValidateManifestsRequest) (*ValidateManifestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateManifests not implemented")
+}
+func (UnimplementedCoreServer) GetClusterStatus(context.Context, *GetClusterStatusRequest) (*GetClusterStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClusterStatus not implemented")
+}
+func (UnimplementedCoreServer) GetHealthScores(context.Context, *GetHealthScoresRequest) (*GetHealthScoresResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealthScores not implemented")
+}
+func (UnimplementedCoreServer) GenerateTenantManifests(context.Context, *GenerateTenantManifestsRequest) (*GenerateTenantManifestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateTenantManifests not implemented")
+}
+func (UnimplementedCoreServer) AddCluster(context.Context, *AddClusterRequest) (*AddClusterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCluster not implemented")
+}
+func (UnimplementedCoreServer) RemoveCluster(context.Context, *RemoveClusterRequest) (*RemoveClusterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveCluster not implemented")
+}
+func (UnimplementedCoreServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedCoreServer) GetSession(context.Context, *GetSessionRequest) (*GetSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSession not implemented")
+}
+func (UnimplementedCoreServer) DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSession not implemented")
+}
 func (UnimplementedCoreServer) mustEmbedUnimplementedCoreServer() {}
 
 // UnsafeCoreServer may be embedded to opt out of forward compatibility for this service.
@@ -298,6 +597,27 @@ func _Core_ListObjects_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Core_WatchObjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchObjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoreServer).WatchObjects(m, &coreWatchObjectsServer{stream})
+}
+
+type Core_WatchObjectsServer interface {
+	Send(*WatchObjectsResponse) error
+	grpc.ServerStream
+}
+
+type coreWatchObjectsServer struct {
+	grpc.ServerStream
+}
+
+func (x *coreWatchObjectsServer) Send(m *WatchObjectsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Core_ListFluxRuntimeObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListFluxRuntimeObjectsRequest)
 	if err := dec(in); err != nil {
@@ -442,6 +762,24 @@ func _Core_SyncFluxObject_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Core_SyncResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).SyncResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/SyncResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).SyncResource(ctx, req.(*SyncResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Core_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetVersionRequest)
 	if err := dec(in); err != nil {
@@ -496,6 +834,240 @@ func _Core_ToggleSuspendResource_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Core_SuspendResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).SuspendResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/SuspendResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).SuspendResource(ctx, req.(*SuspendResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ResumeResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).ResumeResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/ResumeResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).ResumeResource(ctx, req.(*ResumeResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_GetSessionLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetSessionLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/GetSessionLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetSessionLogs(ctx, req.(*GetSessionLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_GetObjectStatusHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetObjectStatusHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetObjectStatusHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/GetObjectStatusHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetObjectStatusHistory(ctx, req.(*GetObjectStatusHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ValidateManifests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateManifestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).ValidateManifests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/ValidateManifests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).ValidateManifests(ctx, req.(*ValidateManifestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_GetClusterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClusterStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetClusterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/GetClusterStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetClusterStatus(ctx, req.(*GetClusterStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_GetHealthScores_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHealthScoresRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetHealthScores(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/GetHealthScores",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetHealthScores(ctx, req.(*GetHealthScoresRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_GenerateTenantManifests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateTenantManifestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GenerateTenantManifests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/GenerateTenantManifests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GenerateTenantManifests(ctx, req.(*GenerateTenantManifestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_AddCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).AddCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/AddCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).AddCluster(ctx, req.(*AddClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_RemoveCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).RemoveCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/RemoveCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).RemoveCluster(ctx, req.(*RemoveClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).GetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/GetSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).GetSession(ctx, req.(*GetSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Core_DeleteSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoreServer).DeleteSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gitops_core.v1.Core/DeleteSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoreServer).DeleteSession(ctx, req.(*DeleteSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Core_ServiceDesc is the grpc.ServiceDesc for Core service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -543,6 +1115,10 @@ var Core_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SyncFluxObject",
 			Handler:    _Core_SyncFluxObject_Handler,
 		},
+		{
+			MethodName: "SyncResource",
+			Handler:    _Core_SyncResource_Handler,
+		},
 		{
 			MethodName: "GetVersion",
 			Handler:    _Core_GetVersion_Handler,
@@ -555,7 +1131,65 @@ var Core_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ToggleSuspendResource",
 			Handler:    _Core_ToggleSuspendResource_Handler,
 		},
+		{
+			MethodName: "SuspendResource",
+			Handler:    _Core_SuspendResource_Handler,
+		},
+		{
+			MethodName: "ResumeResource",
+			Handler:    _Core_ResumeResource_Handler,
+		},
+		{
+			MethodName: "GetSessionLogs",
+			Handler:    _Core_GetSessionLogs_Handler,
+		},
+		{
+			MethodName: "GetObjectStatusHistory",
+			Handler:    _Core_GetObjectStatusHistory_Handler,
+		},
+		{
+			MethodName: "ValidateManifests",
+			Handler:    _Core_ValidateManifests_Handler,
+		},
+		{
+			MethodName: "GetClusterStatus",
+			Handler:    _Core_GetClusterStatus_Handler,
+		},
+		{
+			MethodName: "GetHealthScores",
+			Handler:    _Core_GetHealthScores_Handler,
+		},
+		{
+			MethodName: "GenerateTenantManifests",
+			Handler:    _Core_GenerateTenantManifests_Handler,
+		},
+		{
+			MethodName: "AddCluster",
+			Handler:    _Core_AddCluster_Handler,
+		},
+		{
+			MethodName: "RemoveCluster",
+			Handler:    _Core_RemoveCluster_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _Core_ListSessions_Handler,
+		},
+		{
+			MethodName: "GetSession",
+			Handler:    _Core_GetSession_Handler,
+		},
+		{
+			MethodName: "DeleteSession",
+			Handler:    _Core_DeleteSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchObjects",
+			Handler:       _Core_WatchObjects_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/core/core.proto",
 }
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// CredentialsFileName is the file, stored next to the CLI config, that holds
+// session tokens obtained with `gitops login`.
+const CredentialsFileName = "weave-gitops-credentials.json"
+
+// Credentials maps API endpoints to the session token used to talk to them.
+type Credentials struct {
+	Tokens map[string]string `json:"tokens"`
+}
+
+// GetToken returns the stored session token for the endpoint, or an empty
+// string if the CLI has not logged in to it.
+func GetToken(endpoint string) (string, error) {
+	creds, err := readCredentials()
+	if err != nil {
+		return "", err
+	}
+
+	return creds.Tokens[endpoint], nil
+}
+
+// SaveToken stores the session token for the endpoint, replacing any token
+// that was previously stored for it.
+func SaveToken(endpoint, token string) error {
+	creds, err := readCredentials()
+	if err != nil {
+		return err
+	}
+
+	creds.Tokens[endpoint] = token
+
+	credsPath, err := getConfigPath(CredentialsFileName)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding credentials: %w", err)
+	}
+
+	// The file holds bearer tokens, so only the current user may read it.
+	if err := os.WriteFile(credsPath, data, 0600); err != nil {
+		return fmt.Errorf("error writing credentials file: %w", err)
+	}
+
+	return nil
+}
+
+func readCredentials() (*Credentials, error) {
+	creds := &Credentials{Tokens: map[string]string{}}
+
+	credsPath, err := getConfigPath(CredentialsFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(credsPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return creds, nil
+		}
+
+		return nil, fmt.Errorf("error reading credentials file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("error parsing credentials JSON: %w", err)
+	}
+
+	if creds.Tokens == nil {
+		creds.Tokens = map[string]string{}
+	}
+
+	return creds, nil
+}
@@ -77,7 +77,7 @@ func NewKubeHTTPClientWithConfig(config *rest.Config, contextName string, additi
 		}
 	}
 
-	rawClient, err := client.New(config, client.Options{
+	rawClient, err := client.NewWithWatch(config, client.Options{
 		Scheme: scheme,
 	})
 	if err != nil {
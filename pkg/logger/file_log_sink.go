@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// fileLogSink appends the session logs to a local file, a LogEntry JSON
+// object per line. The file is rotated once it would grow over maxSize
+// bytes, keeping maxBackups older files named after it with a .1, .2...
+// suffix, .1 being the newest.
+type fileLogSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileLogSink returns a sink appending the session logs to the file at
+// path.
+func NewFileLogSink(path string, maxSize int64, maxBackups int) (LogSink, error) {
+	s := &fileLogSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := s.open(os.O_APPEND); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *fileLogSink) open(flag int) error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|flag, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+func (s *fileLogSink) Write(entries []LogEntry) error {
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		data = append(data, '\n')
+
+		if s.maxSize > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+
+		n, err := s.file.Write(data)
+		s.size += int64(n)
+
+		if err != nil {
+			return fmt.Errorf("failed to write log file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *fileLogSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	backup := func(i int) string {
+		if i == 0 {
+			return s.path
+		}
+
+		return fmt.Sprintf("%s.%d", s.path, i)
+	}
+
+	// the oldest backup is overwritten
+	for i := s.maxBackups; i > 0; i-- {
+		if err := os.Rename(backup(i-1), backup(i)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+
+	return s.open(os.O_TRUNC)
+}
+
+func (s *fileLogSink) Close() error {
+	return s.file.Close()
+}
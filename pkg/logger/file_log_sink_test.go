@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestFileLogSink(t *testing.T) {
+	g := NewGomegaWithT(t)
+	start := time.Date(2022, 10, 10, 10, 10, 10, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "session.log")
+
+	lines := func(name string) []string {
+		data, err := os.ReadFile(name)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	// each line is 83 bytes long
+	sink, err := NewFileLogSink(path, 200, 1)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(sink.Write([]LogEntry{
+		{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "one"},
+		{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "two"},
+	})).To(Succeed())
+	g.Expect(lines(path)).To(HaveLen(2))
+	g.Expect(lines(path)[1]).To(ContainSubstring(`"message":"two"`))
+
+	t.Run("rotates the file once full", func(t *testing.T) {
+		g.Expect(sink.Write([]LogEntry{
+			{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "six"},
+		})).To(Succeed())
+		g.Expect(lines(path)).To(HaveLen(1))
+		g.Expect(lines(path + ".1")).To(HaveLen(2))
+	})
+
+	t.Run("keeps the newest backups", func(t *testing.T) {
+		g.Expect(sink.Write([]LogEntry{
+			{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "ten"},
+			{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "new"},
+		})).To(Succeed())
+		g.Expect(lines(path)).To(HaveLen(1))
+		g.Expect(lines(path + ".1")[0]).To(ContainSubstring(`"message":"six"`))
+		g.Expect(path + ".2").NotTo(BeAnExistingFile())
+	})
+
+	g.Expect(sink.Close()).To(Succeed())
+
+	t.Run("appends to existing files", func(t *testing.T) {
+		sink, err := NewFileLogSink(path, 200, 1)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(sink.Write([]LogEntry{
+			{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "old"},
+		})).To(Succeed())
+		g.Expect(lines(path)).To(HaveLen(2))
+		g.Expect(sink.Close()).To(Succeed())
+	})
+}
@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Kinds of the progress events, one for each method of the Logger.
+const (
+	EventKindMessage  = "message"
+	EventKindAction   = "action"
+	EventKindFailure  = "failure"
+	EventKindGenerate = "generate"
+	EventKindSuccess  = "success"
+	EventKindWaiting  = "waiting"
+	EventKindWarning  = "warning"
+)
+
+// ProgressEvent is a line written by the JSON logger: a session log line,
+// along with the kind of progress it reports.
+type ProgressEvent struct {
+	LogEntry
+	Kind string `json:"kind"`
+}
+
+// JSONLogger writes the lines as JSON progress events, one per line, so
+// they can be read by the tools GitOps Run is running in, e.g. CI pipelines.
+type JSONLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+	l   logr.Logger
+}
+
+// NewJSONLogger returns a logger writing JSON progress events to writer.
+// The lines logged with L() are written as JSON too.
+func NewJSONLogger(writer io.Writer) Logger {
+	l := &JSONLogger{
+		w:   writer,
+		enc: json.NewEncoder(writer),
+	}
+
+	l.l = jsonLogr(l)
+
+	return l
+}
+
+func (l *JSONLogger) L() logr.Logger {
+	return l.l
+}
+
+// Write writes lines already encoded as JSON, so the lines of L() aren't
+// interleaved with the events.
+func (l *JSONLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Write(p)
+}
+
+func (l *JSONLogger) putEvent(kind, level, format string, a ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// there's nowhere else to report it if the output is gone
+	_ = l.enc.Encode(ProgressEvent{
+		LogEntry: LogEntry{
+			Timestamp: time.Now(),
+			Level:     level,
+			Source:    LogSourceCLI,
+			Message:   fmt.Sprintf(format, a...),
+		},
+		Kind: kind,
+	})
+}
+
+func (l *JSONLogger) Println(format string, a ...interface{}) {
+	l.putEvent(EventKindMessage, LogLevelInfo, format, a...)
+}
+
+func (l *JSONLogger) Actionf(format string, a ...interface{}) {
+	l.putEvent(EventKindAction, LogLevelInfo, format, a...)
+}
+
+func (l *JSONLogger) Failuref(format string, a ...interface{}) {
+	l.putEvent(EventKindFailure, LogLevelError, format, a...)
+}
+
+func (l *JSONLogger) Generatef(format string, a ...interface{}) {
+	l.putEvent(EventKindGenerate, LogLevelInfo, format, a...)
+}
+
+func (l *JSONLogger) Successf(format string, a ...interface{}) {
+	l.putEvent(EventKindSuccess, LogLevelInfo, format, a...)
+}
+
+func (l *JSONLogger) Waitingf(format string, a ...interface{}) {
+	l.putEvent(EventKindWaiting, LogLevelInfo, format, a...)
+}
+
+func (l *JSONLogger) Warningf(format string, a ...interface{}) {
+	l.putEvent(EventKindWarning, LogLevelWarning, format, a...)
+}
+
+func (l *JSONLogger) Flush() error {
+	return nil
+}
+
+func (l *JSONLogger) Close() error {
+	return nil
+}
+
+func jsonLogr(w io.Writer) logr.Logger {
+	jsonEncoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:     "timestamp",
+		LevelKey:    "level",
+		MessageKey:  "message",
+		EncodeTime:  zapcore.RFC3339NanoTimeEncoder,
+		EncodeLevel: zapcore.LowercaseLevelEncoder,
+	})
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	logger := zap.New(zapcore.NewCore(jsonEncoder, zapcore.AddSync(w), level))
+
+	return zapr.NewLogger(logger.With(zap.String("source", LogSourceCLI), zap.String("kind", EventKindMessage)))
+}
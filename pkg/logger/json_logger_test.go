@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestJSONLogger(t *testing.T) {
+	t.Run("writes a progress event per line", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out bytes.Buffer
+
+		log := NewJSONLogger(&out)
+		log.Actionf("Syncing %d files", 3)
+		log.Warningf("Couldn't upload %s", "app.yaml")
+		log.Failuref("Failed")
+
+		var events []ProgressEvent
+
+		scanner := bufio.NewScanner(&out)
+		for scanner.Scan() {
+			var event ProgressEvent
+			g.Expect(json.Unmarshal(scanner.Bytes(), &event)).To(Succeed())
+			events = append(events, event)
+		}
+
+		g.Expect(events).To(HaveLen(3))
+		g.Expect(events[0].Kind).To(Equal(EventKindAction))
+		g.Expect(events[0].Level).To(Equal(LogLevelInfo))
+		g.Expect(events[0].Source).To(Equal(LogSourceCLI))
+		g.Expect(events[0].Message).To(Equal("Syncing 3 files"))
+		g.Expect(events[0].Timestamp.IsZero()).To(BeFalse())
+		g.Expect(events[1].Kind).To(Equal(EventKindWarning))
+		g.Expect(events[1].Level).To(Equal(LogLevelWarning))
+		g.Expect(events[2].Kind).To(Equal(EventKindFailure))
+		g.Expect(events[2].Level).To(Equal(LogLevelError))
+	})
+
+	t.Run("writes the lines of the logr as JSON too", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out bytes.Buffer
+
+		NewJSONLogger(&out).L().Info("reconciled", "name", "run-dev-ks")
+
+		var event map[string]interface{}
+		g.Expect(json.Unmarshal(out.Bytes(), &event)).To(Succeed())
+		g.Expect(event).To(HaveKeyWithValue("message", "reconciled"))
+		g.Expect(event).To(HaveKeyWithValue("level", LogLevelInfo))
+		g.Expect(event).To(HaveKeyWithValue("source", LogSourceCLI))
+		g.Expect(event).To(HaveKeyWithValue("kind", EventKindMessage))
+		g.Expect(event).To(HaveKeyWithValue("name", "run-dev-ks"))
+	})
+}
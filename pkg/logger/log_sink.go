@@ -0,0 +1,276 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-multierror"
+)
+
+// LogEntry is a line of the GitOps Run session logs.
+type LogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	Source    string    `json:"source"`
+	Message   string    `json:"message"`
+}
+
+// Levels of the session log lines, from the least to the most severe.
+const (
+	LogLevelInfo    = "info"
+	LogLevelWarning = "warning"
+	LogLevelError   = "error"
+)
+
+// Sources of the session log lines: GitOps Run itself, the Flux controllers
+// it waits for, and the events of the Kustomization it reconciles.
+const (
+	LogSourceCLI           = "cli"
+	LogSourceFlux          = "flux"
+	LogSourceKustomization = "kustomization"
+)
+
+// LogSink stores the session logs, e.g. in the dev bucket, in Loki or in
+// local files.
+type LogSink interface {
+	// Write stores lines, in the order they were logged.
+	Write(entries []LogEntry) error
+	// Close releases the sink once the last lines were written.
+	Close() error
+}
+
+// The lines of the session logs are buffered and written to the sinks in
+// chunks of up to logChunkSize bytes of messages, at least every
+// logFlushInterval.
+const (
+	logChunkSize     = 64 * 1024
+	logFlushInterval = time.Second
+)
+
+// SinkLogger writes to another logger and to the session log sinks.
+type SinkLogger struct {
+	source string
+	chunks *logChunks
+	log0   Logger
+}
+
+// NewSinkLogger returns a logger writing to log0 and to sinks. It must be
+// closed to write the last lines and close the sinks.
+func NewSinkLogger(log0 Logger, sinks ...LogSink) Logger {
+	chunks := newLogChunks(sinks)
+
+	go chunks.run(logFlushInterval, func(err error) {
+		log0.Failuref("failed to write session logs: %v", err)
+	})
+
+	return &SinkLogger{
+		source: LogSourceCLI,
+		chunks: chunks,
+		log0:   log0,
+	}
+}
+
+// WithLogSource returns a logger recording the session log lines of log as
+// coming from source, or log itself if it isn't a SinkLogger.
+func WithLogSource(log Logger, source string) Logger {
+	sinkLog, ok := log.(*SinkLogger)
+	if !ok {
+		return log
+	}
+
+	withSource := *sinkLog
+	withSource.source = source
+
+	return &withSource
+}
+
+func (l *SinkLogger) L() logr.Logger {
+	return l.log0.L()
+}
+
+func (l *SinkLogger) putLog(level, msg string) {
+	err := l.chunks.write(LogEntry{
+		Timestamp: time.Now(),
+		Level:     level,
+		Source:    l.source,
+		Message:   msg,
+	})
+	if err != nil {
+		l.log0.Failuref("failed to write session logs: %v", err)
+	}
+}
+
+// Flush writes the buffered lines to the sinks.
+func (l *SinkLogger) Flush() error {
+	return l.chunks.flush()
+}
+
+// Close writes the buffered lines and closes the sinks. The lines logged
+// afterwards are only written to the other logger.
+func (l *SinkLogger) Close() error {
+	return l.chunks.close()
+}
+
+func (l *SinkLogger) Println(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelInfo, msg)
+	l.log0.Println(msg)
+}
+
+func (l *SinkLogger) Actionf(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelInfo, msg)
+	l.log0.Actionf(msg)
+}
+
+func (l *SinkLogger) Failuref(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelError, msg)
+	l.log0.Failuref(msg)
+}
+
+func (l *SinkLogger) Generatef(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelInfo, msg)
+	l.log0.Generatef(msg)
+}
+
+func (l *SinkLogger) Successf(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelInfo, msg)
+	l.log0.Successf(msg)
+}
+
+func (l *SinkLogger) Waitingf(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelInfo, msg)
+	l.log0.Waitingf(msg)
+}
+
+func (l *SinkLogger) Warningf(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	l.putLog(LogLevelWarning, msg)
+	l.log0.Warningf(msg)
+}
+
+// logChunks buffers the lines of a session until they're written to the
+// sinks together. Sinks are written to outside of the lock of the buffer,
+// so slow sinks don't hold up logging, and one chunk at a time, in order.
+type logChunks struct {
+	sync.Mutex
+	writeLock sync.Mutex
+	sinks     []LogSink
+	buffer    []LogEntry
+	size      int
+	closed    bool
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+func newLogChunks(sinks []LogSink) *logChunks {
+	return &logChunks{
+		sinks:   sinks,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (c *logChunks) write(entry LogEntry) error {
+	c.Lock()
+
+	if c.closed {
+		c.Unlock()
+		return nil
+	}
+
+	c.buffer = append(c.buffer, entry)
+	c.size += len(entry.Message)
+	full := c.size >= logChunkSize
+
+	c.Unlock()
+
+	if full {
+		return c.flush()
+	}
+
+	return nil
+}
+
+// flush writes the buffered lines to the sinks. They're dropped if that
+// fails, so they don't pile up while a sink is unavailable.
+func (c *logChunks) flush() error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.Lock()
+	entries := c.buffer
+	c.buffer = nil
+	c.size = 0
+	c.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var errs *multierror.Error
+
+	for _, sink := range c.sinks {
+		if err := sink.Write(entries); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// run flushes the buffered lines every interval until the chunks are
+// closed, reporting the errors to onError.
+func (c *logChunks) run(interval time.Duration, onError func(error)) {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.flush(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// close flushes the chunks and closes the sinks the first time it's called.
+// The lines written afterwards are dropped.
+func (c *logChunks) close() error {
+	c.Lock()
+	closed := c.closed
+	c.closed = true
+	c.Unlock()
+
+	if closed {
+		return nil
+	}
+
+	close(c.stop)
+	<-c.stopped
+
+	var errs *multierror.Error
+
+	if err := c.flush(); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	for _, sink := range c.sinks {
+		if err := sink.Close(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
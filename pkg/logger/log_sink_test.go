@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeSink struct {
+	sync.Mutex
+	chunks [][]LogEntry
+	closed bool
+	err    error
+}
+
+func (s *fakeSink) Write(entries []LogEntry) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.chunks = append(s.chunks, append([]LogEntry{}, entries...))
+
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.closed = true
+
+	return nil
+}
+
+func (s *fakeSink) written() [][]LogEntry {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.chunks
+}
+
+func TestLogChunks(t *testing.T) {
+	start := time.Date(2022, 10, 10, 10, 10, 10, 0, time.UTC)
+
+	t.Run("writes the buffered lines together when flushed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		sink := &fakeSink{}
+		chunks := newLogChunks([]LogSink{sink})
+
+		g.Expect(chunks.write(LogEntry{Timestamp: start, Level: LogLevelInfo, Message: "one"})).To(Succeed())
+		g.Expect(chunks.write(LogEntry{Timestamp: start.Add(time.Second), Level: LogLevelError, Message: "two"})).To(Succeed())
+		g.Expect(sink.written()).To(BeEmpty())
+
+		g.Expect(chunks.flush()).To(Succeed())
+		g.Expect(sink.written()).To(HaveLen(1))
+		g.Expect(sink.written()[0]).To(HaveLen(2))
+		g.Expect(sink.written()[0][1].Message).To(Equal("two"))
+	})
+
+	t.Run("writes the lines once a chunk is full", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		sink := &fakeSink{}
+		chunks := newLogChunks([]LogSink{sink})
+
+		msg := strings.Repeat("x", logChunkSize/2)
+		for i := 0; i < 3; i++ {
+			g.Expect(chunks.write(LogEntry{Timestamp: start, Message: msg})).To(Succeed())
+		}
+
+		g.Expect(sink.written()).To(HaveLen(1))
+		g.Expect(sink.written()[0]).To(HaveLen(2))
+	})
+
+	t.Run("flushes periodically until closed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		sink := &fakeSink{}
+		chunks := newLogChunks([]LogSink{sink})
+
+		go chunks.run(time.Millisecond, func(err error) {})
+
+		g.Expect(chunks.write(LogEntry{Timestamp: start, Message: "one"})).To(Succeed())
+		g.Eventually(sink.written).Should(HaveLen(1))
+
+		g.Expect(chunks.close()).To(Succeed())
+		g.Expect(sink.closed).To(BeTrue())
+
+		// lines are dropped once closed
+		g.Expect(chunks.write(LogEntry{Timestamp: start.Add(time.Second), Message: "two"})).To(Succeed())
+		g.Expect(chunks.close()).To(Succeed())
+		g.Expect(sink.written()).To(HaveLen(1))
+	})
+
+	t.Run("writes to all the sinks", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		failing := &fakeSink{err: errors.New("sink unavailable")}
+		sink := &fakeSink{}
+		chunks := newLogChunks([]LogSink{failing, sink})
+
+		g.Expect(chunks.write(LogEntry{Timestamp: start, Message: "one"})).To(Succeed())
+		g.Expect(chunks.flush()).To(MatchError(ContainSubstring("sink unavailable")))
+		g.Expect(sink.written()).To(HaveLen(1))
+
+		// the lines aren't written again
+		failing.err = nil
+		g.Expect(chunks.flush()).To(Succeed())
+		g.Expect(failing.written()).To(BeEmpty())
+	})
+	t.Run("logs while the sinks are written to", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		sink := &blockingSink{writing: make(chan struct{}), release: make(chan struct{})}
+		chunks := newLogChunks([]LogSink{sink})
+
+		g.Expect(chunks.write(LogEntry{Timestamp: start, Message: "one"})).To(Succeed())
+
+		flushed := make(chan error)
+		go func() { flushed <- chunks.flush() }()
+
+		<-sink.writing
+
+		written := make(chan error)
+		go func() { written <- chunks.write(LogEntry{Timestamp: start.Add(time.Second), Message: "two"}) }()
+
+		g.Eventually(written).Should(Receive(BeNil()))
+
+		close(sink.release)
+		g.Eventually(flushed).Should(Receive(BeNil()))
+	})
+}
+
+// blockingSink blocks writes until released.
+type blockingSink struct {
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(entries []LogEntry) error {
+	s.writing <- struct{}{}
+	<-s.release
+
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	return nil
+}
+
+func TestS3LogSinkKeys(t *testing.T) {
+	g := NewGomegaWithT(t)
+	start := time.Date(2022, 10, 10, 10, 10, 10, 0, time.UTC)
+
+	objects := map[string]string{}
+	sink := &s3LogSink{id: "session", put: func(key string, data []byte) error {
+		objects[key] = string(data)
+		return nil
+	}}
+
+	g.Expect(sink.Write([]LogEntry{
+		{Timestamp: start, Message: "one"},
+		{Timestamp: start.Add(time.Second), Message: "two"},
+	})).To(Succeed())
+	g.Expect(objects).To(HaveKey("session/20221010-101010.00000.json"))
+
+	lines := strings.Split(strings.TrimSuffix(objects["session/20221010-101010.00000.json"], "\n"), "\n")
+	g.Expect(lines).To(HaveLen(2))
+	g.Expect(lines[1]).To(ContainSubstring(`"message":"two"`))
+
+	// the chunks started at the same time are named apart
+	g.Expect(sink.Write([]LogEntry{{Timestamp: start, Message: "three"}})).To(Succeed())
+	g.Expect(objects).To(HaveKey("session/20221010-101010.00001.json"))
+}
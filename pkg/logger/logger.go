@@ -18,6 +18,10 @@ type Logger interface {
 	Waitingf(format string, a ...interface{})
 	Warningf(format string, a ...interface{})
 	L() logr.Logger
+	// Flush writes the lines the logger buffered, if any.
+	Flush() error
+	// Close flushes the logger and stops buffering lines.
+	Close() error
 }
 
 type CliLogger struct {
@@ -67,6 +71,14 @@ func (l *CliLogger) Warningf(format string, a ...interface{}) {
 	l.Info("⚠️ " + fmt.Sprintf(format, a...))
 }
 
+func (l *CliLogger) Flush() error {
+	return nil
+}
+
+func (l *CliLogger) Close() error {
+	return nil
+}
+
 func defaultLogr(w io.Writer) logr.Logger {
 	//jsonEncoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
 	consoleEncoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const lokiPushPath = "/loki/api/v1/push"
+
+// lokiLogSink pushes the session logs to Loki, in a stream per level and
+// source of the lines.
+type lokiLogSink struct {
+	url     string
+	session string
+	client  *http.Client
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// NewLokiLogSink returns a sink pushing the logs of session to the Loki at
+// url, e.g. http://loki:3100. Credentials can be set in the URL.
+func NewLokiLogSink(url, session string) LogSink {
+	return &lokiLogSink{
+		url:     strings.TrimSuffix(url, "/") + lokiPushPath,
+		session: session,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *lokiLogSink) Write(entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	req := lokiPushRequest{}
+	streams := map[[2]string]*lokiStream{}
+
+	for _, entry := range entries {
+		labels := [2]string{entry.Level, entry.Source}
+
+		stream, ok := streams[labels]
+		if !ok {
+			stream = &lokiStream{
+				Stream: map[string]string{
+					"app":     "gitops-run",
+					"session": s.session,
+					"level":   entry.Level,
+					"source":  entry.Source,
+				},
+			}
+			streams[labels] = stream
+			req.Streams = append(req.Streams, stream)
+		}
+
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+			entry.Message,
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to push logs to loki: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to push logs to loki: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+func (s *lokiLogSink) Close() error {
+	return nil
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLokiLogSink(t *testing.T) {
+	g := NewGomegaWithT(t)
+	start := time.Date(2022, 10, 10, 10, 10, 10, 0, time.UTC)
+
+	var pushed lokiPushRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != lokiPushPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&pushed); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := NewLokiLogSink(srv.URL+"/", "run-main")
+
+	g.Expect(sink.Write([]LogEntry{
+		{Timestamp: start, Level: LogLevelInfo, Source: LogSourceCLI, Message: "one"},
+		{Timestamp: start.Add(time.Second), Level: LogLevelError, Source: LogSourceKustomization, Message: "two"},
+		{Timestamp: start.Add(2 * time.Second), Level: LogLevelInfo, Source: LogSourceCLI, Message: "three"},
+	})).To(Succeed())
+
+	g.Expect(pushed.Streams).To(HaveLen(2))
+	g.Expect(pushed.Streams[0].Stream).To(Equal(map[string]string{
+		"app":     "gitops-run",
+		"session": "run-main",
+		"level":   "info",
+		"source":  "cli",
+	}))
+	g.Expect(pushed.Streams[0].Values).To(Equal([][2]string{
+		{"1665396610000000000", "one"},
+		{"1665396612000000000", "three"},
+	}))
+	g.Expect(pushed.Streams[1].Stream).To(HaveKeyWithValue("source", "kustomization"))
+
+	failing := NewLokiLogSink(srv.URL+"/missing", "run-main")
+	g.Expect(failing.Write([]LogEntry{{Timestamp: start, Message: "one"}})).To(MatchError(ContainSubstring("404 Not Found")))
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/weaveworks/weave-gitops/pkg/s3"
+)
+
+const (
+	// S3LogBucketName is the dev bucket that GitOps Run session logs are written to.
+	S3LogBucketName = "gitops-run-logs"
+	// S3LogTimestampFormat is the layout of the object names within a session's
+	// prefix, each named after the time of its first log line.
+	S3LogTimestampFormat = "20060102-150405.00000"
+	// S3LogObjectSuffix is the extension of the objects, each holding
+	// LogEntry JSON objects, one per line. Sessions started by older
+	// versions wrote a plain text line per ".txt" object.
+	S3LogObjectSuffix = ".json"
+)
+
+// The object names have a resolution of 10µs.
+const s3LogKeyResolution = 10 * time.Microsecond
+
+// s3LogSink puts each chunk of the session logs in an object of the dev
+// bucket, for the dashboard to read.
+type s3LogSink struct {
+	id      string
+	put     func(key string, data []byte) error
+	lastKey time.Time
+}
+
+// NewS3LogSink returns a sink writing the logs of session id to the dev
+// bucket at endpoint.
+func NewS3LogSink(id, endpoint string, accessKey, secretKey, caCert []byte) (LogSink, error) {
+	minioClient, err := s3.NewMinioClient(endpoint, accessKey, secretKey, caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := minioClient.MakeBucket(context.Background(), S3LogBucketName, minio.MakeBucketOptions{}); err != nil {
+		return nil, err
+	}
+
+	return &s3LogSink{
+		id: id,
+		put: func(key string, data []byte) error {
+			_, err := minioClient.PutObject(context.Background(),
+				S3LogBucketName,
+				key,
+				bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+					ContentType: "application/x-ndjson",
+				})
+
+			return err
+		},
+	}, nil
+}
+
+func (s *s3LogSink) Write(entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var data bytes.Buffer
+
+	encoder := json.NewEncoder(&data)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	// chunks started within the resolution of the object names would
+	// overwrite each other
+	keyTime := entries[0].Timestamp
+	if keyTime.Sub(s.lastKey) < s3LogKeyResolution {
+		keyTime = s.lastKey.Add(s3LogKeyResolution)
+	}
+
+	s.lastKey = keyTime
+
+	key := fmt.Sprintf("%s/%s%s", s.id, keyTime.Format(S3LogTimestampFormat), S3LogObjectSuffix)
+
+	if err := s.put(key, data.Bytes()); err != nil {
+		return fmt.Errorf("failed to put logs to s3: %w", err)
+	}
+
+	return nil
+}
+
+func (s *s3LogSink) Close() error {
+	return nil
+}
@@ -19,7 +19,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kustomize/api/resource"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
@@ -111,8 +110,8 @@ func getDashboardHelmChart(log logger.Logger, ctx context.Context, kubeClient cl
 }
 
 // ReconcileDashboard reconciles the dashboard.
-func ReconcileDashboard(ctx context.Context, kubeClient client.Client, name string, namespace string, podName string, timeout time.Duration) error {
-	const interval = 3 * time.Second / 2
+func ReconcileDashboard(ctx context.Context, log logger.Logger, kubeClient client.Client, name string, namespace string, podName string, timeout time.Duration) error {
+	opts := run.WaitOptions{Interval: 3 * time.Second / 2, Timeout: timeout}
 
 	helmChartName := namespace + "-" + name
 
@@ -129,41 +128,42 @@ func ReconcileDashboard(ctx context.Context, kubeClient client.Client, name stri
 
 	var sourceRequestedAt string
 
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
+	// the chart is created by helm-controller once the release is applied
+	if err := run.Wait(ctx, log, "HelmChart "+helmChartName+" to be created", opts, func(ctx context.Context) (bool, string, error) {
 		var err error
 		sourceRequestedAt, err = run.RequestReconciliation(ctx, kubeClient,
 			namespacedName, gvk)
+		if err != nil {
+			return false, err.Error(), nil
+		}
 
-		return err == nil, nil
+		return true, "", nil
 	}); err != nil {
 		return err
 	}
 
-	// wait for the reconciliation of dashboard to be done
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		dashboard := &sourcev1.HelmChart{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Namespace: namespace,
-			Name:      helmChartName,
-		}, dashboard); err != nil {
-			return false, err
-		}
+	dashboard := &sourcev1.HelmChart{ObjectMeta: metav1.ObjectMeta{Name: helmChartName, Namespace: namespace}}
 
-		return dashboard.Status.GetLastHandledReconcileRequest() == sourceRequestedAt, nil
-	}); err != nil {
+	// wait for the reconciliation of dashboard to be done
+	if err := run.Wait(ctx, log, "HelmChart "+helmChartName+" to be reconciled", opts,
+		run.ReconciledCondition(kubeClient, dashboard, sourceRequestedAt)); err != nil {
 		return err
 	}
 
 	// wait for dashboard to be ready
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
+	if err := run.Wait(ctx, log, "dashboard pod to be ready", opts, func(ctx context.Context) (bool, string, error) {
 		namespacedName := types.NamespacedName{Namespace: namespace, Name: podName}
 
-		dashboard, _ := run.GetPodFromResourceDescription(ctx, namespacedName, "deployment", kubeClient)
-		if dashboard == nil {
-			return false, nil
+		pod, err := run.GetPodFromResourceDescription(ctx, namespacedName, "deployment", kubeClient)
+		if pod == nil {
+			if err != nil {
+				return false, err.Error(), nil
+			}
+
+			return false, "", nil
 		}
 
-		return isPodStatusConditionPresentAndEqual(dashboard.Status.Conditions, corev1.PodReady, corev1.ConditionTrue), nil
+		return isPodStatusConditionPresentAndEqual(pod.Status.Conditions, corev1.PodReady, corev1.ConditionTrue), string(pod.Status.Phase), nil
 	}); err != nil {
 		return err
 	}
@@ -7,6 +7,9 @@ import (
 	coretypes "github.com/weaveworks/weave-gitops/core/server/types"
 	"github.com/weaveworks/weave-gitops/pkg/flux"
 	"github.com/weaveworks/weave-gitops/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -53,3 +56,60 @@ func GetFluxVersion(log logger.Logger, ctx context.Context, kubeClient client.Cl
 
 	return fluxVersion, nil
 }
+
+// FluxComponent is a Flux controller GitOps Run needs, along with the CRDs of
+// the objects it creates for it.
+type FluxComponent struct {
+	// Name is the name of the component, and of its deployment, e.g.
+	// source-controller.
+	Name string
+	// CRDs are the names of the CRDs, e.g. buckets.source.toolkit.fluxcd.io.
+	CRDs []string
+}
+
+// MissingFluxComponents returns the names of the components whose controller
+// or CRDs aren't installed, e.g. when Flux was installed without them, so
+// they can be installed before their objects are created. The namespace is
+// the one of the installed controllers, where the missing ones belong, and
+// empty if there are none.
+func MissingFluxComponents(ctx context.Context, kubeClient client.Client, components []FluxComponent) (missingNames []string, namespace string, err error) {
+	deployments := &appsv1.DeploymentList{}
+
+	if err := kubeClient.List(ctx, deployments, client.MatchingLabels{
+		coretypes.PartOfLabel: "flux",
+	}); err != nil {
+		return nil, "", fmt.Errorf("error listing the Flux controllers: %w", err)
+	}
+
+	installed := map[string]bool{}
+	for _, deployment := range deployments.Items {
+		installed[deployment.Name] = true
+
+		if namespace == "" {
+			namespace = deployment.Namespace
+		}
+	}
+
+	for _, component := range components {
+		missing := !installed[component.Name]
+
+		for _, name := range component.CRDs {
+			if missing {
+				break
+			}
+
+			err := kubeClient.Get(ctx, client.ObjectKey{Name: name}, &extensionsv1.CustomResourceDefinition{})
+			if apierrors.IsNotFound(err) {
+				missing = true
+			} else if err != nil {
+				return nil, "", fmt.Errorf("error getting CRD %s: %w", name, err)
+			}
+		}
+
+		if missing {
+			missingNames = append(missingNames, component.Name)
+		}
+	}
+
+	return missingNames, namespace, nil
+}
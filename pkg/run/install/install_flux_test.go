@@ -9,9 +9,14 @@ import (
 	"github.com/weaveworks/weave-gitops/core/server"
 	coretypes "github.com/weaveworks/weave-gitops/core/server/types"
 	"github.com/weaveworks/weave-gitops/pkg/flux"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
 	"github.com/weaveworks/weave-gitops/pkg/logger"
 	"github.com/weaveworks/weave-gitops/pkg/run"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 const (
@@ -50,3 +55,30 @@ var _ = Describe("GetFluxVersion", func() {
 		Expect(fluxVersion).To(Equal(testVersion))
 	})
 })
+
+var _ = Describe("MissingFluxComponents", func() {
+	It("returns the components whose controller or CRDs are missing", func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).NotTo(HaveOccurred())
+
+		fluxLabels := map[string]string{coretypes.PartOfLabel: "flux"}
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "source-controller", Namespace: "flux-system", Labels: fluxLabels}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "helm-controller", Namespace: "flux-system", Labels: fluxLabels}},
+			// not part of Flux
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "kustomize-controller", Namespace: "default"}},
+			&extensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "buckets.source.toolkit.fluxcd.io"}},
+			&extensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "kustomizations.kustomize.toolkit.fluxcd.io"}},
+		).Build()
+
+		missing, namespace, err := MissingFluxComponents(context.Background(), kubeClient, []FluxComponent{
+			{Name: "source-controller", CRDs: []string{"buckets.source.toolkit.fluxcd.io"}},
+			{Name: "kustomize-controller", CRDs: []string{"kustomizations.kustomize.toolkit.fluxcd.io"}},
+			{Name: "helm-controller", CRDs: []string{"helmreleases.helm.toolkit.fluxcd.io"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(Equal([]string{"kustomize-controller", "helm-controller"}))
+		Expect(namespace).To(Equal("flux-system"))
+	})
+})
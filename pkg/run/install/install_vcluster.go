@@ -2,7 +2,7 @@ package install
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -35,7 +35,49 @@ func makeVClusterHelmRepository(namespace string) (*sourcev1.HelmRepository, err
 	return helmRepository, nil
 }
 
-func makeVClusterHelmRelease(name string, namespace string, command string, portForwards []string, automationKind string) (*helmv2.HelmRelease, error) {
+func makeVClusterHelmRelease(name string, namespace string, command string, portForwards []string, automationKind string, scheduling session.Scheduling) (*helmv2.HelmRelease, error) {
+	annotations := session.DefaultLogAnnotations(name)
+	annotations["run.weave.works/cli-version"] = version.Version
+	annotations["run.weave.works/port-forward"] = strings.Join(portForwards, ",")
+	annotations["run.weave.works/command"] = command
+	annotations["run.weave.works/automation-kind"] = automationKind
+	annotations["run.weave.works/namespace"] = namespace
+
+	schedulingAnnotations, err := scheduling.Annotations()
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range schedulingAnnotations {
+		annotations[k] = v
+	}
+
+	valuesMap := map[string]interface{}{
+		"labels": map[string]string{
+			"app.kubernetes.io/part-of": "gitops-run",
+		},
+		"annotations": annotations,
+	}
+
+	if len(scheduling.Resources.Requests) > 0 || len(scheduling.Resources.Limits) > 0 {
+		valuesMap["vcluster"] = map[string]interface{}{
+			"resources": scheduling.Resources,
+		}
+	}
+
+	if len(scheduling.NodeSelector) > 0 {
+		valuesMap["nodeSelector"] = scheduling.NodeSelector
+	}
+
+	if len(scheduling.Tolerations) > 0 {
+		valuesMap["tolerations"] = scheduling.Tolerations
+	}
+
+	values, err := json.Marshal(valuesMap)
+	if err != nil {
+		return nil, err
+	}
+
 	helmRelease := &helmv2.HelmRelease{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -64,32 +106,14 @@ func makeVClusterHelmRelease(name string, namespace string, command string, port
 			Upgrade: &helmv2.Upgrade{
 				CRDs: helmv2.CreateReplace,
 			},
-			Values: &apiextensions.JSON{Raw: []byte(fmt.Sprintf(`
-{
-  "labels": {
-    "app.kubernetes.io/part-of": "gitops-run"
-  },
-  "annotations": {
-    "run.weave.works/cli-version": "%s",
-    "run.weave.works/port-forward": "%s",
-    "run.weave.works/command": "%s",
-    "run.weave.works/automation-kind": "%s",
-    "run.weave.works/namespace": "%s"
-  }
-}`,
-				version.Version,
-				strings.Join(portForwards, ","),
-				command,
-				automationKind,
-				namespace,
-			))},
+			Values: &apiextensions.JSON{Raw: values},
 		},
 	}
 
 	return helmRelease, nil
 }
 
-func installVCluster(kubeClient client.Client, name string, namespace string, portForwards []string, automationKind string) error {
+func installVCluster(kubeClient client.Client, name string, namespace string, portForwards []string, automationKind string, scheduling session.Scheduling) error {
 	helmRepo, err := makeVClusterHelmRepository(namespace)
 	if err != nil {
 		return err
@@ -106,7 +130,7 @@ func installVCluster(kubeClient client.Client, name string, namespace string, po
 	args := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
 	command := strings.Join(args, " ")
 
-	helmRelease, err := makeVClusterHelmRelease(name, namespace, command, portForwards, automationKind)
+	helmRelease, err := makeVClusterHelmRelease(name, namespace, command, portForwards, automationKind, scheduling)
 	if err != nil {
 		return err
 	}
@@ -5,12 +5,15 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestMakeVClusterHelmReleaseAnnotations(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	hl, err := makeVClusterHelmRelease("name", "namespace", "command", []string{"9999", "1111"}, "automationKind")
+	hl, err := makeVClusterHelmRelease("name", "namespace", "command", []string{"9999", "1111"}, "automationKind", session.Scheduling{})
 	g.Expect(err).ToNot(HaveOccurred())
 
 	g.Expect(hl.Name).To(Equal("name"))
@@ -25,3 +28,65 @@ func TestMakeVClusterHelmReleaseAnnotations(t *testing.T) {
 	g.Expect(annotations["run.weave.works/command"]).To(Equal("command"))
 	g.Expect(annotations["run.weave.works/port-forward"]).To(Equal("9999,1111"))
 }
+
+func TestMakeVClusterHelmReleaseLogAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	hl, err := makeVClusterHelmRelease("name", "namespace", `gitops beta run --port-forward "port=8000"`, nil, "ks", session.Scheduling{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	values := map[string]interface{}{}
+	g.Expect(json.Unmarshal(hl.Spec.Values.Raw, &values)).ToNot(HaveOccurred())
+
+	annotations := values["annotations"].(map[string]interface{})
+	g.Expect(annotations["run.weave.works/command"]).To(Equal(`gitops beta run --port-forward "port=8000"`))
+	g.Expect(annotations["run.weave.works/run-id"]).To(Equal("name"))
+	g.Expect(annotations["run.weave.works/logs-bucket"]).To(Equal("gitops-run-logs"))
+	g.Expect(annotations["run.weave.works/logs-server"]).To(Equal("gitops-run/run-dev-bucket"))
+}
+
+func TestMakeVClusterHelmReleaseScheduling(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	hl, err := makeVClusterHelmRelease("name", "namespace", "command", nil, "ks", session.Scheduling{
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		},
+		NodeSelector: map[string]string{"pool": "dev"},
+		Tolerations:  []corev1.Toleration{{Key: "dev", Operator: corev1.TolerationOpExists}},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	values := map[string]interface{}{}
+	g.Expect(json.Unmarshal(hl.Spec.Values.Raw, &values)).ToNot(HaveOccurred())
+
+	g.Expect(values["vcluster"]).To(Equal(map[string]interface{}{
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{"memory": "2Gi"},
+		},
+	}))
+	g.Expect(values["nodeSelector"]).To(Equal(map[string]interface{}{"pool": "dev"}))
+	g.Expect(values["tolerations"]).To(Equal([]interface{}{
+		map[string]interface{}{"key": "dev", "operator": "Exists"},
+	}))
+
+	annotations := values["annotations"].(map[string]interface{})
+	g.Expect(annotations["run.weave.works/resources"]).To(Equal(`{"limits":{"memory":"2Gi"}}`))
+	g.Expect(annotations["run.weave.works/node-selector"]).To(Equal(`{"pool":"dev"}`))
+	g.Expect(annotations["run.weave.works/tolerations"]).To(Equal(`[{"key":"dev","operator":"Exists"}]`))
+}
+
+func TestMakeVClusterHelmReleaseWithoutScheduling(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	hl, err := makeVClusterHelmRelease("name", "namespace", "command", nil, "ks", session.Scheduling{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	values := map[string]interface{}{}
+	g.Expect(json.Unmarshal(hl.Spec.Values.Raw, &values)).ToNot(HaveOccurred())
+
+	g.Expect(values).ToNot(HaveKey("vcluster"))
+	g.Expect(values).ToNot(HaveKey("nodeSelector"))
+	g.Expect(values).ToNot(HaveKey("tolerations"))
+	g.Expect(values["annotations"]).ToNot(HaveKey("run.weave.works/resources"))
+}
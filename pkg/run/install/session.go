@@ -6,6 +6,7 @@ import (
 	"syscall"
 
 	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/run/session"
 
 	vcluster "github.com/loft-sh/vcluster/cmd/vclusterctl/cmd"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/flags"
@@ -22,10 +23,11 @@ type Session struct {
 	dashboardHashedPassword string
 	portForwards            []string
 	automationKind          string
+	scheduling              session.Scheduling
 }
 
 func (s *Session) Start() error {
-	if err := installVCluster(s.kubeClient, s.name, s.namespace, s.portForwards, s.automationKind); err != nil {
+	if err := installVCluster(s.kubeClient, s.name, s.namespace, s.portForwards, s.automationKind, s.scheduling); err != nil {
 		return err
 	}
 
@@ -68,26 +70,18 @@ func (s *Session) Connect() error {
 		sig := <-c
 		signal.Reset(sig)
 
-		thisProc := os.Getpid()
-		allProcesses, err := ps.Processes()
+		_ = signalChildren(syscall.SIGUSR1)
+	}()
 
-		if err != nil {
-			return
-		}
+	// the sync is paused and resumed by the sub-process
+	pauses := make(chan os.Signal, 1)
+	signal.Notify(pauses, syscall.SIGUSR2)
 
-		for _, proc := range allProcesses {
-			if proc.PPid() == thisProc {
-				// ok it's a child process, obtain the process object
-				procObject, err := os.FindProcess(proc.Pid())
-				if err != nil {
-					continue
-				}
-
-				// and notify it
-				if err := procObject.Signal(syscall.SIGUSR1); err != nil {
-					return
-				}
-			}
+	defer signal.Stop(pauses)
+
+	go func() {
+		for range pauses {
+			_ = signalChildren(syscall.SIGUSR2)
 		}
 	}()
 
@@ -96,6 +90,34 @@ func (s *Session) Connect() error {
 	return err
 }
 
+// signalChildren sends sig to the child processes, like the sub-process
+// running in the session.
+func signalChildren(sig os.Signal) error {
+	thisProc := os.Getpid()
+	allProcesses, err := ps.Processes()
+
+	if err != nil {
+		return err
+	}
+
+	for _, proc := range allProcesses {
+		if proc.PPid() == thisProc {
+			// ok it's a child process, obtain the process object
+			procObject, err := os.FindProcess(proc.Pid())
+			if err != nil {
+				continue
+			}
+
+			// and notify it
+			if err := procObject.Signal(sig); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (s *Session) Close() error {
 	if err := uninstallVcluster(s.kubeClient, s.name, s.namespace); err != nil {
 		return err
@@ -104,7 +126,7 @@ func (s *Session) Close() error {
 	return nil
 }
 
-func NewSession(log logger.Logger, kubeClient client.Client, name string, namespace string, portForwards []string, dashboardHashedPassword string, automationKind string) (*Session, error) {
+func NewSession(log logger.Logger, kubeClient client.Client, name string, namespace string, portForwards []string, dashboardHashedPassword string, automationKind string, scheduling session.Scheduling) (*Session, error) {
 	return &Session{
 		name:                    name,
 		namespace:               namespace,
@@ -113,5 +135,6 @@ func NewSession(log logger.Logger, kubeClient client.Client, name string, namesp
 		portForwards:            portForwards,
 		dashboardHashedPassword: dashboardHashedPassword,
 		automationKind:          automationKind,
+		scheduling:              scheduling,
 	}, nil
 }
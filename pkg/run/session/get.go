@@ -10,6 +10,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// NotFoundError is returned by Get if the session doesn't exist.
+type NotFoundError struct {
+	Name      string
+	Namespace string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("session %s/%s not found", e.Namespace, e.Name)
+}
+
 func Get(kubeClient client.Client, name string, namespace string) (*InternalSession, error) {
 	var result *InternalSession
 
@@ -19,7 +29,7 @@ func Get(kubeClient client.Client, name string, namespace string) (*InternalSess
 		Name:      name,
 	}, &statefulSet); err != nil {
 		if errors.IsNotFound(err) {
-			return nil, fmt.Errorf("session %s/%s not found", namespace, name)
+			return nil, &NotFoundError{Name: name, Namespace: namespace}
 		}
 
 		return nil, err
@@ -40,6 +50,8 @@ func Get(kubeClient client.Client, name string, namespace string) (*InternalSess
 		CliVersion:       annotations["run.weave.works/cli-version"],
 		PortForward:      strings.Split(annotations["run.weave.works/port-forward"], ","),
 		Namespace:        annotations["run.weave.works/namespace"],
+		LogSource:        logSourceFromAnnotations(statefulSet.Name, annotations),
+		Scheduling:       schedulingFromAnnotations(annotations),
 	}
 
 	return result, nil
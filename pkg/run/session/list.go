@@ -32,6 +32,8 @@ func List(kubeClient client.Client, targetNamespace string) ([]*InternalSession,
 			CliVersion:       annotations["run.weave.works/cli-version"],
 			PortForward:      strings.Split(annotations["run.weave.works/port-forward"], ","),
 			Namespace:        annotations["run.weave.works/namespace"],
+			LogSource:        logSourceFromAnnotations(s.Name, annotations),
+			Scheduling:       schedulingFromAnnotations(annotations),
 		})
 	}
 
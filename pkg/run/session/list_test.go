@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -27,10 +28,13 @@ func (m *mockList) List(_ context.Context, list client.ObjectList, _ ...client.L
 						Name:      "name",
 						Namespace: "namespace",
 						Annotations: map[string]string{
-							"run.weave.works/command":      "command",
-							"run.weave.works/cli-version":  "cli-version",
-							"run.weave.works/port-forward": "9999,1111",
-							"run.weave.works/namespace":    "flux-system",
+							"run.weave.works/command":       "command",
+							"run.weave.works/cli-version":   "cli-version",
+							"run.weave.works/port-forward":  "9999,1111",
+							"run.weave.works/namespace":     "flux-system",
+							"run.weave.works/node-selector": `{"pool":"dev"}`,
+							"run.weave.works/tolerations":   `[{"key":"dev","operator":"Exists"}]`,
+							"run.weave.works/resources":     `{"limits":{"memory":"2Gi"}}`,
 						},
 					},
 				},
@@ -53,4 +57,7 @@ func TestList(t *testing.T) {
 	g.Expect(list[0].Command).To(Equal("command"))
 	g.Expect(list[0].CliVersion).To(Equal("cli-version"))
 	g.Expect(list[0].Namespace).To(Equal("flux-system"))
+	g.Expect(list[0].Scheduling.NodeSelector).To(Equal(map[string]string{"pool": "dev"}))
+	g.Expect(list[0].Scheduling.Tolerations).To(Equal([]corev1.Toleration{{Key: "dev", Operator: corev1.TolerationOpExists}}))
+	g.Expect(list[0].Scheduling.Resources.Limits.Memory().String()).To(Equal("2Gi"))
 }
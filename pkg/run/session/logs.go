@@ -0,0 +1,76 @@
+package session
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Annotations on a session recording where its logs are written. Objects
+// are given as namespace/name.
+const (
+	RunIDAnnotation           = "run.weave.works/run-id"
+	LogsBucketAnnotation      = "run.weave.works/logs-bucket"
+	LogsServerAnnotation      = "run.weave.works/logs-server"
+	LogsCredentialsAnnotation = "run.weave.works/logs-credentials"
+	LogsCertificateAnnotation = "run.weave.works/logs-certificate"
+)
+
+// Where sessions created before the annotations were added write their
+// logs. These match the dev bucket server installed by pkg/run/watch.
+const (
+	DefaultLogsNamespace   = "gitops-run"
+	DefaultLogsBucket      = "gitops-run-logs"
+	DefaultLogsServer      = "run-dev-bucket"
+	DefaultLogsCredentials = "run-dev-bucket-credentials"
+	DefaultLogsCertificate = "dev-bucket-server-certs"
+)
+
+// LogSource is the dev bucket a session writes its logs to.
+type LogSource struct {
+	// RunID prefixes the names of the log objects
+	RunID       string
+	Bucket      string
+	Server      types.NamespacedName
+	Credentials types.NamespacedName
+	Certificate types.NamespacedName
+}
+
+// DefaultLogAnnotations returns the annotations for a session that writes
+// its logs to the default dev bucket server.
+func DefaultLogAnnotations(name string) map[string]string {
+	return map[string]string{
+		RunIDAnnotation:           name,
+		LogsBucketAnnotation:      DefaultLogsBucket,
+		LogsServerAnnotation:      DefaultLogsNamespace + "/" + DefaultLogsServer,
+		LogsCredentialsAnnotation: DefaultLogsNamespace + "/" + DefaultLogsCredentials,
+		LogsCertificateAnnotation: DefaultLogsNamespace + "/" + DefaultLogsCertificate,
+	}
+}
+
+func logSourceFromAnnotations(name string, annotations map[string]string) LogSource {
+	get := func(key, def string) string {
+		if v := annotations[key]; v != "" {
+			return v
+		}
+
+		return def
+	}
+
+	return LogSource{
+		RunID:       get(RunIDAnnotation, name),
+		Bucket:      get(LogsBucketAnnotation, DefaultLogsBucket),
+		Server:      parseNamespacedName(get(LogsServerAnnotation, DefaultLogsServer)),
+		Credentials: parseNamespacedName(get(LogsCredentialsAnnotation, DefaultLogsCredentials)),
+		Certificate: parseNamespacedName(get(LogsCertificateAnnotation, DefaultLogsCertificate)),
+	}
+}
+
+// parseNamespacedName parses namespace/name, defaulting the namespace.
+func parseNamespacedName(s string) types.NamespacedName {
+	if namespace, name, ok := strings.Cut(s, "/"); ok {
+		return types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
+	return types.NamespacedName{Namespace: DefaultLogsNamespace, Name: s}
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestLogSourceFromAnnotationsDefaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := logSourceFromAnnotations("run-123", nil)
+	g.Expect(src).To(Equal(LogSource{
+		RunID:       "run-123",
+		Bucket:      DefaultLogsBucket,
+		Server:      types.NamespacedName{Namespace: DefaultLogsNamespace, Name: DefaultLogsServer},
+		Credentials: types.NamespacedName{Namespace: DefaultLogsNamespace, Name: DefaultLogsCredentials},
+		Certificate: types.NamespacedName{Namespace: DefaultLogsNamespace, Name: DefaultLogsCertificate},
+	}))
+	g.Expect(logSourceFromAnnotations("run-123", DefaultLogAnnotations("run-123"))).To(Equal(src))
+}
+
+func TestLogSourceFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := logSourceFromAnnotations("run-123", map[string]string{
+		RunIDAnnotation:           "abc",
+		LogsBucketAnnotation:      "team-a-logs",
+		LogsServerAnnotation:      "team-a/bucket",
+		LogsCredentialsAnnotation: "team-a/bucket-credentials",
+		LogsCertificateAnnotation: "bucket-certs",
+	})
+	g.Expect(src).To(Equal(LogSource{
+		RunID:       "abc",
+		Bucket:      "team-a-logs",
+		Server:      types.NamespacedName{Namespace: "team-a", Name: "bucket"},
+		Credentials: types.NamespacedName{Namespace: "team-a", Name: "bucket-credentials"},
+		Certificate: types.NamespacedName{Namespace: DefaultLogsNamespace, Name: "bucket-certs"},
+	}))
+}
@@ -22,25 +22,45 @@ type InternalSession struct {
 	CliVersion       string
 	Command          string
 	Namespace        string
+	LogSource        LogSource
+	Scheduling       Scheduling
 }
 
+// Remove deletes a session and waits for its vcluster and volume to be gone.
 func Remove(kubeClient client.Client, session *InternalSession) error {
-	var (
-		helmRelease helmv2.HelmRelease
-		result      error
-	)
+	var result error
+
+	if err := Terminate(kubeClient, session); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if err := WaitForRemoval(kubeClient, session); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	return result
+}
+
+// Terminate deletes the HelmRelease of a session, so Flux uninstalls its
+// vcluster. It doesn't wait for the vcluster to be gone.
+func Terminate(kubeClient client.Client, session *InternalSession) error {
+	helmRelease := helmv2.HelmRelease{}
 
 	if err := kubeClient.Get(context.Background(),
 		types.NamespacedName{
 			Namespace: session.SessionNamespace,
 			Name:      session.SessionName,
 		}, &helmRelease); err != nil {
-		result = multierror.Append(result, err)
+		return err
 	}
 
-	if err := kubeClient.Delete(context.Background(), &helmRelease); err != nil {
-		result = multierror.Append(result, err)
-	}
+	return kubeClient.Delete(context.Background(), &helmRelease)
+}
+
+// WaitForRemoval waits for the vcluster of a terminated session to be
+// uninstalled, then deletes the volume it leaves behind.
+func WaitForRemoval(kubeClient client.Client, session *InternalSession) error {
+	var result error
 
 	if err := wait.Poll(2*time.Second, 5*time.Minute, func() (bool, error) {
 		instance := appsv1.StatefulSet{}
@@ -0,0 +1,134 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Annotations on a session recording how its vcluster is scheduled, as JSON.
+const (
+	ResourcesAnnotation    = "run.weave.works/resources"
+	NodeSelectorAnnotation = "run.weave.works/node-selector"
+	TolerationsAnnotation  = "run.weave.works/tolerations"
+)
+
+// Scheduling is where the vcluster of a session runs, and the resources it
+// may use, so sessions don't starve shared clusters.
+type Scheduling struct {
+	// Resources of the vcluster container.
+	Resources    corev1.ResourceRequirements
+	NodeSelector map[string]string
+	Tolerations  []corev1.Toleration
+}
+
+// Annotations returns the annotations recording the scheduling, leaving out
+// what isn't set.
+func (s Scheduling) Annotations() (map[string]string, error) {
+	annotations := map[string]string{}
+
+	set := func(key string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		annotations[key] = string(data)
+
+		return nil
+	}
+
+	if len(s.Resources.Requests) > 0 || len(s.Resources.Limits) > 0 {
+		if err := set(ResourcesAnnotation, s.Resources); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.NodeSelector) > 0 {
+		if err := set(NodeSelectorAnnotation, s.NodeSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.Tolerations) > 0 {
+		if err := set(TolerationsAnnotation, s.Tolerations); err != nil {
+			return nil, err
+		}
+	}
+
+	return annotations, nil
+}
+
+// schedulingFromAnnotations returns the scheduling recorded in annotations.
+// Invalid annotations are ignored, like missing ones.
+func schedulingFromAnnotations(annotations map[string]string) Scheduling {
+	var result Scheduling
+
+	get := func(key string, v interface{}) {
+		if data := annotations[key]; data != "" {
+			_ = json.Unmarshal([]byte(data), v)
+		}
+	}
+
+	get(ResourcesAnnotation, &result.Resources)
+	get(NodeSelectorAnnotation, &result.NodeSelector)
+	get(TolerationsAnnotation, &result.Tolerations)
+
+	return result
+}
+
+// ParseResourceList parses resource quantities by name, e.g. "cpu" to
+// "500m".
+func ParseResourceList(quantities map[string]string) (corev1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+
+	result := corev1.ResourceList{}
+
+	for name, value := range quantities {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q of resource %s: %w", value, name, err)
+		}
+
+		result[corev1.ResourceName(name)] = quantity
+	}
+
+	return result, nil
+}
+
+// ParseToleration parses a toleration of a taint like kubectl taint sets
+// it, "<key>[=<value>][:<effect>]". Without a value, any value of the key is
+// tolerated, and without an effect, any effect.
+func ParseToleration(s string) (corev1.Toleration, error) {
+	keyValue, effect, _ := strings.Cut(s, ":")
+	key, value, hasValue := strings.Cut(keyValue, "=")
+
+	if key == "" {
+		return corev1.Toleration{}, fmt.Errorf("invalid toleration %q, must be <key>[=<value>][:<effect>]", s)
+	}
+
+	result := corev1.Toleration{
+		Key:      key,
+		Operator: corev1.TolerationOpExists,
+	}
+
+	if hasValue {
+		result.Operator = corev1.TolerationOpEqual
+		result.Value = value
+	}
+
+	switch corev1.TaintEffect(effect) {
+	case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		result.Effect = corev1.TaintEffect(effect)
+	default:
+		return corev1.Toleration{}, fmt.Errorf("invalid toleration effect %q, must be either %s, %s or %s", effect,
+			corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseToleration(t *testing.T) {
+	tests := []struct {
+		toleration string
+		expected   corev1.Toleration
+	}{
+		{"dev", corev1.Toleration{Key: "dev", Operator: corev1.TolerationOpExists}},
+		{"dev:NoSchedule", corev1.Toleration{Key: "dev", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+		{"pool=dev", corev1.Toleration{Key: "pool", Operator: corev1.TolerationOpEqual, Value: "dev"}},
+		{"pool=dev:NoExecute", corev1.Toleration{Key: "pool", Operator: corev1.TolerationOpEqual, Value: "dev", Effect: corev1.TaintEffectNoExecute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.toleration, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			result, err := ParseToleration(tt.toleration)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result).To(Equal(tt.expected))
+		})
+	}
+
+	t.Run("rejects invalid tolerations", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := ParseToleration("=dev")
+		g.Expect(err).To(MatchError(ContainSubstring("must be <key>[=<value>][:<effect>]")))
+
+		_, err = ParseToleration("dev:Never")
+		g.Expect(err).To(MatchError(ContainSubstring("invalid toleration effect")))
+	})
+}
+
+func TestParseResourceList(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	result, err := ParseResourceList(map[string]string{"cpu": "500m", "memory": "1Gi"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}))
+
+	_, err = ParseResourceList(map[string]string{"cpu": "lots"})
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid quantity "lots" of resource cpu`)))
+}
+
+func TestSchedulingAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scheduling := Scheduling{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		},
+		NodeSelector: map[string]string{"pool": "dev"},
+	}
+
+	annotations, err := scheduling.Annotations()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(annotations).To(HaveLen(2))
+	g.Expect(schedulingFromAnnotations(annotations)).To(Equal(scheduling))
+
+	annotations, err = Scheduling{}.Annotations()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(annotations).To(BeEmpty())
+}
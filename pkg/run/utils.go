@@ -4,10 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os/user"
 	"strings"
-	"time"
 
-	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/weaveworks/weave-gitops/core/fluxsync"
 	"github.com/weaveworks/weave-gitops/pkg/kube"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -15,33 +15,20 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// RequestReconciliation requests a reconciliation of the object, recording
+// the user running the CLI as the one who requested it. It returns the value
+// of the request annotation.
 func RequestReconciliation(ctx context.Context, kubeClient client.Client, namespacedName types.NamespacedName, gvk schema.GroupVersionKind) (string, error) {
-	requestAt := time.Now().Format(time.RFC3339Nano)
-
-	return requestAt, retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
-		object := &metav1.PartialObjectMetadata{}
-		object.SetGroupVersionKind(gvk)
-		object.SetName(namespacedName.Name)
-		object.SetNamespace(namespacedName.Namespace)
-		if err := kubeClient.Get(ctx, namespacedName, object); err != nil {
-			return err
-		}
-		patch := client.MergeFrom(object.DeepCopy())
-		if ann := object.GetAnnotations(); ann == nil {
-			object.SetAnnotations(map[string]string{
-				meta.ReconcileRequestAnnotation: requestAt,
-			})
-		} else {
-			ann[meta.ReconcileRequestAnnotation] = requestAt
-			object.SetAnnotations(ann)
-		}
-		err = kubeClient.Patch(ctx, object, patch)
-		return err
-	})
+	trigger := fluxsync.ReconcileTrigger{Origin: fluxsync.OriginCLI}
+
+	if u, err := user.Current(); err == nil {
+		trigger.RequestedBy = u.Username
+	}
+
+	return fluxsync.RequestReconciliation(ctx, kubeClient, namespacedName, gvk, trigger)
 }
 
 // IsLocalCluster checks if it's a local cluster. See https://skaffold.dev/docs/environment/local-cluster/
@@ -98,24 +85,27 @@ func GetPodFromResourceDescription(ctx context.Context, namespacedName types.Nam
 			return nil, ErrNoPodsForService
 		}
 
-		for _, pod := range podList.Items {
-			if pod.Status.Phase == corev1.PodRunning {
-				return &pod, nil
-			}
+		if pod := firstReadyPod(podList.Items); pod != nil {
+			return pod, nil
 		}
 
-		return nil, ErrNoRunningPodsForService
+		return nil, ErrNoReadyPodsForService
 	case "deployment":
 		deployment := &appsv1.Deployment{}
 		if err := kubeClient.Get(ctx, namespacedName, deployment); err != nil {
 			return nil, fmt.Errorf("error getting deployment: %s, namespaced Name: %v", err, namespacedName)
 		}
 
+		selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector of deployment %v: %w", namespacedName, err)
+		}
+
 		// list pods of the deployment "deployment" by selector in a specific namespace using the controller-runtime client
 		podList := &corev1.PodList{}
 		if err := kubeClient.List(ctx, podList,
 			client.MatchingLabelsSelector{
-				Selector: labels.Set(deployment.Spec.Selector.MatchLabels).AsSelector(),
+				Selector: selector,
 			},
 			client.InNamespace(deployment.Namespace),
 		); err != nil {
@@ -126,14 +116,38 @@ func GetPodFromResourceDescription(ctx context.Context, namespacedName types.Nam
 			return nil, ErrNoPodsForDeployment
 		}
 
-		for _, pod := range podList.Items {
-			if pod.Status.Phase == corev1.PodRunning {
-				return &pod, nil
-			}
+		if pod := firstReadyPod(podList.Items); pod != nil {
+			return pod, nil
 		}
 
-		return nil, ErrNoRunningPodsForDeployment
+		return nil, ErrNoReadyPodsForDeployment
 	}
 
 	return nil, errors.New("unsupported spec kind")
 }
+
+// IsPodReady returns whether the pod is ready to serve, and isn't being
+// deleted.
+func IsPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func firstReadyPod(pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		if IsPodReady(&pods[i]) {
+			return &pods[i]
+		}
+	}
+
+	return nil
+}
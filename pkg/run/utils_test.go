@@ -121,6 +121,7 @@ type stateGetPodFromResourceDescription string
 const (
 	stateListReturnErr    stateGetPodFromResourceDescription = "list-return-err"
 	stateListNoRunningPod stateGetPodFromResourceDescription = "list-no-running-pod"
+	stateListNotReadyPod  stateGetPodFromResourceDescription = "list-not-ready-pod"
 	stateListZeroPod      stateGetPodFromResourceDescription = "list-zero-pod"
 	stateListHasPod       stateGetPodFromResourceDescription = "list-has-pod"
 
@@ -152,6 +153,18 @@ func (c *mockClientForGetPodFromResourceDescription) List(_ context.Context, lis
 				},
 			})
 
+		case stateListNotReadyPod:
+			podList.Items = append(podList.Items, corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod-1",
+					Namespace: listOptions.Namespace,
+				},
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+					Phase:      corev1.PodRunning,
+				},
+			})
+
 		case stateListHasPod:
 			podList.Items = append(podList.Items, corev1.Pod{
 				ObjectMeta: metav1.ObjectMeta{
@@ -159,7 +172,7 @@ func (c *mockClientForGetPodFromResourceDescription) List(_ context.Context, lis
 					Namespace: listOptions.Namespace,
 				},
 				Status: corev1.PodStatus{
-					Conditions: []corev1.PodCondition{},
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
 					Phase:      corev1.PodRunning,
 				},
 			})
@@ -296,7 +309,16 @@ var _ = Describe("GetPodFromResourceDescription", func() {
 
 		Expect(err).To(HaveOccurred())
 		Expect(pod).To(BeNil())
-		Expect(err.Error()).To(ContainSubstring("no running pods found for service"))
+		Expect(err.Error()).To(ContainSubstring("no ready pods found for service"))
+	})
+
+	It("doesn't return a pod that isn't ready", func() {
+		namespacedName := types.NamespacedName{Namespace: "ns", Name: "name"}
+
+		pod, err := GetPodFromResourceDescription(context.Background(), namespacedName, "service", &mockClientForGetPodFromResourceDescription{state: stateListNotReadyPod})
+
+		Expect(err).To(MatchError(ErrNoReadyPodsForService))
+		Expect(pod).To(BeNil())
 	})
 
 	// Deployment tests
@@ -345,6 +367,6 @@ var _ = Describe("GetPodFromResourceDescription", func() {
 
 		Expect(err).To(HaveOccurred())
 		Expect(pod).To(BeNil())
-		Expect(err.Error()).To(ContainSubstring("no running pods found for deployment"))
+		Expect(err.Error()).To(ContainSubstring("no ready pods found for deployment"))
 	})
 })
@@ -0,0 +1,296 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// progressLogPeriod is how often progress is logged while the state of what
+// is waited for doesn't change.
+const progressLogPeriod = 10 * time.Second
+
+// ErrWaitTimeout is returned when what is waited for isn't done in time.
+var ErrWaitTimeout = errors.New("timed out")
+
+// WaitCondition checks whether waiting is over. The message describes the
+// current state, e.g. the message of a Ready condition, and is reported as
+// progress while waiting. Returning an error stops waiting.
+type WaitCondition func(ctx context.Context) (done bool, message string, err error)
+
+// WaitProgress describes a wait that isn't over yet.
+type WaitProgress struct {
+	Description string
+	Elapsed     time.Duration
+	Message     string
+}
+
+type WaitOptions struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	// MaxInterval is the longest interval between checks: the interval is
+	// doubled after every check until it reaches it. By default the interval
+	// doesn't change.
+	MaxInterval time.Duration
+	// Jitter lengthens every interval by a random fraction of it, up to
+	// Jitter, so the checks of waits started together are spread out.
+	Jitter float64
+	// Changes triggers a check whenever it receives, without waiting for the
+	// interval to pass, e.g. when the object waited for changes. See
+	// WatchObjectChanges.
+	Changes <-chan struct{}
+	// OnProgress is called after every check until waiting is over. By
+	// default progress is logged whenever the message changes, and every
+	// 10s otherwise.
+	OnProgress func(WaitProgress)
+}
+
+// Wait checks condition every interval, and whenever Changes receives, until
+// it's done, it returns an error, the timeout expires or the context is
+// cancelled. The description
+// is what is waited for, e.g. "Bucket dev-bucket to be ready".
+func Wait(ctx context.Context, log logger.Logger, description string, opts WaitOptions, condition WaitCondition) error {
+	onProgress := opts.OnProgress
+	if onProgress == nil {
+		onProgress = logProgress(log)
+	}
+
+	start := time.Now()
+
+	timeout := time.NewTimer(opts.Timeout)
+	defer timeout.Stop()
+
+	interval := opts.Interval
+	changes := opts.Changes
+
+	message := ""
+
+	for {
+		done, msg, err := condition(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for %s: %w", description, err)
+		}
+
+		if done {
+			return nil
+		}
+
+		message = msg
+
+		onProgress(WaitProgress{
+			Description: description,
+			Elapsed:     time.Since(start),
+			Message:     message,
+		})
+
+		next := time.NewTimer(jitter(interval, opts.Jitter))
+
+		select {
+		case <-ctx.Done():
+			next.Stop()
+			return fmt.Errorf("stopped waiting for %s: %w", description, ctx.Err())
+		case <-timeout.C:
+			next.Stop()
+
+			if message == "" {
+				return fmt.Errorf("%w after %s waiting for %s", ErrWaitTimeout, opts.Timeout, description)
+			}
+
+			return fmt.Errorf("%w after %s waiting for %s: %s", ErrWaitTimeout, opts.Timeout, description, message)
+		case _, ok := <-changes:
+			next.Stop()
+
+			// poll only, once the watch ended
+			if !ok {
+				changes = nil
+			}
+		case <-next.C:
+		}
+
+		interval = backoff(interval, opts.MaxInterval)
+	}
+}
+
+// WaitForObject waits like Wait, also checking condition whenever obj
+// changes when kubeClient can watch it.
+func WaitForObject(ctx context.Context, log logger.Logger, kubeClient client.Client, obj client.Object, description string, opts WaitOptions, condition WaitCondition) error {
+	changes, stopWatching := WatchObjectChanges(ctx, kubeClient, obj)
+	defer stopWatching()
+
+	if changes != nil {
+		opts.Changes = changes
+	}
+
+	return Wait(ctx, log, description, opts, condition)
+}
+
+// backoff returns the interval following interval, doubled up to
+// maxInterval.
+func backoff(interval, maxInterval time.Duration) time.Duration {
+	if interval >= maxInterval {
+		return interval
+	}
+
+	if interval *= 2; interval > maxInterval {
+		return maxInterval
+	}
+
+	return interval
+}
+
+// jitter returns interval, lengthened by a random fraction of it up to
+// maxFactor.
+func jitter(interval time.Duration, maxFactor float64) time.Duration {
+	if maxFactor <= 0 {
+		return interval
+	}
+
+	return interval + time.Duration(rand.Float64()*maxFactor*float64(interval))
+}
+
+// WatchObjectChanges returns a channel receiving whenever obj changes, to be
+// set as the Changes of WaitOptions, and a function to stop watching. The
+// channel is nil when kubeClient can't watch, so only polling is done, and
+// it's closed when the watch ends.
+func WatchObjectChanges(ctx context.Context, kubeClient client.Client, obj client.Object) (<-chan struct{}, func()) {
+	if k, ok := kubeClient.(*kube.KubeHTTP); ok {
+		kubeClient = k.Client
+	}
+
+	watchingClient, ok := kubeClient.(client.WithWatch)
+	if !ok {
+		return nil, func() {}
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, kubeClient.Scheme())
+	if err != nil {
+		return nil, func() {}
+	}
+
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+	watcher, err := watchingClient.Watch(ctx, list,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{"metadata.name": obj.GetName()},
+	)
+	if err != nil {
+		return nil, func() {}
+	}
+
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		defer close(changes)
+
+		for range watcher.ResultChan() {
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes, watcher.Stop
+}
+
+// logProgress logs progress when the message changes, or once in a while so
+// users can tell the wait isn't stuck.
+func logProgress(log logger.Logger) func(WaitProgress) {
+	logged, lastMessage, lastLogged := false, "", time.Duration(0)
+
+	return func(p WaitProgress) {
+		if logged && p.Message == lastMessage && p.Elapsed-lastLogged < progressLogPeriod {
+			return
+		}
+
+		logged, lastMessage, lastLogged = true, p.Message, p.Elapsed
+		elapsed := p.Elapsed.Round(time.Second)
+
+		if p.Message == "" {
+			log.Waitingf("Waiting for %s (%s elapsed)", p.Description, elapsed)
+		} else {
+			log.Waitingf("Waiting for %s (%s elapsed): %s", p.Description, elapsed, p.Message)
+		}
+	}
+}
+
+// ObjectWithConditions is a Flux object whose reconciliation can be waited
+// for.
+type ObjectWithConditions interface {
+	client.Object
+	GetConditions() []metav1.Condition
+}
+
+// ReconciledCondition is done once obj has handled the reconciliation
+// requested at requestedAt, as returned by RequestReconciliation.
+func ReconciledCondition(kubeClient client.Client, obj ObjectWithConditions, requestedAt string) WaitCondition {
+	return func(ctx context.Context) (bool, string, error) {
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return false, "", err
+		}
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return false, "", err
+		}
+
+		handledAt, _, _ := unstructured.NestedString(content, "status", "lastHandledReconcileAt")
+
+		return handledAt == requestedAt, conditionMessage(obj, meta.ReadyCondition), nil
+	}
+}
+
+// StatusCondition is done once the condition of obj is true.
+func StatusCondition(kubeClient client.Client, obj ObjectWithConditions, conditionType string) WaitCondition {
+	return func(ctx context.Context) (bool, string, error) {
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return false, "", err
+		}
+
+		return apimeta.IsStatusConditionTrue(obj.GetConditions(), conditionType), conditionMessage(obj, conditionType), nil
+	}
+}
+
+// DeletedCondition is done once obj is deleted, reporting the finalizers it
+// waits for.
+func DeletedCondition(kubeClient client.Client, obj client.Object) WaitCondition {
+	return func(ctx context.Context) (bool, string, error) {
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, "", nil
+			}
+
+			return false, "", err
+		}
+
+		if finalizers := obj.GetFinalizers(); len(finalizers) > 0 {
+			return false, "finalized by " + strings.Join(finalizers, ", "), nil
+		}
+
+		return false, "", nil
+	}
+}
+
+func conditionMessage(obj ObjectWithConditions, conditionType string) string {
+	cond := apimeta.FindStatusCondition(obj.GetConditions(), conditionType)
+	if cond == nil {
+		return ""
+	}
+
+	return cond.Message
+}
@@ -0,0 +1,169 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Wait", func() {
+	var (
+		ctx  context.Context
+		log  logger.Logger
+		opts WaitOptions
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		log = logger.NewCLILogger(GinkgoWriter)
+		opts = WaitOptions{Interval: time.Millisecond, Timeout: time.Second}
+	})
+
+	It("reports progress until the condition is done", func() {
+		checks := 0
+		progress := []WaitProgress{}
+		opts.OnProgress = func(p WaitProgress) { progress = append(progress, p) }
+
+		err := Wait(ctx, log, "the test", opts, func(ctx context.Context) (bool, string, error) {
+			checks++
+			return checks == 3, "check done", nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(progress).To(HaveLen(2))
+		Expect(progress[1].Description).To(Equal("the test"))
+		Expect(progress[1].Message).To(Equal("check done"))
+		Expect(progress[1].Elapsed).To(BeNumerically(">", progress[0].Elapsed))
+	})
+
+	It("stops on errors", func() {
+		err := Wait(ctx, log, "the test", opts, func(ctx context.Context) (bool, string, error) {
+			return false, "", errors.New("broken")
+		})
+		Expect(err).To(MatchError("waiting for the test: broken"))
+	})
+
+	It("times out with the last message", func() {
+		opts.Timeout = 10 * time.Millisecond
+
+		err := Wait(ctx, log, "the test", opts, func(ctx context.Context) (bool, string, error) {
+			return false, "still going", nil
+		})
+		Expect(errors.Is(err, ErrWaitTimeout)).To(BeTrue())
+		Expect(err).To(MatchError("timed out after 10ms waiting for the test: still going"))
+	})
+
+	It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(ctx)
+
+		err := Wait(ctx, log, "the test", opts, func(ctx context.Context) (bool, string, error) {
+			cancel()
+			return false, "", nil
+		})
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+	It("checks as soon as there are changes", func() {
+		opts.Interval = time.Hour
+		changes := make(chan struct{}, 1)
+		opts.Changes = changes
+
+		checks := 0
+
+		err := Wait(ctx, log, "the test", opts, func(ctx context.Context) (bool, string, error) {
+			checks++
+			changes <- struct{}{}
+
+			return checks == 3, "", nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("polls once the changes stop", func() {
+		changes := make(chan struct{})
+		close(changes)
+		opts.Changes = changes
+
+		checks := 0
+
+		err := Wait(ctx, log, "the test", opts, func(ctx context.Context) (bool, string, error) {
+			checks++
+			return checks == 3, "", nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("backs off up to the max interval", func() {
+		Expect(backoff(time.Second, 0)).To(Equal(time.Second))
+		Expect(backoff(time.Second, 3*time.Second)).To(Equal(2 * time.Second))
+		Expect(backoff(2*time.Second, 3*time.Second)).To(Equal(3 * time.Second))
+		Expect(backoff(3*time.Second, 3*time.Second)).To(Equal(3 * time.Second))
+	})
+
+	It("lengthens the intervals by up to the jitter", func() {
+		Expect(jitter(time.Second, 0)).To(Equal(time.Second))
+
+		for i := 0; i < 10; i++ {
+			Expect(jitter(time.Second, 0.5)).To(And(
+				BeNumerically(">=", time.Second),
+				BeNumerically("<=", 3*time.Second/2),
+			))
+		}
+	})
+
+	It("watches the changes of objects", func() {
+		scheme := runtime.NewScheme()
+		Expect(sourcev1.AddToScheme(scheme)).To(Succeed())
+
+		bucket := &sourcev1.Bucket{ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"}}
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bucket).Build()
+
+		changes, stop := WatchObjectChanges(ctx, kubeClient, bucket)
+		Expect(changes).NotTo(BeNil())
+
+		bucket.Spec.BucketName = "changed"
+		Expect(kubeClient.Update(ctx, bucket)).To(Succeed())
+		Eventually(changes).Should(Receive())
+
+		stop()
+		Eventually(changes).Should(BeClosed())
+	})
+
+	It("waits for Flux objects to be reconciled and ready", func() {
+		scheme := runtime.NewScheme()
+		Expect(sourcev1.AddToScheme(scheme)).To(Succeed())
+
+		bucket := &sourcev1.Bucket{ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"}}
+		bucket.Status.LastHandledReconcileAt = "now"
+		bucket.Status.Conditions = []metav1.Condition{{
+			Type:    meta.ReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Message: "fetching",
+		}}
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bucket).Build()
+
+		done, message, err := ReconciledCondition(kubeClient, &sourcev1.Bucket{ObjectMeta: bucket.ObjectMeta}, "now")(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(BeTrue())
+		Expect(message).To(Equal("fetching"))
+
+		done, _, err = ReconciledCondition(kubeClient, &sourcev1.Bucket{ObjectMeta: bucket.ObjectMeta}, "later")(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(BeFalse())
+
+		done, message, err = StatusCondition(kubeClient, &sourcev1.Bucket{ObjectMeta: bucket.ObjectMeta}, meta.ReadyCondition)(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(BeFalse())
+		Expect(message).To(Equal("fetching"))
+	})
+})
@@ -0,0 +1,180 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/hashicorp/go-multierror"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/run"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// RunIDAnnotation is the annotation of the objects created by GitOps Run,
+// recording the session that created them.
+const RunIDAnnotation = "metadata.weave.works/run-id"
+
+// CleanupOptions are how the GitOps Run objects are deleted.
+type CleanupOptions struct {
+	// Wait is how the objects are waited for to be deleted, as the Flux
+	// controllers finalize them.
+	Wait run.WaitOptions
+	// Force removes the finalizers of the objects that aren't deleted in
+	// time, e.g. when the Flux controllers are gone, so they're not left
+	// behind.
+	Force bool
+}
+
+// deleteObject deletes obj and waits for it to be gone.
+func deleteObject(ctx context.Context, log logger.Logger, kubeClient client.Client, obj client.Object, opts CleanupOptions) error {
+	kind := "object"
+	if gvk, err := apiutil.GVKForObject(obj, kubeClient.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+
+	name := obj.GetName()
+
+	log.Actionf("Deleting %s %s ...", kind, name)
+
+	if err := kubeClient.Delete(ctx, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Successf("%s %s was already deleted", kind, name)
+			return nil
+		}
+
+		log.Failuref("Error deleting %s %s: %v", kind, name, err)
+
+		return fmt.Errorf("error deleting %s %s: %w", kind, name, err)
+	}
+
+	err := run.WaitForObject(ctx, log, kubeClient, obj, kind+" "+name+" to be deleted", opts.Wait, run.DeletedCondition(kubeClient, obj))
+
+	switch {
+	case err == nil:
+		log.Successf("Deleted %s %s", kind, name)
+		return nil
+	case !errors.Is(err, run.ErrWaitTimeout) || !opts.Force:
+		log.Failuref("%s %s isn't deleted: %v", kind, name, err)
+		return fmt.Errorf("%s %s isn't deleted: %w", kind, name, err)
+	}
+
+	log.Warningf("%s %s isn't deleted in time, removing its finalizers ...", kind, name)
+
+	if err := removeFinalizers(ctx, kubeClient, obj); err != nil {
+		log.Failuref("Error removing the finalizers of %s %s: %v", kind, name, err)
+		return fmt.Errorf("error removing the finalizers of %s %s: %w", kind, name, err)
+	}
+
+	log.Successf("Deleted %s %s", kind, name)
+
+	return nil
+}
+
+// removeFinalizers removes the finalizers of obj, so it's deleted without
+// being finalized.
+func removeFinalizers(ctx context.Context, kubeClient client.Client, obj client.Object) error {
+	err := kubeClient.Patch(ctx, obj, client.RawPatch(types.MergePatchType, []byte(`{"metadata":{"finalizers":null}}`)))
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// FindLeftovers finds the objects GitOps Run left behind in namespace, or in
+// all the namespaces when it's empty, e.g. when it was killed before cleaning
+// up. They're found by their run-id annotation, matching runID when it's set.
+// The dependents come first, so they're deleted first.
+func FindLeftovers(ctx context.Context, kubeClient client.Client, namespace, runID string) ([]client.Object, error) {
+	var listOpts []client.ListOption
+	if namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+
+	matches := func(obj metav1.Object) bool {
+		id, ok := obj.GetAnnotations()[RunIDAnnotation]
+		return ok && (runID == "" || id == runID)
+	}
+
+	var result []client.Object
+
+	kss := &kustomizev1.KustomizationList{}
+	if err := kubeClient.List(ctx, kss, listOpts...); err != nil {
+		return nil, fmt.Errorf("error listing Kustomizations: %w", err)
+	}
+
+	for i := len(kss.Items) - 1; i >= 0; i-- {
+		if matches(&kss.Items[i]) {
+			result = append(result, &kss.Items[i])
+		}
+	}
+
+	helmReleases := &helmv2.HelmReleaseList{}
+	if err := kubeClient.List(ctx, helmReleases, listOpts...); err != nil {
+		return nil, fmt.Errorf("error listing HelmReleases: %w", err)
+	}
+
+	for i := range helmReleases.Items {
+		if matches(&helmReleases.Items[i]) {
+			result = append(result, &helmReleases.Items[i])
+		}
+	}
+
+	buckets := &sourcev1.BucketList{}
+	if err := kubeClient.List(ctx, buckets, listOpts...); err != nil {
+		return nil, fmt.Errorf("error listing Buckets: %w", err)
+	}
+
+	for i := range buckets.Items {
+		bucket := &buckets.Items[i]
+		if !matches(bucket) {
+			continue
+		}
+
+		result = append(result, bucket)
+
+		// the credentials of the dev bucket
+		if bucket.Spec.SecretRef != nil {
+			result = append(result, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:      bucket.Spec.SecretRef.Name,
+				Namespace: bucket.Namespace,
+			}})
+		}
+	}
+
+	ociRepositories := &sourcev1.OCIRepositoryList{}
+	if err := kubeClient.List(ctx, ociRepositories, listOpts...); err != nil {
+		return nil, fmt.Errorf("error listing OCIRepositories: %w", err)
+	}
+
+	for i := range ociRepositories.Items {
+		if matches(&ociRepositories.Items[i]) {
+			result = append(result, &ociRepositories.Items[i])
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteObjects deletes the objects in order, e.g. the leftovers found by
+// FindLeftovers, going on when some can't be deleted.
+func DeleteObjects(ctx context.Context, log logger.Logger, kubeClient client.Client, objects []client.Object, opts CleanupOptions) error {
+	var failures *multierror.Error
+
+	for _, obj := range objects {
+		if err := deleteObject(ctx, log, kubeClient, obj, opts); err != nil {
+			failures = multierror.Append(failures, err)
+		}
+	}
+
+	return failures.ErrorOrNil()
+}
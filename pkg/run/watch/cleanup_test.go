@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/run"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("FindLeftovers", func() {
+	It("finds the objects annotated with a run id, dependents first", func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		annotated := func(runID string) metav1.ObjectMeta {
+			return metav1.ObjectMeta{Annotations: map[string]string{RunIDAnnotation: runID}}
+		}
+
+		ks := &kustomizev1.Kustomization{ObjectMeta: annotated("run-1")}
+		ks.Name, ks.Namespace = "run-dev-ks", "flux-system"
+
+		otherKs := &kustomizev1.Kustomization{ObjectMeta: annotated("run-2")}
+		otherKs.Name, otherKs.Namespace = "run-dev-ks", "dev"
+
+		bucket := &sourcev1.Bucket{ObjectMeta: annotated("run-1"), Spec: sourcev1.BucketSpec{
+			SecretRef: &meta.LocalObjectReference{Name: "run-dev-bucket-credentials"},
+		}}
+		bucket.Name, bucket.Namespace = "run-dev-bucket", "flux-system"
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			ks, otherKs, bucket,
+			&kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: "flux-system", Namespace: "flux-system"}},
+		).Build()
+
+		names := func(objects []client.Object) []string {
+			var result []string
+			for _, obj := range objects {
+				result = append(result, obj.GetNamespace()+"/"+obj.GetName())
+			}
+
+			return result
+		}
+
+		leftovers, err := FindLeftovers(context.Background(), kubeClient, "flux-system", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(leftovers)).To(Equal([]string{
+			"flux-system/run-dev-ks",
+			"flux-system/run-dev-bucket",
+			"flux-system/run-dev-bucket-credentials",
+		}))
+		Expect(leftovers[2]).To(BeAssignableToTypeOf(&corev1.Secret{}))
+
+		leftovers, err = FindLeftovers(context.Background(), kubeClient, "", "run-2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(leftovers)).To(Equal([]string{"dev/run-dev-ks"}))
+	})
+})
+
+var _ = Describe("DeleteObjects", func() {
+	var (
+		kubeClient client.Client
+		ks         *kustomizev1.Kustomization
+		opts       CleanupOptions
+	)
+
+	BeforeEach(func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		// the finalizer of a kustomize-controller that's gone
+		ks = &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{
+			Name:       "run-dev-ks",
+			Namespace:  "flux-system",
+			Finalizers: []string{"finalizers.fluxcd.io"},
+		}}
+
+		kubeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(ks).Build()
+
+		opts = CleanupOptions{Wait: run.WaitOptions{Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond}}
+	})
+
+	It("fails when the objects aren't finalized in time", func() {
+		err := DeleteObjects(context.Background(), logger.NewCLILogger(io.Discard), kubeClient, []client.Object{ks}, opts)
+		Expect(err).To(MatchError(ContainSubstring("Kustomization run-dev-ks isn't deleted")))
+	})
+
+	It("removes the finalizers when forced", func() {
+		opts.Force = true
+
+		Expect(DeleteObjects(context.Background(), logger.NewCLILogger(io.Discard), kubeClient, []client.Object{ks}, opts)).To(Succeed())
+
+		err := kubeClient.Get(context.Background(), client.ObjectKeyFromObject(ks), &kustomizev1.Kustomization{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
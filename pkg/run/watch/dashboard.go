@@ -14,11 +14,10 @@ import (
 // EnablePortForwardingForDashboard enables port forwarding for the GitOps Dashboard.
 func EnablePortForwardingForDashboard(ctx context.Context, log logger.Logger, kubeClient client.Client, config *rest.Config, namespace string, podName string, dashboardPort string) (func(), error) {
 	specMap := &PortForwardSpec{
-		Namespace:     namespace,
-		Name:          podName,
-		Kind:          "deployment",
-		HostPort:      dashboardPort,
-		ContainerPort: server.DefaultPort,
+		Namespace: namespace,
+		Name:      podName,
+		Kind:      "deployment",
+		Ports:     []PortMapping{{HostPort: dashboardPort, ContainerPort: server.DefaultPort}},
 	}
 	// get pod from specMap
 	namespacedName := types.NamespacedName{Namespace: specMap.Namespace, Name: specMap.Name}
@@ -0,0 +1,90 @@
+package watch
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer coalesces the file change events happening in quick succession,
+// e.g. when an editor saves several files, so they're synced at once.
+type Debouncer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending uint64
+	last    time.Time
+	paused  bool
+}
+
+// NewDebouncer returns a Debouncer whose batches of events are complete once
+// no event happened for window. It starts with an event pending, so the
+// files are synced a first time.
+func NewDebouncer(window time.Duration) *Debouncer {
+	return &Debouncer{
+		window:  window,
+		pending: 1,
+	}
+}
+
+// Add records an event happening at now.
+func (d *Debouncer) Add(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pending++
+	d.last = now
+}
+
+// Pause keeps the events pending until Resume, e.g. while files are being
+// refactored. It returns false if the debouncer was already paused.
+func (d *Debouncer) Pause() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.paused {
+		return false
+	}
+
+	d.paused = true
+
+	return true
+}
+
+// Resume lets the events pending since Pause be ready. It returns false if
+// the debouncer wasn't paused.
+func (d *Debouncer) Resume() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.paused {
+		return false
+	}
+
+	d.paused = false
+
+	return true
+}
+
+// Paused returns whether the debouncer is paused.
+func (d *Debouncer) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.paused
+}
+
+// Ready returns how many events are pending and whether their batch is
+// complete at now. Once it is, the events aren't pending anymore. While the
+// debouncer is paused, no batch is complete.
+func (d *Debouncer) Ready(now time.Time) (uint64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.paused || d.pending == 0 || now.Sub(d.last) < d.window {
+		return d.pending, false
+	}
+
+	pending := d.pending
+	d.pending = 0
+
+	return pending, true
+}
@@ -0,0 +1,57 @@
+package watch
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Debouncer", func() {
+	It("is ready at first to sync the files", func() {
+		d := NewDebouncer(time.Second)
+
+		count, ready := d.Ready(time.Now())
+		Expect(ready).To(BeTrue())
+		Expect(count).To(Equal(uint64(1)))
+
+		_, ready = d.Ready(time.Now())
+		Expect(ready).To(BeFalse())
+	})
+
+	It("waits for the window without events to pass", func() {
+		d := NewDebouncer(time.Second)
+		d.Ready(time.Now())
+
+		start := time.Now()
+		d.Add(start)
+		d.Add(start.Add(800 * time.Millisecond))
+
+		_, ready := d.Ready(start.Add(time.Second))
+		Expect(ready).To(BeFalse())
+
+		count, ready := d.Ready(start.Add(1800 * time.Millisecond))
+		Expect(ready).To(BeTrue())
+		Expect(count).To(Equal(uint64(2)))
+	})
+
+	It("keeps the events pending while paused", func() {
+		d := NewDebouncer(time.Second)
+		d.Ready(time.Now())
+
+		start := time.Now()
+		Expect(d.Pause()).To(BeTrue())
+		Expect(d.Pause()).To(BeFalse())
+		d.Add(start)
+
+		_, ready := d.Ready(start.Add(time.Minute))
+		Expect(ready).To(BeFalse())
+
+		Expect(d.Resume()).To(BeTrue())
+		Expect(d.Resume()).To(BeFalse())
+
+		count, ready := d.Ready(start.Add(time.Minute))
+		Expect(ready).To(BeTrue())
+		Expect(count).To(Equal(uint64(1)))
+	})
+})
@@ -0,0 +1,184 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxEventsPerObject is how many of the latest events of each failing
+	// object are shown.
+	maxEventsPerObject = 5
+	// maxControllerLogLines is how many of the latest lines of the
+	// kustomize-controller logs about a Kustomization are shown, out of the
+	// controllerLogTailLines it last logged.
+	maxControllerLogLines  = 10
+	controllerLogTailLines = 500
+)
+
+// showDiagnostics shows why the Kustomization ksName isn't healthy: the
+// events of its failing objects, and what the kustomize-controller logged
+// about it. The diagnostics are best effort, so only warnings are shown when
+// they can't be found.
+func showDiagnostics(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, namespace, ksName string, failing []unstructured.Unstructured) {
+	ksLog := logger.WithLogSource(log, logger.LogSourceKustomization)
+	fluxLog := logger.WithLogSource(log, logger.LogSourceFlux)
+
+	events, err := findEventMessages(ctx, kubeClient, failing, maxEventsPerObject)
+	if err != nil {
+		ksLog.Warningf("Couldn't get the events of the failing objects: %v", err)
+	}
+
+	for _, msg := range events {
+		ksLog.Warningf("%s", msg)
+	}
+
+	if cfg == nil {
+		return
+	}
+
+	lines, err := findControllerLogs(ctx, cfg, namespace, ksName)
+	if err != nil {
+		fluxLog.Warningf("Couldn't get the logs of the kustomize-controller: %v", err)
+		return
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	fluxLog.Println("Latest logs of the kustomize-controller about Kustomization %s:", ksName)
+
+	for _, line := range lines {
+		fluxLog.Println("%s", line)
+	}
+}
+
+// findEventMessages finds the latest events of objects, up to maxPerObject
+// each.
+func findEventMessages(ctx context.Context, kubeClient client.Client, objects []unstructured.Unstructured, maxPerObject int) ([]string, error) {
+	eventsByNamespace := map[string][]corev1.Event{}
+
+	var messages []string
+
+	for _, obj := range objects {
+		events, ok := eventsByNamespace[obj.GetNamespace()]
+		if !ok {
+			eventList := &corev1.EventList{}
+			if err := kubeClient.List(ctx, eventList, client.InNamespace(obj.GetNamespace())); err != nil {
+				return nil, err
+			}
+
+			events = eventList.Items
+			eventsByNamespace[obj.GetNamespace()] = events
+		}
+
+		var objEvents []corev1.Event
+
+		for _, event := range events {
+			if event.InvolvedObject.Kind == obj.GetKind() && event.InvolvedObject.Name == obj.GetName() {
+				objEvents = append(objEvents, event)
+			}
+		}
+
+		sort.SliceStable(objEvents, func(i, j int) bool {
+			return eventTime(objEvents[i]).Before(eventTime(objEvents[j]))
+		})
+
+		if len(objEvents) > maxPerObject {
+			objEvents = objEvents[len(objEvents)-maxPerObject:]
+		}
+
+		for _, event := range objEvents {
+			msg := fmt.Sprintf("%s %s/%s: %s %s: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), event.Type, event.Reason, strings.TrimSpace(event.Message))
+			if event.Count > 1 {
+				msg += fmt.Sprintf(" (x%d)", event.Count)
+			}
+
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+// eventTime returns when the event last happened.
+func eventTime(event corev1.Event) *metav1.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return &event.LastTimestamp
+	case !event.EventTime.IsZero():
+		return &metav1.Time{Time: event.EventTime.Time}
+	default:
+		return &event.FirstTimestamp
+	}
+}
+
+// findControllerLogs finds the latest lines the kustomize-controller in
+// namespace logged about the Kustomization ksName.
+func findControllerLogs(ctx context.Context, cfg *rest.Config, namespace, ksName string) ([]string, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=kustomize-controller",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no kustomize-controller pods in namespace %s", namespace)
+	}
+
+	tailLines := int64(controllerLogTailLines)
+
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{
+		TailLines: &tailLines,
+	}).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	return filterControllerLogs(stream, ksName, maxControllerLogLines)
+}
+
+// filterControllerLogs returns the last maxLines lines of the controller
+// logs mentioning the Kustomization ksName.
+func filterControllerLogs(r io.Reader, ksName string, maxLines int) ([]string, error) {
+	// the controller logs the name of the object it reconciles as a JSON
+	// field
+	needle := fmt.Sprintf("%q", ksName)
+
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, needle) {
+			continue
+		}
+
+		lines = append(lines, line)
+		if len(lines) > maxLines {
+			lines = lines[1:]
+		}
+	}
+
+	return lines, scanner.Err()
+}
@@ -0,0 +1,74 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("findEventMessages", func() {
+	It("returns the latest events of the failing objects", func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		start := time.Date(2022, 10, 10, 10, 10, 10, 0, time.UTC)
+
+		event := func(name, involved, reason string, at time.Duration, count int32) *corev1.Event {
+			return &corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "dev"},
+				InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: involved, Namespace: "dev"},
+				Type:           corev1.EventTypeWarning,
+				Reason:         reason,
+				Message:        reason + " message\n",
+				LastTimestamp:  metav1.NewTime(start.Add(at)),
+				Count:          count,
+			}
+		}
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			event("app.3", "app", "Third", 3*time.Second, 1),
+			event("app.1", "app", "First", time.Second, 1),
+			event("app.2", "app", "Second", 2*time.Second, 4),
+			event("other.1", "other", "Other", time.Second, 1),
+		).Build()
+
+		app := unstructured.Unstructured{}
+		app.SetKind("Deployment")
+		app.SetNamespace("dev")
+		app.SetName("app")
+
+		messages, err := findEventMessages(context.Background(), kubeClient, []unstructured.Unstructured{app}, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(messages).To(Equal([]string{
+			"Deployment dev/app: Warning Second: Second message (x4)",
+			"Deployment dev/app: Warning Third: Third message",
+		}))
+	})
+})
+
+var _ = Describe("filterControllerLogs", func() {
+	It("returns the last lines about the Kustomization", func() {
+		var logs strings.Builder
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(&logs, `{"msg":"reconciled %d","name":"run-dev-ks"}`+"\n", i)
+			fmt.Fprintf(&logs, `{"msg":"reconciled %d","name":"run-dev-ks-2"}`+"\n", i)
+		}
+
+		lines, err := filterControllerLogs(strings.NewReader(logs.String()), "run-dev-ks", 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(Equal([]string{
+			`{"msg":"reconciled 3","name":"run-dev-ks"}`,
+			`{"msg":"reconciled 4","name":"run-dev-ks"}`,
+		}))
+	})
+})
@@ -0,0 +1,250 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/kustomize"
+	"github.com/fluxcd/pkg/ssa"
+	"github.com/google/go-cmp/cmp"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectChange is how syncing the files would change an object.
+type ObjectChange struct {
+	// Subject is the object, as Kind/namespace/name.
+	Subject string
+	Action  ssa.Action
+	// Diff is the diff of the live object with the object once applied,
+	// when it's configured.
+	Diff string
+	// Err is why the object can't be applied.
+	Err error
+}
+
+// DiffDevKustomizations shows what syncing the files under dir would change
+// in the cluster, before they're synced. The paths of the dev Kustomizations
+// are built like the kustomize-controller builds them, and their objects are
+// diffed with the live ones by a server-side dry-run.
+func DiffDevKustomizations(ctx context.Context, log logger.Logger, kubeClient client.Client, dir string, ignorer *ignore.GitIgnore, followSymlinks bool, namespace string, ksNames []string) error {
+	buildDir, err := os.MkdirTemp("", "run-diff")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDir)
+
+	// the Kustomizations are built from a copy of the synced files, as
+	// building them may write kustomization.yaml files
+	if err := copySyncedFiles(log, dir, buildDir, ignorer, followSymlinks); err != nil {
+		return fmt.Errorf("error copying the files to build: %w", err)
+	}
+
+	manager := ssa.NewResourceManager(kubeClient, nil, ssa.Owner{
+		Field: "kustomize-controller",
+		Group: kustomizev1.GroupVersion.Group,
+	})
+
+	ksLog := logger.WithLogSource(log, logger.LogSourceKustomization)
+
+	for _, name := range ksNames {
+		ks := &kustomizev1.Kustomization{}
+		if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ks); err != nil {
+			return fmt.Errorf("error getting Kustomization %s: %w", name, err)
+		}
+
+		ksLog.Actionf("Changes of Kustomization %s:", name)
+
+		objects, err := buildKustomization(ctx, kubeClient, ks, buildDir)
+		if err != nil {
+			ksLog.Failuref("Error building Kustomization %s: %v", name, err)
+			continue
+		}
+
+		changes := diffObjects(ctx, manager, ks, objects)
+		changes = append(changes, prunedObjects(ks, objects)...)
+
+		showObjectChanges(ksLog, changes)
+	}
+
+	return nil
+}
+
+// copySyncedFiles copies the files under dir that are synced to destDir.
+func copySyncedFiles(log logger.Logger, dir, destDir string, ignorer *ignore.GitIgnore, followSymlinks bool) error {
+	return walkSyncedFiles(log, dir, ignorer, followSymlinks, func(path, relPath string, info os.FileInfo) error {
+		dest := filepath.Join(destDir, filepath.FromSlash(relPath))
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+			return err
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		dst, err := os.Create(dest)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return err
+		}
+
+		return dst.Close()
+	})
+}
+
+// buildKustomization builds the objects of the Kustomization from the files
+// under root, generating its kustomization.yaml if there's none and
+// substituting its variables like the kustomize-controller does.
+func buildKustomization(ctx context.Context, kubeClient client.Client, ks *kustomizev1.Kustomization, root string) ([]*unstructured.Unstructured, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ks)
+	if err != nil {
+		return nil, err
+	}
+
+	u := unstructured.Unstructured{Object: data}
+
+	dirPath := filepath.Join(root, filepath.FromSlash(ks.Spec.Path))
+
+	if _, err := kustomize.NewGenerator(root, u).WriteFile(dirPath); err != nil {
+		return nil, err
+	}
+
+	resMap, err := kustomize.SecureBuild(root, dirPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if ks.Spec.PostBuild != nil {
+		for _, res := range resMap.Resources() {
+			substituted, err := kustomize.SubstituteVariables(ctx, kubeClient, u, res, false)
+			if err != nil {
+				return nil, fmt.Errorf("error substituting the variables of %s: %w", res.CurId(), err)
+			}
+
+			// the substitution is disabled for this object
+			if substituted == nil {
+				continue
+			}
+
+			if _, err := resMap.Replace(substituted); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifests, err := resMap.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := ssa.ReadObjects(bytes.NewReader(manifests))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ssa.SetNativeKindsDefaults(objects); err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// diffObjects diffs the objects of the Kustomization with the live ones.
+func diffObjects(ctx context.Context, manager *ssa.ResourceManager, ks *kustomizev1.Kustomization, objects []*unstructured.Unstructured) []ObjectChange {
+	manager.SetOwnerLabels(objects, ks.Name, ks.Namespace)
+
+	var result []ObjectChange
+
+	for _, obj := range objects {
+		entry, live, merged, err := manager.Diff(ctx, obj, ssa.DefaultDiffOptions())
+		if err != nil {
+			result = append(result, ObjectChange{Subject: ssa.FmtUnstructured(obj), Err: err})
+			continue
+		}
+
+		change := ObjectChange{Subject: entry.Subject, Action: ssa.Action(entry.Action)}
+
+		if live != nil && merged != nil {
+			change.Diff = cmp.Diff(live.Object, merged.Object)
+		}
+
+		result = append(result, change)
+	}
+
+	return result
+}
+
+// prunedObjects returns the objects the Kustomization applied that aren't
+// among its objects anymore, so they'd be deleted.
+func prunedObjects(ks *kustomizev1.Kustomization, objects []*unstructured.Unstructured) []ObjectChange {
+	if !ks.Spec.Prune || ks.Status.Inventory == nil {
+		return nil
+	}
+
+	applied := map[string]bool{}
+	for _, obj := range objects {
+		applied[object.UnstructuredToObjMetadata(obj).String()] = true
+	}
+
+	var result []ObjectChange
+
+	for _, entry := range ks.Status.Inventory.Entries {
+		if applied[entry.ID] {
+			continue
+		}
+
+		objMeta, err := object.ParseObjMetadata(entry.ID)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, ObjectChange{Subject: ssa.FmtObjMetadata(objMeta), Action: ssa.DeletedAction})
+	}
+
+	return result
+}
+
+// showObjectChanges shows the objects that would change, leaving out the
+// unchanged ones.
+func showObjectChanges(log logger.Logger, changes []ObjectChange) {
+	changed := false
+
+	for _, change := range changes {
+		switch {
+		case change.Err != nil:
+			log.Failuref("%s can't be applied: %v", change.Subject, change.Err)
+		case change.Action == ssa.UnchangedAction:
+			continue
+		case change.Action == ssa.DeletedAction:
+			log.Warningf("%s %s", change.Subject, change.Action)
+		default:
+			log.Generatef("%s %s", change.Subject, change.Action)
+		}
+
+		if change.Diff != "" {
+			log.Println("%s", change.Diff)
+		}
+
+		changed = true
+	}
+
+	if !changed {
+		log.Successf("No changes")
+	}
+}
@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/ssa"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("buildKustomization", func() {
+	It("builds the objects of the path like the kustomize-controller", func() {
+		dir, err := os.MkdirTemp("", "diff")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(os.MkdirAll(filepath.Join(dir, "dev"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "dev", "cm.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  env: ${cluster_env}
+`), 0644)).To(Succeed())
+
+		scheme, err := kube.CreateScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		ks := &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: "run-dev-ks", Namespace: "flux-system"},
+			Spec: kustomizev1.KustomizationSpec{
+				Path:            "./dev",
+				TargetNamespace: "dev",
+				PostBuild: &kustomizev1.PostBuild{
+					Substitute: map[string]string{"cluster_env": "dev"},
+				},
+			},
+		}
+
+		objects, err := buildKustomization(context.Background(), fake.NewClientBuilder().WithScheme(scheme).Build(), ks, dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objects).To(HaveLen(1))
+		Expect(objects[0].GetNamespace()).To(Equal("dev"))
+		Expect(objects[0].Object["data"]).To(Equal(map[string]interface{}{"env": "dev"}))
+
+		// the files being edited are left untouched
+		_, err = os.Stat(filepath.Join(dir, "dev", "kustomization.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("prunedObjects", func() {
+	It("returns the objects of the inventory that aren't applied anymore", func() {
+		ks := &kustomizev1.Kustomization{
+			Spec: kustomizev1.KustomizationSpec{Prune: true},
+			Status: kustomizev1.KustomizationStatus{
+				Inventory: &kustomizev1.ResourceInventory{
+					Entries: []kustomizev1.ResourceRef{
+						{ID: "dev_app__ConfigMap", Version: "v1"},
+						{ID: "dev_app_apps_Deployment", Version: "v1"},
+					},
+				},
+			},
+		}
+
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetNamespace("dev")
+		cm.SetName("app")
+
+		Expect(prunedObjects(ks, []*unstructured.Unstructured{cm})).To(Equal([]ObjectChange{
+			{Subject: "Deployment/dev/app", Action: ssa.DeletedAction},
+		}))
+
+		ks.Spec.Prune = false
+		Expect(prunedObjects(ks, nil)).To(BeEmpty())
+	})
+})
@@ -2,65 +2,183 @@ package watch
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/weaveworks/weave-gitops/core/logger"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type PortForwardSpec struct {
-	Namespace     string
-	Name          string
-	Kind          string
+	Namespace string
+	Name      string
+	Kind      string
+	Ports     []PortMapping
+}
+
+// PortMapping forwards a port on localhost to a port of the container.
+type PortMapping struct {
 	HostPort      string
 	ContainerPort string
-	Map           map[string]string
 }
 
-// parse port forward specin the key-value format of "port=8000:8080,resource=svc/app,namespace=default"
+// HostPorts returns the ports the spec listens on locally.
+func (s *PortForwardSpec) HostPorts() []string {
+	var result []string
+
+	for _, p := range s.Ports {
+		result = append(result, p.HostPort)
+	}
+
+	return result
+}
+
+// ParsePortForwardSpec parses a port forward spec in the key-value format of
+// "port=8000:8080,resource=svc/app,namespace=default".
+//
+// The port is either "8000:8080", or "8000" to use the same port locally and
+// in the container. The container port can also be the name of a port, of
+// the service if the resource is a service, or else of the pod's containers.
+// Several ports are forwarded when separated by semicolons, e.g.
+// "port=8000:8080;9000:9090", or when the port key is repeated.
+// The namespace defaults to "default".
 func ParsePortForwardSpec(spec string) (*PortForwardSpec, error) {
 	specMap := PortForwardSpec{
-		Map: make(map[string]string),
+		Namespace: "default",
 	}
-	specMap.Namespace = "default"
+
+	seen := map[string]bool{}
 
 	for _, pair := range strings.Split(spec, ",") {
-		kv := strings.SplitN(pair, "=", 2)
-		if len(kv) != 2 {
-			return nil, fmt.Errorf("invalid port forward spec: %s", spec)
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid port forward spec %q: %q is not in the key=value format", spec, pair)
+		}
+
+		if seen[key] && key != "port" {
+			return nil, fmt.Errorf("invalid port forward spec %q: key %q is set more than once", spec, key)
 		}
 
-		if kv[0] == "port" {
-			// split into port and host port
-			portAndHostPort := strings.Split(kv[1], ":")
-			specMap.HostPort = portAndHostPort[0]
-			specMap.ContainerPort = portAndHostPort[1]
-		} else if kv[0] == "resource" {
-			// specMap["resource"] = kv[1]
-			// split kv[1] into kind and name
-			kindAndName := strings.Split(kv[1], "/")
-			if len(kindAndName) != 2 {
-				return nil, fmt.Errorf("invalid resource: %s", kv[1])
+		seen[key] = true
+
+		var err error
+
+		switch key {
+		case "port":
+			for _, ports := range strings.Split(value, ";") {
+				var mapping PortMapping
+
+				mapping.HostPort, mapping.ContainerPort, err = parsePorts(ports)
+				if err != nil {
+					break
+				}
+
+				for _, other := range specMap.Ports {
+					if other.HostPort == mapping.HostPort {
+						err = fmt.Errorf("host port %q is forwarded more than once", mapping.HostPort)
+					}
+				}
+
+				if err != nil {
+					break
+				}
+
+				specMap.Ports = append(specMap.Ports, mapping)
+			}
+		case "resource":
+			specMap.Kind, specMap.Name, err = parseResource(value)
+		case "namespace":
+			if errs := validation.IsDNS1123Label(value); len(errs) > 0 {
+				err = errors.New(strings.Join(errs, ", "))
 			}
-			specMap.Kind = generalizeKind(kindAndName[0])
-			specMap.Name = kindAndName[1]
-		} else if kv[0] == "namespace" {
-			specMap.Namespace = kv[1]
-		} else {
-			specMap.Map[kv[0]] = kv[1]
+
+			specMap.Namespace = value
+		default:
+			err = errors.New("unknown key, valid keys are port, resource and namespace")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid port forward spec %q: key %q: %w", spec, key, err)
+		}
+	}
+
+	for _, key := range []string{"port", "resource"} {
+		if !seen[key] {
+			return nil, fmt.Errorf("invalid port forward spec %q: key %q is required", spec, key)
 		}
 	}
 
 	return &specMap, nil
 }
 
+// parsePorts parses "8000:8080", "8000:http" or "8000" into the host and
+// container ports.
+func parsePorts(value string) (string, string, error) {
+	hostPort, containerPort, ok := strings.Cut(value, ":")
+	if !ok {
+		containerPort = hostPort
+	}
+
+	if errs := validation.IsValidPortNum(atoi(hostPort)); len(errs) > 0 {
+		return "", "", fmt.Errorf("host port %q: %s", hostPort, strings.Join(errs, ", "))
+	}
+
+	if _, err := strconv.Atoi(containerPort); err == nil {
+		if errs := validation.IsValidPortNum(atoi(containerPort)); len(errs) > 0 {
+			return "", "", fmt.Errorf("container port %q: %s", containerPort, strings.Join(errs, ", "))
+		}
+	} else if errs := validation.IsValidPortName(containerPort); len(errs) > 0 {
+		return "", "", fmt.Errorf("container port %q is neither a number nor a port name: %s", containerPort, strings.Join(errs, ", "))
+	}
+
+	return hostPort, containerPort, nil
+}
+
+// atoi returns -1 if s isn't a number, so it's rejected as a port.
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
+
+// parseResource parses "svc/app" into the generalized kind and the name.
+func parseResource(value string) (string, string, error) {
+	kind, name, ok := strings.Cut(value, "/")
+	if !ok || kind == "" || name == "" {
+		return "", "", fmt.Errorf("%q is not in the kind/name format", value)
+	}
+
+	kind = generalizeKind(kind)
+
+	switch kind {
+	case "pod", "service", "deployment":
+	default:
+		return "", "", fmt.Errorf("unsupported kind %q, valid kinds are pod, service and deployment", kind)
+	}
+
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", "", fmt.Errorf("name %q: %s", name, strings.Join(errs, ", "))
+	}
+
+	return kind, name, nil
+}
+
 func generalizeKind(kind string) string {
 	// switch over kind
 	switch kind {
@@ -78,6 +196,73 @@ func generalizeKind(kind string) string {
 	}
 }
 
+// ResolveContainerPort returns the number of the container port to forward
+// to in the pod, given containerPort of one of the ports of specMap. Named
+// ports are looked up in the service if the resource is a service, and then
+// in the pod's containers.
+func ResolveContainerPort(ctx context.Context, kubeClient client.Client, specMap *PortForwardSpec, pod *corev1.Pod, containerPort string) (string, error) {
+	port := containerPort
+	if _, err := strconv.Atoi(port); err == nil {
+		return port, nil
+	}
+
+	if specMap.Kind == "service" {
+		svc := &corev1.Service{}
+		if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: specMap.Namespace, Name: specMap.Name}, svc); err != nil {
+			return "", fmt.Errorf("error getting service: %w", err)
+		}
+
+		found := false
+
+		for _, p := range svc.Spec.Ports {
+			if p.Name == port {
+				found = true
+				port = p.TargetPort.String()
+
+				// An unset target port defaults to the service port
+				if p.TargetPort.IntValue() == 0 && p.TargetPort.Type == intstr.Int {
+					port = strconv.Itoa(int(p.Port))
+				}
+			}
+		}
+
+		if !found {
+			return "", fmt.Errorf("service %s/%s has no port named %q", svc.Namespace, svc.Name, containerPort)
+		}
+
+		if _, err := strconv.Atoi(port); err == nil {
+			return port, nil
+		}
+	}
+
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == port {
+				return strconv.Itoa(int(p.ContainerPort)), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("pod %s/%s has no container port named %q", pod.Namespace, pod.Name, port)
+}
+
+// ResolveContainerPorts resolves the container ports of all the ports of
+// specMap in the pod, like ResolveContainerPort.
+func ResolveContainerPorts(ctx context.Context, kubeClient client.Client, specMap *PortForwardSpec, pod *corev1.Pod) error {
+	for i, p := range specMap.Ports {
+		port, err := ResolveContainerPort(ctx, kubeClient, specMap, pod, p.ContainerPort)
+		if err != nil {
+			return err
+		}
+
+		specMap.Ports[i].ContainerPort = port
+	}
+
+	return nil
+}
+
+// ForwardPort forwards all the ports of specMap to the pod, over a single
+// connection, until waitFwd is closed.
 func ForwardPort(log logr.Logger, pod *corev1.Pod, cfg *rest.Config, specMap *PortForwardSpec, waitFwd chan struct{}, readyChannel chan struct{}) error {
 	reqURL, err := url.Parse(
 		fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s/portforward",
@@ -97,13 +282,19 @@ func ForwardPort(log logr.Logger, pod *corev1.Pod, cfg *rest.Config, specMap *Po
 
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", reqURL)
 
+	var ports []string
+
+	for _, p := range specMap.Ports {
+		ports = append(ports, fmt.Sprintf("%s:%s", p.HostPort, p.ContainerPort))
+	}
+
 	outStd := bytes.Buffer{}
 	outErr := bytes.Buffer{}
 
 	fw, err2 := portforward.NewOnAddresses(
 		dialer,
 		[]string{"localhost"},
-		[]string{fmt.Sprintf("%s:%s", specMap.HostPort, specMap.ContainerPort)},
+		ports,
 		waitFwd,
 		readyChannel,
 		&outStd,
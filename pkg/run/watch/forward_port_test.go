@@ -0,0 +1,136 @@
+package watch
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = DescribeTable("ParsePortForwardSpec", func(spec string, expected *PortForwardSpec) {
+	result, err := ParsePortForwardSpec(spec)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result).To(Equal(expected))
+},
+	Entry("host and container port", "port=8000:8080,resource=svc/app", &PortForwardSpec{
+		Namespace: "default", Name: "app", Kind: "service", Ports: []PortMapping{{HostPort: "8000", ContainerPort: "8080"}},
+	}),
+	Entry("same host and container port", "port=8000,resource=deployment/app,namespace=apps", &PortForwardSpec{
+		Namespace: "apps", Name: "app", Kind: "deployment", Ports: []PortMapping{{HostPort: "8000", ContainerPort: "8000"}},
+	}),
+	Entry("named container port", "resource=po/app, port=8000:http", &PortForwardSpec{
+		Namespace: "default", Name: "app", Kind: "pod", Ports: []PortMapping{{HostPort: "8000", ContainerPort: "http"}},
+	}),
+	Entry("several ports", "port=8000:8080;9000:metrics,resource=svc/app", &PortForwardSpec{
+		Namespace: "default", Name: "app", Kind: "service", Ports: []PortMapping{{HostPort: "8000", ContainerPort: "8080"}, {HostPort: "9000", ContainerPort: "metrics"}},
+	}),
+	Entry("repeated port key", "port=8000:8080,resource=svc/app,port=9000", &PortForwardSpec{
+		Namespace: "default", Name: "app", Kind: "service", Ports: []PortMapping{{HostPort: "8000", ContainerPort: "8080"}, {HostPort: "9000", ContainerPort: "9000"}},
+	}),
+)
+
+var _ = DescribeTable("ParsePortForwardSpec errors", func(spec string, expected string) {
+	_, err := ParsePortForwardSpec(spec)
+	Expect(err).To(MatchError(ContainSubstring(expected)))
+},
+	Entry("not key=value", "port=8000,svc/app", `"svc/app" is not in the key=value format`),
+	Entry("unknown key", "port=8000,resource=svc/app,namepsace=apps", `key "namepsace": unknown key`),
+	Entry("duplicate key", "port=8000,resource=svc/app,resource=svc/other", `key "resource" is set more than once`),
+	Entry("duplicate host port", "port=8000:8080;8000:9090,resource=svc/app", `key "port": host port "8000" is forwarded more than once`),
+	Entry("missing port", "resource=svc/app", `key "port" is required`),
+	Entry("missing resource", "port=8000", `key "resource" is required`),
+	Entry("host port not a number", "port=http:8080,resource=svc/app", `key "port": host port "http"`),
+	Entry("host port out of range", "port=80000:8080,resource=svc/app", `key "port": host port "80000"`),
+	Entry("empty container port", "port=8000:,resource=svc/app", `key "port": container port ""`),
+	Entry("bad resource", "port=8000,resource=app", `key "resource": "app" is not in the kind/name format`),
+	Entry("unsupported kind", "port=8000,resource=statefulset/app", `key "resource": unsupported kind "statefulset"`),
+	Entry("bad namespace", "port=8000,resource=svc/app,namespace=Apps", `key "namespace"`),
+)
+
+var _ = Describe("ResolveContainerPort", func() {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1234", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Ports: []corev1.ContainerPort{{Name: "web", ContainerPort: 9898}},
+			}},
+		},
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+				{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9797)},
+			},
+		},
+	}
+
+	kubeClient := fake.NewClientBuilder().WithObjects(svc).Build()
+
+	It("resolves service port names", func() {
+		port, err := ResolveContainerPort(context.Background(), kubeClient, &PortForwardSpec{Namespace: "default", Name: "app", Kind: "service"}, pod, "http")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal("9898"))
+
+		port, err = ResolveContainerPort(context.Background(), kubeClient, &PortForwardSpec{Namespace: "default", Name: "app", Kind: "service"}, pod, "metrics")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal("9797"))
+	})
+
+	It("resolves container port names", func() {
+		port, err := ResolveContainerPort(context.Background(), kubeClient, &PortForwardSpec{Namespace: "default", Name: "app", Kind: "deployment"}, pod, "web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal("9898"))
+	})
+
+	It("resolves all the ports of a spec", func() {
+		spec := &PortForwardSpec{Namespace: "default", Name: "app", Kind: "service", Ports: []PortMapping{
+			{HostPort: "8000", ContainerPort: "http"},
+			{HostPort: "9000", ContainerPort: "metrics"},
+		}}
+		Expect(ResolveContainerPorts(context.Background(), kubeClient, spec, pod)).To(Succeed())
+		Expect(spec.Ports).To(Equal([]PortMapping{{HostPort: "8000", ContainerPort: "9898"}, {HostPort: "9000", ContainerPort: "9797"}}))
+	})
+
+	It("fails for unknown port names", func() {
+		_, err := ResolveContainerPort(context.Background(), kubeClient, &PortForwardSpec{Namespace: "default", Name: "app", Kind: "service"}, pod, "grpc")
+		Expect(err).To(MatchError(ContainSubstring(`service default/app has no port named "grpc"`)))
+	})
+})
+
+var _ = Describe("isPodStillTargeted", func() {
+	readyPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1234", Namespace: "default", UID: "1234"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	It("keeps a pod that is still ready", func() {
+		kubeClient := fake.NewClientBuilder().WithObjects(readyPod.DeepCopy()).Build()
+
+		Expect(isPodStillTargeted(context.Background(), kubeClient, &PortForwardSpec{Kind: "service"}, readyPod)).To(BeTrue())
+	})
+
+	It("drops a pod that is gone", func() {
+		kubeClient := fake.NewClientBuilder().Build()
+
+		Expect(isPodStillTargeted(context.Background(), kubeClient, &PortForwardSpec{Kind: "service"}, readyPod)).To(BeFalse())
+	})
+
+	It("drops a pod of a service that isn't ready anymore", func() {
+		notReady := readyPod.DeepCopy()
+		notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+		kubeClient := fake.NewClientBuilder().WithObjects(notReady).Build()
+
+		Expect(isPodStillTargeted(context.Background(), kubeClient, &PortForwardSpec{Kind: "service"}, readyPod)).To(BeFalse())
+		Expect(isPodStillTargeted(context.Background(), kubeClient, &PortForwardSpec{Kind: "pod"}, readyPod)).To(BeTrue())
+	})
+})
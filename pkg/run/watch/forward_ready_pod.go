@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"time"
+
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/run"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// How often the pod the ports are forwarded to is checked to still be there.
+const podCheckInterval = 2 * time.Second
+
+// ForwardPortToReadyPod forwards the ports of specMap to a ready pod of its
+// resource, until stop is closed or ctx is done. When the pod disappears or
+// stops being ready, e.g. as a rollout replaces it, the ports are forwarded
+// to another ready pod instead.
+func ForwardPortToReadyPod(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, specMap *PortForwardSpec, stop <-chan struct{}) {
+	namespacedName := types.NamespacedName{Namespace: specMap.Namespace, Name: specMap.Name}
+
+	for {
+		pod, err := run.GetPodFromResourceDescription(ctx, namespacedName, specMap.Kind, kubeClient)
+		if err == nil {
+			err = forwardPortToPod(ctx, log, kubeClient, cfg, specMap, pod, stop)
+		}
+
+		if err != nil {
+			log.Failuref("Error forwarding port: %v", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(podCheckInterval):
+		}
+	}
+}
+
+// forwardPortToPod forwards the ports of specMap to pod, until stop is closed,
+// ctx is done, or the pod is gone.
+func forwardPortToPod(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, specMap *PortForwardSpec, pod *corev1.Pod, stop <-chan struct{}) error {
+	// the named ports are resolved in each pod, as they may change with it
+	resolved := *specMap
+	resolved.Ports = append([]PortMapping{}, specMap.Ports...)
+
+	if err := ResolveContainerPorts(ctx, kubeClient, &resolved, pod); err != nil {
+		return err
+	}
+
+	podStop := make(chan struct{})
+	done := make(chan struct{})
+
+	defer close(done)
+
+	go func() {
+		defer close(podStop)
+
+		ticker := time.NewTicker(podCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if !isPodStillTargeted(ctx, kubeClient, specMap, pod) {
+					log.Warningf("Pod %s/%s is gone, port forwarding to another pod ...", pod.Namespace, pod.Name)
+					return
+				}
+			}
+		}
+	}()
+
+	log.Actionf("Port forwarding to pod %s/%s ...", pod.Namespace, pod.Name)
+
+	// this function _BLOCKS_ until podStop is closed, or the connection is lost
+	return ForwardPort(log.L(), pod, cfg, &resolved, podStop, make(chan struct{}))
+}
+
+// isPodStillTargeted returns whether pod is still the one the ports of
+// specMap are forwarded to. A pod named by the spec only has to exist, while
+// the pods of services and deployments have to stay ready.
+func isPodStillTargeted(ctx context.Context, kubeClient client.Client, specMap *PortForwardSpec, pod *corev1.Pod) bool {
+	current := &corev1.Pod{}
+	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(pod), current); err != nil {
+		return false
+	}
+
+	if current.UID != pod.UID {
+		return false
+	}
+
+	return specMap.Kind == "pod" || run.IsPodReady(current)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package watch
+
+import (
+	"os"
+	"strings"
+)
+
+// isHidden returns whether the file is hidden, i.e. its name starts with a
+// dot.
+func isHidden(info os.FileInfo) bool {
+	return strings.HasPrefix(info.Name(), ".")
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package watch
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+// isHidden returns whether the file is hidden, i.e. its name starts with a
+// dot like on the other systems, or it has the hidden attribute.
+func isHidden(info os.FileInfo) bool {
+	if strings.HasPrefix(info.Name(), ".") {
+		return true
+	}
+
+	if attrs, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return attrs.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+	}
+
+	return false
+}
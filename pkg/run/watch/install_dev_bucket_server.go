@@ -8,6 +8,7 @@ import (
 
 	"github.com/weaveworks/weave-gitops/pkg/logger"
 	"github.com/weaveworks/weave-gitops/pkg/run"
+	"github.com/weaveworks/weave-gitops/pkg/s3"
 	"github.com/weaveworks/weave-gitops/pkg/tls"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -34,6 +35,7 @@ var (
 )
 
 // InstallDevBucketServer installs the dev bucket server, open port forwarding, and returns a function that can be used to the port forwarding.
+// The server deletes the session logs logsRetention doesn't keep.
 func InstallDevBucketServer(
 	ctx context.Context,
 	log logger.Logger,
@@ -42,7 +44,8 @@ func InstallDevBucketServer(
 	httpPort,
 	httpsPort int32,
 	accessKey,
-	secretKey []byte) (func(), []byte, error) {
+	secretKey []byte,
+	logsRetention s3.Retention) (func(), []byte, error) {
 	var (
 		err                error
 		devBucketAppLabels = map[string]string{
@@ -50,28 +53,8 @@ func InstallDevBucketServer(
 		}
 	)
 
-	// create namespace
-	devBucketNamespace := corev1.Namespace{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: GitOpsRunNamespace,
-		},
-	}
-
-	log.Actionf("Checking namespace %s ...", GitOpsRunNamespace)
-
-	err = kubeClient.Get(ctx,
-		client.ObjectKeyFromObject(&devBucketNamespace),
-		&devBucketNamespace)
-
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := kubeClient.Create(ctx, &devBucketNamespace); err != nil {
-			log.Failuref("Error creating namespace %s: %v", GitOpsRunNamespace, err.Error())
-			return nil, nil, err
-		} else {
-			log.Successf("Created namespace %s", GitOpsRunNamespace)
-		}
-	} else if err == nil {
-		log.Successf("Namespace %s already existed", GitOpsRunNamespace)
+	if err := createGitOpsRunNamespace(ctx, log, kubeClient); err != nil {
+		return nil, nil, err
 	}
 
 	// create service
@@ -213,6 +196,8 @@ func InstallDevBucketServer(
 								fmt.Sprintf("--https-port=%d", httpsPort),
 								"--cert-file=/tmp/certs/cert.pem",
 								"--key-file=/tmp/certs/cert.key",
+								fmt.Sprintf("--logs-max-age=%s", logsRetention.MaxAge),
+								fmt.Sprintf("--logs-max-objects=%d", logsRetention.MaxObjects),
 							},
 							VolumeMounts: []corev1.VolumeMount{{
 								Name:      "certs",
@@ -243,7 +228,45 @@ func InstallDevBucketServer(
 		log.Successf("Deployment %s/%s already existed", GitOpsRunNamespace, RunDevBucketName)
 	}
 
-	log.Actionf("Waiting for deployment %s to be ready ...", RunDevBucketName)
+	waitForDeployment(ctx, log, kubeClient, &devBucketDeployment)
+
+	cancelPortFwd, err := forwardPortToService(ctx, log, kubeClient, config, RunDevBucketName, httpsPort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cancelPortFwd, cert.Cert, nil
+}
+
+func createGitOpsRunNamespace(ctx context.Context, log logger.Logger, kubeClient client.Client) error {
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: GitOpsRunNamespace,
+		},
+	}
+
+	log.Actionf("Checking namespace %s ...", GitOpsRunNamespace)
+
+	err := kubeClient.Get(ctx,
+		client.ObjectKeyFromObject(&namespace),
+		&namespace)
+
+	if err != nil && apierrors.IsNotFound(err) {
+		if err := kubeClient.Create(ctx, &namespace); err != nil {
+			log.Failuref("Error creating namespace %s: %v", GitOpsRunNamespace, err.Error())
+			return err
+		} else {
+			log.Successf("Created namespace %s", GitOpsRunNamespace)
+		}
+	} else if err == nil {
+		log.Successf("Namespace %s already existed", GitOpsRunNamespace)
+	}
+
+	return nil
+}
+
+func waitForDeployment(ctx context.Context, log logger.Logger, kubeClient client.Client, deployment *appsv1.Deployment) {
+	log.Actionf("Waiting for deployment %s to be ready ...", deployment.Name)
 
 	if err := wait.ExponentialBackoff(wait.Backoff{
 		Duration: 1 * time.Second,
@@ -251,7 +274,7 @@ func InstallDevBucketServer(
 		Jitter:   1,
 		Steps:    10,
 	}, func() (done bool, err error) {
-		d := devBucketDeployment.DeepCopy()
+		d := deployment.DeepCopy()
 		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(d), d); err != nil {
 			return false, err
 		}
@@ -268,13 +291,16 @@ func InstallDevBucketServer(
 	}); err != nil {
 		log.Failuref("Max retry exceeded waiting for deployment to be ready")
 	}
+}
 
+// forwardPortToService forwards the port of a service of GitOps Run to the
+// same port on localhost, and returns a function stopping it.
+func forwardPortToService(ctx context.Context, log logger.Logger, kubeClient client.Client, config *rest.Config, name string, port int32) (func(), error) {
 	specMap := &PortForwardSpec{
-		Name:          RunDevBucketName,
-		Namespace:     GitOpsRunNamespace,
-		Kind:          "service",
-		HostPort:      strconv.Itoa(int(httpsPort)),
-		ContainerPort: strconv.Itoa(int(httpsPort)),
+		Name:      name,
+		Namespace: GitOpsRunNamespace,
+		Kind:      "service",
+		Ports:     []PortMapping{{HostPort: strconv.Itoa(int(port)), ContainerPort: strconv.Itoa(int(port))}},
 	}
 	// get pod from specMap
 	namespacedName := types.NamespacedName{Namespace: specMap.Namespace, Name: specMap.Name}
@@ -300,15 +326,16 @@ func InstallDevBucketServer(
 		}()
 		<-readyChannel
 
-		log.Successf("Port forwarding for %s is ready.", RunDevBucketName)
+		log.Successf("Port forwarding for %s is ready.", name)
 
-		return cancelPortFwd, cert.Cert, nil
+		return cancelPortFwd, nil
 	}
 
-	return nil, nil, fmt.Errorf("pod not found")
+	return nil, fmt.Errorf("pod not found")
 }
 
-// UninstallDevBucketServer deletes the dev-bucket namespace.
+// UninstallDevBucketServer deletes the dev-bucket namespace, along with the
+// dev registry when it's used instead.
 func UninstallDevBucketServer(ctx context.Context, log logger.Logger, kubeClient client.Client) error {
 	// create namespace
 	devBucketNamespace := corev1.Namespace{
@@ -0,0 +1,140 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	RunDevRegistryName = "run-dev-registry"
+	RunDevOCIName      = "run-dev-oci"
+)
+
+var (
+	// DevRegistryContainerImage is the registry the dev OCI artifacts are
+	// pushed to. It may be set by flags passed to `go build`.
+	DevRegistryContainerImage = "registry:2.8.1"
+)
+
+// DevRegistryRepository returns the OCI repository the dev artifact is
+// pushed to, as seen from within the cluster.
+func DevRegistryRepository(port int32) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d/%s", RunDevRegistryName, GitOpsRunNamespace, port, RunDevOCIName)
+}
+
+// InstallDevRegistry installs an OCI registry the dev artifacts are pushed to,
+// as an alternative to the dev bucket server, and forwards its port to
+// localhost. It returns a function stopping the port forwarding.
+func InstallDevRegistry(ctx context.Context, log logger.Logger, kubeClient client.Client, config *rest.Config, port int32) (func(), error) {
+	devRegistryAppLabels := map[string]string{
+		"app": RunDevRegistryName,
+	}
+
+	if err := createGitOpsRunNamespace(ctx, log, kubeClient); err != nil {
+		return nil, err
+	}
+
+	// create service
+	devRegistryService := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunDevRegistryName,
+			Namespace: GitOpsRunNamespace,
+			Labels:    devRegistryAppLabels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name: fmt.Sprintf("%s-http", RunDevRegistryName),
+					Port: port,
+				},
+			},
+			Selector: devRegistryAppLabels,
+		},
+	}
+
+	log.Actionf("Checking service %s/%s ...", GitOpsRunNamespace, RunDevRegistryName)
+
+	err := kubeClient.Get(ctx,
+		client.ObjectKeyFromObject(&devRegistryService),
+		&devRegistryService)
+
+	if err != nil && apierrors.IsNotFound(err) {
+		if err := kubeClient.Create(ctx, &devRegistryService); err != nil {
+			log.Failuref("Error creating service %s/%s: %v", GitOpsRunNamespace, RunDevRegistryName, err.Error())
+			return nil, err
+		} else {
+			log.Successf("Created service %s/%s", GitOpsRunNamespace, RunDevRegistryName)
+		}
+	} else if err == nil {
+		log.Successf("Service %s/%s already existed", GitOpsRunNamespace, RunDevRegistryName)
+	}
+
+	// create deployment
+	replicas := int32(1)
+	devRegistryDeployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunDevRegistryName,
+			Namespace: GitOpsRunNamespace,
+			Labels:    devRegistryAppLabels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: devRegistryAppLabels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: devRegistryAppLabels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            RunDevRegistryName,
+							Image:           DevRegistryContainerImage,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Env: []corev1.EnvVar{
+								{Name: "REGISTRY_HTTP_ADDR", Value: fmt.Sprintf(":%d", port)},
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									ContainerPort: port,
+								},
+							},
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyAlways,
+				},
+			},
+		},
+	}
+
+	log.Actionf("Checking deployment %s/%s ...", GitOpsRunNamespace, RunDevRegistryName)
+
+	err = kubeClient.Get(ctx,
+		client.ObjectKeyFromObject(&devRegistryDeployment),
+		&devRegistryDeployment)
+
+	if err != nil && apierrors.IsNotFound(err) {
+		if err := kubeClient.Create(ctx, &devRegistryDeployment); err != nil {
+			log.Failuref("Error creating deployment %s/%s: %v", GitOpsRunNamespace, RunDevRegistryName, err.Error())
+			return nil, err
+		} else {
+			log.Successf("Created deployment %s/%s", GitOpsRunNamespace, RunDevRegistryName)
+		}
+	} else if err == nil {
+		log.Successf("Deployment %s/%s already existed", GitOpsRunNamespace, RunDevRegistryName)
+	}
+
+	waitForDeployment(ctx, log, kubeClient, &devRegistryDeployment)
+
+	return forwardPortToService(ctx, log, kubeClient, config, RunDevRegistryName, port)
+}
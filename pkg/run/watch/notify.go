@@ -0,0 +1,143 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// SyncStatus is the state of the dev Kustomizations or HelmRelease once
+// they're reconciled.
+type SyncStatus string
+
+const (
+	SyncStatusHealthy SyncStatus = "Healthy"
+	SyncStatusFailed  SyncStatus = "Failed"
+)
+
+// Notification is the payload posted to the webhook when the sync status
+// changes.
+type Notification struct {
+	Session string     `json:"session"`
+	Status  SyncStatus `json:"status"`
+	Message string     `json:"message"`
+	Time    time.Time  `json:"time"`
+}
+
+// Notifier notifies the developer when the dev objects become healthy or
+// fail, e.g. while they work in another window. Only the changes of the
+// status are notified.
+type Notifier struct {
+	session    string
+	desktop    bool
+	webhookURL string
+	client     *http.Client
+	last       SyncStatus
+}
+
+// NewNotifier returns a notifier showing desktop notifications when desktop
+// is set, and posting the notifications to webhookURL as JSON when it's set.
+func NewNotifier(session string, desktop bool, webhookURL string) *Notifier {
+	return &Notifier{
+		session:    session,
+		desktop:    desktop,
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify notifies the status, unless it's the one notified last. It returns
+// the errors of all the notifications that failed.
+func (n *Notifier) Notify(ctx context.Context, status SyncStatus, message string) error {
+	if status == n.last {
+		return nil
+	}
+
+	n.last = status
+
+	notification := Notification{
+		Session: n.session,
+		Status:  status,
+		Message: message,
+		Time:    time.Now(),
+	}
+
+	var failures *multierror.Error
+
+	if n.desktop {
+		if err := showDesktopNotification(ctx, "GitOps Run: "+string(status), message); err != nil {
+			failures = multierror.Append(failures, fmt.Errorf("failed to show desktop notification: %w", err))
+		}
+	}
+
+	if n.webhookURL != "" {
+		if err := n.postWebhook(ctx, notification); err != nil {
+			failures = multierror.Append(failures, err)
+		}
+	}
+
+	return failures.ErrorOrNil()
+}
+
+func (n *Notifier) postWebhook(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to post notification to webhook: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// showDesktopNotification shows a notification with the tools of the system,
+// notify-send on Linux and osascript on macOS.
+func showDesktopNotification(ctx context.Context, title, message string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=gitops", title, message)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	default:
+		return fmt.Errorf("desktop notifications aren't supported on %s", runtime.GOOS)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,59 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notifier", func() {
+	It("posts the changes of the status to the webhook", func() {
+		var received []Notification
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var notification Notification
+			Expect(json.NewDecoder(r.Body).Decode(&notification)).To(Succeed())
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			received = append(received, notification)
+		}))
+		defer srv.Close()
+
+		ctx := context.Background()
+		notifier := NewNotifier("run-main", false, srv.URL)
+
+		Expect(notifier.Notify(ctx, SyncStatusHealthy, "The changes are reconciled")).To(Succeed())
+		Expect(notifier.Notify(ctx, SyncStatusHealthy, "The changes are reconciled")).To(Succeed())
+		Expect(notifier.Notify(ctx, SyncStatusFailed, "Kustomization run-dev-ks is not ready")).To(Succeed())
+		Expect(notifier.Notify(ctx, SyncStatusFailed, "Kustomization run-dev-ks is not ready")).To(Succeed())
+		Expect(notifier.Notify(ctx, SyncStatusHealthy, "The changes are reconciled")).To(Succeed())
+
+		Expect(received).To(HaveLen(3))
+		Expect(received[0].Session).To(Equal("run-main"))
+		Expect(received[0].Status).To(Equal(SyncStatusHealthy))
+		Expect(received[1].Status).To(Equal(SyncStatusFailed))
+		Expect(received[1].Message).To(Equal("Kustomization run-dev-ks is not ready"))
+		Expect(received[2].Status).To(Equal(SyncStatusHealthy))
+	})
+
+	It("returns the errors of the webhook", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "no such hook", http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		notifier := NewNotifier("run-main", false, srv.URL)
+
+		err := notifier.Notify(context.Background(), SyncStatusFailed, "failed")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("404 Not Found: no such hook"))
+	})
+
+	It("quotes AppleScript strings", func() {
+		Expect(appleScriptString(`say "hi" \o/`)).To(Equal(`"say \"hi\" \\o/"`))
+	})
+})
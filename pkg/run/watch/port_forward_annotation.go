@@ -0,0 +1,112 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PortForwardAnnotation on a Service applied by GitOps Run forwards its ports
+// to localhost, in the format of the port of a port forward spec, e.g.
+// "8080:http;9090".
+const PortForwardAnnotation = "run.weave.works/port-forward"
+
+// FindAnnotatedPortForwards returns the port forward specs of the Services
+// annotated with PortForwardAnnotation, among the objects applied by the
+// Kustomizations named ksNames.
+func FindAnnotatedPortForwards(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, ksNames []string) ([]*PortForwardSpec, error) {
+	var result []*PortForwardSpec
+
+	for _, name := range ksNames {
+		ks := &kustomizev1.Kustomization{}
+		if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, ks); err != nil {
+			return nil, err
+		}
+
+		if ks.Status.Inventory == nil {
+			continue
+		}
+
+		for _, entry := range ks.Status.Inventory.Entries {
+			objMeta, err := object.ParseObjMetadata(entry.ID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid inventory item '%s', error: %w", entry.ID, err)
+			}
+
+			if objMeta.GroupKind.Group != "" || objMeta.GroupKind.Kind != "Service" {
+				continue
+			}
+
+			svc := &corev1.Service{}
+			if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: objMeta.Namespace, Name: objMeta.Name}, svc); err != nil {
+				return nil, err
+			}
+
+			ports, ok := svc.Annotations[PortForwardAnnotation]
+			if !ok {
+				continue
+			}
+
+			spec, err := ParsePortForwardSpec(fmt.Sprintf("port=%s,resource=svc/%s,namespace=%s", ports, svc.Name, svc.Namespace))
+			if err != nil {
+				log.Warningf("Ignoring annotation %s of service %s/%s: %v", PortForwardAnnotation, svc.Namespace, svc.Name, err)
+				continue
+			}
+
+			result = append(result, spec)
+		}
+	}
+
+	return result, nil
+}
+
+// ShowPortForwards prints where the ports of specs are forwarded from.
+func ShowPortForwards(log logger.Logger, specs []*PortForwardSpec) {
+	for _, spec := range specs {
+		for _, p := range spec.Ports {
+			log.Successf("Forwarding http://localhost:%s to %s/%s/%s port %s", p.HostPort, spec.Kind, spec.Namespace, spec.Name, p.ContainerPort)
+		}
+	}
+}
+
+// ForwardPortsToReadyPods forwards the ports of all specs like
+// ForwardPortToReadyPod, until stop is closed or ctx is done. A spec is
+// skipped if one of its host ports is already forwarded by an earlier spec.
+func ForwardPortsToReadyPods(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, specs []*PortForwardSpec, stop <-chan struct{}) {
+	var (
+		wg        sync.WaitGroup
+		hostPorts = map[string]bool{}
+	)
+
+specs:
+	for _, spec := range specs {
+		for _, port := range spec.HostPorts() {
+			if hostPorts[port] {
+				log.Warningf("Not forwarding to %s/%s/%s, port %s is already forwarded", spec.Kind, spec.Namespace, spec.Name, port)
+				continue specs
+			}
+		}
+
+		for _, port := range spec.HostPorts() {
+			hostPorts[port] = true
+		}
+
+		wg.Add(1)
+
+		go func(spec *PortForwardSpec) {
+			defer wg.Done()
+
+			ForwardPortToReadyPod(ctx, log, kubeClient, cfg, spec, stop)
+		}(spec)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package watch
+
+import (
+	"context"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("FindAnnotatedPortForwards", func() {
+	It("finds the annotated services applied by the Kustomizations", func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).NotTo(HaveOccurred())
+
+		ks := &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: RunDevKsName, Namespace: "flux-system"},
+			Status: kustomizev1.KustomizationStatus{
+				Inventory: &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{
+					{ID: "dev_frontend__Service", Version: "v1"},
+					{ID: "dev_backend__Service", Version: "v1"},
+					{ID: "dev_broken__Service", Version: "v1"},
+					{ID: "dev_frontend_apps_Deployment", Version: "v1"},
+				}},
+			},
+		}
+
+		services := []*corev1.Service{
+			{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "dev", Annotations: map[string]string{PortForwardAnnotation: "8080:http;9797:metrics"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "dev"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "dev", Annotations: map[string]string{PortForwardAnnotation: "http"}}},
+		}
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ks, services[0], services[1], services[2]).Build()
+
+		specs, err := FindAnnotatedPortForwards(context.Background(), logger.NewCLILogger(io.Discard), kubeClient, "flux-system", []string{RunDevKsName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(specs).To(Equal([]*PortForwardSpec{{
+			Namespace: "dev",
+			Name:      "frontend",
+			Kind:      "service",
+			Ports:     []PortMapping{{HostPort: "8080", ContainerPort: "http"}, {HostPort: "9797", ContainerPort: "metrics"}},
+		}}))
+	})
+})
@@ -35,7 +35,7 @@ func createBucketAndSecretObjects(params SetupRunObjectParams) (corev1.Secret, s
 			Namespace: params.Namespace,
 			Annotations: map[string]string{
 				"metadata.weave.works/description": "This is a temporary Bucket created by GitOps Run. This will be cleaned up when this instance of GitOps Run is ended.",
-				"metadata.weave.works/run-id":      params.SessionName,
+				RunIDAnnotation:                    params.SessionName,
 				"metadata.weave.works/username":    params.Username,
 			},
 		},
@@ -44,9 +44,13 @@ func createBucketAndSecretObjects(params SetupRunObjectParams) (corev1.Secret, s
 			Provider:   "generic",
 			BucketName: RunDevBucketName,
 			Endpoint:   fmt.Sprintf("%s.%s.svc.cluster.local:%d", RunDevBucketName, GitOpsRunNamespace, params.DevBucketPort),
-			Insecure:   true,
-			Timeout:    &metav1.Duration{Duration: params.Timeout},
-			SecretRef:  &meta.LocalObjectReference{Name: devBucketCredentials},
+			// The Bucket API has no way to trust the self-signed CA of the
+			// dev bucket, so Flux reaches it over HTTP within the cluster,
+			// with the random credentials of the session. The CLI only
+			// reaches it over HTTPS, through port forwarding.
+			Insecure:  true,
+			Timeout:   &metav1.Duration{Duration: params.Timeout},
+			SecretRef: &meta.LocalObjectReference{Name: devBucketCredentials},
 		},
 	}
 
@@ -83,38 +87,22 @@ func reconcileBucketAndSecretObjects(ctx context.Context, log logger.Logger, kub
 	return nil
 }
 
-func cleanupBucketAndSecretObjects(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string) {
+func cleanupBucketAndSecretObjects(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, opts CleanupOptions) error {
 	var devBucketCredentials = fmt.Sprintf("%s-credentials", RunDevBucketName)
 
-	// delete secret
-	secret := corev1.Secret{
+	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      devBucketCredentials,
 			Namespace: namespace,
 		},
 	}
 
-	log.Actionf("Deleting secret %s ...", secret.Name)
-
-	if err := kubeClient.Delete(ctx, &secret); err != nil {
-		log.Failuref("Error deleting secret %s: %v", secret.Name, err.Error())
-	} else {
-		log.Successf("Deleted secret %s", secret.Name)
-	}
-
-	// delete source
-	source := sourcev1.Bucket{
+	source := &sourcev1.Bucket{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      RunDevBucketName,
 			Namespace: namespace,
 		},
 	}
 
-	log.Actionf("Deleting source %s ...", source.Name)
-
-	if err := kubeClient.Delete(ctx, &source); err != nil {
-		log.Failuref("Error deleting source %s: %v", source.Name, err.Error())
-	} else {
-		log.Successf("Deleted source %s", source.Name)
-	}
+	return DeleteObjects(ctx, log, kubeClient, []client.Object{secret, source}, opts)
 }
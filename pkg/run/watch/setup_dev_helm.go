@@ -8,6 +8,7 @@ import (
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	"github.com/fluxcd/pkg/apis/meta"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/hashicorp/go-multierror"
 	"github.com/weaveworks/weave-gitops/pkg/logger"
 	"github.com/weaveworks/weave-gitops/pkg/run"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -15,43 +16,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func SetupBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient client.Client, params SetupRunObjectParams) error {
 	secret, source := createBucketAndSecretObjects(params)
-
-	helm := helmv2.HelmRelease{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      RunDevHelmName,
-			Namespace: params.Namespace,
-			Annotations: map[string]string{
-				"metadata.weave.works/description": "This is a temporary HelmRelease created by GitOps Run. This will be cleaned up when this instance of GitOps Run is ended.",
-				"metadata.weave.works/run-id":      params.SessionName,
-				"metadata.weave.works/username":    params.Username,
-			},
-		},
-		Spec: helmv2.HelmReleaseSpec{
-			Interval: metav1.Duration{Duration: 30 * 24 * time.Hour}, // 30 days
-			Chart: helmv2.HelmChartTemplate{
-				Spec: helmv2.HelmChartTemplateSpec{
-					Chart: params.Path,
-					SourceRef: helmv2.CrossNamespaceObjectReference{
-						Kind: sourcev1.BucketKind,
-						Name: RunDevBucketName,
-					},
-				},
-			},
-			Timeout: &metav1.Duration{Duration: params.Timeout},
-		},
-	}
+	helm := createHelmReleaseObject(params)
 
 	err := reconcileBucketAndSecretObjects(ctx, log, kubeClient, secret, source)
 	if err != nil {
 		return err
 	}
 
+	if err := reconcileTargetNamespace(ctx, log, kubeClient, params.TargetNamespace); err != nil {
+		return err
+	}
+
 	// create ks
 	log.Actionf("Checking HelmRelease %s ...", helm.Name)
 
@@ -62,7 +42,7 @@ func SetupBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient
 			log.Successf("Created HelmRelease %s", helm.Name)
 		}
 	} else if err == nil {
-		log.Successf("HelmRelease %s already existed", source.Name)
+		log.Successf("HelmRelease %s already existed", helm.Name)
 	}
 
 	log.Successf("Setup Bucket Source and HelmRelease successfully")
@@ -70,25 +50,60 @@ func SetupBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient
 	return nil
 }
 
+func createHelmReleaseObject(params SetupRunObjectParams) helmv2.HelmRelease {
+	return helmv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunDevHelmName,
+			Namespace: params.Namespace,
+			Annotations: map[string]string{
+				"metadata.weave.works/description": "This is a temporary HelmRelease created by GitOps Run. This will be cleaned up when this instance of GitOps Run is ended.",
+				RunIDAnnotation:                    params.SessionName,
+				"metadata.weave.works/username":    params.Username,
+			},
+		},
+		Spec: helmv2.HelmReleaseSpec{
+			Interval: metav1.Duration{Duration: 30 * 24 * time.Hour}, // 30 days
+			Chart: helmv2.HelmChartTemplate{
+				Spec: helmv2.HelmChartTemplateSpec{
+					Chart: params.Paths[0],
+					SourceRef: helmv2.CrossNamespaceObjectReference{
+						Kind: sourcev1.BucketKind,
+						Name: RunDevBucketName,
+					},
+					// the chart is rebuilt on every sync, not only when
+					// its version is bumped
+					ReconcileStrategy: sourcev1.ReconcileStrategyRevision,
+					ValuesFiles:       params.ValuesFiles,
+				},
+			},
+			Timeout:         &metav1.Duration{Duration: params.Timeout},
+			TargetNamespace: params.TargetNamespace,
+		},
+	}
+}
+
 // CleanupBucketSourceAndHelm removes the bucket source and ks
-func CleanupBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string) error {
-	// delete ks
-	helm := helmv2.HelmRelease{
+func CleanupBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, opts CleanupOptions) error {
+	var failures *multierror.Error
+
+	helm := &helmv2.HelmRelease{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      RunDevHelmName,
 			Namespace: namespace,
 		},
 	}
 
-	log.Actionf("Deleting HelmRelease %s ...", helm.Name)
+	if err := deleteObject(ctx, log, kubeClient, helm, opts); err != nil {
+		failures = multierror.Append(failures, err)
+	}
 
-	if err := kubeClient.Delete(ctx, &helm); err != nil {
-		log.Failuref("Error deleting HelmRelease %s: %v", helm.Name, err.Error())
-	} else {
-		log.Successf("Deleted HelmRelease %s", helm.Name)
+	if err := cleanupBucketAndSecretObjects(ctx, log, kubeClient, namespace, opts); err != nil {
+		failures = multierror.Append(failures, err)
 	}
 
-	cleanupBucketAndSecretObjects(ctx, log, kubeClient, namespace)
+	if err := failures.ErrorOrNil(); err != nil {
+		return err
+	}
 
 	log.Successf("Cleanup Bucket Source and HelmRelease successfully")
 
@@ -96,9 +111,7 @@ func CleanupBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClie
 }
 
 // ReconcileDevBucketSourceAndHelm reconciles the dev-bucket and dev-helm asynchronously.
-func ReconcileDevBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, timeout time.Duration) error {
-	const interval = 10 * time.Second
-
+func ReconcileDevBucketSourceAndHelm(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, opts run.WaitOptions) error {
 	log.Actionf("Start reconciling %s and %s ...", RunDevBucketName, RunDevHelmName)
 
 	// reconcile dev-bucket
@@ -117,41 +130,25 @@ func ReconcileDevBucketSourceAndHelm(ctx context.Context, log logger.Logger, kub
 
 	log.Actionf("Reconciling %s ...", RunDevBucketName)
 
-	// wait for the reconciliation of dev-bucket to be done
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		devBucket := &sourcev1.Bucket{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevBucketName,
-			Namespace: namespace,
-		}, devBucket); err != nil {
-			return false, err
-		}
+	devBucket := &sourcev1.Bucket{ObjectMeta: metav1.ObjectMeta{Name: RunDevBucketName, Namespace: namespace}}
 
-		return devBucket.Status.GetLastHandledReconcileRequest() == sourceRequestedAt, nil
-	}); err != nil {
+	// wait for the reconciliation of dev-bucket to be done
+	if err := run.WaitForObject(ctx, log, kubeClient, devBucket, "Bucket "+RunDevBucketName+" to be reconciled", opts,
+		run.ReconciledCondition(kubeClient, devBucket, sourceRequestedAt)); err != nil {
 		return err
 	}
 
 	log.Successf("Reconciled %s", RunDevBucketName)
 
 	// wait for devBucket to be ready
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		devBucket := &sourcev1.Bucket{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevBucketName,
-			Namespace: namespace,
-		}, devBucket); err != nil {
-			return false, err
-		}
-
-		return apimeta.IsStatusConditionPresentAndEqual(devBucket.Status.Conditions, meta.ReadyCondition, metav1.ConditionTrue), nil
-	}); err != nil {
+	if err := run.WaitForObject(ctx, log, kubeClient, devBucket, "Bucket "+RunDevBucketName+" to be ready", opts,
+		run.StatusCondition(kubeClient, devBucket, meta.ReadyCondition)); err != nil {
 		return err
 	}
 
 	log.Successf("Bucket %s is ready", RunDevBucketName)
 
-	// reconcile dev-ks
+	// reconcile dev-helm
 	helmRequestedAt, err := run.RequestReconciliation(ctx, kubeClient,
 		types.NamespacedName{
 			Name:      RunDevHelmName,
@@ -167,47 +164,35 @@ func ReconcileDevBucketSourceAndHelm(ctx context.Context, log logger.Logger, kub
 
 	log.Actionf("Reconciling %s ...", RunDevHelmName)
 
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		devHelm := &helmv2.HelmRelease{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevHelmName,
-			Namespace: namespace,
-		}, devHelm); err != nil {
-			return false, err
-		}
+	devHelm := &helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Name: RunDevHelmName, Namespace: namespace}}
 
-		return devHelm.Status.GetLastHandledReconcileRequest() == helmRequestedAt, nil
-	}); err != nil {
+	if err := run.WaitForObject(ctx, log, kubeClient, devHelm, "HelmRelease "+RunDevHelmName+" to be reconciled", opts,
+		run.ReconciledCondition(kubeClient, devHelm, helmRequestedAt)); err != nil {
 		return err
 	}
 
 	log.Successf("Reconciled %s", RunDevHelmName)
 
-	devHelm := &helmv2.HelmRelease{}
-	devHelmErr := wait.Poll(interval, timeout, func() (bool, error) {
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevHelmName,
-			Namespace: namespace,
-		}, devHelm); err != nil {
-			return false, err
+	// a failed release stops the wait, rather than waiting for the timeout
+	devHelmErr := run.WaitForObject(ctx, log, kubeClient, devHelm, "HelmRelease "+RunDevHelmName+" to be ready", opts, func(ctx context.Context) (bool, string, error) {
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(devHelm), devHelm); err != nil {
+			return false, "", err
 		}
 
-		log.Actionf("Waiting for %s to be ready ...", RunDevHelmName)
-
 		cond := apimeta.FindStatusCondition(devHelm.Status.Conditions, meta.ReadyCondition)
 		if cond == nil {
-			return false, nil
+			return false, "", nil
 		}
 
-		if cond.Status != "False" && cond.Status != "True" {
-			log.Waitingf("Waiting for HelmRelease %s to be ready: %s", devHelm.Name, cond.Message)
-			return false, nil
-		} else if cond.Status == "False" {
+		switch cond.Status {
+		case metav1.ConditionTrue:
+			return true, cond.Message, nil
+		case metav1.ConditionFalse:
 			log.Failuref("HelmRelease %s is not ready: %s", devHelm.Name, cond.Message)
-			return false, fmt.Errorf("HelmRelease %s is not ready: %s", devHelm.Name, cond.Message)
+			return false, cond.Message, fmt.Errorf("HelmRelease %s is not ready: %s", devHelm.Name, cond.Message)
+		default:
+			return false, cond.Message, nil
 		}
-
-		return true, nil
 	})
 
 	return devHelmErr
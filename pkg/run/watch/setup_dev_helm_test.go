@@ -0,0 +1,34 @@
+package watch
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+var _ = Describe("createHelmReleaseObject", func() {
+	It("builds the chart from the dev bucket on every revision", func() {
+		helm := createHelmReleaseObject(SetupRunObjectParams{
+			Namespace:   "flux-system",
+			Paths:       []string{"chart/podinfo"},
+			ValuesFiles: []string{"chart/podinfo/values.yaml", "chart/podinfo/values-dev.yaml"},
+		})
+		Expect(helm.Name).To(Equal(RunDevHelmName))
+		Expect(helm.Spec.Chart.Spec.Chart).To(Equal("chart/podinfo"))
+		Expect(helm.Spec.Chart.Spec.SourceRef.Name).To(Equal(RunDevBucketName))
+		Expect(helm.Spec.Chart.Spec.ReconcileStrategy).To(Equal(sourcev1.ReconcileStrategyRevision))
+		Expect(helm.Spec.Chart.Spec.ValuesFiles).To(Equal([]string{"chart/podinfo/values.yaml", "chart/podinfo/values-dev.yaml"}))
+		Expect(helm.Spec.TargetNamespace).To(BeEmpty())
+	})
+
+	It("installs the chart in the target namespace", func() {
+		helm := createHelmReleaseObject(SetupRunObjectParams{
+			Namespace:       "flux-system",
+			TargetNamespace: "dev",
+			Paths:           []string{"chart/podinfo"},
+		})
+		Expect(helm.Namespace).To(Equal("flux-system"))
+		Expect(helm.Spec.TargetNamespace).To(Equal("dev"))
+	})
+})
@@ -2,113 +2,515 @@ package watch
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/fluxcd/pkg/apis/meta"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/go-multierror"
 	"github.com/minio/minio-go/v7"
 	ignore "github.com/sabhiram/go-gitignore"
 	"github.com/weaveworks/weave-gitops/pkg/logger"
 	"github.com/weaveworks/weave-gitops/pkg/run"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// PathOrder is how the Kustomizations of the paths of a run depend on each
+// other.
+type PathOrder string
+
+const (
+	// PathOrderParallel applies all the paths at once.
+	PathOrderParallel PathOrder = "parallel"
+	// PathOrderSequential applies each path once the one before it is ready.
+	PathOrderSequential PathOrder = "sequential"
+)
+
 type SetupRunObjectParams struct {
-	Namespace     string
-	Path          string
+	Namespace string
+	// TargetNamespace is where the objects without a namespace are applied,
+	// instead of Namespace. It's created if it's missing.
+	TargetNamespace string
+	// Paths are synced by a Kustomization each, all from the dev bucket.
+	// A Helm chart is synced from the first one.
+	Paths     []string
+	PathOrder PathOrder
+	// ValuesFiles of the Helm chart, relative to the root of the dev
+	// bucket. They replace the values.yaml of the chart, so it has to be
+	// listed too to be used.
+	ValuesFiles   []string
 	Timeout       time.Duration
 	DevBucketPort int32
-	SessionName   string
-	Username      string
-	AccessKey     []byte
-	SecretKey     []byte
+	// DevRegistryPort is the port of the dev registry, when the paths are
+	// pushed as an OCI artifact instead of synced to the dev bucket.
+	DevRegistryPort int32
+	SessionName     string
+	Username        string
+	AccessKey       []byte
+	SecretKey       []byte
+	// Substitute and SubstituteFrom are the post-build substitutions of
+	// the Kustomizations, like in production.
+	Substitute     map[string]string
+	SubstituteFrom []kustomizev1.SubstituteReference
+	// DependsOn are the Kustomizations every Kustomization waits for, e.g.
+	// the ones applying the CRDs of the paths.
+	DependsOn []meta.NamespacedObjectReference
+	// HealthChecks are the objects the Kustomization of the last path waits
+	// for, instead of all the objects it applies.
+	HealthChecks []meta.NamespacedObjectKindReference
+}
+
+// ParseSubstituteFrom parses a reference to the ConfigMap or Secret holding
+// post-build substitution variables, e.g. "secret/cluster-vars" or
+// "configmap/cluster-vars".
+func ParseSubstituteFrom(ref string) (kustomizev1.SubstituteReference, error) {
+	kind, name, found := strings.Cut(ref, "/")
+	if !found || name == "" {
+		return kustomizev1.SubstituteReference{}, fmt.Errorf("invalid substitute-from %q, must be either secret/<name> or configmap/<name>", ref)
+	}
+
+	switch strings.ToLower(kind) {
+	case "secret":
+		kind = "Secret"
+	case "configmap", "cm":
+		kind = "ConfigMap"
+	default:
+		return kustomizev1.SubstituteReference{}, fmt.Errorf("invalid substitute-from kind %q, must be either secret or configmap", kind)
+	}
+
+	return kustomizev1.SubstituteReference{Kind: kind, Name: name}, nil
+}
+
+// ParseDependsOn parses a reference to a Kustomization, either
+// "<namespace>/<name>" or "<name>" for one in the namespace of the
+// Kustomizations of the run.
+func ParseDependsOn(ref string) (meta.NamespacedObjectReference, error) {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		namespace, name = "", ref
+	}
+
+	if name == "" || (found && namespace == "") || strings.Contains(name, "/") {
+		return meta.NamespacedObjectReference{}, fmt.Errorf("invalid depends-on %q, must be either <name> or <namespace>/<name>", ref)
+	}
+
+	return meta.NamespacedObjectReference{Namespace: namespace, Name: name}, nil
+}
+
+// healthCheckAPIVersions are the API versions of the kinds health checks
+// may be set for without one.
+var healthCheckAPIVersions = map[string]string{
+	"Deployment":    "apps/v1",
+	"StatefulSet":   "apps/v1",
+	"DaemonSet":     "apps/v1",
+	"HelmRelease":   helmv2.GroupVersion.String(),
+	"Kustomization": kustomizev1.GroupVersion.String(),
+}
+
+// ParseHealthCheck parses a reference to an object to check the health of,
+// "[<apiVersion>/]<kind>/<name>.<namespace>", e.g.
+// "Deployment/podinfo.apps" or "cert-manager.io/v1/Certificate/app.apps".
+// The API version may be left out for workloads and Flux objects.
+func ParseHealthCheck(ref string) (meta.NamespacedObjectKindReference, error) {
+	invalid := fmt.Errorf("invalid health check %q, must be [<apiVersion>/]<kind>/<name>.<namespace>", ref)
+
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || len(parts) > 4 {
+		return meta.NamespacedObjectKindReference{}, invalid
+	}
+
+	kind := parts[len(parts)-2]
+
+	dot := strings.LastIndex(parts[len(parts)-1], ".")
+	if dot < 0 {
+		return meta.NamespacedObjectKindReference{}, invalid
+	}
+
+	name, namespace := parts[len(parts)-1][:dot], parts[len(parts)-1][dot+1:]
+
+	apiVersion := strings.Join(parts[:len(parts)-2], "/")
+	if apiVersion == "" {
+		apiVersion = healthCheckAPIVersions[kind]
+		if apiVersion == "" {
+			return meta.NamespacedObjectKindReference{}, fmt.Errorf("the API version of the health check %q must be set for kind %s", ref, kind)
+		}
+	}
+
+	if kind == "" || name == "" || namespace == "" {
+		return meta.NamespacedObjectKindReference{}, invalid
+	}
+
+	return meta.NamespacedObjectKindReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       name,
+		Namespace:  namespace,
+	}, nil
+}
+
+// DevKsNames returns the names of the Kustomizations syncing count paths.
+// The first one is named RunDevKsName, like when there's a single path.
+func DevKsNames(count int) []string {
+	var names []string
+
+	for i := 1; i <= count; i++ {
+		if i == 1 {
+			names = append(names, RunDevKsName)
+		} else {
+			names = append(names, fmt.Sprintf("%s-%d", RunDevKsName, i))
+		}
+	}
+
+	return names
 }
 
 func SetupBucketSourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, params SetupRunObjectParams) error {
 	secret, source := createBucketAndSecretObjects(params)
 
-	ks := kustomizev1.Kustomization{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      RunDevKsName,
-			Namespace: params.Namespace,
-			Annotations: map[string]string{
-				"metadata.weave.works/description": "This is a temporary Kustomization created by GitOps Run. This will be cleaned up when this instance of GitOps Run is ended.",
-				"metadata.weave.works/run-id":      params.SessionName,
-				"metadata.weave.works/username":    params.Username,
+	err := reconcileBucketAndSecretObjects(ctx, log, kubeClient, secret, source)
+	if err != nil {
+		return err
+	}
+
+	if err := reconcileTargetNamespace(ctx, log, kubeClient, params.TargetNamespace); err != nil {
+		return err
+	}
+
+	kss := createKustomizationObjects(params, kustomizev1.CrossNamespaceSourceReference{
+		Kind: sourcev1.BucketKind,
+		Name: RunDevBucketName,
+	})
+
+	if err := reconcileKustomizationObjects(ctx, log, kubeClient, kss); err != nil {
+		return err
+	}
+
+	log.Successf("Setup Bucket Source and Kustomization successfully")
+
+	return nil
+}
+
+// reconcileTargetNamespace creates the target namespace of the objects if
+// it's missing. It's left in place at cleanup, as other objects may have
+// been put there meanwhile.
+func reconcileTargetNamespace(ctx context.Context, log logger.Logger, kubeClient client.Client, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	log.Actionf("Checking namespace %s ...", name)
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+
+	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(ns), ns); err == nil {
+		log.Successf("Namespace %s already existed", name)
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("couldn't get namespace %s: %w", name, err)
+	}
+
+	ns.Annotations = map[string]string{
+		"metadata.weave.works/description": "This is the target namespace created by GitOps Run.",
+	}
+
+	if err := kubeClient.Create(ctx, ns); err != nil {
+		return fmt.Errorf("couldn't create namespace %s: %w", name, err)
+	}
+
+	log.Successf("Created namespace %s", name)
+
+	return nil
+}
+
+func reconcileKustomizationObjects(ctx context.Context, log logger.Logger, kubeClient client.Client, kss []kustomizev1.Kustomization) error {
+	for _, ks := range kss {
+		// create ks
+		log.Actionf("Checking Kustomization %s ...", ks.Name)
+
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(&ks), &ks); err != nil && apierrors.IsNotFound(err) {
+			if err := kubeClient.Create(ctx, &ks); err != nil {
+				return fmt.Errorf("couldn't create kustomization %s: %v", ks.Name, err.Error())
+			} else {
+				log.Successf("Created Kustomization %s", ks.Name)
+			}
+		} else if err == nil {
+			log.Successf("Kustomization %s already existed", ks.Name)
+		}
+	}
+
+	return nil
+}
+
+func createKustomizationObjects(params SetupRunObjectParams, sourceRef kustomizev1.CrossNamespaceSourceReference) []kustomizev1.Kustomization {
+	var result []kustomizev1.Kustomization
+
+	names := DevKsNames(len(params.Paths))
+
+	for i, name := range names {
+		ks := kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: params.Namespace,
+				Annotations: map[string]string{
+					"metadata.weave.works/description": "This is a temporary Kustomization created by GitOps Run. This will be cleaned up when this instance of GitOps Run is ended.",
+					RunIDAnnotation:                    params.SessionName,
+					"metadata.weave.works/username":    params.Username,
+				},
 			},
-		},
-		Spec: kustomizev1.KustomizationSpec{
-			Interval: metav1.Duration{Duration: 30 * 24 * time.Hour}, // 30 days
-			Prune:    true,                                           // GC the kustomization
-			SourceRef: kustomizev1.CrossNamespaceSourceReference{
-				Kind: sourcev1.BucketKind,
-				Name: RunDevBucketName,
+			Spec: kustomizev1.KustomizationSpec{
+				Interval:  metav1.Duration{Duration: 30 * 24 * time.Hour}, // 30 days
+				Prune:     true,                                           // GC the kustomization
+				SourceRef: sourceRef,
+				Timeout:   &metav1.Duration{Duration: params.Timeout},
+				Path:      params.Paths[i],
+				Wait:      true,
+				// the objects without a namespace are applied in it
+				TargetNamespace: params.TargetNamespace,
 			},
-			Timeout: &metav1.Duration{Duration: params.Timeout},
-			Path:    params.Path,
-			Wait:    true,
-		},
+		}
+
+		if len(params.Substitute) > 0 || len(params.SubstituteFrom) > 0 {
+			ks.Spec.PostBuild = &kustomizev1.PostBuild{
+				Substitute:     params.Substitute,
+				SubstituteFrom: params.SubstituteFrom,
+			}
+		}
+
+		ks.Spec.DependsOn = append(ks.Spec.DependsOn, params.DependsOn...)
+
+		if params.PathOrder == PathOrderSequential && i > 0 {
+			ks.Spec.DependsOn = append(ks.Spec.DependsOn, meta.NamespacedObjectReference{Name: result[i-1].Name})
+		}
+
+		// the health checks are ignored while waiting for all the objects,
+		// and they're done last so the objects of all the paths are applied
+		if len(params.HealthChecks) > 0 && i == len(names)-1 {
+			ks.Spec.Wait = false
+			ks.Spec.HealthChecks = params.HealthChecks
+		}
+
+		result = append(result, ks)
 	}
 
-	err := reconcileBucketAndSecretObjects(ctx, log, kubeClient, secret, source)
+	return result
+}
+
+const (
+	// contentHashMetadata is the user metadata of the objects synced by
+	// SyncDir holding the SHA-256 sum of their content.
+	contentHashMetadata = "sha256"
+	// contentHashHeader is the header contentHashMetadata is returned in.
+	contentHashHeader = "X-Amz-Meta-Sha256"
+)
+
+// syncProgressInterval is how many uploads SyncDir reports its progress
+// after.
+const syncProgressInterval = 100
+
+// SyncDir uploads the files in a directory that changed since the last sync
+// to an S3 bucket with minio library, and removes the objects of the files
+// that were removed. The symlinks are synced like the files they point to
+// when followSymlinks is set. At most concurrency files are uploaded at once.
+func SyncDir(ctx context.Context, log logger.Logger, dir string, bucket string, client *minio.Client, ignorer *ignore.GitIgnore, followSymlinks bool, concurrency int) error {
+	start := time.Now()
+
+	log.Actionf("Syncing bucket %s ...", bucket)
+
+	exists, err := client.BucketExists(ctx, bucket)
 	if err != nil {
 		return err
 	}
 
-	// create ks
-	log.Actionf("Checking Kustomization %s ...", ks.Name)
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return err
+		}
+	}
+
+	// the sizes of the objects, their content hash is only checked when
+	// the size of the file is the same
+	sizes := map[string]int64{}
 
-	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(&ks), &ks); err != nil && apierrors.IsNotFound(err) {
-		if err := kubeClient.Create(ctx, &ks); err != nil {
-			return fmt.Errorf("couldn't create kustomization %s: %v", ks.Name, err.Error())
-		} else {
-			log.Successf("Created Kustomization %s", ks.Name)
+	for object := range client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
 		}
-	} else if err == nil {
-		log.Successf("Kustomization %s already existed", source.Name)
+
+		sizes[object.Key] = object.Size
 	}
 
-	log.Successf("Setup Bucket Source and Kustomization successfully")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg             sync.WaitGroup
+		mu             sync.Mutex
+		slots          = make(chan struct{}, concurrency)
+		failures       *multierror.Error
+		uploadCount    int
+		unchangedCount int
+	)
+
+	err = walkSyncedFiles(log, dir, ignorer, followSymlinks, func(path, objectName string, info os.FileInfo) error {
+		size, synced := sizes[objectName]
+		// the objects left once the walk is done are the removed files
+		delete(sizes, objectName)
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			uploaded, err := syncFile(ctx, client, bucket, path, objectName, size, synced)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err != nil:
+				if !errors.Is(err, context.Canceled) {
+					failures = multierror.Append(failures, fmt.Errorf("%v: %w", path, err))
+				}
+			case uploaded:
+				uploadCount++
+				// through the logger, stdout may be JSON lines
+				if uploadCount%syncProgressInterval == 0 {
+					log.Waitingf("Uploaded %d files", uploadCount)
+				}
+			default:
+				unchangedCount++
+			}
+		}()
+
+		return nil
+	})
+
+	wg.Wait()
+
+	if failures != nil {
+		// Report the errors, but continue anyway - this could be e.g.
+		// a file with odd permissions, which isn't necessarily a problem
+		log.Failuref("Couldn't upload %d files: %v", failures.Len(), failures)
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Failuref("Error syncing directory: %v", err)
+		return err
+	}
+
+	removeCount := 0
+
+	// only a complete walk tells which files were removed
+	if err == nil && ctx.Err() == nil {
+		for objectName := range sizes {
+			if err := client.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{}); err != nil {
+				log.Failuref("Couldn't remove %v: %v", objectName, err)
+				continue
+			}
+
+			removeCount++
+		}
+	}
+
+	log.Actionf("Synced in %v: %d files uploaded, %d removed, %d unchanged",
+		time.Since(start).Round(time.Millisecond), uploadCount, removeCount, unchangedCount)
 
 	return nil
 }
 
-// SyncDir recursively uploads all files in a directory to an S3 bucket with minio library
-func SyncDir(ctx context.Context, log logger.Logger, dir string, bucket string, client *minio.Client, ignorer *ignore.GitIgnore) error {
-	log.Actionf("Refreshing bucket %s ...", bucket)
+// syncFile uploads the file at path as objectName, unless the object already
+// synced, of the given size, has the same content. It returns whether it
+// uploaded the file.
+//
+// The content is compared with the SHA-256 sum stored in the metadata of the
+// object on upload, as the ETag is only the MD5 sum of the content for
+// objects uploaded in a single part.
+func syncFile(ctx context.Context, client *minio.Client, bucket, path, objectName string, size int64, synced bool) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return false, err
+	}
 
-	if err := client.RemoveBucketWithOptions(ctx, bucket, minio.RemoveBucketOptions{
-		ForceDelete: true,
-	}); err != nil {
-		// if error is not bucket not found, return error
-		if !strings.Contains(err.Error(), "NoSuchBucket") {
-			return err
+	if synced && info.Size() == size {
+		object, err := client.StatObject(ctx, bucket, objectName, minio.StatObjectOptions{})
+		if err == nil && object.Metadata.Get(contentHashHeader) == sum {
+			return false, nil
 		}
 	}
 
-	if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
-		return err
+	opts := minio.PutObjectOptions{
+		UserMetadata: map[string]string{contentHashMetadata: sum},
+		// the streaming signature of an empty body has no content length,
+		// which the dev bucket server rejects
+		DisableContentSha256: info.Size() == 0,
 	}
 
-	uploadCount := 0
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	if _, err := client.FPutObject(ctx, bucket, objectName, path, opts); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// fileSHA256 returns the SHA-256 sum of the content of the file at path, in
+// hex.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// walkSyncedFiles calls fn with each file under dir that is synced to the
+// cluster, along with its path relative to dir, with forward slashes on every
+// system so it can be used as an object name. Hidden directories and the
+// files the ignorer matches are skipped. The symlinks are followed when
+// followSymlinks is set, see WalkDir.
+func walkSyncedFiles(log logger.Logger, dir string, ignorer *ignore.GitIgnore, followSymlinks bool, fn func(path, relPath string, info os.FileInfo) error) error {
+	return WalkDir(dir, followSymlinks, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			log.Failuref("Error walking directory: %v", err)
 			return err
@@ -116,85 +518,79 @@ func SyncDir(ctx context.Context, log logger.Logger, dir string, bucket string,
 
 		if info.IsDir() {
 			// if it's a hidden directory, ignore it
-			if strings.HasPrefix(info.Name(), ".") {
+			if path != dir && isHidden(info) {
 				return filepath.SkipDir
 			}
 
 			return nil
 		}
 
-		objectName, err := filepath.Rel(dir, path)
+		relPath, err := filepath.Rel(dir, path)
 		if err != nil {
 			log.Failuref("Error getting relative path: %v", err)
 			return err
 		}
+
+		relPath = filepath.ToSlash(relPath)
+
 		if ignorer.MatchesPath(path) {
 			return nil
 		}
-		// upload the file
-		_, err = client.FPutObject(ctx, bucket, objectName, path, minio.PutObjectOptions{})
 
-		if err != nil {
-			if errors.Is(err, context.Canceled) {
-				return err
-			}
-			errResp, ok := err.(minio.ErrorResponse)
-			if ok && errResp.Code == "MissingContentLength" {
-				// This happens when the file was empty - this is OK
-				return nil
-			}
-			// Report the error, but continue anyway - this could be e.g.
-			// a file with odd permissions, which isn't necessarily a problem
-			log.Failuref("Couldn't upload %v: %v", path, err)
-			return nil
-		}
-		uploadCount = uploadCount + 1
-		if uploadCount%10 == 0 {
-			fmt.Print(".")
-		}
-		return nil
+		return fn(path, relPath, info)
 	})
+}
 
-	fmt.Println()
-	log.Actionf("Uploaded %d files", uploadCount)
+// CleanupBucketSourceAndKS removes the bucket source and the Kustomizations
+// syncing from it
+func CleanupBucketSourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, opts CleanupOptions) error {
+	var failures *multierror.Error
 
-	if err != nil && !errors.Is(err, context.Canceled) {
-		log.Failuref("Error syncing directory: %v", err)
+	if err := cleanupKustomizationObjects(ctx, log, kubeClient, namespace, sourcev1.BucketKind, RunDevBucketName, opts); err != nil {
+		failures = multierror.Append(failures, err)
+	}
+
+	if err := cleanupBucketAndSecretObjects(ctx, log, kubeClient, namespace, opts); err != nil {
+		failures = multierror.Append(failures, err)
+	}
+
+	if err := failures.ErrorOrNil(); err != nil {
 		return err
 	}
 
+	log.Successf("Cleanup Bucket Source and Kustomization successfully")
+
 	return nil
 }
 
-// CleanupBucketSourceAndKS removes the bucket source and ks
-func CleanupBucketSourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string) error {
-	// delete ks
-	ks := kustomizev1.Kustomization{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      RunDevKsName,
-			Namespace: namespace,
-		},
+// cleanupKustomizationObjects deletes the Kustomizations syncing from a source
+func cleanupKustomizationObjects(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace, sourceKind, sourceName string, opts CleanupOptions) error {
+	list := kustomizev1.KustomizationList{}
+	if err := kubeClient.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		log.Failuref("Error listing Kustomizations: %v", err.Error())
+		return err
 	}
 
-	log.Actionf("Deleting Kustomization %s ...", ks.Name)
+	var objects []client.Object
 
-	if err := kubeClient.Delete(ctx, &ks); err != nil {
-		log.Failuref("Error deleting Kustomization %s: %v", ks.Name, err.Error())
-	} else {
-		log.Successf("Deleted Kustomization %s", ks.Name)
-	}
-
-	cleanupBucketAndSecretObjects(ctx, log, kubeClient, namespace)
+	// delete the dependents first, in case Flux waits for them
+	for i := len(list.Items) - 1; i >= 0; i-- {
+		ks := &list.Items[i]
+		if ks.Spec.SourceRef.Kind != sourceKind || ks.Spec.SourceRef.Name != sourceName {
+			continue
+		}
 
-	log.Successf("Cleanup Bucket Source and Kustomization successfully")
+		objects = append(objects, ks)
+	}
 
-	return nil
+	return DeleteObjects(ctx, log, kubeClient, objects, opts)
 }
 
-// findConditionMessages finds the messages in the condition of objects in the inventory.
-func findConditionMessages(ctx context.Context, kubeClient client.Client, ks *kustomizev1.Kustomization) ([]string, error) {
+// findConditionMessages finds the messages in the condition of objects in the
+// inventory, along with the objects having them.
+func findConditionMessages(ctx context.Context, kubeClient client.Client, ks *kustomizev1.Kustomization) ([]string, []unstructured.Unstructured, error) {
 	if ks.Status.Inventory == nil {
-		return nil, fmt.Errorf("inventory is nil")
+		return nil, nil, fmt.Errorf("inventory is nil")
 	}
 
 	gvks := map[string]schema.GroupVersionKind{}
@@ -202,7 +598,7 @@ func findConditionMessages(ctx context.Context, kubeClient client.Client, ks *ku
 	for _, entry := range ks.Status.Inventory.Entries {
 		objMeta, err := object.ParseObjMetadata(entry.ID)
 		if err != nil {
-			return nil, fmt.Errorf("invalid inventory item '%s', error: %w", entry.ID, err)
+			return nil, nil, fmt.Errorf("invalid inventory item '%s', error: %w", entry.ID, err)
 		}
 
 		gvkID := strings.Join([]string{objMeta.GroupKind.Group, entry.Version, objMeta.GroupKind.Kind}, "_")
@@ -216,7 +612,10 @@ func findConditionMessages(ctx context.Context, kubeClient client.Client, ks *ku
 		}
 	}
 
-	var messages []string
+	var (
+		messages []string
+		failing  []unstructured.Unstructured
+	)
 
 	for _, gvk := range gvks {
 		unstructuredList := &unstructured.UnstructuredList{}
@@ -232,10 +631,12 @@ func findConditionMessages(ctx context.Context, kubeClient client.Client, ks *ku
 				).AsSelector(),
 			},
 		); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		for _, u := range unstructuredList.Items {
+			failed := false
+
 			if conditions, found, err := unstructured.NestedSlice(u.UnstructuredContent(), "status", "conditions"); err == nil && found {
 				for _, condition := range conditions {
 					c := condition.(map[string]interface{})
@@ -243,15 +644,20 @@ func findConditionMessages(ctx context.Context, kubeClient client.Client, ks *ku
 						if status != "True" {
 							if message, found, err := unstructured.NestedString(c, "message"); err == nil && found {
 								messages = append(messages, fmt.Sprintf("%s %s/%s: %s", u.GetKind(), u.GetNamespace(), u.GetName(), message))
+								failed = true
 							}
 						}
 					}
 				}
 			}
+
+			if failed {
+				failing = append(failing, u)
+			}
 		}
 	}
 
-	return messages, nil
+	return messages, failing, nil
 }
 
 func WatchDirsForFileWalker(watcher *fsnotify.Watcher, ignorer *ignore.GitIgnore) func(path string, info os.FileInfo, err error) error {
@@ -262,7 +668,7 @@ func WatchDirsForFileWalker(watcher *fsnotify.Watcher, ignorer *ignore.GitIgnore
 
 		if info.IsDir() {
 			// if it's a hidden directory, ignore it
-			if strings.HasPrefix(info.Name(), ".") {
+			if isHidden(info) {
 				return filepath.SkipDir
 			}
 
@@ -326,9 +732,12 @@ resources: [] # 👋 Start adding the resources you want to sync here
 	return nil
 }
 
-// ReconcileDevBucketSourceAndKS reconciles the dev-bucket and dev-ks asynchronously.
-func ReconcileDevBucketSourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, timeout time.Duration) error {
-	const interval = 3 * time.Second / 2
+// ReconcileDevBucketSourceAndKS reconciles the dev-bucket and the
+// Kustomizations syncing from it, named after DevKsNames, asynchronously.
+func ReconcileDevBucketSourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, namespace string, ksNames []string, opts run.WaitOptions) error {
+	// the progress of the reconciliations is reported by the Flux
+	// controllers
+	fluxLog := logger.WithLogSource(log, logger.LogSourceFlux)
 
 	// reconcile dev-bucket
 	sourceRequestedAt, err := run.RequestReconciliation(ctx, kubeClient,
@@ -344,111 +753,124 @@ func ReconcileDevBucketSourceAndKS(ctx context.Context, log logger.Logger, kubeC
 		return err
 	}
 
-	// wait for the reconciliation of dev-bucket to be done
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		devBucket := &sourcev1.Bucket{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevBucketName,
-			Namespace: namespace,
-		}, devBucket); err != nil {
-			return false, err
-		}
+	devBucket := &sourcev1.Bucket{ObjectMeta: metav1.ObjectMeta{Name: RunDevBucketName, Namespace: namespace}}
 
-		return devBucket.Status.GetLastHandledReconcileRequest() == sourceRequestedAt, nil
-	}); err != nil {
+	// wait for the reconciliation of dev-bucket to be done
+	if err := run.WaitForObject(ctx, fluxLog, kubeClient, devBucket, "Bucket "+RunDevBucketName+" to be reconciled", opts,
+		run.ReconciledCondition(kubeClient, devBucket, sourceRequestedAt)); err != nil {
 		return err
 	}
 
 	// wait for devBucket to be ready
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		devBucket := &sourcev1.Bucket{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevBucketName,
-			Namespace: namespace,
-		}, devBucket); err != nil {
-			return false, err
-		}
-		return apimeta.IsStatusConditionPresentAndEqual(devBucket.Status.Conditions, meta.ReadyCondition, metav1.ConditionTrue), nil
-	}); err != nil {
+	if err := run.WaitForObject(ctx, fluxLog, kubeClient, devBucket, "Bucket "+RunDevBucketName+" to be ready", opts,
+		run.StatusCondition(kubeClient, devBucket, meta.ReadyCondition)); err != nil {
 		return err
 	}
 
-	// reconcile dev-ks
-	ksRequestedAt, err := run.RequestReconciliation(ctx, kubeClient,
-		types.NamespacedName{
-			Name:      RunDevKsName,
-			Namespace: namespace,
-		}, schema.GroupVersionKind{
-			Group:   "kustomize.toolkit.fluxcd.io",
-			Version: "v1beta2",
-			Kind:    kustomizev1.KustomizationKind,
-		})
-	if err != nil {
-		return err
-	}
+	return reconcileDevKustomizations(ctx, log, kubeClient, cfg, namespace, ksNames, opts)
+}
 
-	if err := wait.Poll(interval, timeout, func() (bool, error) {
-		devKs := &kustomizev1.Kustomization{}
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevKsName,
-			Namespace: namespace,
-		}, devKs); err != nil {
-			return false, err
+// reconcileDevKustomizations reconciles the Kustomizations named ksNames, once
+// their source is ready. When one of them doesn't become healthy, the
+// failures of its objects are shown, along with their events and the logs of
+// the kustomize-controller about it.
+func reconcileDevKustomizations(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, namespace string, ksNames []string, opts run.WaitOptions) error {
+	// the failures are reported by the events of the Kustomizations
+	fluxLog := logger.WithLogSource(log, logger.LogSourceFlux)
+	ksLog := logger.WithLogSource(log, logger.LogSourceKustomization)
+
+	// request all the reconciliations first, so the ones that don't depend
+	// on each other are done at the same time
+	ksRequestedAt := make([]string, len(ksNames))
+
+	for i, name := range ksNames {
+		var err error
+
+		ksRequestedAt[i], err = run.RequestReconciliation(ctx, kubeClient,
+			types.NamespacedName{
+				Name:      name,
+				Namespace: namespace,
+			}, schema.GroupVersionKind{
+				Group:   "kustomize.toolkit.fluxcd.io",
+				Version: "v1beta2",
+				Kind:    kustomizev1.KustomizationKind,
+			})
+		if err != nil {
+			return err
 		}
-
-		return devKs.Status.GetLastHandledReconcileRequest() == ksRequestedAt, nil
-	}); err != nil {
-		return err
 	}
 
-	devKs := &kustomizev1.Kustomization{}
-	devKsErr := wait.Poll(interval, timeout, func() (bool, error) {
-		if err := kubeClient.Get(ctx, types.NamespacedName{
-			Name:      RunDevKsName,
-			Namespace: namespace,
-		}, devKs); err != nil {
-			return false, err
+	for i, name := range ksNames {
+		devKs := &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+
+		if err := run.WaitForObject(ctx, fluxLog, kubeClient, devKs, "Kustomization "+name+" to be reconciled", opts,
+			run.ReconciledCondition(kubeClient, devKs, ksRequestedAt[i])); err != nil {
+			return err
 		}
 
-		healthy := apimeta.IsStatusConditionPresentAndEqual(
-			devKs.Status.Conditions,
-			kustomizev1.HealthyCondition,
-			metav1.ConditionTrue,
-		)
-		return healthy, nil
-	})
+		devKsErr := run.WaitForObject(ctx, fluxLog, kubeClient, devKs, "Kustomization "+name+" to be healthy", opts,
+			run.StatusCondition(kubeClient, devKs, kustomizev1.HealthyCondition))
 
-	if devKsErr != nil {
-		messages, err := findConditionMessages(ctx, kubeClient, devKs)
-		if err != nil {
-			return err
+		if devKsErr != nil {
+			messages, failing, err := findConditionMessages(ctx, kubeClient, devKs)
+			if err != nil {
+				return err
+			}
+
+			for _, msg := range messages {
+				ksLog.Failuref(msg)
+			}
+
+			showDiagnostics(ctx, log, kubeClient, cfg, namespace, name, failing)
+
+			return devKsErr
 		}
+	}
+
+	return nil
+}
 
-		for _, msg := range messages {
-			log.Failuref(msg)
+// ignoreFiles are the files in the root dir listing the files that aren't
+// synced: the .gitignore, and the .sourceignore Flux honors for its sources.
+var ignoreFiles = []string{".gitignore", ".sourceignore"}
+
+// AreEventsLost returns whether the error of a file watcher means some
+// change events were lost, so all the files must be synced again. On
+// Windows, the watcher reports it as a short read of its buffer.
+func AreEventsLost(err error) bool {
+	return errors.Is(err, fsnotify.ErrEventOverflow) || err.Error() == "short read in readEvents()"
+}
+
+// IsIgnoreFile returns whether path is one of the ignore files of rootDir,
+// so the matcher CreateIgnorer returns must be created again when it changes.
+func IsIgnoreFile(rootDir, path string) bool {
+	for _, name := range ignoreFiles {
+		if filepath.Clean(path) == filepath.Join(rootDir, name) {
+			return true
 		}
 	}
 
-	return devKsErr
+	return false
 }
 
-func CreateIgnorer(gitRootDir string) *ignore.GitIgnore {
-	ignoreFile := filepath.Join(gitRootDir, ".gitignore")
+// CreateIgnorer returns a matcher of the files under rootDir that aren't
+// synced, the ones listed by its ignore files along with the excludes
+// patterns, in the .gitignore format.
+func CreateIgnorer(rootDir string, excludes ...string) *ignore.GitIgnore {
+	var lines []string
 
-	var ignorer *ignore.GitIgnore = nil
-	if _, err := os.Stat(ignoreFile); err == nil {
-		ignorer, err = ignore.CompileIgnoreFile(ignoreFile)
+	for _, name := range ignoreFiles {
+		content, err := os.ReadFile(filepath.Join(rootDir, name))
 		if err != nil {
-			// If we couldn't parse gitignore, just ignore nothing
-			ignorer = nil
+			// Whether there was no ignore file or it couldn't be read,
+			// it just doesn't ignore anything
+			continue
 		}
-	}
 
-	if ignorer == nil {
-		// Whether there was no gitignore file or the one that was there was broken,
-		// fall back to just no ignore lines - this is just a pass-through
-		ignorer = ignore.CompileIgnoreLines()
+		lines = append(lines, strings.Split(string(content), "\n")...)
 	}
 
-	return ignorer
+	lines = append(lines, excludes...)
+
+	return ignore.CompileIgnoreLines(lines...)
 }
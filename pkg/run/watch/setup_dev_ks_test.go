@@ -1,16 +1,36 @@
 package watch
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fsnotify/fsnotify"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 // mock controller-runtime client
@@ -122,7 +142,7 @@ var _ = Describe("findConditionMessages", func() {
 				},
 			},
 		}
-		messages, err := findConditionMessages(context.Background(), client, ks)
+		messages, _, err := findConditionMessages(context.Background(), client, ks)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(messages).To(Equal([]string{
 			"Deployment default/deployment: This is message",
@@ -132,6 +152,182 @@ var _ = Describe("findConditionMessages", func() {
 	})
 })
 
+var _ = Describe("createKustomizationObjects", func() {
+	It("creates a Kustomization per path", func() {
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace: "flux-system",
+			Paths:     []string{"infrastructure", "apps"},
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+		Expect(kss).To(HaveLen(2))
+		Expect(kss[0].Name).To(Equal(RunDevKsName))
+		Expect(kss[0].Spec.Path).To(Equal("infrastructure"))
+		Expect(kss[1].Name).To(Equal(RunDevKsName + "-2"))
+		Expect(kss[1].Spec.Path).To(Equal("apps"))
+		Expect(kss[1].Spec.SourceRef.Name).To(Equal(RunDevBucketName))
+		Expect(kss[1].Spec.DependsOn).To(BeEmpty())
+	})
+
+	It("makes each Kustomization depend on the one before it in sequential order", func() {
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace: "flux-system",
+			Paths:     []string{"infrastructure", "configs", "apps"},
+			PathOrder: PathOrderSequential,
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+		Expect(kss).To(HaveLen(3))
+		Expect(kss[0].Spec.DependsOn).To(BeEmpty())
+		Expect(kss[1].Spec.DependsOn).To(Equal([]meta.NamespacedObjectReference{{Name: RunDevKsName}}))
+		Expect(kss[2].Spec.DependsOn).To(Equal([]meta.NamespacedObjectReference{{Name: RunDevKsName + "-2"}}))
+	})
+})
+
+var _ = Describe("createKustomizationObjects target namespace", func() {
+	It("applies the objects of every Kustomization in the target namespace", func() {
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace:       "flux-system",
+			TargetNamespace: "dev",
+			Paths:           []string{"infrastructure", "apps"},
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+
+		for _, ks := range kss {
+			Expect(ks.Namespace).To(Equal("flux-system"))
+			Expect(ks.Spec.TargetNamespace).To(Equal("dev"))
+		}
+	})
+})
+
+var _ = Describe("reconcileTargetNamespace", func() {
+	It("creates the target namespace if it's missing", func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "staging"}},
+		).Build()
+
+		log := logger.NewCLILogger(io.Discard)
+
+		Expect(reconcileTargetNamespace(context.Background(), log, kubeClient, "dev")).To(Succeed())
+		Expect(reconcileTargetNamespace(context.Background(), log, kubeClient, "staging")).To(Succeed())
+		Expect(reconcileTargetNamespace(context.Background(), log, kubeClient, "")).To(Succeed())
+
+		namespaces := &corev1.NamespaceList{}
+		Expect(kubeClient.List(context.Background(), namespaces)).To(Succeed())
+		Expect(namespaces.Items).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("createKustomizationObjects post-build", func() {
+	It("substitutes the variables in every Kustomization", func() {
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace:      "flux-system",
+			Paths:          []string{"infrastructure", "apps"},
+			Substitute:     map[string]string{"cluster_env": "dev"},
+			SubstituteFrom: []kustomizev1.SubstituteReference{{Kind: "Secret", Name: "cluster-vars"}},
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+		Expect(kss).To(HaveLen(2))
+
+		for _, ks := range kss {
+			Expect(ks.Spec.PostBuild).ToNot(BeNil())
+			Expect(ks.Spec.PostBuild.Substitute).To(Equal(map[string]string{"cluster_env": "dev"}))
+			Expect(ks.Spec.PostBuild.SubstituteFrom).To(Equal([]kustomizev1.SubstituteReference{{Kind: "Secret", Name: "cluster-vars"}}))
+		}
+	})
+
+	It("leaves out the post-build without substitutions", func() {
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace: "flux-system",
+			Paths:     []string{"apps"},
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+		Expect(kss[0].Spec.PostBuild).To(BeNil())
+	})
+})
+
+var _ = Describe("createKustomizationObjects dependencies and health checks", func() {
+	It("makes every Kustomization depend on the given ones", func() {
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace: "flux-system",
+			Paths:     []string{"infrastructure", "apps"},
+			PathOrder: PathOrderSequential,
+			DependsOn: []meta.NamespacedObjectReference{{Name: "crds"}},
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+		Expect(kss[0].Spec.DependsOn).To(Equal([]meta.NamespacedObjectReference{{Name: "crds"}}))
+		Expect(kss[1].Spec.DependsOn).To(Equal([]meta.NamespacedObjectReference{{Name: "crds"}, {Name: RunDevKsName}}))
+	})
+
+	It("checks the health of the given objects in the last Kustomization", func() {
+		healthChecks := []meta.NamespacedObjectKindReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "podinfo", Namespace: "apps"}}
+
+		kss := createKustomizationObjects(SetupRunObjectParams{
+			Namespace:    "flux-system",
+			Paths:        []string{"infrastructure", "apps"},
+			HealthChecks: healthChecks,
+		}, kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.BucketKind, Name: RunDevBucketName})
+		Expect(kss[0].Spec.Wait).To(BeTrue())
+		Expect(kss[0].Spec.HealthChecks).To(BeEmpty())
+		Expect(kss[1].Spec.Wait).To(BeFalse())
+		Expect(kss[1].Spec.HealthChecks).To(Equal(healthChecks))
+	})
+})
+
+var _ = DescribeTable("ParseDependsOn", func(ref string, expected meta.NamespacedObjectReference) {
+	result, err := ParseDependsOn(ref)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(result).To(Equal(expected))
+},
+	Entry("name", "crds", meta.NamespacedObjectReference{Name: "crds"}),
+	Entry("namespace and name", "infra/crds", meta.NamespacedObjectReference{Namespace: "infra", Name: "crds"}),
+)
+
+var _ = DescribeTable("ParseDependsOn errors", func(ref string) {
+	_, err := ParseDependsOn(ref)
+	Expect(err).To(MatchError(ContainSubstring("must be either <name> or <namespace>/<name>")))
+},
+	Entry("empty", ""),
+	Entry("no namespace", "/crds"),
+	Entry("no name", "infra/"),
+	Entry("too many parts", "infra/crds/more"),
+)
+
+var _ = DescribeTable("ParseHealthCheck", func(ref string, expected meta.NamespacedObjectKindReference) {
+	result, err := ParseHealthCheck(ref)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(result).To(Equal(expected))
+},
+	Entry("workload", "Deployment/podinfo.apps", meta.NamespacedObjectKindReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "podinfo", Namespace: "apps"}),
+	Entry("dotted name", "HelmRelease/podinfo.v6.apps", meta.NamespacedObjectKindReference{APIVersion: "helm.toolkit.fluxcd.io/v2beta1", Kind: "HelmRelease", Name: "podinfo.v6", Namespace: "apps"}),
+	Entry("core API version", "v1/Service/podinfo.apps", meta.NamespacedObjectKindReference{APIVersion: "v1", Kind: "Service", Name: "podinfo", Namespace: "apps"}),
+	Entry("API version", "cert-manager.io/v1/Certificate/app.apps", meta.NamespacedObjectKindReference{APIVersion: "cert-manager.io/v1", Kind: "Certificate", Name: "app", Namespace: "apps"}),
+)
+
+var _ = DescribeTable("ParseHealthCheck errors", func(ref string, expected string) {
+	_, err := ParseHealthCheck(ref)
+	Expect(err).To(MatchError(ContainSubstring(expected)))
+},
+	Entry("no kind", "podinfo.apps", "must be [<apiVersion>/]<kind>/<name>.<namespace>"),
+	Entry("no namespace", "Deployment/podinfo", "must be [<apiVersion>/]<kind>/<name>.<namespace>"),
+	Entry("no name", "Deployment/.apps", "must be [<apiVersion>/]<kind>/<name>.<namespace>"),
+	Entry("unknown kind", "Certificate/app.apps", "must be set for kind Certificate"),
+)
+
+var _ = DescribeTable("ParseSubstituteFrom", func(ref string, expected kustomizev1.SubstituteReference) {
+	result, err := ParseSubstituteFrom(ref)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(result).To(Equal(expected))
+},
+	Entry("secret", "secret/cluster-vars", kustomizev1.SubstituteReference{Kind: "Secret", Name: "cluster-vars"}),
+	Entry("configmap", "ConfigMap/cluster-vars", kustomizev1.SubstituteReference{Kind: "ConfigMap", Name: "cluster-vars"}),
+	Entry("configmap short name", "cm/cluster-vars", kustomizev1.SubstituteReference{Kind: "ConfigMap", Name: "cluster-vars"}),
+)
+
+var _ = DescribeTable("ParseSubstituteFrom errors", func(ref string, expected string) {
+	_, err := ParseSubstituteFrom(ref)
+	Expect(err).To(MatchError(ContainSubstring(expected)))
+},
+	Entry("no kind", "cluster-vars", "must be either secret/<name> or configmap/<name>"),
+	Entry("no name", "secret/", "must be either secret/<name> or configmap/<name>"),
+	Entry("other kind", "deployment/app", "invalid substitute-from kind"),
+)
+
 var _ = Describe("InitializeTargetDir", func() {
 	It("creates a file in an empty directory", func() {
 		dir, err := os.MkdirTemp("", "target-dir")
@@ -204,4 +400,190 @@ var _ = Describe("CreateIgnorer", func() {
 		ignorer := CreateIgnorer(str)
 		Expect(ignorer.MatchesPath("bin/gitops")).To(Equal(false))
 	})
+	It("combines the sourceignore and the excludes", func() {
+		dir, err := os.MkdirTemp("", "root-dir")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("bin/\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".sourceignore"), []byte("# docs aren't applied\n*.md\n"), 0644)).To(Succeed())
+
+		ignorer := CreateIgnorer(dir, "*.tmp")
+		Expect(ignorer.MatchesPath("bin/gitops")).To(Equal(true))
+		Expect(ignorer.MatchesPath("apps/README.md")).To(Equal(true))
+		Expect(ignorer.MatchesPath("apps/app.yaml.tmp")).To(Equal(true))
+		Expect(ignorer.MatchesPath("apps/app.yaml")).To(Equal(false))
+	})
+})
+
+var _ = Describe("SyncDir", func() {
+	It("only uploads the changed files and removes the deleted ones", func() {
+		ctx := context.Background()
+
+		srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+		defer srv.Close()
+
+		minioClient, err := minio.New(strings.TrimPrefix(srv.URL, "http://"), &minio.Options{
+			Creds: credentials.NewStaticV4("access", "secret", ""),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		dir, err := os.MkdirTemp("", "sync-dir")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(os.WriteFile(filepath.Join(dir, "unchanged.yaml"), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "changed.yaml"), []byte("kind: Secret"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "removed.yaml"), []byte("kind: Service"), 0644)).To(Succeed())
+
+		log := logger.NewCLILogger(io.Discard)
+		ignorer := ignore.CompileIgnoreLines()
+
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+
+		unchanged, err := minioClient.StatObject(ctx, RunDevBucketName, "unchanged.yaml", minio.StatObjectOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(dir, "changed.yaml"), []byte("kind: Deployment"), 0644)).To(Succeed())
+		Expect(os.Remove(filepath.Join(dir, "removed.yaml"))).To(Succeed())
+
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+
+		var keys []string
+		for object := range minioClient.ListObjects(ctx, RunDevBucketName, minio.ListObjectsOptions{Recursive: true}) {
+			Expect(object.Err).ToNot(HaveOccurred())
+			keys = append(keys, object.Key)
+		}
+		Expect(keys).To(ConsistOf("unchanged.yaml", "changed.yaml"))
+
+		stillUnchanged, err := minioClient.StatObject(ctx, RunDevBucketName, "unchanged.yaml", minio.StatObjectOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stillUnchanged.LastModified).To(Equal(unchanged.LastModified))
+
+		changed, err := minioClient.GetObject(ctx, RunDevBucketName, "changed.yaml", minio.GetObjectOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		content, err := io.ReadAll(changed)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("kind: Deployment"))
+	})
+
+	It("compares the content of the files with the same size", func() {
+		ctx := context.Background()
+
+		srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+		defer srv.Close()
+
+		minioClient, err := minio.New(strings.TrimPrefix(srv.URL, "http://"), &minio.Options{
+			Creds: credentials.NewStaticV4("access", "secret", ""),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		dir, err := os.MkdirTemp("", "sync-dir")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(os.WriteFile(filepath.Join(dir, "empty.yaml"), nil, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "changed.yaml"), []byte("kind: Secret"), 0644)).To(Succeed())
+
+		var out bytes.Buffer
+
+		log := logger.NewCLILogger(&out)
+		ignorer := ignore.CompileIgnoreLines()
+
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("2 files uploaded, 0 removed, 0 unchanged"))
+
+		_, err = minioClient.StatObject(ctx, RunDevBucketName, "empty.yaml", minio.StatObjectOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		out.Reset()
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("0 files uploaded, 0 removed, 2 unchanged"))
+
+		// same size, other content
+		Expect(os.WriteFile(filepath.Join(dir, "changed.yaml"), []byte("kind: Sacret"), 0644)).To(Succeed())
+
+		out.Reset()
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("1 files uploaded, 0 removed, 1 unchanged"))
+
+		changed, err := minioClient.GetObject(ctx, RunDevBucketName, "changed.yaml", minio.GetObjectOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		content, err := io.ReadAll(changed)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("kind: Sacret"))
+	})
+
+	It("reports the progress through the logger", func() {
+		ctx := context.Background()
+
+		srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+		defer srv.Close()
+
+		minioClient, err := minio.New(strings.TrimPrefix(srv.URL, "http://"), &minio.Options{
+			Creds: credentials.NewStaticV4("access", "secret", ""),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		dir, err := os.MkdirTemp("", "sync-dir")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		for i := 0; i < 120; i++ {
+			Expect(os.WriteFile(filepath.Join(dir, fmt.Sprintf("cm-%d.yaml", i)), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+		}
+
+		var out bytes.Buffer
+
+		log := logger.NewJSONLogger(&out)
+
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignore.CompileIgnoreLines(), false, 4)).To(Succeed())
+		Expect(log.Flush()).To(Succeed())
+
+		// every line is JSON, as with --no-tty
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		for _, line := range lines {
+			Expect(json.Valid([]byte(line))).To(BeTrue(), line)
+		}
+
+		Expect(out.String()).To(ContainSubstring("Uploaded 100 files"))
+	})
+})
+
+var _ = Describe("IsIgnoreFile", func() {
+	It("matches the ignore files of the root dir only", func() {
+		Expect(IsIgnoreFile("/repo", "/repo/.gitignore")).To(BeTrue())
+		Expect(IsIgnoreFile("/repo", "/repo/.sourceignore")).To(BeTrue())
+		Expect(IsIgnoreFile("/repo", "/repo/apps/.gitignore")).To(BeFalse())
+		Expect(IsIgnoreFile("/repo", "/repo/app.yaml")).To(BeFalse())
+	})
+})
+
+var _ = Describe("walkSyncedFiles", func() {
+	It("names the files with slashes, skipping the hidden dirs but the root one", func() {
+		dir, err := os.MkdirTemp("", ".hidden-root")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(os.MkdirAll(filepath.Join(dir, "apps", "dev"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, ".cache"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "apps", "dev", "app.yaml"), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".cache", "app.yaml"), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+
+		var names []string
+
+		Expect(walkSyncedFiles(logger.NewCLILogger(io.Discard), dir, ignore.CompileIgnoreLines(), false, func(path, relPath string, info os.FileInfo) error {
+			names = append(names, relPath)
+			return nil
+		})).To(Succeed())
+		Expect(names).To(Equal([]string{"apps/dev/app.yaml"}))
+	})
+})
+
+var _ = Describe("AreEventsLost", func() {
+	It("tells the overflows of the watchers apart", func() {
+		Expect(AreEventsLost(fsnotify.ErrEventOverflow)).To(BeTrue())
+		Expect(AreEventsLost(errors.New("short read in readEvents()"))).To(BeTrue())
+		Expect(AreEventsLost(errors.New("permission denied"))).To(BeFalse())
+	})
 })
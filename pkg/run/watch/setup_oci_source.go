@@ -0,0 +1,265 @@
+package watch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/hashicorp/go-multierror"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+	"github.com/weaveworks/weave-gitops/pkg/run"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SourceMode is how the files of a run get to the cluster.
+type SourceMode string
+
+const (
+	// SourceModeBucket syncs the files to the dev bucket.
+	SourceModeBucket SourceMode = "bucket"
+	// SourceModeOCI pushes the files as an OCI artifact to the dev registry.
+	SourceModeOCI SourceMode = "oci"
+)
+
+const (
+	// RunDevOCITag is the tag the dev artifact is pushed as, overwritten on
+	// every sync.
+	RunDevOCITag = "latest"
+
+	// The media types of Flux artifacts, as pushed by `flux push artifact`.
+	runDevOCIConfigMediaType  = "application/vnd.cncf.flux.config.v1+json"
+	runDevOCIContentMediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+)
+
+// SetupOCISourceAndKS creates the OCIRepository the dev artifact is pulled
+// from, and the Kustomizations syncing its paths.
+func SetupOCISourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, params SetupRunObjectParams) error {
+	source := createOCIRepositoryObject(params)
+
+	// create source
+	log.Actionf("Checking OCI source %s ...", source.Name)
+
+	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(&source), &source); err != nil && apierrors.IsNotFound(err) {
+		if err := kubeClient.Create(ctx, &source); err != nil {
+			return fmt.Errorf("couldn't create source %s: %v", source.Name, err.Error())
+		} else {
+			log.Successf("Created source %s", source.Name)
+		}
+	} else if err == nil {
+		log.Successf("Source %s already existed", source.Name)
+	}
+
+	if err := reconcileTargetNamespace(ctx, log, kubeClient, params.TargetNamespace); err != nil {
+		return err
+	}
+
+	kss := createKustomizationObjects(params, kustomizev1.CrossNamespaceSourceReference{
+		Kind: sourcev1.OCIRepositoryKind,
+		Name: RunDevOCIName,
+	})
+
+	if err := reconcileKustomizationObjects(ctx, log, kubeClient, kss); err != nil {
+		return err
+	}
+
+	log.Successf("Setup OCI Source and Kustomization successfully")
+
+	return nil
+}
+
+func createOCIRepositoryObject(params SetupRunObjectParams) sourcev1.OCIRepository {
+	return sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunDevOCIName,
+			Namespace: params.Namespace,
+			Annotations: map[string]string{
+				"metadata.weave.works/description": "This is a temporary OCIRepository created by GitOps Run. This will be cleaned up when this instance of GitOps Run is ended.",
+				RunIDAnnotation:                    params.SessionName,
+				"metadata.weave.works/username":    params.Username,
+			},
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       "oci://" + DevRegistryRepository(params.DevRegistryPort),
+			Reference: &sourcev1.OCIRepositoryRef{Tag: RunDevOCITag},
+			Interval:  metav1.Duration{Duration: 30 * 24 * time.Hour}, // 30 days
+			Timeout:   &metav1.Duration{Duration: params.Timeout},
+			// the dev registry is only reachable within the cluster
+			Insecure: true,
+		},
+	}
+}
+
+// PushDir pushes all files in a directory as the dev artifact, to the dev
+// registry at registry, e.g. localhost:5000. The symlinks are pushed like the
+// files they point to when followSymlinks is set.
+func PushDir(ctx context.Context, log logger.Logger, dir string, registry string, ignorer *ignore.GitIgnore, followSymlinks bool) error {
+	ref, err := name.NewTag(fmt.Sprintf("%s/%s:%s", registry, RunDevOCIName, RunDevOCITag), name.Insecure)
+	if err != nil {
+		return err
+	}
+
+	log.Actionf("Pushing artifact %s ...", ref)
+
+	var content bytes.Buffer
+
+	count, err := writeSyncedFilesArchive(log, &content, dir, ignorer, followSymlinks)
+	if err != nil {
+		log.Failuref("Error archiving directory: %v", err)
+		return err
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(content.Bytes(), runDevOCIContentMediaType),
+	})
+	if err != nil {
+		return err
+	}
+
+	img = mutate.MediaType(img, ggcrtypes.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, runDevOCIConfigMediaType)
+
+	if err := remote.Write(ref, img, remote.WithContext(ctx)); err != nil {
+		log.Failuref("Error pushing artifact: %v", err)
+		return err
+	}
+
+	log.Actionf("Pushed %d files", count)
+
+	return nil
+}
+
+// writeSyncedFilesArchive writes the files under dir that are synced to w as
+// a tar.gz archive, and returns how many there are.
+func writeSyncedFilesArchive(log logger.Logger, w io.Writer, dir string, ignorer *ignore.GitIgnore, followSymlinks bool) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	count := 0
+
+	err := walkSyncedFiles(log, dir, ignorer, followSymlinks, func(path, relPath string, info os.FileInfo) error {
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(relPath)
+
+		file, err := os.Open(path)
+		if err != nil {
+			// like when syncing to the dev bucket, the other files are
+			// still worth syncing
+			log.Failuref("Couldn't archive %v: %v", path, err)
+			return nil
+		}
+		defer file.Close()
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := io.CopyN(tw, file, info.Size()); err != nil {
+			return err
+		}
+
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+
+	return count, gz.Close()
+}
+
+// CleanupOCISourceAndKS removes the OCI source and the Kustomizations syncing
+// from it
+func CleanupOCISourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, namespace string, opts CleanupOptions) error {
+	var failures *multierror.Error
+
+	if err := cleanupKustomizationObjects(ctx, log, kubeClient, namespace, sourcev1.OCIRepositoryKind, RunDevOCIName, opts); err != nil {
+		failures = multierror.Append(failures, err)
+	}
+
+	source := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunDevOCIName,
+			Namespace: namespace,
+		},
+	}
+
+	if err := deleteObject(ctx, log, kubeClient, source, opts); err != nil {
+		failures = multierror.Append(failures, err)
+	}
+
+	if err := failures.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	log.Successf("Cleanup OCI Source and Kustomization successfully")
+
+	return nil
+}
+
+// ReconcileDevOCISourceAndKS reconciles the dev OCIRepository and the
+// Kustomizations syncing from it, named after DevKsNames, asynchronously.
+func ReconcileDevOCISourceAndKS(ctx context.Context, log logger.Logger, kubeClient client.Client, cfg *rest.Config, namespace string, ksNames []string, opts run.WaitOptions) error {
+	// the progress of the reconciliations is reported by the Flux
+	// controllers
+	fluxLog := logger.WithLogSource(log, logger.LogSourceFlux)
+
+	sourceRequestedAt, err := run.RequestReconciliation(ctx, kubeClient,
+		types.NamespacedName{
+			Name:      RunDevOCIName,
+			Namespace: namespace,
+		}, schema.GroupVersionKind{
+			Group:   sourcev1.GroupVersion.Group,
+			Version: sourcev1.GroupVersion.Version,
+			Kind:    sourcev1.OCIRepositoryKind,
+		})
+	if err != nil {
+		return err
+	}
+
+	devOCI := &sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{Name: RunDevOCIName, Namespace: namespace}}
+
+	if err := run.WaitForObject(ctx, fluxLog, kubeClient, devOCI, "OCIRepository "+RunDevOCIName+" to be reconciled", opts,
+		run.ReconciledCondition(kubeClient, devOCI, sourceRequestedAt)); err != nil {
+		return err
+	}
+
+	if err := run.WaitForObject(ctx, fluxLog, kubeClient, devOCI, "OCIRepository "+RunDevOCIName+" to be ready", opts,
+		run.StatusCondition(kubeClient, devOCI, meta.ReadyCondition)); err != nil {
+		return err
+	}
+
+	return reconcileDevKustomizations(ctx, log, kubeClient, cfg, namespace, ksNames, opts)
+}
@@ -0,0 +1,66 @@
+package watch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+)
+
+var _ = Describe("createOCIRepositoryObject", func() {
+	It("pulls the dev artifact from the dev registry", func() {
+		source := createOCIRepositoryObject(SetupRunObjectParams{
+			Namespace:       "flux-system",
+			DevRegistryPort: 5000,
+		})
+		Expect(source.Name).To(Equal(RunDevOCIName))
+		Expect(source.Namespace).To(Equal("flux-system"))
+		Expect(source.Spec.URL).To(Equal("oci://run-dev-registry.gitops-run.svc.cluster.local:5000/run-dev-oci"))
+		Expect(source.Spec.Reference.Tag).To(Equal(RunDevOCITag))
+		Expect(source.Spec.Insecure).To(BeTrue())
+	})
+})
+
+var _ = Describe("writeSyncedFilesArchive", func() {
+	It("archives the files that are synced", func() {
+		dir, err := os.MkdirTemp("", "oci-dir")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		Expect(os.MkdirAll(filepath.Join(dir, "apps"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, ".git"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "apps", "app.yaml"), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "apps", "app.yaml~"), []byte("backup"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0644)).To(Succeed())
+
+		var archive bytes.Buffer
+
+		count, err := writeSyncedFilesArchive(logger.NewCLILogger(io.Discard), &archive, dir, ignore.CompileIgnoreLines("*~"), false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		gz, err := gzip.NewReader(&archive)
+		Expect(err).ToNot(HaveOccurred())
+
+		tr := tar.NewReader(gz)
+
+		hdr, err := tr.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hdr.Name).To(Equal("apps/app.yaml"))
+
+		content, err := io.ReadAll(tr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("kind: ConfigMap"))
+
+		_, err = tr.Next()
+		Expect(err).To(Equal(io.EOF))
+	})
+})
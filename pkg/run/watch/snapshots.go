@@ -0,0 +1,265 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+)
+
+// RunSnapshotsBucketName is the bucket of the dev bucket server keeping the
+// snapshots of the synced files, so a session can be rolled back.
+const RunSnapshotsBucketName = "gitops-run-snapshots"
+
+// snapshotIDLayout is the layout of the timestamps identifying snapshots,
+// which sort like the times they stand for.
+const snapshotIDLayout = "20060102-150405.000"
+
+// the content of the snapshots is kept once for all of them, by its MD5 sum
+const snapshotBlobsPrefix = "blobs/"
+
+// Snapshot is the state of the dev bucket after a sync.
+type Snapshot struct {
+	// ID is the UTC timestamp of the snapshot, e.g. 20221015-134501.123.
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Objects are the ETags of the objects of the dev bucket by name. They're
+	// the MD5 sums of their content, as the files are uploaded in a single
+	// part.
+	Objects map[string]string `json:"objects"`
+}
+
+// RecordSnapshot records the content of the dev bucket as a snapshot of the
+// session, unless it's the same as the last one. It returns the snapshot,
+// and whether it was recorded.
+func RecordSnapshot(ctx context.Context, client *minio.Client, sessionName string) (Snapshot, bool, error) {
+	objects := map[string]string{}
+
+	for object := range client.ListObjects(ctx, RunDevBucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return Snapshot{}, false, object.Err
+		}
+
+		objects[object.Key] = object.ETag
+	}
+
+	snapshots, err := ListSnapshots(ctx, client, sessionName)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	if len(snapshots) > 0 && sameObjects(snapshots[len(snapshots)-1].Objects, objects) {
+		return snapshots[len(snapshots)-1], false, nil
+	}
+
+	exists, err := client.BucketExists(ctx, RunSnapshotsBucketName)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	if !exists {
+		if err := client.MakeBucket(ctx, RunSnapshotsBucketName, minio.MakeBucketOptions{}); err != nil {
+			return Snapshot{}, false, err
+		}
+	}
+
+	for name, etag := range objects {
+		if err := copySnapshotBlob(ctx, client, name, etag); err != nil {
+			return Snapshot{}, false, fmt.Errorf("error copying %s: %w", name, err)
+		}
+	}
+
+	now := time.Now().UTC()
+
+	snapshot := Snapshot{
+		ID:      now.Format(snapshotIDLayout),
+		Time:    now,
+		Objects: objects,
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	if _, err := client.PutObject(ctx, RunSnapshotsBucketName, snapshotObjectName(sessionName, snapshot.ID),
+		bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: "application/json"}); err != nil {
+		return Snapshot{}, false, err
+	}
+
+	return snapshot, true, nil
+}
+
+// copySnapshotBlob copies the content of the object of the dev bucket to the
+// snapshots, unless it's already there.
+func copySnapshotBlob(ctx context.Context, client *minio.Client, name, etag string) error {
+	blobName := snapshotBlobsPrefix + etag
+
+	if _, err := client.StatObject(ctx, RunSnapshotsBucketName, blobName, minio.StatObjectOptions{}); err == nil {
+		return nil
+	} else if minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		return err
+	}
+
+	_, err := client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: RunSnapshotsBucketName, Object: blobName},
+		minio.CopySrcOptions{Bucket: RunDevBucketName, Object: name})
+
+	return err
+}
+
+// ListSnapshots returns the snapshots of the session, oldest first.
+func ListSnapshots(ctx context.Context, client *minio.Client, sessionName string) ([]Snapshot, error) {
+	var result []Snapshot
+
+	for object := range client.ListObjects(ctx, RunSnapshotsBucketName, minio.ListObjectsOptions{Prefix: sessionName + "/", Recursive: true}) {
+		if object.Err != nil {
+			if minio.ToErrorResponse(object.Err).Code == "NoSuchBucket" {
+				return nil, nil
+			}
+
+			return nil, object.Err
+		}
+
+		snapshot, err := getSnapshot(ctx, client, object.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, snapshot)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// GetSnapshot returns the snapshot of the session with the ID.
+func GetSnapshot(ctx context.Context, client *minio.Client, sessionName, id string) (Snapshot, error) {
+	snapshot, err := getSnapshot(ctx, client, snapshotObjectName(sessionName, id))
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return Snapshot{}, fmt.Errorf("snapshot %s not found", id)
+	}
+
+	return snapshot, err
+}
+
+func getSnapshot(ctx context.Context, client *minio.Client, objectName string) (Snapshot, error) {
+	object, err := client.GetObject(ctx, RunSnapshotsBucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer object.Close()
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(object).Decode(&snapshot); err != nil {
+		return Snapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+// RestoreSnapshot puts the dev bucket back in the state of the snapshot,
+// copying back the objects that changed and removing the ones that were
+// added since. The Flux objects syncing from the bucket need to be
+// reconciled afterwards. The next sync uploads the files as they are again.
+func RestoreSnapshot(ctx context.Context, log logger.Logger, client *minio.Client, snapshot Snapshot) error {
+	log.Actionf("Restoring snapshot %s ...", snapshot.ID)
+
+	current := map[string]string{}
+
+	for object := range client.ListObjects(ctx, RunDevBucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+
+		current[object.Key] = object.ETag
+	}
+
+	restoreCount, removeCount := 0, 0
+
+	for name, etag := range snapshot.Objects {
+		if current[name] == etag {
+			continue
+		}
+
+		if _, err := client.CopyObject(ctx,
+			minio.CopyDestOptions{Bucket: RunDevBucketName, Object: name},
+			minio.CopySrcOptions{Bucket: RunSnapshotsBucketName, Object: snapshotBlobsPrefix + etag}); err != nil {
+			return fmt.Errorf("error restoring %s: %w", name, err)
+		}
+
+		restoreCount++
+	}
+
+	for name := range current {
+		if _, ok := snapshot.Objects[name]; ok {
+			continue
+		}
+
+		if err := client.RemoveObject(ctx, RunDevBucketName, name, minio.RemoveObjectOptions{}); err != nil {
+			return fmt.Errorf("error removing %s: %w", name, err)
+		}
+
+		removeCount++
+	}
+
+	log.Actionf("Restored snapshot %s: %d files restored, %d removed", snapshot.ID, restoreCount, removeCount)
+
+	return nil
+}
+
+// ShowSnapshots shows the snapshots, marking the one the dev bucket is in.
+func ShowSnapshots(log logger.Logger, snapshots []Snapshot, currentID string) {
+	if len(snapshots) == 0 {
+		log.Println("No snapshots recorded yet")
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		mark := " "
+		if snapshot.ID == currentID {
+			mark = "*"
+		}
+
+		log.Println("%s %s  %s  %d files", mark, snapshot.ID, snapshot.Time.Local().Format("15:04:05"), len(snapshot.Objects))
+	}
+}
+
+// PreviousSnapshot returns the snapshot recorded before the one with the ID,
+// so it can be rolled back to.
+func PreviousSnapshot(snapshots []Snapshot, id string) (Snapshot, bool) {
+	for i := len(snapshots) - 1; i > 0; i-- {
+		if snapshots[i].ID == id {
+			return snapshots[i-1], true
+		}
+	}
+
+	return Snapshot{}, false
+}
+
+func snapshotObjectName(sessionName, id string) string {
+	return path.Join(sessionName, id+".json")
+}
+
+func sameObjects(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, etag := range a {
+		if etagB, ok := b[name]; !ok || etagB != etag {
+			return false
+		}
+	}
+
+	return true
+}
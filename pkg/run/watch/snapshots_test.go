@@ -0,0 +1,96 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/weaveworks/weave-gitops/pkg/logger"
+)
+
+var _ = Describe("Snapshots", func() {
+	It("records the synced files and restores them", func() {
+		ctx := context.Background()
+
+		srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+		defer srv.Close()
+
+		minioClient, err := minio.New(strings.TrimPrefix(srv.URL, "http://"), &minio.Options{
+			Creds: credentials.NewStaticV4("access", "secret", ""),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		dir, err := os.MkdirTemp("", "snapshots")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		log := logger.NewCLILogger(io.Discard)
+		ignorer := ignore.CompileIgnoreLines()
+
+		Expect(os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+
+		first, recorded, err := RecordSnapshot(ctx, minioClient, "run-main")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recorded).To(BeTrue())
+
+		// nothing changed
+		same, recorded, err := RecordSnapshot(ctx, minioClient, "run-main")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recorded).To(BeFalse())
+		Expect(same.ID).To(Equal(first.ID))
+
+		Expect(os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("kind: Secret"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "svc.yaml"), []byte("kind: Service"), 0644)).To(Succeed())
+		Expect(SyncDir(ctx, log, dir, RunDevBucketName, minioClient, ignorer, false, 2)).To(Succeed())
+
+		second, recorded, err := RecordSnapshot(ctx, minioClient, "run-main")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recorded).To(BeTrue())
+
+		snapshots, err := ListSnapshots(ctx, minioClient, "run-main")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+		Expect(snapshots[1].ID).To(Equal(second.ID))
+
+		otherSnapshots, err := ListSnapshots(ctx, minioClient, "run-other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(otherSnapshots).To(BeEmpty())
+
+		previous, ok := PreviousSnapshot(snapshots, second.ID)
+		Expect(ok).To(BeTrue())
+		Expect(previous.ID).To(Equal(first.ID))
+
+		_, ok = PreviousSnapshot(snapshots, first.ID)
+		Expect(ok).To(BeFalse())
+
+		Expect(RestoreSnapshot(ctx, log, minioClient, previous)).To(Succeed())
+
+		var keys []string
+		for object := range minioClient.ListObjects(ctx, RunDevBucketName, minio.ListObjectsOptions{Recursive: true}) {
+			Expect(object.Err).ToNot(HaveOccurred())
+			keys = append(keys, object.Key)
+		}
+		Expect(keys).To(ConsistOf("cm.yaml"))
+
+		restored, err := minioClient.GetObject(ctx, RunDevBucketName, "cm.yaml", minio.GetObjectOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		content, err := io.ReadAll(restored)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("kind: ConfigMap"))
+
+		_, err = GetSnapshot(ctx, minioClient, "run-main", "20000101-000000.000")
+		Expect(err).To(MatchError("snapshot 20000101-000000.000 not found"))
+	})
+})
@@ -0,0 +1,46 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SuspendDevKustomizations suspends the reconciliation of the Kustomizations
+// named ksNames, or resumes it, so nothing reaches the cluster while syncing
+// is paused.
+func SuspendDevKustomizations(ctx context.Context, kubeClient client.Client, namespace string, ksNames []string, suspend bool) error {
+	for _, name := range ksNames {
+		ks := &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+
+		if err := setSuspended(ctx, kubeClient, ks, suspend); err != nil {
+			return fmt.Errorf("error suspending Kustomization %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// SuspendDevHelmRelease suspends the reconciliation of the dev HelmRelease,
+// or resumes it.
+func SuspendDevHelmRelease(ctx context.Context, kubeClient client.Client, namespace string, suspend bool) error {
+	helmRelease := &helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Name: RunDevHelmName, Namespace: namespace}}
+
+	if err := setSuspended(ctx, kubeClient, helmRelease, suspend); err != nil {
+		return fmt.Errorf("error suspending HelmRelease %s: %w", RunDevHelmName, err)
+	}
+
+	return nil
+}
+
+// setSuspended sets the spec.suspend field the Flux objects share.
+func setSuspended(ctx context.Context, kubeClient client.Client, obj client.Object, suspend bool) error {
+	patch := fmt.Sprintf(`{"spec":{"suspend":%t}}`, suspend)
+
+	return kubeClient.Patch(ctx, obj, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
@@ -0,0 +1,47 @@
+package watch
+
+import (
+	"context"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/weaveworks/weave-gitops/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("SuspendDevKustomizations", func() {
+	It("suspends and resumes the Kustomizations", func() {
+		scheme, err := kube.CreateScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		names := DevKsNames(2)
+
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: names[0], Namespace: "flux-system"}},
+			&kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: names[1], Namespace: "flux-system"}},
+		).Build()
+
+		suspended := func() []bool {
+			var result []bool
+
+			for _, name := range names {
+				ks := &kustomizev1.Kustomization{}
+				Expect(kubeClient.Get(context.Background(), client.ObjectKey{Namespace: "flux-system", Name: name}, ks)).To(Succeed())
+
+				result = append(result, ks.Spec.Suspend)
+			}
+
+			return result
+		}
+
+		Expect(SuspendDevKustomizations(context.Background(), kubeClient, "flux-system", names, true)).To(Succeed())
+		Expect(suspended()).To(Equal([]bool{true, true}))
+
+		Expect(SuspendDevKustomizations(context.Background(), kubeClient, "flux-system", names, false)).To(Succeed())
+		Expect(suspended()).To(Equal([]bool{false, false}))
+	})
+})
@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WalkDir walks the file tree rooted at root like filepath.Walk. When
+// followSymlinks is set, the symlinks are walked like the files and dirs
+// they point to, under the path of the link, e.g. for the manifests shared
+// by the apps of a mono-repo. The links making cycles, and the broken ones,
+// are skipped.
+func WalkDir(root string, followSymlinks bool, fn filepath.WalkFunc) error {
+	if !followSymlinks {
+		return filepath.Walk(root, fn)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+
+	return walkFollowingSymlinks(root, realRoot, []string{realRoot}, fn)
+}
+
+// walkFollowingSymlinks walks the dir at realPath, giving fn the paths under
+// path instead. The walked dirs are the real paths of the dirs that were
+// walked to get there, so the links to them are cycles.
+func walkFollowingSymlinks(path, realPath string, walked []string, fn filepath.WalkFunc) error {
+	return filepath.Walk(realPath, func(p string, info os.FileInfo, err error) error {
+		rel, relErr := filepath.Rel(realPath, p)
+		if relErr != nil {
+			return relErr
+		}
+
+		logicalPath := filepath.Join(path, rel)
+
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return fn(logicalPath, info, err)
+		}
+
+		target, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			// broken link
+			return nil
+		}
+
+		targetInfo, err := os.Stat(target)
+		if err != nil {
+			return nil
+		}
+
+		if !targetInfo.IsDir() {
+			return fn(logicalPath, targetInfo, nil)
+		}
+
+		if isWithin(filepath.Dir(p), target) {
+			return nil
+		}
+
+		for _, dir := range walked {
+			if isWithin(dir, target) {
+				return nil
+			}
+		}
+
+		return walkFollowingSymlinks(logicalPath, target, append(walked[:len(walked):len(walked)], target), fn)
+	})
+}
+
+// isWithin returns whether path is dir or one of its descendants.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,77 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WalkDir", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "symlinks")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(dir, "shared"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "apps", "a"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "apps", "b"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "shared", "cm.yaml"), []byte("kind: ConfigMap"), 0644)).To(Succeed())
+
+		// the shared manifests, linked from both apps
+		Expect(os.Symlink(filepath.Join("..", "..", "shared"), filepath.Join(dir, "apps", "a", "shared"))).To(Succeed())
+		Expect(os.Symlink(filepath.Join("..", "..", "shared", "cm.yaml"), filepath.Join(dir, "apps", "b", "cm.yaml"))).To(Succeed())
+		// a cycle through the shared manifests, and a broken link
+		Expect(os.Symlink(filepath.Join("..", "apps"), filepath.Join(dir, "shared", "apps"))).To(Succeed())
+		Expect(os.Symlink("missing.yaml", filepath.Join(dir, "apps", "missing.yaml"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	walk := func(followSymlinks bool) (files, dirs []string) {
+		Expect(WalkDir(dir, followSymlinks, func(path string, info os.FileInfo, err error) error {
+			Expect(err).ToNot(HaveOccurred())
+
+			rel, err := filepath.Rel(dir, path)
+			Expect(err).ToNot(HaveOccurred())
+
+			if info.IsDir() {
+				dirs = append(dirs, filepath.ToSlash(rel))
+			} else {
+				files = append(files, filepath.ToSlash(rel))
+			}
+
+			return nil
+		})).To(Succeed())
+
+		return files, dirs
+	}
+
+	It("walks the symlinks like the files and dirs they point to, under their path", func() {
+		files, dirs := walk(true)
+		Expect(files).To(ConsistOf(
+			"apps/a/shared/cm.yaml",
+			"apps/b/cm.yaml",
+			"apps/a/shared/apps/b/cm.yaml",
+			"shared/cm.yaml",
+			"shared/apps/b/cm.yaml",
+			"shared/apps/a/shared/cm.yaml",
+		))
+		Expect(dirs).To(ContainElements("apps/a/shared", "shared/apps"))
+		// the links back to a dir being walked are cycles
+		Expect(dirs).ToNot(ContainElements("apps/a/shared/apps/a/shared"))
+		Expect(dirs).ToNot(ContainElements("shared/apps/a/shared/apps"))
+	})
+
+	It("doesn't follow the symlinks by default", func() {
+		files, dirs := walk(false)
+		Expect(files).To(ContainElements("apps/a/shared", "apps/b/cm.yaml"))
+		Expect(files).ToNot(ContainElement("apps/a/shared/cm.yaml"))
+		Expect(dirs).ToNot(ContainElement("apps/a/shared"))
+	})
+})
@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+)
+
+// Retention bounds the objects kept under each top-level prefix of a bucket,
+// e.g. the logs of each GitOps Run session. Zero values keep all objects.
+type Retention struct {
+	// MaxAge is how long objects are kept after they were last modified.
+	MaxAge time.Duration
+	// MaxObjects is how many objects are kept under each prefix, the oldest
+	// ones being deleted first.
+	MaxObjects int
+}
+
+// Enforce deletes the objects of bucket the retention doesn't keep, and
+// returns how many it deleted. A bucket that doesn't exist yet is empty.
+func (r Retention) Enforce(backend gofakes3.Backend, bucket string, now time.Time) (int, error) {
+	if r.MaxAge <= 0 && r.MaxObjects <= 0 {
+		return 0, nil
+	}
+
+	list, err := backend.ListBucket(bucket, nil, gofakes3.ListBucketPage{})
+	if err != nil {
+		if gofakes3.HasErrorCode(err, gofakes3.ErrNoSuchBucket) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	perPrefix := map[string][]*gofakes3.Content{}
+
+	for _, content := range list.Contents {
+		prefix := strings.SplitN(content.Key, "/", 2)[0]
+		perPrefix[prefix] = append(perPrefix[prefix], content)
+	}
+
+	var expired []string
+
+	for _, contents := range perPrefix {
+		// oldest first, the keys of the logs being their timestamps
+		sort.Slice(contents, func(i, j int) bool {
+			if !contents[i].LastModified.Equal(contents[j].LastModified.Time) {
+				return contents[i].LastModified.Before(contents[j].LastModified.Time)
+			}
+
+			return contents[i].Key < contents[j].Key
+		})
+
+		for i, content := range contents {
+			tooOld := r.MaxAge > 0 && now.Sub(content.LastModified.Time) > r.MaxAge
+			tooMany := r.MaxObjects > 0 && len(contents)-i > r.MaxObjects
+
+			if tooOld || tooMany {
+				expired = append(expired, content.Key)
+			}
+		}
+	}
+
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	result, err := backend.DeleteMulti(bucket, expired...)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(result.Deleted), result.AsError()
+}
+
+// Run enforces the retention of bucket every interval until ctx is done,
+// logging the errors.
+func (r Retention) Run(ctx context.Context, logger *log.Logger, backend gofakes3.Backend, bucket string, interval time.Duration) {
+	if r.MaxAge <= 0 && r.MaxObjects <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := r.Enforce(backend, bucket, now); err != nil {
+				logger.Printf("error enforcing the retention of bucket %s: %s", bucket, err)
+			}
+		}
+	}
+}
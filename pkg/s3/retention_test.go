@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	. "github.com/onsi/gomega"
+)
+
+func TestRetentionEnforce(t *testing.T) {
+	start := time.Date(2022, 10, 10, 10, 0, 0, 0, time.UTC)
+
+	newBackend := func(g *WithT) *s3mem.Backend {
+		clock := gofakes3.FixedTimeSource(start)
+		backend := s3mem.New(s3mem.WithTimeSource(clock))
+		g.Expect(backend.CreateBucket("logs")).To(Succeed())
+
+		// an object every hour
+		for _, key := range []string{
+			"session-a/1.json", "session-a/2.json", "session-a/3.json",
+			"session-b/1.json",
+		} {
+			_, err := backend.PutObject("logs", key, nil, bytes.NewReader([]byte("{}")), 2)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			clock.Advance(time.Hour)
+		}
+
+		return backend
+	}
+
+	keys := func(g *WithT, backend *s3mem.Backend) []string {
+		list, err := backend.ListBucket("logs", nil, gofakes3.ListBucketPage{})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		result := []string{}
+		for _, content := range list.Contents {
+			result = append(result, content.Key)
+		}
+
+		return result
+	}
+
+	t.Run("deletes the objects older than the max age", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		backend := newBackend(g)
+
+		deleted, err := Retention{MaxAge: 2 * time.Hour}.Enforce(backend, "logs", start.Add(4*time.Hour))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(deleted).To(Equal(2))
+		g.Expect(keys(g, backend)).To(ConsistOf("session-a/3.json", "session-b/1.json"))
+	})
+
+	t.Run("keeps the newest objects of each prefix", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		backend := newBackend(g)
+
+		deleted, err := Retention{MaxObjects: 1}.Enforce(backend, "logs", start)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(deleted).To(Equal(2))
+		g.Expect(keys(g, backend)).To(ConsistOf("session-a/3.json", "session-b/1.json"))
+	})
+
+	t.Run("keeps all objects by default", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		backend := newBackend(g)
+
+		deleted, err := Retention{}.Enforce(backend, "logs", start.Add(time.Hour*24*365))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(deleted).To(BeZero())
+		g.Expect(keys(g, backend)).To(HaveLen(4))
+	})
+
+	t.Run("ignores missing buckets", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		deleted, err := Retention{MaxObjects: 1}.Enforce(s3mem.New(), "logs", start)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(deleted).To(BeZero())
+	})
+}
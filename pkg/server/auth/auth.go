@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/sethvargo/go-limiter/httplimit"
 	"github.com/sethvargo/go-limiter/memorystore"
@@ -56,6 +57,16 @@ func RegisterAuthServer(mux *http.ServeMux, prefix string, srv *AuthServer, logi
 	mux.Handle(prefix+"/sign_in", middleware.Handle(srv.SignIn()))
 	mux.HandleFunc(prefix+"/userinfo", srv.UserInfo)
 	mux.Handle(prefix+"/logout", srv.Logout())
+	mux.Handle(prefix+"/backchannel_logout", srv.BackChannelLogout())
+	mux.Handle(prefix+"/device", srv.DeviceVerification())
+	mux.Handle(prefix+"/device/code", middleware.Handle(srv.DeviceAuthorization(prefix+"/device")))
+	mux.Handle(prefix+"/device/token", srv.DeviceToken())
+	mux.Handle(prefix+"/token", middleware.Handle(srv.TokenExchange()))
+	mux.Handle(prefix+"/webauthn/register/begin", srv.WebAuthnRegisterBegin())
+	mux.Handle(prefix+"/webauthn/register/finish", srv.WebAuthnRegisterFinish())
+	mux.Handle(prefix+"/webauthn/login/begin", middleware.Handle(srv.WebAuthnLoginBegin()))
+	mux.Handle(prefix+"/webauthn/login/finish", middleware.Handle(srv.WebAuthnLoginFinish()))
+	mux.Handle(prefix+"/impersonate", WithAPIAuth(srv.Impersonate(), srv, nil))
 
 	return nil
 }
@@ -74,9 +85,12 @@ func Principal(ctx context.Context) *UserPrincipal {
 
 // UserPrincipal is a simple model for the user, including their ID and Groups.
 type UserPrincipal struct {
-	ID     string   `json:"id"`
-	Groups []string `json:"groups"`
-	token  *string  `json:"-"`
+	ID          string   `json:"id"`
+	Groups      []string `json:"groups"`
+	DisplayName string   `json:"displayName,omitempty"`
+	// Impersonator is the admin acting as this user, if any
+	Impersonator string  `json:"impersonator,omitempty"`
+	token        *string `json:"-"`
 }
 
 // Token returns the private access token for this principal.
@@ -148,9 +162,15 @@ func WithPrincipal(ctx context.Context, p *UserPrincipal) context.Context {
 func WithAPIAuth(next http.Handler, srv *AuthServer, publicRoutes []string) http.Handler {
 	multi := MultiAuthPrincipal{Log: srv.Log, Getters: []PrincipalGetter{}}
 
+	// the tokens of device logins, e.g. of gitops login, are minted from a
+	// session of any of the login methods
+	if srv.tokenSignerVerifier != nil {
+		multi.Getters = append(multi.Getters, NewDeviceTokenPrincipalGetter(srv.Log, srv.clusterTokenVerifier()))
+	}
+
 	// FIXME: currently the order must be OIDC last, or it'll "shadow" the other
 	// methods so they don't work.
-	methods := []AuthMethod{UserAccount, TokenPassthrough, OIDC}
+	methods := []AuthMethod{TrustedHeader, UserAccount, TokenFile, TokenPassthrough, OIDC}
 	for _, method := range methods {
 		enabled, ok := srv.authMethods[method]
 		if !ok {
@@ -166,13 +186,13 @@ func WithAPIAuth(next http.Handler, srv *AuthServer, publicRoutes []string) http
 		case OIDC:
 			if srv.oidcEnabled() {
 				// OIDC tokens may be passed by token or cookie
-				multi.Getters = append(multi.Getters, NewJWTAuthorizationHeaderPrincipalGetter(srv.Log, srv.verifier(), srv.OIDCConfig.ClaimsConfig))
+				multi.Getters = append(multi.Getters, NewJWTAuthorizationHeaderPrincipalGetter(srv.Log, srv.sessionVerifier(srv.verifier()), srv.OIDCConfig.ClaimsConfig))
 
 				if srv.oidcPassthroughEnabled() {
 					srv.Log.V(logger.LogLevelDebug).Info("JWT Token Passthrough Enabled")
-					multi.Getters = append(multi.Getters, NewJWTPassthroughCookiePrincipalGetter(srv.Log, srv.verifier(), IDTokenCookieName))
+					multi.Getters = append(multi.Getters, NewJWTPassthroughCookiePrincipalGetter(srv.Log, srv.sessionVerifier(srv.passthroughVerifier()), IDTokenCookieName))
 				} else {
-					multi.Getters = append(multi.Getters, NewJWTCookiePrincipalGetter(srv.Log, srv.verifier(), IDTokenCookieName, srv.OIDCConfig.ClaimsConfig))
+					multi.Getters = append(multi.Getters, NewJWTCookiePrincipalGetter(srv.Log, srv.sessionVerifier(srv.verifier()), IDTokenCookieName, srv.OIDCConfig.ClaimsConfig))
 				}
 			}
 
@@ -185,6 +205,22 @@ func WithAPIAuth(next http.Handler, srv *AuthServer, publicRoutes []string) http
 		case TokenPassthrough:
 			tokenAuth := NewBearerTokenPassthroughPrincipalGetter(srv.Log, nil, AuthorizationTokenHeaderName, srv.kubernetesClient)
 			multi.Getters = append(multi.Getters, tokenAuth)
+
+		case TokenFile:
+			if len(srv.staticTokens) == 0 {
+				srv.Log.V(logger.LogLevelWarn).Info("The token-file auth method is enabled but no tokens are configured")
+				continue
+			}
+
+			multi.Getters = append(multi.Getters, NewTokenFilePrincipalGetter(srv.Log, srv.staticTokens))
+
+		case TrustedHeader:
+			if srv.trustedHeaders == nil {
+				srv.Log.V(logger.LogLevelWarn).Info("The trusted-header auth method is enabled but no shared secret or trusted CIDRs are configured")
+				continue
+			}
+
+			multi.Getters = append(multi.Getters, srv.trustedHeaders)
 		}
 	}
 
@@ -194,16 +230,27 @@ func WithAPIAuth(next http.Handler, srv *AuthServer, publicRoutes []string) http
 			return
 		}
 
-		principal, err := multi.Principal(r)
+		start := time.Now()
+		principal, getter, err := multi.principal(r)
+
 		if err != nil {
 			srv.Log.Error(err, "failed to get principal")
 		}
 
 		if principal == nil || err != nil {
+			opsTokenVerification.WithLabelValues(resultFailure).Observe(time.Since(start).Seconds())
 			srv.Log.V(logger.LogLevelWarn).Info("Authentication failed", "err", err, "principal", principal)
 			JSONError(srv.Log, rw, "Authentication required", http.StatusUnauthorized)
 			return
 		}
+		opsTokenVerification.WithLabelValues(resultSuccess).Observe(time.Since(start).Seconds())
+		activeSessions.seen(principal.ID, sessionMethod(getter), principal.Groups)
+
+		if impersonated := srv.impersonatedPrincipal(r, principal); impersonated != nil {
+			srv.Log.Info("impersonated request", "impersonator", principal.ID, "user", impersonated.ID, "groups", impersonated.Groups, "method", r.Method, "path", r.URL.Path)
+			principal = impersonated
+		}
+
 		next.ServeHTTP(rw, r.Clone(WithPrincipal(r.Context(), principal)))
 	})
 }
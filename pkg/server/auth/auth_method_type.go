@@ -17,6 +17,10 @@ const (
 	OIDC
 	// EE CLI tokens
 	TokenPassthrough
+	// Bearer tokens read from a file
+	TokenFile
+	// Identity headers set by a trusted proxy
+	TrustedHeader
 )
 
 // This is a function to mimic a const slice
@@ -56,6 +60,10 @@ func (am *AuthMethod) String() string {
 		return "oidc"
 	case TokenPassthrough:
 		return "token-passthrough"
+	case TokenFile:
+		return "token-file"
+	case TrustedHeader:
+		return "trusted-header"
 	default:
 		return fmt.Sprintf("AuthMethod(%d)", am)
 	}
@@ -70,6 +78,10 @@ func (am *AuthMethod) UnmarshalText(text []byte) error {
 		*am = OIDC
 	case "token-passthrough":
 		*am = TokenPassthrough
+	case "token-file":
+		*am = TokenFile
+	case "trusted-header":
+		*am = TrustedHeader
 	default:
 		return fmt.Errorf("unknown auth method '%q'", text)
 	}
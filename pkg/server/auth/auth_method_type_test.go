@@ -9,7 +9,7 @@ import (
 )
 
 func TestInvariant(t *testing.T) {
-	authMethods := []auth.AuthMethod{auth.UserAccount, auth.OIDC, auth.TokenPassthrough}
+	authMethods := []auth.AuthMethod{auth.UserAccount, auth.OIDC, auth.TokenPassthrough, auth.TokenFile, auth.TrustedHeader}
 
 	for _, method := range authMethods {
 		authstring := method.String()
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// backChannelLogoutEvent must be a member of the events claim of a logout
+// token.
+//
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+const backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// logoutTokenClaims are the claims of a logout token that aren't checked by
+// the ID token verifier.
+type logoutTokenClaims struct {
+	SessionID string                            `json:"sid"`
+	JTI       string                            `json:"jti"`
+	Nonce     *string                           `json:"nonce"`
+	Events    map[string]map[string]interface{} `json:"events"`
+}
+
+// BackChannelLogout handles logout tokens POSTed by the OIDC provider when a
+// user logs out, or is disabled, at the provider. Sessions of the user, or
+// only the session in the sid claim if present, are revoked immediately
+// rather than when the ID token cookie expires.
+//
+// Revocations are kept in memory, so each replica of the server must be sent
+// the logout token.
+func (s *AuthServer) BackChannelLogout() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.Header().Add("Allow", "POST")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		// Responses to the provider must not be cached.
+		rw.Header().Set("Cache-Control", "no-store")
+
+		if !s.oidcEnabled() {
+			JSONError(s.Log, rw, "oidc provider not configured", http.StatusBadRequest)
+			return
+		}
+
+		rawToken := r.PostFormValue("logout_token")
+		if rawToken == "" {
+			s.backChannelLogoutError(rw, errors.New("logout_token is required"))
+			return
+		}
+
+		subject, claims, expiry, err := s.verifyLogoutToken(r.Context(), rawToken)
+		if err != nil {
+			s.backChannelLogoutError(rw, err)
+			return
+		}
+
+		if claims.JTI != "" && !s.revocations.useLogoutToken(claims.JTI, expiry) {
+			s.backChannelLogoutError(rw, errors.New("logout token has already been used"))
+			return
+		}
+
+		if claims.SessionID != "" {
+			s.revocations.revokeSession(claims.SessionID)
+		} else {
+			s.revocations.revokeSubject(subject)
+		}
+
+		s.Log.Info("back-channel logout", "subject", subject, "sid", claims.SessionID)
+
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+// verifyLogoutToken validates a logout token as described in
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#Validation
+func (s *AuthServer) verifyLogoutToken(ctx context.Context, rawToken string) (string, logoutTokenClaims, time.Time, error) {
+	var claims logoutTokenClaims
+
+	token, err := s.verifier().Verify(oidc.ClientContext(ctx, s.client), rawToken)
+	if err != nil {
+		return "", claims, time.Time{}, fmt.Errorf("failed to verify logout token: %w", err)
+	}
+
+	if err := token.Claims(&claims); err != nil {
+		return "", claims, time.Time{}, fmt.Errorf("failed to parse logout token claims: %w", err)
+	}
+
+	if token.Subject == "" && claims.SessionID == "" {
+		return "", claims, time.Time{}, errors.New("logout token must have a sub or sid claim")
+	}
+
+	if _, ok := claims.Events[backChannelLogoutEvent]; !ok {
+		return "", claims, time.Time{}, errors.New("logout token is missing the back-channel logout event")
+	}
+
+	if claims.Nonce != nil {
+		return "", claims, time.Time{}, errors.New("logout token must not have a nonce claim")
+	}
+
+	return token.Subject, claims, token.Expiry, nil
+}
+
+func (s *AuthServer) backChannelLogoutError(rw http.ResponseWriter, err error) {
+	s.Log.Error(err, "back-channel logout failed")
+
+	writeJSON(s.Log, rw, struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{Error: "invalid_request", ErrorDescription: err.Error()}, http.StatusBadRequest)
+}
+
+// revocationList records the users and sessions logged out by the OIDC
+// provider. Tokens issued before a revocation are rejected, so the user can
+// log in again straight away.
+type revocationList struct {
+	mu        sync.Mutex
+	subjects  map[string]time.Time
+	sessions  map[string]time.Time
+	jtis      map[string]time.Time
+	retention time.Duration
+	now       func() time.Time
+}
+
+// revocationRetention is how long revocations must be kept for sessions of
+// the duration, and the cluster tokens derived from them, to expire.
+func revocationRetention(sessionDuration time.Duration) time.Duration {
+	if sessionDuration <= 0 {
+		sessionDuration = defaultCookieDuration
+	}
+
+	if sessionDuration < maxClusterTokenDuration {
+		return maxClusterTokenDuration
+	}
+
+	return sessionDuration
+}
+
+// newRevocationList creates a revocation list that forgets revocations
+// after the retention, by when every token issued before them has expired.
+func newRevocationList(retention time.Duration) *revocationList {
+	if retention <= 0 {
+		retention = defaultCookieDuration
+	}
+
+	return &revocationList{
+		subjects:  map[string]time.Time{},
+		sessions:  map[string]time.Time{},
+		jtis:      map[string]time.Time{},
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (l *revocationList) revokeSubject(subject string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune()
+	l.subjects[subject] = l.now()
+}
+
+func (l *revocationList) revokeSession(sid string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune()
+	l.sessions[sid] = l.now()
+}
+
+// useLogoutToken records the ID of a logout token until it expires, and
+// returns false if it has been seen before.
+func (l *revocationList) useLogoutToken(jti string, expiry time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune()
+
+	if _, ok := l.jtis[jti]; ok {
+		return false
+	}
+
+	if expiry.IsZero() {
+		expiry = l.now().Add(l.retention)
+	}
+
+	l.jtis[jti] = expiry
+
+	return true
+}
+
+// revoked returns true if a token with the subject and session ID issued at
+// the time has been logged out.
+func (l *revocationList) revoked(subject, sid string, issuedAt time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if at, ok := l.subjects[subject]; ok && subject != "" && !issuedAt.After(at) {
+		return true
+	}
+
+	if at, ok := l.sessions[sid]; ok && sid != "" && !issuedAt.After(at) {
+		return true
+	}
+
+	return false
+}
+
+func (l *revocationList) prune() {
+	now := l.now()
+
+	for k, at := range l.subjects {
+		if now.Sub(at) > l.retention {
+			delete(l.subjects, k)
+		}
+	}
+
+	for k, at := range l.sessions {
+		if now.Sub(at) > l.retention {
+			delete(l.sessions, k)
+		}
+	}
+
+	for k, expiry := range l.jtis {
+		if now.After(expiry) {
+			delete(l.jtis, k)
+		}
+	}
+}
+
+// revocationCheckingVerifier rejects tokens that have been revoked by a
+// back-channel logout.
+type revocationCheckingVerifier struct {
+	tokenVerifier
+	revocations *revocationList
+}
+
+func (v revocationCheckingVerifier) Verify(ctx context.Context, rawIDToken string) (*oidc.IDToken, error) {
+	token, err := v.tokenVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		SessionID string `json:"sid"`
+	}
+
+	if err := token.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+
+	if v.revocations.revoked(token.Subject, claims.SessionID, token.IssuedAt) {
+		return nil, errors.New("token has been revoked by the identity provider")
+	}
+
+	return token, nil
+}
+
+// revocationCheckingClusterVerifier rejects tokens derived from a session
+// that has been revoked by a back-channel logout.
+type revocationCheckingClusterVerifier struct {
+	ClusterTokenVerifier
+	revocations *revocationList
+}
+
+func (v revocationCheckingClusterVerifier) VerifyClusterToken(token, cluster string) (*ClusterClaims, error) {
+	claims, err := v.ClusterTokenVerifier.VerifyClusterToken(token, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if parent := claims.Parent; parent != nil {
+		var issuedAt time.Time
+		if parent.IssuedAt != nil {
+			issuedAt = parent.IssuedAt.Time
+		}
+
+		if v.revocations.revoked(parent.Subject, parent.ID, issuedAt) {
+			return nil, errors.New("parent session has been revoked by the identity provider")
+		}
+	}
+
+	return claims, nil
+}
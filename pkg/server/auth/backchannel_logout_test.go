@@ -0,0 +1,227 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oauth2-proxy/mockoidc"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+const backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// testClaims lets arbitrary claims be signed by the mock OIDC provider.
+type testClaims map[string]interface{}
+
+func (testClaims) Valid() error { return nil }
+
+func TestBackChannelLogoutRevokesSubject(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, m := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+	api := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}), s, nil)
+
+	before := signTestToken(g, m, testClaims{"sub": "jane", "email": "jane@example.com", "iat": time.Now().Add(-time.Minute).Unix()})
+	g.Expect(apiStatus(api, before)).To(Equal(http.StatusOK))
+
+	logout := signTestToken(g, m, logoutClaims("jane", "", "jti-1"))
+	resp := backChannelLogout(s, logout)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(resp.Header.Get("Cache-Control")).To(Equal("no-store"))
+
+	g.Expect(apiStatus(api, before)).To(Equal(http.StatusUnauthorized))
+
+	// Logging in again after the logout works
+	after := signTestToken(g, m, testClaims{"sub": "jane", "email": "jane@example.com", "iat": time.Now().Add(time.Second).Unix()})
+	g.Expect(apiStatus(api, after)).To(Equal(http.StatusOK))
+
+	// Logout tokens can't be replayed
+	g.Expect(backChannelLogout(s, logout).StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func TestBackChannelLogoutRevokesSession(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, m := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+	api := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}), s, nil)
+
+	iat := time.Now().Add(-time.Minute).Unix()
+	laptop := signTestToken(g, m, testClaims{"sub": "jane", "sid": "laptop", "email": "jane@example.com", "iat": iat})
+	phone := signTestToken(g, m, testClaims{"sub": "jane", "sid": "phone", "email": "jane@example.com", "iat": iat})
+
+	g.Expect(backChannelLogout(s, signTestToken(g, m, logoutClaims("jane", "laptop", "jti-1"))).StatusCode).To(Equal(http.StatusOK))
+
+	g.Expect(apiStatus(api, laptop)).To(Equal(http.StatusUnauthorized))
+	g.Expect(apiStatus(api, phone)).To(Equal(http.StatusOK))
+}
+
+func TestBackChannelLogoutRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims testClaims
+	}{
+		{
+			name:   "no sub or sid",
+			claims: logoutClaims("", "", "jti"),
+		},
+		{
+			name:   "no logout event",
+			claims: withClaim(logoutClaims("jane", "", "jti"), "events", map[string]interface{}{}),
+		},
+		{
+			name:   "nonce",
+			claims: withClaim(logoutClaims("jane", "", "jti"), "nonce", "abc"),
+		},
+		{
+			name:   "wrong audience",
+			claims: withClaim(logoutClaims("jane", "", "jti"), "aud", "another-client"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			s, m := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+
+			claims := tt.claims
+			claims["iss"] = m.Issuer()
+
+			if _, ok := claims["aud"]; !ok {
+				claims["aud"] = m.Config().ClientID
+			}
+
+			token, err := m.Keypair.SignJWT(claims)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			resp := backChannelLogout(s, token)
+			g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	}
+}
+
+func TestBackChannelLogoutWithWrongMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+
+	w := httptest.NewRecorder()
+	s.BackChannelLogout().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/oauth2/backchannel_logout", nil))
+	g.Expect(w.Result().StatusCode).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func logoutClaims(sub, sid, jti string) testClaims {
+	c := testClaims{
+		"iat":    time.Now().Unix(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+		"jti":    jti,
+		"events": map[string]interface{}{backChannelLogoutEvent: map[string]interface{}{}},
+	}
+
+	if sub != "" {
+		c["sub"] = sub
+	}
+
+	if sid != "" {
+		c["sid"] = sid
+	}
+
+	return c
+}
+
+func withClaim(c testClaims, name string, value interface{}) testClaims {
+	c[name] = value
+
+	return c
+}
+
+func signTestToken(g *WithT, m *mockoidc.MockOIDC, claims testClaims) string {
+	claims["iss"] = m.Issuer()
+	claims["aud"] = m.Config().ClientID
+
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+
+	token, err := m.Keypair.SignJWT(claims)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return token
+}
+
+func backChannelLogout(s *auth.AuthServer, token string) *http.Response {
+	form := url.Values{"logout_token": {token}}
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/oauth2/backchannel_logout", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	s.BackChannelLogout().ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func apiStatus(api http.Handler, token string) int {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/v1/objects", nil)
+	req.AddCookie(&http.Cookie{Name: auth.IDTokenCookieName, Value: token})
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	return w.Result().StatusCode
+}
+
+func TestBackChannelLogoutRevokesDerivedTokens(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, m := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+	s.EnableTokenExchange(knownClusters("leaf-1"))
+
+	session := signTestToken(g, m, testClaims{"sub": "jane", "sid": "laptop", "email": "jane@example.com", "iat": time.Now().Add(-time.Minute).Unix()})
+
+	form := url.Values{
+		"grant_type":         {auth.TokenExchangeGrantType},
+		"subject_token":      {session},
+		"subject_token_type": {auth.TokenTypeIDToken},
+		"audience":           {"leaf-1"},
+	}
+
+	w := exchangeToken(s, form)
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+
+	var resp auth.TokenExchangeResponse
+	g.Expect(json.NewDecoder(w.Body).Decode(&resp)).To(Succeed())
+
+	principal, err := s.VerifyClusterToken(resp.AccessToken, "leaf-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(principal.ID).To(Equal("jane@example.com"))
+
+	g.Expect(backChannelLogout(s, signTestToken(g, m, logoutClaims("jane", "laptop", "jti-1"))).StatusCode).To(Equal(http.StatusOK))
+
+	_, err = s.VerifyClusterToken(resp.AccessToken, "leaf-1")
+	g.Expect(err).To(HaveOccurred())
+
+	// The revoked session can't be exchanged for new tokens either.
+	g.Expect(exchangeToken(s, form).Code).To(Equal(http.StatusBadRequest))
+}
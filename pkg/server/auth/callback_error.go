@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// How the Callback handler reports a failed login.
+const (
+	// CallbackErrorHTML renders an error page with a link to log in again
+	CallbackErrorHTML = "html"
+	// CallbackErrorJSON returns the error as JSON, for API clients
+	CallbackErrorJSON = "json"
+	// CallbackErrorRedirect redirects to the sign in page, which shows the
+	// error reason from the query parameters
+	CallbackErrorRedirect = "redirect"
+)
+
+// Reasons a login can fail in the Callback handler. These are passed to the
+// frontend so must not change.
+const (
+	CallbackReasonProviderError  = "provider_error"
+	CallbackReasonMissingCode    = "missing_code"
+	CallbackReasonMissingState   = "missing_state"
+	CallbackReasonStateMismatch  = "state_mismatch"
+	CallbackReasonInvalidState   = "invalid_state"
+	CallbackReasonExchangeFailed = "exchange_failed"
+	CallbackReasonInvalidToken   = "invalid_token"
+)
+
+// CorrelationIDHeader is set on failed callback responses so the error can
+// be matched to the server logs.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// signInPath is the frontend page users are sent back to after a failed
+// login.
+const signInPath = "/sign_in"
+
+var callbackErrorPage = template.Must(template.New("callback-error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Login failed - Weave GitOps</title>
+</head>
+<body>
+<h1>Login failed</h1>
+<p>{{ .Message }}</p>
+<p>If this keeps happening, contact your administrator with the correlation ID <code>{{ .CorrelationID }}</code>.</p>
+<p><a href="{{ .RetryURL }}">Retry login</a></p>
+</body>
+</html>
+`))
+
+// CallbackError is the response returned when the Callback handler fails.
+type CallbackError struct {
+	Code          int    `json:"code"`
+	Reason        string `json:"reason"`
+	Message       string `json:"message"`
+	CorrelationID string `json:"correlationId"`
+	RetryURL      string `json:"retryUrl"`
+}
+
+// SetCallbackErrorResponse configures how the Callback handler reports a
+// failed login, one of "html", "json" or "redirect".
+func (s *AuthServer) SetCallbackErrorResponse(format string) error {
+	switch format {
+	case CallbackErrorHTML, CallbackErrorJSON, CallbackErrorRedirect:
+		s.callbackErrorResponse = format
+	default:
+		return fmt.Errorf("unknown callback error response %q, valid values are %s, %s and %s", format, CallbackErrorHTML, CallbackErrorJSON, CallbackErrorRedirect)
+	}
+
+	return nil
+}
+
+// callbackError logs the cause of a failed login under a new correlation ID
+// and reports it to the user. The cause isn't shown to the user as it may
+// contain details of the OIDC provider.
+func (s *AuthServer) callbackError(rw http.ResponseWriter, r *http.Request, code int, reason, message string, cause error) {
+	correlationID, err := generateCorrelationID()
+	if err != nil {
+		s.Log.Error(err, "failed to generate correlation ID")
+	}
+
+	s.Log.Error(cause, "OIDC callback failed", "reason", reason, "correlationID", correlationID)
+
+	resp := CallbackError{
+		Code:          code,
+		Reason:        reason,
+		Message:       message,
+		CorrelationID: correlationID,
+		RetryURL:      signInURL(reason, correlationID),
+	}
+
+	rw.Header().Set(CorrelationIDHeader, correlationID)
+
+	switch s.callbackErrorResponse {
+	case CallbackErrorRedirect:
+		http.Redirect(rw, r, resp.RetryURL, http.StatusSeeOther)
+	case CallbackErrorJSON:
+		writeJSON(s.Log, rw, resp, code)
+	default:
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Header().Set("Cache-Control", "no-store")
+		rw.WriteHeader(code)
+
+		if err := callbackErrorPage.Execute(rw, resp); err != nil {
+			s.Log.Error(err, "failed rendering callback error page")
+		}
+	}
+}
+
+func signInURL(reason, correlationID string) string {
+	q := url.Values{}
+	q.Set("error", reason)
+	q.Set("correlation_id", correlationID)
+
+	return signInPath + "?" + q.Encode()
+}
+
+func generateCorrelationID() (string, error) {
+	b := make([]byte, 8)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
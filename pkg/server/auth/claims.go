@@ -6,9 +6,14 @@ import (
 
 // ClaimsConfig provides the keys to extract the details for a Principal
 // from set of JWT claims.
+//
+// Username is the claim used as the ID of the user when impersonating, it
+// should be stable, e.g. sub or upn. DisplayName is an optional claim that is
+// only shown to the user in the UI.
 type ClaimsConfig struct {
-	Username string
-	Groups   string
+	Username    string
+	Groups      string
+	DisplayName string
 }
 
 type claimsToken interface {
@@ -59,5 +64,12 @@ func (c *ClaimsConfig) PrincipalFromClaims(token claimsToken) (*UserPrincipal, e
 		}
 	}
 
-	return &UserPrincipal{ID: id, Groups: groups}, nil
+	var displayName string
+
+	if c != nil && c.DisplayName != "" {
+		// The display name is cosmetic, so a missing claim is not an error.
+		displayName, _ = claims[c.DisplayName].(string)
+	}
+
+	return &UserPrincipal{ID: id, Groups: groups, DisplayName: displayName}, nil
 }
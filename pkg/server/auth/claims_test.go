@@ -42,6 +42,20 @@ func TestPrincipalFromClaims(t *testing.T) {
 			config: &auth.ClaimsConfig{Groups: "test_groups"},
 			want:   &auth.UserPrincipal{ID: "example@example.com", Groups: []string{"new-group1", "new-group2"}},
 		},
+		{
+			name: "display name claim",
+			token: testutils.MakeJWToken(t, privKey, "example@example.com", func(m map[string]any) {
+				m["name"] = "Example User"
+			}),
+			config: &auth.ClaimsConfig{Username: "sub", DisplayName: "name"},
+			want:   &auth.UserPrincipal{ID: "testing", Groups: []string{"testing"}, DisplayName: "Example User"},
+		},
+		{
+			name:   "missing display name claim",
+			token:  testutils.MakeJWToken(t, privKey, "example@example.com"),
+			config: &auth.ClaimsConfig{DisplayName: "name"},
+			want:   &auth.UserPrincipal{ID: "example@example.com", Groups: []string{"testing"}},
+		},
 	}
 
 	srv := testutils.MakeKeysetServer(t, privKey)
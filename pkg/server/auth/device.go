@@ -0,0 +1,593 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// DeviceCodeGrantType is the grant_type used when polling the token
+	// endpoint as described in RFC 8628 section 3.4.
+	DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// deviceCodeExpiry is how long a device code and its user code stay
+	// valid before the CLI has to start over.
+	deviceCodeExpiry = 10 * time.Minute
+	// devicePollInterval is the minimum number of seconds the client must
+	// wait between polling requests.
+	devicePollInterval = 5 * time.Second
+	// deviceSlowDownIncrement is added to the interval of a client each
+	// time it is told to slow down, see RFC 8628 section 3.5.
+	deviceSlowDownIncrement = 5 * time.Second
+	// deviceMaxPending caps the device authorizations waiting for approval,
+	// so unauthenticated clients can't grow the store without bound.
+	deviceMaxPending = 1000
+	// deviceSweepPeriod is how often expired device authorizations are
+	// removed, whether or not new ones are created.
+	deviceSweepPeriod = time.Minute
+
+	// userCodeCharset excludes vowels and ambiguous characters as
+	// recommended in RFC 8628 section 6.1.
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+
+	// deviceAudience is the audience of the tokens handed to devices, it
+	// can't be requested through the token exchange endpoint.
+	deviceAudience = reservedAudiencePrefix + "device"
+	// deviceCSRFCookieName holds the CSRF token of the verification page,
+	// which is also sent with the form.
+	deviceCSRFCookieName = "device_csrf"
+)
+
+// Error codes returned from the device token endpoint, see RFC 8628 section 3.5.
+const (
+	DeviceErrAuthorizationPending = "authorization_pending"
+	DeviceErrSlowDown             = "slow_down"
+	DeviceErrAccessDenied         = "access_denied"
+	DeviceErrExpiredToken         = "expired_token"
+	DeviceErrInvalidGrant         = "invalid_grant"
+	DeviceErrUnsupportedGrantType = "unsupported_grant_type"
+)
+
+// DeviceAuthorizationResponse is returned from the device authorization
+// endpoint when a client starts the device flow.
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceTokenResponse is returned from the device token endpoint once the
+// user has approved the request.
+type DeviceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in,omitempty"`
+}
+
+// OAuthErrorResponse is returned from the device and token exchange
+// endpoints when a request is pending or has failed.
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type deviceAuthorization struct {
+	deviceCode string
+	userCode   string
+	expiresAt  time.Time
+	lastPoll   time.Time
+	interval   time.Duration
+	token      string
+	denied     bool
+}
+
+// deviceAuthStore keeps pending device authorizations in memory. Like the
+// rest of the AuthServer this means device logins do not survive a restart
+// and are not shared between replicas.
+type deviceAuthStore struct {
+	mu          sync.Mutex
+	byDevice    map[string]*deviceAuthorization
+	byUserCode  map[string]*deviceAuthorization
+	now         func() time.Time
+	expiry      time.Duration
+	minInterval time.Duration
+	maxPending  int
+}
+
+var errTooManyDeviceAuthorizations = errors.New("too many pending device authorizations")
+
+func newDeviceAuthStore() *deviceAuthStore {
+	return &deviceAuthStore{
+		byDevice:    map[string]*deviceAuthorization{},
+		byUserCode:  map[string]*deviceAuthorization{},
+		now:         time.Now,
+		expiry:      deviceCodeExpiry,
+		minInterval: devicePollInterval,
+		maxPending:  deviceMaxPending,
+	}
+}
+
+// sweep removes expired device authorizations periodically until the
+// context is cancelled.
+func (d *deviceAuthStore) sweep(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		d.mu.Lock()
+		d.expireLocked()
+		d.mu.Unlock()
+	}
+}
+
+func (d *deviceAuthStore) create() (*deviceAuthorization, error) {
+	deviceCode, err := generateNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate device code: %w", err)
+	}
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate user code: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireLocked()
+
+	if len(d.byDevice) >= d.maxPending {
+		return nil, errTooManyDeviceAuthorizations
+	}
+
+	da := &deviceAuthorization{
+		deviceCode: deviceCode,
+		userCode:   userCode,
+		expiresAt:  d.now().Add(d.expiry),
+		interval:   d.minInterval,
+	}
+	d.byDevice[deviceCode] = da
+	d.byUserCode[userCode] = da
+
+	return da, nil
+}
+
+// approve binds a session token to the pending authorization identified by
+// userCode.
+func (d *deviceAuthStore) approve(userCode, token string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	da, ok := d.byUserCode[normalizeUserCode(userCode)]
+	if !ok || d.now().After(da.expiresAt) {
+		return false
+	}
+
+	da.token = token
+
+	return true
+}
+
+func (d *deviceAuthStore) deny(userCode string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	da, ok := d.byUserCode[normalizeUserCode(userCode)]
+	if !ok || d.now().After(da.expiresAt) {
+		return false
+	}
+
+	da.denied = true
+
+	return true
+}
+
+func (d *deviceAuthStore) pending(userCode string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	da, ok := d.byUserCode[normalizeUserCode(userCode)]
+
+	return ok && !d.now().After(da.expiresAt) && da.token == "" && !da.denied
+}
+
+// poll returns the token for deviceCode if it has been approved, otherwise
+// it returns the RFC 8628 error code the client should receive.
+func (d *deviceAuthStore) poll(deviceCode string) (string, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	da, ok := d.byDevice[deviceCode]
+	if !ok {
+		return "", DeviceErrInvalidGrant
+	}
+
+	now := d.now()
+
+	if now.After(da.expiresAt) {
+		d.removeLocked(da)
+		return "", DeviceErrExpiredToken
+	}
+
+	if da.denied {
+		d.removeLocked(da)
+		return "", DeviceErrAccessDenied
+	}
+
+	if da.token != "" {
+		d.removeLocked(da)
+		return da.token, ""
+	}
+
+	// A client polling too fast must wait longer from then on.
+	if !da.lastPoll.IsZero() && now.Sub(da.lastPoll) < da.interval {
+		da.lastPoll = now
+		da.interval += deviceSlowDownIncrement
+
+		return "", DeviceErrSlowDown
+	}
+
+	da.lastPoll = now
+
+	return "", DeviceErrAuthorizationPending
+}
+
+func (d *deviceAuthStore) removeLocked(da *deviceAuthorization) {
+	delete(d.byDevice, da.deviceCode)
+	delete(d.byUserCode, da.userCode)
+}
+
+func (d *deviceAuthStore) expireLocked() {
+	now := d.now()
+
+	for _, da := range d.byDevice {
+		if now.After(da.expiresAt) {
+			d.removeLocked(da)
+		}
+	}
+}
+
+func generateUserCode() (string, error) {
+	b := make([]byte, userCodeLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = userCodeCharset[int(b[i])%len(userCodeCharset)]
+	}
+
+	return string(b), nil
+}
+
+// normalizeUserCode strips the separator and case from a user supplied
+// code so "bcdf-ghjk" matches "BCDFGHJK".
+func normalizeUserCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// DeviceAuthorization starts the device flow for a client that has no
+// browser, returning the codes the user needs to approve the login from
+// another device.
+func (s *AuthServer) DeviceAuthorization(verificationPath string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.Header().Add("Allow", "POST")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		da, err := s.devices.create()
+		if errors.Is(err, errTooManyDeviceAuthorizations) {
+			s.Log.Info("rejecting device authorization", "reason", err.Error())
+			JSONError(s.Log, rw, err.Error(), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if err != nil {
+			s.Log.Error(err, "failed to create device authorization")
+			JSONError(s.Log, rw, "failed to create device authorization", http.StatusInternalServerError)
+
+			return
+		}
+
+		verificationURI := requestBaseURL(r) + verificationPath
+		userCode := formatUserCode(da.userCode)
+
+		resp := DeviceAuthorizationResponse{
+			DeviceCode:              da.deviceCode,
+			UserCode:                userCode,
+			VerificationURI:         verificationURI,
+			VerificationURIComplete: verificationURI + "?" + url.Values{"user_code": {userCode}}.Encode(),
+			ExpiresIn:               int(s.devices.expiry.Seconds()),
+			Interval:                int(s.devices.minInterval.Seconds()),
+		}
+
+		writeJSON(s.Log, rw, resp, http.StatusOK)
+	}
+}
+
+// DeviceToken is polled by the client until the user has approved or denied
+// the device authorization.
+func (s *AuthServer) DeviceToken() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.Header().Add("Allow", "POST")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if grantType := r.FormValue("grant_type"); grantType != DeviceCodeGrantType {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: DeviceErrUnsupportedGrantType}, http.StatusBadRequest)
+			return
+		}
+
+		token, errCode := s.devices.poll(r.FormValue("device_code"))
+		if errCode != "" {
+			if errCode != DeviceErrAuthorizationPending && errCode != DeviceErrSlowDown {
+				opsLoginAttempts.WithLabelValues(loginMethodDevice, resultFailure).Inc()
+			}
+
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: errCode}, http.StatusBadRequest)
+			return
+		}
+
+		recordLogin(loginMethodDevice, nil)
+
+		writeJSON(s.Log, rw, DeviceTokenResponse{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(s.OIDCConfig.TokenDuration.Seconds()),
+		}, http.StatusOK)
+	}
+}
+
+// DeviceVerification is the page the user opens in a browser to approve a
+// device login. The user must already be signed in to the dashboard, a token
+// for their identity is handed to the waiting client once they approve. The
+// form carries a CSRF token, so other sites can't approve a login for the
+// user.
+func (s *AuthServer) DeviceVerification() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			rw.Header().Add("Allow", "GET, POST")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		principal, parent, ok := s.sessionPrincipal(r)
+		if !ok {
+			http.Redirect(rw, r, "/sign_in?"+url.Values{"redirect": {r.URL.RequestURI()}}.Encode(), http.StatusSeeOther)
+			return
+		}
+
+		userCode := r.FormValue("user_code")
+
+		if r.Method == http.MethodGet {
+			page := devicePage{UserCode: userCode, Pending: true}
+			if userCode != "" && !s.devices.pending(userCode) {
+				page = devicePage{Message: "Unknown or expired code."}
+			}
+
+			if page.Pending {
+				csrfToken, err := generateNonce()
+				if err != nil {
+					s.Log.Error(err, "failed to generate CSRF token")
+					rw.WriteHeader(http.StatusInternalServerError)
+
+					return
+				}
+
+				http.SetCookie(rw, &http.Cookie{
+					Name:     deviceCSRFCookieName,
+					Value:    csrfToken,
+					Path:     r.URL.Path,
+					Expires:  time.Now().UTC().Add(s.devices.expiry),
+					HttpOnly: true,
+					SameSite: http.SameSiteStrictMode,
+				})
+
+				page.CSRFToken = csrfToken
+			}
+
+			s.renderDevicePage(rw, page, http.StatusOK)
+
+			return
+		}
+
+		if !sameOrigin(r) || !validDeviceCSRFToken(r) {
+			s.renderDevicePage(rw, devicePage{Message: "The request could not be verified, reload the page and try again."}, http.StatusForbidden)
+			return
+		}
+
+		var handled bool
+
+		if r.FormValue("action") == "approve" {
+			token, err := s.tokenSignerVerifier.SignClusterToken(principal, deviceAudience, s.OIDCConfig.TokenDuration, parent)
+			if err != nil {
+				s.Log.Error(err, "failed to sign device token")
+				rw.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			handled = s.devices.approve(userCode, token)
+		} else {
+			handled = s.devices.deny(userCode)
+		}
+
+		if !handled {
+			s.renderDevicePage(rw, devicePage{UserCode: userCode, Message: "Unknown or expired code."}, http.StatusOK)
+			return
+		}
+
+		s.renderDevicePage(rw, devicePage{Message: "Done. You can return to your terminal."}, http.StatusOK)
+	}
+}
+
+// sessionPrincipal returns the principal and the revocable session of the
+// dashboard session of the request if it is valid for either of the enabled
+// login methods.
+func (s *AuthServer) sessionPrincipal(r *http.Request) (*UserPrincipal, *ParentSession, bool) {
+	cookie, err := r.Cookie(IDTokenCookieName)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	principal, parent, err := s.principalFromToken(r.Context(), cookie.Value)
+	if err != nil || principal == nil {
+		return nil, nil, false
+	}
+
+	return principal, parent, true
+}
+
+// validDeviceCSRFToken checks the CSRF token of the form matches the one of
+// the cookie set with the page.
+func validDeviceCSRFToken(r *http.Request) bool {
+	cookie, err := r.Cookie(deviceCSRFCookieName)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.FormValue("csrf_token"))) == 1
+}
+
+// sameOrigin checks the Origin, or else the Referer, of a request is the
+// server itself. Browsers send either with form posts.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+
+	return err == nil && u.Host == r.Host
+}
+
+// DeviceTokenPrincipalGetter accepts the tokens handed to clients by the
+// device flow, in the Authorization header. Other tokens are left to the
+// other getters.
+type DeviceTokenPrincipalGetter struct {
+	log      logr.Logger
+	verifier ClusterTokenVerifier
+}
+
+func NewDeviceTokenPrincipalGetter(log logr.Logger, verifier ClusterTokenVerifier) PrincipalGetter {
+	return &DeviceTokenPrincipalGetter{
+		log:      log,
+		verifier: verifier,
+	}
+}
+
+func (pg *DeviceTokenPrincipalGetter) Principal(r *http.Request) (*UserPrincipal, error) {
+	token := extractToken(r.Header.Get(AuthorizationTokenHeaderName))
+	if token == "" {
+		return nil, nil
+	}
+
+	claims, err := pg.verifier.VerifyClusterToken(token, deviceAudience)
+	if err != nil {
+		// probably not our token, e.g. an OIDC one
+		return nil, nil
+	}
+
+	return NewUserPrincipal(ID(claims.Subject), Groups(claims.Groups)), nil
+}
+
+type devicePage struct {
+	UserCode  string
+	CSRFToken string
+	Pending   bool
+	Message   string
+}
+
+var devicePageTemplate = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Weave GitOps device login</title></head>
+<body>
+<h1>Weave GitOps device login</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Pending}}
+<form method="POST">
+<p>Confirm that this code matches the one shown in your terminal.</p>
+<input type="text" name="user_code" value="{{.UserCode}}" autocomplete="off">
+<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+<button type="submit" name="action" value="approve">Approve</button>
+<button type="submit" name="action" value="deny">Deny</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+func (s *AuthServer) renderDevicePage(rw http.ResponseWriter, page devicePage, code int) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("X-Frame-Options", "DENY")
+	rw.WriteHeader(code)
+
+	if err := devicePageTemplate.Execute(rw, page); err != nil {
+		s.Log.Error(err, "failed to render device page")
+	}
+}
+
+func writeJSON(log logr.Logger, rw http.ResponseWriter, v interface{}, code int) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(code)
+
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Error(err, "failed encoding response")
+	}
+}
+
+// requestBaseURL reconstructs the externally visible scheme and host of the
+// request, honouring X-Forwarded-Proto when behind a TLS terminating proxy.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return scheme + "://" + r.Host
+}
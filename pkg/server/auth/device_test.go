@@ -0,0 +1,340 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+	s.OIDCConfig.TokenDuration = time.Hour
+
+	signed, err := tokenSignerVerifier.Sign("admin")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tests := []struct {
+		name      string
+		action    string
+		wantToken bool
+		wantError string
+	}{
+		{
+			name:      "approved",
+			action:    "approve",
+			wantToken: true,
+		},
+		{
+			name:      "denied",
+			action:    "deny",
+			wantError: auth.DeviceErrAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			da := startDeviceFlow(g, s)
+			g.Expect(da.UserCode).To(MatchRegexp(`^[A-Z]{4}-[A-Z]{4}$`))
+			g.Expect(da.VerificationURI).To(Equal("http://example.com/oauth2/device"))
+			g.Expect(da.VerificationURIComplete).To(HavePrefix(da.VerificationURI + "?user_code="))
+
+			_, errCode := pollDeviceToken(g, s, da.DeviceCode)
+			g.Expect(errCode).To(Equal(auth.DeviceErrAuthorizationPending))
+
+			_, errCode = pollDeviceToken(g, s, da.DeviceCode)
+			g.Expect(errCode).To(Equal(auth.DeviceErrSlowDown))
+
+			csrf := openDevicePage(g, s, da.UserCode, signed)
+
+			w := submitDevicePage(s, url.Values{"user_code": {strings.ToLower(da.UserCode)}, "action": {tt.action}, "csrf_token": {csrf.Value}}, signed, csrf, "")
+			g.Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+			token, errCode := pollDeviceToken(g, s, da.DeviceCode)
+			g.Expect(errCode).To(Equal(tt.wantError))
+
+			if tt.wantToken {
+				// the device gets a token of its own, not the session
+				g.Expect(token).NotTo(BeEmpty())
+				g.Expect(token).NotTo(Equal(signed))
+
+				_, err := tokenSignerVerifier.Verify(token)
+				g.Expect(err).To(HaveOccurred())
+
+				req := httptest.NewRequest(http.MethodGet, "http://example.com/v1/objects", nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+
+				principal, err := auth.NewDeviceTokenPrincipalGetter(logr.Discard(), tokenSignerVerifier).Principal(req)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(principal.ID).To(Equal("admin"))
+			} else {
+				g.Expect(token).To(BeEmpty())
+			}
+
+			_, errCode = pollDeviceToken(g, s, da.DeviceCode)
+			g.Expect(errCode).To(Equal(auth.DeviceErrInvalidGrant))
+		})
+	}
+}
+
+func TestDeviceVerificationRejectsCrossSiteRequests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+	s.OIDCConfig.TokenDuration = time.Hour
+
+	signed, err := tokenSignerVerifier.Sign("admin")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tests := []struct {
+		name      string
+		csrfToken string
+		cookie    bool
+		origin    string
+	}{
+		{
+			name: "no CSRF token",
+		},
+		{
+			name:      "wrong CSRF token",
+			csrfToken: "guessed",
+			cookie:    true,
+		},
+		{
+			name:   "other origin",
+			cookie: true,
+			origin: "https://attacker.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			da := startDeviceFlow(g, s)
+			csrf := openDevicePage(g, s, da.UserCode, signed)
+
+			form := url.Values{"user_code": {da.UserCode}, "action": {"approve"}}
+
+			if tt.cookie {
+				form.Set("csrf_token", csrf.Value)
+			} else {
+				csrf = nil
+			}
+
+			if tt.csrfToken != "" {
+				form.Set("csrf_token", tt.csrfToken)
+			}
+
+			w := submitDevicePage(s, form, signed, csrf, tt.origin)
+			g.Expect(w.Result().StatusCode).To(Equal(http.StatusForbidden))
+
+			_, errCode := pollDeviceToken(g, s, da.DeviceCode)
+			g.Expect(errCode).To(Equal(auth.DeviceErrAuthorizationPending))
+		})
+	}
+}
+
+func TestDeviceTokenAuthenticatesAPIRequests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+	s.OIDCConfig.TokenDuration = time.Hour
+
+	signed, err := tokenSignerVerifier.Sign("admin")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	da := startDeviceFlow(g, s)
+	csrf := openDevicePage(g, s, da.UserCode, signed)
+
+	w := submitDevicePage(s, url.Values{"user_code": {da.UserCode}, "action": {"approve"}, "csrf_token": {csrf.Value}}, signed, csrf, "http://example.com")
+	g.Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+	token, errCode := pollDeviceToken(g, s, da.DeviceCode)
+	g.Expect(errCode).To(BeEmpty())
+
+	var principal *auth.UserPrincipal
+
+	handler := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal = auth.Principal(r.Context())
+	}), s, nil)
+
+	for bearer, want := range map[string]int{token: http.StatusOK, signed: http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/v1/objects", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		g.Expect(w.Result().StatusCode).To(Equal(want))
+	}
+
+	g.Expect(principal.ID).To(Equal("admin"))
+}
+
+func TestDeviceVerificationRequiresSession(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/oauth2/device?user_code=BCDF-GHJK", nil)
+	req.AddCookie(&http.Cookie{Name: auth.IDTokenCookieName, Value: "not-a-token"})
+
+	w := httptest.NewRecorder()
+	s.DeviceVerification().ServeHTTP(w, req)
+
+	resp := w.Result()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusSeeOther))
+	g.Expect(resp.Header.Get("Location")).To(Equal("/sign_in?redirect=%2Foauth2%2Fdevice%3Fuser_code%3DBCDF-GHJK"))
+}
+
+func TestDeviceTokenUnsupportedGrantType(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), nil, []auth.AuthMethod{auth.UserAccount})
+
+	form := url.Values{"grant_type": {"authorization_code"}, "device_code": {"abc"}}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/oauth2/device/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	s.DeviceToken().ServeHTTP(w, req)
+
+	resp := w.Result()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+	var errResp auth.OAuthErrorResponse
+	g.Expect(json.NewDecoder(resp.Body).Decode(&errResp)).To(Succeed())
+	g.Expect(errResp.Error).To(Equal(auth.DeviceErrUnsupportedGrantType))
+}
+
+// openDevicePage opens the verification page, returning its CSRF cookie.
+func openDevicePage(g *WithT, s *auth.AuthServer, userCode, session string) *http.Cookie {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/oauth2/device?"+url.Values{"user_code": {userCode}}.Encode(), nil)
+	req.AddCookie(&http.Cookie{Name: auth.IDTokenCookieName, Value: session})
+
+	w := httptest.NewRecorder()
+	s.DeviceVerification().ServeHTTP(w, req)
+
+	resp := w.Result()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(resp.Cookies()).To(HaveLen(1))
+
+	csrf := resp.Cookies()[0]
+	g.Expect(csrf.SameSite).To(Equal(http.SameSiteStrictMode))
+	g.Expect(w.Body.String()).To(ContainSubstring(`name="csrf_token"`))
+
+	return csrf
+}
+
+func submitDevicePage(s *auth.AuthServer, form url.Values, session string, csrf *http.Cookie, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/oauth2/device", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: auth.IDTokenCookieName, Value: session})
+
+	if csrf != nil {
+		req.AddCookie(&http.Cookie{Name: csrf.Name, Value: csrf.Value})
+	}
+
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+
+	w := httptest.NewRecorder()
+	s.DeviceVerification().ServeHTTP(w, req)
+
+	return w
+}
+
+func startDeviceFlow(g *WithT, s *auth.AuthServer) auth.DeviceAuthorizationResponse {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/oauth2/device/code", nil)
+	w := httptest.NewRecorder()
+	s.DeviceAuthorization("/oauth2/device").ServeHTTP(w, req)
+
+	resp := w.Result()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	var da auth.DeviceAuthorizationResponse
+	g.Expect(json.NewDecoder(resp.Body).Decode(&da)).To(Succeed())
+
+	return da
+}
+
+func pollDeviceToken(g *WithT, s *auth.AuthServer, deviceCode string) (string, string) {
+	form := url.Values{"grant_type": {auth.DeviceCodeGrantType}, "device_code": {deviceCode}}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/oauth2/device/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	s.DeviceToken().ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode == http.StatusOK {
+		var tok auth.DeviceTokenResponse
+		g.Expect(json.NewDecoder(resp.Body).Decode(&tok)).To(Succeed())
+
+		return tok.AccessToken, ""
+	}
+
+	var errResp auth.OAuthErrorResponse
+	g.Expect(json.NewDecoder(resp.Body).Decode(&errResp)).To(Succeed())
+
+	return "", errResp.Error
+}
+
+func makeClusterUserClient() ctrlclient.Client {
+	return ctrlclientfake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      auth.ClusterUserAuthSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"username": []byte("admin"),
+			"password": []byte("hash"),
+		},
+	}).Build()
+}
+
+func TestDeviceAuthorizationLimit(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+
+	for i := 0; i < 1000; i++ {
+		startDeviceFlow(g, s)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/oauth2/device/code", nil)
+	w := httptest.NewRecorder()
+	s.DeviceAuthorization("/oauth2/device").ServeHTTP(w, req)
+	g.Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+}
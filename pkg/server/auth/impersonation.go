@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ImpersonationCookieName holds the user an admin is impersonating.
+	ImpersonationCookieName = "impersonation"
+
+	// The audience of impersonation tokens is bound to the admin that
+	// started impersonating, so the cookie is useless to anybody else.
+	impersonationAudiencePrefix = reservedAudiencePrefix + "impersonate:"
+	impersonationDuration       = time.Hour
+
+	// systemPrefix is reserved by Kubernetes for its own users and groups,
+	// e.g. system:masters, which must never be impersonated.
+	systemPrefix = "system:"
+)
+
+// ImpersonationRequest is the user and groups an admin wants to act as.
+type ImpersonationRequest struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups"`
+}
+
+// EnableImpersonation allows members of the admin group to act as other
+// users for troubleshooting. Only the allowed groups may be impersonated
+// along with the user.
+func (s *AuthServer) EnableImpersonation(adminGroup string, allowedGroups []string) {
+	s.impersonationAdminGroup = adminGroup
+	s.impersonationGroups = allowedGroups
+}
+
+// Impersonate starts impersonating the user in the request on POST, and
+// stops on DELETE. It must be wrapped in WithAPIAuth so the admin is known.
+//
+// While impersonating, WithAPIAuth replaces the admin with the impersonated
+// user and logs every request with both identities.
+func (s *AuthServer) Impersonate() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if s.impersonationAdminGroup == "" {
+			JSONError(s.Log, rw, "Impersonation is not enabled", http.StatusNotFound)
+			return
+		}
+
+		principal := Principal(r.Context())
+		if principal == nil {
+			JSONError(s.Log, rw, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			s.startImpersonation(rw, r, principal)
+		case http.MethodDelete:
+			if principal.Impersonator != "" {
+				s.Log.Info("impersonation stopped", "impersonator", principal.Impersonator, "user", principal.ID, "groups", principal.Groups)
+			}
+
+			http.SetCookie(rw, s.clearCookie(ImpersonationCookieName))
+			rw.WriteHeader(http.StatusNoContent)
+		default:
+			rw.Header().Add("Allow", "POST, DELETE")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *AuthServer) startImpersonation(rw http.ResponseWriter, r *http.Request, admin *UserPrincipal) {
+	if admin.Impersonator != "" {
+		JSONError(s.Log, rw, "Already impersonating a user", http.StatusConflict)
+		return
+	}
+
+	if !s.isImpersonationAdmin(admin) {
+		s.Log.Info("impersonation denied", "user", admin.ID, "groups", admin.Groups)
+		JSONError(s.Log, rw, "Impersonation requires membership of the admin group", http.StatusForbidden)
+
+		return
+	}
+
+	var req ImpersonationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JSONError(s.Log, rw, "Failed to read request body.", http.StatusBadRequest)
+		return
+	}
+
+	if req.User == "" {
+		JSONError(s.Log, rw, "user is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.checkImpersonationTarget(req); err != nil {
+		s.Log.Info("impersonation denied", "user", admin.ID, "target", req.User, "groups", req.Groups, "error", err.Error())
+		JSONError(s.Log, rw, err.Error(), http.StatusForbidden)
+
+		return
+	}
+
+	target := NewUserPrincipal(ID(req.User), Groups(req.Groups))
+
+	token, err := s.tokenSignerVerifier.SignClusterToken(target, impersonationAudience(admin.ID), impersonationDuration, nil)
+	if err != nil {
+		s.Log.Error(err, "failed to sign impersonation token")
+		JSONError(s.Log, rw, "Failed to start impersonation", http.StatusInternalServerError)
+
+		return
+	}
+
+	s.Log.Info("impersonation started", "impersonator", admin.ID, "user", target.ID, "groups", target.Groups)
+
+	cookie := s.createCookie(ImpersonationCookieName, token)
+	cookie.Expires = time.Now().UTC().Add(impersonationDuration)
+	http.SetCookie(rw, cookie)
+
+	writeJSON(s.Log, rw, UserInfo{
+		Email:  target.ID,
+		ID:     target.ID,
+		Groups: target.Groups,
+	}, http.StatusOK)
+}
+
+// impersonatedPrincipal returns the user the admin is impersonating, or nil
+// if the request has no valid impersonation cookie for this admin.
+func (s *AuthServer) impersonatedPrincipal(r *http.Request, admin *UserPrincipal) *UserPrincipal {
+	if s.impersonationAdminGroup == "" {
+		return nil
+	}
+
+	cookie, err := r.Cookie(ImpersonationCookieName)
+	if err != nil {
+		return nil
+	}
+
+	claims, err := s.tokenSignerVerifier.VerifyClusterToken(cookie.Value, impersonationAudience(admin.ID))
+	if err != nil {
+		s.Log.Info("ignoring invalid impersonation cookie", "user", admin.ID, "error", err.Error())
+		return nil
+	}
+
+	// The admin may have been removed from the group since starting.
+	if !s.isImpersonationAdmin(admin) {
+		s.Log.Info("ignoring impersonation cookie of user no longer in the admin group", "user", admin.ID)
+		return nil
+	}
+
+	// This deliberately doesn't carry the admin's token, so requests are
+	// made by impersonating the user rather than passing the token through.
+	p := NewUserPrincipal(ID(claims.Subject), Groups(claims.Groups))
+	p.Impersonator = admin.ID
+
+	return p
+}
+
+// checkImpersonationTarget rejects Kubernetes' own users and groups, and
+// groups the operator hasn't allowed to be impersonated.
+func (s *AuthServer) checkImpersonationTarget(req ImpersonationRequest) error {
+	if strings.HasPrefix(req.User, systemPrefix) {
+		return fmt.Errorf("user %q can't be impersonated", req.User)
+	}
+
+	for _, group := range req.Groups {
+		if strings.HasPrefix(group, systemPrefix) || !contains(s.impersonationGroups, group) {
+			return fmt.Errorf("group %q can't be impersonated", group)
+		}
+	}
+
+	return nil
+}
+
+func (s *AuthServer) isImpersonationAdmin(p *UserPrincipal) bool {
+	return contains(p.Groups, s.impersonationAdminGroup)
+}
+
+func impersonationAudience(admin string) string {
+	return impersonationAudiencePrefix + admin
+}
@@ -0,0 +1,162 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+func TestImpersonation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	srv := makeImpersonationServer(t)
+	impersonate := auth.WithAPIAuth(srv.Impersonate(), srv, nil)
+
+	resp := impersonationRequest(g, impersonate, http.MethodPost, "admin-token", nil, auth.ImpersonationRequest{User: "bob", Groups: []string{"developers"}})
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	cookie := findCookie(resp, auth.ImpersonationCookieName)
+	g.Expect(cookie).NotTo(BeNil())
+
+	var principal *auth.UserPrincipal
+
+	api := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal = auth.Principal(r.Context())
+	}), srv, nil)
+
+	impersonationRequest(g, api, http.MethodGet, "admin-token", cookie, nil)
+	g.Expect(principal).To(Equal(&auth.UserPrincipal{ID: "bob", Groups: []string{"developers"}, Impersonator: "alice"}))
+
+	// The cookie is bound to the admin that started impersonating
+	impersonationRequest(g, api, http.MethodGet, "other-admin-token", cookie, nil)
+	g.Expect(principal.ID).To(Equal("carol"))
+	g.Expect(principal.Impersonator).To(BeEmpty())
+
+	resp = impersonationRequest(g, impersonate, http.MethodDelete, "admin-token", cookie, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+	g.Expect(findCookie(resp, auth.ImpersonationCookieName).Value).To(BeEmpty())
+}
+
+func TestImpersonationRequiresAdminGroup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	srv := makeImpersonationServer(t)
+	impersonate := auth.WithAPIAuth(srv.Impersonate(), srv, nil)
+
+	resp := impersonationRequest(g, impersonate, http.MethodPost, "dev-token", nil, auth.ImpersonationRequest{User: "alice", Groups: []string{"ops-admins"}})
+	g.Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+	g.Expect(findCookie(resp, auth.ImpersonationCookieName)).To(BeNil())
+}
+
+func TestImpersonationRejectsTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		req  auth.ImpersonationRequest
+	}{
+		{
+			name: "system group",
+			req:  auth.ImpersonationRequest{User: "bob", Groups: []string{"system:masters"}},
+		},
+		{
+			name: "group not allowed",
+			req:  auth.ImpersonationRequest{User: "bob", Groups: []string{"developers", "ops-admins"}},
+		},
+		{
+			name: "system user",
+			req:  auth.ImpersonationRequest{User: "system:serviceaccount:flux-system:kustomize-controller"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			srv := makeImpersonationServer(t)
+
+			resp := impersonationRequest(g, auth.WithAPIAuth(srv.Impersonate(), srv, nil), http.MethodPost, "admin-token", nil, tt.req)
+			g.Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+			g.Expect(findCookie(resp, auth.ImpersonationCookieName)).To(BeNil())
+		})
+	}
+}
+
+func TestImpersonationCookieIgnoredForNonAdmins(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	srv := makeImpersonationServer(t)
+
+	resp := impersonationRequest(g, auth.WithAPIAuth(srv.Impersonate(), srv, nil), http.MethodPost, "admin-token", nil, auth.ImpersonationRequest{User: "bob"})
+	cookie := findCookie(resp, auth.ImpersonationCookieName)
+	g.Expect(cookie).NotTo(BeNil())
+
+	var principal *auth.UserPrincipal
+
+	api := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal = auth.Principal(r.Context())
+	}), srv, nil)
+
+	impersonationRequest(g, api, http.MethodGet, "dev-token", cookie, nil)
+	g.Expect(principal.ID).To(Equal("dave"))
+}
+
+func TestImpersonationDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.TokenFile})
+	g.Expect(srv.SetStaticTokens([]auth.StaticToken{{Token: "admin-token", User: "alice", Groups: []string{"ops-admins"}}})).To(Succeed())
+
+	resp := impersonationRequest(g, auth.WithAPIAuth(srv.Impersonate(), srv, nil), http.MethodPost, "admin-token", nil, auth.ImpersonationRequest{User: "bob"})
+	g.Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+}
+
+func makeImpersonationServer(t *testing.T) *auth.AuthServer {
+	t.Helper()
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.TokenFile})
+
+	if err := srv.SetStaticTokens([]auth.StaticToken{
+		{Token: "admin-token", User: "alice", Groups: []string{"ops-admins"}},
+		{Token: "other-admin-token", User: "carol", Groups: []string{"ops-admins"}},
+		{Token: "dev-token", User: "dave", Groups: []string{"developers"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.EnableImpersonation("ops-admins", []string{"developers", "system:masters"})
+
+	return srv
+}
+
+func impersonationRequest(g *WithT, handler http.Handler, method, token string, cookie *http.Cookie, body interface{}) *http.Response {
+	var buf bytes.Buffer
+
+	if body != nil {
+		g.Expect(json.NewEncoder(&buf).Encode(body)).To(Succeed())
+	}
+
+	req := httptest.NewRequest(method, "https://example.com/oauth2/impersonate", &buf)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w.Result()
+}
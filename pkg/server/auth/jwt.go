@@ -151,18 +151,51 @@ type MultiAuthPrincipal struct {
 }
 
 func (m MultiAuthPrincipal) Principal(r *http.Request) (*UserPrincipal, error) {
+	p, _, err := m.principal(r)
+
+	return p, err
+}
+
+// principal is Principal, but also returns the getter that found the
+// principal.
+func (m MultiAuthPrincipal) principal(r *http.Request) (*UserPrincipal, PrincipalGetter, error) {
 	for _, v := range m.Getters {
 		p, err := v.Principal(r)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		if p != nil {
 			m.Log.V(logger.LogLevelDebug).Info("Found principal", "user", p.ID, "groups", p.Groups, "tokenLength", len(p.Token()), "method", reflect.TypeOf(v))
 
-			return p, nil
+			return p, v, nil
 		}
 	}
 
-	return nil, errors.New("could not find valid principal")
+	return nil, nil, errors.New("could not find valid principal")
+}
+
+// sessionMethod is the auth method label of the session metrics for a
+// principal getter.
+func sessionMethod(pg PrincipalGetter) string {
+	var method AuthMethod
+
+	switch pg.(type) {
+	case *JWTCookiePrincipalGetter, *JWTAuthorizationHeaderPrincipalGetter, *JWTPassthroughCookiePrincipalGetter:
+		method = OIDC
+	case *JWTAdminCookiePrincipalGetter:
+		method = UserAccount
+	case *BearerTokenPassthroughPrincipalGetter:
+		method = TokenPassthrough
+	case *TokenFilePrincipalGetter:
+		method = TokenFile
+	case *TrustedHeaderPrincipalGetter:
+		method = TrustedHeader
+	case *DeviceTokenPrincipalGetter:
+		return loginMethodDevice
+	default:
+		return "unknown"
+	}
+
+	return method.String()
 }
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Values of the method label of the login metrics.
+const (
+	loginMethodUserAccount = "user-account"
+	loginMethodOIDC        = "oidc"
+	loginMethodDevice      = "device"
+	loginMethodWebAuthn    = "webauthn"
+)
+
+// Values of the result label of the auth metrics.
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	opsLoginAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gitops",
+			Subsystem: "auth",
+			Name:      "login_attempts_total",
+			Help:      "The number of login attempts",
+		},
+		[]string{
+			// How the user tried to log in
+			"method",
+			// Whether the login succeeded
+			"result",
+		},
+	)
+	opsTokenVerification = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gitops",
+			Subsystem: "auth",
+			Name:      "token_verification_duration_seconds",
+			Help:      "The time taken to authenticate an API request",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{
+			// Whether a principal was found
+			"result",
+		},
+	)
+	opsUserInfo = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gitops",
+			Subsystem: "auth",
+			Name:      "userinfo_requests_total",
+			Help:      "The number of user info lookups",
+		},
+		[]string{
+			// Whether the lookup succeeded
+			"result",
+		},
+	)
+	opsActiveSessions = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "gitops",
+			Subsystem: "auth",
+			Name:      "active_sessions",
+			Help:      "The number of users that made an authenticated request within the session duration",
+		},
+		func() float64 { return float64(activeSessions.count()) },
+	)
+
+	opsActiveSessionsByGroup = &sessionsByGroupCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName("gitops", "auth", "active_sessions_by_group"),
+			"The number of users that made an authenticated request within the session duration, by auth method and top-level group. Users in several groups are counted in each",
+			[]string{"method", "group"},
+			nil,
+		),
+		tracker: activeSessions,
+	}
+
+	activeSessions = newSessionTracker(defaultCookieDuration)
+
+	Registry = prometheus.NewRegistry()
+)
+
+func registerMetrics() {
+	_ = Registry.Register(opsLoginAttempts)
+	_ = Registry.Register(opsTokenVerification)
+	_ = Registry.Register(opsUserInfo)
+	_ = Registry.Register(opsActiveSessions)
+	_ = Registry.Register(opsActiveSessionsByGroup)
+}
+
+func recordLogin(method string, err error) {
+	opsLoginAttempts.WithLabelValues(method, result(err)).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+
+	return resultSuccess
+}
+
+// Values of the group label of the session metrics for sessions without
+// groups, and groups beyond the cardinality limit.
+const (
+	sessionGroupNone  = "none"
+	sessionGroupOther = "other"
+)
+
+// DefaultSessionGroupLimit is how many distinct groups get their own label
+// value, so large directories can't blow up the number of series.
+const DefaultSessionGroupLimit = 20
+
+// sessionTracker remembers when each user was last seen. Sessions are
+// stateless cookies so this is the closest we get to counting them.
+type sessionTracker struct {
+	mu       sync.Mutex
+	sessions map[sessionKey]trackedSession
+	window   time.Duration
+	now      func() time.Time
+
+	// groupLimit is the number of distinct group label values, after which
+	// groups are reported as "other"
+	groupLimit int
+	// hashGroups reports groups by a hash of their name
+	hashGroups bool
+	// groupLabels are the first groups seen, which have been given their
+	// own label value. They are kept for the life of the process so series
+	// don't change label.
+	groupLabels map[string]bool
+}
+
+type sessionKey struct {
+	id     string
+	method string
+}
+
+type trackedSession struct {
+	lastSeen time.Time
+	// groups are the group label values
+	groups []string
+}
+
+type sessionGroupKey struct {
+	method string
+	group  string
+}
+
+func newSessionTracker(window time.Duration) *sessionTracker {
+	return &sessionTracker{
+		sessions:    map[sessionKey]trackedSession{},
+		window:      window,
+		now:         time.Now,
+		groupLimit:  DefaultSessionGroupLimit,
+		groupLabels: map[string]bool{},
+	}
+}
+
+func (t *sessionTracker) setWindow(window time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if window > 0 {
+		t.window = window
+	}
+}
+
+// SetSessionGroupMetrics configures the group label of the active session
+// metrics. Only the first limit distinct top-level groups get their own
+// label value, and if hash is set the group names are replaced by a hash so
+// they don't leak into the metrics backend.
+func (s *AuthServer) SetSessionGroupMetrics(limit int, hash bool) {
+	activeSessions.setGroupLabels(limit, hash)
+}
+
+func (t *sessionTracker) setGroupLabels(limit int, hash bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.groupLimit = limit
+	t.hashGroups = hash
+	t.groupLabels = map[string]bool{}
+}
+
+func (t *sessionTracker) seen(id, method string, groups []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sessions[sessionKey{id: id, method: method}] = trackedSession{
+		lastSeen: t.now(),
+		groups:   t.groupLabelValues(groups),
+	}
+}
+
+// count returns the number of distinct users seen within the window.
+func (t *sessionTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune()
+
+	ids := map[string]bool{}
+	for k := range t.sessions {
+		ids[k.id] = true
+	}
+
+	return len(ids)
+}
+
+// countByGroup returns the number of users seen within the window for each
+// auth method and top-level group.
+func (t *sessionTracker) countByGroup() map[sessionGroupKey]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune()
+
+	counts := map[sessionGroupKey]int{}
+
+	for k, session := range t.sessions {
+		for _, group := range session.groups {
+			counts[sessionGroupKey{method: k.method, group: group}]++
+		}
+	}
+
+	return counts
+}
+
+func (t *sessionTracker) prune() {
+	cutoff := t.now().Add(-t.window)
+
+	for k, session := range t.sessions {
+		if session.lastSeen.Before(cutoff) {
+			delete(t.sessions, k)
+		}
+	}
+}
+
+// groupLabelValues returns the distinct label values of the top-level
+// groups, e.g. "platform" for "/platform/oncall".
+func (t *sessionTracker) groupLabelValues(groups []string) []string {
+	topLevel := map[string]bool{}
+
+	for _, g := range groups {
+		g = strings.TrimPrefix(g, "/")
+		if g == "" {
+			continue
+		}
+
+		g, _, _ = strings.Cut(g, "/")
+		topLevel[t.groupLabel(g)] = true
+	}
+
+	if len(topLevel) == 0 {
+		return []string{sessionGroupNone}
+	}
+
+	values := make([]string, 0, len(topLevel))
+	for v := range topLevel {
+		values = append(values, v)
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+func (t *sessionTracker) groupLabel(group string) string {
+	if !t.groupLabels[group] {
+		if len(t.groupLabels) >= t.groupLimit {
+			return sessionGroupOther
+		}
+
+		t.groupLabels[group] = true
+	}
+
+	if t.hashGroups {
+		sum := sha256.Sum256([]byte(group))
+		return hex.EncodeToString(sum[:])[:12]
+	}
+
+	return group
+}
+
+// sessionsByGroupCollector reports the active sessions by group when
+// scraped, as the groups aren't known in advance.
+type sessionsByGroupCollector struct {
+	desc    *prometheus.Desc
+	tracker *sessionTracker
+}
+
+func (c *sessionsByGroupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sessionsByGroupCollector) Collect(ch chan<- prometheus.Metric) {
+	for k, n := range c.tracker.countByGroup() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), k.method, k.group)
+	}
+}
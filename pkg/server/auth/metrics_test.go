@@ -0,0 +1,110 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+func TestLoginMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := makeWebAuthnServer(t, nil)
+
+	failures := metricValue(g, "gitops_auth_login_attempts_total", map[string]string{"method": "user-account", "result": "failure"})
+	successes := metricValue(g, "gitops_auth_login_attempts_total", map[string]string{"method": "user-account", "result": "success"})
+
+	g.Expect(signIn(g, s, "admin", "wrong-password").StatusCode).To(Equal(http.StatusUnauthorized))
+	g.Expect(signIn(g, s, "someone-else", "my-secret-password").StatusCode).To(Equal(http.StatusUnauthorized))
+	g.Expect(signIn(g, s, "admin", "my-secret-password").StatusCode).To(Equal(http.StatusOK))
+
+	g.Expect(metricValue(g, "gitops_auth_login_attempts_total", map[string]string{"method": "user-account", "result": "failure"})).To(Equal(failures + 2))
+	g.Expect(metricValue(g, "gitops_auth_login_attempts_total", map[string]string{"method": "user-account", "result": "success"})).To(Equal(successes + 1))
+}
+
+func TestActiveSessionsByGroupMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.TokenFile})
+	g.Expect(srv.SetStaticTokens([]auth.StaticToken{
+		{Token: "token-1", User: "metrics-alice", Groups: []string{"/platform/oncall", "/platform/dev"}},
+		{Token: "token-2", User: "metrics-bob", Groups: []string{"payments"}},
+		{Token: "token-3", User: "metrics-carol"},
+		{Token: "token-4", User: "metrics-dave", Groups: []string{"platform"}},
+	})).To(Succeed())
+
+	srv.SetSessionGroupMetrics(1, false)
+	t.Cleanup(func() { srv.SetSessionGroupMetrics(auth.DefaultSessionGroupLimit, false) })
+
+	api := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}), srv, nil)
+	request := func(token string) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/v1/objects", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res := httptest.NewRecorder()
+		api.ServeHTTP(res, req)
+		g.Expect(res.Code).To(Equal(http.StatusOK))
+	}
+
+	groupSessions := func(group string) float64 {
+		return metricValue(g, "gitops_auth_active_sessions_by_group", map[string]string{"method": "token-file", "group": group})
+	}
+
+	platform, other, none := groupSessions("platform"), groupSessions("other"), groupSessions("none")
+
+	for _, token := range []string{"token-1", "token-2", "token-3", "token-1"} {
+		request(token)
+	}
+
+	g.Expect(groupSessions("platform")).To(Equal(platform + 1))
+	g.Expect(groupSessions("other")).To(Equal(other + 1))
+	g.Expect(groupSessions("none")).To(Equal(none + 1))
+
+	srv.SetSessionGroupMetrics(1, true)
+	request("token-4")
+
+	sum := sha256.Sum256([]byte("platform"))
+	g.Expect(groupSessions(hex.EncodeToString(sum[:])[:12])).To(Equal(1.0))
+}
+
+// metricValue returns the value of the counter or gauge with the given
+// labels, or 0 if it hasn't been recorded yet.
+func metricValue(g *WithT, name string, labels map[string]string) float64 {
+	families, err := auth.Registry.Gather()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			matched := 0
+
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] == l.GetValue() {
+					matched++
+				}
+			}
+
+			if matched == len(labels) {
+				if m.GetCounter() != nil {
+					return m.GetCounter().GetValue()
+				}
+
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
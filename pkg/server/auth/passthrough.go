@@ -1,12 +1,16 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-logr/logr"
 	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -62,7 +66,7 @@ func (pg *BearerTokenPassthroughPrincipalGetter) Principal(r *http.Request) (*Us
 
 // NewJWTPassthroughCookiePrincipalGetter creates and returns a new
 // JWTPassthroughCookiePrincipalGetter.
-func NewJWTPassthroughCookiePrincipalGetter(log logr.Logger, verifier *oidc.IDTokenVerifier, cookieName string) PrincipalGetter {
+func NewJWTPassthroughCookiePrincipalGetter(log logr.Logger, verifier tokenVerifier, cookieName string) PrincipalGetter {
 	return &JWTPassthroughCookiePrincipalGetter{
 		log:        log,
 		verifier:   verifier,
@@ -76,7 +80,7 @@ func NewJWTPassthroughCookiePrincipalGetter(log logr.Logger, verifier *oidc.IDTo
 // The JWT Token is parsed, and the token and user/groups are available.
 type JWTPassthroughCookiePrincipalGetter struct {
 	log        logr.Logger
-	verifier   *oidc.IDTokenVerifier
+	verifier   tokenVerifier
 	cookieName string
 }
 
@@ -101,3 +105,149 @@ func (pg *JWTPassthroughCookiePrincipalGetter) Principal(r *http.Request) (*User
 
 	return principal, nil
 }
+
+// PassthroughConfig restricts which OIDC tokens are passed through to the
+// Kubernetes API when FeatureFlagOIDCPassthrough is set. Without it any token
+// the issuer signed for the client ID is accepted.
+type PassthroughConfig struct {
+	// Audiences accepted in the aud claim, defaults to the client ID
+	Audiences []string
+	// RequiredClaims maps claims to the value they must have. If the claim
+	// is a list it must contain the value.
+	RequiredClaims map[string]string
+	// ClockSkew is how long after expiry, or before the nbf claim, a token
+	// is still accepted
+	ClockSkew time.Duration
+}
+
+func passthroughConfigFromSecret(secret corev1.Secret) PassthroughConfig {
+	cfg := PassthroughConfig{
+		Audiences: splitList(string(secret.Data["passthroughAudiences"])),
+	}
+
+	for _, pair := range splitList(string(secret.Data["passthroughRequiredClaims"])) {
+		if cfg.RequiredClaims == nil {
+			cfg.RequiredClaims = map[string]string{}
+		}
+
+		k, v, _ := strings.Cut(pair, "=")
+		cfg.RequiredClaims[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	if skew, err := time.ParseDuration(string(secret.Data["passthroughClockSkew"])); err == nil {
+		cfg.ClockSkew = skew
+	}
+
+	return cfg
+}
+
+func splitList(s string) []string {
+	var res []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+// PassthroughVerifier verifies the signature of a token with the issuer and
+// then applies the PassthroughConfig to it.
+type PassthroughVerifier struct {
+	verifier *oidc.IDTokenVerifier
+	clientID string
+	config   PassthroughConfig
+	now      func() time.Time
+}
+
+// NewPassthroughVerifier creates a PassthroughVerifier. The audience and
+// expiry are checked using the config, so the verifier should be created with
+// SkipClientIDCheck and SkipExpiryCheck set.
+func NewPassthroughVerifier(verifier *oidc.IDTokenVerifier, clientID string, config PassthroughConfig) *PassthroughVerifier {
+	return &PassthroughVerifier{
+		verifier: verifier,
+		clientID: clientID,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// Verify checks the token is signed by the issuer and matches the config.
+func (v *PassthroughVerifier) Verify(ctx context.Context, rawIDToken string) (*oidc.IDToken, error) {
+	token, err := v.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.validate(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (v *PassthroughVerifier) validate(token *oidc.IDToken) error {
+	audiences := v.config.Audiences
+	if len(audiences) == 0 {
+		audiences = []string{v.clientID}
+	}
+
+	if !containsAny(token.Audience, audiences) {
+		return fmt.Errorf("token audience %v is not one of %v", token.Audience, audiences)
+	}
+
+	claims := map[string]interface{}{}
+	if err := token.Claims(&claims); err != nil {
+		return fmt.Errorf("failed to parse claims from the JWT token: %w", err)
+	}
+
+	now := v.now()
+
+	if now.Add(-v.config.ClockSkew).After(token.Expiry) {
+		return fmt.Errorf("token expired at %v", token.Expiry)
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok {
+		notBefore := time.Unix(int64(nbf), 0)
+		if now.Add(v.config.ClockSkew).Before(notBefore) {
+			return fmt.Errorf("token is not valid before %v", notBefore)
+		}
+	}
+
+	for claim, want := range v.config.RequiredClaims {
+		if !claimHasValue(claims[claim], want) {
+			return fmt.Errorf("token claim %q does not have the required value %q", claim, want)
+		}
+	}
+
+	return nil
+}
+
+func containsAny(have, want []string) bool {
+	for _, s := range want {
+		if contains(have, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func claimHasValue(claim interface{}, want string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == want
+	case bool, float64:
+		return fmt.Sprint(v) == want
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -3,10 +3,12 @@ package auth_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	. "github.com/onsi/gomega"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	"github.com/weaveworks/weave-gitops/pkg/testutils"
 )
@@ -41,3 +43,73 @@ func TestPassthroughPrincipalGetter(t *testing.T) {
 		})
 	}
 }
+
+func TestPassthroughVerifier(t *testing.T) {
+	privKey := testutils.MakeRSAPrivateKey(t)
+	srv := testutils.MakeKeysetServer(t, privKey)
+	keySet := oidc.NewRemoteKeySet(oidc.ClientContext(context.TODO(), srv.Client()), srv.URL)
+	verifier := oidc.NewVerifier("http://127.0.0.1:5556/dex", keySet, &oidc.Config{SkipClientIDCheck: true, SkipExpiryCheck: true})
+
+	expired := func(claims map[string]any) {
+		claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	}
+
+	tests := []struct {
+		name    string
+		config  auth.PassthroughConfig
+		opts    []func(map[string]any)
+		wantErr string
+	}{
+		{
+			name: "defaults to the client ID audience",
+		},
+		{
+			name:    "audience not accepted",
+			config:  auth.PassthroughConfig{Audiences: []string{"kubernetes"}},
+			wantErr: "token audience [test-service] is not one of [kubernetes]",
+		},
+		{
+			name:   "one of the accepted audiences",
+			config: auth.PassthroughConfig{Audiences: []string{"kubernetes", "test-service"}},
+		},
+		{
+			name:   "required claims",
+			config: auth.PassthroughConfig{RequiredClaims: map[string]string{"hd": "example.com", "groups": "testing", "email_verified": "true"}},
+			opts: []func(map[string]any){func(claims map[string]any) {
+				claims["hd"] = "example.com"
+				claims["email_verified"] = true
+			}},
+		},
+		{
+			name:    "missing required claim",
+			config:  auth.PassthroughConfig{RequiredClaims: map[string]string{"hd": "example.com"}},
+			wantErr: `token claim "hd" does not have the required value "example.com"`,
+		},
+		{
+			name:    "expired",
+			opts:    []func(map[string]any){expired},
+			wantErr: "token expired",
+		},
+		{
+			name:   "expired within clock skew",
+			config: auth.PassthroughConfig{ClockSkew: time.Minute},
+			opts:   []func(map[string]any){expired},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			token := testutils.MakeJWToken(t, privKey, "example@example.com", tt.opts...)
+
+			_, err := auth.NewPassthroughVerifier(verifier, "test-service", tt.config).Verify(context.TODO(), token)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithms the cluster user password can be hashed with. The algorithm
+// and its parameters are encoded in the hash, so SignIn works with any of
+// them.
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// The argon2id defaults are the second recommended option of RFC 9106, for
+// environments where 2 GiB of memory per hash is too much.
+const (
+	DefaultArgon2Memory      uint32 = 64 * 1024
+	DefaultArgon2Iterations  uint32 = 3
+	DefaultArgon2Parallelism uint8  = 4
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// ErrPasswordMismatch is returned when a password doesn't match its hash.
+var ErrPasswordMismatch = errors.New("password does not match the hash")
+
+// PasswordHashConfig is how to hash a password.
+type PasswordHashConfig struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2Memory is in KiB
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// DefaultPasswordHashConfig returns the config used by `gitops get
+// bcrypt-hash` when no flags are set.
+func DefaultPasswordHashConfig() PasswordHashConfig {
+	return PasswordHashConfig{
+		Algorithm:         PasswordHashBcrypt,
+		BcryptCost:        bcrypt.DefaultCost,
+		Argon2Memory:      DefaultArgon2Memory,
+		Argon2Iterations:  DefaultArgon2Iterations,
+		Argon2Parallelism: DefaultArgon2Parallelism,
+	}
+}
+
+// HashPassword hashes the password with the configured algorithm.
+//
+// Argon2id hashes use the PHC string format also used by the reference
+// implementation, i.e. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>.
+func HashPassword(password []byte, cfg PasswordHashConfig) ([]byte, error) {
+	switch cfg.Algorithm {
+	case PasswordHashBcrypt, "":
+		return bcrypt.GenerateFromPassword(password, cfg.BcryptCost)
+	case PasswordHashArgon2id:
+		if cfg.Argon2Memory == 0 || cfg.Argon2Iterations == 0 || cfg.Argon2Parallelism == 0 {
+			return nil, errors.New("argon2id memory, iterations and parallelism must be greater than 0")
+		}
+
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+
+		key := argon2.IDKey(password, salt, cfg.Argon2Iterations, cfg.Argon2Memory, cfg.Argon2Parallelism, argon2KeyLength)
+
+		return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))), nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q, valid values are %s and %s", cfg.Algorithm, PasswordHashBcrypt, PasswordHashArgon2id)
+	}
+}
+
+// ComparePasswordHash checks the password against a bcrypt or argon2id
+// hash, detecting the algorithm from the hash. It returns
+// ErrPasswordMismatch if the password is wrong.
+func ComparePasswordHash(hash, password []byte) error {
+	if !strings.HasPrefix(string(hash), "$argon2id$") {
+		err := bcrypt.CompareHashAndPassword(hash, password)
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+
+		return err
+	}
+
+	params, salt, key, err := parseArgon2idHash(string(hash))
+	if err != nil {
+		return err
+	}
+
+	actual := argon2.IDKey(password, salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrPasswordMismatch
+	}
+
+	return nil
+}
+
+func parseArgon2idHash(hash string) (PasswordHashConfig, []byte, []byte, error) {
+	cfg := PasswordHashConfig{Algorithm: PasswordHashArgon2id}
+
+	// "", "argon2id", version, params, salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return cfg, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return cfg, nil, nil, fmt.Errorf("invalid argon2id hash version: %w", err)
+	}
+
+	if version != argon2.Version {
+		return cfg, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &cfg.Argon2Memory, &cfg.Argon2Iterations, &cfg.Argon2Parallelism); err != nil {
+		return cfg, nil, nil, fmt.Errorf("invalid argon2id hash parameters: %w", err)
+	}
+
+	if cfg.Argon2Memory == 0 || cfg.Argon2Iterations == 0 || cfg.Argon2Parallelism == 0 {
+		return cfg, nil, nil, errors.New("invalid argon2id hash parameters: must be greater than 0")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return cfg, nil, nil, fmt.Errorf("invalid argon2id hash salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return cfg, nil, nil, fmt.Errorf("invalid argon2id hash key: %w", err)
+	}
+
+	if len(key) == 0 {
+		return cfg, nil, nil, errors.New("invalid argon2id hash key: empty")
+	}
+
+	return cfg, salt, key, nil
+}
@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    auth.PasswordHashConfig
+		prefix string
+	}{
+		{
+			name:   "bcrypt",
+			cfg:    auth.PasswordHashConfig{Algorithm: auth.PasswordHashBcrypt, BcryptCost: 5},
+			prefix: "$2a$05$",
+		},
+		{
+			name: "argon2id",
+			cfg: auth.PasswordHashConfig{
+				Algorithm:         auth.PasswordHashArgon2id,
+				Argon2Memory:      1024,
+				Argon2Iterations:  2,
+				Argon2Parallelism: 1,
+			},
+			prefix: "$argon2id$v=19$m=1024,t=2,p=1$",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			hash, err := auth.HashPassword([]byte("my-secret-password"), tt.cfg)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(hash)).To(HavePrefix(tt.prefix))
+
+			g.Expect(auth.ComparePasswordHash(hash, []byte("my-secret-password"))).To(Succeed())
+			g.Expect(auth.ComparePasswordHash(hash, []byte("wrong-password"))).To(MatchError(auth.ErrPasswordMismatch))
+		})
+	}
+}
+
+func TestHashPasswordRejectsUnknownAlgorithm(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := auth.HashPassword([]byte("my-secret-password"), auth.PasswordHashConfig{Algorithm: "md5"})
+	g.Expect(err).To(MatchError(ContainSubstring("unknown password hash algorithm")))
+}
+
+func TestComparePasswordHashInvalidArgon2id(t *testing.T) {
+	for _, hash := range []string{
+		"$argon2id$v=19$m=1024,t=2$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=16$m=1024,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=1024,t=0,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=1024,t=2,p=1$c29tZXNhbHQ$",
+		"$argon2id$v=19$m=1024,t=2,p=1$c29tZXNhbHQ",
+	} {
+		g := NewGomegaWithT(t)
+
+		err := auth.ComparePasswordHash([]byte(hash), []byte("password"))
+		g.Expect(err).To(HaveOccurred(), hash)
+		g.Expect(err).NotTo(MatchError(auth.ErrPasswordMismatch), hash)
+	}
+}
+
+func TestSignInWithArgon2idPassword(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	password := "my-secret-password"
+
+	hashed, err := auth.HashPassword([]byte(password), auth.PasswordHashConfig{
+		Algorithm:         auth.PasswordHashArgon2id,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	hashedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-user-auth",
+			Namespace: "flux-system",
+		},
+		Data: map[string][]byte{
+			"password": hashed,
+		},
+	}
+
+	fakeKubernetesClient := ctrlclientfake.NewClientBuilder().WithObjects(hashedSecret).Build()
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, fakeKubernetesClient, tokenSignerVerifier, []auth.AuthMethod{auth.OIDC})
+
+	for pass, status := range map[string]int{password: http.StatusOK, "wrong-password": http.StatusUnauthorized} {
+		j, err := json.Marshal(auth.LoginRequest{Password: pass})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		req := httptest.NewRequest(http.MethodPost, "https://example.com/signin", bytes.NewReader(j))
+		w := httptest.NewRecorder()
+		s.SignIn().ServeHTTP(w, req)
+
+		g.Expect(w.Result().StatusCode).To(Equal(status), pass)
+	}
+}
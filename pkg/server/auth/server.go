@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,8 +12,8 @@ import (
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-logr/logr"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/weaveworks/weave-gitops/pkg/featureflags"
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -46,6 +47,7 @@ type OIDCConfig struct {
 	RedirectURL   string
 	TokenDuration time.Duration
 	ClaimsConfig  *ClaimsConfig
+	Passthrough   PassthroughConfig
 }
 
 // This is only used if the OIDCConfig doesn't have a TokenDuration set. If
@@ -67,6 +69,23 @@ type AuthConfig struct {
 type AuthServer struct {
 	AuthConfig
 	provider *oidc.Provider
+	devices  *deviceAuthStore
+
+	webAuthn         *webauthn.WebAuthn
+	webAuthnSessions *webAuthnSessionStore
+
+	staticTokens []StaticToken
+
+	trustedHeaders PrincipalGetter
+
+	callbackErrorResponse string
+
+	impersonationAdminGroup string
+	impersonationGroups     []string
+
+	knownCluster func(name string) bool
+
+	revocations *revocationList
 }
 
 // LoginRequest represents the data submitted by client when the auth flow (non-OIDC) is used.
@@ -76,10 +95,14 @@ type LoginRequest struct {
 }
 
 // UserInfo represents the response returned from the user info handler.
+//
+// Email is kept for compatibility and has the same value as ID, DisplayName
+// is only set if a display name claim is configured.
 type UserInfo struct {
-	Email  string   `json:"email"`
-	ID     string   `json:"id"`
-	Groups []string `json:"groups"`
+	Email       string   `json:"email"`
+	ID          string   `json:"id"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Groups      []string `json:"groups"`
 }
 
 // NewOIDCConfigFromSecret takes a corev1.Secret and extracts the fields.
@@ -92,8 +115,17 @@ type UserInfo struct {
 //
 // The following keys are optional
 // - tokenDuration - defaults to 1 hour.
-// - claimUsername - defaults to "email"
+// - claimUserID - the claim used to impersonate the user, defaults to "email"
+// - claimUsername - deprecated alias for claimUserID
 // - claimGroups - defaults to "groups"
+// - claimDisplayName - the claim shown as the user's name in the UI, defaults
+// to the user ID
+// - passthroughAudiences - comma separated audiences accepted when passing
+// tokens through, defaults to the clientID
+// - passthroughRequiredClaims - comma separated claim=value pairs that
+// passed through tokens must have
+// - passthroughClockSkew - tolerance when checking passed through tokens
+// have expired, defaults to none
 func NewOIDCConfigFromSecret(secret corev1.Secret) OIDCConfig {
 	cfg := OIDCConfig{
 		IssuerURL:    string(secret.Data["issuerURL"]),
@@ -102,6 +134,7 @@ func NewOIDCConfigFromSecret(secret corev1.Secret) OIDCConfig {
 		RedirectURL:  string(secret.Data["redirectURL"]),
 	}
 	cfg.ClaimsConfig = claimsConfigFromSecret(secret)
+	cfg.Passthrough = passthroughConfigFromSecret(secret)
 
 	tokenDuration, err := time.ParseDuration(string(secret.Data["tokenDuration"]))
 	if err != nil {
@@ -114,7 +147,11 @@ func NewOIDCConfigFromSecret(secret corev1.Secret) OIDCConfig {
 }
 
 func claimsConfigFromSecret(secret corev1.Secret) *ClaimsConfig {
-	claimUsername, ok := secret.Data["claimUsername"]
+	claimUsername, ok := secret.Data["claimUserID"]
+	if !ok {
+		claimUsername, ok = secret.Data["claimUsername"]
+	}
+
 	if !ok {
 		claimUsername = []byte(ClaimUsername)
 	}
@@ -126,8 +163,9 @@ func claimsConfigFromSecret(secret corev1.Secret) *ClaimsConfig {
 
 	if len(claimUsername) > 0 && len(claimGroups) > 0 {
 		return &ClaimsConfig{
-			Username: string(claimUsername),
-			Groups:   string(claimGroups),
+			Username:    string(claimUsername),
+			Groups:      string(claimGroups),
+			DisplayName: string(secret.Data["claimDisplayName"]),
 		}
 	}
 
@@ -188,11 +226,24 @@ func NewAuthServer(ctx context.Context, cfg AuthConfig) (*AuthServer, error) {
 		featureflags.Set(FeatureFlagOIDCAuth, FeatureFlagSet)
 	}
 
-	if featureflags.Get(FeatureFlagOIDCAuth) != FeatureFlagSet && featureflags.Get(FeatureFlagClusterUser) != FeatureFlagSet {
+	// Static tokens can't be used to log in to the UI, but are enough on
+	// their own for API clients. A trusted proxy handles logging in itself.
+	if featureflags.Get(FeatureFlagOIDCAuth) != FeatureFlagSet && featureflags.Get(FeatureFlagClusterUser) != FeatureFlagSet && !cfg.authMethods[TokenFile] && !cfg.authMethods[TrustedHeader] {
 		return nil, fmt.Errorf("neither OIDC auth or local auth enabled, can't start")
 	}
 
-	return &AuthServer{cfg, provider}, nil
+	registerMetrics()
+	activeSessions.setWindow(cfg.OIDCConfig.TokenDuration)
+
+	devices := newDeviceAuthStore()
+	go devices.sweep(ctx, deviceSweepPeriod)
+
+	return &AuthServer{
+		AuthConfig:  cfg,
+		provider:    provider,
+		devices:     devices,
+		revocations: newRevocationList(revocationRetention(cfg.OIDCConfig.TokenDuration)),
+	}, nil
 }
 
 // SetRedirectURL is used to set the redirect URL. This is meant to be used
@@ -213,6 +264,18 @@ func (s *AuthServer) verifier() *oidc.IDTokenVerifier {
 	return s.provider.Verifier(&oidc.Config{ClientID: s.OIDCConfig.ClientID})
 }
 
+// sessionVerifier verifies OIDC tokens used to authenticate API requests,
+// which are rejected once revoked by a back-channel logout.
+func (s *AuthServer) sessionVerifier(verifier tokenVerifier) tokenVerifier {
+	return revocationCheckingVerifier{tokenVerifier: verifier, revocations: s.revocations}
+}
+
+func (s *AuthServer) passthroughVerifier() *PassthroughVerifier {
+	verifier := s.provider.Verifier(&oidc.Config{SkipClientIDCheck: true, SkipExpiryCheck: true})
+
+	return NewPassthroughVerifier(verifier, s.OIDCConfig.ClientID, s.OIDCConfig.Passthrough)
+}
+
 func (s *AuthServer) oauth2Config(scopes []string) *oauth2.Config {
 	// Ensure "openid" scope is always present.
 	if !contains(scopes, oidc.ScopeOpenID) {
@@ -267,67 +330,83 @@ func (s *AuthServer) Callback() http.HandlerFunc {
 
 		// Authorization redirect callback from OAuth2 auth flow.
 		if errorCode := r.FormValue("error"); errorCode != "" {
-			s.Log.Info("authz redirect callback failed", "error", errorCode, "error_description", r.FormValue("error_description"))
-			rw.WriteHeader(http.StatusBadRequest)
+			s.callbackError(rw, r, http.StatusBadRequest, CallbackReasonProviderError,
+				"The identity provider rejected the login request.",
+				fmt.Errorf("authz redirect callback failed: %s: %s", errorCode, r.FormValue("error_description")))
 
 			return
 		}
 
 		code := r.FormValue("code")
 		if code == "" {
-			s.Log.Info("code value was empty")
-			rw.WriteHeader(http.StatusBadRequest)
+			s.callbackError(rw, r, http.StatusBadRequest, CallbackReasonMissingCode,
+				"The identity provider didn't return an authorization code.",
+				errors.New("code value was empty"))
 
 			return
 		}
 
 		cookie, err := r.Cookie(StateCookieName)
 		if err != nil {
-			s.Log.Error(err, "cookie was not found in the request", "cookie", StateCookieName)
-			rw.WriteHeader(http.StatusBadRequest)
+			s.callbackError(rw, r, http.StatusBadRequest, CallbackReasonMissingState,
+				"The login session was not found, it may have expired or cookies may be blocked.",
+				fmt.Errorf("cookie %s was not found in the request: %w", StateCookieName, err))
 
 			return
 		}
 
 		if state := r.FormValue("state"); state != cookie.Value {
-			s.Log.Info("cookie value does not match state form value")
-			rw.WriteHeader(http.StatusBadRequest)
+			s.callbackError(rw, r, http.StatusBadRequest, CallbackReasonStateMismatch,
+				"The login session doesn't match this request, it may have been started in another tab.",
+				errors.New("cookie value does not match state form value"))
 
 			return
 		}
 
 		b, err := base64.StdEncoding.DecodeString(cookie.Value)
 		if err != nil {
-			s.Log.Error(err, "cannot base64 decode cookie", "cookie", StateCookieName, "cookie_value", cookie.Value)
-			rw.WriteHeader(http.StatusBadRequest)
+			s.callbackError(rw, r, http.StatusBadRequest, CallbackReasonInvalidState,
+				"The login session is invalid.",
+				fmt.Errorf("cannot base64 decode cookie %s: %w", StateCookieName, err))
 
 			return
 		}
 
 		if err := json.Unmarshal(b, &state); err != nil {
-			s.Log.Error(err, "failed to unmarshal state to JSON", "state", string(b))
-			rw.WriteHeader(http.StatusBadRequest)
+			s.callbackError(rw, r, http.StatusBadRequest, CallbackReasonInvalidState,
+				"The login session is invalid.",
+				fmt.Errorf("failed to unmarshal state to JSON: %w", err))
 
 			return
 		}
 
 		token, err = s.oauth2Config(nil).Exchange(ctx, code)
 		if err != nil {
-			s.Log.Error(err, "failed to exchange auth code for token", "code", code)
-			rw.WriteHeader(http.StatusInternalServerError)
+			recordLogin(loginMethodOIDC, err)
+			s.callbackError(rw, r, http.StatusInternalServerError, CallbackReasonExchangeFailed,
+				"The authorization code couldn't be exchanged with the identity provider.",
+				fmt.Errorf("failed to exchange auth code for token: %w", err))
 
 			return
 		}
 
 		rawIDToken, ok := token.Extra("id_token").(string)
 		if !ok {
-			JSONError(s.Log, rw, "no id_token in token response", http.StatusInternalServerError)
+			s.callbackError(rw, r, http.StatusInternalServerError, CallbackReasonInvalidToken,
+				"The identity provider didn't return an ID token.",
+				errors.New("no id_token in token response"))
+
 			return
 		}
 
 		_, err = s.verifier().Verify(r.Context(), rawIDToken)
+		recordLogin(loginMethodOIDC, err)
+
 		if err != nil {
-			JSONError(s.Log, rw, fmt.Sprintf("failed to verify ID token: %v", err), http.StatusInternalServerError)
+			s.callbackError(rw, r, http.StatusInternalServerError, CallbackReasonInvalidToken,
+				"The ID token from the identity provider couldn't be verified.",
+				fmt.Errorf("failed to verify ID token: %w", err))
+
 			return
 		}
 
@@ -374,19 +453,25 @@ func (s *AuthServer) SignIn() http.HandlerFunc {
 		}
 
 		if loginRequest.Username != string(hashedSecret.Data["username"]) {
+			opsLoginAttempts.WithLabelValues(loginMethodUserAccount, resultFailure).Inc()
 			s.Log.Info("Wrong username")
 			rw.WriteHeader(http.StatusUnauthorized)
 
 			return
 		}
 
-		if err := bcrypt.CompareHashAndPassword(hashedSecret.Data["password"], []byte(loginRequest.Password)); err != nil {
+		if err := ComparePasswordHash(hashedSecret.Data["password"], []byte(loginRequest.Password)); err != nil {
+			recordLogin(loginMethodUserAccount, err)
 			s.Log.Error(err, "Failed to compare hash with password")
 			rw.WriteHeader(http.StatusUnauthorized)
 
 			return
 		}
 
+		if s.requireSecondFactor(rw, r, loginRequest.Username) {
+			return
+		}
+
 		signed, err := s.tokenSignerVerifier.Sign(loginRequest.Username)
 		if err != nil {
 			s.Log.Error(err, "Failed to create and sign token")
@@ -395,6 +480,8 @@ func (s *AuthServer) SignIn() http.HandlerFunc {
 			return
 		}
 
+		recordLogin(loginMethodUserAccount, nil)
+
 		http.SetCookie(rw, s.createCookie(IDTokenCookieName, signed))
 		rw.WriteHeader(http.StatusOK)
 	}
@@ -412,6 +499,15 @@ func (s *AuthServer) UserInfo(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.trustedHeaders != nil {
+		if p, _ := s.trustedHeaders.Principal(r); p != nil {
+			opsUserInfo.WithLabelValues(resultSuccess).Inc()
+			toJSON(rw, UserInfo{ID: p.ID, Email: p.ID, Groups: p.Groups}, s.Log)
+
+			return
+		}
+	}
+
 	c, err := findAuthCookie(r)
 	if err != nil {
 		s.Log.Error(err, "Failed to get cookie from request")
@@ -422,6 +518,8 @@ func (s *AuthServer) UserInfo(rw http.ResponseWriter, r *http.Request) {
 
 	claims, err := s.tokenSignerVerifier.Verify(c.Value)
 	if err == nil {
+		opsUserInfo.WithLabelValues(resultSuccess).Inc()
+
 		ui := UserInfo{
 			ID:    claims.Subject,
 			Email: claims.Subject,
@@ -442,6 +540,7 @@ func (s *AuthServer) UserInfo(rw http.ResponseWriter, r *http.Request) {
 		AccessToken: c.Value,
 	}))
 	if err != nil {
+		opsUserInfo.WithLabelValues(resultFailure).Inc()
 		s.Log.Error(err, "failed to query userinfo")
 		JSONError(s.Log, rw, fmt.Sprintf("failed to query user info endpoint: %v", err), http.StatusUnauthorized)
 
@@ -449,6 +548,8 @@ func (s *AuthServer) UserInfo(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	userPrincipal, err := s.OIDCConfig.ClaimsConfig.PrincipalFromClaims(info)
+	opsUserInfo.WithLabelValues(result(err)).Inc()
+
 	if err != nil {
 		s.Log.Error(err, "failed to parse user info")
 		JSONError(s.Log, rw, fmt.Sprintf("failed to query user info endpoint: %v", err), http.StatusUnauthorized)
@@ -457,9 +558,10 @@ func (s *AuthServer) UserInfo(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	ui := UserInfo{
-		ID:     userPrincipal.ID,
-		Email:  userPrincipal.ID,
-		Groups: userPrincipal.Groups,
+		ID:          userPrincipal.ID,
+		Email:       userPrincipal.ID,
+		DisplayName: userPrincipal.DisplayName,
+		Groups:      userPrincipal.Groups,
 	}
 
 	toJSON(rw, ui, s.Log)
@@ -534,6 +636,9 @@ func (s *AuthServer) createCookie(name, value string) *http.Cookie {
 		Expires:  time.Now().UTC().Add(s.OIDCConfig.TokenDuration),
 		HttpOnly: true,
 		Secure:   false,
+		// Lax still sends the cookies when the OIDC provider redirects back
+		// to the callback, but not with cross-site posts
+		SameSite: http.SameSiteLaxMode,
 	}
 
 	return cookie
@@ -170,6 +170,74 @@ func TestCallbackCodeExchangeError(t *testing.T) {
 	g.Expect(w.Result().StatusCode).To(Equal(http.StatusInternalServerError))
 }
 
+func TestCallbackErrorResponse(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(g *WithT, resp *http.Response)
+	}{
+		{
+			format: auth.CallbackErrorHTML,
+			check: func(g *WithT, resp *http.Response) {
+				g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				g.Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/html"))
+
+				body, err := io.ReadAll(resp.Body)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(string(body)).To(ContainSubstring(resp.Header.Get(auth.CorrelationIDHeader)))
+				g.Expect(string(body)).To(ContainSubstring(`href="/sign_in?correlation_id=`))
+			},
+		},
+		{
+			format: auth.CallbackErrorJSON,
+			check: func(g *WithT, resp *http.Response) {
+				g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+				var body auth.CallbackError
+				g.Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+				g.Expect(body.Reason).To(Equal(auth.CallbackReasonProviderError))
+				g.Expect(body.CorrelationID).To(Equal(resp.Header.Get(auth.CorrelationIDHeader)))
+				g.Expect(body.RetryURL).To(HavePrefix("/sign_in?"))
+			},
+		},
+		{
+			format: auth.CallbackErrorRedirect,
+			check: func(g *WithT, resp *http.Response) {
+				g.Expect(resp.StatusCode).To(Equal(http.StatusSeeOther))
+
+				location, err := resp.Location()
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(location.Path).To(Equal("/sign_in"))
+				g.Expect(location.Query().Get("error")).To(Equal(auth.CallbackReasonProviderError))
+				g.Expect(location.Query().Get("correlation_id")).To(Equal(resp.Header.Get(auth.CorrelationIDHeader)))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			s, _ := makeAuthServer(t, nil, nil, []auth.AuthMethod{auth.OIDC})
+			g.Expect(s.SetCallbackErrorResponse(tt.format)).To(Succeed())
+
+			req := httptest.NewRequest(http.MethodGet, "https://example.com/callback?error=access_denied", nil)
+			w := httptest.NewRecorder()
+			s.Callback().ServeHTTP(w, req)
+
+			resp := w.Result()
+			g.Expect(resp.Header.Get(auth.CorrelationIDHeader)).NotTo(BeEmpty())
+			tt.check(g, resp)
+		})
+	}
+}
+
+func TestSetCallbackErrorResponseRejectsUnknownFormat(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := makeAuthServer(t, nil, nil, []auth.AuthMethod{auth.OIDC})
+	g.Expect(s.SetCallbackErrorResponse("xml")).NotTo(Succeed())
+}
+
 func TestSignInAllowsPOST(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -917,6 +985,23 @@ func TestNewOIDCConfigFromSecret(t *testing.T) {
 				ClaimsConfig:  &auth.ClaimsConfig{Username: "email", Groups: "groups"},
 			},
 		},
+		{
+			name: "passthrough validation",
+			data: map[string][]byte{
+				"passthroughAudiences":      []byte("test-client-id, kubernetes"),
+				"passthroughRequiredClaims": []byte("hd=example.com,email_verified=true"),
+				"passthroughClockSkew":      []byte("30s"),
+			},
+			want: auth.OIDCConfig{
+				TokenDuration: time.Hour * 1,
+				ClaimsConfig:  &auth.ClaimsConfig{Username: "email", Groups: "groups"},
+				Passthrough: auth.PassthroughConfig{
+					Audiences:      []string{"test-client-id", "kubernetes"},
+					RequiredClaims: map[string]string{"hd": "example.com", "email_verified": "true"},
+					ClockSkew:      30 * time.Second,
+				},
+			},
+		},
 		{
 			name: "overridden claims",
 			data: map[string][]byte{
@@ -930,6 +1015,20 @@ func TestNewOIDCConfigFromSecret(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "user ID and display name claims",
+			data: map[string][]byte{
+				"claimUserID":      []byte("sub"),
+				"claimUsername":    []byte("test-user"),
+				"claimDisplayName": []byte("name"),
+			},
+			want: auth.OIDCConfig{
+				TokenDuration: time.Hour * 1,
+				ClaimsConfig: &auth.ClaimsConfig{
+					Username: "sub", Groups: "groups", DisplayName: "name",
+				},
+			},
+		},
 	}
 
 	for _, tt := range configTests {
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// TokenExchangeGrantType is the grant_type for RFC 8693 token exchange.
+	TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	// TokenTypeJWT identifies both the subject tokens we accept and the
+	// tokens we issue.
+	TokenTypeJWT = "urn:ietf:params:oauth:token-type:jwt"
+	// TokenTypeIDToken is accepted as a subject token type for OIDC sessions.
+	TokenTypeIDToken = "urn:ietf:params:oauth:token-type:id_token"
+
+	// defaultClusterTokenDuration is the lifetime of an exchanged token if
+	// the client doesn't ask for a shorter one.
+	defaultClusterTokenDuration = 5 * time.Minute
+	// maxClusterTokenDuration caps the lifetime of exchanged tokens.
+	maxClusterTokenDuration = 15 * time.Minute
+
+	// reservedAudiencePrefix is used for the audience of tokens we use
+	// internally, which can't be requested through token exchange.
+	reservedAudiencePrefix = "weave-gitops:"
+)
+
+// Error codes returned from the token exchange endpoint, see RFC 8693
+// section 2.2.2 and RFC 6749 section 5.2.
+const (
+	TokenErrInvalidRequest = "invalid_request"
+	TokenErrInvalidGrant   = "invalid_grant"
+	TokenErrInvalidTarget  = "invalid_target"
+)
+
+// TokenExchangeResponse is returned from the token exchange endpoint.
+type TokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+}
+
+// EnableTokenExchange sets how the token exchange endpoint checks that the
+// audience names a cluster, by name or alias. Tokens can't be exchanged
+// until it is set.
+func (s *AuthServer) EnableTokenExchange(knownCluster func(name string) bool) {
+	s.knownCluster = knownCluster
+}
+
+// TokenExchange implements RFC 8693 token exchange. Given a dashboard
+// session token it returns a short-lived token that carries the same user
+// and groups that would be impersonated, scoped to the cluster named in the
+// audience parameter. The server proxies the Kubernetes API of the cluster
+// to clients with the token.
+func (s *AuthServer) TokenExchange() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.Header().Add("Allow", "POST")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if grantType := r.FormValue("grant_type"); grantType != TokenExchangeGrantType {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: DeviceErrUnsupportedGrantType}, http.StatusBadRequest)
+			return
+		}
+
+		subjectToken := r.FormValue("subject_token")
+		if subjectToken == "" {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidRequest, ErrorDescription: "subject_token is required"}, http.StatusBadRequest)
+			return
+		}
+
+		if tokenType := r.FormValue("subject_token_type"); tokenType != TokenTypeJWT && tokenType != TokenTypeIDToken {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidRequest, ErrorDescription: "unsupported subject_token_type"}, http.StatusBadRequest)
+			return
+		}
+
+		if tokenType := r.FormValue("requested_token_type"); tokenType != "" && tokenType != TokenTypeJWT {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidRequest, ErrorDescription: "unsupported requested_token_type"}, http.StatusBadRequest)
+			return
+		}
+
+		cluster := r.FormValue("audience")
+		if cluster == "" || strings.HasPrefix(cluster, reservedAudiencePrefix) {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidTarget, ErrorDescription: "audience must name a cluster"}, http.StatusBadRequest)
+			return
+		}
+
+		if s.knownCluster == nil || !s.knownCluster(cluster) {
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidTarget, ErrorDescription: "unknown cluster"}, http.StatusBadRequest)
+			return
+		}
+
+		duration := defaultClusterTokenDuration
+
+		if v := r.FormValue("expires_in"); v != "" {
+			secs, err := strconv.Atoi(v)
+			if err != nil || secs <= 0 {
+				writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidRequest, ErrorDescription: "invalid expires_in"}, http.StatusBadRequest)
+				return
+			}
+
+			duration = time.Duration(secs) * time.Second
+			if duration > maxClusterTokenDuration {
+				duration = maxClusterTokenDuration
+			}
+		}
+
+		principal, parent, err := s.principalFromToken(r.Context(), subjectToken)
+		if err != nil {
+			s.Log.Error(err, "token exchange rejected subject token")
+			writeJSON(s.Log, rw, OAuthErrorResponse{Error: TokenErrInvalidGrant}, http.StatusBadRequest)
+
+			return
+		}
+
+		signed, err := s.tokenSignerVerifier.SignClusterToken(principal, cluster, duration, parent)
+		if err != nil {
+			s.Log.Error(err, "failed to sign cluster token")
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		writeJSON(s.Log, rw, TokenExchangeResponse{
+			AccessToken:     signed,
+			IssuedTokenType: TokenTypeJWT,
+			TokenType:       "N_A",
+			ExpiresIn:       int(duration.Seconds()),
+		}, http.StatusOK)
+	}
+}
+
+// VerifyClusterToken returns the principal carried by a token issued by the
+// token exchange endpoint if it is valid for the named cluster.
+func (s *AuthServer) VerifyClusterToken(token, cluster string) (*UserPrincipal, error) {
+	if strings.HasPrefix(cluster, reservedAudiencePrefix) {
+		return nil, fmt.Errorf("not a cluster: %q", cluster)
+	}
+
+	claims, err := s.clusterTokenVerifier().VerifyClusterToken(token, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUserPrincipal(ID(claims.Subject), Groups(claims.Groups)), nil
+}
+
+// principalFromToken verifies a dashboard session token issued either by
+// the cluster user login or the OIDC provider and returns its principal.
+// OIDC tokens are verified like WithAPIAuth does, so sessions revoked by a
+// back-channel logout are rejected, and the session is returned as the
+// parent of the tokens derived from it.
+func (s *AuthServer) principalFromToken(ctx context.Context, token string) (*UserPrincipal, *ParentSession, error) {
+	if s.tokenSignerVerifier != nil {
+		if principal, _ := parseJWTAdminToken(s.tokenSignerVerifier, token); principal != nil {
+			return principal, nil, nil
+		}
+	}
+
+	if !s.oidcEnabled() {
+		return nil, nil, errors.New("invalid session token")
+	}
+
+	idToken, err := s.sessionVerifier(s.verifier()).Verify(ctx, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify JWT token: %w", err)
+	}
+
+	principal, err := s.OIDCConfig.ClaimsConfig.PrincipalFromClaims(idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var claims struct {
+		SessionID string `json:"sid"`
+	}
+
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+
+	return principal, &ParentSession{
+		Subject:  idToken.Subject,
+		ID:       claims.SessionID,
+		IssuedAt: jwt.NewNumericDate(idToken.IssuedAt),
+	}, nil
+}
+
+// clusterTokenVerifier verifies the tokens derived from dashboard sessions,
+// which are rejected once their parent session is revoked.
+func (s *AuthServer) clusterTokenVerifier() ClusterTokenVerifier {
+	return revocationCheckingClusterVerifier{ClusterTokenVerifier: s.tokenSignerVerifier, revocations: s.revocations}
+}
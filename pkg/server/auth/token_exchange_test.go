@@ -0,0 +1,155 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+func TestTokenExchange(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+	s.EnableTokenExchange(knownClusters("leaf-1", "leaf-2"))
+
+	session, err := tokenSignerVerifier.Sign("admin")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	w := exchangeToken(s, url.Values{
+		"grant_type":         {auth.TokenExchangeGrantType},
+		"subject_token":      {session},
+		"subject_token_type": {auth.TokenTypeJWT},
+		"audience":           {"leaf-1"},
+		"expires_in":         {"3600"},
+	})
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+
+	var resp auth.TokenExchangeResponse
+	g.Expect(json.NewDecoder(w.Body).Decode(&resp)).To(Succeed())
+	g.Expect(resp.IssuedTokenType).To(Equal(auth.TokenTypeJWT))
+	g.Expect(resp.ExpiresIn).To(Equal(int((15 * time.Minute).Seconds())))
+
+	principal, err := s.VerifyClusterToken(resp.AccessToken, "leaf-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(principal.ID).To(Equal("admin"))
+
+	_, err = s.VerifyClusterToken(resp.AccessToken, "leaf-2")
+	g.Expect(err).To(HaveOccurred())
+
+	// An exchanged token must not be usable as a dashboard session.
+	_, err = tokenSignerVerifier.Verify(resp.AccessToken)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestTokenExchangeErrors(t *testing.T) {
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := makeAuthServer(t, makeClusterUserClient(), tokenSignerVerifier, []auth.AuthMethod{auth.UserAccount})
+	s.EnableTokenExchange(knownClusters("leaf-1", "leaf-2"))
+
+	session, err := tokenSignerVerifier.Sign("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		form    url.Values
+		wantErr string
+	}{
+		{
+			name: "wrong grant type",
+			form: url.Values{
+				"grant_type": {"client_credentials"},
+			},
+			wantErr: auth.DeviceErrUnsupportedGrantType,
+		},
+		{
+			name: "missing audience",
+			form: url.Values{
+				"grant_type":         {auth.TokenExchangeGrantType},
+				"subject_token":      {session},
+				"subject_token_type": {auth.TokenTypeJWT},
+			},
+			wantErr: auth.TokenErrInvalidTarget,
+		},
+		{
+			name: "reserved audience",
+			form: url.Values{
+				"grant_type":         {auth.TokenExchangeGrantType},
+				"subject_token":      {session},
+				"subject_token_type": {auth.TokenTypeJWT},
+				"audience":           {"weave-gitops:webauthn"},
+			},
+			wantErr: auth.TokenErrInvalidTarget,
+		},
+		{
+			name: "unknown cluster",
+			form: url.Values{
+				"grant_type":         {auth.TokenExchangeGrantType},
+				"subject_token":      {session},
+				"subject_token_type": {auth.TokenTypeJWT},
+				"audience":           {"leaf-3"},
+			},
+			wantErr: auth.TokenErrInvalidTarget,
+		},
+		{
+			name: "invalid subject token",
+			form: url.Values{
+				"grant_type":         {auth.TokenExchangeGrantType},
+				"subject_token":      {"not-a-token"},
+				"subject_token_type": {auth.TokenTypeJWT},
+				"audience":           {"leaf-1"},
+			},
+			wantErr: auth.TokenErrInvalidGrant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			w := exchangeToken(s, tt.form)
+			g.Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+			var resp auth.OAuthErrorResponse
+			g.Expect(json.NewDecoder(w.Body).Decode(&resp)).To(Succeed())
+			g.Expect(resp.Error).To(Equal(tt.wantErr))
+		})
+	}
+}
+
+func exchangeToken(s *auth.AuthServer, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/oauth2/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	s.TokenExchange().ServeHTTP(w, req)
+
+	return w
+}
+
+func knownClusters(names ...string) func(string) bool {
+	return func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+)
+
+// StaticToken is a single entry of a token file.
+type StaticToken struct {
+	Token  string   `json:"token"`
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// LoadTokenFile reads the bearer tokens accepted by the token-file auth
+// method.
+//
+// Files ending in .yaml or .yml contain a list of StaticToken, anything
+// else is read as CSV in the same format as kube-apiserver's
+// --token-auth-file:
+//
+//	token,user,uid,"group1,group2"
+//
+// The uid column is ignored as it can't be impersonated.
+func LoadTokenFile(path string) ([]StaticToken, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening token file: %w", err)
+	}
+	defer f.Close()
+
+	var tokens []StaticToken
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		tokens, err = parseYAMLTokens(f)
+	default:
+		tokens, err = parseCSVTokens(f)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parsing token file %s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+
+	for i, t := range tokens {
+		if t.Token == "" || t.User == "" {
+			return nil, fmt.Errorf("parsing token file %s: entry %d: token and user are required", path, i+1)
+		}
+
+		if seen[t.Token] {
+			return nil, fmt.Errorf("parsing token file %s: entry %d: duplicate token", path, i+1)
+		}
+
+		seen[t.Token] = true
+	}
+
+	return tokens, nil
+}
+
+func parseYAMLTokens(r io.Reader) ([]StaticToken, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []StaticToken
+	if err := yaml.UnmarshalStrict(data, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func parseCSVTokens(r io.Reader) ([]StaticToken, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var tokens []StaticToken
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: expected at least token and user columns", line)
+		}
+
+		t := StaticToken{
+			Token: strings.TrimSpace(record[0]),
+			User:  strings.TrimSpace(record[1]),
+		}
+
+		if len(record) > 3 {
+			for _, g := range strings.Split(record[3], ",") {
+				if g = strings.TrimSpace(g); g != "" {
+					t.Groups = append(t.Groups, g)
+				}
+			}
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+// TokenFilePrincipalGetter looks up the Authorization header (bearer token)
+// in a static list of tokens.
+type TokenFilePrincipalGetter struct {
+	log    logr.Logger
+	tokens []StaticToken
+}
+
+// NewTokenFilePrincipalGetter creates a new implementation of the
+// PrincipalGetter interface that accepts the tokens from a token file.
+func NewTokenFilePrincipalGetter(log logr.Logger, tokens []StaticToken) PrincipalGetter {
+	return &TokenFilePrincipalGetter{
+		log:    log,
+		tokens: tokens,
+	}
+}
+
+// Principal is an implementation of the PrincipalGetter interface.
+//
+// Unknown tokens are ignored so that other auth methods can handle them.
+func (pg *TokenFilePrincipalGetter) Principal(r *http.Request) (*UserPrincipal, error) {
+	token := extractToken(r.Header.Get("Authorization"))
+	if token == "" {
+		return nil, nil
+	}
+
+	var found *StaticToken
+
+	// Check every token so the time taken doesn't leak which one matched.
+	for i := range pg.tokens {
+		if subtle.ConstantTimeCompare([]byte(pg.tokens[i].Token), []byte(token)) == 1 {
+			found = &pg.tokens[i]
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	return NewUserPrincipal(ID(found.User), Groups(found.Groups)), nil
+}
+
+// SetStaticTokens configures the tokens accepted by the token-file auth
+// method.
+func (s *AuthServer) SetStaticTokens(tokens []StaticToken) error {
+	if !s.authMethods[TokenFile] {
+		return fmt.Errorf("static tokens were provided but the token-file auth method is not enabled")
+	}
+
+	s.staticTokens = tokens
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+func TestLoadTokenFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     []auth.StaticToken
+		wantErr  string
+	}{
+		{
+			name:     "csv",
+			filename: "tokens.csv",
+			content: `# comment
+token-1,alice,1001,"developers,viewers"
+token-2,bob,1002
+token-3,carol
+`,
+			want: []auth.StaticToken{
+				{Token: "token-1", User: "alice", Groups: []string{"developers", "viewers"}},
+				{Token: "token-2", User: "bob"},
+				{Token: "token-3", User: "carol"},
+			},
+		},
+		{
+			name:     "yaml",
+			filename: "tokens.yaml",
+			content: `- token: token-1
+  user: alice
+  groups: [developers]
+`,
+			want: []auth.StaticToken{
+				{Token: "token-1", User: "alice", Groups: []string{"developers"}},
+			},
+		},
+		{
+			name:     "missing user",
+			filename: "tokens.csv",
+			content:  "token-1\n",
+			wantErr:  "line 1: expected at least token and user columns",
+		},
+		{
+			name:     "empty user",
+			filename: "tokens.yml",
+			content:  "- token: token-1\n",
+			wantErr:  "entry 1: token and user are required",
+		},
+		{
+			name:     "duplicate token",
+			filename: "tokens.csv",
+			content:  "token-1,alice\ntoken-1,bob\n",
+			wantErr:  "entry 2: duplicate token",
+		},
+		{
+			name:     "unknown yaml field",
+			filename: "tokens.yaml",
+			content:  "- token: token-1\n  user: alice\n  uid: 1001\n",
+			wantErr:  "unknown field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			path := filepath.Join(t.TempDir(), tt.filename)
+			g.Expect(os.WriteFile(path, []byte(tt.content), 0600)).To(Succeed())
+
+			tokens, err := auth.LoadTokenFile(path)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(tokens).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestTokenFilePrincipalGetter(t *testing.T) {
+	getter := auth.NewTokenFilePrincipalGetter(logr.Discard(), []auth.StaticToken{
+		{Token: "token-1", User: "alice", Groups: []string{"developers"}},
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   *auth.UserPrincipal
+	}{
+		{"known token", "Bearer token-1", auth.NewUserPrincipal(auth.ID("alice"), auth.Groups([]string{"developers"}))},
+		{"unknown token", "Bearer token-2", nil},
+		{"no header", "", nil},
+		{"not a bearer token", "Basic token-1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			principal, err := getter.Principal(req)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(principal).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestWithAPIAuthTokenFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.TokenFile})
+	g.Expect(srv.SetStaticTokens([]auth.StaticToken{{Token: "token-1", User: "alice"}})).To(Succeed())
+
+	var principal *auth.UserPrincipal
+
+	handler := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal = auth.Principal(r.Context())
+	}), srv, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/v1/objects", nil)
+	req.Header.Set("Authorization", "Bearer token-1")
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusOK))
+	g.Expect(principal.ID).To(Equal("alice"))
+
+	req.Header.Set("Authorization", "Bearer token-2")
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusUnauthorized))
+}
+
+func TestSetStaticTokensRequiresAuthMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+	g.Expect(srv.SetStaticTokens([]auth.StaticToken{{Token: "token-1", User: "alice"}})).NotTo(Succeed())
+}
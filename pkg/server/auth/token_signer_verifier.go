@@ -21,9 +21,36 @@ type TokenVerifier interface {
 	Verify(token string) (*AdminClaims, error)
 }
 
+// ClusterClaims are the claims of a token minted by the token exchange
+// endpoint. The audience is the name of the cluster the token is scoped to.
+type ClusterClaims struct {
+	jwt.RegisteredClaims
+	Groups []string `json:"groups,omitempty"`
+	// Parent is the OIDC session the token was derived from, if any.
+	Parent *ParentSession `json:"parent,omitempty"`
+}
+
+// ParentSession identifies the OIDC session a token was derived from, so the
+// token is revoked along with it by a back-channel logout.
+type ParentSession struct {
+	Subject  string           `json:"sub,omitempty"`
+	ID       string           `json:"sid,omitempty"`
+	IssuedAt *jwt.NumericDate `json:"iat,omitempty"`
+}
+
+type ClusterTokenSigner interface {
+	SignClusterToken(principal *UserPrincipal, cluster string, expireAfter time.Duration, parent *ParentSession) (string, error)
+}
+
+type ClusterTokenVerifier interface {
+	VerifyClusterToken(token, cluster string) (*ClusterClaims, error)
+}
+
 type TokenSignerVerifier interface {
 	TokenSigner
 	TokenVerifier
+	ClusterTokenSigner
+	ClusterTokenVerifier
 }
 
 type HMACTokenSignerVerifier struct {
@@ -86,13 +113,64 @@ func (sv *HMACTokenSignerVerifier) Verify(tokenString string) (*AdminClaims, err
 		return nil, fmt.Errorf("failed to verify token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*AdminClaims); ok && token.Valid {
+	// Tokens with an audience were minted for a single cluster by the token
+	// exchange and must not be usable as a dashboard session.
+	if claims, ok := token.Claims.(*AdminClaims); ok && token.Valid && len(claims.Audience) == 0 {
 		return claims, nil
 	} else {
 		return nil, errors.New("invalid token")
 	}
 }
 
+// SignClusterToken creates a token carrying the identity and groups of the
+// principal that is only valid for the named cluster. The parent is the
+// session the principal was taken from, if it can be revoked.
+func (sv *HMACTokenSignerVerifier) SignClusterToken(principal *UserPrincipal, cluster string, expireAfter time.Duration, parent *ParentSession) (string, error) {
+	now := time.Now().UTC()
+	claims := ClusterClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expireAfter)),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   principal.ID,
+			Audience:  jwt.ClaimStrings{cluster},
+		},
+		Groups: principal.Groups,
+		Parent: parent,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString(sv.hmacSecret)
+}
+
+// VerifyClusterToken verifies a token created by SignClusterToken and
+// checks that it was issued for the named cluster.
+func (sv *HMACTokenSignerVerifier) VerifyClusterToken(tokenString, cluster string) (*ClusterClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ClusterClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+
+			return sv.hmacSecret, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ClusterClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if !claims.VerifyAudience(cluster, true) {
+		return nil, fmt.Errorf("token is not valid for cluster %q", cluster)
+	}
+
+	return claims, nil
+}
+
 func (sv *HMACTokenSignerVerifier) SetDevMode(enabled bool) {
 	sv.devMode = enabled
 }
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/core/logger"
+)
+
+// Defaults for the headers set by identity-aware proxies such as
+// oauth2-proxy and Pomerium.
+const (
+	DefaultTrustedUserHeader   = "X-Forwarded-User"
+	DefaultTrustedGroupsHeader = "X-Forwarded-Groups"
+	DefaultTrustedSecretHeader = "X-Forwarded-Auth-Secret"
+)
+
+// TrustedHeaderConfig configures the trusted-header auth method, where an
+// identity-aware proxy in front of the dashboard authenticates the user and
+// passes on who they are in request headers.
+//
+// As anyone could set the headers, they are only trusted if the request
+// carries the shared secret or comes from one of the trusted networks. If
+// both are configured, both must match.
+type TrustedHeaderConfig struct {
+	UserHeader string
+	// GroupsHeader holds a comma-separated list of groups
+	GroupsHeader string
+	SecretHeader string
+	Secret       string
+	// TrustedCIDRs are the networks the proxy connects from
+	TrustedCIDRs []string
+}
+
+// TrustedHeaderPrincipalGetter reads the principal from headers set by a
+// trusted proxy.
+type TrustedHeaderPrincipalGetter struct {
+	log      logr.Logger
+	cfg      TrustedHeaderConfig
+	networks []*net.IPNet
+}
+
+// NewTrustedHeaderPrincipalGetter creates a new implementation of the
+// PrincipalGetter interface that trusts the headers of an identity-aware
+// proxy.
+func NewTrustedHeaderPrincipalGetter(log logr.Logger, cfg TrustedHeaderConfig) (PrincipalGetter, error) {
+	if cfg.Secret == "" && len(cfg.TrustedCIDRs) == 0 {
+		return nil, fmt.Errorf("the trusted-header auth method requires a shared secret or trusted CIDRs")
+	}
+
+	if cfg.UserHeader == "" {
+		cfg.UserHeader = DefaultTrustedUserHeader
+	}
+
+	if cfg.GroupsHeader == "" {
+		cfg.GroupsHeader = DefaultTrustedGroupsHeader
+	}
+
+	if cfg.SecretHeader == "" {
+		cfg.SecretHeader = DefaultTrustedSecretHeader
+	}
+
+	networks := []*net.IPNet{}
+
+	for _, cidr := range cfg.TrustedCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted CIDR %q: %w", cidr, err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return &TrustedHeaderPrincipalGetter{
+		log:      log,
+		cfg:      cfg,
+		networks: networks,
+	}, nil
+}
+
+// Principal is an implementation of the PrincipalGetter interface.
+//
+// Requests without the user header, or that don't come from the proxy, are
+// ignored so that other auth methods can handle them.
+func (pg *TrustedHeaderPrincipalGetter) Principal(r *http.Request) (*UserPrincipal, error) {
+	user := r.Header.Get(pg.cfg.UserHeader)
+	if user == "" {
+		return nil, nil
+	}
+
+	if !pg.trusted(r) {
+		pg.log.V(logger.LogLevelWarn).Info("Ignoring identity headers from an untrusted source", "remoteAddr", r.RemoteAddr, "user", user)
+		return nil, nil
+	}
+
+	groups := []string{}
+
+	for _, v := range r.Header.Values(pg.cfg.GroupsHeader) {
+		for _, group := range strings.Split(v, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return NewUserPrincipal(ID(user), Groups(groups)), nil
+}
+
+func (pg *TrustedHeaderPrincipalGetter) trusted(r *http.Request) bool {
+	if pg.cfg.Secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(pg.cfg.SecretHeader)), []byte(pg.cfg.Secret)) != 1 {
+		return false
+	}
+
+	if len(pg.networks) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range pg.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnableTrustedHeaders configures the trusted-header auth method.
+func (s *AuthServer) EnableTrustedHeaders(cfg TrustedHeaderConfig) error {
+	if !s.authMethods[TrustedHeader] {
+		return fmt.Errorf("trusted header settings were provided but the trusted-header auth method is not enabled")
+	}
+
+	getter, err := NewTrustedHeaderPrincipalGetter(s.Log, cfg)
+	if err != nil {
+		return err
+	}
+
+	s.trustedHeaders = getter
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+)
+
+func TestTrustedHeaderPrincipalGetter(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        auth.TrustedHeaderConfig
+		remoteAddr string
+		headers    map[string]string
+		want       *auth.UserPrincipal
+	}{
+		{
+			name:    "shared secret",
+			cfg:     auth.TrustedHeaderConfig{Secret: "s3cret"},
+			headers: map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-Groups": "developers, viewers,", "X-Forwarded-Auth-Secret": "s3cret"},
+			want:    auth.NewUserPrincipal(auth.ID("alice"), auth.Groups([]string{"developers", "viewers"})),
+		},
+		{
+			name:    "wrong secret",
+			cfg:     auth.TrustedHeaderConfig{Secret: "s3cret"},
+			headers: map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-Auth-Secret": "guess"},
+		},
+		{
+			name:    "no user",
+			cfg:     auth.TrustedHeaderConfig{Secret: "s3cret"},
+			headers: map[string]string{"X-Forwarded-Auth-Secret": "s3cret"},
+		},
+		{
+			name:       "trusted network",
+			cfg:        auth.TrustedHeaderConfig{TrustedCIDRs: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.1.2.3:4567",
+			headers:    map[string]string{"X-Forwarded-User": "alice"},
+			want:       auth.NewUserPrincipal(auth.ID("alice"), auth.Groups([]string{})),
+		},
+		{
+			name:       "untrusted network",
+			cfg:        auth.TrustedHeaderConfig{TrustedCIDRs: []string{"10.0.0.0/8"}},
+			remoteAddr: "192.168.1.2:4567",
+			headers:    map[string]string{"X-Forwarded-User": "alice"},
+		},
+		{
+			name:       "secret and network both required",
+			cfg:        auth.TrustedHeaderConfig{Secret: "s3cret", TrustedCIDRs: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.1.2.3:4567",
+			headers:    map[string]string{"X-Forwarded-User": "alice"},
+		},
+		{
+			name:    "custom headers",
+			cfg:     auth.TrustedHeaderConfig{UserHeader: "X-Pomerium-Claim-Email", GroupsHeader: "X-Pomerium-Claim-Groups", SecretHeader: "X-Proxy-Secret", Secret: "s3cret"},
+			headers: map[string]string{"X-Pomerium-Claim-Email": "alice@example.com", "X-Pomerium-Claim-Groups": "developers", "X-Proxy-Secret": "s3cret"},
+			want:    auth.NewUserPrincipal(auth.ID("alice@example.com"), auth.Groups([]string{"developers"})),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			getter, err := auth.NewTrustedHeaderPrincipalGetter(logr.Discard(), tt.cfg)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			principal, err := getter.Principal(req)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(principal).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestNewTrustedHeaderPrincipalGetterRequiresGuard(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := auth.NewTrustedHeaderPrincipalGetter(logr.Discard(), auth.TrustedHeaderConfig{})
+	g.Expect(err).To(MatchError(ContainSubstring("requires a shared secret or trusted CIDRs")))
+
+	_, err = auth.NewTrustedHeaderPrincipalGetter(logr.Discard(), auth.TrustedHeaderConfig{TrustedCIDRs: []string{"10.0.0.0"}})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid trusted CIDR")))
+}
+
+func TestWithAPIAuthTrustedHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.TrustedHeader})
+	g.Expect(srv.EnableTrustedHeaders(auth.TrustedHeaderConfig{Secret: "s3cret"})).To(Succeed())
+
+	var principal *auth.UserPrincipal
+
+	handler := auth.WithAPIAuth(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal = auth.Principal(r.Context())
+	}), srv, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/v1/objects", nil)
+	req.Header.Set("X-Forwarded-User", "alice")
+	req.Header.Set("X-Forwarded-Groups", "developers")
+	req.Header.Set("X-Forwarded-Auth-Secret", "s3cret")
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusOK))
+	g.Expect(principal.ID).To(Equal("alice"))
+	g.Expect(principal.Groups).To(Equal([]string{"developers"}))
+
+	res = httptest.NewRecorder()
+	srv.UserInfo(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusOK))
+
+	var info auth.UserInfo
+	g.Expect(json.NewDecoder(res.Body).Decode(&info)).To(Succeed())
+	g.Expect(info).To(Equal(auth.UserInfo{ID: "alice", Email: "alice", Groups: []string{"developers"}}))
+
+	req.Header.Del("X-Forwarded-Auth-Secret")
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusUnauthorized))
+}
+
+func TestEnableTrustedHeadersRequiresAuthMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	srv, _ := makeAuthServer(t, nil, tsv, []auth.AuthMethod{auth.OIDC})
+	g.Expect(srv.EnableTrustedHeaders(auth.TrustedHeaderConfig{Secret: "s3cret"})).NotTo(Succeed())
+}
@@ -0,0 +1,475 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// WebAuthnCredentialsSecretName is the secret holding the public keys of
+	// the authenticators registered for the cluster user.
+	WebAuthnCredentialsSecretName = "cluster-user-webauthn"
+	// WebAuthnPendingCookieName holds the proof that the cluster user has
+	// entered the right password while the second factor is outstanding.
+	WebAuthnPendingCookieName = "webauthn_pending"
+
+	webAuthnCredentialsKey = "credentials"
+	// webAuthnAudience is the audience of pending login tokens, it can't be
+	// requested through the token exchange endpoint.
+	webAuthnAudience        = reservedAudiencePrefix + "webauthn"
+	webAuthnPendingDuration = 5 * time.Minute
+)
+
+// WebAuthnConfig configures the relying party for WebAuthn second factor
+// authentication of the cluster user.
+type WebAuthnConfig struct {
+	// RPID is the domain the dashboard is served from, e.g. gitops.example.com
+	RPID string
+	// RPOrigin is the origin the browser reports, e.g.
+	// https://gitops.example.com. Defaults to RPID.
+	RPOrigin string
+}
+
+// SignInResponse is returned from SignIn when the password was correct but a
+// second factor is still required.
+type SignInResponse struct {
+	WebAuthnRequired bool `json:"webAuthnRequired"`
+}
+
+// WebAuthnRegisterRequest starts registering an authenticator. The password
+// is asked for again so a stolen session can't register a key of its own.
+type WebAuthnRegisterRequest struct {
+	Password string `json:"password"`
+}
+
+// EnableWebAuthn turns on WebAuthn as a second factor for the cluster user.
+// Once the cluster user has registered an authenticator, SignIn no longer
+// issues a session on its own.
+func (s *AuthServer) EnableWebAuthn(cfg WebAuthnConfig) error {
+	w, err := webauthn.New(&webauthn.Config{
+		RPDisplayName: "Weave GitOps",
+		RPID:          cfg.RPID,
+		RPOrigin:      cfg.RPOrigin,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid WebAuthn configuration: %w", err)
+	}
+
+	s.webAuthn = w
+	s.webAuthnSessions = newWebAuthnSessionStore()
+
+	return nil
+}
+
+func (s *AuthServer) webAuthnEnabled() bool {
+	return s.webAuthn != nil
+}
+
+// clusterUser is the cluster user as a WebAuthn user.
+type clusterUser struct {
+	name        string
+	credentials []webauthn.Credential
+}
+
+func (u *clusterUser) WebAuthnID() []byte                         { return []byte(u.name) }
+func (u *clusterUser) WebAuthnName() string                       { return u.name }
+func (u *clusterUser) WebAuthnDisplayName() string                { return u.name }
+func (u *clusterUser) WebAuthnIcon() string                       { return "" }
+func (u *clusterUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+func (s *AuthServer) loadClusterUser(ctx context.Context, name string) (*clusterUser, error) {
+	user := &clusterUser{name: name}
+
+	var secret corev1.Secret
+	if err := s.kubernetesClient.Get(ctx, ctrlclient.ObjectKey{
+		Namespace: s.namespace,
+		Name:      WebAuthnCredentialsSecretName,
+	}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return user, nil
+		}
+
+		return nil, err
+	}
+
+	if data := secret.Data[webAuthnCredentialsKey]; len(data) > 0 {
+		if err := json.Unmarshal(data, &user.credentials); err != nil {
+			return nil, fmt.Errorf("failed to decode WebAuthn credentials: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *AuthServer) saveClusterUser(ctx context.Context, user *clusterUser) error {
+	data, err := json.Marshal(user.credentials)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.namespace,
+			Name:      WebAuthnCredentialsSecretName,
+		},
+	}
+
+	err = s.kubernetesClient.Get(ctx, ctrlclient.ObjectKeyFromObject(secret), secret)
+
+	switch {
+	case apierrors.IsNotFound(err):
+		secret.Data = map[string][]byte{webAuthnCredentialsKey: data}
+		return s.kubernetesClient.Create(ctx, secret)
+	case err != nil:
+		return err
+	}
+
+	patch := ctrlclient.MergeFrom(secret.DeepCopy())
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	secret.Data[webAuthnCredentialsKey] = data
+
+	return s.kubernetesClient.Patch(ctx, secret, patch)
+}
+
+// checkClusterUserPassword checks the password of the cluster user.
+func (s *AuthServer) checkClusterUserPassword(ctx context.Context, username, password string) error {
+	var secret corev1.Secret
+	if err := s.kubernetesClient.Get(ctx, ctrlclient.ObjectKey{
+		Namespace: s.namespace,
+		Name:      ClusterUserAuthSecretName,
+	}, &secret); err != nil {
+		return err
+	}
+
+	if username != string(secret.Data["username"]) {
+		return errors.New("wrong username")
+	}
+
+	return ComparePasswordHash(secret.Data["password"], []byte(password))
+}
+
+// requireSecondFactor is called by SignIn once the password has been
+// checked. It returns true if it has handled the response.
+func (s *AuthServer) requireSecondFactor(rw http.ResponseWriter, r *http.Request, username string) bool {
+	if !s.webAuthnEnabled() {
+		return false
+	}
+
+	user, err := s.loadClusterUser(r.Context(), username)
+	if err != nil {
+		s.Log.Error(err, "Failed to load WebAuthn credentials")
+		rw.WriteHeader(http.StatusInternalServerError)
+
+		return true
+	}
+
+	if len(user.credentials) == 0 {
+		return false
+	}
+
+	pending, err := s.tokenSignerVerifier.SignClusterToken(NewUserPrincipal(ID(username)), webAuthnAudience, webAuthnPendingDuration, nil)
+	if err != nil {
+		s.Log.Error(err, "Failed to sign pending login token")
+		rw.WriteHeader(http.StatusInternalServerError)
+
+		return true
+	}
+
+	cookie := s.createCookie(WebAuthnPendingCookieName, pending)
+	cookie.Expires = time.Now().UTC().Add(webAuthnPendingDuration)
+	http.SetCookie(rw, cookie)
+
+	writeJSON(s.Log, rw, SignInResponse{WebAuthnRequired: true}, http.StatusOK)
+
+	return true
+}
+
+// WebAuthnRegisterBegin starts registering an authenticator for the signed
+// in cluster user, once they've confirmed their password.
+func (s *AuthServer) WebAuthnRegisterBegin() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, ok := s.webAuthnUser(rw, r, s.adminSessionUser)
+		if !ok {
+			return
+		}
+
+		var req WebAuthnRegisterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JSONError(s.Log, rw, "Failed to read request body.", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.checkClusterUserPassword(r.Context(), user.name, req.Password); err != nil {
+			s.Log.Info("WebAuthn registration not confirmed", "error", err.Error())
+			rw.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		exclusions := []protocol.CredentialDescriptor{}
+		for _, c := range user.credentials {
+			exclusions = append(exclusions, c.Descriptor())
+		}
+
+		options, session, err := s.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+		if err != nil {
+			s.Log.Error(err, "Failed to begin WebAuthn registration")
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		s.webAuthnSessions.put("register/"+user.name, session)
+
+		writeJSON(s.Log, rw, options, http.StatusOK)
+	}
+}
+
+// WebAuthnRegisterFinish verifies the attestation from the browser and
+// stores the new credential.
+func (s *AuthServer) WebAuthnRegisterFinish() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, ok := s.webAuthnUser(rw, r, s.adminSessionUser)
+		if !ok {
+			return
+		}
+
+		session, ok := s.webAuthnSessions.take("register/" + user.name)
+		if !ok {
+			JSONError(s.Log, rw, "no registration in progress", http.StatusBadRequest)
+			return
+		}
+
+		credential, err := s.webAuthn.FinishRegistration(user, *session, r)
+		if err != nil {
+			s.Log.Error(err, "WebAuthn registration failed")
+			JSONError(s.Log, rw, "registration failed", http.StatusBadRequest)
+
+			return
+		}
+
+		user.credentials = append(user.credentials, *credential)
+
+		if err := s.saveClusterUser(r.Context(), user); err != nil {
+			s.Log.Error(err, "Failed to store WebAuthn credential")
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+// WebAuthnLoginBegin returns the assertion challenge for a cluster user that
+// has already entered the right password.
+func (s *AuthServer) WebAuthnLoginBegin() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, ok := s.webAuthnUser(rw, r, s.pendingLoginUser)
+		if !ok {
+			return
+		}
+
+		if len(user.credentials) == 0 {
+			JSONError(s.Log, rw, "no authenticator registered", http.StatusBadRequest)
+			return
+		}
+
+		options, session, err := s.webAuthn.BeginLogin(user)
+		if err != nil {
+			s.Log.Error(err, "Failed to begin WebAuthn login")
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		s.webAuthnSessions.put("login/"+user.name, session)
+
+		writeJSON(s.Log, rw, options, http.StatusOK)
+	}
+}
+
+// WebAuthnLoginFinish verifies the assertion from the browser and issues
+// the session cookie.
+func (s *AuthServer) WebAuthnLoginFinish() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, ok := s.webAuthnUser(rw, r, s.pendingLoginUser)
+		if !ok {
+			return
+		}
+
+		session, ok := s.webAuthnSessions.take("login/" + user.name)
+		if !ok {
+			JSONError(s.Log, rw, "no login in progress", http.StatusBadRequest)
+			return
+		}
+
+		credential, err := s.webAuthn.FinishLogin(user, *session, r)
+		recordLogin(loginMethodWebAuthn, err)
+
+		if err != nil {
+			s.Log.Error(err, "WebAuthn login failed")
+			rw.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		// Persist the signature counter so cloned authenticators can be
+		// detected on the next login.
+		for i := range user.credentials {
+			if string(user.credentials[i].ID) == string(credential.ID) {
+				user.credentials[i].Authenticator = credential.Authenticator
+			}
+		}
+
+		if err := s.saveClusterUser(r.Context(), user); err != nil {
+			s.Log.Error(err, "Failed to update WebAuthn credential")
+		}
+
+		signed, err := s.tokenSignerVerifier.Sign(user.name)
+		if err != nil {
+			s.Log.Error(err, "Failed to create and sign token")
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		http.SetCookie(rw, s.clearCookie(WebAuthnPendingCookieName))
+		http.SetCookie(rw, s.createCookie(IDTokenCookieName, signed))
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+// webAuthnUser checks the request method and identifies the user with the
+// given function, writing the error response if either fails.
+func (s *AuthServer) webAuthnUser(rw http.ResponseWriter, r *http.Request, identify func(*http.Request) (string, error)) (*clusterUser, bool) {
+	if r.Method != http.MethodPost {
+		rw.Header().Add("Allow", "POST")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+
+		return nil, false
+	}
+
+	if !s.webAuthnEnabled() {
+		JSONError(s.Log, rw, "WebAuthn is not enabled", http.StatusNotFound)
+		return nil, false
+	}
+
+	name, err := identify(r)
+	if err != nil {
+		s.Log.Info("WebAuthn request not authenticated", "error", err.Error())
+		rw.WriteHeader(http.StatusUnauthorized)
+
+		return nil, false
+	}
+
+	user, err := s.loadClusterUser(r.Context(), name)
+	if err != nil {
+		s.Log.Error(err, "Failed to load WebAuthn credentials")
+		rw.WriteHeader(http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	return user, true
+}
+
+// adminSessionUser returns the cluster user signed in with the request.
+func (s *AuthServer) adminSessionUser(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(IDTokenCookieName)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := s.tokenSignerVerifier.Verify(cookie.Value)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.Subject, nil
+}
+
+// pendingLoginUser returns the cluster user that has entered the right
+// password but not yet completed the second factor.
+func (s *AuthServer) pendingLoginUser(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(WebAuthnPendingCookieName)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := s.tokenSignerVerifier.VerifyClusterToken(cookie.Value, webAuthnAudience)
+	if err != nil {
+		return "", err
+	}
+
+	if claims.Subject == "" {
+		return "", errors.New("pending login token has no subject")
+	}
+
+	return claims.Subject, nil
+}
+
+// webAuthnSessionStore keeps the state of WebAuthn ceremonies in memory
+// between the begin and finish requests.
+type webAuthnSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]webAuthnSession
+	now      func() time.Time
+}
+
+type webAuthnSession struct {
+	data      *webauthn.SessionData
+	expiresAt time.Time
+}
+
+func newWebAuthnSessionStore() *webAuthnSessionStore {
+	return &webAuthnSessionStore{
+		sessions: map[string]webAuthnSession{},
+		now:      time.Now,
+	}
+}
+
+func (w *webAuthnSessionStore) put(key string, data *webauthn.SessionData) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+
+	for k, v := range w.sessions {
+		if now.After(v.expiresAt) {
+			delete(w.sessions, k)
+		}
+	}
+
+	w.sessions[key] = webAuthnSession{data: data, expiresAt: now.Add(webAuthnPendingDuration)}
+}
+
+func (w *webAuthnSessionStore) take(key string) (*webauthn.SessionData, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	session, ok := w.sessions[key]
+	delete(w.sessions, key)
+
+	if !ok || w.now().After(session.expiresAt) {
+		return nil, false
+	}
+
+	return session.data, true
+}
@@ -0,0 +1,217 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSignInRequiresWebAuthn(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := makeWebAuthnServer(t, []webauthn.Credential{{ID: []byte("credential-1"), PublicKey: []byte("key")}})
+
+	resp := signIn(g, s, "admin", "my-secret-password")
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	var body auth.SignInResponse
+	g.Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+	g.Expect(body.WebAuthnRequired).To(BeTrue())
+
+	g.Expect(findCookie(resp, auth.IDTokenCookieName)).To(BeNil())
+	pending := findCookie(resp, auth.WebAuthnPendingCookieName)
+	g.Expect(pending).ToNot(BeNil())
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/oauth2/webauthn/login/begin", nil)
+	req.AddCookie(pending)
+
+	w := httptest.NewRecorder()
+	s.WebAuthnLoginBegin().ServeHTTP(w, req)
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+
+	var assertion protocol.CredentialAssertion
+	g.Expect(json.NewDecoder(w.Body).Decode(&assertion)).To(Succeed())
+	g.Expect(assertion.Response.AllowedCredentials).To(HaveLen(1))
+	g.Expect([]byte(assertion.Response.AllowedCredentials[0].CredentialID)).To(Equal([]byte("credential-1")))
+}
+
+func TestSignInWithoutRegisteredAuthenticator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := makeWebAuthnServer(t, nil)
+
+	resp := signIn(g, s, "admin", "my-secret-password")
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(findCookie(resp, auth.IDTokenCookieName)).ToNot(BeNil())
+	g.Expect(findCookie(resp, auth.WebAuthnPendingCookieName)).To(BeNil())
+}
+
+func TestWebAuthnRequiresAuthentication(t *testing.T) {
+	s, tsv := makeWebAuthnServer(t, nil)
+
+	// A dashboard session must not be accepted in place of a pending login.
+	session, err := tsv.Sign("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		cookie  *http.Cookie
+	}{
+		{
+			name:    "register without session",
+			handler: s.WebAuthnRegisterBegin(),
+		},
+		{
+			name:    "login without pending cookie",
+			handler: s.WebAuthnLoginBegin(),
+		},
+		{
+			name:    "login with session token",
+			handler: s.WebAuthnLoginBegin(),
+			cookie:  &http.Cookie{Name: auth.WebAuthnPendingCookieName, Value: session},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			req := httptest.NewRequest(http.MethodPost, "https://example.com/oauth2/webauthn", nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, req)
+			g.Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	}
+}
+
+func TestWebAuthnRegisterBegin(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, tsv := makeWebAuthnServer(t, nil)
+
+	session, err := tsv.Sign("admin")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	w := registerBegin(g, s, session, "my-secret-password")
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+
+	var creation protocol.CredentialCreation
+	g.Expect(json.NewDecoder(w.Body).Decode(&creation)).To(Succeed())
+	g.Expect(creation.Response.RelyingParty.ID).To(Equal("example.com"))
+	g.Expect(creation.Response.User.Name).To(Equal("admin"))
+}
+
+func TestWebAuthnRegisterBeginRequiresPassword(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, tsv := makeWebAuthnServer(t, nil)
+
+	session, err := tsv.Sign("admin")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(registerBegin(g, s, session, "").Code).To(Equal(http.StatusUnauthorized))
+	g.Expect(registerBegin(g, s, session, "wrong-password").Code).To(Equal(http.StatusUnauthorized))
+}
+
+func registerBegin(g *WithT, s *auth.AuthServer, session, password string) *httptest.ResponseRecorder {
+	j, err := json.Marshal(auth.WebAuthnRegisterRequest{Password: password})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/oauth2/webauthn/register/begin", bytes.NewReader(j))
+	req.AddCookie(&http.Cookie{Name: auth.IDTokenCookieName, Value: session})
+
+	w := httptest.NewRecorder()
+	s.WebAuthnRegisterBegin().ServeHTTP(w, req)
+
+	return w
+}
+
+func makeWebAuthnServer(t *testing.T, credentials []webauthn.Credential) (*auth.AuthServer, auth.TokenSignerVerifier) {
+	t.Helper()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("my-secret-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := ctrlclientfake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      auth.ClusterUserAuthSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"username": []byte("admin"),
+			"password": hashed,
+		},
+	})
+
+	if credentials != nil {
+		data, err := json.Marshal(credentials)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		builder = builder.WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      auth.WebAuthnCredentialsSecretName,
+				Namespace: testNamespace,
+			},
+			Data: map[string][]byte{
+				"credentials": data,
+			},
+		})
+	}
+
+	tsv, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := makeAuthServer(t, builder.Build(), tsv, []auth.AuthMethod{auth.UserAccount})
+
+	if err := s.EnableWebAuthn(auth.WebAuthnConfig{RPID: "example.com", RPOrigin: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return s, tsv
+}
+
+func signIn(g *WithT, s *auth.AuthServer, username, password string) *http.Response {
+	j, err := json.Marshal(auth.LoginRequest{Username: username, Password: password})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/signin", bytes.NewReader(j))
+	w := httptest.NewRecorder()
+	s.SignIn().ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func findCookie(resp *http.Response, name string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
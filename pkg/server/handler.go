@@ -8,8 +8,10 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	core "github.com/weaveworks/weave-gitops/core/server"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
 	"github.com/weaveworks/weave-gitops/pkg/server/auth"
 	"github.com/weaveworks/weave-gitops/pkg/server/middleware"
+	"github.com/weaveworks/weave-gitops/pkg/server/policy"
 )
 
 var (
@@ -23,6 +25,25 @@ type Config struct {
 	AppOptions       []ApplicationsOption
 	CoreServerConfig core.CoreServerConfig
 	AuthServer       *auth.AuthServer
+	// Policy restricts which API methods users may call, nil to only rely
+	// on Kubernetes RBAC
+	Policy *policy.Enforcer
+}
+
+// NewPolicyEnforcer creates an authorization policy enforcer that knows the
+// methods of the core API. The rules of ToggleSuspendResource apply to
+// SuspendResource and ResumeResource too, the ones of SyncFluxObject to
+// SyncResource, and the other way around.
+func NewPolicyEnforcer(log logr.Logger) *policy.Enforcer {
+	routes := policy.RoutesFromService(pb.File_api_core_core_proto.Services().ByName("Core"))
+	routes.Add(http.MethodGet, core.ArtifactPath, "DownloadArtifact")
+	// the archive has the same logs as GetSessionLogs, so the same rules apply
+	routes.Add(http.MethodGet, core.SessionLogsArchivePath, "GetSessionLogs")
+	routes.Alias("SuspendResource", "ToggleSuspendResource")
+	routes.Alias("ResumeResource", "ToggleSuspendResource")
+	routes.Alias("SyncResource", "SyncFluxObject")
+
+	return policy.NewEnforcer(log, routes)
 }
 
 // NewHandlers creates and returns a new server configured to serve the core
@@ -34,7 +55,12 @@ func NewHandlers(ctx context.Context, log logr.Logger, cfg *Config) (http.Handle
 		return nil, fmt.Errorf("could not start up core servers: %w", err)
 	}
 
-	httpHandler := auth.WithAPIAuth(mux, cfg.AuthServer, PublicRoutes)
+	handler := http.Handler(mux)
+	if cfg.Policy != nil {
+		handler = cfg.Policy.Middleware(handler)
+	}
+
+	httpHandler := auth.WithAPIAuth(handler, cfg.AuthServer, PublicRoutes)
 
 	return httpHandler, nil
 }
@@ -0,0 +1,76 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"github.com/weaveworks/weave-gitops/pkg/server/policy"
+)
+
+func TestPolicyEnforcerRoutes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := policy.Parse([]byte(`
+rules:
+- effect: deny
+  methods: [GetSessionLogs, DownloadArtifact]
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	enforcer := server.NewPolicyEnforcer(logr.Discard())
+	enforcer.SetPolicy(p)
+
+	principal := &auth.UserPrincipal{ID: "anne"}
+
+	for _, tt := range []struct {
+		verb, path string
+		allowed    bool
+	}{
+		{http.MethodPost, "/v1/session_logs", false},
+		{http.MethodGet, "/v1/session_logs/run-1/archive", false},
+		{http.MethodGet, "/v1/object/podinfo/artifact", false},
+		{http.MethodGet, "/v1/object/podinfo", true},
+	} {
+		g.Expect(enforcer.Allowed(httptest.NewRequest(tt.verb, tt.path, nil), principal)).To(Equal(tt.allowed), tt.verb+" "+tt.path)
+	}
+}
+
+func TestPolicyEnforcerAliases(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// written before the methods replacing ToggleSuspendResource and
+	// SyncFluxObject existed
+	p, err := policy.Parse([]byte(`
+rules:
+- effect: allow
+  methods: [ToggleSuspendResource]
+  groups: [operators]
+- effect: deny
+  methods: [ToggleSuspendResource]
+- effect: deny
+  methods: [SyncFluxObject]
+  groups: [viewers]
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	enforcer := server.NewPolicyEnforcer(logr.Discard())
+	enforcer.SetPolicy(p)
+
+	operator := &auth.UserPrincipal{ID: "anne", Groups: []string{"operators"}}
+	viewer := &auth.UserPrincipal{ID: "bob", Groups: []string{"viewers"}}
+
+	for _, path := range []string{"/v1/suspend", "/v1/resources/suspend", "/v1/resources/resume"} {
+		g.Expect(enforcer.Allowed(httptest.NewRequest(http.MethodPost, path, nil), operator)).To(BeTrue(), path)
+		g.Expect(enforcer.Allowed(httptest.NewRequest(http.MethodPost, path, nil), viewer)).To(BeFalse(), path)
+	}
+
+	for _, path := range []string{"/v1/sync", "/v1/resources/sync"} {
+		g.Expect(enforcer.Allowed(httptest.NewRequest(http.MethodPost, path, nil), operator)).To(BeTrue(), path)
+		g.Expect(enforcer.Allowed(httptest.NewRequest(http.MethodPost, path, nil), viewer)).To(BeFalse(), path)
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/logger"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// KubeProxyPath is where the Kubernetes API of each cluster is served, at
+// KubeProxyPath + <cluster name or alias>, to clients with a token the
+// token exchange issued for that cluster.
+const KubeProxyPath = "/kube/"
+
+// NewKubeProxy returns a handler proxying requests to the Kubernetes API of
+// the cluster in the path. The request must carry a token exchanged for the
+// cluster, whose user and groups are impersonated like in the dashboard, so
+// Kubernetes RBAC applies as usual.
+func NewKubeProxy(log logr.Logger, authServer *auth.AuthServer, clustersManager clustersmngr.ClustersManager) http.Handler {
+	transports := &clusterTransports{transports: map[string]clusterTransport{}}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		clusterName, apiPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, KubeProxyPath), "/")
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if clusterName == "" || token == "" {
+			auth.JSONError(log, rw, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		principal, err := authServer.VerifyClusterToken(token, clusterName)
+		if err != nil {
+			log.V(logger.LogLevelDebug).Info("Rejected cluster token", "cluster", clusterName, "err", err)
+			auth.JSONError(log, rw, "Authentication required", http.StatusUnauthorized)
+
+			return
+		}
+
+		cl, err := clustersManager.GetCluster(clusterName)
+		if err != nil {
+			auth.JSONError(log, rw, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		serverConfig, err := cl.GetServerConfig()
+		if err != nil {
+			log.Error(err, "getting cluster config", "cluster", clusterName)
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		target, _, err := rest.DefaultServerURL(serverConfig.Host, "", schema.GroupVersion{}, true)
+		if err != nil {
+			log.Error(err, "getting cluster URL", "cluster", clusterName)
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		base, err := transports.get(clusterName, serverConfig)
+		if err != nil {
+			log.Error(err, "creating cluster transport", "cluster", clusterName)
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		impersonating := transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+			UserName: principal.ID,
+			Groups:   principal.Groups,
+		}, base)
+
+		proxy := &httputil.ReverseProxy{
+			Director: func(req *http.Request) {
+				req.URL.Scheme = target.Scheme
+				req.URL.Host = target.Host
+				req.URL.Path = path.Join("/", target.Path, apiPath)
+				req.URL.RawPath = ""
+				req.Host = target.Host
+
+				// the cluster authenticates the server, which impersonates
+				// the user of the token and nobody else
+				req.Header.Del("Authorization")
+
+				for name := range req.Header {
+					if strings.HasPrefix(name, "Impersonate-") {
+						req.Header.Del(name)
+					}
+				}
+			},
+			Transport: impersonating,
+			// watches stream their events
+			FlushInterval: -1,
+		}
+
+		proxy.ServeHTTP(rw, r)
+	})
+}
+
+// clusterTransports keeps the transport of each cluster, so connections are
+// reused across requests. Users are impersonated on top of it.
+type clusterTransports struct {
+	lock       sync.Mutex
+	transports map[string]clusterTransport
+}
+
+type clusterTransport struct {
+	config    *rest.Config
+	transport http.RoundTripper
+}
+
+// get returns the transport of the cluster, created again when the cluster
+// was, from a new config.
+func (t *clusterTransports) get(clusterName string, config *rest.Config) (http.RoundTripper, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	cached, ok := t.transports[clusterName]
+	if ok && cached.config == config {
+		return cached.transport, nil
+	}
+
+	rt, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		utilnet.CloseIdleConnectionsFor(cached.transport)
+	}
+
+	t.transports[clusterName] = clusterTransport{config: config, transport: rt}
+
+	return rt, nil
+}
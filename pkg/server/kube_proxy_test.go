@@ -0,0 +1,208 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/cluster/clusterfakes"
+	"github.com/weaveworks/weave-gitops/core/clustersmngr/clustersmngrfakes"
+	"github.com/weaveworks/weave-gitops/pkg/server"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKubeProxyWithExchangedToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var upstream *http.Request
+
+	var connections int32
+
+	apiServer := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		upstream = r.Clone(context.Background())
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"kind":"PodList"}`))
+	}))
+	apiServer.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	apiServer.Start()
+	defer apiServer.Close()
+
+	leaf := &clusterfakes.FakeCluster{}
+	leaf.GetNameReturns("leaf-1")
+	// with its own dialer, client-go doesn't share the cluster's transport
+	leaf.GetServerConfigReturns(&rest.Config{Host: apiServer.URL, BearerToken: "server-token", Dial: (&net.Dialer{}).DialContext}, nil)
+
+	clustersManager := &clustersmngrfakes.FakeClustersManager{}
+	clustersManager.GetClusterCalls(func(name string) (cluster.Cluster, error) {
+		if name == "leaf-1" || name == "production" {
+			return leaf, nil
+		}
+
+		return nil, clustersmngr.ClusterNotFoundError{Cluster: name}
+	})
+
+	tokenSignerVerifier, err := auth.NewHMACTokenSignerVerifier(5 * time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clusterUser := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: auth.ClusterUserAuthSecretName, Namespace: "flux-system"},
+	}
+	k8s := fake.NewClientBuilder().WithObjects(clusterUser).Build()
+
+	authCfg, err := auth.NewAuthServerConfig(logr.Discard(), auth.OIDCConfig{}, k8s, tokenSignerVerifier, "flux-system", map[auth.AuthMethod]bool{auth.UserAccount: true})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	authServer, err := auth.NewAuthServer(context.Background(), authCfg)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	authServer.EnableTokenExchange(func(name string) bool {
+		_, err := clustersManager.GetCluster(name)
+		return err == nil
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/oauth2/token", authServer.TokenExchange())
+	mux.Handle(server.KubeProxyPath, server.NewKubeProxy(logr.Discard(), authServer, clustersManager))
+
+	dashboard := httptest.NewServer(mux)
+	defer dashboard.Close()
+
+	session, err := tokenSignerVerifier.Sign("alice")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the alias is the audience and the path
+	clusterToken := exchangeClusterToken(g, dashboard.URL, session, "production")
+
+	req, err := http.NewRequest(http.MethodGet, dashboard.URL+server.KubeProxyPath+"production/api/v1/namespaces/default/pods?limit=10", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	req.Header.Set("Authorization", "Bearer "+clusterToken)
+	req.Header.Set("Impersonate-Group", "system:masters")
+
+	res, err := http.DefaultClient.Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer res.Body.Close()
+
+	g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(upstream).NotTo(BeNil())
+	g.Expect(upstream.URL.Path).To(Equal("/api/v1/namespaces/default/pods"))
+	g.Expect(upstream.URL.RawQuery).To(Equal("limit=10"))
+	g.Expect(upstream.Header.Get("Authorization")).To(Equal("Bearer server-token"))
+	g.Expect(upstream.Header.Get("Impersonate-User")).To(Equal("alice"))
+	g.Expect(upstream.Header.Values("Impersonate-Group")).NotTo(ContainElement("system:masters"))
+
+	t.Run("the token is only valid for its cluster", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstream = nil
+
+		req, err := http.NewRequest(http.MethodGet, dashboard.URL+server.KubeProxyPath+"leaf-1/api/v1/namespaces", nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer "+clusterToken)
+
+		res, err := http.DefaultClient.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		g.Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+		g.Expect(upstream).To(BeNil())
+	})
+
+	t.Run("a session token is not a cluster token", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstream = nil
+
+		req, err := http.NewRequest(http.MethodGet, dashboard.URL+server.KubeProxyPath+"production/api/v1/namespaces", nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer "+session)
+
+		res, err := http.DefaultClient.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		g.Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+		g.Expect(upstream).To(BeNil())
+	})
+
+	t.Run("the cluster's connections are reused across users", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		bobSession, err := tokenSignerVerifier.Sign("bob")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		for _, user := range []struct{ name, token string }{
+			{"alice", clusterToken},
+			{"bob", exchangeClusterToken(g, dashboard.URL, bobSession, "production")},
+			{"alice", clusterToken},
+		} {
+			req, err := http.NewRequest(http.MethodGet, dashboard.URL+server.KubeProxyPath+"production/api/v1/namespaces", nil)
+			g.Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer "+user.token)
+
+			res, err := http.DefaultClient.Do(req)
+			g.Expect(err).NotTo(HaveOccurred())
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+
+			g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+			g.Expect(upstream.Header.Get("Impersonate-User")).To(Equal(user.name))
+		}
+
+		g.Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+	})
+
+	t.Run("tokens are not exchanged for unknown clusters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		res, err := http.PostForm(dashboard.URL+"/oauth2/token", exchangeForm(session, "staging"))
+		g.Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		g.Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+}
+
+func exchangeClusterToken(g *WithT, dashboardURL, session, cluster string) string {
+	res, err := http.PostForm(dashboardURL+"/oauth2/token", exchangeForm(session, cluster))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	defer res.Body.Close()
+
+	g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+	var token auth.TokenExchangeResponse
+	g.Expect(json.NewDecoder(res.Body).Decode(&token)).To(Succeed())
+	g.Expect(strings.Count(token.AccessToken, ".")).To(Equal(2))
+
+	return token.AccessToken
+}
+
+func exchangeForm(session, cluster string) url.Values {
+	return url.Values{
+		"grant_type":         {auth.TokenExchangeGrantType},
+		"subject_token":      {session},
+		"subject_token_type": {auth.TokenTypeJWT},
+		"audience":           {cluster},
+	}
+}
@@ -0,0 +1,110 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMapKey is the key of the policy in its ConfigMap.
+const ConfigMapKey = "policy.yaml"
+
+// Enforcer checks API requests against the current policy.
+type Enforcer struct {
+	log    logr.Logger
+	routes *Routes
+
+	mu     sync.RWMutex
+	policy *Policy
+}
+
+// NewEnforcer creates an Enforcer that allows everything until a policy is
+// set.
+func NewEnforcer(log logr.Logger, routes *Routes) *Enforcer {
+	return &Enforcer{
+		log:    log.WithName("policy"),
+		routes: routes,
+		policy: &Policy{DefaultEffect: Allow},
+	}
+}
+
+// SetPolicy replaces the policy.
+func (e *Enforcer) SetPolicy(p *Policy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.policy = p
+}
+
+// Allowed returns whether the request is allowed by the policy.
+func (e *Enforcer) Allowed(r *http.Request, principal *auth.UserPrincipal) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	// Requests to unknown routes only match wildcard rules.
+	method, _ := e.routes.Method(r)
+
+	return e.policy.Allowed(method, principal, e.routes.Aliases(method)...)
+}
+
+// Middleware denies requests the policy doesn't allow with a 403. It must
+// run after WithAPIAuth, requests without a principal are public routes and
+// aren't checked.
+func (e *Enforcer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal := auth.Principal(r.Context())
+
+		if principal != nil && !e.Allowed(r, principal) {
+			e.log.Info("request denied by policy", "user", principal.ID, "groups", principal.Groups, "method", r.Method, "path", r.URL.Path)
+			auth.JSONError(e.log, rw, "Forbidden by the authorization policy", http.StatusForbidden)
+
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// LoadConfigMap sets the policy from a ConfigMap.
+func (e *Enforcer) LoadConfigMap(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	cm := corev1.ConfigMap{}
+	if err := c.Get(ctx, key, &cm); err != nil {
+		return fmt.Errorf("failed to get policy ConfigMap: %w", err)
+	}
+
+	p, err := Parse([]byte(cm.Data[ConfigMapKey]))
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	changed := !reflect.DeepEqual(e.policy, p)
+	e.policy = p
+	e.mu.Unlock()
+
+	if changed {
+		e.log.Info("loaded authorization policy", "configmap", key.String(), "rules", len(p.Rules))
+	}
+
+	return nil
+}
+
+// WatchConfigMap reloads the policy from a ConfigMap every interval until
+// the context is cancelled. If the ConfigMap can't be loaded the previous
+// policy stays in force.
+func (e *Enforcer) WatchConfigMap(ctx context.Context, c client.Client, key client.ObjectKey, interval time.Duration) {
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := e.LoadConfigMap(ctx, c, key); err != nil {
+			e.log.Error(err, "failed to reload authorization policy, keeping the previous one")
+		}
+	}, interval)
+}
@@ -0,0 +1,85 @@
+package policy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	pb "github.com/weaveworks/weave-gitops/pkg/api/core"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"github.com/weaveworks/weave-gitops/pkg/server/policy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRoutesFromService(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	routes := policy.RoutesFromService(pb.File_api_core_core_proto.Services().ByName("Core"))
+	routes.Add(http.MethodGet, "/v1/object/{name}/artifact", "DownloadArtifact")
+
+	for _, tt := range []struct {
+		verb, path, method string
+	}{
+		{http.MethodGet, "/v1/object/podinfo", "GetObject"},
+		{http.MethodGet, "/v1/object/podinfo/artifact", "DownloadArtifact"},
+		{http.MethodPost, "/v1/suspend", "ToggleSuspendResource"},
+		{http.MethodGet, "/v1/suspend", ""},
+		{http.MethodGet, "/v1/object/", ""},
+	} {
+		method, _ := routes.Method(httptest.NewRequest(tt.verb, tt.path, nil))
+		g.Expect(method).To(Equal(tt.method), tt.verb+" "+tt.path)
+	}
+}
+
+func TestEnforcerMiddleware(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitops-policy", Namespace: "flux-system"},
+		Data: map[string]string{
+			policy.ConfigMapKey: `
+rules:
+- effect: allow
+  methods: [ToggleSuspendResource]
+  groups: [operators]
+- effect: deny
+  methods: [ToggleSuspendResource]
+`,
+		},
+	}).Build()
+
+	enforcer := policy.NewEnforcer(logr.Discard(), policy.RoutesFromService(pb.File_api_core_core_proto.Services().ByName("Core")))
+	g.Expect(enforcer.LoadConfigMap(ctx, c, client.ObjectKey{Namespace: "flux-system", Name: "gitops-policy"})).To(Succeed())
+
+	handler := enforcer.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+
+	request := func(verb, path string, principal *auth.UserPrincipal) int {
+		req := httptest.NewRequest(verb, path, nil)
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		return res.Code
+	}
+
+	operator := &auth.UserPrincipal{ID: "alice", Groups: []string{"operators"}}
+	developer := &auth.UserPrincipal{ID: "bob", Groups: []string{"developers"}}
+
+	g.Expect(request(http.MethodPost, "/v1/suspend", operator)).To(Equal(http.StatusOK))
+	g.Expect(request(http.MethodPost, "/v1/suspend", developer)).To(Equal(http.StatusForbidden))
+	g.Expect(request(http.MethodGet, "/v1/object/podinfo", developer)).To(Equal(http.StatusOK))
+	g.Expect(request(http.MethodGet, "/v1/featureflags", nil)).To(Equal(http.StatusOK))
+
+	g.Expect(enforcer.LoadConfigMap(ctx, c, client.ObjectKey{Namespace: "flux-system", Name: "missing"})).NotTo(Succeed())
+	g.Expect(request(http.MethodPost, "/v1/suspend", developer)).To(Equal(http.StatusForbidden))
+}
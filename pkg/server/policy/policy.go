@@ -0,0 +1,129 @@
+// Package policy implements a small authorization layer on top of
+// Kubernetes RBAC, allowing or denying API methods to groups of users.
+package policy
+
+import (
+	"fmt"
+
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"sigs.k8s.io/yaml"
+)
+
+// Rule effects.
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Wildcard matches every API method.
+const Wildcard = "*"
+
+// Rule allows or denies API methods to users. A rule without users or
+// groups applies to everyone.
+type Rule struct {
+	Effect string `json:"effect"`
+	// Methods are API method names, e.g. ToggleSuspendResource, or *
+	Methods []string `json:"methods"`
+	Groups  []string `json:"groups,omitempty"`
+	Users   []string `json:"users,omitempty"`
+}
+
+// Policy is an ordered list of rules, the first rule that matches a request
+// decides whether it's allowed. Requests that don't match any rule get the
+// default effect, which is allow so the policy only needs to list the
+// exceptions.
+type Policy struct {
+	DefaultEffect string `json:"defaultEffect,omitempty"`
+	Rules         []Rule `json:"rules"`
+}
+
+// Parse reads a YAML policy, e.g.
+//
+//	rules:
+//	- effect: allow
+//	  methods: [ToggleSuspendResource, SyncFluxObject]
+//	  groups: [operators]
+//	- effect: deny
+//	  methods: [ToggleSuspendResource, SyncFluxObject]
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if p.DefaultEffect == "" {
+		p.DefaultEffect = Allow
+	}
+
+	if p.DefaultEffect != Allow && p.DefaultEffect != Deny {
+		return nil, fmt.Errorf("invalid policy: defaultEffect must be %s or %s", Allow, Deny)
+	}
+
+	for i, r := range p.Rules {
+		if r.Effect != Allow && r.Effect != Deny {
+			return nil, fmt.Errorf("invalid policy: rule %d: effect must be %s or %s", i+1, Allow, Deny)
+		}
+
+		if len(r.Methods) == 0 {
+			return nil, fmt.Errorf("invalid policy: rule %d: at least one method is required", i+1)
+		}
+	}
+
+	return p, nil
+}
+
+// Allowed returns whether the principal may call the API method. Rules
+// naming one of the aliases of the method apply to it as well.
+func (p *Policy) Allowed(method string, principal *auth.UserPrincipal, aliases ...string) bool {
+	methods := append([]string{method}, aliases...)
+
+	for _, r := range p.Rules {
+		if r.matches(methods, principal) {
+			return r.Effect == Allow
+		}
+	}
+
+	return p.DefaultEffect == Allow
+}
+
+func (r Rule) matches(methods []string, principal *auth.UserPrincipal) bool {
+	if !containsAny(r.Methods, methods) && !contains(r.Methods, Wildcard) {
+		return false
+	}
+
+	if len(r.Users) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+
+	if contains(r.Users, principal.ID) {
+		return true
+	}
+
+	for _, g := range principal.Groups {
+		if contains(r.Groups, g) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(values, vs []string) bool {
+	for _, v := range vs {
+		if contains(values, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package policy_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/server/auth"
+	"github.com/weaveworks/weave-gitops/pkg/server/policy"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := policy.Parse([]byte(`
+rules:
+- effect: allow
+  methods: [ToggleSuspendResource, SyncFluxObject]
+  groups: [operators]
+- effect: allow
+  methods: [ToggleSuspendResource]
+  users: [carol]
+- effect: deny
+  methods: [ToggleSuspendResource, SyncFluxObject]
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	operator := &auth.UserPrincipal{ID: "alice", Groups: []string{"developers", "operators"}}
+	developer := &auth.UserPrincipal{ID: "bob", Groups: []string{"developers"}}
+	carol := &auth.UserPrincipal{ID: "carol"}
+
+	g.Expect(p.Allowed("ToggleSuspendResource", operator)).To(BeTrue())
+	g.Expect(p.Allowed("ToggleSuspendResource", developer)).To(BeFalse())
+	g.Expect(p.Allowed("ToggleSuspendResource", carol)).To(BeTrue())
+	g.Expect(p.Allowed("SyncFluxObject", carol)).To(BeFalse())
+	g.Expect(p.Allowed("GetObject", developer)).To(BeTrue())
+}
+
+func TestPolicyDefaultDeny(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := policy.Parse([]byte(`
+defaultEffect: deny
+rules:
+- effect: allow
+  methods: ["*"]
+  groups: [viewers]
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(p.Allowed("GetObject", &auth.UserPrincipal{ID: "alice", Groups: []string{"viewers"}})).To(BeTrue())
+	g.Expect(p.Allowed("GetObject", &auth.UserPrincipal{ID: "bob"})).To(BeFalse())
+}
+
+func TestParseInvalidPolicy(t *testing.T) {
+	for policyYAML, wantErr := range map[string]string{
+		"defaultEffect: maybe":                    "defaultEffect must be allow or deny",
+		"rules: [{effect: permit, methods: [a]}]": "rule 1: effect must be allow or deny",
+		"rules: [{effect: allow}]":                "rule 1: at least one method is required",
+		"rules: [{effect: allow, verbs: [a]}]":    "unknown field",
+	} {
+		g := NewGomegaWithT(t)
+
+		_, err := policy.Parse([]byte(policyYAML))
+		g.Expect(err).To(MatchError(ContainSubstring(wantErr)), policyYAML)
+	}
+}
+
+func TestAllowedWithAliases(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := policy.Parse([]byte(`
+rules:
+- effect: deny
+  methods: [ToggleSuspendResource]
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	user := &auth.UserPrincipal{ID: "anne"}
+
+	g.Expect(p.Allowed("SuspendResource", user)).To(BeTrue())
+	g.Expect(p.Allowed("SuspendResource", user, "ToggleSuspendResource")).To(BeFalse())
+	g.Expect(p.Allowed("GetObject", user, "ListObjects")).To(BeTrue())
+}
@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Routes maps HTTP requests to the API methods they call, so rules can be
+// written against method names instead of paths.
+type Routes struct {
+	routes []route
+	// aliases are the methods each method is also known as
+	aliases map[string][]string
+}
+
+type route struct {
+	verb     string
+	segments []string
+	method   string
+}
+
+// RoutesFromService reads the routes of a gRPC service from its
+// google.api.http annotations.
+func RoutesFromService(service protoreflect.ServiceDescriptor) *Routes {
+	routes := &Routes{}
+
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		m := methods.Get(i)
+
+		rule, ok := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+			verb, path := httpRulePattern(r)
+			if path != "" {
+				routes.Add(verb, path, string(m.Name()))
+			}
+		}
+	}
+
+	return routes
+}
+
+func httpRulePattern(rule *annotations.HttpRule) (string, string) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
+
+// Add registers a route that isn't described by a gRPC service. Path
+// variables are written as {name}, and {name=**} matches the rest of the
+// path.
+func (rs *Routes) Add(verb, path, method string) {
+	rs.routes = append(rs.routes, route{
+		verb:     verb,
+		segments: strings.Split(strings.Trim(path, "/"), "/"),
+		method:   method,
+	})
+}
+
+// Alias makes the rules of each method apply to the other one too, e.g.
+// when a method replaces one with the same effect under a new name.
+func (rs *Routes) Alias(method, other string) {
+	if rs.aliases == nil {
+		rs.aliases = map[string][]string{}
+	}
+
+	rs.aliases[method] = append(rs.aliases[method], other)
+	rs.aliases[other] = append(rs.aliases[other], method)
+}
+
+// Aliases returns the other names of a method.
+func (rs *Routes) Aliases(method string) []string {
+	return rs.aliases[method]
+}
+
+// Method returns the API method a request calls.
+func (rs *Routes) Method(r *http.Request) (string, bool) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	for _, route := range rs.routes {
+		if route.verb == r.Method && route.match(segments) {
+			return route.method, true
+		}
+	}
+
+	return "", false
+}
+
+func (rt route) match(segments []string) bool {
+	for i, s := range rt.segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "=**}") {
+			return len(segments) > i
+		}
+
+		if i >= len(segments) {
+			return false
+		}
+
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			if segments[i] == "" {
+				return false
+			}
+
+			continue
+		}
+
+		if s != segments[i] {
+			return false
+		}
+	}
+
+	return len(segments) == len(rt.segments)
+}
@@ -0,0 +1,35 @@
+package tenancy
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/weaveworks/weave-gitops/pkg/gitproviders"
+)
+
+// PullRequest opens a pull request adding the manifests of the tenant to
+// dir/<tenant>.yaml in a repository, on a new tenant-<name> branch.
+func PullRequest(ctx context.Context, provider gitproviders.GitProvider, repoURL gitproviders.RepoURL, dir string, t Tenant) (gitprovider.PullRequest, error) {
+	manifests, err := t.Manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := path.Join(dir, t.Name+".yaml")
+	content := string(manifests)
+
+	pr, err := provider.CreatePullRequest(ctx, repoURL, gitproviders.PullRequestInfo{
+		Title:         fmt.Sprintf("Onboard tenant %s", t.Name),
+		Description:   fmt.Sprintf("Adds the namespaces, RBAC and Flux objects of tenant %s.", t.Name),
+		CommitMessage: fmt.Sprintf("Onboard tenant %s", t.Name),
+		NewBranch:     "tenant-" + t.Name,
+		Files:         []gitprovider.CommitFile{{Path: &filePath, Content: &content}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pull request for tenant %s: %w", t.Name, err)
+	}
+
+	return pr, nil
+}
@@ -0,0 +1,203 @@
+// Package tenancy generates what a platform team applies to onboard a
+// tenant: its namespaces, labelled so the tenant shows in listings, a
+// service account and RBAC for Flux to impersonate, and optionally the Flux
+// objects syncing the tenant's own repository.
+package tenancy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// TenantLabel is set on the namespaces of a tenant, objects in them are
+// listed as belonging to the tenant.
+const TenantLabel = "toolkit.fluxcd.io/tenant"
+
+const (
+	DefaultClusterRole = "cluster-admin"
+	DefaultBranch      = "main"
+	DefaultPath        = "./"
+
+	// reconcilerRoleBinding is the name Flux gives the role binding of
+	// tenants, so generated tenants can be managed with the flux CLI.
+	reconcilerRoleBinding = "gotk-reconciler"
+)
+
+// Tenant is a team sharing a cluster with others.
+type Tenant struct {
+	Name       string
+	Namespaces []string
+	// ClusterRole is bound to the tenant's service account in each of its
+	// namespaces.
+	ClusterRole string
+	// SourceURL is the tenant's own repository, nothing is synced if it's
+	// empty.
+	SourceURL string
+	Branch    string
+	Path      string
+}
+
+// Validate checks the tenant can be generated, and sets defaults.
+func (t *Tenant) Validate() error {
+	if errs := validation.IsDNS1123Label(t.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid tenant name %q: %s", t.Name, strings.Join(errs, ", "))
+	}
+
+	if len(t.Namespaces) == 0 {
+		return errors.New("a tenant needs at least one namespace")
+	}
+
+	seen := map[string]bool{}
+
+	for _, ns := range t.Namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, ", "))
+		}
+
+		if seen[ns] {
+			return fmt.Errorf("namespace %q is listed more than once", ns)
+		}
+
+		seen[ns] = true
+	}
+
+	if t.ClusterRole == "" {
+		t.ClusterRole = DefaultClusterRole
+	}
+
+	if t.SourceURL != "" {
+		if t.Branch == "" {
+			t.Branch = DefaultBranch
+		}
+
+		if t.Path == "" {
+			t.Path = DefaultPath
+		}
+	}
+
+	return nil
+}
+
+// Objects returns the objects onboarding the tenant. The repository of the
+// tenant is synced from its first namespace.
+func (t Tenant) Objects() ([]client.Object, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Each object gets its own map, callers may change them
+	labels := func() map[string]string { return map[string]string{TenantLabel: t.Name} }
+	objects := []client.Object{}
+
+	for _, ns := range t.Namespaces {
+		objects = append(objects,
+			&corev1.Namespace{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: metav1.ObjectMeta{Name: ns, Labels: labels()},
+			},
+			&corev1.ServiceAccount{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+				ObjectMeta: metav1.ObjectMeta{Name: t.Name, Namespace: ns, Labels: labels()},
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: reconcilerRoleBinding, Namespace: ns, Labels: labels()},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     t.ClusterRole,
+				},
+				Subjects: []rbacv1.Subject{
+					{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: fmt.Sprintf("gotk:%s:reconciler", ns)},
+					{Kind: rbacv1.ServiceAccountKind, Name: t.Name, Namespace: ns},
+				},
+			},
+		)
+	}
+
+	if t.SourceURL == "" {
+		return objects, nil
+	}
+
+	syncMeta := metav1.ObjectMeta{Name: t.Name, Namespace: t.Namespaces[0], Labels: labels()}
+
+	objects = append(objects,
+		&sourcev1.GitRepository{
+			TypeMeta:   metav1.TypeMeta{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.GitRepositoryKind},
+			ObjectMeta: syncMeta,
+			Spec: sourcev1.GitRepositorySpec{
+				URL:       t.SourceURL,
+				Reference: &sourcev1.GitRepositoryRef{Branch: t.Branch},
+				Interval:  metav1.Duration{Duration: time.Minute},
+			},
+		},
+		&kustomizev1.Kustomization{
+			TypeMeta:   metav1.TypeMeta{APIVersion: kustomizev1.GroupVersion.String(), Kind: kustomizev1.KustomizationKind},
+			ObjectMeta: syncMeta,
+			Spec: kustomizev1.KustomizationSpec{
+				SourceRef: kustomizev1.CrossNamespaceSourceReference{
+					Kind: sourcev1.GitRepositoryKind,
+					Name: t.Name,
+				},
+				Path:               t.Path,
+				Prune:              true,
+				ServiceAccountName: t.Name,
+				Interval:           metav1.Duration{Duration: 10 * time.Minute},
+			},
+		},
+	)
+
+	return objects, nil
+}
+
+// Manifests returns the objects onboarding the tenant as a YAML stream.
+func (t Tenant) Manifests() ([]byte, error) {
+	objects, err := t.Objects()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+
+	for i, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, err)
+		}
+
+		// Empty fields of new objects are noise in a repository
+		unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(content, "status")
+
+		if spec, ok := content["spec"].(map[string]interface{}); ok && len(spec) == 0 {
+			delete(content, "spec")
+		}
+
+		data, err := yaml.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, err)
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
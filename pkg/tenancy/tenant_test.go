@@ -0,0 +1,122 @@
+package tenancy_test
+
+import (
+	"context"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks/weave-gitops/pkg/gitproviders"
+	"github.com/weaveworks/weave-gitops/pkg/gitproviders/gitprovidersfakes"
+	"github.com/weaveworks/weave-gitops/pkg/tenancy"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestTenantObjects(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	objects, err := tenancy.Tenant{Name: "team-a", Namespaces: []string{"apps", "jobs"}}.Objects()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(objects).To(HaveLen(6))
+
+	ns, ok := objects[0].(*corev1.Namespace)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(ns.Name).To(Equal("apps"))
+	g.Expect(ns.Labels).To(HaveKeyWithValue(tenancy.TenantLabel, "team-a"))
+
+	rb, ok := objects[5].(*rbacv1.RoleBinding)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(rb.Namespace).To(Equal("jobs"))
+	g.Expect(rb.RoleRef.Name).To(Equal(tenancy.DefaultClusterRole))
+	g.Expect(rb.Subjects).To(ContainElement(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "team-a", Namespace: "jobs"}))
+
+	t.Run("syncs the tenant repository from its first namespace", func(t *testing.T) {
+		objects, err := tenancy.Tenant{
+			Name:        "team-a",
+			Namespaces:  []string{"apps", "jobs"},
+			ClusterRole: "edit",
+			SourceURL:   "https://github.com/example/team-a",
+		}.Objects()
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(objects).To(HaveLen(8))
+
+		ks, ok := objects[7].(*kustomizev1.Kustomization)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(ks.Namespace).To(Equal("apps"))
+		g.Expect(ks.Spec.ServiceAccountName).To(Equal("team-a"))
+		g.Expect(ks.Spec.Path).To(Equal(tenancy.DefaultPath))
+	})
+}
+
+func TestTenantValidate(t *testing.T) {
+	for _, tenant := range []tenancy.Tenant{
+		{Name: "Team_A", Namespaces: []string{"apps"}},
+		{Name: "team-a"},
+		{Name: "team-a", Namespaces: []string{"apps", "apps"}},
+		{Name: "team-a", Namespaces: []string{"apps."}},
+	} {
+		g := NewGomegaWithT(t)
+		g.Expect(tenant.Validate()).NotTo(Succeed(), "%+v", tenant)
+	}
+}
+
+func TestTenantManifests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	manifests, err := tenancy.Tenant{Name: "team-a", Namespaces: []string{"apps"}}.Manifests()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(manifests)).To(Equal(`apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    toolkit.fluxcd.io/tenant: team-a
+  name: apps
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  labels:
+    toolkit.fluxcd.io/tenant: team-a
+  name: team-a
+  namespace: apps
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  labels:
+    toolkit.fluxcd.io/tenant: team-a
+  name: gotk-reconciler
+  namespace: apps
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- apiGroup: rbac.authorization.k8s.io
+  kind: User
+  name: gotk:apps:reconciler
+- kind: ServiceAccount
+  name: team-a
+  namespace: apps
+`))
+}
+
+func TestPullRequest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	repoURL, err := gitproviders.NewRepoURL("https://github.com/example/fleet")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	provider := &gitprovidersfakes.FakeGitProvider{}
+
+	_, err = tenancy.PullRequest(context.Background(), provider, repoURL, "tenants", tenancy.Tenant{Name: "team-a", Namespaces: []string{"apps"}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(provider.CreatePullRequestCallCount()).To(Equal(1))
+	_, _, info := provider.CreatePullRequestArgsForCall(0)
+	g.Expect(info.NewBranch).To(Equal("tenant-team-a"))
+	g.Expect(info.Files).To(HaveLen(1))
+	g.Expect(*info.Files[0].Path).To(Equal("tenants/team-a.yaml"))
+	g.Expect(*info.Files[0].Content).To(ContainSubstring("name: gotk-reconciler"))
+}
@@ -94,6 +94,7 @@ function HelmReleaseDetail({
           "Last Updated",
           <Timestamp time={automationLastUpdated(helmRelease)} />,
         ],
+        ["Last Sync Requested By", helmRelease?.lastSyncRequestedBy],
         ["Namespace", helmRelease?.namespace],
       ]}
     />
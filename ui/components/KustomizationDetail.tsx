@@ -74,6 +74,7 @@ function KustomizationDetail({
           "Last Updated",
           <Timestamp time={automationLastUpdated(kustomization)} />,
         ],
+        ["Last Sync Requested By", kustomization?.lastSyncRequestedBy],
         ["Namespace", kustomization?.namespace],
       ]}
     />
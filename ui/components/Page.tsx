@@ -1,6 +1,7 @@
 import _ from "lodash";
 import React from "react";
 import styled from "styled-components";
+import { useUnreachableClusters } from "../hooks/clusters";
 import useCommon from "../hooks/common";
 import { MultiRequestError, RequestError } from "../lib/types";
 import Alert from "./Alert";
@@ -49,6 +50,29 @@ function Errors({ error }) {
   return null;
 }
 
+// Objects of unreachable clusters are missing from every list, say so
+// instead of silently showing less.
+function UnreachableClusters() {
+  const clusters = useUnreachableClusters();
+
+  if (!clusters.length) return null;
+
+  return (
+    <Flex wide column>
+      {_.map(clusters, (c) => (
+        <Flex key={c.name} wide start>
+          <Alert
+            title={`Cluster ${c.name} is unreachable`}
+            message={`Its objects may be missing or out of date: ${c.error}`}
+            severity="warning"
+          />
+        </Flex>
+      ))}
+      <Spacer padding="xs" />
+    </Flex>
+  );
+}
+
 function Page({ children, loading, error, className }: PageProps) {
   const { settings } = useCommon();
 
@@ -64,6 +88,7 @@ function Page({ children, loading, error, className }: PageProps) {
     <Content wide between column className={className}>
       <Children column wide tall start>
         <Errors error={error} />
+        <UnreachableClusters />
         {children}
       </Children>
       {settings.renderFooter && <Footer />}
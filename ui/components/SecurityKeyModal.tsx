@@ -0,0 +1,95 @@
+import { Input } from "@material-ui/core";
+import * as React from "react";
+import styled from "styled-components";
+import { Auth } from "../contexts/AuthContext";
+import Alert from "./Alert";
+import Button from "./Button";
+import Flex from "./Flex";
+import Modal from "./Modal";
+
+type Props = {
+  className?: string;
+  open: boolean;
+  onClose: () => void;
+};
+
+function SecurityKeyModal({ className, open, onClose }: Props) {
+  const { registerSecurityKey } = React.useContext(Auth);
+  const [password, setPassword] = React.useState("");
+  const [loading, setLoading] = React.useState(false);
+  const [error, setError] = React.useState<string>(null);
+  const [registered, setRegistered] = React.useState(false);
+
+  React.useEffect(() => {
+    if (!open) {
+      setPassword("");
+      setError(null);
+      setRegistered(false);
+    }
+  }, [open]);
+
+  const handleSubmit = (e: React.FormEvent) => {
+    e.preventDefault();
+    setLoading(true);
+    setError(null);
+    registerSecurityKey(password)
+      .then(() => setRegistered(true))
+      .catch((err) =>
+        setError(
+          err?.status === 401
+            ? "Incorrect password."
+            : err?.message || `${err?.status} ${err?.statusText}`
+        )
+      )
+      .finally(() => {
+        setPassword("");
+        setLoading(false);
+      });
+  };
+
+  return (
+    <Modal
+      className={className}
+      title="Register security key"
+      description="Once a security key is registered, signing in with the password also requires the key."
+      open={open}
+      onClose={onClose}
+    >
+      {error && (
+        <Alert severity="error" title="Error" message={error} center />
+      )}
+      {registered ? (
+        <Alert
+          severity="success"
+          title="Security key registered"
+          message="The key will be required the next time you sign in."
+          center
+        />
+      ) : (
+        <form onSubmit={handleSubmit}>
+          <Flex wide column>
+            <Input
+              id="security-key-password"
+              type="password"
+              placeholder="Confirm your password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              required
+            />
+            <Flex wide end>
+              <Button type="submit" disabled={loading || !password}>
+                Register
+              </Button>
+            </Flex>
+          </Flex>
+        </form>
+      )}
+    </Modal>
+  );
+}
+
+export default styled(SecurityKeyModal)`
+  #security-key-password {
+    margin-bottom: ${(props) => props.theme.spacing.small};
+  }
+`;
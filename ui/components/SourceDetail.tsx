@@ -1,4 +1,5 @@
 import _ from "lodash";
+import qs from "query-string";
 import * as React from "react";
 import { useRouteMatch } from "react-router-dom";
 import styled from "styled-components";
@@ -109,6 +110,19 @@ function SourceDetail({ className, source, info, type, customActions }: Props) {
         >
           {source?.suspended ? "Resume" : "Suspend"}
         </Button>
+        <Spacer padding="xs" />
+        <Button
+          href={`/v1/object/${encodeURIComponent(
+            source.name
+          )}/artifact?${qs.stringify({
+            namespace: source.namespace,
+            clusterName: source.clusterName,
+            kind: type,
+          })}`}
+          download
+        >
+          Download Artifact
+        </Button>
         <CustomActions actions={customActions} />
       </Flex>
 
@@ -11,6 +11,7 @@ import styled from "styled-components";
 import { Auth } from "../contexts/AuthContext";
 import { V2Routes } from "../lib/types";
 import Icon, { IconType } from "./Icon";
+import SecurityKeyModal from "./SecurityKeyModal";
 
 const SettingsMenu = styled(Menu)`
   .MuiList-root {
@@ -44,6 +45,7 @@ const PersonButton = styled(IconButton)<{ open: boolean }>`
 function UserSettings({ className }: { className?: string }) {
   const history = useHistory();
   const [anchorEl, setAnchorEl] = React.useState(null);
+  const [securityKeyOpen, setSecurityKeyOpen] = React.useState(false);
   const { userInfo, logOut } = React.useContext(Auth);
 
   const open = Boolean(anchorEl);
@@ -75,10 +77,15 @@ function UserSettings({ className }: { className?: string }) {
         onClick={handleClose}
         transformOrigin={{ horizontal: 150, vertical: -80 }}
       >
-        <MenuItem disabled>Hello, {userInfo?.email}</MenuItem>
+        <MenuItem disabled>
+          Hello, {userInfo?.displayName || userInfo?.email}
+        </MenuItem>
         <MenuItem onClick={() => history.push(V2Routes.Notifications)}>
           Notifications
         </MenuItem>
+        <MenuItem onClick={() => setSecurityKeyOpen(true)}>
+          Register security key
+        </MenuItem>
         <MenuItem className="logout" onClick={() => logOut()}>
           <ListItemIcon>
             <Icon type={IconType.LogoutIcon} size="base" />
@@ -86,6 +93,10 @@ function UserSettings({ className }: { className?: string }) {
           Logout
         </MenuItem>
       </SettingsMenu>
+      <SecurityKeyModal
+        open={securityKeyOpen}
+        onClose={() => setSecurityKeyOpen(false)}
+      />
     </div>
   );
 }
@@ -1,6 +1,7 @@
 import qs from "query-string";
 import * as React from "react";
 import { Redirect, useHistory } from "react-router-dom";
+import { webAuthnLogin, webAuthnRegister } from "../lib/webauthn";
 import { AppContext } from "./AppContext";
 
 export enum AuthRoutes {
@@ -40,12 +41,14 @@ export type AuthContext = {
   signIn: (data: any) => void;
   userInfo: {
     email: string;
+    displayName?: string;
     groups: string[];
   };
   error: { status: number; statusText: string };
   setError: any;
   loading: boolean;
   logOut: () => void;
+  registerSecurityKey: (password: string) => Promise<Response>;
 };
 
 export const Auth = React.createContext<AuthContext | null>({} as AuthContext);
@@ -55,6 +58,7 @@ export default function AuthContextProvider({ children }) {
 
   const [userInfo, setUserInfo] = React.useState<{
     email: string;
+    displayName?: string;
     groups: string[];
   }>(null);
   const [loading, setLoading] = React.useState<boolean>(true);
@@ -67,19 +71,40 @@ export default function AuthContextProvider({ children }) {
       method: "POST",
       body: JSON.stringify(data),
     })
-      .then((response) => {
+      .then(async (response) => {
         if (response.status !== 200) {
           setError(response);
           return;
         }
-        getUserInfo().then(() => {
+        // The password was right but a registered security key has to
+        // confirm the sign in before the server issues the session.
+        const body = await response.text();
+        if (body && JSON.parse(body).webAuthnRequired) {
+          await webAuthnLogin(request);
+        }
+        return getUserInfo().then(() => {
           setError(null);
           history.push(qs.parse(location.search).redirect || "/");
         });
       })
+      .catch((err) =>
+        setError(
+          err instanceof Response
+            ? err
+            : {
+                status: 400,
+                statusText: err?.message || "Security key sign in failed",
+              }
+        )
+      )
       .finally(() => setLoading(false));
   }, []);
 
+  const registerSecurityKey = React.useCallback(
+    (password: string) => webAuthnRegister(request, password),
+    []
+  );
+
   const getUserInfo = React.useCallback(() => {
     setLoading(true);
     return request(AuthRoutes.USER_INFO)
@@ -90,7 +115,13 @@ export default function AuthContextProvider({ children }) {
         }
         return response.json();
       })
-      .then((data) => setUserInfo({ email: data?.email, groups: [] }))
+      .then((data) =>
+        setUserInfo({
+          email: data?.email,
+          displayName: data?.displayName,
+          groups: [],
+        })
+      )
       .catch((err) => console.log(err))
       .finally(() => setLoading(false));
   }, []);
@@ -125,6 +156,7 @@ export default function AuthContextProvider({ children }) {
           setError,
           loading,
           logOut,
+          registerSecurityKey,
         }}
       >
         {children}
@@ -0,0 +1,27 @@
+import { useContext } from "react";
+import { useQuery } from "react-query";
+import { CoreClientContext } from "../contexts/CoreClientContext";
+import { GetClusterStatusResponse } from "../lib/api/core/core.pb";
+import { RequestError } from "../lib/types";
+
+// Clusters are checked by the server every 30 seconds
+const clusterStatusInterval = 30000;
+
+export function useClusterStatus() {
+  const { api } = useContext(CoreClientContext);
+
+  return useQuery<GetClusterStatusResponse, RequestError>(
+    "cluster_status",
+    () => api.GetClusterStatus({}),
+    {
+      refetchInterval: clusterStatusInterval,
+      retry: false,
+    }
+  );
+}
+
+export function useUnreachableClusters() {
+  const { data } = useClusterStatus();
+
+  return (data?.clusters || []).filter((c) => !c.reachable && c.error);
+}
@@ -37,6 +37,18 @@ describe("objects lib", () => {
     ]);
   });
 
+  it("extracts the last sync trigger", () => {
+    const payload =
+      '{"metadata":{"annotations":{"reconcile.weave.works/requested-by":"alice","reconcile.weave.works/origin":"ui"}}}\n';
+
+    const obj = new FluxObject({
+      payload,
+    });
+
+    expect(obj.lastSyncRequestedBy).toEqual("alice (ui)");
+    expect(new FluxObject({ payload: "{}" }).lastSyncRequestedBy).toEqual("");
+  });
+
   it("dumps yaml", () => {
     const payload =
       '{"apiVersion":"helm.toolkit.fluxcd.io/v2beta1","kind":"HelmRelease"}\n';
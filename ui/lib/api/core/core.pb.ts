@@ -17,6 +17,12 @@ export type ListError = {
   message?: string
 }
 
+export type ClusterError = {
+  clusterName?: string
+  namespaces?: string[]
+  message?: string
+}
+
 export type ListFluxRuntimeObjectsRequest = {
   namespace?: string
   clusterName?: string
@@ -25,6 +31,7 @@ export type ListFluxRuntimeObjectsRequest = {
 export type ListFluxRuntimeObjectsResponse = {
   deployments?: Gitops_coreV1Types.Deployment[]
   errors?: ListError[]
+  clusterErrors?: ClusterError[]
 }
 
 export type ListFluxCrdsRequest = {
@@ -34,6 +41,7 @@ export type ListFluxCrdsRequest = {
 export type ListFluxCrdsResponse = {
   crds?: Gitops_coreV1Types.Crd[]
   errors?: ListError[]
+  clusterErrors?: ClusterError[]
 }
 
 export type GetObjectRequest = {
@@ -52,11 +60,30 @@ export type ListObjectsRequest = {
   kind?: string
   clusterName?: string
   labels?: {[key: string]: string}
+  pagination?: Pagination
+  namespaces?: string[]
+  fieldSelector?: string
+  clusterLabelSelector?: string
 }
 
 export type ListObjectsResponse = {
   objects?: Gitops_coreV1Types.Object[]
   errors?: ListError[]
+  nextToken?: string
+  clusterErrors?: ClusterError[]
+}
+
+export type WatchObjectsRequest = {
+  kinds?: string[]
+  namespaces?: string[]
+  clusterNames?: string[]
+  labels?: {[key: string]: string}
+}
+
+export type WatchObjectsResponse = {
+  type?: string
+  object?: Gitops_coreV1Types.Object
+  errors?: ListError[]
 }
 
 export type GetReconciledObjectsRequest = {
@@ -98,10 +125,12 @@ export type ListNamespacesResponse = {
 
 export type ListEventsRequest = {
   involvedObject?: Gitops_coreV1Types.ObjectRef
+  withSource?: boolean
 }
 
 export type ListEventsResponse = {
   events?: Gitops_coreV1Types.Event[]
+  clusterErrors?: ClusterError[]
 }
 
 export type SyncFluxObjectRequest = {
@@ -112,6 +141,17 @@ export type SyncFluxObjectRequest = {
 export type SyncFluxObjectResponse = {
 }
 
+export type SyncResourceRequest = {
+  object?: Gitops_coreV1Types.ObjectRef
+  withSource?: boolean
+}
+
+export type SyncResourceResponse = {
+  conditions?: Gitops_coreV1Types.Condition[]
+  sourceConditions?: Gitops_coreV1Types.Condition[]
+  lastHandledReconcileAt?: string
+}
+
 export type GetVersionRequest = {
 }
 
@@ -139,6 +179,195 @@ export type ToggleSuspendResourceRequest = {
 export type ToggleSuspendResourceResponse = {
 }
 
+export type SuspendResourceRequest = {
+  objects?: Gitops_coreV1Types.ObjectRef[]
+  comment?: string
+}
+
+export type SuspendResourceResponse = {
+}
+
+export type ResumeResourceRequest = {
+  objects?: Gitops_coreV1Types.ObjectRef[]
+  comment?: string
+}
+
+export type ResumeResourceResponse = {
+}
+
+export type GetSessionLogsRequest = {
+  sessionNamespace?: string
+  sessionIds?: string[]
+  sessionSelector?: string
+  token?: string
+  follow?: boolean
+  level?: string
+  sources?: string[]
+  pageSize?: number
+}
+
+export type LogEntry = {
+  timestamp?: string
+  sessionId?: string
+  message?: string
+  sortingKey?: string
+  level?: string
+  source?: string
+}
+
+export type GetSessionLogsResponse = {
+  logs?: LogEntry[]
+  nextToken?: string
+  error?: string
+  hasMore?: boolean
+}
+
+export type GetObjectStatusHistoryRequest = {
+  name?: string
+  namespace?: string
+  kind?: string
+  clusterName?: string
+  timestamp?: string
+  around?: number
+}
+
+export type StatusSnapshot = {
+  timestamp?: string
+  revision?: string
+  observedGeneration?: string
+  suspended?: boolean
+  conditions?: Gitops_coreV1Types.Condition[]
+}
+
+export type GetObjectStatusHistoryResponse = {
+  snapshot?: StatusSnapshot
+  snapshots?: StatusSnapshot[]
+}
+
+export type ValidateManifestsRequest = {
+  manifests?: string
+  clusterName?: string
+}
+
+export type ManifestFinding = {
+  document?: number
+  severity?: string
+  apiVersion?: string
+  kind?: string
+  name?: string
+  namespace?: string
+  message?: string
+}
+
+export type ValidateManifestsResponse = {
+  valid?: boolean
+  findings?: ManifestFinding[]
+}
+
+export type GetClusterStatusRequest = {
+  clusterName?: string
+  labelSelector?: string
+}
+
+export type ClusterStatus = {
+  name?: string
+  reachable?: boolean
+  serverVersion?: string
+  lastChecked?: string
+  lastSuccessfulList?: string
+  error?: string
+  labels?: {[key: string]: string}
+  id?: string
+}
+
+export type GetClusterStatusResponse = {
+  clusters?: ClusterStatus[]
+}
+
+export type GetHealthScoresRequest = {
+  clusterName?: string
+}
+
+export type HealthScoreCount = {
+  kind?: string
+  severity?: string
+  count?: number
+}
+
+export type ClusterHealthScore = {
+  clusterName?: string
+  score?: number
+  objects?: HealthScoreCount[]
+  timestamp?: string
+}
+
+export type GetHealthScoresResponse = {
+  scores?: ClusterHealthScore[]
+}
+
+export type GenerateTenantManifestsRequest = {
+  name?: string
+  namespaces?: string[]
+  clusterRole?: string
+  sourceUrl?: string
+  branch?: string
+  path?: string
+}
+
+export type GenerateTenantManifestsResponse = {
+  manifests?: string
+}
+
+export type AddClusterRequest = {
+  name?: string
+  kubeconfig?: string
+}
+
+export type AddClusterResponse = {
+  clusterName?: string
+}
+
+export type RemoveClusterRequest = {
+  name?: string
+}
+
+export type RemoveClusterResponse = {
+}
+
+export type Session = {
+  name?: string
+  namespace?: string
+  command?: string
+  cliVersion?: string
+  portForward?: string[]
+  targetNamespace?: string
+}
+
+export type ListSessionsRequest = {
+  namespace?: string
+}
+
+export type ListSessionsResponse = {
+  sessions?: Session[]
+}
+
+export type GetSessionRequest = {
+  name?: string
+  namespace?: string
+}
+
+export type GetSessionResponse = {
+  session?: Session
+}
+
+export type DeleteSessionRequest = {
+  name?: string
+  namespace?: string
+}
+
+export type DeleteSessionResponse = {
+}
+
 export class Core {
   static GetObject(req: GetObjectRequest, initReq?: fm.InitReq): Promise<GetObjectResponse> {
     return fm.fetchReq<GetObjectRequest, GetObjectResponse>(`/v1/object/${req["name"]}?${fm.renderURLSearchParams(req, ["name"])}`, {...initReq, method: "GET"})
@@ -146,6 +375,9 @@ export class Core {
   static ListObjects(req: ListObjectsRequest, initReq?: fm.InitReq): Promise<ListObjectsResponse> {
     return fm.fetchReq<ListObjectsRequest, ListObjectsResponse>(`/v1/objects`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
+  static WatchObjects(req: WatchObjectsRequest, entityNotifier?: fm.NotifyStreamEntityArrival<WatchObjectsResponse>, initReq?: fm.InitReq): Promise<void> {
+    return fm.fetchStreamingRequest<WatchObjectsRequest, WatchObjectsResponse>(`/v1/objects/watch`, entityNotifier, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
   static ListFluxRuntimeObjects(req: ListFluxRuntimeObjectsRequest, initReq?: fm.InitReq): Promise<ListFluxRuntimeObjectsResponse> {
     return fm.fetchReq<ListFluxRuntimeObjectsRequest, ListFluxRuntimeObjectsResponse>(`/v1/flux_runtime_objects?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
   }
@@ -170,6 +402,9 @@ export class Core {
   static SyncFluxObject(req: SyncFluxObjectRequest, initReq?: fm.InitReq): Promise<SyncFluxObjectResponse> {
     return fm.fetchReq<SyncFluxObjectRequest, SyncFluxObjectResponse>(`/v1/sync`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
+  static SyncResource(req: SyncResourceRequest, initReq?: fm.InitReq): Promise<SyncResourceResponse> {
+    return fm.fetchReq<SyncResourceRequest, SyncResourceResponse>(`/v1/resources/sync`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
   static GetVersion(req: GetVersionRequest, initReq?: fm.InitReq): Promise<GetVersionResponse> {
     return fm.fetchReq<GetVersionRequest, GetVersionResponse>(`/v1/version?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
   }
@@ -179,4 +414,43 @@ export class Core {
   static ToggleSuspendResource(req: ToggleSuspendResourceRequest, initReq?: fm.InitReq): Promise<ToggleSuspendResourceResponse> {
     return fm.fetchReq<ToggleSuspendResourceRequest, ToggleSuspendResourceResponse>(`/v1/suspend`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
+  static SuspendResource(req: SuspendResourceRequest, initReq?: fm.InitReq): Promise<SuspendResourceResponse> {
+    return fm.fetchReq<SuspendResourceRequest, SuspendResourceResponse>(`/v1/resources/suspend`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static ResumeResource(req: ResumeResourceRequest, initReq?: fm.InitReq): Promise<ResumeResourceResponse> {
+    return fm.fetchReq<ResumeResourceRequest, ResumeResourceResponse>(`/v1/resources/resume`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static GetSessionLogs(req: GetSessionLogsRequest, initReq?: fm.InitReq): Promise<GetSessionLogsResponse> {
+    return fm.fetchReq<GetSessionLogsRequest, GetSessionLogsResponse>(`/v1/session_logs`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static GetObjectStatusHistory(req: GetObjectStatusHistoryRequest, initReq?: fm.InitReq): Promise<GetObjectStatusHistoryResponse> {
+    return fm.fetchReq<GetObjectStatusHistoryRequest, GetObjectStatusHistoryResponse>(`/v1/object/${req["name"]}/status_history?${fm.renderURLSearchParams(req, ["name"])}`, {...initReq, method: "GET"})
+  }
+  static ValidateManifests(req: ValidateManifestsRequest, initReq?: fm.InitReq): Promise<ValidateManifestsResponse> {
+    return fm.fetchReq<ValidateManifestsRequest, ValidateManifestsResponse>(`/v1/validate`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static GetClusterStatus(req: GetClusterStatusRequest, initReq?: fm.InitReq): Promise<GetClusterStatusResponse> {
+    return fm.fetchReq<GetClusterStatusRequest, GetClusterStatusResponse>(`/v1/clusters/status?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
+  }
+  static GetHealthScores(req: GetHealthScoresRequest, initReq?: fm.InitReq): Promise<GetHealthScoresResponse> {
+    return fm.fetchReq<GetHealthScoresRequest, GetHealthScoresResponse>(`/v1/health_scores?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
+  }
+  static GenerateTenantManifests(req: GenerateTenantManifestsRequest, initReq?: fm.InitReq): Promise<GenerateTenantManifestsResponse> {
+    return fm.fetchReq<GenerateTenantManifestsRequest, GenerateTenantManifestsResponse>(`/v1/tenants/manifests`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static AddCluster(req: AddClusterRequest, initReq?: fm.InitReq): Promise<AddClusterResponse> {
+    return fm.fetchReq<AddClusterRequest, AddClusterResponse>(`/v1/clusters`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static RemoveCluster(req: RemoveClusterRequest, initReq?: fm.InitReq): Promise<RemoveClusterResponse> {
+    return fm.fetchReq<RemoveClusterRequest, RemoveClusterResponse>(`/v1/clusters/${req["name"]}`, {...initReq, method: "DELETE"})
+  }
+  static ListSessions(req: ListSessionsRequest, initReq?: fm.InitReq): Promise<ListSessionsResponse> {
+    return fm.fetchReq<ListSessionsRequest, ListSessionsResponse>(`/v1/sessions?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
+  }
+  static GetSession(req: GetSessionRequest, initReq?: fm.InitReq): Promise<GetSessionResponse> {
+    return fm.fetchReq<GetSessionRequest, GetSessionResponse>(`/v1/sessions/${req["name"]}?${fm.renderURLSearchParams(req, ["name"])}`, {...initReq, method: "GET"})
+  }
+  static DeleteSession(req: DeleteSessionRequest, initReq?: fm.InitReq): Promise<DeleteSessionResponse> {
+    return fm.fetchReq<DeleteSessionRequest, DeleteSessionResponse>(`/v1/sessions/${req["name"]}`, {...initReq, method: "DELETE"})
+  }
 }
\ No newline at end of file
@@ -110,4 +110,5 @@ export type Event = {
   host?: string
   name?: string
   uid?: string
+  involvedObject?: ObjectRef
 }
\ No newline at end of file
@@ -65,6 +65,18 @@ export class FluxObject {
     });
   }
 
+  // Who requested the last manual reconciliation and from where, e.g.
+  // "alice (ui)". Empty if the object has only reconciled on its interval.
+  get lastSyncRequestedBy(): string {
+    const annotations = this.obj.metadata?.annotations || {};
+    const requestedBy = annotations["reconcile.weave.works/requested-by"];
+    const origin = annotations["reconcile.weave.works/origin"];
+    if (!requestedBy) {
+      return origin || "";
+    }
+    return origin ? `${requestedBy} (${origin})` : requestedBy;
+  }
+
   get labels(): [string, string][] {
     const labels = this.obj.metadata?.labels || {};
     return Object.keys(labels).flatMap((key) => {
@@ -22,6 +22,8 @@ import {
   Core,
   GetChildObjectsRequest,
   GetChildObjectsResponse,
+  GetClusterStatusRequest,
+  GetClusterStatusResponse,
   GetReconciledObjectsRequest,
   GetReconciledObjectsResponse,
   GetVersionRequest,
@@ -34,6 +36,9 @@ import { RequestError } from "./types";
 
 export type CoreOverrides = {
   GetChildObjects?: (req: GetChildObjectsRequest) => GetChildObjectsResponse;
+  GetClusterStatus?: (
+    req: GetClusterStatusRequest
+  ) => GetClusterStatusResponse;
   GetReconciledObjects?: (
     req: GetReconciledObjectsRequest
   ) => GetReconciledObjectsResponse;
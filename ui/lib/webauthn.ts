@@ -0,0 +1,111 @@
+export enum WebAuthnRoutes {
+  REGISTER_BEGIN = "/oauth2/webauthn/register/begin",
+  REGISTER_FINISH = "/oauth2/webauthn/register/finish",
+  LOGIN_BEGIN = "/oauth2/webauthn/login/begin",
+  LOGIN_FINISH = "/oauth2/webauthn/login/finish",
+}
+
+type Request = typeof window.fetch;
+
+// The server sends challenges as base64url and user and credential IDs as
+// standard base64, so both alphabets are accepted.
+export function decodeBase64(value: string): ArrayBuffer {
+  const normalized = value.replace(/-/g, "+").replace(/_/g, "/");
+  const padded = normalized.padEnd(
+    normalized.length + ((4 - (normalized.length % 4)) % 4),
+    "="
+  );
+  const binary = atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+// encodeBase64URL encodes as unpadded base64url, which the server expects
+// in the responses of the authenticator.
+export function encodeBase64URL(value: ArrayBuffer): string {
+  let binary = "";
+  new Uint8Array(value).forEach((b) => (binary += String.fromCharCode(b)));
+  return btoa(binary)
+    .replace(/\+/g, "-")
+    .replace(/\//g, "_")
+    .replace(/=+$/, "");
+}
+
+const decodeCredentials = (credentials?: { id: string }[]) =>
+  credentials?.map((c) => ({ ...c, id: decodeBase64(c.id) }));
+
+async function postJSON(request: Request, path: string, body?: any) {
+  const response = await request(path, {
+    method: "POST",
+    body: body && JSON.stringify(body),
+  });
+  if (response.status !== 200) {
+    throw response;
+  }
+  return response;
+}
+
+// webAuthnLogin completes a sign in that the server answered with
+// webAuthnRequired, asking the browser for an assertion of a registered
+// authenticator.
+export async function webAuthnLogin(request: Request) {
+  const begin = await postJSON(request, WebAuthnRoutes.LOGIN_BEGIN);
+  const { publicKey } = await begin.json();
+
+  const credential = (await navigator.credentials.get({
+    publicKey: {
+      ...publicKey,
+      challenge: decodeBase64(publicKey.challenge),
+      allowCredentials: decodeCredentials(publicKey.allowCredentials),
+    },
+  })) as PublicKeyCredential;
+
+  const response = credential.response as AuthenticatorAssertionResponse;
+
+  return postJSON(request, WebAuthnRoutes.LOGIN_FINISH, {
+    id: credential.id,
+    rawId: encodeBase64URL(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: encodeBase64URL(response.clientDataJSON),
+      authenticatorData: encodeBase64URL(response.authenticatorData),
+      signature: encodeBase64URL(response.signature),
+      userHandle: response.userHandle
+        ? encodeBase64URL(response.userHandle)
+        : "",
+    },
+  });
+}
+
+// webAuthnRegister registers a new authenticator for the signed in cluster
+// user. The server requires the password again before it does.
+export async function webAuthnRegister(request: Request, password: string) {
+  const begin = await postJSON(request, WebAuthnRoutes.REGISTER_BEGIN, {
+    password,
+  });
+  const { publicKey } = await begin.json();
+
+  const credential = (await navigator.credentials.create({
+    publicKey: {
+      ...publicKey,
+      challenge: decodeBase64(publicKey.challenge),
+      user: { ...publicKey.user, id: decodeBase64(publicKey.user.id) },
+      excludeCredentials: decodeCredentials(publicKey.excludeCredentials),
+    },
+  })) as PublicKeyCredential;
+
+  const response = credential.response as AuthenticatorAttestationResponse;
+
+  return postJSON(request, WebAuthnRoutes.REGISTER_FINISH, {
+    id: credential.id,
+    rawId: encodeBase64URL(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: encodeBase64URL(response.clientDataJSON),
+      attestationObject: encodeBase64URL(response.attestationObject),
+    },
+  });
+}
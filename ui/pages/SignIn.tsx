@@ -1,6 +1,8 @@
 import { Divider, IconButton, Input, InputAdornment } from "@material-ui/core";
 import { Visibility, VisibilityOff } from "@material-ui/icons";
+import qs from "query-string";
 import * as React from "react";
+import { useLocation } from "react-router-dom";
 import styled from "styled-components";
 import Alert from "../components/Alert";
 import Button from "../components/Button";
@@ -61,6 +63,36 @@ const DocsWrapper = styled(Flex)`
   }
 `;
 
+// Reasons the OIDC callback sends back when a login fails, see
+// pkg/server/auth/callback_error.go
+const callbackErrorMessages: { [reason: string]: string } = {
+  provider_error: "The identity provider rejected the login request.",
+  missing_code: "The identity provider didn't return an authorization code.",
+  missing_state:
+    "The login session was not found, it may have expired or cookies may be blocked.",
+  state_mismatch:
+    "The login session doesn't match this request, it may have been started in another tab.",
+  invalid_state: "The login session is invalid.",
+  exchange_failed:
+    "The authorization code couldn't be exchanged with the identity provider.",
+  invalid_token: "The identity provider didn't return a valid ID token.",
+};
+
+export function callbackErrorMessage(search: string): string | null {
+  const { error, correlation_id } = qs.parse(search);
+  if (!error) {
+    return null;
+  }
+
+  const message =
+    callbackErrorMessages[error as string] ||
+    "Login with the OIDC provider failed.";
+
+  return correlation_id
+    ? `${message} Correlation ID: ${correlation_id}`
+    : message;
+}
+
 function SignIn() {
   const { data } = useFeatureFlags();
   const flags = data.flags;
@@ -74,6 +106,8 @@ function SignIn() {
   const [password, setPassword] = React.useState<string>("");
   const [username, setUsername] = React.useState<string>("");
   const [showPassword, setShowPassword] = React.useState<boolean>(false);
+  const { search } = useLocation();
+  const callbackError = callbackErrorMessage(search);
 
   const handleOIDCSubmit = () => {
     const CURRENT_URL = window.origin;
@@ -112,6 +146,14 @@ function SignIn() {
       <React.Suspense fallback={null}>
         <SignInBackgroundAnimation />
       </React.Suspense>
+      {callbackError && !authError && (
+        <AlertWrapper
+          severity="error"
+          title="Error signing in"
+          message={callbackError}
+          center
+        />
+      )}
       {authError && (
         <AlertWrapper
           severity="error"
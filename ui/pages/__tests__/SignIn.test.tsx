@@ -0,0 +1,19 @@
+import { callbackErrorMessage } from "../SignIn";
+
+describe("callbackErrorMessage", () => {
+  it("returns null without an error", () => {
+    expect(callbackErrorMessage("")).toBeNull();
+  });
+  it("describes a known reason with the correlation ID", () => {
+    expect(
+      callbackErrorMessage("?error=state_mismatch&correlation_id=abc123")
+    ).toEqual(
+      "The login session doesn't match this request, it may have been started in another tab. Correlation ID: abc123"
+    );
+  });
+  it("falls back for an unknown reason", () => {
+    expect(callbackErrorMessage("?error=something_else")).toEqual(
+      "Login with the OIDC provider failed."
+    );
+  });
+});